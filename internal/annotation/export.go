@@ -0,0 +1,92 @@
+package annotation
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-government-consultant/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExportJSON exports annotations with their threads as JSON
+func ExportJSON(annotations []models.Annotation) ([]byte, error) {
+	data, err := json.MarshalIndent(annotations, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal annotations to JSON: %w", err)
+	}
+	return data, nil
+}
+
+// ExportCSV exports annotations as CSV with one row per comment: each annotation, followed by
+// its replies
+func ExportCSV(annotations []models.Annotation) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	header := []string{
+		"Thread ID",
+		"Comment ID",
+		"Document ID",
+		"Version",
+		"Start",
+		"End",
+		"Quote",
+		"Status",
+		"Author ID",
+		"Created At",
+		"Body",
+		"Mentions",
+	}
+	if err := writer.Write(header); err != nil {
+		return nil, fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	for _, annotation := range annotations {
+		thread := []string{
+			annotation.ID.Hex(),
+			annotation.DocumentID.Hex(),
+			strconv.Itoa(annotation.Version),
+			strconv.Itoa(annotation.Anchor.Start),
+			strconv.Itoa(annotation.Anchor.End),
+			annotation.Anchor.Quote,
+			string(annotation.Status),
+		}
+
+		rows := [][]string{commentRow(thread, annotation.ID, annotation.CreatedBy, annotation.CreatedAt, annotation.Body, annotation.Mentions)}
+		for _, reply := range annotation.Replies {
+			rows = append(rows, commentRow(thread, reply.ID, reply.CreatedBy, reply.CreatedAt, reply.Body, reply.Mentions))
+		}
+		if err := writer.WriteAll(rows); err != nil {
+			return nil, fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("failed to flush CSV writer: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// commentRow builds the CSV row of one comment in a thread
+func commentRow(thread []string, id, author primitive.ObjectID, createdAt time.Time, body string, mentions []primitive.ObjectID) []string {
+	mentionIDs := make([]string, len(mentions))
+	for i, mention := range mentions {
+		mentionIDs[i] = mention.Hex()
+	}
+
+	row := []string{thread[0], id.Hex()}
+	row = append(row, thread[1:]...)
+	return append(row,
+		author.Hex(),
+		createdAt.Format(time.RFC3339),
+		body,
+		strings.Join(mentionIDs, ";"),
+	)
+}
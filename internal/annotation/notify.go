@@ -0,0 +1,103 @@
+package annotation
+
+import (
+	"context"
+	"time"
+
+	"ai-government-consultant/internal/models"
+	"ai-government-consultant/internal/websocket"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Websocket message types pushed to collaborators when annotations change
+const (
+	EventCreated   = "annotation_created"
+	EventReplied   = "annotation_replied"
+	EventUpdated   = "annotation_updated"
+	EventResolved  = "annotation_resolved"
+	EventReopened  = "annotation_reopened"
+	EventDeleted   = "annotation_deleted"
+	EventMentioned = "annotation_mention" // sent instead of the above to users newly mentioned by the change
+)
+
+// Publisher delivers messages to the websocket connections of a user; *websocket.Hub is one
+type Publisher interface {
+	BroadcastToUser(userID string, message websocket.Message)
+}
+
+// Event is the data of an annotation message
+type Event struct {
+	DocumentID string             `json:"document_id"`
+	Annotation *models.Annotation `json:"annotation"`
+	ActorID    string             `json:"actor_id"`
+}
+
+// publish pushes a change to an annotation to the document's collaborators in the background:
+// the document's uploader, everyone taking part in an annotation thread on any of its versions,
+// and the users mentioned by the change. Only users who may read the document at the time of the
+// change are sent it, and the user who made the change is not.
+func (s *Service) publish(doc *models.Document, annotation *models.Annotation, event string, actor primitive.ObjectID, mentioned []primitive.ObjectID) {
+	if s.publisher == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		recipients, err := s.collaborators(ctx, doc, annotation, mentioned)
+		if err != nil {
+			s.logger.Error("Failed to find annotation collaborators", err, map[string]interface{}{
+				"annotation_id": annotation.ID.Hex(),
+			})
+			return
+		}
+
+		data := Event{
+			DocumentID: annotation.DocumentID.Hex(),
+			Annotation: annotation,
+			ActorID:    actor.Hex(),
+		}
+		for _, user := range recipients {
+			if user.ID == actor || !user.CanAccessDocument(doc) {
+				continue
+			}
+			messageType := event
+			if containsID(mentioned, user.ID) {
+				messageType = EventMentioned
+			}
+			s.publisher.BroadcastToUser(user.ID.Hex(), websocket.Message{
+				Type:   messageType,
+				Data:   data,
+				ID:     annotation.ID.Hex(),
+				UserID: actor.Hex(),
+			})
+		}
+	}()
+}
+
+// collaborators returns the active users with an interest in a change to annotation
+func (s *Service) collaborators(ctx context.Context, doc *models.Document, annotation *models.Annotation, mentioned []primitive.ObjectID) ([]models.User, error) {
+	ids := append([]primitive.ObjectID{doc.UploadedBy}, mentioned...)
+	ids = append(ids, annotation.Participants()...)
+
+	projection := bson.M{"created_by": 1, "mentions": 1, "replies.created_by": 1, "replies.mentions": 1}
+	cursor, err := s.collection.Find(ctx, bson.M{"series_id": annotation.SeriesID}, options.Find().SetProjection(projection))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var threads []models.Annotation
+	if err := cursor.All(ctx, &threads); err != nil {
+		return nil, err
+	}
+	for i := range threads {
+		ids = append(ids, threads[i].Participants()...)
+	}
+
+	return s.findUsers(ctx, bson.M{"_id": bson.M{"$in": ids}})
+}
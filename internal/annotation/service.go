@@ -0,0 +1,475 @@
+// Package annotation manages comment threads anchored to passages of documents. An annotation
+// belongs to one version of a document and is visible to exactly the users who may read that
+// document, so its visibility follows the document's classification, compartments and sharing.
+// Changes are pushed to the document's collaborators over the websocket hub.
+package annotation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ai-government-consultant/internal/document"
+	"ai-government-consultant/internal/models"
+	"ai-government-consultant/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// maxBodyLength bounds the characters in an annotation or reply
+	maxBodyLength = 10000
+
+	// maxQuoteLength bounds the bytes of the annotated passage kept with an annotation
+	maxQuoteLength = 2000
+
+	// maxMentions bounds the users mentioned in one annotation or reply
+	maxMentions = 50
+)
+
+var (
+	// ErrNotFound is returned when an annotation does not exist
+	ErrNotFound = errors.New("annotation not found")
+
+	// ErrNoText is returned when annotating a document that has no extracted text yet
+	ErrNoText = errors.New("document has no text to annotate")
+
+	// ErrBodyTooLong is returned when an annotation or reply exceeds maxBodyLength characters
+	ErrBodyTooLong = fmt.Errorf("annotation body exceeds %d characters", maxBodyLength)
+)
+
+// mentionPattern matches an @mention of a user by email address, e.g. "@jane.doe@agency.gov"
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@.])@([A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,})`)
+
+// Filter selects annotations to list or search
+type Filter struct {
+	DocumentID    *primitive.ObjectID     // annotations on one document version
+	SeriesID      *primitive.ObjectID     // annotations on every version of a document
+	Status        models.AnnotationStatus // "" for any status
+	Query         string                  // full-text search of annotation and reply bodies
+	MentionedUser *primitive.ObjectID     // threads mentioning this user
+	Access        *document.AccessScope   // nil lists annotations on every document
+}
+
+// Service handles annotations and their threads
+type Service struct {
+	collection *mongo.Collection
+	users      *mongo.Collection
+	publisher  Publisher
+	logger     logger.Logger
+}
+
+// NewService creates a new annotation service. publisher may be nil, in which case changes are
+// not pushed to anyone.
+func NewService(db *mongo.Database, publisher Publisher, logger logger.Logger) *Service {
+	return &Service{
+		collection: db.Collection("annotations"),
+		users:      db.Collection("users"),
+		publisher:  publisher,
+		logger:     logger,
+	}
+}
+
+// Create annotates the characters [start, end) of a document's text
+func (s *Service) Create(ctx context.Context, doc *models.Document, author primitive.ObjectID, start, end int, body string) (*models.Annotation, error) {
+	if doc.Content == "" {
+		return nil, ErrNoText
+	}
+	byteStart, byteEnd, ok := byteRange(doc.Content, start, end)
+	if !ok {
+		return nil, models.ErrAnnotationAnchorInvalid
+	}
+	body, err := checkBody(body)
+	if err != nil {
+		return nil, err
+	}
+	mentions, err := s.resolveMentions(ctx, doc, body)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	annotation := &models.Annotation{
+		ID:         primitive.NewObjectID(),
+		DocumentID: doc.ID,
+		SeriesID:   doc.VersionSeries(),
+		Version:    doc.VersionNumber(),
+		Anchor: models.TextAnchor{
+			Start: start,
+			End:   end,
+			Quote: quote(doc, byteStart, byteEnd),
+		},
+		Body:      body,
+		Mentions:  mentions,
+		Replies:   []models.AnnotationReply{},
+		Status:    models.AnnotationStatusOpen,
+		CreatedBy: author,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := annotation.Validate(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.collection.InsertOne(ctx, annotation); err != nil {
+		return nil, fmt.Errorf("failed to create annotation: %w", err)
+	}
+	s.publish(doc, annotation, EventCreated, author, mentions)
+	return annotation, nil
+}
+
+// Get returns an annotation
+func (s *Service) Get(ctx context.Context, id primitive.ObjectID) (*models.Annotation, error) {
+	var annotation models.Annotation
+	if err := s.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&annotation); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to find annotation: %w", err)
+	}
+	return &annotation, nil
+}
+
+// List returns the annotations matching filter with the total number of matches. Text searches
+// are ordered by relevance, annotations of a document by their position in it, and anything
+// else by most recent first.
+func (s *Service) List(ctx context.Context, filter *Filter, limit, skip int) ([]models.Annotation, int64, error) {
+	match := bson.M{}
+	if filter.Query != "" {
+		match["$text"] = bson.M{"$search": filter.Query}
+	}
+	if filter.DocumentID != nil {
+		match["document_id"] = *filter.DocumentID
+	}
+	if filter.SeriesID != nil {
+		match["series_id"] = *filter.SeriesID
+	}
+	if filter.Status != "" {
+		match["status"] = filter.Status
+	}
+	if filter.MentionedUser != nil {
+		match["$or"] = []bson.M{
+			{"mentions": *filter.MentionedUser},
+			{"replies.mentions": *filter.MentionedUser},
+		}
+	}
+
+	sort := bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}
+	switch {
+	case filter.Query != "":
+		sort = bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "_id", Value: -1}}
+	case filter.DocumentID != nil || filter.SeriesID != nil:
+		sort = bson.D{{Key: "version", Value: 1}, {Key: "anchor.start", Value: 1}, {Key: "created_at", Value: 1}}
+	}
+
+	pipeline := []bson.M{{"$match": match}}
+	if filter.Query != "" {
+		pipeline = append(pipeline, bson.M{"$addFields": bson.M{"score": bson.M{"$meta": "textScore"}}})
+	}
+	if filter.Access != nil {
+		// Only annotations on documents the user may read
+		pipeline = append(pipeline,
+			bson.M{"$lookup": bson.M{
+				"from": "documents",
+				"let":  bson.M{"document_id": "$document_id"},
+				"pipeline": []bson.M{
+					{"$match": bson.M{"$expr": bson.M{"$eq": bson.A{"$_id", "$$document_id"}}}},
+					{"$project": bson.M{"classification": 1, "uploaded_by": 1, "shared_with": 1}},
+				},
+				"as": "document",
+			}},
+			bson.M{"$unwind": "$document"},
+			bson.M{"$match": filter.Access.Match("document.")},
+			bson.M{"$project": bson.M{"document": 0}},
+		)
+	}
+	pipeline = append(pipeline, bson.M{"$facet": bson.M{
+		"items": []bson.M{{"$sort": sort}, {"$skip": skip}, {"$limit": limit}},
+		"total": []bson.M{{"$count": "count"}},
+	}})
+
+	cursor, err := s.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list annotations: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Items []models.Annotation `bson:"items"`
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode annotations: %w", err)
+	}
+
+	annotations := []models.Annotation{}
+	var total int64
+	if len(results) > 0 {
+		if results[0].Items != nil {
+			annotations = results[0].Items
+		}
+		if len(results[0].Total) > 0 {
+			total = results[0].Total[0].Count
+		}
+	}
+	return annotations, total, nil
+}
+
+// Reply adds a reply to an annotation's thread
+func (s *Service) Reply(ctx context.Context, doc *models.Document, id, author primitive.ObjectID, body string) (*models.Annotation, error) {
+	body, err := checkBody(body)
+	if err != nil {
+		return nil, err
+	}
+	mentions, err := s.resolveMentions(ctx, doc, body)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	reply := models.AnnotationReply{
+		ID:        primitive.NewObjectID(),
+		Body:      body,
+		Mentions:  mentions,
+		CreatedBy: author,
+		CreatedAt: now,
+	}
+	annotation, err := s.update(ctx, id, bson.M{
+		"$push": bson.M{"replies": reply},
+		"$set":  bson.M{"updated_at": now},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(doc, annotation, EventReplied, author, mentions)
+	return annotation, nil
+}
+
+// Edit replaces the body of an annotation. Users mentioned for the first time are notified.
+func (s *Service) Edit(ctx context.Context, doc *models.Document, id, editor primitive.ObjectID, body string) (*models.Annotation, error) {
+	body, err := checkBody(body)
+	if err != nil {
+		return nil, err
+	}
+	mentions, err := s.resolveMentions(ctx, doc, body)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	annotation, err := s.update(ctx, id, bson.M{"$set": bson.M{
+		"body":       body,
+		"mentions":   mentions,
+		"updated_at": time.Now(),
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	var added []primitive.ObjectID
+	for _, mention := range mentions {
+		if !containsID(previous.Mentions, mention) {
+			added = append(added, mention)
+		}
+	}
+	s.publish(doc, annotation, EventUpdated, editor, added)
+	return annotation, nil
+}
+
+// Resolve marks an annotation's thread resolved. Resolving a resolved thread changes nothing.
+func (s *Service) Resolve(ctx context.Context, doc *models.Document, id, resolvedBy primitive.ObjectID) (*models.Annotation, error) {
+	now := time.Now()
+	return s.setStatus(ctx, doc, id, resolvedBy, models.AnnotationStatusResolved, bson.M{
+		"$set": bson.M{
+			"status":      models.AnnotationStatusResolved,
+			"resolved_by": resolvedBy,
+			"resolved_at": now,
+			"updated_at":  now,
+		},
+	})
+}
+
+// Reopen reopens a resolved annotation's thread. Reopening an open thread changes nothing.
+func (s *Service) Reopen(ctx context.Context, doc *models.Document, id, reopenedBy primitive.ObjectID) (*models.Annotation, error) {
+	return s.setStatus(ctx, doc, id, reopenedBy, models.AnnotationStatusOpen, bson.M{
+		"$set":   bson.M{"status": models.AnnotationStatusOpen, "updated_at": time.Now()},
+		"$unset": bson.M{"resolved_by": "", "resolved_at": ""},
+	})
+}
+
+// setStatus moves an annotation to status, publishing the change only if the status changed
+func (s *Service) setStatus(ctx context.Context, doc *models.Document, id, actor primitive.ObjectID, status models.AnnotationStatus, update bson.M) (*models.Annotation, error) {
+	after := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var annotation models.Annotation
+	err := s.collection.FindOneAndUpdate(ctx, bson.M{"_id": id, "status": bson.M{"$ne": status}}, update, after).Decode(&annotation)
+	if err == mongo.ErrNoDocuments {
+		// Either it does not exist or it already has the status
+		return s.Get(ctx, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update annotation: %w", err)
+	}
+
+	event := EventResolved
+	if status == models.AnnotationStatusOpen {
+		event = EventReopened
+	}
+	s.publish(doc, &annotation, event, actor, nil)
+	return &annotation, nil
+}
+
+// Delete deletes an annotation with its replies
+func (s *Service) Delete(ctx context.Context, doc *models.Document, id, deletedBy primitive.ObjectID) error {
+	var annotation models.Annotation
+	if err := s.collection.FindOneAndDelete(ctx, bson.M{"_id": id}).Decode(&annotation); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to delete annotation: %w", err)
+	}
+	s.publish(doc, &annotation, EventDeleted, deletedBy, nil)
+	return nil
+}
+
+// update applies update to an annotation and returns the result
+func (s *Service) update(ctx context.Context, id primitive.ObjectID, update bson.M) (*models.Annotation, error) {
+	after := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var annotation models.Annotation
+	if err := s.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, after).Decode(&annotation); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to update annotation: %w", err)
+	}
+	return &annotation, nil
+}
+
+// resolveMentions returns the users @mentioned in body who may read the document. Mentions of
+// unknown or inactive users, and of users without access to the document, are dropped so that
+// nobody is pointed at a document they cannot see.
+func (s *Service) resolveMentions(ctx context.Context, doc *models.Document, body string) ([]primitive.ObjectID, error) {
+	var emails []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		email := strings.TrimRight(match[1], ".")
+		if !containsString(emails, email) && len(emails) < maxMentions {
+			emails = append(emails, email)
+		}
+	}
+	mentions := []primitive.ObjectID{}
+	if len(emails) == 0 {
+		return mentions, nil
+	}
+
+	users, err := s.findUsers(ctx, bson.M{"email": bson.M{"$in": emails}})
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		if user.CanAccessDocument(doc) {
+			mentions = append(mentions, user.ID)
+		}
+	}
+	return mentions, nil
+}
+
+// findUsers returns the active users matching filter
+func (s *Service) findUsers(ctx context.Context, filter bson.M) ([]models.User, error) {
+	filter["is_active"] = true
+	projection := bson.M{"password_hash": 0, "mfa_secret": 0}
+	cursor, err := s.users.Find(ctx, filter, options.Find().SetProjection(projection))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find users: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, fmt.Errorf("failed to decode users: %w", err)
+	}
+	return users, nil
+}
+
+// checkBody trims an annotation or reply body and checks its length
+func checkBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", models.ErrAnnotationBodyRequired
+	}
+	if utf8.RuneCountInString(body) > maxBodyLength {
+		return "", ErrBodyTooLong
+	}
+	return body, nil
+}
+
+// byteRange converts the character range [start, end) of text to a byte range
+func byteRange(text string, start, end int) (int, int, bool) {
+	if start < 0 || end <= start {
+		return 0, 0, false
+	}
+	byteStart, byteEnd := -1, -1
+	offset := 0
+	for i := range text {
+		if offset == start {
+			byteStart = i
+		}
+		if offset == end {
+			byteEnd = i
+			break
+		}
+		offset++
+	}
+	if byteEnd < 0 && offset == end {
+		byteEnd = len(text)
+	}
+	return byteStart, byteEnd, byteStart >= 0 && byteEnd >= 0
+}
+
+// quote returns the annotated bytes of a document's text. It reads the redacted text, which has
+// the same byte offsets, so that the quote never holds the personal data the document does.
+func quote(doc *models.Document, start, end int) string {
+	text := doc.Content
+	if doc.RedactedContent != "" {
+		if len(doc.RedactedContent) != len(doc.Content) {
+			return ""
+		}
+		text = doc.RedactedContent
+	}
+	if end-start > maxQuoteLength {
+		end = start + maxQuoteLength
+		for end > start && !utf8.RuneStart(text[end]) {
+			end--
+		}
+	}
+	return text[start:end]
+}
+
+func containsID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
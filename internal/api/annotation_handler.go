@@ -0,0 +1,487 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ai-government-consultant/internal/annotation"
+	"ai-government-consultant/internal/document"
+	"ai-government-consultant/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxAnnotationExport bounds the annotations in one export
+const maxAnnotationExport = 10000
+
+// AnnotationHandler handles annotations on documents and their comment threads
+type AnnotationHandler struct {
+	annotationService *annotation.Service
+	documentService   *document.Service
+}
+
+// NewAnnotationHandler creates a new annotation handler
+func NewAnnotationHandler(annotationService *annotation.Service, documentService *document.Service) *AnnotationHandler {
+	return &AnnotationHandler{
+		annotationService: annotationService,
+		documentService:   documentService,
+	}
+}
+
+// CreateAnnotationRequest anchors a comment to the characters [start, end) of a document's text
+type CreateAnnotationRequest struct {
+	Start *int   `json:"start" binding:"required"`
+	End   *int   `json:"end" binding:"required"`
+	Body  string `json:"body" binding:"required"` // may @mention users by email, e.g. "@jane.doe@agency.gov"
+}
+
+// AnnotationCommentRequest is the body of a reply, or the new body of an annotation
+type AnnotationCommentRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+// CreateAnnotation annotates a passage of a document
+func (h *AnnotationHandler) CreateAnnotation(c *gin.Context) {
+	user, doc, ok := authorizeDocumentID(c, h.documentService, c.Param("id"), "read")
+	if !ok {
+		return
+	}
+
+	var req CreateAnnotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	created, err := h.annotationService.Create(ctx, doc, user.ID, *req.Start, *req.End, req.Body)
+	if err != nil {
+		respondAnnotationError(c, "Failed to create annotation", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponse{
+		Message: "Annotation created successfully",
+		Data:    created,
+	})
+}
+
+// ListDocumentAnnotations lists the annotations on a document version in reading order, or on
+// every version the user may read with all_versions=true. status filters by "open" or "resolved".
+func (h *AnnotationHandler) ListDocumentAnnotations(c *gin.Context) {
+	user, doc, ok := authorizeDocumentID(c, h.documentService, c.Param("id"), "read")
+	if !ok {
+		return
+	}
+
+	filter, ok := documentAnnotationFilter(c, user, doc)
+	if !ok {
+		return
+	}
+	limit, skip := annotationPagination(c)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	annotations, total, err := h.annotationService.List(ctx, filter, limit, skip)
+	if err != nil {
+		respondAnnotationError(c, "Failed to fetch annotations", err)
+		return
+	}
+
+	respondAnnotationPage(c, annotations, total, limit, skip)
+}
+
+// ExportDocumentAnnotations exports the annotation threads of a document as JSON, or as CSV with
+// format=csv. It takes the same filters as ListDocumentAnnotations.
+func (h *AnnotationHandler) ExportDocumentAnnotations(c *gin.Context) {
+	user, doc, ok := authorizeDocumentID(c, h.documentService, c.Param("id"), "read")
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid export format",
+			Message: "Supported formats: json, csv",
+			Code:    "INVALID_FORMAT",
+		})
+		return
+	}
+
+	filter, ok := documentAnnotationFilter(c, user, doc)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	annotations, _, err := h.annotationService.List(ctx, filter, maxAnnotationExport, 0)
+	if err != nil {
+		respondAnnotationError(c, "Failed to export annotations", err)
+		return
+	}
+
+	var data []byte
+	contentType := "application/json"
+	if format == "csv" {
+		data, err = annotation.ExportCSV(annotations)
+		contentType = "text/csv"
+	} else {
+		data, err = annotation.ExportJSON(annotations)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to export annotations",
+			Message: err.Error(),
+			Code:    "EXPORT_FAILED",
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=annotations_"+doc.ID.Hex()+"."+format)
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// SearchAnnotations searches annotations across the documents the user may read. q searches
+// annotation and reply text, mentions=me keeps threads mentioning the user, and document_id and
+// status narrow the results further.
+func (h *AnnotationHandler) SearchAnnotations(c *gin.Context) {
+	user, ok := annotationUser(c)
+	if !ok {
+		return
+	}
+
+	filter := &annotation.Filter{
+		Query:  c.Query("q"),
+		Access: document.NewAccessScope(user),
+	}
+	if !parseAnnotationStatus(c, filter) {
+		return
+	}
+	if documentID := c.Query("document_id"); documentID != "" {
+		id, err := primitive.ObjectIDFromHex(documentID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Invalid document ID",
+				Code:  "INVALID_DOCUMENT_ID",
+			})
+			return
+		}
+		filter.DocumentID = &id
+	}
+	if c.Query("mentions") == "me" {
+		filter.MentionedUser = &user.ID
+	}
+	limit, skip := annotationPagination(c)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	annotations, total, err := h.annotationService.List(ctx, filter, limit, skip)
+	if err != nil {
+		respondAnnotationError(c, "Failed to search annotations", err)
+		return
+	}
+
+	respondAnnotationPage(c, annotations, total, limit, skip)
+}
+
+// GetAnnotation returns an annotation with its thread
+func (h *AnnotationHandler) GetAnnotation(c *gin.Context) {
+	_, found, _, ok := h.authorizeAnnotation(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Annotation retrieved successfully",
+		Data:    found,
+	})
+}
+
+// UpdateAnnotation replaces the body of an annotation. Only its author, or a user with the
+// documents admin permission, may change it.
+func (h *AnnotationHandler) UpdateAnnotation(c *gin.Context) {
+	user, found, doc, ok := h.authorizeAnnotation(c)
+	if !ok || !canModerateAnnotation(c, user, found) {
+		return
+	}
+
+	var req AnnotationCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	updated, err := h.annotationService.Edit(ctx, doc, found.ID, user.ID, req.Body)
+	if err != nil {
+		respondAnnotationError(c, "Failed to update annotation", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Annotation updated successfully",
+		Data:    updated,
+	})
+}
+
+// DeleteAnnotation deletes an annotation with its thread. Only its author, or a user with the
+// documents admin permission, may delete it.
+func (h *AnnotationHandler) DeleteAnnotation(c *gin.Context) {
+	user, found, doc, ok := h.authorizeAnnotation(c)
+	if !ok || !canModerateAnnotation(c, user, found) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.annotationService.Delete(ctx, doc, found.ID, user.ID); err != nil {
+		respondAnnotationError(c, "Failed to delete annotation", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Annotation deleted successfully",
+	})
+}
+
+// ReplyToAnnotation adds a reply to an annotation's thread
+func (h *AnnotationHandler) ReplyToAnnotation(c *gin.Context) {
+	user, found, doc, ok := h.authorizeAnnotation(c)
+	if !ok {
+		return
+	}
+
+	var req AnnotationCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	updated, err := h.annotationService.Reply(ctx, doc, found.ID, user.ID, req.Body)
+	if err != nil {
+		respondAnnotationError(c, "Failed to add reply", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponse{
+		Message: "Reply added successfully",
+		Data:    updated,
+	})
+}
+
+// ResolveAnnotation marks an annotation's thread resolved
+func (h *AnnotationHandler) ResolveAnnotation(c *gin.Context) {
+	h.setAnnotationStatus(c, models.AnnotationStatusResolved)
+}
+
+// ReopenAnnotation reopens a resolved annotation's thread
+func (h *AnnotationHandler) ReopenAnnotation(c *gin.Context) {
+	h.setAnnotationStatus(c, models.AnnotationStatusOpen)
+}
+
+func (h *AnnotationHandler) setAnnotationStatus(c *gin.Context, status models.AnnotationStatus) {
+	user, found, doc, ok := h.authorizeAnnotation(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var updated *models.Annotation
+	var err error
+	if status == models.AnnotationStatusResolved {
+		updated, err = h.annotationService.Resolve(ctx, doc, found.ID, user.ID)
+	} else {
+		updated, err = h.annotationService.Reopen(ctx, doc, found.ID, user.ID)
+	}
+	if err != nil {
+		respondAnnotationError(c, "Failed to update annotation status", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Annotation " + string(status),
+		Data:    updated,
+	})
+}
+
+// authorizeAnnotation loads the annotation named by the :id parameter and checks that the user
+// may read the document it is on. On failure the error response has been written and ok is false.
+func (h *AnnotationHandler) authorizeAnnotation(c *gin.Context) (user *models.User, found *models.Annotation, doc *models.Document, ok bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid annotation ID",
+			Code:  "INVALID_ANNOTATION_ID",
+		})
+		return nil, nil, nil, false
+	}
+
+	if _, ok := annotationUser(c); !ok {
+		return nil, nil, nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	found, err = h.annotationService.Get(ctx, id)
+	if err != nil {
+		respondAnnotationError(c, "Failed to retrieve annotation", err)
+		return nil, nil, nil, false
+	}
+
+	user, doc, ok = authorizeDocumentID(c, h.documentService, found.DocumentID.Hex(), "read")
+	if !ok {
+		return nil, nil, nil, false
+	}
+	return user, found, doc, true
+}
+
+// canModerateAnnotation checks that the user wrote the annotation or may administer documents,
+// writing a 403 response if not
+func canModerateAnnotation(c *gin.Context, user *models.User, found *models.Annotation) bool {
+	if found.CreatedBy == user.ID || user.HasPermission("documents", "admin") {
+		return true
+	}
+	c.JSON(http.StatusForbidden, ErrorResponse{
+		Error: "Only the author of an annotation may change it",
+		Code:  "INSUFFICIENT_PERMISSIONS",
+	})
+	return false
+}
+
+// documentAnnotationFilter builds the filter for the annotations on a document from the
+// all_versions and status query parameters, writing a 400 response if they are invalid
+func documentAnnotationFilter(c *gin.Context, user *models.User, doc *models.Document) (*annotation.Filter, bool) {
+	filter := &annotation.Filter{DocumentID: &doc.ID}
+	if c.Query("all_versions") == "true" {
+		// Other versions may be classified differently, so each is checked against the user's access
+		series := doc.VersionSeries()
+		filter = &annotation.Filter{
+			SeriesID: &series,
+			Access:   document.NewAccessScope(user),
+		}
+	}
+	if !parseAnnotationStatus(c, filter) {
+		return nil, false
+	}
+	return filter, true
+}
+
+// parseAnnotationStatus sets the filter's status from the status query parameter, writing a 400
+// response if it is unknown
+func parseAnnotationStatus(c *gin.Context, filter *annotation.Filter) bool {
+	status := models.AnnotationStatus(c.Query("status"))
+	if status != "" && !status.IsValid() {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid annotation status",
+			Message: "Supported statuses: open, resolved",
+			Code:    "INVALID_STATUS",
+		})
+		return false
+	}
+	filter.Status = status
+	return true
+}
+
+// annotationUser returns the authenticated user if they may read documents, writing an error
+// response if not
+func annotationUser(c *gin.Context) (*models.User, bool) {
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "User not authenticated",
+			Code:  "NOT_AUTHENTICATED",
+		})
+		return nil, false
+	}
+
+	user := userInterface.(*models.User)
+	if !user.HasPermission("documents", "read") {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: "Insufficient permissions to read annotations",
+			Code:  "INSUFFICIENT_PERMISSIONS",
+		})
+		return nil, false
+	}
+	return user, true
+}
+
+// annotationPagination parses the limit and skip query parameters
+func annotationPagination(c *gin.Context) (limit, skip int) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	skip, err = strconv.Atoi(c.DefaultQuery("skip", "0"))
+	if err != nil || skip < 0 {
+		skip = 0
+	}
+	return limit, skip
+}
+
+func respondAnnotationPage(c *gin.Context, annotations []models.Annotation, total int64, limit, skip int) {
+	c.JSON(http.StatusOK, gin.H{
+		"data": annotations,
+		"pagination": gin.H{
+			"page":       (skip / limit) + 1,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": int((total + int64(limit) - 1) / int64(limit)),
+		},
+	})
+}
+
+// respondAnnotationError writes the response for an error from the annotation service
+func respondAnnotationError(c *gin.Context, message string, err error) {
+	status, code := http.StatusInternalServerError, "ANNOTATION_ERROR"
+	switch {
+	case errors.Is(err, annotation.ErrNotFound):
+		status, code = http.StatusNotFound, "ANNOTATION_NOT_FOUND"
+	case errors.Is(err, annotation.ErrNoText):
+		status, code = http.StatusConflict, "DOCUMENT_NOT_PROCESSED"
+	case errors.Is(err, annotation.ErrBodyTooLong),
+		errors.Is(err, models.ErrAnnotationBodyRequired),
+		errors.Is(err, models.ErrAnnotationAnchorInvalid):
+		status, code = http.StatusBadRequest, "INVALID_REQUEST"
+	}
+	c.JSON(status, ErrorResponse{
+		Error:   message,
+		Message: err.Error(),
+		Code:    code,
+	})
+}
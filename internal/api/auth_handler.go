@@ -34,6 +34,11 @@ type RegisterRequest struct {
 	Permissions       []models.Permission       `json:"permissions,omitempty"`
 }
 
+// SetCompartmentsRequest replaces the compartments a user is read into
+type SetCompartmentsRequest struct {
+	Compartments []string `json:"compartments"`
+}
+
 // LoginRequest represents a login request
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
@@ -620,4 +625,53 @@ func (h *AuthHandler) GetUser(c *gin.Context) {
 			"name":  "Placeholder User",
 		},
 	})
-}
\ No newline at end of file
+}
+
+// SetUserCompartments replaces the compartments a user is read into (admin only)
+func (h *AuthHandler) SetUserCompartments(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid user ID format",
+			Message: err.Error(),
+			Code:    "INVALID_USER_ID",
+		})
+		return
+	}
+
+	var req SetCompartmentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+
+	user, err := h.authService.SetUserCompartments(c.Request.Context(), objID, req.Compartments)
+	if err != nil {
+		if err == models.ErrUserNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "User not found",
+				Code:  "USER_NOT_FOUND",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to update compartments",
+			Message: err.Error(),
+			Code:    "UPDATE_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "User compartments updated",
+		Data: gin.H{
+			"id":           user.ID.Hex(),
+			"compartments": user.Compartments,
+		},
+	})
+}
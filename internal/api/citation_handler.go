@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ai-government-consultant/internal/citation"
+	"ai-government-consultant/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CitationHandler handles lookups in the citation index
+type CitationHandler struct {
+	citationIndex *citation.Index
+}
+
+// NewCitationHandler creates a new citation handler
+func NewCitationHandler(citationIndex *citation.Index) *CitationHandler {
+	return &CitationHandler{
+		citationIndex: citationIndex,
+	}
+}
+
+// FindCiting lists the documents and knowledge items citing a law or regulation, given in any
+// common form ("2 CFR 200", "5 U.S.C. § 552", "OMB M-21-31", "Executive Order 14028")
+func (h *CitationHandler) FindCiting(c *gin.Context) {
+	// Get user from context
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "User not authenticated",
+			Code:  "NOT_AUTHENTICATED",
+		})
+		return
+	}
+
+	user := userInterface.(*models.User)
+
+	// Check permissions
+	readDocuments := user.HasPermission("documents", "read")
+	readKnowledge := user.HasPermission("knowledge", "read")
+	if !readDocuments && !readKnowledge {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: "Insufficient permissions to read documents or knowledge",
+			Code:  "INSUFFICIENT_PERMISSIONS",
+		})
+		return
+	}
+
+	ref, ok := citation.Normalize(c.Query("ref"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid citation",
+			Message: "ref must be a single U.S. Code, CFR, OMB or executive order citation",
+			Code:    "INVALID_CITATION",
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	result, err := h.citationIndex.Find(ctx, ref, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to look up citation",
+			Message: err.Error(),
+			Code:    "LOOKUP_FAILED",
+		})
+		return
+	}
+
+	// Filter documents based on user's security clearance
+	documents := make([]citation.CitingDocument, 0)
+	if readDocuments {
+		for _, doc := range result.Documents {
+			if user.CanAccessClassification(doc.Classification.Level) {
+				documents = append(documents, doc)
+			}
+		}
+	}
+	result.Documents = documents
+
+	if !readKnowledge {
+		result.KnowledgeItems = []citation.CitingKnowledgeItem{}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
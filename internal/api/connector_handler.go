@@ -0,0 +1,420 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ai-government-consultant/internal/connector"
+	"ai-government-consultant/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ConnectorHandler handles the ingestion connectors that sync documents from shared storage
+type ConnectorHandler struct {
+	connectorService *connector.Service
+}
+
+// NewConnectorHandler creates a new connector handler
+func NewConnectorHandler(connectorService *connector.Service) *ConnectorHandler {
+	return &ConnectorHandler{
+		connectorService: connectorService,
+	}
+}
+
+// ConnectorRequest creates or replaces a connector. Only the settings of the connector's type
+// apply; a password or secret key left empty on update keeps the stored one.
+type ConnectorRequest struct {
+	Name            string                         `json:"name" binding:"required"`
+	Type            models.ConnectorType           `json:"type" binding:"required"` // "directory", "webdav" or "s3"
+	Path            string                         `json:"path,omitempty"`
+	URL             string                         `json:"url,omitempty"`
+	Username        string                         `json:"username,omitempty"`
+	Password        string                         `json:"password,omitempty"`
+	Endpoint        string                         `json:"endpoint,omitempty"`
+	Bucket          string                         `json:"bucket,omitempty"`
+	Prefix          string                         `json:"prefix,omitempty"`
+	Region          string                         `json:"region,omitempty"`
+	AccessKey       string                         `json:"access_key,omitempty"`
+	SecretKey       string                         `json:"secret_key,omitempty"`
+	UseSSL          bool                           `json:"use_ssl,omitempty"`
+	IntervalMinutes int                            `json:"interval_minutes" binding:"required"`
+	Enabled         *bool                          `json:"enabled,omitempty"` // defaults to true
+	DeletePolicy    models.ConnectorDeletePolicy   `json:"delete_policy,omitempty"`
+	Category        models.DocumentCategory        `json:"category,omitempty"`
+	Tags            []string                       `json:"tags,omitempty"`
+	Classification  *models.SecurityClassification `json:"classification,omitempty"`
+	DuplicatePolicy models.DuplicatePolicy         `json:"duplicate_policy,omitempty"`
+}
+
+// ListConnectors lists every connector
+func (h *ConnectorHandler) ListConnectors(c *gin.Context) {
+	if _, ok := connectorAdmin(c); !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	connectors, err := h.connectorService.List(ctx)
+	if err != nil {
+		respondConnectorError(c, "Failed to fetch connectors", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": connectors,
+	})
+}
+
+// CreateConnector creates a connector; an enabled connector syncs straight away
+func (h *ConnectorHandler) CreateConnector(c *gin.Context) {
+	user, ok := connectorAdmin(c)
+	if !ok {
+		return
+	}
+
+	conn, ok := bindConnector(c)
+	if !ok {
+		return
+	}
+	conn.CreatedBy = user.ID
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.connectorService.Create(ctx, conn); err != nil {
+		respondConnectorError(c, "Failed to create connector", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponse{
+		Message: "Connector created successfully",
+		Data:    conn,
+	})
+}
+
+// GetConnector returns a connector with the state of its last sync
+func (h *ConnectorHandler) GetConnector(c *gin.Context) {
+	if _, ok := connectorAdmin(c); !ok {
+		return
+	}
+	id, ok := connectorID(c, "id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	conn, err := h.connectorService.Get(ctx, id)
+	if err != nil {
+		respondConnectorError(c, "Failed to fetch connector", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Connector retrieved successfully",
+		Data:    conn,
+	})
+}
+
+// UpdateConnector replaces the settings of a connector
+func (h *ConnectorHandler) UpdateConnector(c *gin.Context) {
+	if _, ok := connectorAdmin(c); !ok {
+		return
+	}
+	id, ok := connectorID(c, "id")
+	if !ok {
+		return
+	}
+
+	changes, ok := bindConnector(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	conn, err := h.connectorService.Update(ctx, id, changes)
+	if err != nil {
+		respondConnectorError(c, "Failed to update connector", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Connector updated successfully",
+		Data:    conn,
+	})
+}
+
+// DeleteConnector deletes a connector with its sync state; documents it synced are kept
+func (h *ConnectorHandler) DeleteConnector(c *gin.Context) {
+	if _, ok := connectorAdmin(c); !ok {
+		return
+	}
+	id, ok := connectorID(c, "id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.connectorService.Delete(ctx, id); err != nil {
+		respondConnectorError(c, "Failed to delete connector", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Connector deleted successfully",
+	})
+}
+
+// SyncConnector queues a sync of a connector now
+func (h *ConnectorHandler) SyncConnector(c *gin.Context) {
+	if _, ok := connectorAdmin(c); !ok {
+		return
+	}
+	id, ok := connectorID(c, "id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.connectorService.Sync(ctx, id); err != nil {
+		respondConnectorError(c, "Failed to queue connector sync", err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, SuccessResponse{
+		Message: "Connector sync queued",
+	})
+}
+
+// ListConnectorFiles lists the sync state of a connector's files, optionally by status
+// ("active" or "deleted") or only those whose last sync failed
+func (h *ConnectorHandler) ListConnectorFiles(c *gin.Context) {
+	if _, ok := connectorAdmin(c); !ok {
+		return
+	}
+	id, ok := connectorID(c, "id")
+	if !ok {
+		return
+	}
+
+	status := c.Query("status")
+	if status != "" && status != models.ConnectorFileActive && status != models.ConnectorFileDeleted {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid status",
+			Code:  "INVALID_REQUEST",
+		})
+		return
+	}
+	failed := c.Query("failed") == "true"
+	limit, skip := connectorPagination(c)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if _, err := h.connectorService.Get(ctx, id); err != nil {
+		respondConnectorError(c, "Failed to fetch connector", err)
+		return
+	}
+	files, total, err := h.connectorService.ListFiles(ctx, id, status, failed, limit, skip)
+	if err != nil {
+		respondConnectorError(c, "Failed to fetch connector files", err)
+		return
+	}
+	respondConnectorPage(c, files, total, limit, skip)
+}
+
+// ListConnectorRuns lists a connector's sync reports, newest first
+func (h *ConnectorHandler) ListConnectorRuns(c *gin.Context) {
+	if _, ok := connectorAdmin(c); !ok {
+		return
+	}
+	id, ok := connectorID(c, "id")
+	if !ok {
+		return
+	}
+	limit, skip := connectorPagination(c)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if _, err := h.connectorService.Get(ctx, id); err != nil {
+		respondConnectorError(c, "Failed to fetch connector", err)
+		return
+	}
+	runs, total, err := h.connectorService.ListRuns(ctx, id, limit, skip)
+	if err != nil {
+		respondConnectorError(c, "Failed to fetch connector runs", err)
+		return
+	}
+	respondConnectorPage(c, runs, total, limit, skip)
+}
+
+// GetConnectorRun returns one sync report with its file errors
+func (h *ConnectorHandler) GetConnectorRun(c *gin.Context) {
+	if _, ok := connectorAdmin(c); !ok {
+		return
+	}
+	id, ok := connectorID(c, "id")
+	if !ok {
+		return
+	}
+	runID, ok := connectorID(c, "run_id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	run, err := h.connectorService.GetRun(ctx, id, runID)
+	if err != nil {
+		respondConnectorError(c, "Failed to fetch connector run", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Connector run retrieved successfully",
+		Data:    run,
+	})
+}
+
+// bindConnector reads a connector from the request body
+func bindConnector(c *gin.Context) (*models.Connector, bool) {
+	var req ConnectorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return nil, false
+	}
+
+	conn := &models.Connector{
+		Name: req.Name,
+		Type: req.Type,
+		Settings: models.ConnectorSettings{
+			Path:      req.Path,
+			URL:       req.URL,
+			Username:  req.Username,
+			Password:  req.Password,
+			Endpoint:  req.Endpoint,
+			Bucket:    req.Bucket,
+			Prefix:    req.Prefix,
+			Region:    req.Region,
+			AccessKey: req.AccessKey,
+			SecretKey: req.SecretKey,
+			UseSSL:    req.UseSSL,
+		},
+		IntervalMinutes: req.IntervalMinutes,
+		Enabled:         req.Enabled == nil || *req.Enabled,
+		DeletePolicy:    req.DeletePolicy,
+		Category:        req.Category,
+		Tags:            req.Tags,
+		Classification:  req.Classification,
+		DuplicatePolicy: req.DuplicatePolicy,
+	}
+	return conn, true
+}
+
+// connectorAdmin returns the authenticated user if they may manage connectors, which takes
+// documents:admin
+func connectorAdmin(c *gin.Context) (*models.User, bool) {
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "User not authenticated",
+			Code:  "NOT_AUTHENTICATED",
+		})
+		return nil, false
+	}
+
+	user := userInterface.(*models.User)
+	if !user.HasPermission("documents", "admin") {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: "Insufficient permissions to manage connectors",
+			Code:  "INSUFFICIENT_PERMISSIONS",
+		})
+		return nil, false
+	}
+	return user, true
+}
+
+func connectorID(c *gin.Context, param string) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param(param))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: err.Error(),
+			Code:    "INVALID_ID",
+		})
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+// connectorPagination parses the limit and skip query parameters
+func connectorPagination(c *gin.Context) (limit, skip int) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	skip, err = strconv.Atoi(c.DefaultQuery("skip", "0"))
+	if err != nil || skip < 0 {
+		skip = 0
+	}
+	return limit, skip
+}
+
+func respondConnectorPage(c *gin.Context, data interface{}, total int64, limit, skip int) {
+	c.JSON(http.StatusOK, gin.H{
+		"data": data,
+		"pagination": gin.H{
+			"page":       (skip / limit) + 1,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": int((total + int64(limit) - 1) / int64(limit)),
+		},
+	})
+}
+
+// respondConnectorError writes the response for an error from the connector service
+func respondConnectorError(c *gin.Context, message string, err error) {
+	status, code := http.StatusInternalServerError, "CONNECTOR_ERROR"
+	switch {
+	case errors.Is(err, connector.ErrNotFound):
+		status, code = http.StatusNotFound, "NOT_FOUND"
+	case errors.Is(err, connector.ErrSyncInProgress):
+		status, code = http.StatusConflict, "SYNC_IN_PROGRESS"
+	case errors.Is(err, connector.ErrDirectoryNotAllowed),
+		errors.Is(err, models.ErrConnectorNameRequired),
+		errors.Is(err, models.ErrConnectorTypeInvalid),
+		errors.Is(err, models.ErrConnectorIntervalInvalid),
+		errors.Is(err, models.ErrConnectorDeletePolicyInvalid),
+		errors.Is(err, models.ErrConnectorDuplicateInvalid),
+		errors.Is(err, models.ErrConnectorSourceInvalid):
+		status, code = http.StatusBadRequest, "INVALID_REQUEST"
+	}
+	c.JSON(status, ErrorResponse{
+		Error:   message,
+		Message: err.Error(),
+		Code:    code,
+	})
+}
@@ -1,12 +1,14 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"ai-government-consultant/internal/consultation"
 	"ai-government-consultant/internal/models"
+	"ai-government-consultant/internal/workspace"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -15,12 +17,16 @@ import (
 // ConsultationHandler handles consultation-related API endpoints
 type ConsultationHandler struct {
 	consultationService *consultation.Service
+	sessionManager      *consultation.SessionManager
+	workspaceService    *workspace.Service
 }
 
 // NewConsultationHandler creates a new consultation handler
-func NewConsultationHandler(consultationService *consultation.Service) *ConsultationHandler {
+func NewConsultationHandler(consultationService *consultation.Service, sessionManager *consultation.SessionManager, workspaceService *workspace.Service) *ConsultationHandler {
 	return &ConsultationHandler{
 		consultationService: consultationService,
+		sessionManager:      sessionManager,
+		workspaceService:    workspaceService,
 	}
 }
 
@@ -33,6 +39,7 @@ type CreateConsultationRequest struct {
 	ConfidenceThreshold float64                       `json:"confidence_threshold,omitempty"`
 	Tags                []string                      `json:"tags,omitempty"`
 	IsMultiTurn         bool                          `json:"is_multi_turn,omitempty"`
+	WorkspaceIDs        []string                      `json:"workspace_ids,omitempty"` // draw context only from these workspaces
 }
 
 // ContinueConsultationRequest represents a request to continue a multi-turn consultation
@@ -90,6 +97,11 @@ func (h *ConsultationHandler) CreateConsultation(c *gin.Context) {
 		return
 	}
 
+	workspaceIDs, ok := workspaceScope(c, h.workspaceService, user, req.WorkspaceIDs)
+	if !ok {
+		return
+	}
+
 	// Create consultation request
 	consultationReq := &consultation.ConsultationRequest{
 		Query:               req.Query,
@@ -98,6 +110,9 @@ func (h *ConsultationHandler) CreateConsultation(c *gin.Context) {
 		Context:             req.Context,
 		MaxSources:          req.MaxSources,
 		ConfidenceThreshold: req.ConfidenceThreshold,
+		AllowUnredacted:     user.HasPermission("documents", models.DocumentActionReadPII),
+		WorkspaceIDs:        workspaceIDs,
+		User:                user,
 	}
 
 	// Set defaults
@@ -159,6 +174,7 @@ func (h *ConsultationHandler) CreateConsultation(c *gin.Context) {
 		Status:    models.SessionStatusCompleted,
 		Tags:      req.Tags,
 		IsMultiTurn: req.IsMultiTurn,
+		WorkspaceIDs: workspaceIDs,
 	}
 
 	c.JSON(http.StatusCreated, SuccessResponse{
@@ -502,13 +518,31 @@ func (h *ConsultationHandler) DeleteConsultation(c *gin.Context) {
 		return
 	}
 
-	// Note: In a real implementation, you would:
-	// 1. Fetch the consultation session from the database
-	// 2. Verify it belongs to the user or user has admin permissions
-	// 3. Delete the session from the database
-	// For now, we'll return a success response
-
-	_ = objID
+	// Users can only delete their own sessions, and none under legal hold
+	if err := h.sessionManager.DeleteSession(c.Request.Context(), objID, user.ID); err != nil {
+		if errors.Is(err, models.ErrRecordOnLegalHold) {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "Consultation is under legal hold",
+				Message: err.Error(),
+				Code:    "LEGAL_HOLD",
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "Consultation not found",
+				Message: err.Error(),
+				Code:    "SESSION_NOT_FOUND",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to delete consultation",
+			Message: err.Error(),
+			Code:    "DELETE_FAILED",
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, SuccessResponse{
 		Message: "Consultation deleted successfully",
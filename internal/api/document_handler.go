@@ -1,12 +1,22 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"ai-government-consultant/internal/document"
+	"ai-government-consultant/internal/metadata"
 	"ai-government-consultant/internal/models"
+	"ai-government-consultant/internal/workspace"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -14,24 +24,31 @@ import (
 
 // DocumentHandler handles document-related API endpoints
 type DocumentHandler struct {
-	documentService *document.Service
+	documentService  *document.Service
+	workspaceService *workspace.Service
 }
 
 // NewDocumentHandler creates a new document handler
-func NewDocumentHandler(documentService *document.Service) *DocumentHandler {
+func NewDocumentHandler(documentService *document.Service, workspaceService *workspace.Service) *DocumentHandler {
 	return &DocumentHandler{
-		documentService: documentService,
+		documentService:  documentService,
+		workspaceService: workspaceService,
 	}
 }
 
 // UploadDocumentRequest represents metadata for document upload
 type UploadDocumentRequest struct {
-	Title      string                  `form:"title"`
-	Author     string                  `form:"author"`
-	Department string                  `form:"department"`
-	Category   models.DocumentCategory `form:"category" binding:"required"`
-	Tags       string                  `form:"tags"` // Comma-separated
-	Language   string                  `form:"language"`
+	Title           string                  `form:"title"`
+	Author          string                  `form:"author"`
+	Department      string                  `form:"department"`
+	Category        models.DocumentCategory `form:"category" binding:"required"`
+	Tags            string                  `form:"tags"` // Comma-separated
+	Language        string                  `form:"language"`
+	DuplicatePolicy models.DuplicatePolicy  `form:"duplicate_policy"` // "link" (default), "merge", "reject" or "allow"
+	Classification  string                  `form:"classification"`   // declared level, e.g. "CONFIDENTIAL"; detected from markings when empty
+	Compartments    []string                `form:"compartments"`
+	Handling        []string                `form:"handling"`
+	CustomFields    string                  `form:"custom_fields"` // JSON object, checked against the metadata schemas
 }
 
 // DocumentSearchRequest represents a document search request
@@ -45,6 +62,20 @@ type DocumentSearchRequest struct {
 	Skip       int                     `form:"skip"`
 	SortBy     string                  `form:"sort_by"`
 	SortOrder  string                  `form:"sort_order"`
+	Workspaces []string                `form:"workspace_id"` // limit results to these workspaces; repeated or comma-separated
+	// Indexed custom fields are filtered with custom_fields[<name>]=<value>, read by QueryMap
+}
+
+// UpdateDocumentRequest changes a document's metadata. Omitted fields are left as they are; a
+// custom field set to null is removed.
+type UpdateDocumentRequest struct {
+	Title        *string                  `json:"title,omitempty"`
+	Author       *string                  `json:"author,omitempty"`
+	Department   *string                  `json:"department,omitempty"`
+	Category     *models.DocumentCategory `json:"category,omitempty"`
+	Tags         []string                 `json:"tags,omitempty"`
+	Language     *string                  `json:"language,omitempty"`
+	CustomFields map[string]interface{}   `json:"custom_fields,omitempty"`
 }
 
 // UploadDocument handles document upload
@@ -81,6 +112,43 @@ func (h *DocumentHandler) UploadDocument(c *gin.Context) {
 		return
 	}
 
+	if !req.DuplicatePolicy.IsValid() {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid duplicate policy",
+			Code:  "INVALID_DUPLICATE_POLICY",
+		})
+		return
+	}
+
+	var declared *models.SecurityClassification
+	if req.Classification != "" {
+		declared = &models.SecurityClassification{
+			Level:        normalizeClassificationLevel(req.Classification),
+			Compartments: req.Compartments,
+			Handling:     req.Handling,
+		}
+		if models.ClassificationRank(declared.Level) < 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Invalid classification level",
+				Code:  "INVALID_CLASSIFICATION",
+			})
+			return
+		}
+		if declared.Compartments == nil {
+			declared.Compartments = []string{}
+		}
+		if declared.Handling == nil {
+			declared.Handling = []string{}
+		}
+		if !user.CanAccessClassification(declared.Level) {
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Error: "Insufficient security clearance",
+				Code:  "INSUFFICIENT_CLEARANCE",
+			})
+			return
+		}
+	}
+
 	// Get uploaded file
 	file, err := c.FormFile("file")
 	if err != nil {
@@ -92,11 +160,17 @@ func (h *DocumentHandler) UploadDocument(c *gin.Context) {
 		return
 	}
 
+	customFields, ok := parseCustomFields(c, req.CustomFields)
+	if !ok {
+		return
+	}
+
 	// Create document metadata
 	metadata := models.DocumentMetadata{
-		Category: req.Category,
-		Tags:     parseTags(req.Tags),
-		Language: req.Language,
+		Category:     req.Category,
+		Tags:         parseTags(req.Tags),
+		Language:     req.Language,
+		CustomFields: customFields,
 	}
 
 	if req.Title != "" {
@@ -110,7 +184,7 @@ func (h *DocumentHandler) UploadDocument(c *gin.Context) {
 	}
 
 	// Upload document
-	result, err := h.documentService.UploadDocument(file, metadata, user.ID)
+	result, err := h.documentService.UploadDocument(file, metadata, user.ID, req.DuplicatePolicy, declared)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Document upload failed",
@@ -129,6 +203,16 @@ func (h *DocumentHandler) UploadDocument(c *gin.Context) {
 		return
 	}
 
+	if result.Status == "duplicate" {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":        "Duplicate document",
+			"message":      result.Message,
+			"code":         "DUPLICATE_DOCUMENT",
+			"duplicate_of": result.DuplicateOf.Hex(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusCreated, SuccessResponse{
 		Message: result.Message,
 		Data: gin.H{
@@ -190,15 +274,16 @@ func (h *DocumentHandler) GetDocument(c *gin.Context) {
 		return
 	}
 
-	// Check if user can access this classification level
-	if !user.CanAccessClassification(doc.Classification.Level) {
-		c.JSON(http.StatusForbidden, ErrorResponse{
-			Error: "Insufficient security clearance",
-			Code:  "INSUFFICIENT_CLEARANCE",
+	// Check the user's clearance and compartments
+	if !user.CanAccessDocument(doc) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "Document not found",
+			Code:  "DOCUMENT_NOT_FOUND",
 		})
 		return
 	}
 
+	redactForUser(user, doc)
 	c.JSON(http.StatusOK, gin.H{
 		"document": doc,
 	})
@@ -206,38 +291,14 @@ func (h *DocumentHandler) GetDocument(c *gin.Context) {
 
 // ProcessDocument triggers document processing
 func (h *DocumentHandler) ProcessDocument(c *gin.Context) {
-	documentID := c.Param("id")
-	if documentID == "" {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error: "Document ID is required",
-			Code:  "MISSING_DOCUMENT_ID",
-		})
-		return
-	}
-
-	// Get user from context
-	userInterface, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{
-			Error: "User not authenticated",
-			Code:  "NOT_AUTHENTICATED",
-		})
-		return
-	}
-
-	user := userInterface.(*models.User)
-
-	// Check permissions
-	if !user.HasPermission("documents", "write") {
-		c.JSON(http.StatusForbidden, ErrorResponse{
-			Error: "Insufficient permissions to process documents",
-			Code:  "INSUFFICIENT_PERMISSIONS",
-		})
+	// Load the document first so that only users who may access it can process it
+	user, doc, ok := h.authorizeDocument(c, "write")
+	if !ok {
 		return
 	}
 
 	// Process document
-	doc, err := h.documentService.ProcessDocument(documentID)
+	doc, err := h.documentService.ProcessDocument(doc.ID.Hex())
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			c.JSON(http.StatusNotFound, ErrorResponse{
@@ -256,6 +317,18 @@ func (h *DocumentHandler) ProcessDocument(c *gin.Context) {
 		return
 	}
 
+	// Processing may raise the classification read from the document's markings
+	if !user.CanAccessDocument(doc) {
+		c.JSON(http.StatusOK, SuccessResponse{
+			Message: "Document processed successfully",
+			Data: gin.H{
+				"document_id": doc.ID.Hex(),
+			},
+		})
+		return
+	}
+
+	redactForUser(user, doc)
 	c.JSON(http.StatusOK, SuccessResponse{
 		Message: "Document processed successfully",
 		Data: gin.H{
@@ -266,53 +339,8 @@ func (h *DocumentHandler) ProcessDocument(c *gin.Context) {
 
 // GetProcessingStatus returns the processing status of a document
 func (h *DocumentHandler) GetProcessingStatus(c *gin.Context) {
-	documentID := c.Param("id")
-	if documentID == "" {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error: "Document ID is required",
-			Code:  "MISSING_DOCUMENT_ID",
-		})
-		return
-	}
-
-	// Get user from context
-	userInterface, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{
-			Error: "User not authenticated",
-			Code:  "NOT_AUTHENTICATED",
-		})
-		return
-	}
-
-	user := userInterface.(*models.User)
-
-	// Check permissions
-	if !user.HasPermission("documents", "read") {
-		c.JSON(http.StatusForbidden, ErrorResponse{
-			Error: "Insufficient permissions to read documents",
-			Code:  "INSUFFICIENT_PERMISSIONS",
-		})
-		return
-	}
-
-	// Get processing status
-	doc, err := h.documentService.GetProcessingStatus(documentID)
-	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			c.JSON(http.StatusNotFound, ErrorResponse{
-				Error:   "Document not found",
-				Message: err.Error(),
-				Code:    "DOCUMENT_NOT_FOUND",
-			})
-			return
-		}
-
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to get processing status",
-			Message: err.Error(),
-			Code:    "STATUS_RETRIEVAL_FAILED",
-		})
+	_, doc, ok := h.authorizeDocument(c, "read")
+	if !ok {
 		return
 	}
 
@@ -369,8 +397,21 @@ func (h *DocumentHandler) SearchDocuments(c *gin.Context) {
 		req.Skip = 0
 	}
 
+	workspaceIDs, ok := workspaceScope(c, h.workspaceService, user, req.Workspaces)
+	if !ok {
+		return
+	}
+
 	// Perform document search using the document service
-	documents, total, err := h.documentService.SearchDocuments(req.Query, req.Category, req.Tags, req.Department, req.Author, req.Limit, req.Skip, req.SortBy, req.SortOrder)
+	documents, total, err := h.documentService.SearchDocuments(user, req.Query, req.Category, req.Tags, req.Department, req.Author, workspaceIDs, c.QueryMap("custom_fields"), req.Limit, req.Skip, req.SortBy, req.SortOrder)
+	if errors.Is(err, metadata.ErrInvalidFilter) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid custom field filter",
+			Message: err.Error(),
+			Code:    "INVALID_SEARCH_PARAMS",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Failed to search documents",
@@ -380,13 +421,8 @@ func (h *DocumentHandler) SearchDocuments(c *gin.Context) {
 		return
 	}
 
-	// Filter documents based on user's security clearance
-	filteredDocuments := make([]*models.Document, 0)
-	for _, doc := range documents {
-		if user.CanAccessClassification(doc.Classification.Level) {
-			filteredDocuments = append(filteredDocuments, doc)
-		}
-	}
+	// Access control is part of the query, so every document returned is readable by the user
+	redactForUser(user, documents...)
 
 	// Calculate pagination metadata
 	totalPages := int((total + int64(req.Limit) - 1) / int64(req.Limit))
@@ -394,7 +430,7 @@ func (h *DocumentHandler) SearchDocuments(c *gin.Context) {
 
 	// Return search results in the same format as list endpoint
 	c.JSON(http.StatusOK, gin.H{
-		"data": filteredDocuments,
+		"data": documents,
 		"pagination": gin.H{
 			"page":       currentPage,
 			"limit":      req.Limit,
@@ -449,7 +485,7 @@ func (h *DocumentHandler) ListDocuments(c *gin.Context) {
 	sortOrder := c.DefaultQuery("sortOrder", "desc")
 
 	// Fetch documents from database
-	documents, total, err := h.documentService.ListDocuments(limit, skip, sortBy, sortOrder)
+	documents, total, err := h.documentService.ListDocuments(user, limit, skip, sortBy, sortOrder)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Failed to fetch documents",
@@ -459,13 +495,8 @@ func (h *DocumentHandler) ListDocuments(c *gin.Context) {
 		return
 	}
 
-	// Filter documents based on user's security clearance
-	filteredDocuments := make([]*models.Document, 0)
-	for _, doc := range documents {
-		if user.CanAccessClassification(doc.Classification.Level) {
-			filteredDocuments = append(filteredDocuments, doc)
-		}
-	}
+	// Access control is part of the query, so every document returned is readable by the user
+	redactForUser(user, documents...)
 
 	// Calculate pagination metadata
 	totalPages := int((total + int64(limit) - 1) / int64(limit))
@@ -473,7 +504,7 @@ func (h *DocumentHandler) ListDocuments(c *gin.Context) {
 
 	// Create proper paginated response format that matches frontend expectations
 	c.JSON(http.StatusOK, gin.H{
-		"data": filteredDocuments,
+		"data": documents,
 		"pagination": gin.H{
 			"page":       currentPage,
 			"limit":      limit,
@@ -483,47 +514,15 @@ func (h *DocumentHandler) ListDocuments(c *gin.Context) {
 	})
 }
 
-// UpdateDocument updates document metadata
+// UpdateDocument updates document metadata. The result must satisfy the metadata schemas of the
+// document's department and category.
 func (h *DocumentHandler) UpdateDocument(c *gin.Context) {
-	documentID := c.Param("id")
-	if documentID == "" {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error: "Document ID is required",
-			Code:  "MISSING_DOCUMENT_ID",
-		})
-		return
-	}
-
-	// Get user from context
-	userInterface, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{
-			Error: "User not authenticated",
-			Code:  "NOT_AUTHENTICATED",
-		})
-		return
-	}
-
-	user := userInterface.(*models.User)
-
-	// Check permissions
-	if !user.HasPermission("documents", "write") {
-		c.JSON(http.StatusForbidden, ErrorResponse{
-			Error: "Insufficient permissions to update documents",
-			Code:  "INSUFFICIENT_PERMISSIONS",
-		})
+	user, doc, ok := h.authorizeDocument(c, "write")
+	if !ok {
 		return
 	}
 
-	var req struct {
-		Title      *string                  `json:"title,omitempty"`
-		Author     *string                  `json:"author,omitempty"`
-		Department *string                  `json:"department,omitempty"`
-		Category   *models.DocumentCategory `json:"category,omitempty"`
-		Tags       []string                 `json:"tags,omitempty"`
-		Language   *string                  `json:"language,omitempty"`
-	}
-
+	var req UpdateDocumentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{
 			Error:   "Invalid request",
@@ -533,64 +532,58 @@ func (h *DocumentHandler) UpdateDocument(c *gin.Context) {
 		return
 	}
 
-	// Note: In a real implementation, you would update the document in the database
-	// For now, we'll return a success response
+	updated, err := h.documentService.UpdateMetadata(doc.ID.Hex(), document.MetadataUpdate{
+		Title:        req.Title,
+		Author:       req.Author,
+		Department:   req.Department,
+		Category:     req.Category,
+		Tags:         req.Tags,
+		Language:     req.Language,
+		CustomFields: req.CustomFields,
+	})
+	if err != nil {
+		respondMetadataError(c, "Failed to update document", err)
+		return
+	}
 
+	redactForUser(user, updated)
 	c.JSON(http.StatusOK, SuccessResponse{
 		Message: "Document updated successfully",
-		Data: gin.H{
-			"document_id": documentID,
-		},
+		Data:    updated,
 	})
 }
 
 // DeleteDocument deletes a document
 func (h *DocumentHandler) DeleteDocument(c *gin.Context) {
-	documentID := c.Param("id")
-	if documentID == "" {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error: "Document ID is required",
-			Code:  "MISSING_DOCUMENT_ID",
-		})
-		return
-	}
-
-	// Get user from context
-	userInterface, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{
-			Error: "User not authenticated",
-			Code:  "NOT_AUTHENTICATED",
-		})
-		return
-	}
-
-	user := userInterface.(*models.User)
-
-	// Check permissions
-	if !user.HasPermission("documents", "delete") {
-		c.JSON(http.StatusForbidden, ErrorResponse{
-			Error: "Insufficient permissions to delete documents",
-			Code:  "INSUFFICIENT_PERMISSIONS",
-		})
+	user, doc, ok := h.authorizeDocument(c, "delete")
+	if !ok {
 		return
 	}
 
-	// Validate ObjectID
-	_, err := primitive.ObjectIDFromHex(documentID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid document ID format",
-			Message: err.Error(),
-			Code:    "INVALID_DOCUMENT_ID",
-		})
+	if err := h.documentService.DeleteDocument(doc.ID.Hex(), user.ID); err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecordOnLegalHold):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "Document is under legal hold",
+				Message: err.Error(),
+				Code:    "LEGAL_HOLD",
+			})
+		case strings.Contains(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "Document not found",
+				Message: err.Error(),
+				Code:    "DOCUMENT_NOT_FOUND",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "Failed to delete document",
+				Message: err.Error(),
+				Code:    "DELETE_FAILED",
+			})
+		}
 		return
 	}
 
-	// Note: In a real implementation, you would delete the document from the database
-	// and any associated files from storage
-	// For now, we'll return a success response
-
 	c.JSON(http.StatusOK, SuccessResponse{
 		Message: "Document deleted successfully",
 	})
@@ -701,11 +694,11 @@ func (h *DocumentHandler) GetDocumentContent(c *gin.Context) {
 		return
 	}
 
-	// Check if user can access this classification level
-	if !user.CanAccessClassification(doc.Classification.Level) {
-		c.JSON(http.StatusForbidden, ErrorResponse{
-			Error: "Insufficient security clearance",
-			Code:  "INSUFFICIENT_CLEARANCE",
+	// Check the user's clearance and compartments
+	if !user.CanAccessDocument(doc) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "Document not found",
+			Code:  "DOCUMENT_NOT_FOUND",
 		})
 		return
 	}
@@ -719,6 +712,7 @@ func (h *DocumentHandler) GetDocumentContent(c *gin.Context) {
 		return
 	}
 
+	redactForUser(user, doc)
 	c.JSON(http.StatusOK, gin.H{
 		"document_id":          doc.ID.Hex(),
 		"content":              doc.Content,
@@ -779,17 +773,17 @@ func (h *DocumentHandler) GetDocumentFile(c *gin.Context) {
 		return
 	}
 
-	// Check if user can access this classification level
-	if !user.CanAccessClassification(doc.Classification.Level) {
-		c.JSON(http.StatusForbidden, ErrorResponse{
-			Error: "Insufficient security clearance",
-			Code:  "INSUFFICIENT_CLEARANCE",
+	// Check the user's clearance and compartments
+	if !user.CanAccessDocument(doc) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "Document not found",
+			Code:  "DOCUMENT_NOT_FOUND",
 		})
 		return
 	}
 
-	// Get file data from document service
-	fileData, err := h.documentService.GetDocumentFile(documentID)
+	// Open the original file from blob storage
+	file, err := h.documentService.GetDocumentFile(documentID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Failed to retrieve document file",
@@ -798,12 +792,991 @@ func (h *DocumentHandler) GetDocumentFile(c *gin.Context) {
 		})
 		return
 	}
+	defer file.Close()
 
 	// Set appropriate headers
-	c.Header("Content-Type", doc.ContentType)
-	c.Header("Content-Length", strconv.Itoa(len(fileData)))
+	if doc.ContentType != "" {
+		c.Header("Content-Type", doc.ContentType)
+	}
 	c.Header("Content-Disposition", "inline; filename=\""+doc.Name+"\"")
 
-	// Serve the file
-	c.Data(http.StatusOK, doc.ContentType, fileData)
+	// Stream the file; ServeContent handles Range and conditional requests
+	http.ServeContent(c.Writer, c.Request, doc.Name, doc.UploadedAt, file)
+}
+
+// ListAttachments returns the child documents extracted from a document, such as email attachments
+func (h *DocumentHandler) ListAttachments(c *gin.Context) {
+	documentID := c.Param("id")
+	if documentID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Document ID is required",
+			Code:  "MISSING_DOCUMENT_ID",
+		})
+		return
+	}
+
+	// Get user from context
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "User not authenticated",
+			Code:  "NOT_AUTHENTICATED",
+		})
+		return
+	}
+
+	user := userInterface.(*models.User)
+
+	// Check permissions
+	if !user.HasPermission("documents", "read") {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: "Insufficient permissions to read document",
+			Code:  "INSUFFICIENT_PERMISSIONS",
+		})
+		return
+	}
+
+	// Get parent document
+	doc, err := h.documentService.GetProcessingStatus(documentID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "Document not found",
+				Message: err.Error(),
+				Code:    "DOCUMENT_NOT_FOUND",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to retrieve document",
+			Message: err.Error(),
+			Code:    "RETRIEVAL_FAILED",
+		})
+		return
+	}
+
+	// Check the user's clearance and compartments
+	if !user.CanAccessDocument(doc) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "Document not found",
+			Code:  "DOCUMENT_NOT_FOUND",
+		})
+		return
+	}
+
+	attachments, err := h.documentService.ListAttachments(documentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to fetch attachments",
+			Message: err.Error(),
+			Code:    "FETCH_FAILED",
+		})
+		return
+	}
+
+	// Filter attachments the user may not read
+	filtered := make([]*models.Document, 0, len(attachments))
+	for _, attachment := range attachments {
+		if user.CanAccessDocument(attachment) {
+			filtered = append(filtered, attachment)
+		}
+	}
+
+	redactForUser(user, filtered...)
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Attachments retrieved successfully",
+		Data:    filtered,
+	})
+}
+
+// ListDocumentTables lists the tables extracted from a document, with their columns but not
+// their rows
+func (h *DocumentHandler) ListDocumentTables(c *gin.Context) {
+	user, doc, ok := h.authorizeDocument(c, "read")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	tables, err := h.documentService.ListTables(ctx, doc.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to fetch tables",
+			Message: err.Error(),
+			Code:    "FETCH_FAILED",
+		})
+		return
+	}
+
+	if !user.HasPermission("documents", models.DocumentActionReadPII) {
+		for i := range tables {
+			tables[i].RedactPII()
+		}
+	}
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Tables retrieved successfully",
+		Data:    tables,
+	})
+}
+
+// GetDocumentTable returns one of a document's tables with its rows, as JSON or, with
+// format=csv, as a CSV file
+func (h *DocumentHandler) GetDocumentTable(c *gin.Context) {
+	user, doc, ok := h.authorizeDocument(c, "read")
+	if !ok {
+		return
+	}
+
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil || index < 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid table index",
+			Code:  "INVALID_TABLE_INDEX",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	table, err := h.documentService.GetTable(ctx, doc.ID, index)
+	if err != nil {
+		if errors.Is(err, document.ErrTableNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Table not found",
+				Code:  "TABLE_NOT_FOUND",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to retrieve table",
+			Message: err.Error(),
+			Code:    "RETRIEVAL_FAILED",
+		})
+		return
+	}
+	if !user.HasPermission("documents", models.DocumentActionReadPII) {
+		table.RedactPII()
+	}
+
+	if c.Query("format") != "csv" {
+		c.JSON(http.StatusOK, SuccessResponse{
+			Message: "Table retrieved successfully",
+			Data:    table,
+		})
+		return
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write(table.Columns)
+	writer.WriteAll(table.Rows)
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to export table",
+			Message: err.Error(),
+			Code:    "EXPORT_FAILED",
+		})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s_table_%d.csv", doc.ID.Hex(), index))
+	c.Data(http.StatusOK, "text/csv", buf.Bytes())
+}
+
+// authorizeDocument loads the document named by the :id parameter and checks that the user
+// holds the given documents permission and the clearance and compartments to access it. On failure the error
+// response has been written and ok is false.
+func (h *DocumentHandler) authorizeDocument(c *gin.Context, action string) (user *models.User, doc *models.Document, ok bool) {
+	return authorizeDocumentID(c, h.documentService, c.Param("id"), action)
+}
+
+// authorizeDocumentID is authorizeDocument for a document ID given by the caller
+func authorizeDocumentID(c *gin.Context, documentService *document.Service, documentID, action string) (user *models.User, doc *models.Document, ok bool) {
+	if documentID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Document ID is required",
+			Code:  "MISSING_DOCUMENT_ID",
+		})
+		return nil, nil, false
+	}
+
+	// Get user from context
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "User not authenticated",
+			Code:  "NOT_AUTHENTICATED",
+		})
+		return nil, nil, false
+	}
+
+	user = userInterface.(*models.User)
+
+	// Check permissions
+	if !user.HasPermission("documents", action) {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: "Insufficient permissions to " + action + " document",
+			Code:  "INSUFFICIENT_PERMISSIONS",
+		})
+		return nil, nil, false
+	}
+
+	doc, err := documentService.GetProcessingStatus(documentID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "Document not found",
+				Message: err.Error(),
+				Code:    "DOCUMENT_NOT_FOUND",
+			})
+			return nil, nil, false
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to retrieve document",
+			Message: err.Error(),
+			Code:    "RETRIEVAL_FAILED",
+		})
+		return nil, nil, false
+	}
+
+	// Documents the user may not access are reported as missing, as listing and search omit them
+	if !user.CanAccessDocument(doc) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "Document not found",
+			Code:  "DOCUMENT_NOT_FOUND",
+		})
+		return nil, nil, false
+	}
+
+	return user, doc, true
+}
+
+// UploadDocumentVersion uploads a new revision of an existing document
+func (h *DocumentHandler) UploadDocumentVersion(c *gin.Context) {
+	user, doc, ok := h.authorizeDocument(c, "write")
+	if !ok {
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "File is required",
+			Message: err.Error(),
+			Code:    "MISSING_FILE",
+		})
+		return
+	}
+
+	result, err := h.documentService.UploadDocumentVersion(doc.ID.Hex(), file, user.ID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "concurrently") {
+			status = http.StatusConflict
+		}
+		c.JSON(status, ErrorResponse{
+			Error:   "Version upload failed",
+			Message: err.Error(),
+			Code:    "UPLOAD_FAILED",
+		})
+		return
+	}
+
+	if result.Status == "failed" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Document validation failed",
+			Message: result.Message,
+			Code:    "VALIDATION_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponse{
+		Message: result.Message,
+		Data: gin.H{
+			"document_id": result.DocumentID.Hex(),
+			"status":      result.Status,
+		},
+	})
+}
+
+// ListDocumentVersions lists every version in a document's version chain
+func (h *DocumentHandler) ListDocumentVersions(c *gin.Context) {
+	user, doc, ok := h.authorizeDocument(c, "read")
+	if !ok {
+		return
+	}
+
+	versions, err := h.documentService.ListVersions(doc.ID.Hex())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to fetch versions",
+			Message: err.Error(),
+			Code:    "FETCH_FAILED",
+		})
+		return
+	}
+
+	// Filter versions the user may not read
+	filtered := make([]*models.Document, 0, len(versions))
+	for _, version := range versions {
+		if user.CanAccessDocument(version) {
+			filtered = append(filtered, version)
+		}
+	}
+
+	redactForUser(user, filtered...)
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Versions retrieved successfully",
+		Data:    filtered,
+	})
+}
+
+// GetDocumentVersion retrieves a specific version of a document
+func (h *DocumentHandler) GetDocumentVersion(c *gin.Context) {
+	user, doc, ok := h.authorizeDocument(c, "read")
+	if !ok {
+		return
+	}
+
+	number, err := strconv.Atoi(c.Param("version"))
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Version must be a positive integer",
+			Code:  "INVALID_VERSION",
+		})
+		return
+	}
+
+	version, err := h.documentService.GetVersion(doc.ID.Hex(), number)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "Version not found",
+				Message: err.Error(),
+				Code:    "VERSION_NOT_FOUND",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to retrieve version",
+			Message: err.Error(),
+			Code:    "RETRIEVAL_FAILED",
+		})
+		return
+	}
+
+	if !user.CanAccessDocument(version) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "Document not found",
+			Code:  "DOCUMENT_NOT_FOUND",
+		})
+		return
+	}
+
+	redactForUser(user, version)
+	c.JSON(http.StatusOK, gin.H{
+		"document": version,
+	})
+}
+
+// DiffDocumentVersions returns a paragraph-level diff between two versions of a document.
+// "to" defaults to the requested document's version and "from" to the version before it.
+func (h *DocumentHandler) DiffDocumentVersions(c *gin.Context) {
+	user, doc, ok := h.authorizeDocument(c, "read")
+	if !ok {
+		return
+	}
+
+	to, err := strconv.Atoi(c.DefaultQuery("to", strconv.Itoa(doc.VersionNumber())))
+	if err != nil || to < 1 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Parameter 'to' must be a positive integer",
+			Code:  "INVALID_VERSION",
+		})
+		return
+	}
+	from, err := strconv.Atoi(c.DefaultQuery("from", strconv.Itoa(to-1)))
+	if err != nil || from < 1 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Parameter 'from' must be a positive integer",
+			Code:  "INVALID_VERSION",
+		})
+		return
+	}
+
+	diff, err := h.documentService.DiffVersions(doc.ID.Hex(), from, to, user.HasPermission("documents", models.DocumentActionReadPII))
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "DIFF_FAILED"
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			status, code = http.StatusNotFound, "VERSION_NOT_FOUND"
+		case strings.Contains(err.Error(), "not been processed"), strings.Contains(err.Error(), "too large"):
+			status = http.StatusUnprocessableEntity
+		}
+		c.JSON(status, ErrorResponse{
+			Error:   "Failed to diff versions",
+			Message: err.Error(),
+			Code:    code,
+		})
+		return
+	}
+
+	// Both versions must be readable by the user
+	for _, id := range []primitive.ObjectID{diff.FromID, diff.ToID} {
+		version, err := h.documentService.GetProcessingStatus(id.Hex())
+		if err != nil || !user.CanAccessDocument(version) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Document not found",
+				Code:  "DOCUMENT_NOT_FOUND",
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Diff generated successfully",
+		Data:    diff,
+	})
+}
+
+// ListClassificationReviews lists documents whose declared classification conflicts with their
+// markings, or whose markings conflict with each other, and that are waiting for a reviewer
+func (h *DocumentHandler) ListClassificationReviews(c *gin.Context) {
+	// Get user from context
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "User not authenticated",
+			Code:  "NOT_AUTHENTICATED",
+		})
+		return
+	}
+
+	user := userInterface.(*models.User)
+
+	// Check permissions
+	if !user.HasPermission("documents", "admin") {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: "Insufficient permissions to review document classifications",
+			Code:  "INSUFFICIENT_PERMISSIONS",
+		})
+		return
+	}
+
+	// Parse pagination parameters
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	skip, err := strconv.Atoi(c.DefaultQuery("skip", "0"))
+	if err != nil || skip < 0 {
+		skip = 0
+	}
+
+	documents, total, err := h.documentService.ListClassificationReviews(limit, skip)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to fetch classification reviews",
+			Message: err.Error(),
+			Code:    "FETCH_FAILED",
+		})
+		return
+	}
+
+	// Filter documents based on user's security clearance
+	filteredDocuments := make([]*models.Document, 0)
+	for _, doc := range documents {
+		if user.CanAccessDocument(doc) {
+			filteredDocuments = append(filteredDocuments, doc)
+		}
+	}
+	redactForUser(user, filteredDocuments...)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": filteredDocuments,
+		"pagination": gin.H{
+			"page":       (skip / limit) + 1,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": int((total + int64(limit) - 1) / int64(limit)),
+		},
+	})
+}
+
+// SetDocumentClassification sets a document's classification by hand, resolving any pending review
+func (h *DocumentHandler) SetDocumentClassification(c *gin.Context) {
+	user, doc, ok := h.authorizeDocument(c, "admin")
+	if !ok {
+		return
+	}
+
+	var classification models.SecurityClassification
+	if err := c.ShouldBindJSON(&classification); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+
+	classification.Level = normalizeClassificationLevel(classification.Level)
+	if models.ClassificationRank(classification.Level) < 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid classification level",
+			Code:  "INVALID_CLASSIFICATION",
+		})
+		return
+	}
+	if !user.CanAccessClassification(classification.Level) {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: "Insufficient security clearance",
+			Code:  "INSUFFICIENT_CLEARANCE",
+		})
+		return
+	}
+
+	updated, err := h.documentService.SetClassification(doc.ID.Hex(), classification, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to update classification",
+			Message: err.Error(),
+			Code:    "UPDATE_FAILED",
+		})
+		return
+	}
+	redactForUser(user, updated)
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Classification updated successfully",
+		Data:    updated,
+	})
+}
+
+// ShareDocumentRequest represents a request to share a document with a user
+type ShareDocumentRequest struct {
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// ShareDocument gives a user need-to-know for a document outside its compartments. Only the
+// uploader or a document administrator may share, and the user must be cleared for its level.
+func (h *DocumentHandler) ShareDocument(c *gin.Context) {
+	user, doc, ok := h.authorizeDocument(c, "write")
+	if !ok {
+		return
+	}
+	if doc.UploadedBy != user.ID && !user.HasPermission("documents", "admin") {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: "Only the uploader or a document administrator can share this document",
+			Code:  "INSUFFICIENT_PERMISSIONS",
+		})
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid user ID format",
+			Message: err.Error(),
+			Code:    "INVALID_USER_ID",
+		})
+		return
+	}
+
+	// The body is optional; without it the grant does not expire
+	var req ShareDocumentRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "expires_at must be in the future",
+			Code:  "INVALID_EXPIRY",
+		})
+		return
+	}
+
+	updated, err := h.documentService.ShareDocument(doc.ID.Hex(), userID, user.ID, req.ExpiresAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "User not found",
+				Code:  "USER_NOT_FOUND",
+			})
+		case errors.Is(err, document.ErrGranteeNotCleared):
+			c.JSON(http.StatusUnprocessableEntity, ErrorResponse{
+				Error:   "User is not cleared for this document",
+				Message: err.Error(),
+				Code:    "GRANTEE_NOT_CLEARED",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "Failed to share document",
+				Message: err.Error(),
+				Code:    "SHARE_FAILED",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Document shared successfully",
+		Data: gin.H{
+			"document_id": updated.ID.Hex(),
+			"shared_with": updated.SharedWith,
+		},
+	})
+}
+
+// UnshareDocument revokes a user's sharing grant on a document
+func (h *DocumentHandler) UnshareDocument(c *gin.Context) {
+	user, doc, ok := h.authorizeDocument(c, "write")
+	if !ok {
+		return
+	}
+	if doc.UploadedBy != user.ID && !user.HasPermission("documents", "admin") {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: "Only the uploader or a document administrator can unshare this document",
+			Code:  "INSUFFICIENT_PERMISSIONS",
+		})
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid user ID format",
+			Message: err.Error(),
+			Code:    "INVALID_USER_ID",
+		})
+		return
+	}
+
+	updated, err := h.documentService.UnshareDocument(doc.ID.Hex(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to unshare document",
+			Message: err.Error(),
+			Code:    "UNSHARE_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Document unshared successfully",
+		Data: gin.H{
+			"document_id": updated.ID.Hex(),
+			"shared_with": updated.SharedWith,
+		},
+	})
+}
+
+// normalizeClassificationLevel accepts levels such as "top secret" or "Confidential"
+func normalizeClassificationLevel(level string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(level), "_"))
+}
+
+// redactForUser masks personal data in the documents unless the user may read it unredacted
+func redactForUser(user *models.User, docs ...*models.Document) {
+	if user.HasPermission("documents", models.DocumentActionReadPII) {
+		return
+	}
+	for _, doc := range docs {
+		doc.RedactPII()
+	}
+}
+
+// UploadBatchRequest represents the form fields of a bulk archive upload. Metadata given here
+// applies to every file the manifest does not describe.
+type UploadBatchRequest struct {
+	Author          string                  `form:"author"`
+	Department      string                  `form:"department"`
+	Category        models.DocumentCategory `form:"category"`
+	Tags            string                  `form:"tags"` // Comma-separated
+	Language        string                  `form:"language"`
+	DuplicatePolicy models.DuplicatePolicy  `form:"duplicate_policy"`
+	Classification  string                  `form:"classification"`
+	Compartments    []string                `form:"compartments"`
+	Handling        []string                `form:"handling"`
+	CustomFields    string                  `form:"custom_fields"` // JSON object applied to every file
+}
+
+// UploadBatch accepts a ZIP or TAR archive, with an optional CSV or JSON manifest of per-file
+// metadata, and queues every file in it for ingestion
+func (h *DocumentHandler) UploadBatch(c *gin.Context) {
+	// Get user from context
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "User not authenticated",
+			Code:  "NOT_AUTHENTICATED",
+		})
+		return
+	}
+
+	user := userInterface.(*models.User)
+
+	// Check permissions
+	if !user.HasPermission("documents", "write") {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: "Insufficient permissions to upload documents",
+			Code:  "INSUFFICIENT_PERMISSIONS",
+		})
+		return
+	}
+
+	var req UploadBatchRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+
+	if !req.DuplicatePolicy.IsValid() {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid duplicate policy",
+			Code:  "INVALID_DUPLICATE_POLICY",
+		})
+		return
+	}
+
+	customFields, ok := parseCustomFields(c, req.CustomFields)
+	if !ok {
+		return
+	}
+
+	defaults := models.BatchManifestEntry{
+		Author:       req.Author,
+		Department:   req.Department,
+		Category:     req.Category,
+		Tags:         parseTags(req.Tags),
+		Language:     req.Language,
+		Compartments: req.Compartments,
+		Handling:     req.Handling,
+		CustomFields: customFields,
+	}
+	if req.Classification != "" {
+		defaults.Classification = normalizeClassificationLevel(req.Classification)
+		if models.ClassificationRank(defaults.Classification) < 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Invalid classification level",
+				Code:  "INVALID_CLASSIFICATION",
+			})
+			return
+		}
+	}
+
+	archive, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "File is required",
+			Message: err.Error(),
+			Code:    "MISSING_FILE",
+		})
+		return
+	}
+
+	var manifest []models.BatchManifestEntry
+	if manifestFile, err := c.FormFile("manifest"); err == nil {
+		manifest, err = document.ParseManifest(manifestFile)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid manifest",
+				Message: err.Error(),
+				Code:    "INVALID_MANIFEST",
+			})
+			return
+		}
+	}
+
+	// Every declared classification must be within the uploader's clearance
+	levels := []string{defaults.Classification}
+	for _, entry := range manifest {
+		levels = append(levels, entry.Classification)
+	}
+	for _, level := range levels {
+		if level != "" && !user.CanAccessClassification(level) {
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Error: "Insufficient security clearance",
+				Code:  "INSUFFICIENT_CLEARANCE",
+			})
+			return
+		}
+	}
+
+	batch, err := h.documentService.CreateBatch(archive, manifest, defaults, req.DuplicatePolicy, user.ID)
+	if err != nil {
+		if errors.Is(err, document.ErrInvalidArchive) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid archive",
+				Message: err.Error(),
+				Code:    "INVALID_ARCHIVE",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Batch upload failed",
+			Message: err.Error(),
+			Code:    "UPLOAD_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, SuccessResponse{
+		Message: "Archive uploaded successfully and queued for ingestion",
+		Data: gin.H{
+			"batch_id": batch.ID.Hex(),
+			"status":   batch.Status,
+		},
+	})
+}
+
+// ListBatches lists the user's bulk uploads, or everyone's for document administrators
+func (h *DocumentHandler) ListBatches(c *gin.Context) {
+	// Get user from context
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "User not authenticated",
+			Code:  "NOT_AUTHENTICATED",
+		})
+		return
+	}
+
+	user := userInterface.(*models.User)
+
+	// Check permissions
+	if !user.HasPermission("documents", "write") {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: "Insufficient permissions to upload documents",
+			Code:  "INSUFFICIENT_PERMISSIONS",
+		})
+		return
+	}
+
+	// Parse pagination parameters
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	skip, err := strconv.Atoi(c.DefaultQuery("skip", "0"))
+	if err != nil || skip < 0 {
+		skip = 0
+	}
+
+	var createdBy *primitive.ObjectID
+	if !user.HasPermission("documents", "admin") {
+		createdBy = &user.ID
+	}
+
+	batches, total, err := h.documentService.ListBatches(createdBy, limit, skip)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to fetch batches",
+			Message: err.Error(),
+			Code:    "FETCH_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": batches,
+		"pagination": gin.H{
+			"page":       (skip / limit) + 1,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": int((total + int64(limit) - 1) / int64(limit)),
+		},
+	})
+}
+
+// GetBatch returns a bulk upload with the progress and any error of each file
+func (h *DocumentHandler) GetBatch(c *gin.Context) {
+	// Get user from context
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "User not authenticated",
+			Code:  "NOT_AUTHENTICATED",
+		})
+		return
+	}
+
+	user := userInterface.(*models.User)
+
+	batch, err := h.documentService.GetBatch(c.Param("batch_id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "FETCH_FAILED"
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+			code = "BATCH_NOT_FOUND"
+		} else if strings.Contains(err.Error(), "invalid batch ID") {
+			status = http.StatusBadRequest
+			code = "INVALID_BATCH_ID"
+		}
+		c.JSON(status, ErrorResponse{
+			Error:   "Failed to fetch batch",
+			Message: err.Error(),
+			Code:    code,
+		})
+		return
+	}
+
+	// Only the uploader and document administrators can follow a batch
+	if batch.CreatedBy != user.ID && !user.HasPermission("documents", "admin") {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "Batch not found",
+			Code:  "BATCH_NOT_FOUND",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Batch retrieved successfully",
+		Data:    batch,
+	})
+}
+
+// parseCustomFields reads the custom_fields form field, a JSON object, writing a 400 response if
+// it is malformed
+func parseCustomFields(c *gin.Context, raw string) (map[string]interface{}, bool) {
+	fields := map[string]interface{}{}
+	if strings.TrimSpace(raw) == "" {
+		return fields, true
+	}
+	if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid custom fields",
+			Message: "custom_fields must be a JSON object: " + err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return nil, false
+	}
+	return fields, true
 }
@@ -14,10 +14,12 @@ import (
 
 // EmbeddingService defines the interface for embedding operations
 type EmbeddingService interface {
+	Model() string
 	GenerateEmbedding(ctx context.Context, text string) ([]float64, error)
 	GenerateDocumentEmbedding(ctx context.Context, documentID primitive.ObjectID) error
 	GenerateKnowledgeEmbedding(ctx context.Context, knowledgeID primitive.ObjectID) error
 	VectorSearch(ctx context.Context, query string, options *embedding.SearchOptions) ([]embedding.SearchResult, error)
+	HybridSearch(ctx context.Context, query string, options *embedding.HybridOptions) ([]embedding.HybridResult, error)
 	GetSimilarDocuments(ctx context.Context, documentID primitive.ObjectID, limit int) ([]embedding.SearchResult, error)
 	GetSimilarKnowledge(ctx context.Context, knowledgeID primitive.ObjectID, limit int) ([]embedding.SearchResult, error)
 	ClearCache(ctx context.Context) error
@@ -62,6 +64,7 @@ type GenerateEmbeddingResponse struct {
 	Text       string    `json:"text"`
 	Embeddings []float64 `json:"embeddings"`
 	Dimensions int       `json:"dimensions"`
+	Model      string    `json:"model"` // provider and model that produced the embedding
 }
 
 // VectorSearchRequest represents a vector search request
@@ -146,6 +149,7 @@ func (h *EmbeddingHandler) GenerateEmbedding(c *gin.Context) {
 		Text:       req.Text,
 		Embeddings: embeddings,
 		Dimensions: len(embeddings),
+		Model:      h.service.Model(),
 	}
 
 	c.JSON(http.StatusOK, response)
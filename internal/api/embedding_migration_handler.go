@@ -0,0 +1,237 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ai-government-consultant/internal/embedding"
+	"ai-government-consultant/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EmbeddingMigrationPipeline defines the embedding model migration operations
+type EmbeddingMigrationPipeline interface {
+	StartMigration(ctx context.Context, target string, startedBy primitive.ObjectID) (*models.EmbeddingMigration, error)
+	GetMigration(ctx context.Context, id primitive.ObjectID) (*models.EmbeddingMigration, error)
+	ListMigrations(ctx context.Context, limit int) ([]*models.EmbeddingMigration, error)
+	PauseMigration(ctx context.Context, id primitive.ObjectID) (*models.EmbeddingMigration, error)
+	ResumeMigration(ctx context.Context, id primitive.ObjectID) (*models.EmbeddingMigration, error)
+	CancelMigration(ctx context.Context, id primitive.ObjectID) (*models.EmbeddingMigration, error)
+	CutOverMigration(ctx context.Context, id primitive.ObjectID, force bool) (*models.EmbeddingMigration, error)
+}
+
+// EmbeddingModels reports the embedding models in use
+type EmbeddingModels interface {
+	Model() string
+	ShadowModel() string
+}
+
+// EmbeddingMigrationHandler handles the admin endpoints for embedding model migrations
+type EmbeddingMigrationHandler struct {
+	pipeline EmbeddingMigrationPipeline
+	models   EmbeddingModels
+}
+
+// NewEmbeddingMigrationHandler creates a new embedding migration handler
+func NewEmbeddingMigrationHandler(pipeline EmbeddingMigrationPipeline, models EmbeddingModels) *EmbeddingMigrationHandler {
+	return &EmbeddingMigrationHandler{
+		pipeline: pipeline,
+		models:   models,
+	}
+}
+
+// StartEmbeddingMigrationRequest starts re-embedding the corpus with another model
+type StartEmbeddingMigrationRequest struct {
+	TargetModel string `json:"target_model" binding:"required"` // written as EMBEDDING_MODEL, e.g. "openai/bge-m3"
+}
+
+// EmbeddingMigrationResponse is a migration with its overall progress
+type EmbeddingMigrationResponse struct {
+	*models.EmbeddingMigration
+	Progress float64 `json:"progress"` // share of records processed, from 0 to 1
+}
+
+func embeddingMigrationResponse(migration *models.EmbeddingMigration) EmbeddingMigrationResponse {
+	return EmbeddingMigrationResponse{EmbeddingMigration: migration, Progress: migration.Progress()}
+}
+
+// ListEmbeddingMigrations lists the latest migrations with the models in use on this server
+func (h *EmbeddingMigrationHandler) ListEmbeddingMigrations(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	migrations, err := h.pipeline.ListMigrations(ctx, limit)
+	if err != nil {
+		respondEmbeddingMigrationError(c, "Failed to fetch embedding migrations", err)
+		return
+	}
+
+	data := make([]EmbeddingMigrationResponse, len(migrations))
+	for i, migration := range migrations {
+		data[i] = embeddingMigrationResponse(migration)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"active_model": h.models.Model(),
+		"shadow_model": h.models.ShadowModel(),
+		"data":         data,
+	})
+}
+
+// StartEmbeddingMigration starts re-embedding every stored vector with another model into shadow
+// vectors; searches keep using the active model until the cutover
+func (h *EmbeddingMigrationHandler) StartEmbeddingMigration(c *gin.Context) {
+	var req StartEmbeddingMigrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "User not authenticated",
+			Code:  "NOT_AUTHENTICATED",
+		})
+		return
+	}
+
+	// Counting and stamping the stored vectors can take a while on a large corpus
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Minute)
+	defer cancel()
+
+	migration, err := h.pipeline.StartMigration(ctx, req.TargetModel, user.(*models.User).ID)
+	if err != nil {
+		respondEmbeddingMigrationError(c, "Failed to start embedding migration", err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, SuccessResponse{
+		Message: "Embedding migration started",
+		Data:    embeddingMigrationResponse(migration),
+	})
+}
+
+// GetEmbeddingMigration returns a migration with its progress
+func (h *EmbeddingMigrationHandler) GetEmbeddingMigration(c *gin.Context) {
+	id, ok := embeddingMigrationID(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	migration, err := h.pipeline.GetMigration(ctx, id)
+	if err != nil {
+		respondEmbeddingMigrationError(c, "Failed to fetch embedding migration", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Embedding migration retrieved successfully",
+		Data:    embeddingMigrationResponse(migration),
+	})
+}
+
+// PauseEmbeddingMigration stops a running migration after its current batch
+func (h *EmbeddingMigrationHandler) PauseEmbeddingMigration(c *gin.Context) {
+	h.changeMigration(c, "Embedding migration paused", "Failed to pause embedding migration", h.pipeline.PauseMigration)
+}
+
+// ResumeEmbeddingMigration continues a paused migration where it stopped
+func (h *EmbeddingMigrationHandler) ResumeEmbeddingMigration(c *gin.Context) {
+	h.changeMigration(c, "Embedding migration resumed", "Failed to resume embedding migration", h.pipeline.ResumeMigration)
+}
+
+// CancelEmbeddingMigration abandons a migration before its cutover and removes its shadow vectors
+func (h *EmbeddingMigrationHandler) CancelEmbeddingMigration(c *gin.Context) {
+	h.changeMigration(c, "Embedding migration cancelled", "Failed to cancel embedding migration", h.pipeline.CancelMigration)
+}
+
+// CutOverEmbeddingMigration makes the target model of a ready migration the active model. With
+// force=true it cuts over even though some records have no vector of the target model.
+func (h *EmbeddingMigrationHandler) CutOverEmbeddingMigration(c *gin.Context) {
+	force := c.Query("force") == "true"
+	h.changeMigration(c, "Cut over to the new embedding model", "Failed to cut over embedding migration",
+		func(ctx context.Context, id primitive.ObjectID) (*models.EmbeddingMigration, error) {
+			return h.pipeline.CutOverMigration(ctx, id, force)
+		})
+}
+
+// changeMigration applies change to the migration named by the id parameter
+func (h *EmbeddingMigrationHandler) changeMigration(c *gin.Context, message, failure string,
+	change func(ctx context.Context, id primitive.ObjectID) (*models.EmbeddingMigration, error)) {
+	id, ok := embeddingMigrationID(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), time.Minute)
+	defer cancel()
+
+	migration, err := change(ctx, id)
+	if err != nil {
+		respondEmbeddingMigrationError(c, failure, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: message,
+		Data:    embeddingMigrationResponse(migration),
+	})
+}
+
+// embeddingMigrationID parses the migration ID path parameter, writing a 400 response if it is
+// malformed
+func embeddingMigrationID(c *gin.Context) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: err.Error(),
+			Code:    "INVALID_ID",
+		})
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+func respondEmbeddingMigrationError(c *gin.Context, message string, err error) {
+	status, code := http.StatusInternalServerError, "MIGRATION_ERROR"
+	switch {
+	case errors.Is(err, embedding.ErrMigrationNotFound):
+		status, code = http.StatusNotFound, "NOT_FOUND"
+	case errors.Is(err, embedding.ErrMigrationInProgress):
+		status, code = http.StatusConflict, "MIGRATION_IN_PROGRESS"
+	case errors.Is(err, embedding.ErrMigrationState):
+		status, code = http.StatusConflict, "INVALID_STATE"
+	case errors.Is(err, embedding.ErrMigrationIncomplete):
+		status, code = http.StatusConflict, "MIGRATION_INCOMPLETE"
+	case errors.Is(err, embedding.ErrMigrationSameModel):
+		status, code = http.StatusBadRequest, "INVALID_REQUEST"
+	case errors.Is(err, embedding.ErrMigrationUnsupported):
+		status, code = http.StatusServiceUnavailable, "MIGRATIONS_DISABLED"
+	}
+	c.JSON(status, ErrorResponse{
+		Error:   message,
+		Message: err.Error(),
+		Code:    code,
+	})
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ai-government-consultant/internal/queue"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JobHandler handles the admin endpoints for the background job queue
+type JobHandler struct {
+	jobQueue *queue.Queue
+}
+
+// NewJobHandler creates a new job handler
+func NewJobHandler(jobQueue *queue.Queue) *JobHandler {
+	return &JobHandler{
+		jobQueue: jobQueue,
+	}
+}
+
+// ListJobs lists queued jobs, filtered by status (e.g. "dead" for the dead-letter list) and type
+func (h *JobHandler) ListJobs(c *gin.Context) {
+	status := queue.JobStatus(c.Query("status"))
+	switch status {
+	case "", queue.JobStatusPending, queue.JobStatusRunning, queue.JobStatusCompleted, queue.JobStatusDead:
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid job status",
+			Code:  "INVALID_STATUS",
+		})
+		return
+	}
+
+	// Parse pagination parameters
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	skip, err := strconv.Atoi(c.DefaultQuery("skip", "0"))
+	if err != nil || skip < 0 {
+		skip = 0
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	jobs, total, err := h.jobQueue.List(ctx, status, c.Query("type"), limit, skip)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to fetch jobs",
+			Message: err.Error(),
+			Code:    "FETCH_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": jobs,
+		"pagination": gin.H{
+			"page":       (skip / limit) + 1,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": int((total + int64(limit) - 1) / int64(limit)),
+		},
+	})
+}
+
+// GetJobStats returns job counts by status
+func (h *JobHandler) GetJobStats(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	stats, err := h.jobQueue.Stats(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to fetch job statistics",
+			Message: err.Error(),
+			Code:    "FETCH_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"stats": stats,
+	})
+}
+
+// GetJob retrieves a single job
+func (h *JobHandler) GetJob(c *gin.Context) {
+	jobID, ok := parseJobID(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	job, err := h.jobQueue.Get(ctx, jobID)
+	if err != nil {
+		if err == queue.ErrJobNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Job not found",
+				Code:  "JOB_NOT_FOUND",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to retrieve job",
+			Message: err.Error(),
+			Code:    "RETRIEVAL_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"job": job,
+	})
+}
+
+// RequeueJob moves a dead-lettered job back onto the queue
+func (h *JobHandler) RequeueJob(c *gin.Context) {
+	jobID, ok := parseJobID(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	job, err := h.jobQueue.Requeue(ctx, jobID)
+	if err != nil {
+		switch err {
+		case queue.ErrJobNotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Job not found",
+				Code:  "JOB_NOT_FOUND",
+			})
+		case queue.ErrJobNotRequeueable:
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "Job cannot be requeued",
+				Message: err.Error(),
+				Code:    "JOB_NOT_REQUEUEABLE",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "Failed to requeue job",
+				Message: err.Error(),
+				Code:    "REQUEUE_FAILED",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Job requeued successfully",
+		Data:    job,
+	})
+}
+
+// parseJobID reads the :id parameter, writing a 400 response when it is not a valid ObjectID
+func parseJobID(c *gin.Context) (primitive.ObjectID, bool) {
+	jobID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid job ID format",
+			Message: err.Error(),
+			Code:    "INVALID_JOB_ID",
+		})
+		return primitive.NilObjectID, false
+	}
+	return jobID, true
+}
@@ -0,0 +1,262 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"ai-government-consultant/internal/document"
+	"ai-government-consultant/internal/metadata"
+	"ai-government-consultant/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MetadataSchemaHandler handles the metadata schemas that define the custom fields of documents
+type MetadataSchemaHandler struct {
+	metadataService *metadata.Service
+}
+
+// NewMetadataSchemaHandler creates a new metadata schema handler
+func NewMetadataSchemaHandler(metadataService *metadata.Service) *MetadataSchemaHandler {
+	return &MetadataSchemaHandler{
+		metadataService: metadataService,
+	}
+}
+
+// MetadataSchemaRequest creates or replaces a metadata schema. A schema applies to the documents
+// of its department, its category, or both when both are given.
+type MetadataSchemaRequest struct {
+	Name        string                             `json:"name" binding:"required"`
+	Description string                             `json:"description,omitempty"`
+	Department  string                             `json:"department,omitempty"`
+	Category    models.DocumentCategory            `json:"category,omitempty"`
+	Properties  map[string]models.MetadataProperty `json:"properties" binding:"required"`
+	Required    []string                           `json:"required,omitempty"`
+}
+
+// ListSchemas lists metadata schemas, optionally filtered by department and category
+func (h *MetadataSchemaHandler) ListSchemas(c *gin.Context) {
+	if _, ok := metadataSchemaUser(c, "read"); !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	schemas, err := h.metadataService.ListSchemas(ctx, c.Query("department"), models.DocumentCategory(c.Query("category")))
+	if err != nil {
+		respondMetadataError(c, "Failed to fetch metadata schemas", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": schemas,
+	})
+}
+
+// CreateSchema creates a metadata schema
+func (h *MetadataSchemaHandler) CreateSchema(c *gin.Context) {
+	user, ok := metadataSchemaUser(c, "admin")
+	if !ok {
+		return
+	}
+
+	schema, ok := bindMetadataSchema(c)
+	if !ok {
+		return
+	}
+	schema.CreatedBy = user.ID
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	if err := h.metadataService.CreateSchema(ctx, schema); err != nil {
+		respondMetadataError(c, "Failed to create metadata schema", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponse{
+		Message: "Metadata schema created successfully",
+		Data:    schema,
+	})
+}
+
+// GetSchema returns a metadata schema
+func (h *MetadataSchemaHandler) GetSchema(c *gin.Context) {
+	if _, ok := metadataSchemaUser(c, "read"); !ok {
+		return
+	}
+	id, ok := metadataSchemaID(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	schema, err := h.metadataService.GetSchema(ctx, id)
+	if err != nil {
+		respondMetadataError(c, "Failed to fetch metadata schema", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Metadata schema retrieved successfully",
+		Data:    schema,
+	})
+}
+
+// UpdateSchema replaces the definition of a metadata schema
+func (h *MetadataSchemaHandler) UpdateSchema(c *gin.Context) {
+	if _, ok := metadataSchemaUser(c, "admin"); !ok {
+		return
+	}
+	id, ok := metadataSchemaID(c)
+	if !ok {
+		return
+	}
+
+	changes, ok := bindMetadataSchema(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	schema, err := h.metadataService.UpdateSchema(ctx, id, changes)
+	if err != nil {
+		respondMetadataError(c, "Failed to update metadata schema", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Metadata schema updated successfully",
+		Data:    schema,
+	})
+}
+
+// DeleteSchema deletes a metadata schema
+func (h *MetadataSchemaHandler) DeleteSchema(c *gin.Context) {
+	if _, ok := metadataSchemaUser(c, "admin"); !ok {
+		return
+	}
+	id, ok := metadataSchemaID(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	if err := h.metadataService.DeleteSchema(ctx, id); err != nil {
+		respondMetadataError(c, "Failed to delete metadata schema", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Metadata schema deleted successfully",
+	})
+}
+
+// metadataSchemaUser returns the authenticated user if they hold the documents permission for
+// action, writing the error response otherwise. Schemas are read with documents:read, so
+// uploaders can see which fields are expected, and managed with documents:admin.
+func metadataSchemaUser(c *gin.Context, action string) (*models.User, bool) {
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "User not authenticated",
+			Code:  "NOT_AUTHENTICATED",
+		})
+		return nil, false
+	}
+
+	user := userInterface.(*models.User)
+	if !user.HasPermission("documents", action) {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: "Insufficient permissions to " + action + " metadata schemas",
+			Code:  "INSUFFICIENT_PERMISSIONS",
+		})
+		return nil, false
+	}
+	return user, true
+}
+
+// metadataSchemaID parses the schema ID path parameter, writing a 400 response if it is malformed
+func metadataSchemaID(c *gin.Context) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: err.Error(),
+			Code:    "INVALID_ID",
+		})
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+// bindMetadataSchema reads a MetadataSchemaRequest into a schema, writing a 400 response if it
+// is malformed
+func bindMetadataSchema(c *gin.Context) (*models.MetadataSchema, bool) {
+	var req MetadataSchemaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return nil, false
+	}
+
+	return &models.MetadataSchema{
+		Name:        req.Name,
+		Description: req.Description,
+		Department:  req.Department,
+		Category:    req.Category,
+		Properties:  req.Properties,
+		Required:    req.Required,
+	}, true
+}
+
+// respondMetadataError writes the response for an error from the metadata schema service, or
+// from a document update checked against the schemas. Custom fields that break a schema are
+// listed under "fields".
+func respondMetadataError(c *gin.Context, message string, err error) {
+	var invalid *metadata.ValidationError
+	if errors.As(err, &invalid) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   message,
+			"message": err.Error(),
+			"code":    "INVALID_METADATA",
+			"fields":  invalid.Fields,
+		})
+		return
+	}
+
+	status, code := http.StatusInternalServerError, "METADATA_ERROR"
+	switch {
+	case errors.Is(err, metadata.ErrNotFound), errors.Is(err, document.ErrDocumentNotFound):
+		status, code = http.StatusNotFound, "NOT_FOUND"
+	case errors.Is(err, metadata.ErrFieldConflict):
+		status, code = http.StatusConflict, "FIELD_CONFLICT"
+	case errors.Is(err, metadata.ErrTooManyIndexedFields),
+		errors.Is(err, models.ErrMetadataSchemaNameRequired),
+		errors.Is(err, models.ErrMetadataSchemaScopeRequired),
+		errors.Is(err, models.ErrMetadataSchemaFieldsRequired),
+		errors.Is(err, models.ErrMetadataFieldNameInvalid),
+		errors.Is(err, models.ErrMetadataFieldTypeInvalid),
+		errors.Is(err, models.ErrMetadataFieldConstraintInvalid),
+		errors.Is(err, models.ErrMetadataRequiredFieldUndefined):
+		status, code = http.StatusBadRequest, "INVALID_REQUEST"
+	}
+	c.JSON(status, ErrorResponse{
+		Error:   message,
+		Message: err.Error(),
+		Code:    code,
+	})
+}
@@ -0,0 +1,504 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ai-government-consultant/internal/models"
+	"ai-government-consultant/internal/retention"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RetentionHandler handles retention schedules and legal holds
+type RetentionHandler struct {
+	retentionService *retention.Service
+}
+
+// NewRetentionHandler creates a new retention handler
+func NewRetentionHandler(retentionService *retention.Service) *RetentionHandler {
+	return &RetentionHandler{
+		retentionService: retentionService,
+	}
+}
+
+// RetentionScheduleRequest creates or replaces a retention schedule. A schedule with neither a
+// category nor a workspace is the default for its record type.
+type RetentionScheduleRequest struct {
+	Name          string                   `json:"name" binding:"required"`
+	Description   string                   `json:"description,omitempty"`
+	Authority     string                   `json:"authority,omitempty"`
+	RecordType    models.RecordType        `json:"record_type" binding:"required"` // "document" or "consultation"
+	Category      string                   `json:"category,omitempty"`
+	WorkspaceID   string                   `json:"workspace_id,omitempty"`
+	RetentionDays int                      `json:"retention_days" binding:"required"`
+	Disposition   models.DispositionAction `json:"disposition" binding:"required"` // "delete" or "archive"
+}
+
+// CreateLegalHoldRequest represents a legal hold creation request
+type CreateLegalHoldRequest struct {
+	Name   string `json:"name" binding:"required"`
+	Matter string `json:"matter,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// LegalHoldRecordsRequest lists the records to place under a legal hold
+type LegalHoldRecordsRequest struct {
+	IDs []string `json:"ids" binding:"required"`
+}
+
+// ListSchedules lists retention schedules, optionally filtered by record_type
+func (h *RetentionHandler) ListSchedules(c *gin.Context) {
+	if _, ok := retentionUser(c, "read"); !ok {
+		return
+	}
+
+	recordType := models.RecordType(c.Query("record_type"))
+	if recordType != "" && !recordType.IsValid() {
+		respondRetentionError(c, "Invalid record type", models.ErrRecordTypeInvalid)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	schedules, err := h.retentionService.ListSchedules(ctx, recordType)
+	if err != nil {
+		respondRetentionError(c, "Failed to fetch retention schedules", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": schedules,
+	})
+}
+
+// CreateSchedule creates a retention schedule
+func (h *RetentionHandler) CreateSchedule(c *gin.Context) {
+	user, ok := retentionUser(c, "write")
+	if !ok {
+		return
+	}
+
+	schedule, ok := bindRetentionSchedule(c)
+	if !ok {
+		return
+	}
+	schedule.CreatedBy = user.ID
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.retentionService.CreateSchedule(ctx, schedule); err != nil {
+		respondRetentionError(c, "Failed to create retention schedule", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponse{
+		Message: "Retention schedule created successfully",
+		Data:    schedule,
+	})
+}
+
+// GetSchedule returns a retention schedule
+func (h *RetentionHandler) GetSchedule(c *gin.Context) {
+	if _, ok := retentionUser(c, "read"); !ok {
+		return
+	}
+	id, ok := retentionID(c, "id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	schedule, err := h.retentionService.GetSchedule(ctx, id)
+	if err != nil {
+		respondRetentionError(c, "Failed to fetch retention schedule", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Retention schedule retrieved successfully",
+		Data:    schedule,
+	})
+}
+
+// UpdateSchedule replaces the settings of a retention schedule. Records it covers have their
+// disposition dates recomputed in the background.
+func (h *RetentionHandler) UpdateSchedule(c *gin.Context) {
+	if _, ok := retentionUser(c, "write"); !ok {
+		return
+	}
+	id, ok := retentionID(c, "id")
+	if !ok {
+		return
+	}
+
+	changes, ok := bindRetentionSchedule(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	schedule, err := h.retentionService.GetSchedule(ctx, id)
+	if err != nil {
+		respondRetentionError(c, "Failed to update retention schedule", err)
+		return
+	}
+	if changes.RecordType != schedule.RecordType {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "The record type of a retention schedule cannot change",
+			Code:  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	updated, err := h.retentionService.UpdateSchedule(ctx, id, changes)
+	if err != nil {
+		respondRetentionError(c, "Failed to update retention schedule", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Retention schedule updated successfully",
+		Data:    updated,
+	})
+}
+
+// DeleteSchedule deletes a retention schedule
+func (h *RetentionHandler) DeleteSchedule(c *gin.Context) {
+	if _, ok := retentionUser(c, "write"); !ok {
+		return
+	}
+	id, ok := retentionID(c, "id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.retentionService.DeleteSchedule(ctx, id); err != nil {
+		respondRetentionError(c, "Failed to delete retention schedule", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Retention schedule deleted successfully",
+	})
+}
+
+// ListHolds lists legal holds, optionally filtered by status
+func (h *RetentionHandler) ListHolds(c *gin.Context) {
+	if _, ok := retentionUser(c, "admin"); !ok {
+		return
+	}
+
+	// Parse pagination parameters
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	skip, err := strconv.Atoi(c.DefaultQuery("skip", "0"))
+	if err != nil || skip < 0 {
+		skip = 0
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	holds, total, err := h.retentionService.ListHolds(ctx, models.LegalHoldStatus(c.Query("status")), limit, skip)
+	if err != nil {
+		respondRetentionError(c, "Failed to fetch legal holds", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": holds,
+		"pagination": gin.H{
+			"page":       (skip / limit) + 1,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": int((total + int64(limit) - 1) / int64(limit)),
+		},
+	})
+}
+
+// CreateHold places a new legal hold
+func (h *RetentionHandler) CreateHold(c *gin.Context) {
+	user, ok := retentionUser(c, "admin")
+	if !ok {
+		return
+	}
+
+	var req CreateLegalHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	hold, err := h.retentionService.CreateHold(ctx, req.Name, req.Matter, req.Reason, user.ID)
+	if err != nil {
+		respondRetentionError(c, "Failed to create legal hold", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponse{
+		Message: "Legal hold created successfully",
+		Data:    hold,
+	})
+}
+
+// GetHold returns a legal hold with the number of records under it
+func (h *RetentionHandler) GetHold(c *gin.Context) {
+	if _, ok := retentionUser(c, "admin"); !ok {
+		return
+	}
+	id, ok := retentionID(c, "id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	hold, err := h.retentionService.GetHold(ctx, id)
+	if err != nil {
+		respondRetentionError(c, "Failed to fetch legal hold", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Legal hold retrieved successfully",
+		Data:    hold,
+	})
+}
+
+// ReleaseHold releases a legal hold and lifts it from its records
+func (h *RetentionHandler) ReleaseHold(c *gin.Context) {
+	user, ok := retentionUser(c, "admin")
+	if !ok {
+		return
+	}
+	id, ok := retentionID(c, "id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	hold, err := h.retentionService.ReleaseHold(ctx, id, user.ID)
+	if err != nil {
+		respondRetentionError(c, "Failed to release legal hold", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Legal hold released successfully",
+		Data:    hold,
+	})
+}
+
+// AddHoldRecords places documents or consultations under a legal hold
+func (h *RetentionHandler) AddHoldRecords(c *gin.Context) {
+	user, ok := retentionUser(c, "admin")
+	if !ok {
+		return
+	}
+	id, ok := retentionID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req LegalHoldRecordsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+
+	recordIDs := make([]primitive.ObjectID, 0, len(req.IDs))
+	for _, raw := range req.IDs {
+		recordID, err := primitive.ObjectIDFromHex(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid record ID format",
+				Message: err.Error(),
+				Code:    "INVALID_RECORD_ID",
+			})
+			return
+		}
+		recordIDs = append(recordIDs, recordID)
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	matched, err := h.retentionService.AddRecords(ctx, id, models.RecordType(c.Param("type")), recordIDs, user.ID)
+	if err != nil {
+		respondRetentionError(c, "Failed to place records under legal hold", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Records placed under legal hold successfully",
+		Data:    gin.H{"matched": matched},
+	})
+}
+
+// RemoveHoldRecord lifts a legal hold from one document or consultation
+func (h *RetentionHandler) RemoveHoldRecord(c *gin.Context) {
+	user, ok := retentionUser(c, "admin")
+	if !ok {
+		return
+	}
+	id, ok := retentionID(c, "id")
+	if !ok {
+		return
+	}
+	recordID, ok := retentionID(c, "record_id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	removed, err := h.retentionService.RemoveRecords(ctx, id, models.RecordType(c.Param("type")), []primitive.ObjectID{recordID}, user.ID)
+	if err != nil {
+		respondRetentionError(c, "Failed to lift legal hold from record", err)
+		return
+	}
+	if removed == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "Record is not under this legal hold",
+			Code:  "RECORD_NOT_HELD",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Legal hold lifted from record successfully",
+	})
+}
+
+// retentionUser returns the authenticated user if they hold the retention permission for
+// action, writing the error response otherwise
+func retentionUser(c *gin.Context, action string) (*models.User, bool) {
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "User not authenticated",
+			Code:  "NOT_AUTHENTICATED",
+		})
+		return nil, false
+	}
+
+	user := userInterface.(*models.User)
+	if !user.HasPermission("retention", action) {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: "Insufficient permissions to " + action + " retention",
+			Code:  "INSUFFICIENT_PERMISSIONS",
+		})
+		return nil, false
+	}
+	return user, true
+}
+
+// retentionID parses an ObjectID path parameter, writing a 400 response if it is malformed
+func retentionID(c *gin.Context, param string) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param(param))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: err.Error(),
+			Code:    "INVALID_ID",
+		})
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+// bindRetentionSchedule reads a RetentionScheduleRequest into a schedule, writing a 400
+// response if it is malformed
+func bindRetentionSchedule(c *gin.Context) (*models.RetentionSchedule, bool) {
+	var req RetentionScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return nil, false
+	}
+
+	schedule := &models.RetentionSchedule{
+		Name:          req.Name,
+		Description:   req.Description,
+		Authority:     req.Authority,
+		RecordType:    req.RecordType,
+		Category:      req.Category,
+		RetentionDays: req.RetentionDays,
+		Disposition:   req.Disposition,
+	}
+	if req.WorkspaceID != "" {
+		workspaceID, err := primitive.ObjectIDFromHex(req.WorkspaceID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid workspace ID format",
+				Message: err.Error(),
+				Code:    "INVALID_WORKSPACE_ID",
+			})
+			return nil, false
+		}
+		schedule.WorkspaceID = &workspaceID
+	}
+	return schedule, true
+}
+
+// respondRetentionError writes the response for an error from the retention service
+func respondRetentionError(c *gin.Context, message string, err error) {
+	status, code := http.StatusInternalServerError, "RETENTION_ERROR"
+	switch {
+	case errors.Is(err, retention.ErrNotFound):
+		status, code = http.StatusNotFound, "NOT_FOUND"
+	case errors.Is(err, retention.ErrHoldReleased):
+		status, code = http.StatusConflict, "LEGAL_HOLD_RELEASED"
+	case errors.Is(err, retention.ErrInvalidRecords),
+		errors.Is(err, models.ErrRetentionScheduleNameRequired),
+		errors.Is(err, models.ErrRecordTypeInvalid),
+		errors.Is(err, models.ErrRetentionDaysInvalid),
+		errors.Is(err, models.ErrDispositionActionInvalid),
+		errors.Is(err, models.ErrRetentionScopeInvalid),
+		errors.Is(err, models.ErrLegalHoldNameRequired):
+		status, code = http.StatusBadRequest, "INVALID_REQUEST"
+	}
+	c.JSON(status, ErrorResponse{
+		Error:   message,
+		Message: err.Error(),
+		Code:    code,
+	})
+}
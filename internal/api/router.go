@@ -1,11 +1,19 @@
 package api
 
 import (
+	"ai-government-consultant/internal/annotation"
 	"ai-government-consultant/internal/auth"
+	"ai-government-consultant/internal/citation"
+	"ai-government-consultant/internal/connector"
 	"ai-government-consultant/internal/consultation"
 	"ai-government-consultant/internal/document"
+	"ai-government-consultant/internal/embedding"
+	"ai-government-consultant/internal/metadata"
 	"ai-government-consultant/internal/models"
+	"ai-government-consultant/internal/queue"
+	"ai-government-consultant/internal/retention"
 	"ai-government-consultant/internal/speech"
+	"ai-government-consultant/internal/workspace"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,8 +23,18 @@ type RouterConfig struct {
 	AuthService         *auth.AuthService
 	DocumentService     *document.Service
 	ConsultationService *consultation.Service
+	SessionManager      *consultation.SessionManager
 	KnowledgeService    KnowledgeServiceInterface
 	AuditService        AuditServiceInterface
+	JobQueue            *queue.Queue
+	CitationIndex       *citation.Index
+	WorkspaceService    *workspace.Service
+	RetentionService    *retention.Service
+	AnnotationService   *annotation.Service
+	ConnectorService    *connector.Service
+	MetadataService     *metadata.Service
+	EmbeddingService    *embedding.Service
+	EmbeddingPipeline   *embedding.Pipeline
 	SpeechService       *speech.SpeechService
 	AllowedOrigins      []string
 }
@@ -25,10 +43,22 @@ type RouterConfig struct {
 func SetupRoutes(router *gin.Engine, config *RouterConfig) {
 	// Create handlers
 	authHandler := NewAuthHandler(config.AuthService)
-	documentHandler := NewDocumentHandler(config.DocumentService)
-	consultationHandler := NewConsultationHandler(config.ConsultationService)
+	documentHandler := NewDocumentHandler(config.DocumentService, config.WorkspaceService)
+	consultationHandler := NewConsultationHandler(config.ConsultationService, config.SessionManager, config.WorkspaceService)
 	knowledgeHandler := NewKnowledgeHandler(config.KnowledgeService)
 	auditHandler := NewAuditHandler(config.AuditService)
+	jobHandler := NewJobHandler(config.JobQueue)
+	citationHandler := NewCitationHandler(config.CitationIndex)
+	workspaceHandler := NewWorkspaceHandler(config.WorkspaceService)
+	retentionHandler := NewRetentionHandler(config.RetentionService)
+	annotationHandler := NewAnnotationHandler(config.AnnotationService, config.DocumentService)
+	connectorHandler := NewConnectorHandler(config.ConnectorService)
+	metadataSchemaHandler := NewMetadataSchemaHandler(config.MetadataService)
+	searchHandler := NewSearchHandler(config.EmbeddingService, config.WorkspaceService)
+	var embeddingMigrationHandler *EmbeddingMigrationHandler
+	if config.EmbeddingPipeline != nil {
+		embeddingMigrationHandler = NewEmbeddingMigrationHandler(config.EmbeddingPipeline, config.EmbeddingService)
+	}
 	var speechHandler *SpeechHandler
 	if config.SpeechService != nil {
 		speechHandler = NewSpeechHandler(config.SpeechService)
@@ -77,6 +107,7 @@ func SetupRoutes(router *gin.Engine, config *RouterConfig) {
 		{
 			users.GET("", authHandler.ListUsers)
 			users.GET("/:id", authHandler.GetUser)
+			users.PUT("/:id/compartments", authHandler.SetUserCompartments)
 		}
 
 		// Document management endpoints
@@ -87,6 +118,10 @@ func SetupRoutes(router *gin.Engine, config *RouterConfig) {
 			documents.GET("", documentHandler.ListDocuments)
 			documents.POST("/search", documentHandler.SearchDocuments)
 			documents.POST("/validate", documentHandler.ValidateDocument)
+			documents.GET("/classification-reviews", documentHandler.ListClassificationReviews)
+			documents.POST("/batches", documentHandler.UploadBatch)
+			documents.GET("/batches", documentHandler.ListBatches)
+			documents.GET("/batches/:batch_id", documentHandler.GetBatch)
 
 			// Document-specific endpoints
 			documents.GET("/:id", documentHandler.GetDocument)
@@ -96,6 +131,32 @@ func SetupRoutes(router *gin.Engine, config *RouterConfig) {
 			documents.GET("/:id/status", documentHandler.GetProcessingStatus)
 			documents.GET("/:id/content", documentHandler.GetDocumentContent)
 			documents.GET("/:id/file", documentHandler.GetDocumentFile)
+			documents.GET("/:id/attachments", documentHandler.ListAttachments)
+			documents.GET("/:id/tables", documentHandler.ListDocumentTables)
+			documents.GET("/:id/tables/:index", documentHandler.GetDocumentTable)
+			documents.POST("/:id/versions", documentHandler.UploadDocumentVersion)
+			documents.GET("/:id/versions", documentHandler.ListDocumentVersions)
+			documents.GET("/:id/versions/:version", documentHandler.GetDocumentVersion)
+			documents.GET("/:id/diff", documentHandler.DiffDocumentVersions)
+			documents.PUT("/:id/classification", documentHandler.SetDocumentClassification)
+			documents.PUT("/:id/shares/:user_id", documentHandler.ShareDocument)
+			documents.DELETE("/:id/shares/:user_id", documentHandler.UnshareDocument)
+			documents.GET("/:id/annotations", annotationHandler.ListDocumentAnnotations)
+			documents.POST("/:id/annotations", annotationHandler.CreateAnnotation)
+			documents.GET("/:id/annotations/export", annotationHandler.ExportDocumentAnnotations)
+		}
+
+		// Annotation endpoints
+		annotations := v1.Group("/annotations")
+		annotations.Use(AuthMiddleware(config.AuthService))
+		{
+			annotations.GET("", annotationHandler.SearchAnnotations)
+			annotations.GET("/:id", annotationHandler.GetAnnotation)
+			annotations.PUT("/:id", annotationHandler.UpdateAnnotation)
+			annotations.DELETE("/:id", annotationHandler.DeleteAnnotation)
+			annotations.POST("/:id/replies", annotationHandler.ReplyToAnnotation)
+			annotations.POST("/:id/resolve", annotationHandler.ResolveAnnotation)
+			annotations.POST("/:id/reopen", annotationHandler.ReopenAnnotation)
 		}
 
 		// Consultation endpoints
@@ -141,6 +202,78 @@ func SetupRoutes(router *gin.Engine, config *RouterConfig) {
 			knowledge.GET("/:id/related", knowledgeHandler.GetRelatedKnowledge)
 		}
 
+		// Workspace endpoints
+		workspaces := v1.Group("/workspaces")
+		workspaces.Use(AuthMiddleware(config.AuthService))
+		{
+			workspaces.POST("", workspaceHandler.CreateWorkspace)
+			workspaces.GET("", workspaceHandler.ListWorkspaces)
+			workspaces.GET("/:id", workspaceHandler.GetWorkspace)
+			workspaces.PUT("/:id", workspaceHandler.UpdateWorkspace)
+			workspaces.DELETE("/:id", workspaceHandler.DeleteWorkspace)
+			workspaces.PUT("/:id/members/:user_id", workspaceHandler.SetWorkspaceMember)
+			workspaces.DELETE("/:id/members/:user_id", workspaceHandler.RemoveWorkspaceMember)
+			workspaces.POST("/:id/items/:kind", workspaceHandler.AddWorkspaceItems)
+			workspaces.DELETE("/:id/items/:kind/:item_id", workspaceHandler.RemoveWorkspaceItem)
+		}
+
+		// Citation index endpoints
+		citations := v1.Group("/citations")
+		citations.Use(AuthMiddleware(config.AuthService))
+		{
+			citations.GET("", citationHandler.FindCiting)
+		}
+
+		// Records retention endpoints
+		records := v1.Group("/retention")
+		records.Use(AuthMiddleware(config.AuthService))
+		{
+			records.GET("/schedules", retentionHandler.ListSchedules)
+			records.POST("/schedules", retentionHandler.CreateSchedule)
+			records.GET("/schedules/:id", retentionHandler.GetSchedule)
+			records.PUT("/schedules/:id", retentionHandler.UpdateSchedule)
+			records.DELETE("/schedules/:id", retentionHandler.DeleteSchedule)
+			records.GET("/holds", retentionHandler.ListHolds)
+			records.POST("/holds", retentionHandler.CreateHold)
+			records.GET("/holds/:id", retentionHandler.GetHold)
+			records.POST("/holds/:id/release", retentionHandler.ReleaseHold)
+			records.POST("/holds/:id/records/:type", retentionHandler.AddHoldRecords)
+			records.DELETE("/holds/:id/records/:type/:record_id", retentionHandler.RemoveHoldRecord)
+		}
+
+		// Ingestion connector endpoints
+		connectors := v1.Group("/connectors")
+		connectors.Use(AuthMiddleware(config.AuthService))
+		{
+			connectors.GET("", connectorHandler.ListConnectors)
+			connectors.POST("", connectorHandler.CreateConnector)
+			connectors.GET("/:id", connectorHandler.GetConnector)
+			connectors.PUT("/:id", connectorHandler.UpdateConnector)
+			connectors.DELETE("/:id", connectorHandler.DeleteConnector)
+			connectors.POST("/:id/sync", connectorHandler.SyncConnector)
+			connectors.GET("/:id/files", connectorHandler.ListConnectorFiles)
+			connectors.GET("/:id/runs", connectorHandler.ListConnectorRuns)
+			connectors.GET("/:id/runs/:run_id", connectorHandler.GetConnectorRun)
+		}
+
+		// Metadata schema endpoints
+		metadataSchemas := v1.Group("/metadata-schemas")
+		metadataSchemas.Use(AuthMiddleware(config.AuthService))
+		{
+			metadataSchemas.GET("", metadataSchemaHandler.ListSchemas)
+			metadataSchemas.POST("", metadataSchemaHandler.CreateSchema)
+			metadataSchemas.GET("/:id", metadataSchemaHandler.GetSchema)
+			metadataSchemas.PUT("/:id", metadataSchemaHandler.UpdateSchema)
+			metadataSchemas.DELETE("/:id", metadataSchemaHandler.DeleteSchema)
+		}
+
+		// Hybrid search endpoint
+		search := v1.Group("/search")
+		search.Use(AuthMiddleware(config.AuthService))
+		{
+			search.POST("", searchHandler.HybridSearch)
+		}
+
 		// Speech services endpoints (only if speech service is available)
 		if speechHandler != nil {
 			speech := v1.Group("/speech")
@@ -212,6 +345,23 @@ func SetupRoutes(router *gin.Engine, config *RouterConfig) {
 			system.GET("/info", getSystemInfo)
 			system.GET("/metrics", getSystemMetrics)
 			system.GET("/config", getSystemConfig)
+
+			// Background job queue
+			system.GET("/jobs", jobHandler.ListJobs)
+			system.GET("/jobs/stats", jobHandler.GetJobStats)
+			system.GET("/jobs/:id", jobHandler.GetJob)
+			system.POST("/jobs/:id/requeue", jobHandler.RequeueJob)
+
+			// Embedding model migrations
+			if embeddingMigrationHandler != nil {
+				system.GET("/embedding-migrations", embeddingMigrationHandler.ListEmbeddingMigrations)
+				system.POST("/embedding-migrations", embeddingMigrationHandler.StartEmbeddingMigration)
+				system.GET("/embedding-migrations/:id", embeddingMigrationHandler.GetEmbeddingMigration)
+				system.POST("/embedding-migrations/:id/pause", embeddingMigrationHandler.PauseEmbeddingMigration)
+				system.POST("/embedding-migrations/:id/resume", embeddingMigrationHandler.ResumeEmbeddingMigration)
+				system.POST("/embedding-migrations/:id/cancel", embeddingMigrationHandler.CancelEmbeddingMigration)
+				system.POST("/embedding-migrations/:id/cutover", embeddingMigrationHandler.CutOverEmbeddingMigration)
+			}
 		}
 	}
 
@@ -276,7 +426,7 @@ func getSystemConfig(c *gin.Context) {
 	c.JSON(200, gin.H{
 		"config": gin.H{
 			"max_file_size":         "50MB",
-			"supported_formats":     []string{"pdf", "doc", "docx", "txt"},
+			"supported_formats":     []string{"pdf", "doc", "docx", "odt", "txt", "html", "htm", "md", "markdown", "rtf", "eml", "csv", "xlsx"},
 			"max_consultation_time": "60s",
 			"rate_limits": gin.H{
 				"requests_per_minute": 60,
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"net/http"
+
+	"ai-government-consultant/internal/document"
+	"ai-government-consultant/internal/embedding"
+	"ai-government-consultant/internal/models"
+	"ai-government-consultant/internal/workspace"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchHandler handles hybrid search requests
+type SearchHandler struct {
+	service          EmbeddingService
+	workspaceService *workspace.Service
+}
+
+// NewSearchHandler creates a new search handler
+func NewSearchHandler(service EmbeddingService, workspaceService *workspace.Service) *SearchHandler {
+	return &SearchHandler{
+		service:          service,
+		workspaceService: workspaceService,
+	}
+}
+
+// HybridSearchRequest represents a hybrid search request. Fusion parameters left out take the
+// configured defaults.
+type HybridSearchRequest struct {
+	Query         string   `json:"query" binding:"required"`
+	Collection    string   `json:"collection,omitempty"` // "documents" or "knowledge_items"; both when empty
+	Limit         int      `json:"limit,omitempty"`
+	Threshold     float64  `json:"threshold,omitempty"` // minimum vector similarity
+	Fusion        string   `json:"fusion,omitempty"`    // "rrf" or "weighted"
+	LexicalWeight float64  `json:"lexical_weight,omitempty"`
+	VectorWeight  float64  `json:"vector_weight,omitempty"`
+	RRFK          float64  `json:"rrf_k,omitempty"`
+	Workspaces    []string `json:"workspaces,omitempty"` // only search these workspaces
+}
+
+// HybridSearchResponse represents a hybrid search response
+type HybridSearchResponse struct {
+	Query   string                   `json:"query"`
+	Results []embedding.HybridResult `json:"results"`
+	Count   int                      `json:"count"`
+}
+
+// HybridSearch ranks documents and knowledge items by keyword relevance and semantic similarity
+// @Summary Hybrid search
+// @Description Search documents and knowledge items with BM25 and vector similarity, fused into one ranking with per-result score explanations
+// @Tags search
+// @Accept json
+// @Produce json
+// @Param request body HybridSearchRequest true "Search parameters"
+// @Success 200 {object} HybridSearchResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/search [post]
+func (h *SearchHandler) HybridSearch(c *gin.Context) {
+	// Get user from context
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "User not authenticated",
+			Code:  "NOT_AUTHENTICATED",
+		})
+		return
+	}
+
+	user := userInterface.(*models.User)
+
+	var req HybridSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+
+	switch req.Collection {
+	case "", "documents", "knowledge_items":
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid collection",
+			Message: "Supported collections: documents, knowledge_items",
+			Code:    "INVALID_SEARCH_PARAMS",
+		})
+		return
+	}
+	switch embedding.FusionMethod(req.Fusion) {
+	case "", embedding.FusionRRF, embedding.FusionWeighted:
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid fusion method",
+			Message: "Supported fusion methods: rrf, weighted",
+			Code:    "INVALID_SEARCH_PARAMS",
+		})
+		return
+	}
+
+	// Check permissions; a collection the user may not read is left out of an unscoped search
+	canReadDocuments := user.HasPermission("documents", "read")
+	canReadKnowledge := user.HasPermission("knowledge", "read")
+	collection := req.Collection
+	switch {
+	case collection == "documents" && !canReadDocuments,
+		collection == "knowledge_items" && !canReadKnowledge,
+		!canReadDocuments && !canReadKnowledge:
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: "Insufficient permissions to search",
+			Code:  "INSUFFICIENT_PERMISSIONS",
+		})
+		return
+	case collection == "" && !canReadDocuments:
+		collection = "knowledge_items"
+	case collection == "" && !canReadKnowledge:
+		collection = "documents"
+	}
+
+	workspaceIDs, ok := workspaceScope(c, h.workspaceService, user, req.Workspaces)
+	if !ok {
+		return
+	}
+
+	options := &embedding.HybridOptions{
+		SearchOptions: embedding.SearchOptions{
+			Limit:        req.Limit,
+			Threshold:    req.Threshold,
+			Collection:   collection,
+			Unredacted:   user.HasPermission("documents", models.DocumentActionReadPII),
+			Access:       document.NewAccessScope(user),
+			WorkspaceIDs: workspaceIDs,
+		},
+		Fusion:        embedding.FusionMethod(req.Fusion),
+		LexicalWeight: req.LexicalWeight,
+		VectorWeight:  req.VectorWeight,
+		RRFK:          req.RRFK,
+	}
+	if options.Limit <= 0 {
+		options.Limit = 10
+	}
+	if options.Limit > 100 {
+		options.Limit = 100
+	}
+	if options.Threshold <= 0 {
+		options.Threshold = 0.7
+	}
+
+	results, err := h.service.HybridSearch(c.Request.Context(), req.Query, options)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to perform search",
+			Message: err.Error(),
+			Code:    "SEARCH_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, HybridSearchResponse{
+		Query:   req.Query,
+		Results: results,
+		Count:   len(results),
+	})
+}
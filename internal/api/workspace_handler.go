@@ -0,0 +1,528 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-government-consultant/internal/models"
+	"ai-government-consultant/internal/workspace"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WorkspaceHandler handles workspaces, their members and their items
+type WorkspaceHandler struct {
+	workspaceService *workspace.Service
+}
+
+// NewWorkspaceHandler creates a new workspace handler
+func NewWorkspaceHandler(workspaceService *workspace.Service) *WorkspaceHandler {
+	return &WorkspaceHandler{
+		workspaceService: workspaceService,
+	}
+}
+
+// CreateWorkspaceRequest represents a workspace creation request
+type CreateWorkspaceRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description,omitempty"`
+}
+
+// UpdateWorkspaceRequest represents a workspace update request
+type UpdateWorkspaceRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+// SetWorkspaceMemberRequest sets a member's role
+type SetWorkspaceMemberRequest struct {
+	Role models.WorkspaceRole `json:"role" binding:"required"` // "viewer", "editor" or "owner"
+}
+
+// AddWorkspaceItemsRequest lists the items to add to a workspace
+type AddWorkspaceItemsRequest struct {
+	IDs []string `json:"ids" binding:"required"`
+}
+
+// itemPermissions names the permission resource guarding each kind of workspace item
+var itemPermissions = map[workspace.ItemKind]string{
+	workspace.ItemDocuments:     "documents",
+	workspace.ItemKnowledge:     "knowledge",
+	workspace.ItemConsultations: "consultations",
+}
+
+// CreateWorkspace creates a workspace with the requesting user as its owner
+func (h *WorkspaceHandler) CreateWorkspace(c *gin.Context) {
+	user, ok := workspaceUser(c)
+	if !ok {
+		return
+	}
+
+	// Check permissions
+	if !user.HasPermission("workspaces", "write") {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: "Insufficient permissions to create workspaces",
+			Code:  "INSUFFICIENT_PERMISSIONS",
+		})
+		return
+	}
+
+	var req CreateWorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	created, err := h.workspaceService.Create(ctx, req.Name, req.Description, user.ID)
+	if err != nil {
+		respondWorkspaceError(c, "Failed to create workspace", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponse{
+		Message: "Workspace created successfully",
+		Data:    created,
+	})
+}
+
+// ListWorkspaces lists the workspaces the user is a member of, or every workspace for
+// workspace administrators
+func (h *WorkspaceHandler) ListWorkspaces(c *gin.Context) {
+	user, ok := workspaceUser(c)
+	if !ok {
+		return
+	}
+
+	// Parse pagination parameters
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	skip, err := strconv.Atoi(c.DefaultQuery("skip", "0"))
+	if err != nil || skip < 0 {
+		skip = 0
+	}
+
+	var memberID *primitive.ObjectID
+	if !user.HasPermission("workspaces", "admin") {
+		memberID = &user.ID
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	workspaces, total, err := h.workspaceService.List(ctx, memberID, limit, skip)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to fetch workspaces",
+			Message: err.Error(),
+			Code:    "FETCH_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": workspaces,
+		"pagination": gin.H{
+			"page":       (skip / limit) + 1,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": int((total + int64(limit) - 1) / int64(limit)),
+		},
+	})
+}
+
+// GetWorkspace returns a workspace with its members and item counts
+func (h *WorkspaceHandler) GetWorkspace(c *gin.Context) {
+	_, id, ok := h.authorizeWorkspace(c, models.WorkspaceRoleViewer)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	found, err := h.workspaceService.Get(ctx, id)
+	if err != nil {
+		respondWorkspaceError(c, "Failed to fetch workspace", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Workspace retrieved successfully",
+		Data:    found,
+	})
+}
+
+// UpdateWorkspace renames a workspace or changes its description
+func (h *WorkspaceHandler) UpdateWorkspace(c *gin.Context) {
+	_, id, ok := h.authorizeWorkspace(c, models.WorkspaceRoleOwner)
+	if !ok {
+		return
+	}
+
+	var req UpdateWorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	updated, err := h.workspaceService.Update(ctx, id, req.Name, req.Description)
+	if err != nil {
+		respondWorkspaceError(c, "Failed to update workspace", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Workspace updated successfully",
+		Data:    updated,
+	})
+}
+
+// DeleteWorkspace deletes a workspace. Its documents, knowledge items and consultations are kept.
+func (h *WorkspaceHandler) DeleteWorkspace(c *gin.Context) {
+	_, id, ok := h.authorizeWorkspace(c, models.WorkspaceRoleOwner)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	if err := h.workspaceService.Delete(ctx, id); err != nil {
+		respondWorkspaceError(c, "Failed to delete workspace", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Workspace deleted successfully",
+	})
+}
+
+// SetWorkspaceMember adds a user to a workspace or changes their role
+func (h *WorkspaceHandler) SetWorkspaceMember(c *gin.Context) {
+	user, id, ok := h.authorizeWorkspace(c, models.WorkspaceRoleOwner)
+	if !ok {
+		return
+	}
+
+	memberID, err := primitive.ObjectIDFromHex(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid user ID format",
+			Message: err.Error(),
+			Code:    "INVALID_USER_ID",
+		})
+		return
+	}
+
+	var req SetWorkspaceMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	updated, err := h.workspaceService.SetMember(ctx, id, memberID, req.Role, user.ID)
+	if err != nil {
+		respondWorkspaceError(c, "Failed to update workspace member", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Workspace member updated successfully",
+		Data:    updated,
+	})
+}
+
+// RemoveWorkspaceMember removes a user from a workspace. Members may remove themselves;
+// removing anyone else takes the owner role.
+func (h *WorkspaceHandler) RemoveWorkspaceMember(c *gin.Context) {
+	user, ok := workspaceUser(c)
+	if !ok {
+		return
+	}
+
+	memberID, err := primitive.ObjectIDFromHex(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid user ID format",
+			Message: err.Error(),
+			Code:    "INVALID_USER_ID",
+		})
+		return
+	}
+
+	required := models.WorkspaceRoleOwner
+	if memberID == user.ID {
+		required = models.WorkspaceRoleViewer
+	}
+	_, id, ok := h.authorizeWorkspace(c, required)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	updated, err := h.workspaceService.RemoveMember(ctx, id, memberID)
+	if err != nil {
+		respondWorkspaceError(c, "Failed to remove workspace member", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Workspace member removed successfully",
+		Data:    updated,
+	})
+}
+
+// AddWorkspaceItems adds documents, knowledge items or consultations to a workspace. Items
+// the user cannot read are left out.
+func (h *WorkspaceHandler) AddWorkspaceItems(c *gin.Context) {
+	kind, ok := workspaceItemKind(c)
+	if !ok {
+		return
+	}
+	user, id, ok := h.authorizeWorkspace(c, models.WorkspaceRoleEditor)
+	if !ok {
+		return
+	}
+	if !user.HasPermission(itemPermissions[kind], "read") {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: "Insufficient permissions to read " + string(kind),
+			Code:  "INSUFFICIENT_PERMISSIONS",
+		})
+		return
+	}
+
+	var req AddWorkspaceItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+
+	itemIDs := make([]primitive.ObjectID, 0, len(req.IDs))
+	for _, raw := range req.IDs {
+		itemID, err := primitive.ObjectIDFromHex(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid item ID format",
+				Message: err.Error(),
+				Code:    "INVALID_ITEM_ID",
+			})
+			return
+		}
+		itemIDs = append(itemIDs, itemID)
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	added, err := h.workspaceService.AddItems(ctx, id, kind, itemIDs, user)
+	if err != nil {
+		respondWorkspaceError(c, "Failed to add items to workspace", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Items added to workspace",
+		Data: gin.H{
+			"requested": len(itemIDs),
+			"added":     added,
+		},
+	})
+}
+
+// RemoveWorkspaceItem removes a document, knowledge item or consultation from a workspace
+func (h *WorkspaceHandler) RemoveWorkspaceItem(c *gin.Context) {
+	kind, ok := workspaceItemKind(c)
+	if !ok {
+		return
+	}
+	_, id, ok := h.authorizeWorkspace(c, models.WorkspaceRoleEditor)
+	if !ok {
+		return
+	}
+
+	itemID, err := primitive.ObjectIDFromHex(c.Param("item_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid item ID format",
+			Message: err.Error(),
+			Code:    "INVALID_ITEM_ID",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	removed, err := h.workspaceService.RemoveItems(ctx, id, kind, []primitive.ObjectID{itemID})
+	if err != nil {
+		respondWorkspaceError(c, "Failed to remove item from workspace", err)
+		return
+	}
+	if removed == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "Item is not in the workspace",
+			Code:  "ITEM_NOT_FOUND",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Item removed from workspace",
+	})
+}
+
+// authorizeWorkspace loads the authenticated user and checks they hold at least the required
+// role in the workspace named by the :id parameter. It writes the error response and returns
+// false when they do not.
+func (h *WorkspaceHandler) authorizeWorkspace(c *gin.Context, required models.WorkspaceRole) (*models.User, primitive.ObjectID, bool) {
+	user, ok := workspaceUser(c)
+	if !ok {
+		return nil, primitive.NilObjectID, false
+	}
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid workspace ID format",
+			Message: err.Error(),
+			Code:    "INVALID_WORKSPACE_ID",
+		})
+		return nil, primitive.NilObjectID, false
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.workspaceService.Authorize(ctx, []primitive.ObjectID{id}, user, required); err != nil {
+		respondWorkspaceError(c, "Workspace access denied", err)
+		return nil, primitive.NilObjectID, false
+	}
+	return user, id, true
+}
+
+// workspaceUser returns the authenticated user, writing a 401 response if there is none
+func workspaceUser(c *gin.Context) (*models.User, bool) {
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "User not authenticated",
+			Code:  "NOT_AUTHENTICATED",
+		})
+		return nil, false
+	}
+	return userInterface.(*models.User), true
+}
+
+// workspaceItemKind parses the :kind parameter, writing a 400 response if it is unknown
+func workspaceItemKind(c *gin.Context) (workspace.ItemKind, bool) {
+	kind := workspace.ItemKind(c.Param("kind"))
+	if _, ok := itemPermissions[kind]; !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid item kind",
+			Message: "Supported kinds: documents, knowledge, consultations",
+			Code:    "INVALID_ITEM_KIND",
+		})
+		return "", false
+	}
+	return kind, true
+}
+
+// workspaceScope parses the workspace IDs a search or consultation is limited to, given
+// repeated or comma-separated, and checks the user can read each workspace. It writes the
+// error response and returns false on failure.
+func workspaceScope(c *gin.Context, workspaces *workspace.Service, user *models.User, raw []string) ([]primitive.ObjectID, bool) {
+	var ids []primitive.ObjectID
+	for _, value := range raw {
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			id, err := primitive.ObjectIDFromHex(part)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse{
+					Error:   "Invalid workspace ID format",
+					Message: err.Error(),
+					Code:    "INVALID_WORKSPACE_ID",
+				})
+				return nil, false
+			}
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, true
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := workspaces.Authorize(ctx, ids, user, models.WorkspaceRoleViewer); err != nil {
+		respondWorkspaceError(c, "Workspace access denied", err)
+		return nil, false
+	}
+	return ids, true
+}
+
+// respondWorkspaceError writes the response for an error from the workspace service
+func respondWorkspaceError(c *gin.Context, message string, err error) {
+	status, code := http.StatusInternalServerError, "WORKSPACE_ERROR"
+	switch {
+	case errors.Is(err, workspace.ErrNotFound):
+		status, code = http.StatusNotFound, "WORKSPACE_NOT_FOUND"
+	case errors.Is(err, workspace.ErrForbidden):
+		status, code = http.StatusForbidden, "INSUFFICIENT_WORKSPACE_ROLE"
+	case errors.Is(err, workspace.ErrConflict):
+		status, code = http.StatusConflict, "WORKSPACE_CONFLICT"
+	case errors.Is(err, models.ErrUserNotFound):
+		status, code = http.StatusNotFound, "USER_NOT_FOUND"
+	case errors.Is(err, models.ErrWorkspaceNameRequired),
+		errors.Is(err, models.ErrWorkspaceRoleInvalid),
+		errors.Is(err, models.ErrWorkspaceOwnerRequired),
+		errors.Is(err, workspace.ErrInvalidItems):
+		status, code = http.StatusBadRequest, "INVALID_REQUEST"
+	}
+	c.JSON(status, ErrorResponse{
+		Error:   message,
+		Message: err.Error(),
+		Code:    code,
+	})
+}
@@ -47,6 +47,13 @@ const (
 	EventKnowledgeDeleted  AuditEventType = "KNOWLEDGE_DELETED"
 	EventKnowledgeAccessed AuditEventType = "KNOWLEDGE_ACCESSED"
 
+	// Records management
+	EventLegalHoldPlaced   AuditEventType = "LEGAL_HOLD_PLACED"
+	EventLegalHoldChanged  AuditEventType = "LEGAL_HOLD_CHANGED"
+	EventLegalHoldReleased AuditEventType = "LEGAL_HOLD_RELEASED"
+	EventDeletionBlocked   AuditEventType = "DELETION_BLOCKED"
+	EventRecordDisposed    AuditEventType = "RECORD_DISPOSED"
+
 	// System operations
 	EventSystemStartup        AuditEventType = "SYSTEM_STARTUP"
 	EventSystemShutdown       AuditEventType = "SYSTEM_SHUTDOWN"
@@ -3,6 +3,7 @@ package auth
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"ai-government-consultant/internal/models"
@@ -11,6 +12,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // PasswordHasher defines the interface for password hashing services
@@ -417,6 +419,35 @@ func (a *AuthService) DisableMFA(ctx context.Context, userID primitive.ObjectID,
 
 // Helper methods
 
+// SetUserCompartments replaces the compartments a user is read into. Compartments are only
+// assigned here, by an administrator, never at registration.
+func (a *AuthService) SetUserCompartments(ctx context.Context, userID primitive.ObjectID, compartments []string) (*models.User, error) {
+	seen := make(map[string]bool, len(compartments))
+	cleaned := []string{}
+	for _, compartment := range compartments {
+		compartment = strings.TrimSpace(compartment)
+		if compartment == "" || seen[compartment] {
+			continue
+		}
+		seen[compartment] = true
+		cleaned = append(cleaned, compartment)
+	}
+
+	var user models.User
+	err := a.userCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{"compartments": cleaned, "updated_at": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, models.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user compartments: %w", err)
+	}
+	return &user, nil
+}
+
 func (a *AuthService) getUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
 	err := a.userCollection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
@@ -0,0 +1,235 @@
+// Package citation recognizes references to federal law and policy (U.S. Code sections, CFR
+// parts and sections, OMB memoranda and circulars, and executive orders), normalizes them into
+// canonical IDs and indexes which documents and knowledge items cite them.
+package citation
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Authorities a citation can refer to
+const (
+	AuthorityUSC = "usc"
+	AuthorityCFR = "cfr"
+	AuthorityOMB = "omb"
+	AuthorityEO  = "eo"
+)
+
+// Citation is one reference found in text
+type Citation struct {
+	ID         string  `json:"id"`        // canonical form, e.g. "5 U.S.C. 552(a)" or "2 CFR 200.318"
+	Authority  string  `json:"authority"` // "usc", "cfr", "omb" or "eo"
+	Text       string  `json:"text"`      // the reference as written
+	Start      int     `json:"start"`
+	End        int     `json:"end"`
+	Confidence float64 `json:"confidence"`
+}
+
+// citationPattern recognizes one way of writing a reference and builds its canonical ID from the match
+type citationPattern struct {
+	Authority  string
+	Pattern    *regexp.Regexp
+	Confidence float64
+	Canonical  func(groups []string) string
+}
+
+// patterns are listed from most to least specific; when matches overlap, the earlier pattern wins
+var patterns = []citationPattern{
+	{
+		// "section 552(b) of title 5, United States Code"
+		Authority:  AuthorityUSC,
+		Pattern:    regexp.MustCompile(`\b[Ss]ection\s+(\d+[a-z]?(?:-\d+[a-z]?)?)((?:\([A-Za-z0-9]{1,4}\))*)\s+of\s+[Tt]itle\s+(\d{1,2}),?\s+United\s+States\s+Code`),
+		Confidence: 0.95,
+		Canonical: func(g []string) string {
+			return g[3] + " U.S.C. " + g[1] + g[2]
+		},
+	},
+	{
+		// "5 U.S.C. § 552(a)(3)", "42 USC 1983"
+		Authority:  AuthorityUSC,
+		Pattern:    regexp.MustCompile(`\b(\d{1,2})\s*U\.?\s?S\.?\s?C\.?(?:A\.?|S\.?)?\s*(?:§§?\s*|[Ss]ec(?:tion|\.)\s*)?(\d+[a-z]?(?:-\d+[a-z]?)?)((?:\([A-Za-z0-9]{1,4}\))*)`),
+		Confidence: 0.95,
+		Canonical: func(g []string) string {
+			return g[1] + " U.S.C. " + g[2] + g[3]
+		},
+	},
+	{
+		// "2 CFR 200.318(c)(1)", "48 C.F.R. Part 52", "2 CFR § 200"
+		Authority:  AuthorityCFR,
+		Pattern:    regexp.MustCompile(`\b(\d{1,2})\s*C\.?\s?F\.?\s?R\.?\s*(?:§§?\s*|[Pp]arts?\s+|[Ss]ec(?:tion|\.)\s*)?(\d{1,4})(?:\.(\d+[a-z]?))?((?:\([A-Za-z0-9]{1,4}\))*)`),
+		Confidence: 0.95,
+		Canonical: func(g []string) string {
+			id := g[1] + " CFR " + g[2]
+			if g[3] != "" {
+				id += "." + g[3] + g[4]
+			}
+			return id
+		},
+	},
+	{
+		// "Executive Order 14028", "E.O. 13526", "EO 14110"
+		Authority:  AuthorityEO,
+		Pattern:    regexp.MustCompile(`\b(?:Executive\s+Order|Exec\.\s*Order|E\.\s?O\.|EO)\s*(?:No\.\s*)?(\d{4,5})\b`),
+		Confidence: 0.95,
+		Canonical: func(g []string) string {
+			return "EO " + g[1]
+		},
+	},
+	{
+		// "OMB Circular A-130", "Circular No. A-11"
+		Authority:  AuthorityOMB,
+		Pattern:    regexp.MustCompile(`\b(?:OMB\s+Circular|Circular|OMB)\s+(?:No\.\s*)?(A-\d{1,3})\b`),
+		Confidence: 0.90,
+		Canonical: func(g []string) string {
+			return "OMB " + g[1]
+		},
+	},
+	{
+		// "OMB M-21-31", "OMB Memorandum M-22-09", or a bare "M-21-31"
+		Authority:  AuthorityOMB,
+		Pattern:    regexp.MustCompile(`\b(?:OMB\s+(?:[Mm]emorandum\s+)?)?(M-\d{2}-\d{2,3})\b`),
+		Confidence: 0.85,
+		Canonical: func(g []string) string {
+			return "OMB " + g[1]
+		},
+	},
+}
+
+// titleOnlyPattern matches a whole title of the U.S. Code or CFR, such as "5 U.S.C." or "2 CFR"
+var titleOnlyPattern = regexp.MustCompile(`^(\d{1,2})\s*(?:(U\.?\s?S\.?\s?C\.?)|C\.?\s?F\.?\s?R\.?)$`)
+
+// Parse finds every citation in text, ordered by position
+func Parse(text string) []Citation {
+	var found []Citation
+	for _, pattern := range patterns {
+		for _, match := range pattern.Pattern.FindAllStringSubmatchIndex(text, -1) {
+			start, end := match[0], match[1]
+			if overlaps(found, start, end) {
+				continue
+			}
+
+			groups := make([]string, len(match)/2)
+			for i := range groups {
+				if match[2*i] >= 0 {
+					groups[i] = text[match[2*i]:match[2*i+1]]
+				}
+			}
+
+			found = append(found, Citation{
+				ID:         pattern.Canonical(groups),
+				Authority:  pattern.Authority,
+				Text:       text[start:end],
+				Start:      start,
+				End:        end,
+				Confidence: pattern.Confidence,
+			})
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Start < found[j].Start
+	})
+	return found
+}
+
+// Normalize returns the canonical ID of a single reference such as "2 C.F.R. § 200.318" or
+// "5 USC", for looking citations up. It reports false if ref is not one recognizable citation.
+func Normalize(ref string) (string, bool) {
+	ref = strings.TrimSpace(ref)
+	if match := titleOnlyPattern.FindStringSubmatch(ref); match != nil {
+		if match[2] != "" {
+			return match[1] + " U.S.C.", true
+		}
+		return match[1] + " CFR", true
+	}
+
+	citations := Parse(ref)
+	if len(citations) != 1 {
+		return "", false
+	}
+	return citations[0].ID, true
+}
+
+// Ancestors returns the canonical ID of every citation that contains id, from the whole title
+// down to id itself: "2 CFR 200.318(c)" gives "2 CFR", "2 CFR 200", "2 CFR 200.318" and
+// "2 CFR 200.318(c)". A document citing a section therefore also cites its part and title.
+func Ancestors(id string) []string {
+	var prefix, rest string
+	switch {
+	case strings.Contains(id, " U.S.C. "):
+		i := strings.Index(id, " U.S.C. ") + len(" U.S.C.")
+		prefix, rest = id[:i], id[i+1:]
+	case strings.Contains(id, " CFR "):
+		i := strings.Index(id, " CFR ") + len(" CFR")
+		prefix, rest = id[:i], id[i+1:]
+	default:
+		return []string{id}
+	}
+
+	ancestors := []string{prefix}
+	section := rest
+	if i := strings.Index(rest, "("); i >= 0 {
+		section = rest[:i]
+	}
+	if dot := strings.Index(section, "."); dot >= 0 {
+		// CFR part, then section
+		ancestors = append(ancestors, prefix+" "+section[:dot])
+	}
+	ancestors = append(ancestors, prefix+" "+section)
+	for i := len(section); i < len(rest); i++ {
+		if rest[i] == ')' {
+			ancestors = append(ancestors, prefix+" "+rest[:i+1])
+		}
+	}
+	return ancestors
+}
+
+// Keys returns the deduplicated ancestors of every citation ID, for storing alongside a
+// document or knowledge item so lookups at any level of the hierarchy use one index
+func Keys(ids []string) []string {
+	seen := make(map[string]bool)
+	keys := []string{}
+	for _, id := range ids {
+		for _, key := range Ancestors(id) {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// IDs returns the deduplicated canonical IDs of citations, in order of first appearance
+func IDs(citations []Citation) []string {
+	seen := make(map[string]bool)
+	ids := []string{}
+	for _, c := range citations {
+		if !seen[c.ID] {
+			seen[c.ID] = true
+			ids = append(ids, c.ID)
+		}
+	}
+	return ids
+}
+
+// Within reports whether the citation ID id falls under ref, the same or a broader citation
+func Within(id, ref string) bool {
+	for _, ancestor := range Ancestors(id) {
+		if ancestor == ref {
+			return true
+		}
+	}
+	return false
+}
+
+func overlaps(citations []Citation, start, end int) bool {
+	for _, c := range citations {
+		if start < c.End && c.Start < end {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package citation
+
+import (
+	"context"
+	"fmt"
+
+	"ai-government-consultant/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Index looks up the documents and knowledge items that cite a law or regulation. Both
+// collections store the ancestors of every citation in citation_keys, so a lookup for
+// "2 CFR 200" also finds citations of "2 CFR 200.318".
+type Index struct {
+	documents *mongo.Collection
+	knowledge *mongo.Collection
+}
+
+// CitingDocument is a document that cites the looked-up citation
+type CitingDocument struct {
+	ID             primitive.ObjectID            `json:"id"`
+	Name           string                        `json:"name"`
+	Title          *string                       `json:"title,omitempty"`
+	Classification models.SecurityClassification `json:"classification"`
+	Citations      []string                      `json:"citations"` // the document's citations that fall under the looked-up one
+}
+
+// CitingKnowledgeItem is a knowledge item that cites the looked-up citation
+type CitingKnowledgeItem struct {
+	ID        primitive.ObjectID   `json:"id"`
+	Title     string               `json:"title"`
+	Type      models.KnowledgeType `json:"type"`
+	Citations []string             `json:"citations"`
+}
+
+// Result lists everything that cites one citation
+type Result struct {
+	Citation       string                `json:"citation"`
+	Documents      []CitingDocument      `json:"documents"`
+	KnowledgeItems []CitingKnowledgeItem `json:"knowledge_items"`
+}
+
+// NewIndex creates a citation index over the documents and knowledge_items collections
+func NewIndex(db *mongo.Database) *Index {
+	return &Index{
+		documents: db.Collection("documents"),
+		knowledge: db.Collection("knowledge_items"),
+	}
+}
+
+// Find returns up to limit current documents and active knowledge items citing id or anything under it
+func (i *Index) Find(ctx context.Context, id string, limit int) (*Result, error) {
+	result := &Result{
+		Citation:       id,
+		Documents:      []CitingDocument{},
+		KnowledgeItems: []CitingKnowledgeItem{},
+	}
+
+	docCursor, err := i.documents.Find(ctx,
+		bson.M{
+			"citation_keys": id,
+			"superseded":    bson.M{"$ne": true},
+			"duplicate_of":  bson.M{"$exists": false},
+		},
+		options.Find().
+			SetSort(bson.D{{Key: "uploaded_at", Value: -1}}).
+			SetLimit(int64(limit)).
+			SetProjection(bson.M{"name": 1, "metadata.title": 1, "classification": 1, "citations": 1}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find citing documents: %w", err)
+	}
+	defer docCursor.Close(ctx)
+
+	for docCursor.Next(ctx) {
+		var doc models.Document
+		if err := docCursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to decode document: %w", err)
+		}
+		result.Documents = append(result.Documents, CitingDocument{
+			ID:             doc.ID,
+			Name:           doc.Name,
+			Title:          doc.Metadata.Title,
+			Classification: doc.Classification,
+			Citations:      within(doc.Citations, id),
+		})
+	}
+	if err := docCursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to find citing documents: %w", err)
+	}
+
+	itemCursor, err := i.knowledge.Find(ctx,
+		bson.M{"citation_keys": id, "is_active": true},
+		options.Find().
+			SetSort(bson.D{{Key: "updated_at", Value: -1}}).
+			SetLimit(int64(limit)).
+			SetProjection(bson.M{"title": 1, "type": 1, "citations": 1}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find citing knowledge items: %w", err)
+	}
+	defer itemCursor.Close(ctx)
+
+	for itemCursor.Next(ctx) {
+		var item models.KnowledgeItem
+		if err := itemCursor.Decode(&item); err != nil {
+			return nil, fmt.Errorf("failed to decode knowledge item: %w", err)
+		}
+		result.KnowledgeItems = append(result.KnowledgeItems, CitingKnowledgeItem{
+			ID:        item.ID,
+			Title:     item.Title,
+			Type:      item.Type,
+			Citations: within(item.Citations, id),
+		})
+	}
+	if err := itemCursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to find citing knowledge items: %w", err)
+	}
+
+	return result, nil
+}
+
+// within returns the citations that fall under ref
+func within(citations []string, ref string) []string {
+	matched := []string{}
+	for _, id := range citations {
+		if Within(id, ref) {
+			matched = append(matched, id)
+		}
+	}
+	return matched
+}
@@ -4,17 +4,25 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	AI       AIConfig
-	Research ResearchConfig
-	Security SecurityConfig
-	Logging  LoggingConfig
+	Server    ServerConfig
+	Database  DatabaseConfig
+	Redis     RedisConfig
+	AI        AIConfig
+	Index     VectorIndexConfig
+	Search    SearchConfig
+	Rerank    RerankConfig
+	Storage   StorageConfig
+	Queue     QueueConfig
+	Retention RetentionConfig
+	Connector ConnectorConfig
+	Research  ResearchConfig
+	Security  SecurityConfig
+	Logging   LoggingConfig
 }
 
 type ServerConfig struct {
@@ -38,9 +46,79 @@ type RedisConfig struct {
 }
 
 type AIConfig struct {
-	LLMProvider    string
-	LLMAPIKey      string
-	EmbeddingModel string
+	LLMProvider           string
+	LLMAPIKey             string
+	EmbeddingModel        string // "<provider>/<model>": gemini/text-embedding-004, openai/<model> or local
+	EmbeddingAPIURL       string // base URL of an OpenAI-compatible embeddings server
+	EmbeddingAPIKey       string // defaults to LLMAPIKey for Gemini models
+	EmbeddingDimension    int    // vector dimension of the local embedder
+	EmbeddingModelRefresh int    // seconds between reloads of the active model switched by migrations
+	ChunkSize             int
+	ChunkOverlap          int
+	ChunkHeadingAware     bool
+	SummaryProvider       string // "gemini" or "mock"; empty disables document summaries
+	SummaryModel          string
+	SummaryMaxInput       int
+}
+
+type VectorIndexConfig struct {
+	Enabled        bool   // serve vector search from in-process HNSW indexes instead of scanning MongoDB
+	Path           string // directory of the index snapshots; empty keeps them in memory only
+	SyncInterval   int    // seconds between syncs with MongoDB
+	M              int
+	EfConstruction int
+	EfSearch       int
+}
+
+type SearchConfig struct {
+	Fusion        string // "rrf" or "weighted"
+	RRFK          float64
+	LexicalWeight float64
+	VectorWeight  float64
+	Candidates    int // results taken from each of lexical and vector search before fusion
+}
+
+type RerankConfig struct {
+	Stages               []string // "llm", "cross_encoder" and "mmr", run in the order given; empty disables re-ranking
+	Candidates           int      // sources retrieved from each of documents and knowledge for re-ranking
+	LLMModel             string
+	LLMTopK              int
+	LLMMinScore          float64
+	CrossEncoderURL      string // text-embeddings-inference /rerank endpoint; empty scores word overlap offline
+	CrossEncoderAPIKey   string
+	CrossEncoderTopK     int
+	CrossEncoderMinScore float64
+	MMRLambda            float64
+	MMRTopK              int
+	MMRMinScore          float64
+}
+
+type StorageConfig struct {
+	Backend     string
+	Path        string
+	S3Endpoint  string
+	S3Bucket    string
+	S3Region    string
+	S3AccessKey string
+	S3SecretKey string
+	S3UseSSL    bool
+}
+
+type QueueConfig struct {
+	Workers     int
+	MaxAttempts int
+	BackoffBase int
+	BackoffMax  int
+	Lease       int
+}
+
+type RetentionConfig struct {
+	SweepInterval int // seconds between disposition sweeps
+	BatchSize     int
+}
+
+type ConnectorConfig struct {
+	DirectoryRoots []string // directories that directory connectors may sync from
 }
 
 type ResearchConfig struct {
@@ -91,9 +169,72 @@ func Load() (*Config, error) {
 			DB:       getEnvAsInt("REDIS_DB", 0),
 		},
 		AI: AIConfig{
-			LLMProvider:    getEnv("LLM_PROVIDER", "gemini"),
-			LLMAPIKey:      getEnv("LLM_API_KEY", ""),
-			EmbeddingModel: getEnv("EMBEDDING_MODEL", "text-embedding-004"),
+			LLMProvider:           getEnv("LLM_PROVIDER", "gemini"),
+			LLMAPIKey:             getEnv("LLM_API_KEY", ""),
+			EmbeddingModel:        getEnv("EMBEDDING_MODEL", "text-embedding-004"),
+			EmbeddingAPIURL:       getEnv("EMBEDDING_API_URL", ""),
+			EmbeddingAPIKey:       getEnv("EMBEDDING_API_KEY", ""),
+			EmbeddingDimension:    getEnvAsInt("EMBEDDING_DIMENSION", 384),
+			EmbeddingModelRefresh: getEnvAsInt("EMBEDDING_MODEL_REFRESH", 30),
+			ChunkSize:             getEnvAsInt("CHUNK_SIZE", 1500),
+			ChunkOverlap:          getEnvAsInt("CHUNK_OVERLAP", 200),
+			ChunkHeadingAware:     getEnvAsBool("CHUNK_HEADING_AWARE", true),
+			SummaryProvider:       getEnv("SUMMARY_PROVIDER", ""),
+			SummaryModel:          getEnv("SUMMARY_MODEL", "gemini-1.5-flash"),
+			SummaryMaxInput:       getEnvAsInt("SUMMARY_MAX_INPUT", 24000),
+		},
+		Index: VectorIndexConfig{
+			Enabled:        getEnvAsBool("VECTOR_INDEX_ENABLED", true),
+			Path:           getEnv("VECTOR_INDEX_PATH", "./data/vector-index"),
+			SyncInterval:   getEnvAsInt("VECTOR_INDEX_SYNC_INTERVAL", 300),
+			M:              getEnvAsInt("VECTOR_INDEX_M", 16),
+			EfConstruction: getEnvAsInt("VECTOR_INDEX_EF_CONSTRUCTION", 200),
+			EfSearch:       getEnvAsInt("VECTOR_INDEX_EF_SEARCH", 64),
+		},
+		Search: SearchConfig{
+			Fusion:        getEnv("HYBRID_FUSION", "rrf"),
+			RRFK:          getEnvAsFloat("HYBRID_RRF_K", 60),
+			LexicalWeight: getEnvAsFloat("HYBRID_LEXICAL_WEIGHT", 1),
+			VectorWeight:  getEnvAsFloat("HYBRID_VECTOR_WEIGHT", 1),
+			Candidates:    getEnvAsInt("HYBRID_CANDIDATES", 50),
+		},
+		Rerank: RerankConfig{
+			Stages:               getEnvAsList("RERANK_STAGES"),
+			Candidates:           getEnvAsInt("RERANK_CANDIDATES", 20),
+			LLMModel:             getEnv("RERANK_LLM_MODEL", "gemini-1.5-flash"),
+			LLMTopK:              getEnvAsInt("RERANK_LLM_TOP_K", 0),
+			LLMMinScore:          getEnvAsFloat("RERANK_LLM_MIN_SCORE", 0.3),
+			CrossEncoderURL:      getEnv("RERANK_CROSS_ENCODER_URL", ""),
+			CrossEncoderAPIKey:   getEnv("RERANK_CROSS_ENCODER_API_KEY", ""),
+			CrossEncoderTopK:     getEnvAsInt("RERANK_CROSS_ENCODER_TOP_K", 0),
+			CrossEncoderMinScore: getEnvAsFloat("RERANK_CROSS_ENCODER_MIN_SCORE", 0.1),
+			MMRLambda:            getEnvAsFloat("RERANK_MMR_LAMBDA", 0.7),
+			MMRTopK:              getEnvAsInt("RERANK_MMR_TOP_K", 0),
+			MMRMinScore:          getEnvAsFloat("RERANK_MMR_MIN_SCORE", -1),
+		},
+		Storage: StorageConfig{
+			Backend:     getEnv("BLOB_STORE_BACKEND", "gridfs"),
+			Path:        getEnv("BLOB_STORE_PATH", "./data/blobs"),
+			S3Endpoint:  getEnv("S3_ENDPOINT", ""),
+			S3Bucket:    getEnv("S3_BUCKET", "documents"),
+			S3Region:    getEnv("S3_REGION", "us-east-1"),
+			S3AccessKey: getEnv("S3_ACCESS_KEY", ""),
+			S3SecretKey: getEnv("S3_SECRET_KEY", ""),
+			S3UseSSL:    getEnvAsBool("S3_USE_SSL", false),
+		},
+		Queue: QueueConfig{
+			Workers:     getEnvAsInt("JOB_WORKERS", 4),
+			MaxAttempts: getEnvAsInt("JOB_MAX_ATTEMPTS", 5),
+			BackoffBase: getEnvAsInt("JOB_BACKOFF_BASE", 10),
+			BackoffMax:  getEnvAsInt("JOB_BACKOFF_MAX", 1800),
+			Lease:       getEnvAsInt("JOB_LEASE", 120),
+		},
+		Retention: RetentionConfig{
+			SweepInterval: getEnvAsInt("RETENTION_SWEEP_INTERVAL", 3600),
+			BatchSize:     getEnvAsInt("RETENTION_DISPOSITION_BATCH", 100),
+		},
+		Connector: ConnectorConfig{
+			DirectoryRoots: getEnvAsList("CONNECTOR_DIRECTORY_ROOTS"),
 		},
 		Research: ResearchConfig{
 			NewsAPIKey:            getEnv("NEWS_API_KEY", ""),
@@ -172,3 +313,13 @@ func getEnvAsFloat(key string, defaultValue float64) float64 {
 	}
 	return defaultValue
 }
+
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
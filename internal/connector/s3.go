@@ -0,0 +1,49 @@
+package connector
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"ai-government-consultant/internal/storage"
+)
+
+// s3Source reads the objects of an S3-compatible bucket under a prefix, which is treated as a
+// folder. Keys ending in "/" are folder markers and are ignored.
+type s3Source struct {
+	bucket *storage.S3Store
+	prefix string
+}
+
+func newS3Source(bucket *storage.S3Store, prefix string) *s3Source {
+	prefix = strings.TrimPrefix(prefix, "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &s3Source{bucket: bucket, prefix: prefix}
+}
+
+func (s *s3Source) List(ctx context.Context) ([]RemoteFile, error) {
+	objects, err := s.bucket.List(ctx, s.prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]RemoteFile, 0, len(objects))
+	for _, object := range objects {
+		if strings.HasSuffix(object.Key, "/") {
+			continue
+		}
+		files = append(files, RemoteFile{
+			Path:    strings.TrimPrefix(object.Key, s.prefix),
+			Size:    object.Size,
+			ModTime: object.LastModified,
+			ETag:    object.ETag,
+		})
+	}
+	return files, nil
+}
+
+func (s *s3Source) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	return s.bucket.Open(ctx, s.prefix+path)
+}
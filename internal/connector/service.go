@@ -0,0 +1,369 @@
+// Package connector syncs documents from storage outside the system: a directory on the server,
+// a WebDAV share or an S3-compatible bucket. Each connector is synced periodically on the job
+// queue; new files become documents, changed files new versions of them, and the state of every
+// file and a report of every sync are kept for administrators.
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ai-government-consultant/internal/document"
+	"ai-government-consultant/internal/models"
+	"ai-government-consultant/internal/queue"
+	"ai-government-consultant/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SyncJob is the job type that syncs one connector. Scheduled syncs are keyed by the connector ID
+// and the time they are due, "<id>@<RFC3339 time>"; syncs requested through the API by the
+// connector ID alone.
+const SyncJob = "connector.sync"
+
+// syncLease is how long a sync may hold a connector before another sync may take it over
+const syncLease = 6 * time.Hour
+
+var (
+	// ErrNotFound is returned when a connector or sync run does not exist
+	ErrNotFound = errors.New("not found")
+
+	// ErrSyncInProgress is returned when requesting a sync of a connector that is being synced
+	ErrSyncInProgress = errors.New("connector is already syncing")
+
+	// ErrDirectoryNotAllowed is returned when a directory connector's path is missing or outside
+	// the directories connectors may sync
+	ErrDirectoryNotAllowed = errors.New("directory is not allowed")
+)
+
+// Config configures connectors
+type Config struct {
+	DirectoryRoots []string // directories that directory connectors may sync from, with their subdirectories
+}
+
+// Service manages connectors and syncs them into documents
+type Service struct {
+	connectors *mongo.Collection
+	files      *mongo.Collection
+	runs       *mongo.Collection
+	documents  *document.Service
+	jobs       *queue.Queue
+	logger     logger.Logger
+	config     Config
+}
+
+// NewService creates a connector service that stores documents through documents and runs its
+// syncs on the jobs queue
+func NewService(db *mongo.Database, documents *document.Service, jobs *queue.Queue, log logger.Logger, config *Config) *Service {
+	s := &Service{
+		connectors: db.Collection("connectors"),
+		files:      db.Collection("connector_files"),
+		runs:       db.Collection("connector_runs"),
+		documents:  documents,
+		jobs:       jobs,
+		logger:     log,
+	}
+	if config != nil {
+		s.config = *config
+	}
+	jobs.Handle(SyncJob, s.syncJob)
+	return s
+}
+
+// Start schedules the next sync of every enabled connector that has none waiting, such as
+// connectors whose scheduled job was lost
+func (s *Service) Start(ctx context.Context) error {
+	cursor, err := s.connectors.Find(ctx, bson.M{"enabled": true})
+	if err != nil {
+		return fmt.Errorf("failed to find connectors: %w", err)
+	}
+	var connectors []models.Connector
+	if err := cursor.All(ctx, &connectors); err != nil {
+		return fmt.Errorf("failed to decode connectors: %w", err)
+	}
+
+	for i := range connectors {
+		connector := &connectors[i]
+		if connector.NextSyncAt != nil {
+			open, err := s.jobs.HasOpenJob(ctx, SyncJob, scheduledKey(connector.ID, *connector.NextSyncAt))
+			if err != nil {
+				return err
+			}
+			if open {
+				continue
+			}
+		}
+		if err := s.scheduleSync(ctx, connector, time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Create creates a connector and schedules its first sync straight away if it is enabled
+func (s *Service) Create(ctx context.Context, connector *models.Connector) error {
+	now := time.Now()
+	connector.ID = primitive.NewObjectID()
+	connector.Name = strings.TrimSpace(connector.Name)
+	connector.CreatedAt = now
+	connector.UpdatedAt = now
+	if err := s.validate(connector); err != nil {
+		return err
+	}
+
+	if _, err := s.connectors.InsertOne(ctx, connector); err != nil {
+		return fmt.Errorf("failed to create connector: %w", err)
+	}
+	if connector.Enabled {
+		return s.scheduleSync(ctx, connector, now)
+	}
+	return nil
+}
+
+// Get returns a connector
+func (s *Service) Get(ctx context.Context, id primitive.ObjectID) (*models.Connector, error) {
+	var connector models.Connector
+	if err := s.connectors.FindOne(ctx, bson.M{"_id": id}).Decode(&connector); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to find connector: %w", err)
+	}
+	return &connector, nil
+}
+
+// List returns every connector, by name
+func (s *Service) List(ctx context.Context) ([]models.Connector, error) {
+	cursor, err := s.connectors.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find connectors: %w", err)
+	}
+	connectors := []models.Connector{}
+	if err := cursor.All(ctx, &connectors); err != nil {
+		return nil, fmt.Errorf("failed to decode connectors: %w", err)
+	}
+	return connectors, nil
+}
+
+// Update replaces the settings of a connector. Secrets left empty keep their current value.
+// Pointing the connector at a different source clears its file states, so the new source is
+// synced from scratch and documents from the old one are left alone.
+func (s *Service) Update(ctx context.Context, id primitive.ObjectID, changes *models.Connector) (*models.Connector, error) {
+	connector, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if changes.Settings.Password == "" && changes.Settings.URL == connector.Settings.URL {
+		changes.Settings.Password = connector.Settings.Password
+	}
+	if changes.Settings.SecretKey == "" && changes.Settings.AccessKey == connector.Settings.AccessKey {
+		changes.Settings.SecretKey = connector.Settings.SecretKey
+	}
+	moved := changes.Type != connector.Type || sourceLocation(changes.Settings) != sourceLocation(connector.Settings)
+
+	connector.Name = strings.TrimSpace(changes.Name)
+	connector.Type = changes.Type
+	connector.Settings = changes.Settings
+	connector.IntervalMinutes = changes.IntervalMinutes
+	connector.Enabled = changes.Enabled
+	connector.DeletePolicy = changes.DeletePolicy
+	connector.Category = changes.Category
+	connector.Tags = changes.Tags
+	connector.Classification = changes.Classification
+	connector.DuplicatePolicy = changes.DuplicatePolicy
+	connector.UpdatedAt = time.Now()
+	if err := s.validate(connector); err != nil {
+		return nil, err
+	}
+
+	_, err = s.connectors.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"name":             connector.Name,
+		"type":             connector.Type,
+		"settings":         connector.Settings,
+		"interval_minutes": connector.IntervalMinutes,
+		"enabled":          connector.Enabled,
+		"delete_policy":    connector.DeletePolicy,
+		"category":         connector.Category,
+		"tags":             connector.Tags,
+		"classification":   connector.Classification,
+		"duplicate_policy": connector.DuplicatePolicy,
+		"updated_at":       connector.UpdatedAt,
+	}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update connector: %w", err)
+	}
+	if moved {
+		if _, err := s.files.DeleteMany(ctx, bson.M{"connector_id": id}); err != nil {
+			return nil, fmt.Errorf("failed to reset connector files: %w", err)
+		}
+	}
+
+	// The next sync follows the new interval from the last one
+	next := time.Now()
+	if connector.LastSyncAt != nil && !moved {
+		if due := connector.LastSyncAt.Add(connector.Interval()); due.After(next) {
+			next = due
+		}
+	}
+	if err := s.scheduleSync(ctx, connector, next); err != nil {
+		return nil, err
+	}
+	return connector, nil
+}
+
+// Delete deletes a connector with its file states and sync reports. Its documents are kept.
+func (s *Service) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := s.connectors.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete connector: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	if _, err := s.files.DeleteMany(ctx, bson.M{"connector_id": id}); err != nil {
+		return fmt.Errorf("failed to delete connector files: %w", err)
+	}
+	if _, err := s.runs.DeleteMany(ctx, bson.M{"connector_id": id}); err != nil {
+		return fmt.Errorf("failed to delete connector runs: %w", err)
+	}
+	return nil
+}
+
+// Sync queues a sync of a connector now, whether or not it is enabled
+func (s *Service) Sync(ctx context.Context, id primitive.ObjectID) error {
+	connector, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if connector.SyncingSince != nil && time.Since(*connector.SyncingSince) < syncLease {
+		return ErrSyncInProgress
+	}
+
+	open, err := s.jobs.HasOpenJob(ctx, SyncJob, id.Hex())
+	if err != nil {
+		return err
+	}
+	if open {
+		return nil
+	}
+	if _, err := s.jobs.Enqueue(ctx, SyncJob, id.Hex()); err != nil {
+		return fmt.Errorf("failed to queue connector sync: %w", err)
+	}
+	return nil
+}
+
+// ListFiles returns the sync states of a connector's files by path, optionally only those with
+// a status, and how many there are
+func (s *Service) ListFiles(ctx context.Context, id primitive.ObjectID, status string, failed bool, limit, skip int) ([]models.ConnectorFile, int64, error) {
+	filter := bson.M{"connector_id": id}
+	if status != "" {
+		filter["status"] = status
+	}
+	if failed {
+		filter["error"] = bson.M{"$exists": true}
+	}
+
+	total, err := s.files.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count connector files: %w", err)
+	}
+	cursor, err := s.files.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "path", Value: 1}}).
+		SetSkip(int64(skip)).
+		SetLimit(int64(limit)))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find connector files: %w", err)
+	}
+	files := []models.ConnectorFile{}
+	if err := cursor.All(ctx, &files); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode connector files: %w", err)
+	}
+	return files, total, nil
+}
+
+// ListRuns returns a connector's sync reports, newest first, and how many there are
+func (s *Service) ListRuns(ctx context.Context, id primitive.ObjectID, limit, skip int) ([]models.ConnectorSyncRun, int64, error) {
+	filter := bson.M{"connector_id": id}
+	total, err := s.runs.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count connector runs: %w", err)
+	}
+	cursor, err := s.runs.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "started_at", Value: -1}}).
+		SetSkip(int64(skip)).
+		SetLimit(int64(limit)))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find connector runs: %w", err)
+	}
+	runs := []models.ConnectorSyncRun{}
+	if err := cursor.All(ctx, &runs); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode connector runs: %w", err)
+	}
+	return runs, total, nil
+}
+
+// GetRun returns one sync report of a connector
+func (s *Service) GetRun(ctx context.Context, id, runID primitive.ObjectID) (*models.ConnectorSyncRun, error) {
+	var run models.ConnectorSyncRun
+	if err := s.runs.FindOne(ctx, bson.M{"_id": runID, "connector_id": id}).Decode(&run); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to find connector run: %w", err)
+	}
+	return &run, nil
+}
+
+// validate checks a connector's settings and, for directory connectors, that the directory may
+// be synced
+func (s *Service) validate(connector *models.Connector) error {
+	if err := connector.Validate(); err != nil {
+		return err
+	}
+	if connector.Type == models.ConnectorTypeDirectory {
+		if _, err := s.allowedDirectory(connector.Settings.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scheduleSync records when a connector next syncs and queues the sync for then. A disabled
+// connector has no next sync. Jobs of earlier schedules find they no longer match and do nothing.
+func (s *Service) scheduleSync(ctx context.Context, connector *models.Connector, at time.Time) error {
+	if !connector.Enabled {
+		connector.NextSyncAt = nil
+		_, err := s.connectors.UpdateOne(ctx, bson.M{"_id": connector.ID}, bson.M{"$unset": bson.M{"next_sync_at": ""}})
+		if err != nil {
+			return fmt.Errorf("failed to update connector schedule: %w", err)
+		}
+		return nil
+	}
+
+	at = at.UTC().Truncate(time.Second)
+	connector.NextSyncAt = &at
+	if _, err := s.connectors.UpdateOne(ctx, bson.M{"_id": connector.ID}, bson.M{"$set": bson.M{"next_sync_at": at}}); err != nil {
+		return fmt.Errorf("failed to update connector schedule: %w", err)
+	}
+	if _, err := s.jobs.EnqueueAt(ctx, SyncJob, scheduledKey(connector.ID, at), at); err != nil {
+		return fmt.Errorf("failed to schedule connector sync: %w", err)
+	}
+	return nil
+}
+
+// scheduledKey is the job key of a connector's sync scheduled at a time
+func scheduledKey(id primitive.ObjectID, at time.Time) string {
+	return id.Hex() + "@" + at.UTC().Format(time.RFC3339)
+}
+
+// sourceLocation identifies the place a connector's settings point at, ignoring credentials
+func sourceLocation(settings models.ConnectorSettings) string {
+	return strings.Join([]string{settings.Path, settings.URL, settings.Endpoint, settings.Bucket, settings.Prefix}, "\x00")
+}
@@ -0,0 +1,89 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ai-government-consultant/internal/models"
+	"ai-government-consultant/internal/storage"
+)
+
+// RemoteFile is a file found at a connector's source
+type RemoteFile struct {
+	Path    string // relative to the source's root, "/"-separated
+	Size    int64
+	ModTime time.Time
+	ETag    string // set by sources that report one
+}
+
+// Source lists and reads the files a connector syncs
+type Source interface {
+	// List returns every file under the source's root. It fails rather than returning a partial
+	// listing, since files missing from the listing are treated as deleted.
+	List(ctx context.Context) ([]RemoteFile, error)
+	// Open reads the file at path, as returned by List
+	Open(ctx context.Context, path string) (io.ReadCloser, error)
+}
+
+// newSource creates the source a connector reads from
+func (s *Service) newSource(connector *models.Connector) (Source, error) {
+	settings := connector.Settings
+	switch connector.Type {
+	case models.ConnectorTypeDirectory:
+		root, err := s.allowedDirectory(settings.Path)
+		if err != nil {
+			return nil, err
+		}
+		return &directorySource{root: root}, nil
+	case models.ConnectorTypeWebDAV:
+		return newWebDAVSource(settings.URL, settings.Username, settings.Password)
+	case models.ConnectorTypeS3:
+		bucket, err := storage.NewS3Bucket(&storage.S3Config{
+			Endpoint:  settings.Endpoint,
+			Bucket:    settings.Bucket,
+			Region:    settings.Region,
+			AccessKey: settings.AccessKey,
+			SecretKey: settings.SecretKey,
+			UseSSL:    settings.UseSSL,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return newS3Source(bucket, settings.Prefix), nil
+	default:
+		return nil, models.ErrConnectorTypeInvalid
+	}
+}
+
+// allowedDirectory resolves a directory connector's path, following symbolic links, and checks
+// that it lies within one of the configured directory roots
+func (s *Service) allowedDirectory(dir string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(filepath.Clean(dir))
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrDirectoryNotAllowed, err.Error())
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrDirectoryNotAllowed, err.Error())
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%w: %s is not a directory", ErrDirectoryNotAllowed, dir)
+	}
+
+	for _, root := range s.config.DirectoryRoots {
+		root, err := filepath.EvalSymlinks(filepath.Clean(root))
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(root, resolved)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return resolved, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s is outside the directories connectors may sync", ErrDirectoryNotAllowed, dir)
+}
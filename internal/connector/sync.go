@@ -0,0 +1,373 @@
+package connector
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"ai-government-consultant/internal/document"
+	"ai-government-consultant/internal/models"
+	"ai-government-consultant/internal/queue"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxRunErrors bounds the file errors kept in a sync report; every failure still counts
+const maxRunErrors = 100
+
+// fileOutcome is what a sync did with one file
+type fileOutcome int
+
+const (
+	fileUnchanged fileOutcome = iota
+	fileAdded
+	fileUpdated
+	fileSkipped
+)
+
+// syncJob syncs the connector named by the job key. A scheduled job that no longer matches the
+// connector's next sync, because the connector was rescheduled or disabled, does nothing.
+func (s *Service) syncJob(ctx context.Context, job *queue.Job) error {
+	idHex, scheduledAt, scheduled := strings.Cut(job.Key, "@")
+	id, err := primitive.ObjectIDFromHex(idHex)
+	if err != nil {
+		return fmt.Errorf("invalid connector ID: %w", err)
+	}
+
+	connector, err := s.Get(ctx, id)
+	if err == ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	trigger := "manual"
+	if scheduled {
+		trigger = "scheduled"
+		if !connector.Enabled || connector.NextSyncAt == nil ||
+			connector.NextSyncAt.UTC().Format(time.RFC3339) != scheduledAt {
+			return nil
+		}
+	}
+
+	// Only one sync of a connector runs at a time; the one holding it schedules the next
+	now := time.Now()
+	claim, err := s.connectors.UpdateOne(ctx, bson.M{
+		"_id": id,
+		"$or": bson.A{
+			bson.M{"syncing_since": bson.M{"$exists": false}},
+			bson.M{"syncing_since": bson.M{"$lt": now.Add(-syncLease)}},
+		},
+	}, bson.M{"$set": bson.M{"syncing_since": now}})
+	if err != nil {
+		return fmt.Errorf("failed to claim connector: %w", err)
+	}
+	if claim.MatchedCount == 0 {
+		return nil
+	}
+
+	run := s.sync(ctx, connector, trigger)
+
+	set := bson.M{
+		"last_sync_at":     *run.FinishedAt,
+		"last_sync_status": run.Status,
+		"last_run_id":      run.ID,
+	}
+	unset := bson.M{"syncing_since": ""}
+	if run.Error != "" {
+		set["last_error"] = run.Error
+	} else {
+		unset["last_error"] = ""
+	}
+	if _, err := s.connectors.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set, "$unset": unset}); err != nil {
+		return fmt.Errorf("failed to update connector: %w", err)
+	}
+
+	// Settings may have changed during the sync, so the schedule follows the stored connector
+	current, err := s.Get(ctx, id)
+	if err == ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.scheduleSync(ctx, current, run.FinishedAt.Add(current.Interval()))
+}
+
+// sync lists the connector's source and brings documents in line with it: new files become
+// documents, changed files new versions, and files gone from the source are handled by the
+// delete policy. It returns the sync's report, which is stored as it goes.
+func (s *Service) sync(ctx context.Context, connector *models.Connector, trigger string) *models.ConnectorSyncRun {
+	run := &models.ConnectorSyncRun{
+		ID:          primitive.NewObjectID(),
+		ConnectorID: connector.ID,
+		Trigger:     trigger,
+		Status:      models.ConnectorSyncRunning,
+		StartedAt:   time.Now(),
+	}
+	if _, err := s.runs.InsertOne(ctx, run); err != nil {
+		s.logger.Error("Failed to record connector sync", err, map[string]interface{}{
+			"connector_id": connector.ID.Hex(),
+		})
+	}
+
+	if err := s.syncFiles(ctx, connector, run); err != nil {
+		run.Status = models.ConnectorSyncFailed
+		run.Error = err.Error()
+	} else if run.Failed > 0 {
+		run.Status = models.ConnectorSyncCompletedWithErrors
+	} else {
+		run.Status = models.ConnectorSyncCompleted
+	}
+	finished := time.Now()
+	run.FinishedAt = &finished
+
+	if _, err := s.runs.ReplaceOne(ctx, bson.M{"_id": run.ID}, run, options.Replace().SetUpsert(true)); err != nil {
+		s.logger.Error("Failed to record connector sync", err, map[string]interface{}{
+			"connector_id": connector.ID.Hex(),
+		})
+	}
+	s.logger.Info("Synced connector", map[string]interface{}{
+		"connector_id": connector.ID.Hex(),
+		"status":       run.Status,
+		"added":        run.Added,
+		"updated":      run.Updated,
+		"deleted":      run.Deleted,
+		"failed":       run.Failed,
+	})
+	return run
+}
+
+// syncFiles does the work of a sync, counting each file in run. It fails when the source cannot
+// be listed or the sync is cancelled; a file that cannot be synced is recorded and skipped.
+func (s *Service) syncFiles(ctx context.Context, connector *models.Connector, run *models.ConnectorSyncRun) error {
+	source, err := s.newSource(connector)
+	if err != nil {
+		return err
+	}
+	remote, err := source.List(ctx)
+	if err != nil {
+		return err
+	}
+	sort.Slice(remote, func(i, j int) bool {
+		return remote[i].Path < remote[j].Path
+	})
+	run.Listed = len(remote)
+
+	cursor, err := s.files.Find(ctx, bson.M{"connector_id": connector.ID})
+	if err != nil {
+		return fmt.Errorf("failed to find connector files: %w", err)
+	}
+	var stored []models.ConnectorFile
+	if err := cursor.All(ctx, &stored); err != nil {
+		return fmt.Errorf("failed to decode connector files: %w", err)
+	}
+	states := make(map[string]*models.ConnectorFile, len(stored))
+	for i := range stored {
+		states[stored[i].Path] = &stored[i]
+	}
+
+	seen := make(map[string]bool, len(remote))
+	for _, file := range remote {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		seen[file.Path] = true
+		// Stored times keep millisecond precision
+		file.ModTime = file.ModTime.UTC().Truncate(time.Millisecond)
+
+		outcome, err := s.syncFile(ctx, connector, source, file, states[file.Path])
+		if err != nil {
+			s.recordFailure(ctx, connector, run, file.Path, err)
+			continue
+		}
+		switch outcome {
+		case fileAdded:
+			run.Added++
+		case fileUpdated:
+			run.Updated++
+		case fileSkipped:
+			run.Skipped++
+		default:
+			run.Unchanged++
+		}
+	}
+
+	for filePath, state := range states {
+		if seen[filePath] || state.Status == models.ConnectorFileDeleted {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.removeFile(ctx, connector, state); err != nil {
+			s.recordFailure(ctx, connector, run, filePath, err)
+			continue
+		}
+		run.Deleted++
+	}
+	return nil
+}
+
+// syncFile brings one file's document in line with the source. Size, modification time and ETag
+// decide whether the file is read at all; a file that was touched but whose checksum is unchanged
+// is not stored again.
+func (s *Service) syncFile(ctx context.Context, connector *models.Connector, source Source, file RemoteFile, state *models.ConnectorFile) (fileOutcome, error) {
+	name := path.Base(file.Path)
+	if !document.SupportedFormats[strings.ToLower(path.Ext(name))] || file.Size == 0 {
+		return fileSkipped, nil
+	}
+	if file.Size > document.MaxDocumentSize {
+		return fileUnchanged, fmt.Errorf("file size exceeds maximum allowed size of %d bytes", document.MaxDocumentSize)
+	}
+	if state != nil && state.Status == models.ConnectorFileActive && state.Error == "" &&
+		state.Size == file.Size && state.ModTime.Equal(file.ModTime) && state.ETag == file.ETag {
+		return fileUnchanged, nil
+	}
+
+	data, err := readFile(ctx, source, file.Path)
+	if err != nil {
+		return fileUnchanged, err
+	}
+	checksum := fmt.Sprintf("%x", sha256.Sum256(data))
+
+	outcome := fileUnchanged
+	var documentID *primitive.ObjectID
+	if state != nil && state.DocumentID != nil {
+		documentID = state.DocumentID
+	}
+	if documentID == nil || state.Checksum != checksum {
+		sourceFile := document.SourceFile{
+			Name:            name,
+			Data:            data,
+			Metadata:        fileMetadata(connector, file.Path),
+			Declared:        connector.Classification,
+			DuplicatePolicy: connector.DuplicatePolicy,
+			UploadedBy:      connector.CreatedBy,
+		}
+
+		var doc *models.Document
+		if documentID != nil {
+			doc, err = s.documents.IngestVersion(ctx, *documentID, sourceFile)
+			outcome = fileUpdated
+		}
+		// A document deleted since the last sync is created again
+		if documentID == nil || errors.Is(err, document.ErrDocumentNotFound) {
+			doc, err = s.documents.IngestFile(ctx, sourceFile)
+			outcome = fileAdded
+		}
+		if err != nil {
+			return fileUnchanged, err
+		}
+		documentID = &doc.ID
+	}
+
+	_, err = s.files.UpdateOne(ctx,
+		bson.M{"connector_id": connector.ID, "path": file.Path},
+		bson.M{
+			"$set": bson.M{
+				"size":        file.Size,
+				"mod_time":    file.ModTime,
+				"etag":        file.ETag,
+				"checksum":    checksum,
+				"document_id": documentID,
+				"status":      models.ConnectorFileActive,
+				"synced_at":   time.Now(),
+			},
+			"$unset": bson.M{"error": "", "deleted_at": ""},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fileUnchanged, fmt.Errorf("failed to record file state: %w", err)
+	}
+	return outcome, nil
+}
+
+// removeFile handles a file gone from the source. With the delete policy, every version of its
+// document is deleted; a document under legal hold stays, and the removal is retried next sync.
+func (s *Service) removeFile(ctx context.Context, connector *models.Connector, state *models.ConnectorFile) error {
+	if connector.DeletePolicy == models.ConnectorDeleteRemove && state.DocumentID != nil {
+		err := s.documents.DeleteSeries(ctx, *state.DocumentID, connector.CreatedBy)
+		if err != nil && !errors.Is(err, document.ErrDocumentNotFound) {
+			return err
+		}
+	}
+
+	now := time.Now()
+	_, err := s.files.UpdateOne(ctx, bson.M{"_id": state.ID}, bson.M{
+		"$set":   bson.M{"status": models.ConnectorFileDeleted, "deleted_at": now, "synced_at": now},
+		"$unset": bson.M{"error": ""},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record file state: %w", err)
+	}
+	return nil
+}
+
+// recordFailure counts a file that could not be synced in the run and keeps the error on the
+// file's state, which makes the next sync read the file again
+func (s *Service) recordFailure(ctx context.Context, connector *models.Connector, run *models.ConnectorSyncRun, filePath string, err error) {
+	run.Failed++
+	if len(run.Errors) < maxRunErrors {
+		run.Errors = append(run.Errors, models.ConnectorSyncError{Path: filePath, Error: err.Error()})
+	}
+
+	_, updateErr := s.files.UpdateOne(ctx,
+		bson.M{"connector_id": connector.ID, "path": filePath},
+		bson.M{
+			"$set":         bson.M{"error": err.Error(), "synced_at": time.Now()},
+			"$setOnInsert": bson.M{"status": models.ConnectorFileActive},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if updateErr != nil {
+		s.logger.Error("Failed to record connector file error", updateErr, map[string]interface{}{
+			"connector_id": connector.ID.Hex(),
+			"path":         filePath,
+		})
+	}
+}
+
+// readFile reads a file from the source, up to the largest document size accepted
+func readFile(ctx context.Context, source Source, filePath string) ([]byte, error) {
+	rc, err := source.Open(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, document.MaxDocumentSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if len(data) > document.MaxDocumentSize {
+		return nil, fmt.Errorf("file size exceeds maximum allowed size of %d bytes", document.MaxDocumentSize)
+	}
+	return data, nil
+}
+
+// fileMetadata is the metadata given to documents synced from a file
+func fileMetadata(connector *models.Connector, filePath string) models.DocumentMetadata {
+	metadata := models.DocumentMetadata{
+		Category: connector.Category,
+		Tags:     append([]string{}, connector.Tags...),
+		CustomFields: map[string]interface{}{
+			"connector_id": connector.ID.Hex(),
+			"source_path":  filePath,
+		},
+	}
+	if metadata.Category == "" {
+		metadata.Category = models.DocumentCategoryGeneral
+	}
+	return metadata
+}
@@ -0,0 +1,185 @@
+package connector
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxWebDAVCollections bounds the collections walked in one listing
+	maxWebDAVCollections = 10000
+
+	// maxPropfindResponse bounds the size of one PROPFIND response (32MB)
+	maxPropfindResponse = 32 * 1024 * 1024
+)
+
+// propfindBody requests the properties needed to list a collection
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/><d:getcontentlength/><d:getlastmodified/><d:getetag/></d:prop></d:propfind>`
+
+// webdavSource reads files from a WebDAV collection. Collections are listed one level at a time,
+// since many servers refuse "Depth: infinity".
+type webdavSource struct {
+	base       *url.URL
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+// davMultistatus is the body of a PROPFIND response
+type davMultistatus struct {
+	Responses []struct {
+		Href      string `xml:"DAV: href"`
+		Propstats []struct {
+			Prop struct {
+				ResourceType struct {
+					Collection *struct{} `xml:"DAV: collection"`
+				} `xml:"DAV: resourcetype"`
+				ContentLength string `xml:"DAV: getcontentlength"`
+				LastModified  string `xml:"DAV: getlastmodified"`
+				ETag          string `xml:"DAV: getetag"`
+			} `xml:"DAV: prop"`
+			Status string `xml:"DAV: status"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+func newWebDAVSource(rawURL, username, password string) (*webdavSource, error) {
+	base, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WebDAV URL: %w", err)
+	}
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+	}
+	base.RawPath = ""
+	return &webdavSource{
+		base:       base,
+		username:   username,
+		password:   password,
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+func (w *webdavSource) List(ctx context.Context) ([]RemoteFile, error) {
+	var files []RemoteFile
+	pending := []string{""}
+	visited := map[string]bool{"": true}
+	for len(pending) > 0 {
+		if len(visited) > maxWebDAVCollections {
+			return nil, fmt.Errorf("WebDAV share has more than %d collections", maxWebDAVCollections)
+		}
+		dir := pending[0]
+		pending = pending[1:]
+
+		status, err := w.propfind(ctx, dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, response := range status.Responses {
+			rel, ok := w.relativePath(response.Href)
+			if !ok || rel == dir || strings.TrimSuffix(rel, "/") == strings.TrimSuffix(dir, "/") {
+				continue
+			}
+			for _, propstat := range response.Propstats {
+				if !strings.Contains(propstat.Status, " 200") {
+					continue
+				}
+				prop := propstat.Prop
+				if prop.ResourceType.Collection != nil {
+					rel = strings.TrimSuffix(rel, "/") + "/"
+					if !visited[rel] {
+						visited[rel] = true
+						pending = append(pending, rel)
+					}
+					break
+				}
+
+				file := RemoteFile{
+					Path: strings.TrimSuffix(rel, "/"),
+					ETag: strings.Trim(strings.TrimPrefix(prop.ETag, "W/"), `"`),
+				}
+				file.Size, _ = strconv.ParseInt(strings.TrimSpace(prop.ContentLength), 10, 64)
+				file.ModTime, _ = http.ParseTime(strings.TrimSpace(prop.LastModified))
+				files = append(files, file)
+				break
+			}
+		}
+	}
+	return files, nil
+}
+
+func (w *webdavSource) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	req, err := w.newRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("WebDAV request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("WebDAV GET failed with status %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// propfind lists the members of the collection at dir, relative to the base URL
+func (w *webdavSource) propfind(ctx context.Context, dir string) (*davMultistatus, error) {
+	req, err := w.newRequest(ctx, "PROPFIND", dir, strings.NewReader(propfindBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Depth", "1")
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("WebDAV request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, fmt.Errorf("WebDAV PROPFIND of /%s failed with status %d", dir, resp.StatusCode)
+	}
+
+	var status davMultistatus
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, maxPropfindResponse)).Decode(&status); err != nil {
+		return nil, fmt.Errorf("failed to decode PROPFIND response: %w", err)
+	}
+	return &status, nil
+}
+
+func (w *webdavSource) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	target := *w.base
+	target.Path = w.base.Path + path
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create WebDAV request: %w", err)
+	}
+	if w.username != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+	return req, nil
+}
+
+// relativePath returns the path of an href below the base collection. Servers return hrefs as
+// absolute paths or full URLs, percent-encoded.
+func (w *webdavSource) relativePath(href string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return "", false
+	}
+	resolved := w.base.ResolveReference(u)
+	if resolved.Host != w.base.Host || !strings.HasPrefix(resolved.Path, w.base.Path) {
+		return "", false
+	}
+	return strings.TrimPrefix(resolved.Path, w.base.Path), true
+}
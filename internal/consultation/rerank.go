@@ -0,0 +1,84 @@
+package consultation
+
+import (
+	"context"
+	"sort"
+
+	"ai-government-consultant/internal/embedding"
+	"ai-government-consultant/internal/models"
+	"ai-government-consultant/internal/rerank"
+)
+
+// rerankContext re-ranks the retrieved documents and knowledge items together and keeps at most
+// limit of them, returning what was decided about each source. Without re-ranking stages the
+// sources are kept in retrieval order. Kept sources keep their retrieval scores.
+func (s *Service) rerankContext(ctx context.Context, query string, documents, knowledge []embedding.SearchResult, limit int) ([]embedding.SearchResult, []embedding.SearchResult, []models.SourceDecision) {
+	results := make([]embedding.SearchResult, 0, len(documents)+len(knowledge))
+	results = append(results, documents...)
+	results = append(results, knowledge...)
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+
+	candidates := make([]rerank.Candidate, len(results))
+	for i, result := range results {
+		candidates[i] = rerank.Candidate{Text: sourceText(result), Score: result.Score}
+	}
+
+	var keptDocuments, keptKnowledge []embedding.SearchResult
+	decisions := make([]models.SourceDecision, 0, len(results))
+	for _, outcome := range s.reranker.Rerank(ctx, query, candidates, limit) {
+		result := results[outcome.Index]
+		decisions = append(decisions, sourceDecision(result, outcome))
+		if !outcome.Selected {
+			continue
+		}
+		if result.Knowledge != nil {
+			keptKnowledge = append(keptKnowledge, result)
+		} else {
+			keptDocuments = append(keptDocuments, result)
+		}
+	}
+	return keptDocuments, keptKnowledge, decisions
+}
+
+// sourceText returns the text a retrieved source is judged on: the matching passage of a
+// document, or the document's summary or content, or the knowledge item's content
+func sourceText(result embedding.SearchResult) string {
+	switch {
+	case result.Knowledge != nil:
+		return result.Knowledge.Title + "\n" + result.Knowledge.Content
+	case result.Document == nil:
+		return ""
+	case result.Chunk != nil:
+		return result.Document.Name + "\n" + result.Chunk.Content
+	case result.Document.Summary != nil:
+		return result.Document.Name + "\n" + result.Document.Summary.Executive
+	default:
+		return result.Document.Name + "\n" + result.Document.Content
+	}
+}
+
+// sourceDecision records the outcome of re-ranking for a retrieved source
+func sourceDecision(result embedding.SearchResult, outcome rerank.Outcome) models.SourceDecision {
+	decision := models.SourceDecision{
+		RetrievalScore: result.Score,
+		Score:          outcome.Score,
+		Selected:       outcome.Selected,
+		Dropped:        outcome.Dropped,
+		Steps:          outcome.Steps,
+	}
+	switch {
+	case result.Knowledge != nil:
+		decision.SourceType = "knowledge"
+		decision.SourceID = result.Knowledge.ID
+		decision.Title = result.Knowledge.Title
+	case result.Document != nil:
+		decision.SourceType = "document"
+		decision.SourceID = result.Document.ID
+		decision.Title = result.Document.Name
+		if result.Chunk != nil {
+			chunkID := result.Chunk.ID
+			decision.ChunkID = &chunkID
+		}
+	}
+	return decision
+}
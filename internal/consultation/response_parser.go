@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"strings"
 	"time"
+	"unicode"
 
 	"ai-government-consultant/internal/models"
 
@@ -45,6 +46,7 @@ func (s *Service) parseConsultationResponse(geminiResponse *GeminiResponse, cont
 		NextSteps:       nextSteps,
 		GeneratedAt:     time.Now(),
 		ProcessingTime:  time.Duration(geminiResponse.UsageMetadata.TotalTokenCount) * time.Millisecond, // Rough estimate
+		SourceDecisions: context.Decisions,
 	}
 
 	return response, nil
@@ -606,9 +608,79 @@ func (s *Service) buildDocumentReferences(context *ContextData) []models.Documen
 				Title:      doc.Document.Name,
 				Relevance:  doc.Score,
 			}
+			if doc.Chunk != nil {
+				ref.PageNumber = doc.Chunk.PageNumber
+				ref.Section = doc.Chunk.Section
+			} else if len(doc.Document.Pages) > 0 || len(doc.Document.Sections) > 0 {
+				offset := s.locatePassage(doc.Document.Content, context.Query)
+				ref.PageNumber = doc.Document.PageAt(offset)
+				ref.Section = doc.Document.SectionAt(offset)
+			}
 			references = append(references, ref)
 		}
 	}
 	
 	return references
-}
\ No newline at end of file
+}
+
+// locatePassage returns the content offset of the passage that best matches the query terms
+func (s *Service) locatePassage(content, query string) int {
+	const window = 500
+
+	lowerContent, offsets := lowerWithOffsets(content)
+	var terms []string
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		term = strings.Trim(term, ".,;:!?\"'()[]")
+		if len(term) >= 4 {
+			terms = append(terms, term)
+		}
+	}
+
+	bestOffset, bestScore := 0, 0
+	for _, term := range terms {
+		for start := 0; ; {
+			idx := strings.Index(lowerContent[start:], term)
+			if idx < 0 {
+				break
+			}
+			offset := start + idx
+			end := offset + window
+			if end > len(lowerContent) {
+				end = len(lowerContent)
+			}
+
+			score := 0
+			for _, other := range terms {
+				if strings.Contains(lowerContent[offset:end], other) {
+					score++
+				}
+			}
+			if score > bestScore {
+				bestOffset, bestScore = offset, score
+			}
+			start = offset + len(term)
+		}
+	}
+
+	if bestOffset >= len(offsets) {
+		return 0
+	}
+	return offsets[bestOffset]
+}
+
+// lowerWithOffsets lowercases s and returns, for each byte of the result, the offset in s of the
+// rune it came from. Lowercasing can change a rune's byte length, so offsets found in the lowered
+// text must be mapped back before they are used on s.
+func lowerWithOffsets(s string) (string, []int) {
+	var lowered strings.Builder
+	lowered.Grow(len(s))
+	offsets := make([]int, 0, len(s))
+	for i, r := range s {
+		n := lowered.Len()
+		lowered.WriteRune(unicode.ToLower(r))
+		for ; n < lowered.Len(); n++ {
+			offsets = append(offsets, i)
+		}
+	}
+	return lowered.String(), offsets
+}
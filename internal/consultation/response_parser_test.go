@@ -0,0 +1,137 @@
+package consultation
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"ai-government-consultant/internal/embedding"
+	"ai-government-consultant/internal/models"
+)
+
+func TestLocatePassage(t *testing.T) {
+	filler := strings.Repeat("unrelated text ", 50)
+
+	tests := []struct {
+		name    string
+		content string
+		query   string
+		want    string // the content at the returned offset starts with this; empty means offset 0
+	}{
+		{
+			name:    "ascii",
+			content: "Introduction. " + filler + "Budget procurement standards apply.",
+			query:   "procurement standards",
+			want:    "procurement standards apply",
+		},
+		{
+			name:    "lowercasing shrinks runes",
+			content: "İİİİ ĞÜŞ " + filler + "Budget procurement standards apply.",
+			query:   "What are the procurement standards?",
+			want:    "procurement standards apply",
+		},
+		{
+			name:    "kelvin sign lowers to one byte",
+			content: "KKK " + filler + "Budget procurement standards apply.",
+			query:   "procurement standards",
+			want:    "procurement standards apply",
+		},
+		{
+			name:    "lowercasing grows runes",
+			content: "ȺȺȺȺ " + filler + "Budget procurement standards apply.",
+			query:   "procurement standards",
+			want:    "procurement standards apply",
+		},
+		{
+			name:    "matched text is itself non-ascii",
+			content: filler + "İSTANBUL field office guidance.",
+			query:   "istanbul guidance",
+			want:    "İSTANBUL field office",
+		},
+		{
+			name:    "best window wins over first mention",
+			content: "Standards. " + filler + filler + "Travel standards and procurement standards for travel.",
+			query:   "travel procurement standards",
+			want:    "Travel standards and procurement",
+		},
+		{
+			name:    "no match",
+			content: "İİİİ " + filler,
+			query:   "procurement",
+		},
+		{
+			name:    "short terms are ignored",
+			content: filler + "tax law",
+			query:   "tax law",
+		},
+	}
+	s := &Service{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offset := s.locatePassage(tt.content, tt.query)
+			if tt.want == "" {
+				if offset != 0 {
+					t.Fatalf("locatePassage = %d, want 0", offset)
+				}
+				return
+			}
+			if offset < 0 || offset > len(tt.content) {
+				t.Fatalf("locatePassage = %d, outside content of %d bytes", offset, len(tt.content))
+			}
+			if !utf8.RuneStart(tt.content[offset]) {
+				t.Fatalf("locatePassage = %d, inside a rune", offset)
+			}
+			if !strings.HasPrefix(tt.content[offset:], tt.want) {
+				t.Fatalf("content at %d is %q, want it to start with %q", offset, tt.content[offset:offset+len(tt.want)], tt.want)
+			}
+		})
+	}
+}
+
+func TestLowerWithOffsets(t *testing.T) {
+	tests := []string{"", "Plain ASCII", "İstanbul", "Kelvin", "ȺB", "ÀÉÎ mixed ǅ"}
+	for _, s := range tests {
+		lowered, offsets := lowerWithOffsets(s)
+		if lowered != strings.ToLower(s) {
+			t.Errorf("lowerWithOffsets(%q) = %q, want %q", s, lowered, strings.ToLower(s))
+		}
+		if len(offsets) != len(lowered) {
+			t.Fatalf("lowerWithOffsets(%q) has %d offsets for %d bytes", s, len(offsets), len(lowered))
+		}
+		// Every byte of a lowered rune maps to the start of the rune it came from
+		for i, r := range lowered {
+			original, _ := utf8.DecodeRuneInString(s[offsets[i]:])
+			if strings.ToLower(string(original)) != string(r) {
+				t.Errorf("lowerWithOffsets(%q): byte %d (%q) maps to %q", s, i, r, original)
+			}
+		}
+	}
+}
+
+func TestBuildDocumentReferencesPageFromOriginalContent(t *testing.T) {
+	page1 := "İİİİİİİİİİ ÇĞÖŞÜ overview of the agency. " + strings.Repeat("Background. ", 10)
+	page2 := "Procurement standards for field offices."
+	content := page1 + "\n\n" + page2
+	doc := &models.Document{
+		Name:    "Field Manual",
+		Content: content,
+		Pages: []models.PageSpan{
+			{Number: 1, Start: 0, End: len(page1)},
+			{Number: 2, Start: len(page1) + 2, End: len(content)},
+		},
+	}
+
+	refs := (&Service{}).buildDocumentReferences(&ContextData{
+		Query:     "procurement standards",
+		Documents: []embedding.SearchResult{{Document: doc, Score: 0.9}},
+	})
+	if len(refs) != 1 {
+		t.Fatalf("got %d references, want 1", len(refs))
+	}
+	if refs[0].PageNumber == nil {
+		t.Fatal("PageNumber is not set")
+	}
+	if *refs[0].PageNumber != 2 {
+		t.Fatalf("PageNumber = %d, want 2", *refs[0].PageNumber)
+	}
+}
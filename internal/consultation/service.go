@@ -10,8 +10,11 @@ import (
 	"strings"
 	"time"
 
+	"ai-government-consultant/internal/document"
 	"ai-government-consultant/internal/embedding"
 	"ai-government-consultant/internal/models"
+	"ai-government-consultant/internal/rerank"
+	"ai-government-consultant/internal/retention"
 	"ai-government-consultant/pkg/logger"
 
 	"github.com/redis/go-redis/v9"
@@ -22,6 +25,7 @@ import (
 // EmbeddingServiceInterface defines the interface for embedding operations
 type EmbeddingServiceInterface interface {
 	VectorSearch(ctx context.Context, query string, options *embedding.SearchOptions) ([]embedding.SearchResult, error)
+	HybridSearch(ctx context.Context, query string, options *embedding.HybridOptions) ([]embedding.HybridResult, error)
 	GenerateEmbedding(ctx context.Context, text string) ([]float64, error)
 }
 
@@ -35,6 +39,9 @@ type Service struct {
 	embeddingService EmbeddingServiceInterface
 	logger           logger.Logger
 	rateLimiter      *RateLimiter
+	retention        *retention.Service
+	reranker         *rerank.Pipeline
+	rerankCandidates int
 }
 
 // Config holds the configuration for the consultation service
@@ -46,6 +53,9 @@ type Config struct {
 	EmbeddingService EmbeddingServiceInterface
 	Logger           logger.Logger
 	RateLimit        RateLimitConfig
+	Retention        *retention.Service
+	Reranker         *rerank.Pipeline // re-ranks retrieved sources before they go in the prompt; nil keeps retrieval order
+	RerankCandidates int              // sources retrieved from each of documents and knowledge for re-ranking; defaults to 20
 }
 
 // RateLimitConfig defines rate limiting configuration
@@ -120,6 +130,9 @@ type ConsultationRequest struct {
 	Context          models.ConsultationContext `json:"context"`
 	MaxSources       int                    `json:"max_sources,omitempty"`
 	ConfidenceThreshold float64             `json:"confidence_threshold,omitempty"`
+	AllowUnredacted  bool                   `json:"-"` // requester may see PII in document context
+	WorkspaceIDs     []primitive.ObjectID   `json:"workspace_ids,omitempty"` // limit retrieved context to these workspaces
+	User             *models.User           `json:"-"` // requester; document context only comes from documents they may read
 }
 
 // NewService creates a new consultation service
@@ -148,6 +161,11 @@ func NewService(config *Config) (*Service, error) {
 		rateLimit.BurstSize = 10
 	}
 
+	rerankCandidates := config.RerankCandidates
+	if rerankCandidates <= 0 {
+		rerankCandidates = 20
+	}
+
 	return &Service{
 		geminiAPIKey:     config.GeminiAPIKey,
 		geminiURL:        geminiURL,
@@ -159,6 +177,9 @@ func NewService(config *Config) (*Service, error) {
 		embeddingService: config.EmbeddingService,
 		logger:           config.Logger,
 		rateLimiter:      NewRateLimiter(rateLimit),
+		retention:        config.Retention,
+		reranker:         config.Reranker,
+		rerankCandidates: rerankCandidates,
 	}, nil
 }
 
@@ -174,7 +195,7 @@ func (s *Service) ConsultPolicy(ctx context.Context, request *ConsultationReques
 	}
 
 	// Retrieve context from documents and knowledge base
-	contextData, err := s.retrieveContext(ctx, request.Query, request.MaxSources)
+	contextData, err := s.retrieveContext(ctx, request)
 	if err != nil {
 		s.logger.Error("Failed to retrieve context", err, map[string]interface{}{
 			"query": request.Query,
@@ -218,7 +239,7 @@ func (s *Service) ConsultStrategy(ctx context.Context, request *ConsultationRequ
 	}
 
 	// Retrieve context
-	contextData, err := s.retrieveContext(ctx, request.Query, request.MaxSources)
+	contextData, err := s.retrieveContext(ctx, request)
 	if err != nil {
 		s.logger.Error("Failed to retrieve context", err, map[string]interface{}{
 			"query": request.Query,
@@ -261,7 +282,7 @@ func (s *Service) ConsultOperations(ctx context.Context, request *ConsultationRe
 	}
 
 	// Retrieve context
-	contextData, err := s.retrieveContext(ctx, request.Query, request.MaxSources)
+	contextData, err := s.retrieveContext(ctx, request)
 	if err != nil {
 		s.logger.Error("Failed to retrieve context", err, map[string]interface{}{
 			"query": request.Query,
@@ -304,7 +325,7 @@ func (s *Service) ConsultTechnology(ctx context.Context, request *ConsultationRe
 	}
 
 	// Retrieve context
-	contextData, err := s.retrieveContext(ctx, request.Query, request.MaxSources)
+	contextData, err := s.retrieveContext(ctx, request)
 	if err != nil {
 		s.logger.Error("Failed to retrieve context", err, map[string]interface{}{
 			"query": request.Query,
@@ -407,14 +428,25 @@ func (s *Service) callGeminiAPI(ctx context.Context, prompt string) (*GeminiResp
 
 // ContextData holds retrieved context information
 type ContextData struct {
+	Query         string                   `json:"query,omitempty"`
 	Documents     []embedding.SearchResult `json:"documents"`
 	Knowledge     []embedding.SearchResult `json:"knowledge"`
+	Tables        []TableContext           `json:"tables,omitempty"` // tables of the retrieved documents that bear on the query
+	Decisions     []models.SourceDecision  `json:"decisions,omitempty"` // why each retrieved source was used or left out
 	TotalSources  int                      `json:"total_sources"`
 	QueryEmbedding []float64               `json:"query_embedding,omitempty"`
 }
 
-// retrieveContext retrieves relevant context from documents and knowledge base
-func (s *Service) retrieveContext(ctx context.Context, query string, maxSources int) (*ContextData, error) {
+// retrieveContext retrieves relevant context from documents and knowledge base by hybrid keyword
+// and vector search. Document results are chunk-level hits, so prompts carry the matching
+// passages rather than whole documents. When re-ranking is configured, more sources are retrieved
+// and the re-ranking stages choose among them.
+// Personal data in document passages stays redacted unless the request allows it, and
+// a request naming workspaces only draws on items in them. Passages only come from documents the
+// requesting user may read; without a user no documents are searched.
+func (s *Service) retrieveContext(ctx context.Context, request *ConsultationRequest) (*ContextData, error) {
+	query := request.Query
+	maxSources := request.MaxSources
 	if maxSources == 0 {
 		maxSources = 10
 	}
@@ -423,45 +455,145 @@ func (s *Service) retrieveContext(ctx context.Context, query string, maxSources
 	docLimit := maxSources / 2
 	knowledgeLimit := maxSources - docLimit
 
-	// Search documents
-	docOptions := &embedding.SearchOptions{
-		Limit:      docLimit,
-		Threshold:  0.7,
-		Collection: "documents",
-	}
-	documents, err := s.embeddingService.VectorSearch(ctx, query, docOptions)
-	if err != nil {
-		s.logger.Error("Failed to search documents", err, nil)
-		documents = []embedding.SearchResult{}
+	// Re-ranking picks from a wider set of sources
+	if s.reranker.Enabled() {
+		docLimit = max(docLimit, s.rerankCandidates)
+		knowledgeLimit = max(knowledgeLimit, s.rerankCandidates)
+	}
+
+	// Search the documents the user may read
+	var documents []embedding.SearchResult
+	if request.User != nil {
+		docOptions := &embedding.HybridOptions{SearchOptions: embedding.SearchOptions{
+			Limit:        docLimit,
+			Threshold:    0.7,
+			Collection:   "documents",
+			Unredacted:   request.AllowUnredacted,
+			WorkspaceIDs: request.WorkspaceIDs,
+			Access:       document.NewAccessScope(request.User),
+		}}
+		docResults, err := s.embeddingService.HybridSearch(ctx, query, docOptions)
+		if err != nil {
+			s.logger.Error("Failed to search documents", err, nil)
+		}
+		documents = relevanceResults(docResults)
 	}
 
 	// Search knowledge base
-	knowledgeOptions := &embedding.SearchOptions{
-		Limit:      knowledgeLimit,
-		Threshold:  0.7,
-		Collection: "knowledge_items",
-	}
-	knowledge, err := s.embeddingService.VectorSearch(ctx, query, knowledgeOptions)
+	knowledgeOptions := &embedding.HybridOptions{SearchOptions: embedding.SearchOptions{
+		Limit:        knowledgeLimit,
+		Threshold:    0.7,
+		Collection:   "knowledge_items",
+		WorkspaceIDs: request.WorkspaceIDs,
+	}}
+	knowledgeResults, err := s.embeddingService.HybridSearch(ctx, query, knowledgeOptions)
 	if err != nil {
 		s.logger.Error("Failed to search knowledge", err, nil)
-		knowledge = []embedding.SearchResult{}
+	}
+	knowledge := relevanceResults(knowledgeResults)
+
+	documents, knowledge, decisions := s.rerankContext(ctx, query, documents, knowledge, maxSources)
+
+	// Tables of the matching documents give figures the passages may only mention
+	tables, err := s.relevantTables(ctx, query, documents, request.AllowUnredacted)
+	if err != nil {
+		s.logger.Error("Failed to find document tables", err, nil)
 	}
 
 	contextData := &ContextData{
+		Query:        query,
 		Documents:    documents,
 		Knowledge:    knowledge,
+		Tables:       tables,
 		TotalSources: len(documents) + len(knowledge),
+		Decisions:    decisions,
 	}
 
 	s.logger.Debug("Retrieved context", map[string]interface{}{
 		"documents_found": len(documents),
 		"knowledge_found": len(knowledge),
+		"tables_found":    len(tables),
 		"total_sources":   contextData.TotalSources,
 	})
 
 	return contextData, nil
 }
 
+// relevanceResults converts hybrid search results for the context, scoring each by its fused
+// score relative to the best possible one so that relevance stays between 0 and 1
+func relevanceResults(results []embedding.HybridResult) []embedding.SearchResult {
+	converted := make([]embedding.SearchResult, 0, len(results))
+	for _, result := range results {
+		if result.Explanation.MaxScore > 0 {
+			result.Score /= result.Explanation.MaxScore
+		}
+		converted = append(converted, result.SearchResult)
+	}
+	return converted
+}
+
+// documentExcerpt formats the text of a document search hit for a prompt: the matching
+// passage for chunk-level hits, otherwise the document's summary or its beginning. The
+// generated executive summary of a document, when there is one, gives the passage its context.
+func documentExcerpt(result embedding.SearchResult) string {
+	var summary *models.DocumentSummary
+	if result.Document != nil {
+		summary = result.Document.Summary
+	}
+
+	if chunk := result.Chunk; chunk != nil {
+		var excerpt strings.Builder
+		if summary != nil {
+			fmt.Fprintf(&excerpt, "   Document summary: %s\n", summary.Executive)
+		}
+		var location []string
+		if chunk.Section != nil {
+			location = append(location, *chunk.Section)
+		}
+		if chunk.PageNumber != nil {
+			location = append(location, fmt.Sprintf("page %d", *chunk.PageNumber))
+		}
+		if len(location) > 0 {
+			fmt.Fprintf(&excerpt, "   Passage (%s): %s\n", strings.Join(location, ", "), chunk.Content)
+		} else {
+			fmt.Fprintf(&excerpt, "   Passage: %s\n", chunk.Content)
+		}
+		return excerpt.String()
+	}
+
+	if summary != nil {
+		return summaryExcerpt(summary)
+	}
+	if len(result.Document.Content) > 500 {
+		return fmt.Sprintf("   Content: %s...\n", result.Document.Content[:500])
+	}
+	return fmt.Sprintf("   Content: %s\n", result.Document.Content)
+}
+
+// maxPromptObligations bounds the obligations listed for one document in a prompt
+const maxPromptObligations = 5
+
+// summaryExcerpt formats a document's executive summary and first obligations for a prompt
+func summaryExcerpt(summary *models.DocumentSummary) string {
+	var excerpt strings.Builder
+	fmt.Fprintf(&excerpt, "   Summary: %s\n", summary.Executive)
+	for i, obligation := range summary.Obligations {
+		if i == maxPromptObligations {
+			fmt.Fprintf(&excerpt, "   ...and %d more obligations\n", len(summary.Obligations)-i)
+			break
+		}
+		if i == 0 {
+			excerpt.WriteString("   Obligations:\n")
+		}
+		if obligation.Deadline != "" {
+			fmt.Fprintf(&excerpt, "   - %s (deadline: %s)\n", obligation.Text, obligation.Deadline)
+		} else {
+			fmt.Fprintf(&excerpt, "   - %s\n", obligation.Text)
+		}
+	}
+	return excerpt.String()
+}
+
 // generatePolicyPrompt creates a prompt for policy consultation
 func (s *Service) generatePolicyPrompt(query string, context *ContextData) string {
 	var prompt strings.Builder
@@ -487,16 +619,16 @@ func (s *Service) generatePolicyPrompt(query string, context *ContextData) strin
 			for i, doc := range context.Documents {
 				if doc.Document != nil {
 					prompt.WriteString(fmt.Sprintf("%d. %s (Relevance: %.2f)\n", i+1, doc.Document.Name, doc.Score))
-					if len(doc.Document.Content) > 500 {
-						prompt.WriteString(fmt.Sprintf("   Content: %s...\n", doc.Document.Content[:500]))
-					} else {
-						prompt.WriteString(fmt.Sprintf("   Content: %s\n", doc.Document.Content))
-					}
+					prompt.WriteString(documentExcerpt(doc))
 				}
 			}
 			prompt.WriteString("\n")
 		}
 
+		if len(context.Tables) > 0 {
+			prompt.WriteString(tablesExcerpt(context.Tables))
+		}
+
 		if len(context.Knowledge) > 0 {
 			prompt.WriteString("Knowledge Base:\n")
 			for i, knowledge := range context.Knowledge {
@@ -557,16 +689,16 @@ func (s *Service) generateStrategyPrompt(query string, context *ContextData) str
 			for i, doc := range context.Documents {
 				if doc.Document != nil {
 					prompt.WriteString(fmt.Sprintf("%d. %s (Relevance: %.2f)\n", i+1, doc.Document.Name, doc.Score))
-					if len(doc.Document.Content) > 500 {
-						prompt.WriteString(fmt.Sprintf("   Content: %s...\n", doc.Document.Content[:500]))
-					} else {
-						prompt.WriteString(fmt.Sprintf("   Content: %s\n", doc.Document.Content))
-					}
+					prompt.WriteString(documentExcerpt(doc))
 				}
 			}
 			prompt.WriteString("\n")
 		}
 
+		if len(context.Tables) > 0 {
+			prompt.WriteString(tablesExcerpt(context.Tables))
+		}
+
 		if len(context.Knowledge) > 0 {
 			prompt.WriteString("Knowledge Base:\n")
 			for i, knowledge := range context.Knowledge {
@@ -627,16 +759,16 @@ func (s *Service) generateOperationsPrompt(query string, context *ContextData) s
 			for i, doc := range context.Documents {
 				if doc.Document != nil {
 					prompt.WriteString(fmt.Sprintf("%d. %s (Relevance: %.2f)\n", i+1, doc.Document.Name, doc.Score))
-					if len(doc.Document.Content) > 500 {
-						prompt.WriteString(fmt.Sprintf("   Content: %s...\n", doc.Document.Content[:500]))
-					} else {
-						prompt.WriteString(fmt.Sprintf("   Content: %s\n", doc.Document.Content))
-					}
+					prompt.WriteString(documentExcerpt(doc))
 				}
 			}
 			prompt.WriteString("\n")
 		}
 
+		if len(context.Tables) > 0 {
+			prompt.WriteString(tablesExcerpt(context.Tables))
+		}
+
 		if len(context.Knowledge) > 0 {
 			prompt.WriteString("Knowledge Base:\n")
 			for i, knowledge := range context.Knowledge {
@@ -697,16 +829,16 @@ func (s *Service) generateTechnologyPrompt(query string, context *ContextData) s
 			for i, doc := range context.Documents {
 				if doc.Document != nil {
 					prompt.WriteString(fmt.Sprintf("%d. %s (Relevance: %.2f)\n", i+1, doc.Document.Name, doc.Score))
-					if len(doc.Document.Content) > 500 {
-						prompt.WriteString(fmt.Sprintf("   Content: %s...\n", doc.Document.Content[:500]))
-					} else {
-						prompt.WriteString(fmt.Sprintf("   Content: %s\n", doc.Document.Content))
-					}
+					prompt.WriteString(documentExcerpt(doc))
 				}
 			}
 			prompt.WriteString("\n")
 		}
 
+		if len(context.Tables) > 0 {
+			prompt.WriteString(tablesExcerpt(context.Tables))
+		}
+
 		if len(context.Knowledge) > 0 {
 			prompt.WriteString("Knowledge Base:\n")
 			for i, knowledge := range context.Knowledge {
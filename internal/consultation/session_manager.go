@@ -3,9 +3,12 @@ package consultation
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"ai-government-consultant/internal/models"
+	"ai-government-consultant/internal/retention"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -19,6 +22,7 @@ type SessionManager struct {
 	service   *Service
 	cache     *ConsultationCache
 	analytics *AnalyticsService
+	retention *retention.Service
 }
 
 // NewSessionManager creates a new session manager
@@ -30,6 +34,7 @@ func NewSessionManager(mongodb *mongo.Database, service *Service) *SessionManage
 		service:   service,
 		cache:     cache,
 		analytics: analytics,
+		retention: service.retention,
 	}
 }
 
@@ -43,16 +48,26 @@ func (sm *SessionManager) CreateSession(ctx context.Context, request *Consultati
 
 	// Create session
 	session := &models.ConsultationSession{
-		ID:        primitive.NewObjectID(),
-		UserID:    request.UserID,
-		Type:      request.Type,
-		Query:     request.Query,
-		Context:   request.Context,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		Status:    models.SessionStatusActive,
-		Tags:      []string{},
-		Metadata:  make(map[string]interface{}),
+		ID:           primitive.NewObjectID(),
+		UserID:       request.UserID,
+		Type:         request.Type,
+		Query:        request.Query,
+		Context:      request.Context,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+		Status:       models.SessionStatusActive,
+		Tags:         []string{},
+		Metadata:     make(map[string]interface{}),
+		WorkspaceIDs: request.WorkspaceIDs,
+	}
+
+	// Assign retention from the schedules for the consultation type and workspaces
+	if sm.retention != nil {
+		assigned, err := sm.retention.Assign(ctx, models.RecordTypeConsultation, string(session.Type), session.WorkspaceIDs, session.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to assign retention: %w", err)
+		}
+		session.Retention = assigned
 	}
 
 	// Store session in database
@@ -84,7 +99,7 @@ func (sm *SessionManager) ProcessSession(ctx context.Context, sessionID primitiv
 	}
 
 	// Check cache for similar query first
-	if cachedResponse, err := sm.cache.GetCachedQueryResponse(ctx, session.Query, session.Type, session.UserID); err == nil && cachedResponse != nil {
+	if cachedResponse, err := sm.cache.GetCachedQueryResponse(ctx, cacheQuery(session), session.Type, session.UserID); err == nil && cachedResponse != nil {
 		sm.service.logger.Info("Using cached response for similar query", map[string]interface{}{
 			"session_id": sessionID.Hex(),
 		})
@@ -114,6 +129,11 @@ func (sm *SessionManager) ProcessSession(ctx context.Context, sessionID primitiv
 
 	startTime := time.Now()
 
+	requester, err := sm.requester(ctx, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create consultation request
 	consultationRequest := &ConsultationRequest{
 		Query:               session.Query,
@@ -122,6 +142,8 @@ func (sm *SessionManager) ProcessSession(ctx context.Context, sessionID primitiv
 		Context:             session.Context,
 		MaxSources:          10,
 		ConfidenceThreshold: 0.7,
+		WorkspaceIDs:        session.WorkspaceIDs,
+		User:                requester,
 	}
 
 	// Process consultation based on type
@@ -161,7 +183,7 @@ func (sm *SessionManager) ProcessSession(ctx context.Context, sessionID primitiv
 	response.ProcessingTime = processingTime
 
 	// Cache the response for future similar queries
-	if err := sm.cache.CacheQueryResponse(ctx, session.Query, session.Type, session.UserID, response); err != nil {
+	if err := sm.cache.CacheQueryResponse(ctx, cacheQuery(session), session.Type, session.UserID, response); err != nil {
 		sm.service.logger.Error("Failed to cache query response", err, map[string]interface{}{
 			"session_id": sessionID.Hex(),
 		})
@@ -338,15 +360,15 @@ func (sm *SessionManager) GetSessionsByType(ctx context.Context, consultationTyp
 	return sessions, nil
 }
 
-// DeleteSession deletes a consultation session
+// DeleteSession deletes a consultation session. A session under legal hold is kept, the refusal
+// is audited and models.ErrRecordOnLegalHold is returned.
 func (sm *SessionManager) DeleteSession(ctx context.Context, sessionID primitive.ObjectID, userID primitive.ObjectID) error {
 	collection := sm.mongodb.Collection("consultations")
 
-	// Ensure user can only delete their own sessions
-	filter := bson.M{
-		"_id":     sessionID,
-		"user_id": userID,
-	}
+	// Ensure user can only delete their own sessions, and never one under legal hold
+	filter := retention.NotOnHold()
+	filter["_id"] = sessionID
+	filter["user_id"] = userID
 
 	result, err := collection.DeleteOne(ctx, filter)
 	if err != nil {
@@ -354,6 +376,14 @@ func (sm *SessionManager) DeleteSession(ctx context.Context, sessionID primitive
 	}
 
 	if result.DeletedCount == 0 {
+		var held models.ConsultationSession
+		err := collection.FindOne(ctx, bson.M{"_id": sessionID, "user_id": userID}).Decode(&held)
+		if err == nil && len(held.LegalHoldIDs) > 0 {
+			if sm.retention != nil {
+				sm.retention.LogBlockedDeletion(ctx, models.RecordTypeConsultation, sessionID, userID, held.LegalHoldIDs)
+			}
+			return models.ErrRecordOnLegalHold
+		}
 		return fmt.Errorf("session not found or access denied")
 	}
 
@@ -477,6 +507,11 @@ func (sm *SessionManager) AddConversationTurn(ctx context.Context, sessionID pri
 		enhancedContext.SystemContext["previous_recommendations"] = session.Response.Recommendations
 	}
 
+	requester, err := sm.requester(ctx, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create consultation request for the new turn
 	consultationRequest := &ConsultationRequest{
 		Query:               query,
@@ -485,6 +520,8 @@ func (sm *SessionManager) AddConversationTurn(ctx context.Context, sessionID pri
 		Context:             enhancedContext,
 		MaxSources:          10,
 		ConfidenceThreshold: 0.7,
+		WorkspaceIDs:        session.WorkspaceIDs,
+		User:                requester,
 	}
 
 	// Check cache for similar query in conversation context
@@ -576,6 +613,15 @@ func (sm *SessionManager) GetConversationHistory(ctx context.Context, sessionID
 	return session.ConversationTurns, nil
 }
 
+// requester loads the user a session belongs to, whose access limits the documents it draws on
+func (sm *SessionManager) requester(ctx context.Context, userID primitive.ObjectID) (*models.User, error) {
+	var user models.User
+	if err := sm.mongodb.Collection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		return nil, fmt.Errorf("failed to load session user: %w", err)
+	}
+	return &user, nil
+}
+
 // ContinueConversation creates a new session that continues from a previous session
 func (sm *SessionManager) ContinueConversation(ctx context.Context, previousSessionID primitive.ObjectID, query string) (*models.ConsultationSession, error) {
 	// Get previous session for context
@@ -612,6 +658,7 @@ func (sm *SessionManager) ContinueConversation(ctx context.Context, previousSess
 		Context:             enhancedContext,
 		MaxSources:          10,
 		ConfidenceThreshold: 0.7,
+		WorkspaceIDs:        previousSession.WorkspaceIDs,
 	}
 
 	// Create new session
@@ -716,7 +763,19 @@ func (sm *SessionManager) updateSession(ctx context.Context, session *models.Con
 
 	session.UpdatedAt = time.Now()
 
-	_, err := collection.ReplaceOne(ctx, bson.M{"_id": session.ID}, session)
+	// The session is written field by field so that legal holds placed since it was read are kept
+	fields, err := bson.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+	var set bson.M
+	if err := bson.Unmarshal(fields, &set); err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+	delete(set, "_id")
+	delete(set, "legal_hold_ids")
+
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": session.ID}, bson.M{"$set": set})
 	if err != nil {
 		return fmt.Errorf("failed to update session: %w", err)
 	}
@@ -741,3 +800,17 @@ func (sm *SessionManager) updateSessionStatus(ctx context.Context, sessionID pri
 
 	return nil
 }
+
+// cacheQuery returns the query a session's response is cached under. Responses drawn from
+// particular workspaces are cached apart from unscoped ones.
+func cacheQuery(session *models.ConsultationSession) string {
+	if len(session.WorkspaceIDs) == 0 {
+		return session.Query
+	}
+	ids := make([]string, len(session.WorkspaceIDs))
+	for i, id := range session.WorkspaceIDs {
+		ids[i] = id.Hex()
+	}
+	sort.Strings(ids)
+	return session.Query + "\x00workspaces:" + strings.Join(ids, ",")
+}
@@ -0,0 +1,182 @@
+package consultation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"ai-government-consultant/internal/embedding"
+	"ai-government-consultant/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// maxPromptTables bounds the tables passed to the model with one query
+	maxPromptTables = 3
+
+	// maxPromptTableRows bounds the rows of a table shown in a prompt
+	maxPromptTableRows = 40
+
+	// maxPromptCellLength bounds the characters of a table cell shown in a prompt
+	maxPromptCellLength = 80
+)
+
+// TableContext is a table of a retrieved document, passed to the model as tabular context
+type TableContext struct {
+	DocumentName string               `json:"document_name"`
+	Table        models.DocumentTable `json:"table"`
+	score        int
+}
+
+// relevantTables returns the tables of the retrieved documents that bear on the query: tables
+// inside a matching passage, and tables whose caption, header or row labels share words with
+// the query. Cells stay redacted unless unredacted is set.
+func (s *Service) relevantTables(ctx context.Context, query string, documents []embedding.SearchResult, unredacted bool) ([]TableContext, error) {
+	names := make(map[primitive.ObjectID]string)
+	var ids []primitive.ObjectID
+	for _, result := range documents {
+		if result.Document == nil || result.Document.TableCount == 0 {
+			continue
+		}
+		if _, seen := names[result.Document.ID]; !seen {
+			names[result.Document.ID] = result.Document.Name
+			ids = append(ids, result.Document.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	cursor, err := s.mongodb.Collection("document_tables").Find(ctx, bson.M{"document_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find document tables: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var tables []models.DocumentTable
+	if err := cursor.All(ctx, &tables); err != nil {
+		return nil, fmt.Errorf("failed to decode document tables: %w", err)
+	}
+
+	terms := queryTerms(query)
+	var relevant []TableContext
+	for _, table := range tables {
+		score := tableScore(table, terms)
+		for _, result := range documents {
+			if chunk := result.Chunk; chunk != nil && chunk.DocumentID == table.DocumentID &&
+				chunk.Start < table.End && table.Start < chunk.End {
+				score += 2
+			}
+		}
+		if score == 0 {
+			continue
+		}
+		if !unredacted {
+			table.RedactPII()
+		}
+		relevant = append(relevant, TableContext{
+			DocumentName: names[table.DocumentID],
+			Table:        table,
+			score:        score,
+		})
+	}
+
+	sort.SliceStable(relevant, func(i, j int) bool {
+		return relevant[i].score > relevant[j].score
+	})
+	if len(relevant) > maxPromptTables {
+		relevant = relevant[:maxPromptTables]
+	}
+	return relevant, nil
+}
+
+// queryTerms returns the distinct lowercase words of a query that are worth matching: words of
+// three or more letters and anything with a digit, such as "FY26" or "2025"
+func queryTerms(query string) map[string]bool {
+	terms := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(word) >= 3 || strings.IndexFunc(word, unicode.IsDigit) >= 0 {
+			terms[word] = true
+		}
+	}
+	for _, stopword := range []string{"the", "and", "for", "with", "what", "which", "how", "are", "was", "were", "from", "between", "compare", "versus"} {
+		delete(terms, stopword)
+	}
+	return terms
+}
+
+// tableScore counts the query terms found in a table's caption, header and first column
+func tableScore(table models.DocumentTable, terms map[string]bool) int {
+	labels := []string{table.Caption}
+	labels = append(labels, table.Columns...)
+	for _, row := range table.Rows {
+		if len(row) > 0 {
+			labels = append(labels, row[0])
+		}
+	}
+
+	found := make(map[string]bool)
+	for _, label := range labels {
+		for _, word := range strings.FieldsFunc(strings.ToLower(label), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			if terms[word] {
+				found[word] = true
+			}
+		}
+	}
+	return len(found)
+}
+
+// tablesExcerpt formats tables for a prompt as Markdown tables
+func tablesExcerpt(tables []TableContext) string {
+	var excerpt strings.Builder
+	excerpt.WriteString("Tables:\n")
+	for i, item := range tables {
+		table := item.Table
+		var location []string
+		if table.Caption != "" {
+			location = append(location, table.Caption)
+		}
+		if table.Section != nil {
+			location = append(location, *table.Section)
+		}
+		if table.Page != nil {
+			location = append(location, fmt.Sprintf("page %d", *table.Page))
+		}
+		if len(location) > 0 {
+			fmt.Fprintf(&excerpt, "%d. %s (%s)\n", i+1, item.DocumentName, strings.Join(location, ", "))
+		} else {
+			fmt.Fprintf(&excerpt, "%d. %s\n", i+1, item.DocumentName)
+		}
+
+		writeTableRow(&excerpt, table.Columns)
+		excerpt.WriteString("   |" + strings.Repeat(" --- |", len(table.Columns)) + "\n")
+		for j, row := range table.Rows {
+			if j == maxPromptTableRows {
+				fmt.Fprintf(&excerpt, "   ...and %d more rows\n", table.RowCount-j)
+				break
+			}
+			writeTableRow(&excerpt, row)
+		}
+	}
+	excerpt.WriteString("\n")
+	return excerpt.String()
+}
+
+func writeTableRow(excerpt *strings.Builder, cells []string) {
+	excerpt.WriteString("   |")
+	for _, cell := range cells {
+		if runes := []rune(cell); len(runes) > maxPromptCellLength {
+			cell = string(runes[:maxPromptCellLength]) + "..."
+		}
+		excerpt.WriteString(" " + strings.ReplaceAll(cell, "|", "/") + " |")
+	}
+	excerpt.WriteString("\n")
+}
@@ -68,7 +68,7 @@ func createDefaultAdminUser(ctx context.Context, db *MongoDB) error {
 		Permissions: []models.Permission{
 			{
 				Resource: "documents",
-				Actions:  []string{"read", "write", "delete", "admin"},
+				Actions:  []string{"read", "write", "delete", "admin", models.DocumentActionReadPII},
 			},
 			{
 				Resource: "consultations",
@@ -82,6 +82,14 @@ func createDefaultAdminUser(ctx context.Context, db *MongoDB) error {
 				Resource: "knowledge",
 				Actions:  []string{"read", "write", "delete", "admin"},
 			},
+			{
+				Resource: "workspaces",
+				Actions:  []string{"read", "write", "delete", "admin"},
+			},
+			{
+				Resource: "retention",
+				Actions:  []string{"read", "write", "admin"},
+			},
 			{
 				Resource: "system",
 				Actions:  []string{"read", "write", "admin"},
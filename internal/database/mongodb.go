@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -103,6 +104,26 @@ func (m *MongoDB) CreateIndexes(ctx context.Context) error {
 		return fmt.Errorf("failed to create document indexes: %w", err)
 	}
 
+	// Create indexes for document_chunks collection
+	if err := m.createDocumentChunkIndexes(ctx); err != nil {
+		return fmt.Errorf("failed to create document chunk indexes: %w", err)
+	}
+
+	// Create indexes for document_tables collection
+	if err := m.createDocumentTableIndexes(ctx); err != nil {
+		return fmt.Errorf("failed to create document table indexes: %w", err)
+	}
+
+	// Create indexes for ingest_batches collection
+	if err := m.createBatchIndexes(ctx); err != nil {
+		return fmt.Errorf("failed to create batch indexes: %w", err)
+	}
+
+	// Create indexes for jobs collection
+	if err := m.createJobIndexes(ctx); err != nil {
+		return fmt.Errorf("failed to create job indexes: %w", err)
+	}
+
 	// Create indexes for users collection
 	if err := m.createUserIndexes(ctx); err != nil {
 		return fmt.Errorf("failed to create user indexes: %w", err)
@@ -118,6 +139,26 @@ func (m *MongoDB) CreateIndexes(ctx context.Context) error {
 		return fmt.Errorf("failed to create knowledge indexes: %w", err)
 	}
 
+	// Create indexes for workspaces collection
+	if err := m.createWorkspaceIndexes(ctx); err != nil {
+		return fmt.Errorf("failed to create workspace indexes: %w", err)
+	}
+
+	// Create indexes for retention schedules, legal holds and archived records
+	if err := m.createRetentionIndexes(ctx); err != nil {
+		return fmt.Errorf("failed to create retention indexes: %w", err)
+	}
+
+	// Create indexes for annotations collection
+	if err := m.createAnnotationIndexes(ctx); err != nil {
+		return fmt.Errorf("failed to create annotation indexes: %w", err)
+	}
+
+	// Create indexes for connector collections
+	if err := m.createConnectorIndexes(ctx); err != nil {
+		return fmt.Errorf("failed to create connector indexes: %w", err)
+	}
+
 	// Create indexes for research collections
 	if err := m.createResearchIndexes(ctx); err != nil {
 		return fmt.Errorf("failed to create research indexes: %w", err)
@@ -152,14 +193,143 @@ func (m *MongoDB) createDocumentIndexes(ctx context.Context) error {
 		{
 			Keys: bson.D{{"classification.level", 1}},
 		},
-		// Text index for full-text search
+		// Version chains
+		{
+			Keys: bson.D{{"series_id", 1}, {"version", 1}},
+		},
+		// Duplicate detection
+		{
+			Keys: bson.D{{"content_hash", 1}},
+		},
+		{
+			Keys: bson.D{{"text_hash", 1}},
+		},
+		{
+			Keys: bson.D{{"minhash_bands", 1}},
+		},
+		{
+			Keys: bson.D{{"citation_keys", 1}},
+		},
+		{
+			Keys: bson.D{{"classification_review.status", 1}, {"classification_review.flagged_at", 1}},
+		},
+		{
+			Keys:    bson.D{{"batch_id", 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys: bson.D{{"workspace_ids", 1}},
+		},
+		{
+			Keys:    bson.D{{"shared_with.user_id", 1}},
+			Options: options.Index().SetSparse(true),
+		},
 		{
-			Keys: bson.D{{"name", "text"}, {"content", "text"}},
+			Keys:    bson.D{{"retention.disposition_date", 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{"legal_hold_ids", 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		// Text index for full-text search, covering the generated summaries
+		{
+			Keys: bson.D{
+				{"name", "text"},
+				{"content", "text"},
+				{"summary.executive", "text"},
+				{"summary.sections.summary", "text"},
+				{"summary.obligations.text", "text"},
+			},
+			Options: options.Index().SetName("documents_text"),
 		},
 		// Vector search index for embeddings (MongoDB Atlas Vector Search)
 		// Note: This would need to be created through MongoDB Atlas UI or specific vector search commands
 	}
 
+	// A collection has one text index, so the one from before summaries were indexed is replaced
+	if _, err := collection.Indexes().DropOne(ctx, "name_text_content_text"); err != nil {
+		var cmdErr mongo.CommandError
+		if !errors.As(err, &cmdErr) || (cmdErr.Name != "IndexNotFound" && cmdErr.Name != "NamespaceNotFound") {
+			return fmt.Errorf("failed to drop old text index: %w", err)
+		}
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+// createDocumentChunkIndexes creates indexes for the document_chunks collection
+func (m *MongoDB) createDocumentChunkIndexes(ctx context.Context) error {
+	collection := m.GetCollection("document_chunks")
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{"document_id", 1}, {"index", 1}},
+		},
+		{
+			// Keyword side of hybrid search
+			Keys: bson.D{{"content", "text"}},
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+// createDocumentTableIndexes creates indexes for the document_tables collection
+func (m *MongoDB) createDocumentTableIndexes(ctx context.Context) error {
+	collection := m.GetCollection("document_tables")
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{"document_id", 1}, {"index", 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+// createBatchIndexes creates indexes for the ingest_batches collection
+func (m *MongoDB) createBatchIndexes(ctx context.Context) error {
+	collection := m.GetCollection("ingest_batches")
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{"created_by", 1}, {"created_at", -1}},
+		},
+		{
+			Keys: bson.D{{"created_at", -1}},
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+// createJobIndexes creates indexes for the jobs collection
+func (m *MongoDB) createJobIndexes(ctx context.Context) error {
+	collection := m.GetCollection("jobs")
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{"status", 1}, {"run_at", 1}},
+		},
+		{
+			Keys: bson.D{{"type", 1}, {"key", 1}, {"status", 1}},
+		},
+		{
+			Keys: bson.D{{"updated_at", -1}},
+		},
+		// Completed jobs are kept for a week
+		{
+			Keys:    bson.D{{"completed_at", 1}},
+			Options: options.Index().SetExpireAfterSeconds(7 * 24 * 60 * 60),
+		},
+	}
+
 	_, err := collection.Indexes().CreateMany(ctx, indexes)
 	return err
 }
@@ -214,6 +384,17 @@ func (m *MongoDB) createConsultationIndexes(ctx context.Context) error {
 		{
 			Keys: bson.D{{"tags", 1}},
 		},
+		{
+			Keys: bson.D{{"workspace_ids", 1}},
+		},
+		{
+			Keys:    bson.D{{"retention.disposition_date", 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{"legal_hold_ids", 1}},
+			Options: options.Index().SetSparse(true),
+		},
 		// Compound index for user queries
 		{
 			Keys: bson.D{{"user_id", 1}, {"created_at", -1}},
@@ -260,6 +441,15 @@ func (m *MongoDB) createKnowledgeIndexes(ctx context.Context) error {
 		{
 			Keys: bson.D{{"confidence", -1}},
 		},
+		{
+			Keys: bson.D{{"citation_keys", 1}},
+		},
+		{
+			Keys: bson.D{{"authority", 1}},
+		},
+		{
+			Keys: bson.D{{"workspace_ids", 1}},
+		},
 		{
 			Keys: bson.D{{"validation.is_validated", 1}},
 		},
@@ -285,6 +475,108 @@ func (m *MongoDB) createKnowledgeIndexes(ctx context.Context) error {
 	return err
 }
 
+// createWorkspaceIndexes creates indexes for the workspaces collection
+func (m *MongoDB) createWorkspaceIndexes(ctx context.Context) error {
+	collection := m.GetCollection("workspaces")
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{"member_ids", 1}, {"name", 1}},
+		},
+		{
+			Keys: bson.D{{"name", 1}},
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+// createRetentionIndexes creates indexes for the records retention collections
+func (m *MongoDB) createRetentionIndexes(ctx context.Context) error {
+	collections := map[string][]mongo.IndexModel{
+		"retention_schedules": {
+			{
+				Keys: bson.D{{"record_type", 1}, {"name", 1}},
+			},
+		},
+		"legal_holds": {
+			{
+				Keys: bson.D{{"status", 1}, {"placed_at", -1}},
+			},
+			{
+				Keys: bson.D{{"placed_at", -1}},
+			},
+		},
+		"records_archive": {
+			{
+				Keys: bson.D{{"record_type", 1}, {"record_id", 1}},
+			},
+			{
+				Keys: bson.D{{"archived_at", -1}},
+			},
+		},
+	}
+
+	for name, indexes := range collections {
+		if _, err := m.GetCollection(name).Indexes().CreateMany(ctx, indexes); err != nil {
+			return fmt.Errorf("failed to create indexes for %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// createAnnotationIndexes creates indexes for the annotations collection
+func (m *MongoDB) createAnnotationIndexes(ctx context.Context) error {
+	collection := m.GetCollection("annotations")
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{"document_id", 1}, {"anchor.start", 1}},
+		},
+		{
+			Keys: bson.D{{"series_id", 1}, {"version", 1}, {"anchor.start", 1}},
+		},
+		{
+			Keys: bson.D{{"mentions", 1}},
+		},
+		{
+			Keys: bson.D{{"replies.mentions", 1}},
+		},
+		{
+			Keys:    bson.D{{"body", "text"}, {"replies.body", "text"}, {"anchor.quote", "text"}},
+			Options: options.Index().SetName("annotations_text"),
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+// createConnectorIndexes creates indexes for the connector_files and connector_runs collections
+func (m *MongoDB) createConnectorIndexes(ctx context.Context) error {
+	files := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{"connector_id", 1}, {"path", 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{"connector_id", 1}, {"status", 1}},
+		},
+	}
+	if _, err := m.GetCollection("connector_files").Indexes().CreateMany(ctx, files); err != nil {
+		return err
+	}
+
+	runs := []mongo.IndexModel{
+		{
+			Keys: bson.D{{"connector_id", 1}, {"started_at", -1}},
+		},
+	}
+	_, err := m.GetCollection("connector_runs").Indexes().CreateMany(ctx, runs)
+	return err
+}
+
 // createResearchIndexes creates indexes for research-related collections
 func (m *MongoDB) createResearchIndexes(ctx context.Context) error {
 	// Research results indexes
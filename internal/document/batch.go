@@ -0,0 +1,844 @@
+package document
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-government-consultant/internal/models"
+	"ai-government-consultant/internal/queue"
+	"ai-government-consultant/internal/storage"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// IngestBatchJob is the job type that unpacks an uploaded archive into documents
+const IngestBatchJob = "document.ingest_batch"
+
+const (
+	// maxArchiveSize is the largest archive accepted for bulk upload (2GB)
+	maxArchiveSize = 2 * 1024 * 1024 * 1024
+
+	// maxBatchEntries is the most files ingested from one archive
+	maxBatchEntries = 10000
+
+	// maxManifestSize is the largest manifest accepted (10MB)
+	maxManifestSize = 10 * 1024 * 1024
+)
+
+var (
+	// ErrInvalidArchive is returned when an upload is not a ZIP or TAR archive that can be ingested
+	ErrInvalidArchive = errors.New("invalid archive")
+
+	// ErrInvalidManifest is returned when a batch manifest cannot be parsed
+	ErrInvalidManifest = errors.New("invalid manifest")
+
+	// errCorruptArchive marks archive read failures that retrying will not fix
+	errCorruptArchive = errors.New("archive is corrupt or unreadable")
+)
+
+// archiveEntry is one regular file in an archive
+type archiveEntry struct {
+	Path string
+	Size int64
+	Open func() (io.ReadCloser, error)
+}
+
+// CreateBatch stores an uploaded ZIP or TAR archive and queues it to be unpacked, one document
+// per supported file. Metadata for each file comes from its manifest entry, if any, and
+// otherwise from defaults.
+func (s *Service) CreateBatch(archive *multipart.FileHeader, manifest []models.BatchManifestEntry, defaults models.BatchManifestEntry, duplicatePolicy models.DuplicatePolicy, uploadedBy primitive.ObjectID) (*models.IngestBatch, error) {
+	format := archiveFormat(archive.Filename)
+	if format == "" {
+		return nil, fmt.Errorf("%w: expected a .zip, .tar, .tar.gz or .tgz file", ErrInvalidArchive)
+	}
+	if archive.Size == 0 {
+		return nil, fmt.Errorf("%w: archive is empty", ErrInvalidArchive)
+	}
+	if archive.Size > maxArchiveSize {
+		return nil, fmt.Errorf("%w: archive exceeds maximum allowed size of %d bytes", ErrInvalidArchive, maxArchiveSize)
+	}
+
+	now := time.Now()
+	batchID := primitive.NewObjectID()
+	batch := &models.IngestBatch{
+		ID:              batchID,
+		ArchiveName:     archive.Filename,
+		ArchiveKey:      "batches/" + batchID.Hex(),
+		ArchiveSize:     archive.Size,
+		Format:          format,
+		Status:          models.BatchStatusPending,
+		Defaults:        defaults,
+		Manifest:        manifest,
+		DuplicatePolicy: duplicatePolicy,
+		Items:           []models.BatchItem{},
+		CreatedBy:       uploadedBy,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	src, err := archive.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer src.Close()
+
+	uploadCtx, uploadCancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer uploadCancel()
+
+	if err := s.blobs.Put(uploadCtx, batch.ArchiveKey, src, archive.Size, "application/octet-stream"); err != nil {
+		return nil, fmt.Errorf("failed to store archive: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := s.batches.InsertOne(ctx, batch); err != nil {
+		s.blobs.Delete(ctx, batch.ArchiveKey)
+		return nil, fmt.Errorf("failed to create batch: %w", err)
+	}
+
+	if _, err := s.jobs.Enqueue(ctx, IngestBatchJob, batchID.Hex()); err != nil {
+		s.failBatch(batch, fmt.Sprintf("failed to queue for ingestion: %s", err.Error()))
+		return nil, fmt.Errorf("failed to queue batch for ingestion: %w", err)
+	}
+
+	return batch, nil
+}
+
+// ingestBatchJob unpacks a batch's archive. Entries already recorded by an earlier attempt
+// are skipped, so a retried job picks up where the last one stopped.
+func (s *Service) ingestBatchJob(ctx context.Context, job *queue.Job) error {
+	batchID, err := primitive.ObjectIDFromHex(job.Key)
+	if err != nil {
+		return fmt.Errorf("invalid batch ID: %w", err)
+	}
+
+	var batch models.IngestBatch
+	if err := s.batches.FindOne(ctx, bson.M{"_id": batchID}).Decode(&batch); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		return fmt.Errorf("failed to find batch: %w", err)
+	}
+	if batch.Status != models.BatchStatusPending && batch.Status != models.BatchStatusExtracting {
+		return nil
+	}
+
+	s.batches.UpdateOne(ctx, bson.M{"_id": batch.ID}, bson.M{"$set": bson.M{
+		"status":     models.BatchStatusExtracting,
+		"updated_at": time.Now(),
+	}})
+
+	blob, err := s.blobs.Open(ctx, batch.ArchiveKey)
+	if err != nil {
+		if job.FinalAttempt() {
+			s.failBatch(&batch, err.Error())
+		}
+		return err
+	}
+	defer blob.Close()
+
+	recorded := make(map[string]bool, len(batch.Items))
+	for _, item := range batch.Items {
+		recorded[item.Path] = true
+	}
+
+	count := len(batch.Items)
+	limitReached := false
+	err = walkArchive(batch.Format, blob, func(entry archiveEntry) error {
+		entryPath := cleanArchivePath(entry.Path)
+		if entryPath == "" || recorded[entryPath] {
+			return nil
+		}
+		if count >= maxBatchEntries {
+			limitReached = true
+			return errStopWalk
+		}
+		count++
+
+		item := s.ingestBatchEntry(ctx, &batch, entryPath, entry)
+		_, err := s.batches.UpdateOne(ctx, bson.M{"_id": batch.ID}, bson.M{
+			"$push": bson.M{"items": item},
+			"$set":  bson.M{"updated_at": time.Now()},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record batch item: %w", err)
+		}
+		recorded[entryPath] = true
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopWalk) {
+		if errors.Is(err, errCorruptArchive) || job.FinalAttempt() {
+			s.failBatch(&batch, err.Error())
+			return nil
+		}
+		return err
+	}
+
+	set := bson.M{
+		"status":     models.BatchStatusProcessing,
+		"updated_at": time.Now(),
+	}
+	if limitReached {
+		set["error"] = fmt.Sprintf("archive has more than %d files; the rest were not ingested", maxBatchEntries)
+	}
+	_, err = s.batches.UpdateOne(ctx, bson.M{"_id": batch.ID}, bson.M{
+		"$set":   set,
+		"$unset": bson.M{"archive_key": ""},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update batch: %w", err)
+	}
+	s.blobs.Delete(ctx, batch.ArchiveKey)
+	return nil
+}
+
+// ingestBatchEntry stores one archive entry as a document queued for processing and returns its batch item
+func (s *Service) ingestBatchEntry(ctx context.Context, batch *models.IngestBatch, entryPath string, entry archiveEntry) models.BatchItem {
+	item := models.BatchItem{Path: entryPath}
+	name := path.Base(entryPath)
+	ext := strings.ToLower(filepath.Ext(name))
+
+	switch {
+	case !SupportedFormats[ext]:
+		item.Status = models.BatchItemSkipped
+		item.Error = fmt.Sprintf("unsupported file format: %s", ext)
+		return item
+	case entry.Size == 0:
+		item.Status = models.BatchItemSkipped
+		item.Error = "file is empty"
+		return item
+	case entry.Size > MaxDocumentSize:
+		item.Status = models.BatchItemFailed
+		item.Error = fmt.Sprintf("file size exceeds maximum allowed size of %d bytes", MaxDocumentSize)
+		return item
+	}
+
+	rc, err := entry.Open()
+	if err != nil {
+		item.Status = models.BatchItemFailed
+		item.Error = fmt.Sprintf("failed to read file: %s", err.Error())
+		return item
+	}
+	data, err := io.ReadAll(io.LimitReader(rc, MaxDocumentSize+1))
+	rc.Close()
+	if err != nil {
+		item.Status = models.BatchItemFailed
+		item.Error = fmt.Sprintf("failed to read file: %s", err.Error())
+		return item
+	}
+	if len(data) > MaxDocumentSize {
+		item.Status = models.BatchItemFailed
+		item.Error = fmt.Sprintf("file size exceeds maximum allowed size of %d bytes", MaxDocumentSize)
+		return item
+	}
+
+	meta := batchEntryMetadata(batch, entryPath)
+	metadata := models.DocumentMetadata{
+		Category:     meta.Category,
+		Tags:         meta.Tags,
+		Language:     meta.Language,
+		CustomFields: map[string]interface{}{},
+	}
+	if metadata.Category == "" {
+		metadata.Category = models.DocumentCategoryGeneral
+	}
+	if metadata.Tags == nil {
+		metadata.Tags = []string{}
+	}
+	if meta.Title != "" {
+		metadata.Title = &meta.Title
+	}
+	if meta.Author != "" {
+		metadata.Author = &meta.Author
+	}
+	if meta.Department != "" {
+		metadata.Department = &meta.Department
+	}
+	for key, value := range meta.CustomFields {
+		metadata.CustomFields[key] = value
+	}
+	metadata.CustomFields["archive_path"] = entryPath
+
+	docID, batchID := primitive.NewObjectID(), batch.ID
+	doc := &models.Document{
+		ID:               docID,
+		Name:             name,
+		BlobKey:          blobKey(docID),
+		ContentType:      contentTypeFor(name),
+		Size:             int64(len(data)),
+		UploadedBy:       batch.CreatedBy,
+		UploadedAt:       time.Now(),
+		Classification:   models.SecurityClassification{Level: "INTERNAL"}, // Default classification
+		Metadata:         metadata,
+		ProcessingStatus: models.ProcessingStatusPending,
+		DuplicatePolicy:  batch.DuplicatePolicy,
+		ContentHash:      fmt.Sprintf("%x", sha256.Sum256(data)),
+		BatchID:          &batchID,
+	}
+	if meta.Classification != "" {
+		declared := models.SecurityClassification{
+			Level:        meta.Classification,
+			Compartments: nonNil(meta.Compartments),
+			Handling:     nonNil(meta.Handling),
+		}
+		doc.Classification = declared
+		doc.DeclaredClassification = &declared
+	}
+
+	if err := doc.Validate(); err != nil {
+		item.Status = models.BatchItemFailed
+		item.Error = fmt.Sprintf("document validation failed: %s", err.Error())
+		return item
+	}
+	if err := s.validateMetadata(ctx, &doc.Metadata); err != nil {
+		item.Status = models.BatchItemFailed
+		item.Error = err.Error()
+		return item
+	}
+
+	// An identical file can be rejected straight away, as for single uploads
+	if batch.DuplicatePolicy == models.DuplicatePolicyReject {
+		canonical, err := s.findExactDuplicate(ctx, doc)
+		if err != nil {
+			item.Status = models.BatchItemFailed
+			item.Error = err.Error()
+			return item
+		}
+		if canonical != nil {
+			item.Status = models.BatchItemDuplicate
+			item.Error = fmt.Sprintf("document is identical to existing document %s", canonical.ID.Hex())
+			item.DuplicateOf = &canonical.ID
+			return item
+		}
+	}
+
+	if err := s.blobs.Put(ctx, doc.BlobKey, bytes.NewReader(data), doc.Size, doc.ContentType); err != nil {
+		item.Status = models.BatchItemFailed
+		item.Error = fmt.Sprintf("failed to store file: %s", err.Error())
+		return item
+	}
+	if err := s.assignRetention(ctx, doc); err != nil {
+		s.blobs.Delete(ctx, doc.BlobKey)
+		item.Status = models.BatchItemFailed
+		item.Error = err.Error()
+		return item
+	}
+	if _, err := s.collection.InsertOne(ctx, doc); err != nil {
+		s.blobs.Delete(ctx, doc.BlobKey)
+		item.Status = models.BatchItemFailed
+		item.Error = fmt.Sprintf("failed to insert document: %s", err.Error())
+		return item
+	}
+
+	// A queueing failure marks the document failed, which the item's status then reflects
+	s.queueProcessing(doc.ID)
+
+	item.DocumentID = &doc.ID
+	item.Status = string(models.ProcessingStatusPending)
+	return item
+}
+
+// GetBatch returns a batch with each item's status brought up to date from its document
+func (s *Service) GetBatch(batchID string) (*models.IngestBatch, error) {
+	objID, err := primitive.ObjectIDFromHex(batchID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid batch ID: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var batch models.IngestBatch
+	err = s.batches.FindOne(ctx, bson.M{"_id": objID}, options.FindOne().SetProjection(bson.M{"manifest": 0})).Decode(&batch)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("batch not found")
+		}
+		return nil, fmt.Errorf("failed to find batch: %w", err)
+	}
+
+	if err := s.refreshBatch(ctx, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// ListBatches returns batches newest first, without their items. When createdBy is set only
+// that user's batches are listed.
+func (s *Service) ListBatches(createdBy *primitive.ObjectID, limit, skip int) ([]*models.IngestBatch, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if createdBy != nil {
+		filter["created_by"] = *createdBy
+	}
+
+	total, err := s.batches.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count batches: %w", err)
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(skip)).
+		SetProjection(bson.M{"items": 0, "manifest": 0})
+
+	cursor, err := s.batches.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find batches: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var batches []*models.IngestBatch
+	if err := cursor.All(ctx, &batches); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode batches: %w", err)
+	}
+	return batches, total, nil
+}
+
+// refreshBatch sets each item's status from its document, counts progress and, once every
+// document has finished processing, records the batch as completed
+func (s *Service) refreshBatch(ctx context.Context, batch *models.IngestBatch) error {
+	var ids []primitive.ObjectID
+	for _, item := range batch.Items {
+		if item.DocumentID != nil {
+			ids = append(ids, *item.DocumentID)
+		}
+	}
+
+	type documentStatus struct {
+		ID               primitive.ObjectID      `bson:"_id"`
+		ProcessingStatus models.ProcessingStatus `bson:"processing_status"`
+		ProcessingError  *string                 `bson:"processing_error"`
+	}
+	statuses := make(map[primitive.ObjectID]documentStatus, len(ids))
+	if len(ids) > 0 {
+		cursor, err := s.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}},
+			options.Find().SetProjection(bson.M{"processing_status": 1, "processing_error": 1}))
+		if err != nil {
+			return fmt.Errorf("failed to find batch documents: %w", err)
+		}
+		defer cursor.Close(ctx)
+
+		for cursor.Next(ctx) {
+			var status documentStatus
+			if err := cursor.Decode(&status); err != nil {
+				return fmt.Errorf("failed to decode document: %w", err)
+			}
+			statuses[status.ID] = status
+		}
+		if err := cursor.Err(); err != nil {
+			return fmt.Errorf("failed to find batch documents: %w", err)
+		}
+	}
+
+	progress := &models.BatchProgress{Total: len(batch.Items)}
+	for i := range batch.Items {
+		item := &batch.Items[i]
+		if item.DocumentID != nil {
+			status, ok := statuses[*item.DocumentID]
+			switch {
+			case !ok:
+				item.Status = string(models.ProcessingStatusFailed)
+				item.Error = "document was deleted"
+			default:
+				item.Status = string(status.ProcessingStatus)
+				item.Error = ""
+				if status.ProcessingError != nil {
+					item.Error = *status.ProcessingError
+				}
+			}
+		}
+
+		switch item.Status {
+		case string(models.ProcessingStatusPending):
+			progress.Pending++
+		case string(models.ProcessingStatusProcessing):
+			progress.Processing++
+		case string(models.ProcessingStatusCompleted):
+			progress.Completed++
+		case models.BatchItemSkipped:
+			progress.Skipped++
+		case models.BatchItemDuplicate:
+			progress.Duplicate++
+		default:
+			progress.Failed++
+		}
+	}
+	batch.Progress = progress
+
+	if batch.Status == models.BatchStatusProcessing && progress.Pending == 0 && progress.Processing == 0 {
+		now := time.Now()
+		batch.Status = models.BatchStatusCompleted
+		if progress.Failed > 0 || batch.Error != "" {
+			batch.Status = models.BatchStatusCompletedWithErrors
+		}
+		batch.CompletedAt = &now
+		_, err := s.batches.UpdateOne(ctx, bson.M{"_id": batch.ID, "status": models.BatchStatusProcessing}, bson.M{"$set": bson.M{
+			"status":       batch.Status,
+			"completed_at": now,
+			"updated_at":   now,
+		}})
+		if err != nil {
+			return fmt.Errorf("failed to update batch: %w", err)
+		}
+	}
+	return nil
+}
+
+// failBatch records that a batch's archive could not be ingested and discards the archive
+func (s *Service) failBatch(batch *models.IngestBatch, reason string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	s.batches.UpdateOne(ctx, bson.M{"_id": batch.ID}, bson.M{
+		"$set": bson.M{
+			"status":       models.BatchStatusFailed,
+			"error":        reason,
+			"completed_at": now,
+			"updated_at":   now,
+		},
+		"$unset": bson.M{"archive_key": ""},
+	})
+	if batch.ArchiveKey != "" {
+		s.blobs.Delete(ctx, batch.ArchiveKey)
+	}
+}
+
+// batchEntryMetadata returns the manifest entry for an archive path, falling back to a unique
+// match on the file name, with the batch defaults filling anything it leaves out
+func batchEntryMetadata(batch *models.IngestBatch, entryPath string) models.BatchManifestEntry {
+	var match *models.BatchManifestEntry
+	for i := range batch.Manifest {
+		if batch.Manifest[i].Path == entryPath {
+			match = &batch.Manifest[i]
+			break
+		}
+	}
+	if match == nil {
+		name := path.Base(entryPath)
+		for i := range batch.Manifest {
+			if path.Base(batch.Manifest[i].Path) == name {
+				if match != nil {
+					match = nil // ambiguous
+					break
+				}
+				match = &batch.Manifest[i]
+			}
+		}
+	}
+
+	meta := batch.Defaults
+	if match == nil {
+		return meta
+	}
+	if match.Title != "" {
+		meta.Title = match.Title
+	}
+	if match.Author != "" {
+		meta.Author = match.Author
+	}
+	if match.Department != "" {
+		meta.Department = match.Department
+	}
+	if match.Category != "" {
+		meta.Category = match.Category
+	}
+	if len(match.Tags) > 0 {
+		meta.Tags = match.Tags
+	}
+	if match.Language != "" {
+		meta.Language = match.Language
+	}
+	if match.Classification != "" {
+		meta.Classification = match.Classification
+		meta.Compartments = match.Compartments
+		meta.Handling = match.Handling
+	}
+	if len(match.CustomFields) > 0 {
+		fields := make(map[string]interface{}, len(meta.CustomFields)+len(match.CustomFields))
+		for key, value := range meta.CustomFields {
+			fields[key] = value
+		}
+		for key, value := range match.CustomFields {
+			fields[key] = value
+		}
+		meta.CustomFields = fields
+	}
+	return meta
+}
+
+// ParseManifest parses a CSV or JSON batch manifest. A CSV manifest has a header row naming its
+// columns (path is required; title, author, department, category, tags, language,
+// classification, compartments and handling are optional) with list values separated by
+// semicolons; columns named custom_fields.<name> set custom fields. A JSON manifest is an array
+// of objects with the same fields.
+func ParseManifest(file *multipart.FileHeader) ([]models.BatchManifestEntry, error) {
+	if file.Size > maxManifestSize {
+		return nil, fmt.Errorf("%w: manifest exceeds maximum allowed size of %d bytes", ErrInvalidManifest, maxManifestSize)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest: %w", err)
+	}
+	defer src.Close()
+
+	var entries []models.BatchManifestEntry
+	switch strings.ToLower(filepath.Ext(file.Filename)) {
+	case ".json":
+		if err := json.NewDecoder(src).Decode(&entries); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidManifest, err)
+		}
+	case ".csv":
+		if entries, err = readCSVManifest(src); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: expected a .csv or .json file", ErrInvalidManifest)
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+		entry.Path = cleanArchivePath(entry.Path)
+		if entry.Path == "" {
+			return nil, fmt.Errorf("%w: entry %d has no valid path", ErrInvalidManifest, i+1)
+		}
+		if entry.Classification != "" {
+			entry.Classification = strings.ToUpper(strings.Join(strings.Fields(entry.Classification), "_"))
+			if models.ClassificationRank(entry.Classification) < 0 {
+				return nil, fmt.Errorf("%w: entry %q has unknown classification %q", ErrInvalidManifest, entry.Path, entry.Classification)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// customFieldColumn prefixes the CSV manifest columns that set custom fields
+const customFieldColumn = "custom_fields."
+
+// readCSVManifest parses a CSV manifest with a header row
+func readCSVManifest(r io.Reader) ([]models.BatchManifestEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidManifest, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["path"]; !ok {
+		return nil, fmt.Errorf("%w: CSV manifest needs a path column", ErrInvalidManifest)
+	}
+	customColumns := make(map[string]int)
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		if len(name) > len(customFieldColumn) && strings.EqualFold(name[:len(customFieldColumn)], customFieldColumn) {
+			customColumns[name[len(customFieldColumn):]] = i
+		}
+	}
+
+	var entries []models.BatchManifestEntry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidManifest, err)
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		list := func(name string) []string {
+			var values []string
+			for _, value := range strings.Split(field(name), ";") {
+				if value = strings.TrimSpace(value); value != "" {
+					values = append(values, value)
+				}
+			}
+			return values
+		}
+
+		var customFields map[string]interface{}
+		for name, i := range customColumns {
+			if i < len(record) && strings.TrimSpace(record[i]) != "" {
+				if customFields == nil {
+					customFields = make(map[string]interface{})
+				}
+				customFields[name] = strings.TrimSpace(record[i])
+			}
+		}
+
+		entries = append(entries, models.BatchManifestEntry{
+			Path:           field("path"),
+			Title:          field("title"),
+			Author:         field("author"),
+			Department:     field("department"),
+			Category:       models.DocumentCategory(field("category")),
+			Tags:           list("tags"),
+			Language:       field("language"),
+			Classification: field("classification"),
+			Compartments:   list("compartments"),
+			Handling:       list("handling"),
+			CustomFields:   customFields,
+		})
+	}
+	return entries, nil
+}
+
+// errStopWalk ends an archive walk early without an error
+var errStopWalk = errors.New("stop walking archive")
+
+// walkArchive calls visit for every regular file in the archive
+func walkArchive(format string, blob storage.Blob, visit func(archiveEntry) error) error {
+	switch format {
+	case "zip":
+		reader, err := zip.NewReader(&blobReaderAt{blob: blob}, blob.Size())
+		if err != nil {
+			return fmt.Errorf("%w: %v", errCorruptArchive, err)
+		}
+		for _, file := range reader.File {
+			if !file.Mode().IsRegular() {
+				continue
+			}
+			entry := archiveEntry{
+				Path: file.Name,
+				Size: int64(file.UncompressedSize64),
+				Open: file.Open,
+			}
+			if err := visit(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "tar", "tar.gz":
+		var src io.Reader = blob
+		if format == "tar.gz" {
+			gz, err := gzip.NewReader(blob)
+			if err != nil {
+				return fmt.Errorf("%w: %v", errCorruptArchive, err)
+			}
+			defer gz.Close()
+			src = gz
+		}
+		reader := tar.NewReader(src)
+		for {
+			header, err := reader.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("%w: %v", errCorruptArchive, err)
+			}
+			if header.Typeflag != tar.TypeReg {
+				continue
+			}
+			entry := archiveEntry{
+				Path: header.Name,
+				Size: header.Size,
+				Open: func() (io.ReadCloser, error) {
+					return io.NopCloser(reader), nil
+				},
+			}
+			if err := visit(entry); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%w: unknown format %q", errCorruptArchive, format)
+	}
+}
+
+// blobReaderAt adapts a seekable blob to io.ReaderAt for reading ZIP archives
+type blobReaderAt struct {
+	mu   sync.Mutex
+	blob storage.Blob
+}
+
+func (r *blobReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.blob.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.ReadFull(r.blob, p)
+}
+
+// archiveFormat returns the archive format implied by a file name, or "" if it is not an archive
+func archiveFormat(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return "zip"
+	case strings.HasSuffix(name, ".tar"):
+		return "tar"
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return "tar.gz"
+	}
+	return ""
+}
+
+// cleanArchivePath normalizes an archive entry path to a relative slash-separated path. It
+// returns "" for paths that escape the archive and for operating system metadata such as
+// __MACOSX folders, AppleDouble "._" files and .DS_Store.
+func cleanArchivePath(entryPath string) string {
+	cleaned := path.Clean("/" + strings.ReplaceAll(entryPath, "\\", "/"))
+	cleaned = strings.TrimPrefix(cleaned, "/")
+	if cleaned == "" || cleaned == "." {
+		return ""
+	}
+	for _, part := range strings.Split(cleaned, "/") {
+		if part == "__MACOSX" || part == ".DS_Store" || part == "Thumbs.db" || strings.HasPrefix(part, "._") {
+			return ""
+		}
+	}
+	return cleaned
+}
+
+// contentTypeFor guesses a file's content type from its extension
+func contentTypeFor(name string) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(name)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
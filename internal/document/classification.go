@@ -0,0 +1,354 @@
+package document
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"ai-government-consultant/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Classification review statuses
+const (
+	ClassificationReviewPending  = "pending"
+	ClassificationReviewResolved = "resolved"
+)
+
+var (
+	// bannerPattern matches a line holding only a classification banner such as
+	// "SECRET//NOFORN" or "UNCLASSIFIED//FOR OFFICIAL USE ONLY"
+	bannerPattern = regexp.MustCompile(`(?m)^[ \t]*(?:CLASSIFICATION:[ \t]*)?((?:TOP SECRET|SECRET|CONFIDENTIAL|UNCLASSIFIED|CONTROLLED UNCLASSIFIED INFORMATION|CUI|FOR OFFICIAL USE ONLY)(?://[A-Z0-9 ,/-]*[A-Z0-9])?)[ \t]*$`)
+
+	// disseminationBannerPattern matches a line holding only dissemination controls, such as a
+	// "NOFORN" or "REL TO USA, GBR" line printed under the banner
+	disseminationBannerPattern = regexp.MustCompile(`(?m)^[ \t]*((?:NOFORN|ORCON|PROPIN|REL TO [A-Z]{3,4}(?:,[ \t]*[A-Z]{3,4})*)(?:/(?:NOFORN|ORCON|PROPIN|REL TO [A-Z]{3,4}(?:,[ \t]*[A-Z]{3,4})*))*)[ \t]*$`)
+
+	// portionPattern matches a portion marking such as "(C)", "(S//NF)" or "(U//FOUO)" at the
+	// start of a paragraph, optionally after a list number
+	portionPattern = regexp.MustCompile(`(?m)^[ \t]*(?:[0-9]+[.)][ \t]+|[-*\x{2022}][ \t]+)?(\((?:TS|S|C|U|CUI)(?://[A-Z0-9 ,/-]*[A-Z0-9])?\))[ \t]`)
+
+	// letterListPattern matches a paragraph lettered "(A)", "(B)", ... in legal or outline
+	// style; its presence makes bare "(C)", "(S)" and "(U)" markers ambiguous
+	letterListPattern = regexp.MustCompile(`(?m)^[ \t]*\([ABD-RTV-Z]\)[ \t]`)
+
+	// unclassifiedControls are dissemination controls that put unclassified text above PUBLIC
+	unclassifiedControls = map[string]bool{
+		"FOUO": true, "CUI": true, "LES": true, "SBU": true, "LIMDIS": true, "EXDIS": true, "NODIS": true,
+	}
+
+	// controlAbbreviations expands the short forms used in portion markings
+	controlAbbreviations = map[string]string{
+		"NF":                    "NOFORN",
+		"OC":                    "ORCON",
+		"PR":                    "PROPIN",
+		"IMC":                   "IMCON",
+		"FOR OFFICIAL USE ONLY": "FOUO",
+	}
+
+	// disseminationControls are the dissemination and handling controls; any other token in
+	// a classified marking names a compartment or control system
+	disseminationControls = map[string]bool{
+		"NOFORN": true, "ORCON": true, "PROPIN": true, "IMCON": true, "RELIDO": true, "FISA": true,
+		"DSEN": true, "FOUO": true, "CUI": true, "LES": true, "SBU": true, "LIMDIS": true,
+		"EXDIS": true, "NODIS": true,
+	}
+)
+
+// classificationDetection is the result of parsing a document's classification markings
+type classificationDetection struct {
+	Markings       []models.ClassificationMarking
+	Classification models.SecurityClassification // highest level marked, with every compartment and control
+	BannerLevel    string                        // highest level among banner lines, if any
+	PortionLevel   string                        // highest level among portion markings, if any
+}
+
+// detectClassification parses banner lines and portion markings in content. It returns nil
+// when the document carries no classification markings.
+func detectClassification(content string) *classificationDetection {
+	detection := &classificationDetection{}
+	compartments := newOrderedSet()
+	handling := newOrderedSet()
+
+	add := func(kind string, match []int) {
+		text := content[match[2]:match[3]]
+		level, markingCompartments, markingHandling := parseMarking(strings.Trim(text, "()"))
+		compartments.add(markingCompartments...)
+		handling.add(markingHandling...)
+
+		detection.Markings = append(detection.Markings, models.ClassificationMarking{
+			Text:     text,
+			Kind:     kind,
+			Level:    level,
+			StartPos: match[2],
+			EndPos:   match[3],
+		})
+		if kind == "banner" {
+			detection.BannerLevel = higherClassification(detection.BannerLevel, level)
+		} else {
+			detection.PortionLevel = higherClassification(detection.PortionLevel, level)
+		}
+	}
+
+	for _, match := range bannerPattern.FindAllStringSubmatchIndex(content, -1) {
+		add("banner", match)
+	}
+	for _, match := range disseminationBannerPattern.FindAllStringSubmatchIndex(content, -1) {
+		add("banner", match)
+	}
+
+	ambiguousLetters := letterListPattern.MatchString(content)
+	for _, match := range portionPattern.FindAllStringSubmatchIndex(content, -1) {
+		marking := content[match[2]:match[3]]
+		if ambiguousLetters && (marking == "(C)" || marking == "(S)" || marking == "(U)") {
+			continue
+		}
+		add("portion", match)
+	}
+
+	if len(detection.Markings) == 0 {
+		return nil
+	}
+
+	detection.Classification = models.SecurityClassification{
+		Level:        higherClassification(detection.BannerLevel, detection.PortionLevel),
+		Compartments: compartments.values,
+		Handling:     handling.values,
+	}
+	return detection
+}
+
+// parseMarking splits a marking such as "TOP SECRET//SI/TK//NOFORN" into its level,
+// compartments and handling controls. Markings carrying only controls have an empty level.
+func parseMarking(marking string) (string, []string, []string) {
+	segments := strings.Split(marking, "//")
+
+	level := ""
+	var compartments, handling []string
+	switch strings.TrimSpace(segments[0]) {
+	case "TOP SECRET", "TS":
+		level = "TOP_SECRET"
+	case "SECRET", "S":
+		level = "SECRET"
+	case "CONFIDENTIAL", "C":
+		level = "CONFIDENTIAL"
+	case "UNCLASSIFIED", "U":
+		level = "PUBLIC"
+	case "CUI", "CONTROLLED UNCLASSIFIED INFORMATION":
+		level = "INTERNAL"
+		handling = append(handling, "CUI")
+	case "FOR OFFICIAL USE ONLY":
+		level = "INTERNAL"
+		handling = append(handling, "FOUO")
+	default:
+		// A dissemination-only line such as "NOFORN"
+		segments = append([]string{""}, segments...)
+	}
+
+	for _, segment := range segments[1:] {
+		for _, token := range strings.Split(segment, "/") {
+			token = strings.Join(strings.Fields(token), " ")
+			if token == "" {
+				continue
+			}
+			if expanded, ok := controlAbbreviations[token]; ok {
+				token = expanded
+			}
+			switch {
+			case disseminationControls[token], strings.HasPrefix(token, "REL TO "), strings.HasPrefix(token, "DISPLAY ONLY "):
+				handling = append(handling, token)
+			case level == "INTERNAL" || level == "PUBLIC":
+				// CUI categories and other unclassified markings are handling instructions
+				handling = append(handling, token)
+			default:
+				compartments = append(compartments, token)
+			}
+		}
+	}
+
+	if level == "PUBLIC" {
+		for _, control := range handling {
+			if unclassifiedControls[control] {
+				level = "INTERNAL"
+				break
+			}
+		}
+	}
+	return level, compartments, handling
+}
+
+// applyClassification sets the document's classification from its markings and flags it for
+// review when the markings disagree with the declared classification or with each other.
+// Where they disagree the higher level applies until a reviewer decides.
+func applyClassification(doc *models.Document, detection *classificationDetection) {
+	doc.ClassificationMarkings = nil
+	if detection == nil {
+		if doc.ClassificationReview != nil && doc.ClassificationReview.Status == ClassificationReviewPending {
+			doc.ClassificationReview = nil
+		}
+		return
+	}
+	doc.ClassificationMarkings = detection.Markings
+
+	detected := detection.Classification
+	var reasons []string
+	if detection.BannerLevel != "" && models.ClassificationRank(detection.PortionLevel) > models.ClassificationRank(detection.BannerLevel) {
+		reasons = append(reasons, fmt.Sprintf("portion markings reach %s but the banner is %s", detection.PortionLevel, detection.BannerLevel))
+	}
+
+	effective := detected
+	declared := doc.DeclaredClassification
+	if declared != nil {
+		if detected.Level != "" && declared.Level != detected.Level {
+			reasons = append(reasons, fmt.Sprintf("declared %s but the markings indicate %s", declared.Level, detected.Level))
+		}
+		effective = models.SecurityClassification{
+			Level:                higherClassification(declared.Level, detected.Level),
+			Compartments:         newOrderedSet().add(declared.Compartments...).add(detected.Compartments...).values,
+			Handling:             newOrderedSet().add(declared.Handling...).add(detected.Handling...).values,
+			DeclassificationDate: declared.DeclassificationDate,
+		}
+	} else if effective.Level == "" {
+		// Controls without a level only add to the current classification
+		effective = models.SecurityClassification{
+			Level:                doc.Classification.Level,
+			Compartments:         newOrderedSet().add(doc.Classification.Compartments...).add(detected.Compartments...).values,
+			Handling:             newOrderedSet().add(doc.Classification.Handling...).add(detected.Handling...).values,
+			DeclassificationDate: doc.Classification.DeclassificationDate,
+		}
+	}
+
+	if prior := doc.ClassificationReview; prior != nil && prior.Status == ClassificationReviewResolved && prior.DetectedLevel == detected.Level {
+		// A reviewer already ruled on these markings; the classification they set stands
+		if declared != nil {
+			effective = *declared
+		}
+	} else if len(reasons) > 0 {
+		review := &models.ClassificationReview{
+			Status:        ClassificationReviewPending,
+			Reasons:       reasons,
+			DetectedLevel: detected.Level,
+			FlaggedAt:     time.Now(),
+		}
+		if declared != nil {
+			review.DeclaredLevel = declared.Level
+		}
+		doc.ClassificationReview = review
+	} else {
+		doc.ClassificationReview = nil
+	}
+
+	doc.Classification = effective
+}
+
+// ListClassificationReviews returns documents whose classification is waiting for review, oldest flag first
+func (s *Service) ListClassificationReviews(limit, skip int) ([]*models.Document, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"classification_review.status": ClassificationReviewPending}
+	total, err := s.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count classification reviews: %w", err)
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "classification_review.flagged_at", Value: 1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(skip)).
+		SetProjection(bson.M{
+			"content":           0,
+			"raw_content":       0,
+			"redacted_content":  0,
+			"embeddings":        0,
+			"shadow_embeddings": 0,
+			"minhash":           0,
+		})
+
+	cursor, err := s.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find classification reviews: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var documents []*models.Document
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode documents: %w", err)
+	}
+	return documents, total, nil
+}
+
+// SetClassification records a reviewer's classification for a document. It becomes the declared
+// classification, so later reprocessing keeps it, and resolves any pending review.
+func (s *Service) SetClassification(documentID string, classification models.SecurityClassification, reviewedBy primitive.ObjectID) (*models.Document, error) {
+	if models.ClassificationRank(classification.Level) < 0 {
+		return nil, fmt.Errorf("invalid classification level: %s", classification.Level)
+	}
+	if classification.Compartments == nil {
+		classification.Compartments = []string{}
+	}
+	if classification.Handling == nil {
+		classification.Handling = []string{}
+	}
+
+	doc, err := s.GetProcessingStatus(documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	set := bson.M{
+		"classification":          classification,
+		"declared_classification": classification,
+	}
+	if review := doc.ClassificationReview; review != nil {
+		now := time.Now()
+		review.Status = ClassificationReviewResolved
+		review.ResolvedBy = &reviewedBy
+		review.ResolvedAt = &now
+		set["classification_review"] = review
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := s.collection.UpdateOne(ctx, bson.M{"_id": doc.ID}, bson.M{"$set": set}); err != nil {
+		return nil, fmt.Errorf("failed to update classification: %w", err)
+	}
+
+	doc.Classification = classification
+	doc.DeclaredClassification = &classification
+	return doc, nil
+}
+
+// higherClassification returns the higher of two classification levels, ignoring empty ones
+func higherClassification(a, b string) string {
+	if models.ClassificationRank(b) > models.ClassificationRank(a) {
+		return b
+	}
+	return a
+}
+
+// orderedSet collects strings without duplicates, keeping first-seen order
+type orderedSet struct {
+	seen   map[string]bool
+	values []string
+}
+
+func newOrderedSet() *orderedSet {
+	return &orderedSet{seen: map[string]bool{}, values: []string{}}
+}
+
+func (s *orderedSet) add(values ...string) *orderedSet {
+	for _, value := range values {
+		if !s.seen[value] {
+			s.seen[value] = true
+			s.values = append(s.values, value)
+		}
+	}
+	return s
+}
@@ -0,0 +1,481 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	zipSignature = []byte("PK\x03\x04")
+	oleSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+)
+
+// maxPackagePartSize bounds how much of a single package part is decompressed
+const maxPackagePartSize = 64 << 20
+
+// openPackage opens an Office Open XML or OpenDocument zip package
+func openPackage(data []byte) (*zip.Reader, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open document package: %w", err)
+	}
+	return reader, nil
+}
+
+// readPackagePart returns the contents of a named part, or nil if the part does not exist
+func readPackagePart(pkg *zip.Reader, name string) ([]byte, error) {
+	for _, file := range pkg.File {
+		if file.Name != name {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		defer rc.Close()
+
+		data, err := io.ReadAll(io.LimitReader(rc, maxPackagePartSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if len(data) > maxPackagePartSize {
+			return nil, fmt.Errorf("%s exceeds maximum part size", name)
+		}
+		return data, nil
+	}
+	return nil, nil
+}
+
+// xmlAttr returns the value of the attribute with the given local name
+func xmlAttr(el xml.StartElement, local string) string {
+	for _, attr := range el.Attr {
+		if attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// readXMLFields returns the text of the first element with each of the given local names
+func readXMLFields(data []byte, names ...string) map[string]string {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	fields := make(map[string]string)
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var current string
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return fields
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			current = ""
+			if wanted[t.Name.Local] {
+				if _, done := fields[t.Name.Local]; !done {
+					current = t.Name.Local
+				}
+			}
+		case xml.CharData:
+			if current != "" {
+				fields[current] += string(t)
+			}
+		case xml.EndElement:
+			if current != "" {
+				fields[current] = strings.TrimSpace(fields[current])
+			}
+			current = ""
+		}
+	}
+}
+
+// parsePackageDate parses the W3CDTF timestamps used in document properties
+func parsePackageDate(value string) *time.Time {
+	layouts := []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02"}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+// docxStyle is the subset of a paragraph style definition relevant to the outline
+type docxStyle struct {
+	name     string
+	basedOn  string
+	outline  int // 1-based outline level, 0 when the style sets none
+	numbered bool
+}
+
+// docxReader walks the parts of a WordprocessingML package
+type docxReader struct {
+	styles map[string]*docxStyle
+}
+
+// docxParagraph accumulates the text and properties of a w:p element
+type docxParagraph struct {
+	text     strings.Builder
+	style    string
+	outline  int
+	numbered bool
+}
+
+// extractTextFromDOCX extracts text, the heading outline, page headers/footers,
+// footnotes and comments from an Office Open XML document
+func (s *Service) extractTextFromDOCX(data []byte) (*extractionResult, error) {
+	pkg, err := openPackage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	mainPart := docxMainPart(pkg)
+	document, err := readPackagePart(pkg, mainPart)
+	if err != nil {
+		return nil, err
+	}
+	if document == nil {
+		return nil, fmt.Errorf("document package has no main document part")
+	}
+
+	reader := &docxReader{styles: make(map[string]*docxStyle)}
+	dir := path.Dir(mainPart)
+	if styles, err := readPackagePart(pkg, path.Join(dir, "styles.xml")); err == nil && styles != nil {
+		reader.parseStyles(styles)
+	}
+
+	body, err := reader.parsePart(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", mainPart, err)
+	}
+
+	var front, back []textBlock
+	for _, part := range docxPartsWithPrefix(pkg, dir, "header") {
+		blocks, err := reader.parsePart(part)
+		if err == nil {
+			front = append(front, blocks...)
+		}
+	}
+	for _, name := range []string{"footnotes.xml", "endnotes.xml", "comments.xml"} {
+		part, err := readPackagePart(pkg, path.Join(dir, name))
+		if err != nil || part == nil {
+			continue
+		}
+		if blocks, err := reader.parsePart(part); err == nil {
+			back = append(back, blocks...)
+		}
+	}
+	for _, part := range docxPartsWithPrefix(pkg, dir, "footer") {
+		blocks, err := reader.parsePart(part)
+		if err == nil {
+			back = append(back, blocks...)
+		}
+	}
+
+	result := newStructuredResult(front, body, back)
+	if strings.TrimSpace(result.Text) == "" {
+		return nil, fmt.Errorf("document contains no extractable text")
+	}
+
+	if core, err := readPackagePart(pkg, "docProps/core.xml"); err == nil && core != nil {
+		fields := readXMLFields(core, "title", "creator", "created")
+		if title := fields["title"]; title != "" {
+			result.Title = &title
+		}
+		if author := fields["creator"]; author != "" {
+			result.Author = &author
+		}
+		result.CreatedDate = parsePackageDate(fields["created"])
+	}
+
+	return result, nil
+}
+
+// docxMainPart locates the main document part through the package relationships
+func docxMainPart(pkg *zip.Reader) string {
+	rels, err := readPackagePart(pkg, "_rels/.rels")
+	if err == nil && rels != nil {
+		dec := xml.NewDecoder(bytes.NewReader(rels))
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				break
+			}
+			if el, ok := tok.(xml.StartElement); ok && el.Name.Local == "Relationship" {
+				if strings.HasSuffix(xmlAttr(el, "Type"), "/officeDocument") {
+					return strings.TrimPrefix(xmlAttr(el, "Target"), "/")
+				}
+			}
+		}
+	}
+	return "word/document.xml"
+}
+
+// docxPartsWithPrefix returns the contents of parts such as header1.xml, header2.xml in name order
+func docxPartsWithPrefix(pkg *zip.Reader, dir, prefix string) [][]byte {
+	var names []string
+	for _, file := range pkg.File {
+		if path.Dir(file.Name) == dir && strings.HasPrefix(path.Base(file.Name), prefix) && strings.HasSuffix(file.Name, ".xml") {
+			names = append(names, file.Name)
+		}
+	}
+	sort.Strings(names)
+
+	var parts [][]byte
+	for _, name := range names {
+		if data, err := readPackagePart(pkg, name); err == nil && data != nil {
+			parts = append(parts, data)
+		}
+	}
+	return parts
+}
+
+// parseStyles records the outline level and numbering of each paragraph style
+func (r *docxReader) parseStyles(data []byte) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var current *docxStyle
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "style":
+				current = nil
+				if xmlAttr(t, "type") == "paragraph" {
+					current = &docxStyle{}
+					r.styles[xmlAttr(t, "styleId")] = current
+				}
+			case "name":
+				if current != nil {
+					current.name = xmlAttr(t, "val")
+				}
+			case "basedOn":
+				if current != nil {
+					current.basedOn = xmlAttr(t, "val")
+				}
+			case "outlineLvl":
+				if current != nil {
+					if level, err := strconv.Atoi(xmlAttr(t, "val")); err == nil && level < maxHeadingLevel {
+						current.outline = level + 1
+					}
+				}
+			case "numId":
+				if current != nil && xmlAttr(t, "val") != "0" {
+					current.numbered = true
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "style" {
+				current = nil
+			}
+		}
+	}
+}
+
+// styleOutline resolves a style's heading level and numbering through its basedOn chain
+func (r *docxReader) styleOutline(styleID string) (int, bool) {
+	level, numbered := 0, false
+	for depth := 0; styleID != "" && depth < 10; depth++ {
+		style, ok := r.styles[styleID]
+		if !ok {
+			break
+		}
+		if level == 0 {
+			level = style.outline
+			if level == 0 {
+				level = headingStyleLevel(style.name)
+			}
+		}
+		numbered = numbered || style.numbered
+		styleID = style.basedOn
+	}
+	return level, numbered
+}
+
+// headingStyleLevel recognizes the built-in "heading N" style names
+func headingStyleLevel(name string) int {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !strings.HasPrefix(name, "heading") {
+		return 0
+	}
+	level, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(name, "heading")))
+	if err != nil || level < 1 || level > maxHeadingLevel {
+		return 0
+	}
+	return level
+}
+
+// block converts a finished paragraph into a text block, resolving its heading level
+func (r *docxReader) block(p *docxParagraph) textBlock {
+	level, numbered := r.styleOutline(p.style)
+	if level == 0 && p.style != "" {
+		level = headingStyleLevel(p.style) // documents without styles.xml still use HeadingN ids
+	}
+	if p.outline > 0 {
+		level = p.outline
+	}
+	return textBlock{
+		text:         p.text.String(),
+		level:        level,
+		autoNumbered: numbered || p.numbered,
+	}
+}
+
+// parsePart walks a document, header, footer, note or comments part and returns its paragraphs in order
+func (r *docxReader) parsePart(data []byte) ([]textBlock, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+
+	var (
+		blocks    []textBlock
+		paras     []*docxParagraph
+		tables    tableStack
+		comment   []string
+		author    string
+		inComment bool
+		runDepth  int
+		inText    bool
+		skipDepth int
+	)
+
+	emit := func(block textBlock) {
+		switch {
+		case strings.TrimSpace(block.text) == "":
+		case tables.active():
+			tables.addText(block.text)
+		case inComment:
+			comment = append(comment, block.text)
+		default:
+			blocks = append(blocks, block)
+		}
+	}
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if skipDepth > 0 {
+				skipDepth++
+				continue
+			}
+
+			var para *docxParagraph
+			if len(paras) > 0 {
+				para = paras[len(paras)-1]
+			}
+
+			switch t.Name.Local {
+			case "pPrChange", "rPrChange", "Fallback":
+				// Superseded formatting and duplicate fallback content
+				skipDepth = 1
+			case "footnote", "endnote":
+				if kind := xmlAttr(t, "type"); kind != "" && kind != "normal" {
+					skipDepth = 1 // separators
+				}
+			case "comment":
+				inComment = true
+				author = xmlAttr(t, "author")
+				comment = nil
+			case "p":
+				paras = append(paras, &docxParagraph{})
+			case "pStyle":
+				if para != nil {
+					para.style = xmlAttr(t, "val")
+				}
+			case "outlineLvl":
+				if para != nil {
+					if level, err := strconv.Atoi(xmlAttr(t, "val")); err == nil && level < maxHeadingLevel {
+						para.outline = level + 1
+					}
+				}
+			case "numId":
+				if para != nil && xmlAttr(t, "val") != "0" {
+					para.numbered = true
+				}
+			case "r":
+				runDepth++
+			case "t":
+				inText = true
+			case "tab", "br", "cr":
+				if para != nil && runDepth > 0 {
+					para.text.WriteByte(' ')
+				}
+			case "noBreakHyphen":
+				if para != nil {
+					para.text.WriteByte('-')
+				}
+			case "tbl":
+				tables.startTable()
+			case "tr":
+				tables.startRow()
+			case "tc":
+				tables.startCell()
+			}
+
+		case xml.EndElement:
+			if skipDepth > 0 {
+				skipDepth--
+				continue
+			}
+
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "r":
+				runDepth--
+			case "p":
+				if len(paras) > 0 {
+					para := paras[len(paras)-1]
+					paras = paras[:len(paras)-1]
+					emit(r.block(para))
+				}
+			case "tc":
+				tables.endCell()
+			case "tr":
+				tables.endRow()
+			case "tbl":
+				for _, block := range tables.endTable() {
+					emit(block)
+				}
+			case "comment":
+				inComment = false
+				if len(comment) > 0 {
+					text := strings.Join(comment, " ")
+					if author != "" {
+						text = fmt.Sprintf("Comment (%s): %s", author, text)
+					} else {
+						text = "Comment: " + text
+					}
+					blocks = append(blocks, textBlock{text: text})
+				}
+			}
+
+		case xml.CharData:
+			if inText && skipDepth == 0 && len(paras) > 0 {
+				paras[len(paras)-1].text.Write(t)
+			}
+		}
+	}
+
+	return blocks, nil
+}
@@ -0,0 +1,345 @@
+package document
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"mime/multipart"
+	"strings"
+	"time"
+	"unicode"
+
+	"ai-government-consultant/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// minHashSize is the number of hash functions in a MinHash signature. The signature is
+	// split into minHashBandCount LSH bands; documents sharing any band become candidates,
+	// which catches pairs above nearDuplicateThreshold with near certainty while rarely
+	// pairing unrelated documents.
+	minHashSize      = 64
+	minHashBandCount = 16
+	minHashBandRows  = minHashSize / minHashBandCount
+
+	// nearDuplicateThreshold is the smallest estimated Jaccard similarity of word shingles
+	// treated as a near duplicate
+	nearDuplicateThreshold = 0.85
+
+	// shingleWords is the number of words per shingle, and minShingleWords the shortest text
+	// fingerprinted; a few edits to a very short text swing the similarity too far to be useful
+	shingleWords    = 3
+	minShingleWords = 20
+
+	// maxNearDuplicateCandidates bounds how many band matches are compared per document
+	maxNearDuplicateCandidates = 50
+)
+
+// ErrDuplicateRejected is returned when processing discards a document under the reject policy
+var ErrDuplicateRejected = errors.New("document rejected as a duplicate")
+
+// minHashSeeds holds the multiplier and offset of each MinHash hash function
+var minHashSeeds = func() [minHashSize][2]uint64 {
+	var seeds [minHashSize][2]uint64
+	state := uint64(0x9e3779b97f4a7c15)
+	for i := range seeds {
+		seeds[i][0] = splitmix64(&state) | 1
+		seeds[i][1] = splitmix64(&state)
+	}
+	return seeds
+}()
+
+// textFingerprint holds the hashes used to match a document's extracted text against others
+type textFingerprint struct {
+	TextHash string
+	MinHash  []uint32 // nil when the text is too short
+}
+
+// hashFile returns the hex SHA-256 of an uploaded file
+func hashFile(file *multipart.FileHeader) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer src.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, src); err != nil {
+		return "", fmt.Errorf("failed to hash file: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// fingerprintText hashes the normalized words of text, so that whitespace, case and
+// punctuation differences do not hide an exact duplicate, and computes the MinHash
+// signature of its word shingles for near-duplicate matching
+func fingerprintText(text string) textFingerprint {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return textFingerprint{}
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(words, " ")))
+	fp := textFingerprint{TextHash: hex.EncodeToString(sum[:])}
+	if len(words) < minShingleWords {
+		return fp
+	}
+
+	signature := make([]uint32, minHashSize)
+	for i := range signature {
+		signature[i] = math.MaxUint32
+	}
+	for i := 0; i+shingleWords <= len(words); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:i+shingleWords], " ")))
+		shingle := h.Sum64()
+		for j, seed := range minHashSeeds {
+			if value := uint32((seed[0]*shingle + seed[1]) >> 32); value < signature[j] {
+				signature[j] = value
+			}
+		}
+	}
+	fp.MinHash = signature
+	return fp
+}
+
+// minHashBandKeys hashes each band of rows in a signature into a position-tagged key such as "7:1f09c2d4"
+func minHashBandKeys(signature []uint32) []string {
+	keys := make([]string, 0, minHashBandCount)
+	for band := 0; band < minHashBandCount; band++ {
+		h := fnv.New32a()
+		for _, value := range signature[band*minHashBandRows : (band+1)*minHashBandRows] {
+			h.Write([]byte{byte(value), byte(value >> 8), byte(value >> 16), byte(value >> 24)})
+		}
+		keys = append(keys, fmt.Sprintf("%d:%08x", band, h.Sum32()))
+	}
+	return keys
+}
+
+// minHashSimilarity estimates the Jaccard similarity of two signatures
+func minHashSimilarity(a, b []uint32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	equal := 0
+	for i := range a {
+		if a[i] == b[i] {
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(a))
+}
+
+func splitmix64(state *uint64) uint64 {
+	*state += 0x9e3779b97f4a7c15
+	z := *state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// applyFingerprint stores the text fingerprint on the document
+func applyFingerprint(doc *models.Document, fp textFingerprint) {
+	doc.TextHash = fp.TextHash
+	doc.MinHash = fp.MinHash
+	doc.MinHashBands = nil
+	if fp.MinHash != nil {
+		doc.MinHashBands = minHashBandKeys(fp.MinHash)
+	}
+}
+
+// candidateFilter matches documents that can serve as the canonical copy of doc: uploaded
+// earlier, not themselves duplicates, not failed or superseded, and outside doc's version chain
+func candidateFilter(doc *models.Document) bson.M {
+	filter := bson.M{
+		"_id":               bson.M{"$lt": doc.ID},
+		"duplicate_of":      bson.M{"$exists": false},
+		"processing_status": bson.M{"$ne": models.ProcessingStatusFailed},
+		"superseded":        bson.M{"$ne": true},
+	}
+	if doc.SeriesID != nil {
+		filter["series_id"] = bson.M{"$ne": *doc.SeriesID}
+		filter["_id"] = bson.M{"$lt": doc.ID, "$ne": *doc.SeriesID}
+	}
+	return filter
+}
+
+// findExactDuplicate returns the earliest canonical document with the same original file or normalized text
+func (s *Service) findExactDuplicate(ctx context.Context, doc *models.Document) (*models.Document, error) {
+	hashes := bson.A{}
+	if doc.ContentHash != "" {
+		hashes = append(hashes, bson.M{"content_hash": doc.ContentHash})
+	}
+	if doc.TextHash != "" {
+		hashes = append(hashes, bson.M{"text_hash": doc.TextHash})
+	}
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+
+	filter := candidateFilter(doc)
+	filter["$or"] = hashes
+
+	var canonical models.Document
+	err := s.collection.FindOne(ctx, filter,
+		options.FindOne().SetSort(bson.D{{Key: "_id", Value: 1}}).SetProjection(bson.M{"_id": 1, "metadata": 1}),
+	).Decode(&canonical)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to look up exact duplicates: %w", err)
+	}
+	return &canonical, nil
+}
+
+// findNearDuplicate returns the canonical document whose text is most similar to doc's,
+// if any reaches nearDuplicateThreshold, and the estimated similarity between them
+func (s *Service) findNearDuplicate(ctx context.Context, doc *models.Document) (*models.Document, float64, error) {
+	if len(doc.MinHashBands) == 0 {
+		return nil, 0, nil
+	}
+
+	filter := candidateFilter(doc)
+	filter["minhash_bands"] = bson.M{"$in": doc.MinHashBands}
+
+	cursor, err := s.collection.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(maxNearDuplicateCandidates).
+		SetProjection(bson.M{"_id": 1, "metadata": 1, "minhash": 1}))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to look up near duplicates: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var best *models.Document
+	bestSimilarity := 0.0
+	for cursor.Next(ctx) {
+		var candidate models.Document
+		if err := cursor.Decode(&candidate); err != nil {
+			return nil, 0, fmt.Errorf("failed to decode duplicate candidate: %w", err)
+		}
+		if similarity := minHashSimilarity(doc.MinHash, candidate.MinHash); similarity >= nearDuplicateThreshold && similarity > bestSimilarity {
+			best, bestSimilarity = &candidate, similarity
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to look up near duplicates: %w", err)
+	}
+	return best, bestSimilarity, nil
+}
+
+// detectDuplicate fingerprints the extracted text and applies the document's duplicate policy.
+// Under the reject policy the original file is deleted and ErrDuplicateRejected is returned.
+func (s *Service) detectDuplicate(doc *models.Document) error {
+	applyFingerprint(doc, fingerprintText(doc.Content))
+	doc.DuplicateOf = nil
+	if doc.DuplicatePolicy == models.DuplicatePolicyAllow {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	kind, similarity := "exact", 1.0
+	canonical, err := s.findExactDuplicate(ctx, doc)
+	if err != nil {
+		return err
+	}
+	if canonical == nil {
+		kind = "near"
+		canonical, similarity, err = s.findNearDuplicate(ctx, doc)
+		if err != nil {
+			return err
+		}
+	}
+	if canonical == nil {
+		return nil
+	}
+
+	info := &models.DuplicateInfo{
+		CanonicalID: canonical.ID,
+		Kind:        kind,
+		Similarity:  similarity,
+		DetectedAt:  time.Now(),
+	}
+
+	switch doc.DuplicatePolicy {
+	case models.DuplicatePolicyReject:
+		info.Action = "rejected"
+		_, err := s.collection.UpdateOne(ctx, bson.M{"_id": doc.ID}, bson.M{
+			"$set":   bson.M{"duplicate_of": info, "text_hash": doc.TextHash},
+			"$unset": bson.M{"blob_key": ""},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record duplicate: %w", err)
+		}
+		if doc.BlobKey != "" {
+			s.blobs.Delete(ctx, doc.BlobKey)
+		}
+		return fmt.Errorf("%w: %s duplicate of document %s", ErrDuplicateRejected, kind, canonical.ID.Hex())
+	case models.DuplicatePolicyMerge:
+		info.Action = "merged"
+		if err := s.mergeIntoCanonical(ctx, canonical, doc.Metadata); err != nil {
+			return err
+		}
+	default:
+		info.Action = "linked"
+	}
+
+	doc.DuplicateOf = info
+	return nil
+}
+
+// mergeIntoCanonical adds a duplicate's tags to the canonical document and fills in
+// descriptive fields the canonical document is missing
+func (s *Service) mergeIntoCanonical(ctx context.Context, canonical *models.Document, metadata models.DocumentMetadata) error {
+	set := bson.M{}
+	if canonical.Metadata.Title == nil && metadata.Title != nil {
+		set["metadata.title"] = *metadata.Title
+	}
+	if canonical.Metadata.Author == nil && metadata.Author != nil {
+		set["metadata.author"] = *metadata.Author
+	}
+	if canonical.Metadata.Department == nil && metadata.Department != nil {
+		set["metadata.department"] = *metadata.Department
+	}
+	if canonical.Metadata.CustomFields == nil && len(metadata.CustomFields) > 0 {
+		set["metadata.custom_fields"] = metadata.CustomFields
+	} else {
+		for key, value := range metadata.CustomFields {
+			if strings.ContainsAny(key, ".$") {
+				continue
+			}
+			if _, exists := canonical.Metadata.CustomFields[key]; !exists {
+				set["metadata.custom_fields."+key] = value
+			}
+		}
+	}
+
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(metadata.Tags) > 0 {
+		update["$addToSet"] = bson.M{"metadata.tags": bson.M{"$each": metadata.Tags}}
+	}
+	if len(update) == 0 {
+		return nil
+	}
+
+	if _, err := s.collection.UpdateOne(ctx, bson.M{"_id": canonical.ID}, update); err != nil {
+		return fmt.Errorf("failed to merge metadata into canonical document: %w", err)
+	}
+	return nil
+}
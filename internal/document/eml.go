@@ -0,0 +1,277 @@
+package document
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// maxEmailNesting bounds how deeply multipart bodies are walked
+const maxEmailNesting = 10
+
+// extractedAttachment is a file carried inside a container format such as an email
+type extractedAttachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// emailParts collects the bodies and attachments found while walking a MIME tree
+type emailParts struct {
+	plain       []string
+	html        []string
+	attachments []extractedAttachment
+}
+
+var mimeWordDecoder = &mime.WordDecoder{CharsetReader: charset.NewReaderLabel}
+
+// extractTextFromEML extracts the headers and body of an RFC 822 email, returning
+// attachments so they can be ingested as child documents
+func (s *Service) extractTextFromEML(data []byte) (*extractionResult, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse email: %w", err)
+	}
+
+	parts := &emailParts{}
+	if err := walkEmailPart(msg.Header, msg.Body, parts, 0); err != nil {
+		return nil, fmt.Errorf("failed to read email body: %w", err)
+	}
+
+	subject := decodeEmailHeader(msg.Header.Get("Subject"))
+	from := emailAddresses(msg.Header, "From")
+	to := emailAddresses(msg.Header, "To")
+	cc := emailAddresses(msg.Header, "Cc")
+
+	var text strings.Builder
+	writeHeader := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&text, "%s: %s\n", name, value)
+		}
+	}
+	writeHeader("From", strings.Join(from, ", "))
+	writeHeader("To", strings.Join(to, ", "))
+	writeHeader("Cc", strings.Join(cc, ", "))
+	writeHeader("Date", msg.Header.Get("Date"))
+	writeHeader("Subject", subject)
+
+	body := strings.Join(parts.plain, "\n\n")
+	if strings.TrimSpace(body) == "" {
+		var converted []string
+		for _, part := range parts.html {
+			converted = append(converted, htmlToText([]byte(part)))
+		}
+		body = strings.Join(converted, "\n\n")
+	}
+	if body = normalizeEmailBody(body); body != "" {
+		text.WriteString("\n")
+		text.WriteString(body)
+		text.WriteString("\n")
+	}
+
+	var attachmentNames []string
+	for _, attachment := range parts.attachments {
+		attachmentNames = append(attachmentNames, attachment.Name)
+	}
+	if len(attachmentNames) > 0 {
+		fmt.Fprintf(&text, "\nAttachments: %s\n", strings.Join(attachmentNames, ", "))
+	}
+
+	result := &extractionResult{
+		Text:         strings.TrimSpace(text.String()),
+		Attachments:  parts.attachments,
+		CustomFields: map[string]interface{}{},
+	}
+
+	if subject != "" {
+		result.Title = &subject
+	}
+	if len(from) > 0 {
+		result.Author = &from[0]
+		result.CustomFields["email_from"] = from[0]
+	}
+	if len(to) > 0 {
+		result.CustomFields["email_to"] = to
+	}
+	if len(cc) > 0 {
+		result.CustomFields["email_cc"] = cc
+	}
+	if sent, err := msg.Header.Date(); err == nil {
+		result.CreatedDate = &sent
+		result.CustomFields["sent_date"] = sent
+	}
+	if messageID := strings.TrimSpace(msg.Header.Get("Message-Id")); messageID != "" {
+		result.CustomFields["message_id"] = messageID
+	}
+	if len(attachmentNames) > 0 {
+		result.CustomFields["attachment_names"] = attachmentNames
+	}
+
+	return result, nil
+}
+
+// mimeHeader is the header access shared by mail.Header and textproto.MIMEHeader
+type mimeHeader interface {
+	Get(key string) string
+}
+
+// walkEmailPart collects text bodies and attachments from a MIME entity
+func walkEmailPart(header mimeHeader, body io.Reader, parts *emailParts, depth int) error {
+	if depth > maxEmailNesting {
+		return nil
+	}
+
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := decodeEmailHeader(dispositionParams["filename"])
+	if filename == "" {
+		filename = decodeEmailHeader(params["name"])
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := walkEmailPart(part.Header, part, parts, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := io.ReadAll(decodeTransferEncoding(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return err
+	}
+
+	isAttachment := disposition == "attachment" || filename != "" ||
+		!strings.HasPrefix(mediaType, "text/") && mediaType != "message/rfc822"
+	if mediaType == "message/rfc822" {
+		isAttachment = true
+		if filename == "" {
+			filename = "forwarded-message.eml"
+		}
+	}
+
+	if isAttachment {
+		if filename == "" {
+			exts, _ := mime.ExtensionsByType(mediaType)
+			filename = fmt.Sprintf("attachment-%d", len(parts.attachments)+1)
+			if len(exts) > 0 {
+				filename += exts[0]
+			}
+		}
+		parts.attachments = append(parts.attachments, extractedAttachment{
+			Name:        filepath.Base(filename),
+			ContentType: mediaType,
+			Data:        data,
+		})
+		return nil
+	}
+
+	text := decodeCharset(params["charset"], data)
+	switch mediaType {
+	case "text/html":
+		parts.html = append(parts.html, text)
+	default:
+		parts.plain = append(parts.plain, text)
+	}
+	return nil
+}
+
+func decodeTransferEncoding(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	default:
+		return body
+	}
+}
+
+// decodeCharset converts body bytes in the declared charset to UTF-8
+func decodeCharset(label string, data []byte) string {
+	if label == "" || strings.EqualFold(label, "utf-8") || strings.EqualFold(label, "us-ascii") {
+		return strings.ToValidUTF8(string(data), "�")
+	}
+	reader, err := charset.NewReaderLabel(label, bytes.NewReader(data))
+	if err != nil {
+		return strings.ToValidUTF8(string(data), "�")
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		return strings.ToValidUTF8(string(data), "�")
+	}
+	return string(decoded)
+}
+
+// decodeEmailHeader decodes RFC 2047 encoded words
+func decodeEmailHeader(value string) string {
+	decoded, err := mimeWordDecoder.DecodeHeader(value)
+	if err != nil {
+		return strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(decoded)
+}
+
+// emailAddresses formats the addresses in an address list header
+func emailAddresses(header mail.Header, key string) []string {
+	if header.Get(key) == "" {
+		return nil
+	}
+	parser := &mail.AddressParser{WordDecoder: mimeWordDecoder}
+	list, err := parser.ParseList(header.Get(key))
+	if err != nil {
+		return []string{decodeEmailHeader(header.Get(key))}
+	}
+
+	addresses := make([]string, 0, len(list))
+	for _, addr := range list {
+		if addr.Name != "" {
+			addresses = append(addresses, fmt.Sprintf("%s <%s>", addr.Name, addr.Address))
+		} else {
+			addresses = append(addresses, addr.Address)
+		}
+	}
+	return addresses
+}
+
+// normalizeEmailBody trims trailing whitespace and collapses runs of blank lines
+func normalizeEmailBody(body string) string {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	lines := strings.Split(body, "\n")
+	cleaned := make([]string, 0, len(lines))
+	blank := 0
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			blank++
+			if blank > 1 {
+				continue
+			}
+		} else {
+			blank = 0
+		}
+		cleaned = append(cleaned, line)
+	}
+	return strings.TrimSpace(strings.Join(cleaned, "\n"))
+}
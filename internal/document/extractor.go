@@ -1,6 +1,7 @@
 package document
 
 import (
+	"bytes"
 	"fmt"
 	"path/filepath"
 	"regexp"
@@ -8,22 +9,58 @@ import (
 	"time"
 	"unicode/utf8"
 
+	"ai-government-consultant/internal/citation"
 	"ai-government-consultant/internal/models"
 )
 
+// extractionResult holds extracted text along with the layout and metadata recovered from the source format
+type extractionResult struct {
+	Text         string
+	Pages        []models.PageSpan
+	Sections     []models.SectionSpan
+	Title        *string
+	Author       *string
+	CreatedDate  *time.Time
+	CustomFields map[string]interface{}
+	Attachments  []extractedAttachment
+	Tables       []extractedTable
+}
+
 // extractText extracts text content from a document based on its type
-func (s *Service) extractText(doc *models.Document) (string, error) {
+func (s *Service) extractText(doc *models.Document) (*extractionResult, error) {
 	ext := strings.ToLower(filepath.Ext(doc.Name))
 
+	// Extraction always starts from the original uploaded bytes
+	data, err := s.originalContent(doc)
+	if err != nil {
+		return nil, err
+	}
+
 	switch ext {
 	case ".txt":
-		return s.extractTextFromTXT(doc.Content)
+		text, err := s.extractTextFromTXT(s.sanitizeUTF8Content(data))
+		if err != nil {
+			return nil, err
+		}
+		return &extractionResult{Text: text}, nil
 	case ".pdf":
-		return s.extractTextFromPDF(doc.Content)
-	case ".doc", ".docx":
-		return s.extractTextFromDOC(doc.Content)
+		return s.extractTextFromPDF(data)
+	case ".doc", ".docx", ".odt":
+		return s.extractTextFromOfficeDocument(ext, data)
+	case ".html", ".htm":
+		return s.extractTextFromHTML(data)
+	case ".md", ".markdown":
+		return s.extractTextFromMarkdown(data)
+	case ".rtf":
+		return s.extractTextFromRTF(data)
+	case ".eml":
+		return s.extractTextFromEML(data)
+	case ".csv":
+		return s.extractTextFromCSV(data)
+	case ".xlsx":
+		return s.extractTextFromXLSX(data)
 	default:
-		return "", fmt.Errorf("unsupported file format: %s", ext)
+		return nil, fmt.Errorf("unsupported file format: %s", ext)
 	}
 }
 
@@ -44,36 +81,118 @@ func (s *Service) extractTextFromTXT(content string) (string, error) {
 	return cleaned, nil
 }
 
-// extractTextFromPDF extracts text from PDF files
-// Note: This is a placeholder implementation. In production, you would use a library like unidoc or pdfcpu
-func (s *Service) extractTextFromPDF(content string) (string, error) {
-	// Placeholder implementation - in reality, you'd use a PDF parsing library
-	// For now, we'll assume the content is already text (for testing purposes)
+// extractTextFromPDF extracts text from PDF files page by page, recording where each page starts
+// and the tables laid out on each page
+func (s *Service) extractTextFromPDF(data []byte) (*extractionResult, error) {
+	if !bytes.Contains(data[:min(len(data), 1024)], []byte("%PDF")) {
+		// Not a PDF file (e.g. pre-extracted text used in testing)
+		text, err := s.extractTextFromTXT(string(data))
+		if err != nil {
+			return nil, err
+		}
+		return &extractionResult{Text: text}, nil
+	}
 
-	// Basic PDF text extraction simulation
-	if strings.Contains(content, "%PDF") {
-		// This is actual PDF binary content - in production, use proper PDF library
-		return "PDF content extraction not implemented - use proper PDF library", nil
+	pdf, err := parsePDF(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PDF: %w", err)
 	}
 
-	// If it's already text content (for testing), return as is
-	return s.extractTextFromTXT(content)
-}
+	if pdf.trailer["Encrypt"] != nil {
+		return nil, fmt.Errorf("encrypted PDF documents are not supported")
+	}
+
+	pages := pdf.pages()
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("no pages found in PDF")
+	}
 
-// extractTextFromDOC extracts text from DOC/DOCX files
-// Note: This is a placeholder implementation. In production, you would use a library like unioffice
-func (s *Service) extractTextFromDOC(content string) (string, error) {
-	// Placeholder implementation - in reality, you'd use a DOC/DOCX parsing library
-	// For now, we'll assume the content is already text (for testing purposes)
+	var text strings.Builder
+	var tables []extractedTable
+	spans := make([]models.PageSpan, 0, len(pages))
+	for i, page := range pages {
+		pageText, pageTables := pdf.pageText(page)
+		if pageText != "" && text.Len() > 0 {
+			text.WriteString("\n\n")
+		}
 
-	// Basic DOC/DOCX text extraction simulation
-	if strings.Contains(content, "PK") || strings.Contains(content, "Microsoft") {
-		// This might be actual DOC/DOCX binary content - in production, use proper library
-		return "DOC/DOCX content extraction not implemented - use proper library", nil
+		start := text.Len()
+		text.WriteString(pageText)
+		spans = append(spans, models.PageSpan{
+			Number: i + 1,
+			Start:  start,
+			End:    text.Len(),
+		})
+		for _, table := range pageTables {
+			table.page = i + 1
+			table.start += start
+			table.end += start
+			tables = append(tables, table)
+		}
 	}
 
-	// If it's already text content (for testing), return as is
-	return s.extractTextFromTXT(content)
+	if strings.TrimSpace(text.String()) == "" {
+		return nil, fmt.Errorf("PDF contains no extractable text (it may be a scanned image)")
+	}
+
+	result := &extractionResult{
+		Text:   text.String(),
+		Pages:  spans,
+		Tables: tables,
+	}
+
+	if info := pdf.info(); info != nil {
+		if title := pdf.textString(info["Title"]); title != "" {
+			result.Title = &title
+		}
+		if author := pdf.textString(info["Author"]); author != "" {
+			result.Author = &author
+		}
+		result.CreatedDate = parsePDFDate(pdf.textString(info["CreationDate"]))
+	}
+
+	return result, nil
+}
+
+// applyExtractedMetadata fills metadata fields that the source file declares itself
+func (s *Service) applyExtractedMetadata(metadata *models.DocumentMetadata, result *extractionResult) {
+	if metadata.Title == nil && result.Title != nil {
+		metadata.Title = result.Title
+	}
+	if metadata.Author == nil && result.Author != nil {
+		metadata.Author = result.Author
+	}
+	if metadata.CreatedDate == nil && result.CreatedDate != nil {
+		metadata.CreatedDate = result.CreatedDate
+	}
+	if len(result.CustomFields) > 0 && metadata.CustomFields == nil {
+		metadata.CustomFields = make(map[string]interface{})
+	}
+	for key, value := range result.CustomFields {
+		if _, exists := metadata.CustomFields[key]; !exists {
+			metadata.CustomFields[key] = value
+		}
+	}
+}
+
+// extractTextFromOfficeDocument extracts text from word processing packages (DOCX, ODT)
+func (s *Service) extractTextFromOfficeDocument(ext string, data []byte) (*extractionResult, error) {
+	switch {
+	case bytes.HasPrefix(data, zipSignature):
+		if ext == ".odt" {
+			return s.extractTextFromODT(data)
+		}
+		return s.extractTextFromDOCX(data)
+	case bytes.HasPrefix(data, oleSignature):
+		return nil, fmt.Errorf("legacy binary Word documents are not supported; save the file as .docx")
+	default:
+		// Not a package (e.g. pre-extracted text used in testing)
+		text, err := s.extractTextFromTXT(string(data))
+		if err != nil {
+			return nil, err
+		}
+		return &extractionResult{Text: text}, nil
+	}
 }
 
 // extractMetadata extracts metadata from document content
@@ -236,36 +355,12 @@ func (s *Service) detectLanguage(content string) string {
 	return "unknown"
 }
 
-// extractEntities extracts entities from document text
+// extractEntities extracts entities from document text. Personal data such as emails, phone
+// numbers and SSNs is recorded as sensitive entities; other entities inside those spans are skipped.
 func (s *Service) extractEntities(content string) ([]models.Entity, error) {
+	pii := detectPII(content)
 	entities := []models.Entity{}
 
-	// Extract email addresses
-	emailRegex := regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`)
-	emailMatches := emailRegex.FindAllStringIndex(content, -1)
-	for _, match := range emailMatches {
-		entities = append(entities, models.Entity{
-			Type:       "email",
-			Value:      content[match[0]:match[1]],
-			Confidence: 0.95,
-			StartPos:   match[0],
-			EndPos:     match[1],
-		})
-	}
-
-	// Extract phone numbers (basic US format)
-	phoneRegex := regexp.MustCompile(`\b\d{3}[-.]?\d{3}[-.]?\d{4}\b`)
-	phoneMatches := phoneRegex.FindAllStringIndex(content, -1)
-	for _, match := range phoneMatches {
-		entities = append(entities, models.Entity{
-			Type:       "phone",
-			Value:      content[match[0]:match[1]],
-			Confidence: 0.85,
-			StartPos:   match[0],
-			EndPos:     match[1],
-		})
-	}
-
 	// Extract dates (basic format: MM/DD/YYYY or MM-DD-YYYY)
 	dateRegex := regexp.MustCompile(`\b\d{1,2}[/-]\d{1,2}[/-]\d{4}\b`)
 	dateMatches := dateRegex.FindAllStringIndex(content, -1)
@@ -292,7 +387,26 @@ func (s *Service) extractEntities(content string) ([]models.Entity, error) {
 		})
 	}
 
-	return entities, nil
+	// Extract legal and regulatory citations, valued by their canonical ID
+	for _, c := range citation.Parse(content) {
+		entities = append(entities, models.Entity{
+			Type:       "citation",
+			Value:      c.ID,
+			Confidence: c.Confidence,
+			StartPos:   c.Start,
+			EndPos:     c.End,
+		})
+	}
+
+	// A date inside a date of birth, say, must not repeat the sensitive value unmasked
+	filtered := append([]models.Entity{}, pii...)
+	for _, entity := range entities {
+		if !overlapsAny(pii, entity.StartPos, entity.EndPos) {
+			filtered = append(filtered, entity)
+		}
+	}
+
+	return filtered, nil
 }
 
 // mergeMetadata merges extracted metadata with existing metadata
@@ -0,0 +1,206 @@
+package document
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+// htmlBlockElements start a new paragraph when they open or close
+var htmlBlockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.Header: true, atom.Footer: true, atom.Nav: true, atom.Aside: true, atom.Blockquote: true,
+	atom.Pre: true, atom.Ul: true, atom.Ol: true, atom.Li: true, atom.Dl: true, atom.Dt: true,
+	atom.Dd: true, atom.Figure: true, atom.Figcaption: true, atom.Address: true, atom.Hr: true,
+	atom.Caption: true, atom.Form: true, atom.Fieldset: true, atom.Legend: true,
+	atom.Details: true, atom.Summary: true,
+}
+
+// htmlSkippedElements never contribute visible text
+var htmlSkippedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Svg: true, atom.Math: true, atom.Iframe: true, atom.Object: true, atom.Button: true,
+	atom.Select: true, atom.Textarea: true,
+}
+
+var htmlHeadingLevels = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+// htmlWalker flattens an HTML tree into paragraphs, headings and table rows
+type htmlWalker struct {
+	blocks  []textBlock
+	current strings.Builder
+	tables  tableStack
+	title   string
+	meta    map[string]string
+}
+
+// extractTextFromHTML extracts visible text and the heading outline from a saved web page
+func (s *Service) extractTextFromHTML(data []byte) (*extractionResult, error) {
+	reader, err := charset.NewReader(bytes.NewReader(data), "text/html")
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect HTML encoding: %w", err)
+	}
+
+	root, err := html.Parse(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	walker := &htmlWalker{meta: make(map[string]string)}
+	walker.walk(root)
+	walker.flush(0)
+
+	result := newStructuredResult(nil, walker.blocks, nil)
+	if strings.TrimSpace(result.Text) == "" {
+		return nil, fmt.Errorf("HTML document contains no visible text")
+	}
+
+	if title := strings.Join(strings.Fields(walker.title), " "); title != "" {
+		result.Title = &title
+	}
+	if author := walker.firstMeta("author", "dc.creator", "dcterms.creator", "article:author"); author != "" {
+		result.Author = &author
+	}
+	if created := walker.firstMeta("dcterms.created", "dc.date", "date", "article:published_time"); created != "" {
+		result.CreatedDate = parsePackageDate(created)
+	}
+	if description := walker.firstMeta("description", "og:description"); description != "" {
+		result.CustomFields = map[string]interface{}{"description": description}
+	}
+
+	return result, nil
+}
+
+func (w *htmlWalker) firstMeta(names ...string) string {
+	for _, name := range names {
+		if value := strings.TrimSpace(w.meta[name]); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// flush ends the current paragraph, recording it as a heading when level is non-zero
+func (w *htmlWalker) flush(level int) {
+	text := strings.Join(strings.Fields(w.current.String()), " ")
+	w.current.Reset()
+	if text == "" {
+		return
+	}
+	if w.tables.active() {
+		w.tables.addText(text)
+		return
+	}
+	w.blocks = append(w.blocks, textBlock{text: text, level: level})
+}
+
+func (w *htmlWalker) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.current.WriteString(n.Data)
+		return
+	case html.ElementNode:
+		if htmlSkippedElements[n.DataAtom] {
+			return
+		}
+		switch n.DataAtom {
+		case atom.Title:
+			if n.FirstChild != nil && w.title == "" {
+				w.title = n.FirstChild.Data
+			}
+			return
+		case atom.Meta:
+			key := strings.ToLower(htmlAttr(n, "name"))
+			if key == "" {
+				key = strings.ToLower(htmlAttr(n, "property"))
+			}
+			if key != "" {
+				if _, exists := w.meta[key]; !exists {
+					w.meta[key] = htmlAttr(n, "content")
+				}
+			}
+			return
+		case atom.Br:
+			w.current.WriteByte(' ')
+			return
+		case atom.Img:
+			if alt := strings.TrimSpace(htmlAttr(n, "alt")); alt != "" {
+				w.current.WriteString(" " + alt + " ")
+			}
+			return
+		}
+
+		if level, ok := htmlHeadingLevels[n.DataAtom]; ok {
+			w.flush(0)
+			w.children(n)
+			w.flush(level)
+			return
+		}
+
+		switch n.DataAtom {
+		case atom.Table:
+			w.flush(0)
+			w.tables.startTable()
+			w.children(n)
+			w.flush(0)
+			w.blocks = append(w.blocks, w.tables.endTable()...)
+			return
+		case atom.Tr:
+			w.flush(0)
+			w.tables.startRow()
+			w.children(n)
+			w.flush(0)
+			w.tables.endRow()
+			return
+		case atom.Td, atom.Th:
+			w.flush(0)
+			w.tables.startCell()
+			w.children(n)
+			w.flush(0)
+			w.tables.endCell()
+			return
+		}
+
+		if htmlBlockElements[n.DataAtom] {
+			w.flush(0)
+			w.children(n)
+			w.flush(0)
+			return
+		}
+	}
+
+	w.children(n)
+}
+
+func (w *htmlWalker) children(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		w.walk(child)
+	}
+}
+
+func htmlAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// htmlToText converts an HTML fragment (such as an email body) to plain text
+func htmlToText(data []byte) string {
+	root, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return string(data)
+	}
+	walker := &htmlWalker{meta: make(map[string]string)}
+	walker.walk(root)
+	walker.flush(0)
+	return newStructuredResult(nil, walker.blocks, nil).Text
+}
@@ -0,0 +1,193 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ai-government-consultant/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrDocumentNotFound is returned when ingesting a version of a document that no longer exists
+	ErrDocumentNotFound = errors.New("document not found")
+
+	// ErrDuplicateFile is returned when a file is identical to an existing document and the
+	// duplicate policy rejects it
+	ErrDuplicateFile = errors.New("file is identical to an existing document")
+)
+
+// SourceFile is a file read from somewhere other than an upload, such as a connector's share
+type SourceFile struct {
+	Name            string
+	Data            []byte
+	Metadata        models.DocumentMetadata
+	Declared        *models.SecurityClassification // the classification the file is declared to have, if known
+	DuplicatePolicy models.DuplicatePolicy
+	UploadedBy      primitive.ObjectID
+}
+
+// IngestFile stores a file as a new document queued for processing
+func (s *Service) IngestFile(ctx context.Context, file SourceFile) (*models.Document, error) {
+	if err := validateSourceFile(file); err != nil {
+		return nil, err
+	}
+
+	docID := primitive.NewObjectID()
+	doc := &models.Document{
+		ID:               docID,
+		Name:             file.Name,
+		BlobKey:          blobKey(docID),
+		ContentType:      contentTypeFor(file.Name),
+		Size:             int64(len(file.Data)),
+		UploadedBy:       file.UploadedBy,
+		UploadedAt:       time.Now(),
+		Classification:   models.SecurityClassification{Level: "INTERNAL"}, // Default classification
+		Metadata:         file.Metadata,
+		ProcessingStatus: models.ProcessingStatusPending,
+		DuplicatePolicy:  file.DuplicatePolicy,
+		ContentHash:      fmt.Sprintf("%x", sha256.Sum256(file.Data)),
+	}
+	if file.Declared != nil {
+		declared := *file.Declared
+		doc.Classification = declared
+		doc.DeclaredClassification = &declared
+	}
+	if err := doc.Validate(); err != nil {
+		return nil, fmt.Errorf("document validation failed: %w", err)
+	}
+	if err := s.validateMetadata(ctx, &doc.Metadata); err != nil {
+		return nil, err
+	}
+
+	if file.DuplicatePolicy == models.DuplicatePolicyReject {
+		canonical, err := s.findExactDuplicate(ctx, doc)
+		if err != nil {
+			return nil, err
+		}
+		if canonical != nil {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateFile, canonical.ID.Hex())
+		}
+	}
+
+	if err := s.storeData(ctx, doc, file.Data); err != nil {
+		return nil, err
+	}
+	// A queueing failure marks the document failed, which its status then reflects
+	s.queueProcessing(doc.ID)
+	return doc, nil
+}
+
+// IngestVersion stores a file as a new revision of the document's version chain, queued for
+// processing. The file's metadata is merged into the metadata carried over from the latest version.
+func (s *Service) IngestVersion(ctx context.Context, documentID primitive.ObjectID, file SourceFile) (*models.Document, error) {
+	if err := validateSourceFile(file); err != nil {
+		return nil, err
+	}
+
+	var base models.Document
+	if err := s.collection.FindOne(ctx, bson.M{"_id": documentID}).Decode(&base); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrDocumentNotFound
+		}
+		return nil, fmt.Errorf("failed to find document: %w", err)
+	}
+	latest, err := s.latestVersion(ctx, &base)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := newVersion(latest, file.Name, contentTypeFor(file.Name), int64(len(file.Data)), file.UploadedBy)
+	for key, value := range file.Metadata.CustomFields {
+		doc.Metadata.CustomFields[key] = value
+	}
+	doc.ContentHash = fmt.Sprintf("%x", sha256.Sum256(file.Data))
+	if err := doc.Validate(); err != nil {
+		return nil, fmt.Errorf("document validation failed: %w", err)
+	}
+	if err := s.inheritMetadata(ctx, doc, latest); err != nil {
+		return nil, err
+	}
+	if err := s.validateMetadata(ctx, &doc.Metadata); err != nil {
+		return nil, err
+	}
+
+	if err := s.appendVersion(ctx, latest, func() error {
+		return s.storeData(ctx, doc, file.Data)
+	}); err != nil {
+		return nil, err
+	}
+	s.queueProcessing(doc.ID)
+	return doc, nil
+}
+
+// DeleteSeries deletes every version of a document, newest first, on behalf of userID. It stops
+// at the first version that cannot be deleted, such as one under legal hold, and returns
+// ErrDocumentNotFound when the document no longer exists.
+func (s *Service) DeleteSeries(ctx context.Context, documentID primitive.ObjectID, userID primitive.ObjectID) error {
+	var base models.Document
+	if err := s.collection.FindOne(ctx, bson.M{"_id": documentID}).Decode(&base); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ErrDocumentNotFound
+		}
+		return fmt.Errorf("failed to find document: %w", err)
+	}
+
+	cursor, err := s.collection.Find(ctx, s.seriesFilter(&base), options.Find().
+		SetSort(bson.D{{Key: "version", Value: -1}}).
+		SetProjection(bson.M{"_id": 1, "version": 1}))
+	if err != nil {
+		return fmt.Errorf("failed to find versions: %w", err)
+	}
+	var versions []models.Document
+	if err := cursor.All(ctx, &versions); err != nil {
+		return fmt.Errorf("failed to decode versions: %w", err)
+	}
+
+	for _, version := range versions {
+		if err := s.DeleteDocument(version.ID.Hex(), userID); err != nil {
+			return fmt.Errorf("failed to delete version %d: %w", version.VersionNumber(), err)
+		}
+	}
+	return nil
+}
+
+// storeData writes a file held in memory to blob storage and inserts its document record
+func (s *Service) storeData(ctx context.Context, doc *models.Document, data []byte) error {
+	if err := s.blobs.Put(ctx, doc.BlobKey, bytes.NewReader(data), doc.Size, doc.ContentType); err != nil {
+		return fmt.Errorf("failed to store file: %w", err)
+	}
+	if err := s.assignRetention(ctx, doc); err != nil {
+		s.blobs.Delete(ctx, doc.BlobKey)
+		return err
+	}
+	if _, err := s.collection.InsertOne(ctx, doc); err != nil {
+		s.blobs.Delete(ctx, doc.BlobKey)
+		return fmt.Errorf("failed to insert document: %w", err)
+	}
+	return nil
+}
+
+// validateSourceFile applies the checks made on uploads to a file read from elsewhere
+func validateSourceFile(file SourceFile) error {
+	ext := strings.ToLower(filepath.Ext(file.Name))
+	switch {
+	case !SupportedFormats[ext]:
+		return fmt.Errorf("unsupported file format: %s", ext)
+	case len(file.Data) == 0:
+		return fmt.Errorf("file is empty")
+	case len(file.Data) > MaxDocumentSize:
+		return fmt.Errorf("file size exceeds maximum allowed size of %d bytes", MaxDocumentSize)
+	}
+	return nil
+}
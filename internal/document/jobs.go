@@ -0,0 +1,127 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ai-government-consultant/internal/models"
+	"ai-government-consultant/internal/queue"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ProcessDocumentJob is the job type that extracts text and metadata from an uploaded document
+const ProcessDocumentJob = "document.process"
+
+// queueProcessing adds a processing job for the document. If the job cannot be queued the
+// document is marked failed so it does not sit in pending with nothing to pick it up.
+func (s *Service) queueProcessing(documentID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := s.jobs.Enqueue(ctx, ProcessDocumentJob, documentID.Hex()); err != nil {
+		s.updateProcessingStatus(documentID, models.ProcessingStatusFailed, fmt.Sprintf("failed to queue for processing: %s", err.Error()))
+		return fmt.Errorf("failed to queue document for processing: %w", err)
+	}
+	return nil
+}
+
+// processDocumentJob runs one processing attempt for the document named by the job key.
+// Failed attempts leave the document pending until the queue gives up on the job.
+func (s *Service) processDocumentJob(ctx context.Context, job *queue.Job) error {
+	documentID, err := primitive.ObjectIDFromHex(job.Key)
+	if err != nil {
+		return fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	// Update status to processing
+	s.updateProcessingStatus(documentID, models.ProcessingStatusProcessing, "")
+
+	var doc models.Document
+	err = s.collection.FindOne(ctx, bson.M{"_id": documentID}).Decode(&doc)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			// The document was deleted while queued; nothing left to do
+			return nil
+		}
+		s.recordAttemptFailure(job, documentID, fmt.Errorf("failed to find document: %w", err))
+		return err
+	}
+
+	// Process the document
+	if err := s.processDocument(&doc); err != nil {
+		// A rejected duplicate is a final outcome, not a failure worth retrying
+		if errors.Is(err, ErrDuplicateRejected) {
+			s.updateProcessingStatus(documentID, models.ProcessingStatusFailed, err.Error())
+			return nil
+		}
+		s.recordAttemptFailure(job, documentID, err)
+		return err
+	}
+
+	// Summarize in a job of its own, so a slow or failing model does not hold up processing
+	if err := s.queueSummary(ctx, documentID); err != nil {
+		s.recordAttemptFailure(job, documentID, err)
+		return err
+	}
+
+	// Update status to completed
+	s.updateProcessingStatus(documentID, models.ProcessingStatusCompleted, "")
+	return nil
+}
+
+// recordAttemptFailure marks the document failed after the last attempt, or pending with
+// the error while retries remain
+func (s *Service) recordAttemptFailure(job *queue.Job, documentID primitive.ObjectID, err error) {
+	if job.FinalAttempt() {
+		s.updateProcessingStatus(documentID, models.ProcessingStatusFailed, err.Error())
+		return
+	}
+	s.updateProcessingStatus(documentID, models.ProcessingStatusPending,
+		fmt.Sprintf("attempt %d of %d failed, retrying: %s", job.Attempts, job.MaxAttempts, err.Error()))
+}
+
+// RecoverStaleDocuments queues every document left pending or processing without an open
+// processing job, such as uploads interrupted by a restart, and returns how many were queued
+func (s *Service) RecoverStaleDocuments(ctx context.Context) (int, error) {
+	filter := bson.M{"processing_status": bson.M{"$in": []models.ProcessingStatus{
+		models.ProcessingStatusPending,
+		models.ProcessingStatusProcessing,
+	}}}
+	cursor, err := s.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return 0, fmt.Errorf("failed to find unprocessed documents: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	recovered := 0
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return recovered, fmt.Errorf("failed to decode document: %w", err)
+		}
+
+		open, err := s.jobs.HasOpenJob(ctx, ProcessDocumentJob, doc.ID.Hex())
+		if err != nil {
+			return recovered, err
+		}
+		if open {
+			continue
+		}
+
+		s.updateProcessingStatus(doc.ID, models.ProcessingStatusPending, "")
+		if err := s.queueProcessing(doc.ID); err != nil {
+			return recovered, err
+		}
+		recovered++
+	}
+
+	return recovered, cursor.Err()
+}
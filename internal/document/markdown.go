@@ -0,0 +1,194 @@
+package document
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	markdownATXHeading   = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	markdownSetextH1     = regexp.MustCompile(`^=+\s*$`)
+	markdownSetextH2     = regexp.MustCompile(`^-+\s*$`)
+	markdownFence        = regexp.MustCompile("^(```|~~~)")
+	markdownListMarker   = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+(?:\[[ xX]\]\s+)?`)
+	markdownTableDivider = regexp.MustCompile(`^\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?\s*$`)
+	markdownImage        = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	markdownLink         = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	markdownRefLink      = regexp.MustCompile(`\[([^\]]+)\]\[[^\]]*\]`)
+	markdownEmphasis     = regexp.MustCompile(`(\*{1,3}|~~)([^*~]+)(\*{1,3}|~~)`)
+	markdownUnderscore   = regexp.MustCompile(`(^|\s)_{1,3}([^_]+)_{1,3}($|\s|[.,;:!?)])`)
+	markdownInlineCode   = regexp.MustCompile("`([^`]*)`")
+	markdownHTMLTag      = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+)
+
+// extractTextFromMarkdown extracts text and the heading outline from Markdown, reading
+// title, author and date from YAML front matter when present
+func (s *Service) extractTextFromMarkdown(data []byte) (*extractionResult, error) {
+	content := strings.ReplaceAll(s.sanitizeUTF8Content(data), "\r\n", "\n")
+	frontMatter, content := splitFrontMatter(content)
+
+	lines := strings.Split(content, "\n")
+	var blocks []textBlock
+	var paragraph []string
+	inFence, inTable := false, false
+	var table *extractedTable
+
+	flush := func() {
+		if len(paragraph) > 0 {
+			blocks = append(blocks, textBlock{text: strings.Join(paragraph, " ")})
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+
+		if markdownFence.MatchString(strings.TrimSpace(line)) {
+			flush()
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			// Code is kept line by line
+			if strings.TrimSpace(line) != "" {
+				blocks = append(blocks, textBlock{text: line})
+			}
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			flush()
+			inTable = false
+			continue
+		}
+
+		if match := markdownATXHeading.FindStringSubmatch(trimmed); match != nil {
+			flush()
+			blocks = append(blocks, textBlock{text: markdownInline(match[2]), level: len(match[1])})
+			continue
+		}
+
+		// Setext headings underline a single paragraph line
+		if len(paragraph) == 1 && (markdownSetextH1.MatchString(trimmed) || markdownSetextH2.MatchString(trimmed)) {
+			level := 1
+			if trimmed[0] == '-' {
+				level = 2
+			}
+			blocks = append(blocks, textBlock{text: paragraph[0], level: level})
+			paragraph = nil
+			continue
+		}
+
+		isTableHeader := strings.Contains(trimmed, "|") && i+1 < len(lines) && markdownTableDivider.MatchString(strings.TrimSpace(lines[i+1]))
+		if isTableHeader || inTable && strings.Contains(trimmed, "|") {
+			flush()
+			if isTableHeader {
+				table = &extractedTable{}
+			}
+			inTable = true
+			if !markdownTableDivider.MatchString(trimmed) {
+				cells := strings.Split(strings.Trim(trimmed, "|"), "|")
+				for j, cell := range cells {
+					cells[j] = markdownInline(strings.TrimSpace(cell))
+				}
+				table.rows = append(table.rows, cells)
+				blocks = append(blocks, textBlock{text: strings.Join(cells, " | "), table: table})
+			}
+			continue
+		}
+
+		if markdownSetextH2.MatchString(trimmed) || trimmed == "***" || trimmed == "___" {
+			flush() // thematic break
+			continue
+		}
+
+		trimmed = strings.TrimLeft(trimmed, "> ")
+		if markdownListMarker.MatchString(trimmed) {
+			flush()
+			trimmed = markdownListMarker.ReplaceAllString(trimmed, "")
+		}
+		paragraph = append(paragraph, markdownInline(trimmed))
+	}
+	flush()
+
+	result := newStructuredResult(nil, blocks, nil)
+	if strings.TrimSpace(result.Text) == "" {
+		return nil, fmt.Errorf("markdown document contains no text")
+	}
+
+	if frontMatter != nil {
+		if title := frontMatterString(frontMatter, "title"); title != "" {
+			result.Title = &title
+		}
+		if author := frontMatterString(frontMatter, "author"); author != "" {
+			result.Author = &author
+		}
+		if date, ok := frontMatter["date"].(time.Time); ok {
+			result.CreatedDate = &date
+		} else {
+			result.CreatedDate = parsePackageDate(frontMatterString(frontMatter, "date"))
+		}
+	}
+
+	return result, nil
+}
+
+// splitFrontMatter separates a leading YAML front matter block from the Markdown body
+func splitFrontMatter(content string) (map[string]interface{}, string) {
+	if !strings.HasPrefix(content, "---\n") {
+		return nil, content
+	}
+	end := strings.Index(content[4:], "\n---")
+	if end < 0 {
+		return nil, content
+	}
+
+	var frontMatter map[string]interface{}
+	if err := yaml.Unmarshal([]byte(content[4:4+end]), &frontMatter); err != nil {
+		return nil, content
+	}
+
+	body := content[4+end+4:]
+	if i := strings.IndexByte(body, '\n'); i >= 0 {
+		body = body[i+1:]
+	} else {
+		body = ""
+	}
+	return frontMatter, body
+}
+
+func frontMatterString(frontMatter map[string]interface{}, key string) string {
+	switch v := frontMatter[key].(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case []interface{}:
+		var parts []string
+		for _, item := range v {
+			parts = append(parts, fmt.Sprint(item))
+		}
+		return strings.Join(parts, ", ")
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// markdownInline strips inline Markdown syntax, keeping link and image text
+func markdownInline(text string) string {
+	text = markdownImage.ReplaceAllString(text, "$1")
+	text = markdownLink.ReplaceAllString(text, "$1")
+	text = markdownRefLink.ReplaceAllString(text, "$1")
+	text = markdownInlineCode.ReplaceAllString(text, "$1")
+	text = markdownHTMLTag.ReplaceAllString(text, "")
+	for i := 0; i < 3; i++ {
+		text = markdownEmphasis.ReplaceAllString(text, "$2")
+		text = markdownUnderscore.ReplaceAllString(text, "$1$2$3")
+	}
+	return text
+}
@@ -0,0 +1,139 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ai-government-consultant/internal/metadata"
+	"ai-government-consultant/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MetadataUpdate changes the user-supplied metadata of a document. Nil fields are left as they
+// are, and a custom field set to nil is removed.
+type MetadataUpdate struct {
+	Title        *string
+	Author       *string
+	Department   *string
+	Category     *models.DocumentCategory
+	Tags         []string
+	Language     *string
+	CustomFields map[string]interface{}
+}
+
+// validateMetadata checks the custom fields of metadata against its metadata schemas,
+// converting them to their declared types. It returns a *metadata.ValidationError for fields
+// that do not conform.
+func (s *Service) validateMetadata(ctx context.Context, metadata *models.DocumentMetadata) error {
+	if s.schemas == nil {
+		return nil
+	}
+	return s.schemas.Validate(ctx, metadata)
+}
+
+// invalidMetadata reports whether err is a metadata schema validation failure, as opposed to a
+// failure to look the schemas up
+func invalidMetadata(err error) bool {
+	return errors.Is(err, metadata.ErrInvalidMetadata)
+}
+
+// inheritMetadata carries the schema fields of the latest version over to its successor
+func (s *Service) inheritMetadata(ctx context.Context, doc, latest *models.Document) error {
+	if s.schemas == nil {
+		return nil
+	}
+	if err := s.schemas.Inherit(ctx, &doc.Metadata, latest.Metadata.CustomFields); err != nil {
+		return fmt.Errorf("failed to carry over metadata: %w", err)
+	}
+	return nil
+}
+
+// UpdateMetadata changes the metadata of a document. The result is validated against the
+// metadata schemas that apply to it, so a change of department or category can require new
+// fields; a *metadata.ValidationError lists the fields that do not conform. A change of
+// category also recomputes the document's retention.
+func (s *Service) UpdateMetadata(documentID string, update MetadataUpdate) (*models.Document, error) {
+	objID, err := primitive.ObjectIDFromHex(documentID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var doc models.Document
+	if err := s.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&doc); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrDocumentNotFound
+		}
+		return nil, fmt.Errorf("failed to find document: %w", err)
+	}
+
+	metadata := doc.Metadata
+	if update.Title != nil {
+		metadata.Title = optionalString(*update.Title)
+	}
+	if update.Author != nil {
+		metadata.Author = optionalString(*update.Author)
+	}
+	if update.Department != nil {
+		metadata.Department = optionalString(*update.Department)
+	}
+	if update.Category != nil {
+		metadata.Category = *update.Category
+	}
+	if update.Tags != nil {
+		metadata.Tags = update.Tags
+	}
+	if update.Language != nil {
+		metadata.Language = *update.Language
+	}
+	if len(update.CustomFields) > 0 {
+		fields := make(map[string]interface{}, len(metadata.CustomFields)+len(update.CustomFields))
+		for key, value := range metadata.CustomFields {
+			fields[key] = value
+		}
+		for key, value := range update.CustomFields {
+			if value == nil {
+				delete(fields, key)
+			} else {
+				fields[key] = value
+			}
+		}
+		metadata.CustomFields = fields
+	}
+
+	if err := s.validateMetadata(ctx, &metadata); err != nil {
+		return nil, err
+	}
+
+	set := bson.M{"metadata": metadata}
+	if metadata.Category != doc.Metadata.Category {
+		doc.Metadata = metadata
+		if err := s.assignRetention(ctx, &doc); err != nil {
+			return nil, err
+		}
+		set["retention"] = doc.Retention
+	}
+	if err := s.repository.Update(ctx, objID, bson.M{"$set": set}); err != nil {
+		return nil, err
+	}
+
+	doc.Metadata = metadata
+	return &doc, nil
+}
+
+// optionalString returns a pointer to the trimmed value, or nil if it is empty
+func optionalString(value string) *string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	return &value
+}
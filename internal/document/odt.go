@@ -0,0 +1,251 @@
+package document
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// odtContent collects the text blocks of an OpenDocument text package by region
+type odtContent struct {
+	headers  []textBlock
+	body     []textBlock
+	footers  []textBlock
+	notes    []textBlock
+	comments []textBlock
+}
+
+// extractTextFromODT extracts text, the heading outline, page headers/footers,
+// notes and annotations from an OpenDocument text document
+func (s *Service) extractTextFromODT(data []byte) (*extractionResult, error) {
+	pkg, err := openPackage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := readPackagePart(pkg, "content.xml")
+	if err != nil {
+		return nil, err
+	}
+	if content == nil {
+		return nil, fmt.Errorf("document package has no content.xml")
+	}
+
+	parsed := &odtContent{}
+	numberedLevels := make(map[int]bool)
+
+	// Page headers/footers and outline numbering live in styles.xml
+	if styles, err := readPackagePart(pkg, "styles.xml"); err == nil && styles != nil {
+		if err := parseODTPart(styles, numberedLevels, parsed); err != nil {
+			return nil, fmt.Errorf("failed to parse styles.xml: %w", err)
+		}
+	}
+	if err := parseODTPart(content, numberedLevels, parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse content.xml: %w", err)
+	}
+
+	back := append(append(append([]textBlock{}, parsed.footers...), parsed.notes...), parsed.comments...)
+	result := newStructuredResult(parsed.headers, parsed.body, back)
+	if strings.TrimSpace(result.Text) == "" {
+		return nil, fmt.Errorf("document contains no extractable text")
+	}
+
+	if meta, err := readPackagePart(pkg, "meta.xml"); err == nil && meta != nil {
+		fields := readXMLFields(meta, "title", "initial-creator", "creator", "creation-date")
+		if title := fields["title"]; title != "" {
+			result.Title = &title
+		}
+		author := fields["initial-creator"]
+		if author == "" {
+			author = fields["creator"]
+		}
+		if author != "" {
+			result.Author = &author
+		}
+		result.CreatedDate = parsePackageDate(fields["creation-date"])
+	}
+
+	return result, nil
+}
+
+// odtParagraph accumulates the text of a text:p or text:h element
+type odtParagraph struct {
+	text  strings.Builder
+	level int
+	list  bool // text:is-list-header headings are not numbered
+}
+
+// parseODTPart walks content.xml or styles.xml, routing paragraphs to the region they belong to.
+// Outline numbering declared in styles.xml is recorded in numberedLevels.
+func parseODTPart(data []byte, numberedLevels map[int]bool, out *odtContent) error {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+
+	var (
+		paras     []*odtParagraph
+		tables    tableStack
+		region    string // header, footer, note or annotation
+		regionTag string
+		regionLvl int
+		depth     int
+		comment   []string
+		author    string
+		inCreator bool
+		skipDepth int
+	)
+
+	emit := func(block textBlock) {
+		switch {
+		case strings.TrimSpace(block.text) == "":
+		case tables.active():
+			tables.addText(block.text)
+		case region == "header":
+			out.headers = append(out.headers, block)
+		case region == "footer":
+			out.footers = append(out.footers, block)
+		case region == "note":
+			out.notes = append(out.notes, textBlock{text: block.text})
+		case region == "annotation":
+			comment = append(comment, block.text)
+		default:
+			out.body = append(out.body, block)
+		}
+	}
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if skipDepth > 0 {
+				skipDepth++
+				continue
+			}
+
+			var para *odtParagraph
+			if len(paras) > 0 {
+				para = paras[len(paras)-1]
+			}
+
+			switch t.Name.Local {
+			case "tracked-changes", "note-citation":
+				skipDepth = 1
+			case "date":
+				if region == "annotation" {
+					skipDepth = 1
+				}
+			case "outline-level-style":
+				if level, err := strconv.Atoi(xmlAttr(t, "level")); err == nil && xmlAttr(t, "num-format") != "" {
+					numberedLevels[level] = true
+				}
+			case "header", "header-left", "header-first", "footer", "footer-left", "footer-first":
+				if regionTag == "" {
+					regionTag, regionLvl = t.Name.Local, depth
+					region = strings.SplitN(t.Name.Local, "-", 2)[0]
+				}
+			case "note-body", "annotation":
+				if regionTag == "" {
+					regionTag, regionLvl = t.Name.Local, depth
+					region = "note"
+					if t.Name.Local == "annotation" {
+						region = "annotation"
+						comment, author = nil, ""
+					}
+				}
+			case "creator":
+				inCreator = region == "annotation"
+			case "p":
+				paras = append(paras, &odtParagraph{})
+			case "h":
+				level, err := strconv.Atoi(xmlAttr(t, "outline-level"))
+				if err != nil || level < 1 {
+					level = 1
+				}
+				paras = append(paras, &odtParagraph{level: level, list: xmlAttr(t, "is-list-header") == "true"})
+			case "s":
+				if para != nil {
+					count, err := strconv.Atoi(xmlAttr(t, "c"))
+					if err != nil || count < 1 {
+						count = 1
+					}
+					para.text.WriteString(strings.Repeat(" ", count))
+				}
+			case "tab", "line-break":
+				if para != nil {
+					para.text.WriteByte(' ')
+				}
+			case "table":
+				tables.startTable()
+			case "table-row":
+				tables.startRow()
+			case "table-cell":
+				tables.startCell()
+			}
+
+		case xml.EndElement:
+			if skipDepth > 0 {
+				skipDepth--
+				depth--
+				continue
+			}
+
+			switch t.Name.Local {
+			case "creator":
+				inCreator = false
+			case "p", "h":
+				if len(paras) > 0 {
+					para := paras[len(paras)-1]
+					paras = paras[:len(paras)-1]
+					block := textBlock{text: para.text.String()}
+					if t.Name.Local == "h" && region == "" {
+						block.level = para.level
+						block.autoNumbered = numberedLevels[para.level] && !para.list
+					}
+					emit(block)
+				}
+			case "table-cell":
+				tables.endCell()
+			case "table-row":
+				tables.endRow()
+			case "table":
+				for _, block := range tables.endTable() {
+					emit(block)
+				}
+			}
+
+			if regionTag == t.Name.Local && regionLvl == depth {
+				if region == "annotation" && len(comment) > 0 {
+					text := strings.Join(comment, " ")
+					if author != "" {
+						text = fmt.Sprintf("Comment (%s): %s", strings.TrimSpace(author), text)
+					} else {
+						text = "Comment: " + text
+					}
+					out.comments = append(out.comments, textBlock{text: text})
+				}
+				region, regionTag, regionLvl = "", "", 0
+			}
+			depth--
+
+		case xml.CharData:
+			switch {
+			case skipDepth > 0:
+			case inCreator:
+				author += string(t)
+			case len(paras) > 0:
+				paras[len(paras)-1].text.Write(t)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,831 @@
+package document
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// maxFormXObjectDepth limits recursion into nested form XObjects
+const maxFormXObjectDepth = 8
+
+// pdfName represents a PDF name object such as /Type
+type pdfName string
+
+// pdfKeyword represents a bare keyword or content stream operator
+type pdfKeyword string
+
+// pdfString represents a literal or hexadecimal PDF string
+type pdfString []byte
+
+// pdfRef represents an indirect object reference
+type pdfRef struct {
+	Num int
+	Gen int
+}
+
+// pdfArray represents a PDF array object
+type pdfArray []interface{}
+
+// pdfDict represents a PDF dictionary object
+type pdfDict map[pdfName]interface{}
+
+// pdfStream represents a PDF stream object with its raw (still encoded) data
+type pdfStream struct {
+	Dict pdfDict
+	Data []byte
+}
+
+var errPDFEndOfData = errors.New("unexpected end of PDF data")
+
+// pdfLexer tokenizes PDF file and content stream syntax
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func isPDFWhitespace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+// skipSpace skips whitespace and comments
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isPDFWhitespace(c) {
+			l.pos++
+			continue
+		}
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		break
+	}
+}
+
+// next returns the next token: a primitive object, a pdfKeyword, or a delimiter keyword ("[", "]", "<<", ">>")
+func (l *pdfLexer) next() (interface{}, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, errPDFEndOfData
+	}
+
+	c := l.data[l.pos]
+	switch {
+	case c == '/':
+		l.pos++
+		start := l.pos
+		for l.pos < len(l.data) && !isPDFWhitespace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+			l.pos++
+		}
+		return pdfName(decodePDFNameEscapes(l.data[start:l.pos])), nil
+	case c == '(':
+		return l.readLiteralString()
+	case c == '<':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+			l.pos += 2
+			return pdfKeyword("<<"), nil
+		}
+		return l.readHexString()
+	case c == '>':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '>' {
+			l.pos += 2
+			return pdfKeyword(">>"), nil
+		}
+		l.pos++
+		return pdfKeyword(">"), nil
+	case c == '[' || c == ']' || c == '{' || c == '}':
+		l.pos++
+		return pdfKeyword(string(c)), nil
+	case c == ')':
+		l.pos++
+		return pdfKeyword(")"), nil
+	}
+
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFWhitespace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	word := string(l.data[start:l.pos])
+
+	if isPDFNumber(word) {
+		f, err := strconv.ParseFloat(word, 64)
+		if err == nil {
+			return f, nil
+		}
+	}
+
+	switch word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+
+	return pdfKeyword(word), nil
+}
+
+func isPDFNumber(word string) bool {
+	if word == "" {
+		return false
+	}
+	for i, c := range word {
+		if (c >= '0' && c <= '9') || c == '.' || ((c == '-' || c == '+') && i == 0) {
+			continue
+		}
+		return false
+	}
+	return word != "-" && word != "+" && word != "."
+}
+
+func decodePDFNameEscapes(raw []byte) string {
+	if bytes.IndexByte(raw, '#') < 0 {
+		return string(raw)
+	}
+	var out []byte
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '#' && i+2 < len(raw) {
+			if b, err := hex.DecodeString(string(raw[i+1 : i+3])); err == nil {
+				out = append(out, b[0])
+				i += 2
+				continue
+			}
+		}
+		out = append(out, raw[i])
+	}
+	return string(out)
+}
+
+// readLiteralString reads a parenthesized string, handling escapes and balanced parentheses
+func (l *pdfLexer) readLiteralString() (interface{}, error) {
+	l.pos++ // skip '('
+	depth := 1
+	var out []byte
+
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+			out = append(out, c)
+		case ')':
+			depth--
+			if depth == 0 {
+				return pdfString(out), nil
+			}
+			out = append(out, c)
+		case '\\':
+			if l.pos >= len(l.data) {
+				return pdfString(out), nil
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+				// Line continuation
+			default:
+				if e >= '0' && e <= '7' {
+					val := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						val = val*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					out = append(out, byte(val))
+				} else {
+					out = append(out, e)
+				}
+			}
+		default:
+			out = append(out, c)
+		}
+	}
+
+	return pdfString(out), nil
+}
+
+// readHexString reads a <...> hexadecimal string
+func (l *pdfLexer) readHexString() (interface{}, error) {
+	l.pos++ // skip '<'
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		c := l.data[l.pos]
+		if (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') {
+			digits = append(digits, c)
+		}
+		l.pos++
+	}
+	l.pos++ // skip '>'
+
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	if _, err := hex.Decode(out, digits); err != nil {
+		return nil, fmt.Errorf("invalid hex string: %w", err)
+	}
+	return pdfString(out), nil
+}
+
+// parseObject parses a complete object, assembling arrays, dictionaries and references
+func (l *pdfLexer) parseObject() (interface{}, error) {
+	tok, err := l.next()
+	if err != nil {
+		return nil, err
+	}
+	return l.parseFrom(tok)
+}
+
+func (l *pdfLexer) parseFrom(tok interface{}) (interface{}, error) {
+	switch t := tok.(type) {
+	case pdfKeyword:
+		switch t {
+		case "[":
+			var arr pdfArray
+			for {
+				item, err := l.next()
+				if err != nil {
+					return arr, err
+				}
+				if kw, ok := item.(pdfKeyword); ok && kw == "]" {
+					return arr, nil
+				}
+				obj, err := l.parseFrom(item)
+				if err != nil {
+					return arr, err
+				}
+				arr = append(arr, obj)
+			}
+		case "<<":
+			dict := pdfDict{}
+			for {
+				key, err := l.next()
+				if err != nil {
+					return dict, err
+				}
+				if kw, ok := key.(pdfKeyword); ok && kw == ">>" {
+					return dict, nil
+				}
+				name, ok := key.(pdfName)
+				if !ok {
+					continue
+				}
+				value, err := l.parseObject()
+				if err != nil {
+					return dict, err
+				}
+				dict[name] = value
+			}
+		}
+		return t, nil
+	case float64:
+		// Look ahead for an indirect reference: "num gen R"
+		if t == float64(int(t)) && t >= 0 {
+			saved := l.pos
+			gen, err := l.next()
+			if g, ok := gen.(float64); err == nil && ok && g == float64(int(g)) {
+				r, err := l.next()
+				if kw, ok := r.(pdfKeyword); err == nil && ok && kw == "R" {
+					return pdfRef{Num: int(t), Gen: int(g)}, nil
+				}
+			}
+			l.pos = saved
+		}
+		return t, nil
+	}
+	return tok, nil
+}
+
+// pdfDocument holds the parsed object graph of a PDF file
+type pdfDocument struct {
+	data    []byte
+	objects map[int]interface{}
+	trailer pdfDict
+	fonts   map[interface{}]*pdfFont
+}
+
+// pdfPage holds a page dictionary along with its inherited resources
+type pdfPage struct {
+	Dict      pdfDict
+	Resources pdfDict
+}
+
+var (
+	pdfObjectHeaderRe = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+	pdfEndStreamRe    = regexp.MustCompile(`\r?\n?endstream`)
+)
+
+// parsePDF parses the object graph of a PDF file
+func parsePDF(data []byte) (*pdfDocument, error) {
+	if !bytes.Contains(data[:min(len(data), 1024)], []byte("%PDF")) {
+		return nil, fmt.Errorf("missing PDF header")
+	}
+
+	doc := &pdfDocument{
+		data:    data,
+		objects: make(map[int]interface{}),
+		fonts:   make(map[interface{}]*pdfFont),
+	}
+
+	doc.scanObjects()
+	doc.loadObjectStreams()
+	doc.loadTrailer()
+
+	if len(doc.objects) == 0 {
+		return nil, fmt.Errorf("no PDF objects found")
+	}
+
+	return doc, nil
+}
+
+// scanObjects locates every "N G obj" definition in file order so that later
+// incremental updates override earlier definitions, independent of the xref table
+func (d *pdfDocument) scanObjects() {
+	pos := 0
+	for pos < len(d.data) {
+		loc := pdfObjectHeaderRe.FindSubmatchIndex(d.data[pos:])
+		if loc == nil {
+			return
+		}
+
+		start := pos + loc[0]
+		if start > 0 && d.data[start-1] >= '0' && d.data[start-1] <= '9' {
+			pos = start + 1
+			continue
+		}
+
+		num, _ := strconv.Atoi(string(d.data[pos+loc[2] : pos+loc[3]]))
+		lexer := &pdfLexer{data: d.data, pos: pos + loc[1]}
+
+		obj, err := lexer.parseObject()
+		if err != nil {
+			pos += loc[1]
+			continue
+		}
+
+		if dict, ok := obj.(pdfDict); ok {
+			lexer.skipSpace()
+			if bytes.HasPrefix(d.data[lexer.pos:], []byte("stream")) {
+				obj = d.readStream(dict, lexer)
+			}
+		}
+
+		d.objects[num] = obj
+		if lexer.pos <= pos+loc[1] {
+			lexer.pos = pos + loc[1]
+		}
+		pos = lexer.pos
+	}
+}
+
+// readStream reads stream data following a stream dictionary
+func (d *pdfDocument) readStream(dict pdfDict, lexer *pdfLexer) *pdfStream {
+	pos := lexer.pos + len("stream")
+	if pos < len(d.data) && d.data[pos] == '\r' {
+		pos++
+	}
+	if pos < len(d.data) && d.data[pos] == '\n' {
+		pos++
+	}
+
+	// Trust a direct /Length when it lands on "endstream"
+	if length, ok := dict["Length"].(float64); ok {
+		end := pos + int(length)
+		if end <= len(d.data) && end >= pos {
+			rest := d.data[end:min(len(d.data), end+32)]
+			if bytes.Contains(rest, []byte("endstream")) {
+				lexer.pos = end + bytes.Index(rest, []byte("endstream")) + len("endstream")
+				return &pdfStream{Dict: dict, Data: d.data[pos:end]}
+			}
+		}
+	}
+
+	loc := pdfEndStreamRe.FindIndex(d.data[pos:])
+	if loc == nil {
+		lexer.pos = len(d.data)
+		return &pdfStream{Dict: dict, Data: d.data[pos:]}
+	}
+
+	lexer.pos = pos + loc[1]
+	return &pdfStream{Dict: dict, Data: d.data[pos : pos+loc[0]]}
+}
+
+// loadObjectStreams expands compressed object streams (PDF 1.5+)
+func (d *pdfDocument) loadObjectStreams() {
+	for _, obj := range d.objects {
+		stream, ok := obj.(*pdfStream)
+		if !ok || stream.Dict["Type"] != pdfName("ObjStm") {
+			continue
+		}
+
+		data, err := d.decodeStream(stream)
+		if err != nil {
+			continue
+		}
+
+		count := int(d.number(stream.Dict["N"]))
+		first := int(d.number(stream.Dict["First"]))
+		if first <= 0 || first > len(data) {
+			continue
+		}
+
+		header := &pdfLexer{data: data[:first]}
+		for i := 0; i < count; i++ {
+			numTok, err1 := header.next()
+			offTok, err2 := header.next()
+			num, ok1 := numTok.(float64)
+			off, ok2 := offTok.(float64)
+			if err1 != nil || err2 != nil || !ok1 || !ok2 {
+				break
+			}
+			if _, exists := d.objects[int(num)]; exists {
+				continue
+			}
+			// Offsets are relative to First and must fall inside the decoded stream; the check is
+			// made on the float so that huge values cannot overflow the conversion
+			if off < 0 || off >= float64(len(data)-first) {
+				continue
+			}
+			lexer := &pdfLexer{data: data, pos: first + int(off)}
+			if value, err := lexer.parseObject(); err == nil {
+				d.objects[int(num)] = value
+			}
+		}
+	}
+}
+
+// loadTrailer finds the trailer dictionary, falling back to cross-reference streams
+func (d *pdfDocument) loadTrailer() {
+	if idx := bytes.LastIndex(d.data, []byte("trailer")); idx >= 0 {
+		lexer := &pdfLexer{data: d.data, pos: idx + len("trailer")}
+		if obj, err := lexer.parseObject(); err == nil {
+			if dict, ok := obj.(pdfDict); ok && dict["Root"] != nil {
+				d.trailer = dict
+				return
+			}
+		}
+	}
+
+	for _, obj := range d.objects {
+		if stream, ok := obj.(*pdfStream); ok && stream.Dict["Type"] == pdfName("XRef") && stream.Dict["Root"] != nil {
+			d.trailer = stream.Dict
+			return
+		}
+	}
+
+	d.trailer = pdfDict{}
+}
+
+// resolve follows indirect references
+func (d *pdfDocument) resolve(obj interface{}) interface{} {
+	for i := 0; i < 16; i++ {
+		ref, ok := obj.(pdfRef)
+		if !ok {
+			return obj
+		}
+		obj = d.objects[ref.Num]
+	}
+	return nil
+}
+
+// dict resolves an object to a dictionary, using the dictionary of streams
+func (d *pdfDocument) dict(obj interface{}) pdfDict {
+	switch v := d.resolve(obj).(type) {
+	case pdfDict:
+		return v
+	case *pdfStream:
+		return v.Dict
+	}
+	return nil
+}
+
+func (d *pdfDocument) number(obj interface{}) float64 {
+	if f, ok := d.resolve(obj).(float64); ok {
+		return f
+	}
+	return 0
+}
+
+// decodeStream applies the stream's filters
+func (d *pdfDocument) decodeStream(stream *pdfStream) ([]byte, error) {
+	var filters []pdfName
+	switch f := d.resolve(stream.Dict["Filter"]).(type) {
+	case pdfName:
+		filters = []pdfName{f}
+	case pdfArray:
+		for _, item := range f {
+			if name, ok := d.resolve(item).(pdfName); ok {
+				filters = append(filters, name)
+			}
+		}
+	}
+
+	data := stream.Data
+	for _, filter := range filters {
+		var err error
+		switch filter {
+		case "FlateDecode", "Fl":
+			data, err = inflatePDFData(data)
+		case "ASCIIHexDecode", "AHx":
+			data, err = decodePDFASCIIHex(data)
+		case "ASCII85Decode", "A85":
+			data, err = decodePDFASCII85(data)
+		case "RunLengthDecode", "RL":
+			data = decodePDFRunLength(data)
+		default:
+			return nil, fmt.Errorf("unsupported PDF filter: %s", filter)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply %s: %w", filter, err)
+		}
+	}
+
+	return data, nil
+}
+
+// inflatePDFData decompresses FlateDecode data, keeping whatever is recoverable from truncated streams
+func inflatePDFData(data []byte) ([]byte, error) {
+	var reader io.Reader
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err == nil {
+		reader = zr
+	} else if len(data) > 2 {
+		reader = flate.NewReader(bytes.NewReader(data[2:]))
+	} else {
+		return nil, err
+	}
+
+	// Cap the output so that a small crafted stream cannot expand without bound
+	out, err := io.ReadAll(io.LimitReader(reader, MaxDocumentSize+1))
+	if len(out) > MaxDocumentSize {
+		return nil, fmt.Errorf("decompressed stream exceeds maximum size of %d bytes", MaxDocumentSize)
+	}
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+	return out, nil
+}
+
+func decodePDFASCIIHex(data []byte) ([]byte, error) {
+	var digits []byte
+	for _, c := range data {
+		if c == '>' {
+			break
+		}
+		if !isPDFWhitespace(c) {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	_, err := hex.Decode(out, digits)
+	return out, err
+}
+
+func decodePDFASCII85(data []byte) ([]byte, error) {
+	var out []byte
+	var group [5]byte
+	n := 0
+
+	flush := func(count int) {
+		var value uint32
+		for i := 0; i < 5; i++ {
+			c := byte('u')
+			if i < count {
+				c = group[i]
+			}
+			value = value*85 + uint32(c-'!')
+		}
+		decoded := []byte{byte(value >> 24), byte(value >> 16), byte(value >> 8), byte(value)}
+		out = append(out, decoded[:count-1]...)
+	}
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case isPDFWhitespace(c):
+			continue
+		case c == '~':
+			if n > 1 {
+				flush(n)
+			}
+			return out, nil
+		case c == 'z' && n == 0:
+			out = append(out, 0, 0, 0, 0)
+		case c >= '!' && c <= 'u':
+			group[n] = c
+			n++
+			if n == 5 {
+				flush(5)
+				n = 0
+			}
+		default:
+			return out, fmt.Errorf("invalid ASCII85 character %q", c)
+		}
+	}
+	if n > 1 {
+		flush(n)
+	}
+	return out, nil
+}
+
+func decodePDFRunLength(data []byte) []byte {
+	var out []byte
+	for i := 0; i < len(data); {
+		length := int(data[i])
+		i++
+		switch {
+		case length == 128:
+			return out
+		case length < 128:
+			end := min(i+length+1, len(data))
+			out = append(out, data[i:end]...)
+			i = end
+		default:
+			if i < len(data) {
+				out = append(out, bytes.Repeat([]byte{data[i]}, 257-length)...)
+			}
+			i++
+		}
+	}
+	return out
+}
+
+// pages returns the document's pages in reading order
+func (d *pdfDocument) pages() []pdfPage {
+	var pages []pdfPage
+	visited := make(map[int]bool)
+
+	var walk func(node interface{}, inherited pdfDict)
+	walk = func(node interface{}, inherited pdfDict) {
+		if ref, ok := node.(pdfRef); ok {
+			if visited[ref.Num] {
+				return
+			}
+			visited[ref.Num] = true
+		}
+
+		dict := d.dict(node)
+		if dict == nil {
+			return
+		}
+
+		resources := inherited
+		if res := d.dict(dict["Resources"]); res != nil {
+			resources = res
+		}
+
+		if kids, ok := d.resolve(dict["Kids"]).(pdfArray); ok {
+			for _, kid := range kids {
+				walk(kid, resources)
+			}
+			return
+		}
+
+		if dict["Type"] == pdfName("Page") || dict["Contents"] != nil {
+			pages = append(pages, pdfPage{Dict: dict, Resources: resources})
+		}
+	}
+
+	if catalog := d.dict(d.trailer["Root"]); catalog != nil {
+		walk(catalog["Pages"], nil)
+	}
+
+	if len(pages) > 0 {
+		return pages
+	}
+
+	// Fall back to every page object in object-number order when the page tree is unusable
+	maxNum := 0
+	for num := range d.objects {
+		if num > maxNum {
+			maxNum = num
+		}
+	}
+	for num := 0; num <= maxNum; num++ {
+		if dict := d.dict(d.objects[num]); dict != nil && dict["Type"] == pdfName("Page") {
+			pages = append(pages, pdfPage{Dict: dict, Resources: d.dict(dict["Resources"])})
+		}
+	}
+
+	return pages
+}
+
+// pageContent concatenates and decodes a page's content streams
+func (d *pdfDocument) pageContent(page pdfPage) []byte {
+	var parts []interface{}
+	switch c := d.resolve(page.Dict["Contents"]).(type) {
+	case pdfArray:
+		parts = c
+	case *pdfStream:
+		parts = []interface{}{c}
+	}
+
+	var buf bytes.Buffer
+	for _, part := range parts {
+		stream, ok := d.resolve(part).(*pdfStream)
+		if !ok {
+			continue
+		}
+		data, err := d.decodeStream(stream)
+		if err != nil {
+			continue
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// info returns the document information dictionary
+func (d *pdfDocument) info() pdfDict {
+	return d.dict(d.trailer["Info"])
+}
+
+// textString decodes a PDF text string (UTF-16BE with BOM or PDFDocEncoding)
+func (d *pdfDocument) textString(obj interface{}) string {
+	s, ok := d.resolve(obj).(pdfString)
+	if !ok {
+		return ""
+	}
+	return decodePDFTextString(s)
+}
+
+func decodePDFTextString(s []byte) string {
+	if len(s) >= 2 && s[0] == 0xFE && s[1] == 0xFF {
+		return decodeUTF16BE(s[2:])
+	}
+	runes := make([]rune, 0, len(s))
+	for _, b := range s {
+		runes = append(runes, winAnsiEncoding[b])
+	}
+	return strings.TrimSpace(string(runes))
+}
+
+func decodeUTF16BE(b []byte) string {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// parsePDFDate parses dates of the form D:YYYYMMDDHHmmSSOHH'mm'
+func parsePDFDate(value string) *time.Time {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "D:")
+	if len(value) < 4 {
+		return nil
+	}
+
+	layouts := []string{"20060102150405", "200601021504", "2006010215", "20060102", "200601", "2006"}
+	digits := value
+	if idx := strings.IndexAny(value, "Zz+-"); idx >= 0 {
+		digits = value[:idx]
+	}
+	for _, layout := range layouts {
+		if len(digits) == len(layout) {
+			if t, err := time.Parse(layout, digits); err == nil {
+				return &t
+			}
+		}
+	}
+	return nil
+}
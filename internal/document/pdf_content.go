@@ -0,0 +1,391 @@
+package document
+
+import (
+	"bytes"
+	"math"
+	"strings"
+)
+
+const (
+	// pdfColumnGap is the horizontal jump, in ems, taken to separate table columns
+	pdfColumnGap = 1.5
+
+	// pdfMinTableRows is the fewest aligned lines taken to be a table
+	pdfMinTableRows = 3
+)
+
+// pdfTextWriter accumulates text while turning positioning operators into line and word breaks
+type pdfTextWriter struct {
+	sb strings.Builder
+}
+
+func (w *pdfTextWriter) write(s string) {
+	w.sb.WriteString(s)
+}
+
+func (w *pdfTextWriter) last() byte {
+	str := w.sb.String()
+	if str == "" {
+		return '\n'
+	}
+	return str[len(str)-1]
+}
+
+func (w *pdfTextWriter) space() {
+	if c := w.last(); c != ' ' && c != '\n' {
+		w.sb.WriteByte(' ')
+	}
+}
+
+// gap marks a column gap: a jump along the line too wide to be a word space
+func (w *pdfTextWriter) gap() {
+	str := w.sb.String()
+	switch {
+	case str == "" || w.last() == '\n' || w.last() == '\t':
+	case w.last() == ' ':
+		w.sb.Reset()
+		w.sb.WriteString(str[:len(str)-1] + "\t")
+	default:
+		w.sb.WriteByte('\t')
+	}
+}
+
+func (w *pdfTextWriter) newline() {
+	if w.last() != '\n' {
+		w.sb.WriteByte('\n')
+	}
+}
+
+// pageText extracts the text of a single page and the tables laid out on it, with table
+// offsets relative to the page text
+func (d *pdfDocument) pageText(page pdfPage) (string, []extractedTable) {
+	w := &pdfTextWriter{}
+	d.runContent(d.pageContent(page), page.Resources, w, 0)
+	return normalizeLayoutText(w.sb.String()), layoutTables(w.sb.String())
+}
+
+// pdfTextState tracks the text state and pen position needed to infer word and line breaks
+type pdfTextState struct {
+	font       *pdfFont
+	fontSize   float64
+	charSpace  float64
+	wordSpace  float64
+	scale      float64 // horizontal scaling (Tz) as a fraction
+	leading    float64
+	matrix     [6]float64 // text line matrix
+	penX       float64    // current pen x in user space
+	lastY      float64    // baseline of the previous positioning in user space
+	positioned bool
+}
+
+func newPDFTextState() *pdfTextState {
+	return &pdfTextState{
+		font:     newDefaultPDFFont(),
+		fontSize: 1,
+		scale:    1,
+		matrix:   [6]float64{1, 0, 0, 1, 0, 0},
+	}
+}
+
+// unit returns the size of one text space unit in user space
+func (ts *pdfTextState) unit() float64 {
+	u := math.Hypot(ts.matrix[0], ts.matrix[1])
+	if u == 0 {
+		return 1
+	}
+	return u
+}
+
+// translate moves the text line matrix by (tx, ty) in text space
+func (ts *pdfTextState) translate(tx, ty float64) {
+	m := ts.matrix
+	ts.matrix[4] = tx*m[0] + ty*m[2] + m[4]
+	ts.matrix[5] = tx*m[1] + ty*m[3] + m[5]
+}
+
+// moveTo emits a line or word break when the pen jumps to a new line start
+func (ts *pdfTextState) moveTo(w *pdfTextWriter) {
+	x, y := ts.matrix[4], ts.matrix[5]
+	em := math.Abs(ts.fontSize) * ts.unit()
+	if em == 0 {
+		em = 1
+	}
+
+	if ts.positioned {
+		switch {
+		case math.Abs(y-ts.lastY) > em*0.5:
+			w.newline()
+		case x-ts.penX > em*pdfColumnGap:
+			w.gap()
+		case x-ts.penX > em*0.15 || ts.penX-x > em:
+			w.space()
+		}
+	}
+
+	ts.penX = x
+	ts.lastY = y
+	ts.positioned = true
+}
+
+// show writes a string operand and advances the pen
+func (ts *pdfTextState) show(w *pdfTextWriter, s []byte) {
+	text, width, count, spaces := ts.font.show(s)
+	w.write(text)
+	advance := (width/1000*ts.fontSize + float64(count)*ts.charSpace + float64(spaces)*ts.wordSpace) * ts.scale
+	ts.penX += advance * ts.unit()
+}
+
+// nextLine moves to the start of the next line using the current leading
+func (ts *pdfTextState) nextLine(w *pdfTextWriter) {
+	ts.translate(0, -ts.leading)
+	w.newline()
+	ts.penX = ts.matrix[4]
+	ts.lastY = ts.matrix[5]
+	ts.positioned = true
+}
+
+// runContent interprets the text operators of a content stream
+func (d *pdfDocument) runContent(content []byte, resources pdfDict, w *pdfTextWriter, depth int) {
+	fonts := d.dict(resources["Font"])
+	xobjects := d.dict(resources["XObject"])
+	ts := newPDFTextState()
+
+	lexer := &pdfLexer{data: content}
+	var operands []interface{}
+
+	number := func(i int) float64 {
+		if i < len(operands) {
+			if f, ok := operands[i].(float64); ok {
+				return f
+			}
+		}
+		return 0
+	}
+
+	for {
+		tok, err := lexer.next()
+		if err != nil {
+			return
+		}
+
+		op, ok := tok.(pdfKeyword)
+		if !ok {
+			operands = append(operands, tok)
+			continue
+		}
+
+		switch op {
+		case "[", "<<":
+			if obj, err := lexer.parseFrom(tok); err == nil {
+				operands = append(operands, obj)
+			}
+			continue
+		case "BI":
+			skipInlineImage(lexer)
+		case "BT":
+			ts.matrix = [6]float64{1, 0, 0, 1, 0, 0}
+		case "Tf":
+			if len(operands) >= 2 {
+				if name, ok := operands[0].(pdfName); ok && fonts != nil {
+					ts.font = d.font(fonts[name])
+				}
+				ts.fontSize = number(1)
+			}
+		case "Tc":
+			ts.charSpace = number(0)
+		case "Tw":
+			ts.wordSpace = number(0)
+		case "Tz":
+			ts.scale = number(0) / 100
+		case "TL":
+			ts.leading = number(0)
+		case "Td":
+			ts.translate(number(0), number(1))
+			ts.moveTo(w)
+		case "TD":
+			ts.leading = -number(1)
+			ts.translate(number(0), number(1))
+			ts.moveTo(w)
+		case "Tm":
+			if len(operands) >= 6 {
+				for i := 0; i < 6; i++ {
+					ts.matrix[i] = number(i)
+				}
+				ts.moveTo(w)
+			}
+		case "T*":
+			ts.nextLine(w)
+		case "Tj":
+			if s, ok := lastString(operands); ok {
+				ts.show(w, s)
+			}
+		case "'":
+			ts.nextLine(w)
+			if s, ok := lastString(operands); ok {
+				ts.show(w, s)
+			}
+		case "\"":
+			ts.wordSpace = number(0)
+			ts.charSpace = number(1)
+			ts.nextLine(w)
+			if s, ok := lastString(operands); ok {
+				ts.show(w, s)
+			}
+		case "TJ":
+			if len(operands) > 0 {
+				if arr, ok := operands[len(operands)-1].(pdfArray); ok {
+					for _, item := range arr {
+						switch v := item.(type) {
+						case pdfString:
+							ts.show(w, v)
+						case float64:
+							// Large negative adjustments (thousandths of an em) are word or column gaps
+							if v < -pdfColumnGap*1000 {
+								w.gap()
+							} else if v < -180 {
+								w.space()
+							}
+							ts.penX -= v / 1000 * ts.fontSize * ts.scale * ts.unit()
+						}
+					}
+				}
+			}
+		case "Do":
+			if len(operands) >= 1 && depth < maxFormXObjectDepth && xobjects != nil {
+				if name, ok := operands[0].(pdfName); ok {
+					d.runFormXObject(xobjects[name], resources, w, depth)
+				}
+			}
+		}
+
+		operands = operands[:0]
+	}
+}
+
+// runFormXObject interprets a form XObject referenced by the Do operator
+func (d *pdfDocument) runFormXObject(obj interface{}, parentResources pdfDict, w *pdfTextWriter, depth int) {
+	stream, ok := d.resolve(obj).(*pdfStream)
+	if !ok || stream.Dict["Subtype"] != pdfName("Form") {
+		return
+	}
+
+	data, err := d.decodeStream(stream)
+	if err != nil {
+		return
+	}
+
+	resources := parentResources
+	if res := d.dict(stream.Dict["Resources"]); res != nil {
+		resources = res
+	}
+
+	w.newline()
+	d.runContent(data, resources, w, depth+1)
+	w.newline()
+}
+
+func lastString(operands []interface{}) (pdfString, bool) {
+	if len(operands) == 0 {
+		return nil, false
+	}
+	s, ok := operands[len(operands)-1].(pdfString)
+	return s, ok
+}
+
+// skipInlineImage advances past inline image data (BI ... ID <data> EI)
+func skipInlineImage(lexer *pdfLexer) {
+	for {
+		tok, err := lexer.next()
+		if err != nil {
+			return
+		}
+		if kw, ok := tok.(pdfKeyword); ok && kw == "ID" {
+			break
+		}
+	}
+
+	data := lexer.data
+	for pos := lexer.pos + 1; pos+2 <= len(data); {
+		idx := bytes.Index(data[pos:], []byte("EI"))
+		if idx < 0 {
+			lexer.pos = len(data)
+			return
+		}
+		end := pos + idx
+		before := end == 0 || isPDFWhitespace(data[end-1])
+		after := end+2 == len(data) || isPDFWhitespace(data[end+2])
+		if before && after {
+			lexer.pos = end + 2
+			return
+		}
+		pos = end + 2
+	}
+	lexer.pos = len(data)
+}
+
+// layoutTables finds tables in page text whose column gaps are marked with tabs: runs of at
+// least pdfMinTableRows consecutive lines with two or more cells. Offsets are into the text as
+// normalizeLayoutText returns it, and a caption is taken from the line before a table.
+func layoutTables(text string) []extractedTable {
+	var tables []extractedTable
+	var rows [][]string
+	var previous string
+	offset, runStart, runEnd := 0, 0, 0
+	caption := ""
+
+	closeRun := func() {
+		if len(rows) >= pdfMinTableRows {
+			if table := newExtractedTable(caption, rows); table != nil {
+				table.start, table.end = runStart, runEnd
+				tables = append(tables, *table)
+			}
+		}
+		rows = nil
+	}
+
+	for _, raw := range strings.Split(text, "\n") {
+		line := strings.Join(strings.Fields(raw), " ")
+		if line == "" {
+			continue
+		}
+		start := offset
+		offset += len(line) + 1
+
+		var cells []string
+		for _, cell := range strings.Split(raw, "\t") {
+			if cell = strings.Join(strings.Fields(cell), " "); cell != "" {
+				cells = append(cells, cell)
+			}
+		}
+		if len(cells) < 2 {
+			closeRun()
+			previous = line
+			continue
+		}
+
+		if len(rows) == 0 {
+			runStart, caption = start, ""
+			if captionRe.MatchString(previous) {
+				caption = previous
+			}
+		}
+		rows = append(rows, cells)
+		runEnd = start + len(line)
+		previous = ""
+	}
+	closeRun()
+	return tables
+}
+
+// normalizeLayoutText collapses runs of spaces within lines and drops blank lines
+func normalizeLayoutText(text string) string {
+	lines := strings.Split(text, "\n")
+	cleaned := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.Join(strings.Fields(line), " ")
+		if line != "" {
+			cleaned = append(cleaned, line)
+		}
+	}
+	return strings.Join(cleaned, "\n")
+}
@@ -0,0 +1,486 @@
+package document
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// pdfFont decodes character codes in text-showing operators into Unicode text
+type pdfFont struct {
+	toUnicode    *pdfCMap
+	encoding     [256]rune
+	composite    bool
+	widths       map[int]float64 // glyph advance widths in thousandths of text space units
+	defaultWidth float64
+}
+
+// pdfCMap is a parsed ToUnicode CMap
+type pdfCMap struct {
+	codespaces []pdfCodespace
+	mappings   map[string]string
+	ranges     []pdfCMapRange
+}
+
+type pdfCodespace struct {
+	low  []byte
+	high []byte
+}
+
+type pdfCMapRange struct {
+	low   []byte
+	high  []byte
+	dst   []byte   // base destination when dsts is empty
+	dsts  []string // explicit destinations
+	width int
+}
+
+// font returns the decoder for a font resource, caching by object identity
+func (d *pdfDocument) font(obj interface{}) *pdfFont {
+	key := obj
+	if _, ok := obj.(pdfRef); !ok {
+		key = nil
+	}
+	if key != nil {
+		if cached, ok := d.fonts[key]; ok {
+			return cached
+		}
+	}
+
+	font := newDefaultPDFFont()
+	dict := d.dict(obj)
+	if dict == nil {
+		return font
+	}
+
+	font.composite = dict["Subtype"] == pdfName("Type0")
+	d.loadFontWidths(font, dict)
+
+	if stream, ok := d.resolve(dict["ToUnicode"]).(*pdfStream); ok {
+		if data, err := d.decodeStream(stream); err == nil {
+			font.toUnicode = parsePDFCMap(data)
+		}
+	}
+
+	switch enc := d.resolve(dict["Encoding"]).(type) {
+	case pdfName:
+		font.encoding = baseEncoding(enc)
+	case pdfDict:
+		if base, ok := d.resolve(enc["BaseEncoding"]).(pdfName); ok {
+			font.encoding = baseEncoding(base)
+		}
+		if diffs, ok := d.resolve(enc["Differences"]).(pdfArray); ok {
+			code := 0
+			for _, item := range diffs {
+				switch v := d.resolve(item).(type) {
+				case float64:
+					code = int(v)
+				case pdfName:
+					if code >= 0 && code < 256 {
+						if r, ok := glyphNameToRune(string(v)); ok {
+							font.encoding[code] = r
+						}
+					}
+					code++
+				}
+			}
+		}
+	}
+
+	if key != nil {
+		d.fonts[key] = font
+	}
+	return font
+}
+
+func newDefaultPDFFont() *pdfFont {
+	return &pdfFont{
+		encoding:     winAnsiEncoding,
+		widths:       make(map[int]float64),
+		defaultWidth: 500,
+	}
+}
+
+// loadFontWidths reads /Widths for simple fonts and /W for composite fonts
+func (d *pdfDocument) loadFontWidths(font *pdfFont, dict pdfDict) {
+	if !font.composite {
+		first := int(d.number(dict["FirstChar"]))
+		if widths, ok := d.resolve(dict["Widths"]).(pdfArray); ok {
+			for i, w := range widths {
+				font.widths[first+i] = d.number(w)
+			}
+		}
+		if descriptor := d.dict(dict["FontDescriptor"]); descriptor != nil {
+			if missing := d.number(descriptor["MissingWidth"]); missing > 0 {
+				font.defaultWidth = missing
+			}
+		}
+		return
+	}
+
+	descendants, ok := d.resolve(dict["DescendantFonts"]).(pdfArray)
+	if !ok || len(descendants) == 0 {
+		return
+	}
+	cidFont := d.dict(descendants[0])
+	if cidFont == nil {
+		return
+	}
+
+	font.defaultWidth = 1000
+	if dw := d.number(cidFont["DW"]); dw > 0 {
+		font.defaultWidth = dw
+	}
+
+	w, ok := d.resolve(cidFont["W"]).(pdfArray)
+	if !ok {
+		return
+	}
+	for i := 0; i < len(w); {
+		first, ok := d.resolve(w[i]).(float64)
+		if !ok || i+1 >= len(w) {
+			return
+		}
+		if list, ok := d.resolve(w[i+1]).(pdfArray); ok {
+			for j, width := range list {
+				font.widths[int(first)+j] = d.number(width)
+			}
+			i += 2
+			continue
+		}
+		if i+2 >= len(w) {
+			return
+		}
+		last := int(d.number(w[i+1]))
+		width := d.number(w[i+2])
+		for cid := int(first); cid <= last && cid-int(first) < 65536; cid++ {
+			font.widths[cid] = width
+		}
+		i += 3
+	}
+}
+
+func baseEncoding(name pdfName) [256]rune {
+	switch name {
+	case "MacRomanEncoding":
+		return macRomanEncoding
+	case "StandardEncoding":
+		return standardEncoding
+	}
+	return winAnsiEncoding
+}
+
+// show decodes a string operand, returning its text, its total glyph width in
+// thousandths of text space units, the number of character codes and the number
+// of single-byte space codes (which receive word spacing)
+func (f *pdfFont) show(s []byte) (text string, width float64, count int, spaces int) {
+	var sb strings.Builder
+	for i := 0; i < len(s); {
+		n := 1
+		if f.toUnicode != nil {
+			n = f.toUnicode.codeLength(s[i:], f.composite)
+		} else if f.composite && i+1 < len(s) {
+			n = 2
+		}
+		if i+n > len(s) {
+			n = len(s) - i
+		}
+		code := s[i : i+n]
+		i += n
+
+		sb.WriteString(f.decodeCode(code))
+
+		value := codeValue(code)
+		if w, ok := f.widths[value]; ok {
+			width += w
+		} else {
+			width += f.defaultWidth
+		}
+		count++
+		if n == 1 && code[0] == ' ' {
+			spaces++
+		}
+	}
+	return sb.String(), width, count, spaces
+}
+
+// decodeCode maps a single character code to text
+func (f *pdfFont) decodeCode(code []byte) string {
+	if f.toUnicode != nil {
+		if text, ok := f.toUnicode.lookup(code); ok {
+			return text
+		}
+	}
+
+	if f.composite {
+		// Without a ToUnicode entry the CIDs of a composite font cannot be mapped reliably;
+		// only treat codes as Unicode when they fall in the printable BMP range.
+		if value := rune(codeValue(code)); len(code) == 2 && value >= 0x20 && value < 0xD800 {
+			return string(value)
+		}
+		return ""
+	}
+
+	if r := f.encoding[code[0]]; r != 0 {
+		return string(r)
+	}
+	return ""
+}
+
+// parsePDFCMap parses the codespace, bfchar and bfrange sections of a CMap
+func parsePDFCMap(data []byte) *pdfCMap {
+	cmap := &pdfCMap{mappings: make(map[string]string)}
+	lexer := &pdfLexer{data: data}
+
+	var operands []interface{}
+	for {
+		tok, err := lexer.next()
+		if err != nil {
+			break
+		}
+
+		kw, ok := tok.(pdfKeyword)
+		if !ok {
+			operands = append(operands, tok)
+			continue
+		}
+
+		switch kw {
+		case "begincodespacerange", "beginbfchar", "beginbfrange":
+			operands = operands[:0]
+		case "endcodespacerange":
+			for i := 0; i+1 < len(operands); i += 2 {
+				low, ok1 := operands[i].(pdfString)
+				high, ok2 := operands[i+1].(pdfString)
+				if ok1 && ok2 && len(low) == len(high) && len(low) > 0 {
+					cmap.codespaces = append(cmap.codespaces, pdfCodespace{low: low, high: high})
+				}
+			}
+			operands = operands[:0]
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].(pdfString)
+				dst, ok2 := operands[i+1].(pdfString)
+				if ok1 && ok2 {
+					cmap.mappings[string(src)] = decodeUTF16BE(dst)
+				}
+			}
+			operands = operands[:0]
+		case "endbfrange":
+			cmap.addRanges(operands)
+			operands = operands[:0]
+		case "[":
+			// Arrays inside bfrange destinations
+			arr, err := lexer.parseFrom(tok)
+			if err == nil {
+				operands = append(operands, arr)
+			}
+		default:
+			operands = operands[:0]
+		}
+	}
+
+	return cmap
+}
+
+func (c *pdfCMap) addRanges(operands []interface{}) {
+	for i := 0; i+2 < len(operands); i += 3 {
+		low, ok1 := operands[i].(pdfString)
+		high, ok2 := operands[i+1].(pdfString)
+		if !ok1 || !ok2 || len(low) != len(high) || len(low) == 0 {
+			continue
+		}
+
+		r := pdfCMapRange{low: low, high: high, width: len(low)}
+		switch dst := operands[i+2].(type) {
+		case pdfString:
+			r.dst = dst
+		case pdfArray:
+			for _, item := range dst {
+				if s, ok := item.(pdfString); ok {
+					r.dsts = append(r.dsts, decodeUTF16BE(s))
+				}
+			}
+		default:
+			continue
+		}
+		c.ranges = append(c.ranges, r)
+	}
+}
+
+// codeLength determines how many bytes the next character code occupies
+func (c *pdfCMap) codeLength(s []byte, composite bool) int {
+	for _, cs := range c.codespaces {
+		n := len(cs.low)
+		if n > len(s) {
+			continue
+		}
+		inRange := true
+		for i := 0; i < n; i++ {
+			if s[i] < cs.low[i] || s[i] > cs.high[i] {
+				inRange = false
+				break
+			}
+		}
+		if inRange {
+			return n
+		}
+	}
+	if composite && len(s) >= 2 {
+		return 2
+	}
+	return 1
+}
+
+// lookup maps a character code through the bfchar and bfrange entries
+func (c *pdfCMap) lookup(code []byte) (string, bool) {
+	if text, ok := c.mappings[string(code)]; ok {
+		return text, true
+	}
+	return c.lookupRange(code)
+}
+
+func (c *pdfCMap) lookupRange(code []byte) (string, bool) {
+	for _, r := range c.ranges {
+		if r.width != len(code) || bytes.Compare(code, r.low) < 0 || bytes.Compare(code, r.high) > 0 {
+			continue
+		}
+
+		offset := codeValue(code) - codeValue(r.low)
+		if len(r.dsts) > 0 {
+			if offset < len(r.dsts) {
+				return r.dsts[offset], true
+			}
+			return "", false
+		}
+
+		dst := append([]byte(nil), r.dst...)
+		// Increment the last byte(s) of the destination by the offset
+		carry := offset
+		for j := len(dst) - 1; j >= 0 && carry > 0; j-- {
+			sum := int(dst[j]) + carry
+			dst[j] = byte(sum & 0xFF)
+			carry = sum >> 8
+		}
+		return decodeUTF16BE(dst), true
+	}
+	return "", false
+}
+
+func codeValue(code []byte) int {
+	v := 0
+	for _, b := range code {
+		v = v<<8 | int(b)
+	}
+	return v
+}
+
+// glyphNameToRune maps Adobe glyph names to Unicode
+func glyphNameToRune(name string) (rune, bool) {
+	if r, ok := glyphNames[name]; ok {
+		return r, true
+	}
+	if len(name) == 1 {
+		return rune(name[0]), true
+	}
+	if strings.HasPrefix(name, "uni") && len(name) >= 7 {
+		if v, err := strconv.ParseUint(name[3:7], 16, 32); err == nil {
+			return rune(v), true
+		}
+	}
+	if strings.HasPrefix(name, "u") && len(name) >= 5 && len(name) <= 7 {
+		if v, err := strconv.ParseUint(name[1:], 16, 32); err == nil {
+			return rune(v), true
+		}
+	}
+	// Suffixed variants such as "a.sc" or "one.oldstyle"
+	if idx := strings.IndexByte(name, '.'); idx > 0 {
+		return glyphNameToRune(name[:idx])
+	}
+	return 0, false
+}
+
+var glyphNames = map[string]rune{
+	"space": ' ', "exclam": '!', "quotedbl": '"', "numbersign": '#', "dollar": '$',
+	"percent": '%', "ampersand": '&', "quotesingle": '\'', "quoteright": '’',
+	"parenleft": '(', "parenright": ')', "asterisk": '*', "plus": '+', "comma": ',',
+	"hyphen": '-', "period": '.', "slash": '/', "zero": '0', "one": '1', "two": '2',
+	"three": '3', "four": '4', "five": '5', "six": '6', "seven": '7', "eight": '8',
+	"nine": '9', "colon": ':', "semicolon": ';', "less": '<', "equal": '=',
+	"greater": '>', "question": '?', "at": '@', "bracketleft": '[', "backslash": '\\',
+	"bracketright": ']', "asciicircum": '^', "underscore": '_', "grave": '`',
+	"quoteleft": '‘', "braceleft": '{', "bar": '|', "braceright": '}',
+	"asciitilde": '~', "bullet": '•', "endash": '–', "emdash": '—',
+	"quotedblleft": '“', "quotedblright": '”', "quotesinglbase": '‚',
+	"quotedblbase": '„', "ellipsis": '…', "dagger": '†',
+	"daggerdbl": '‡', "section": '§', "paragraph": '¶',
+	"copyright": '©', "registered": '®', "trademark": '™',
+	"degree": '°', "fi": 'ﬁ', "fl": 'ﬂ', "ff": 'ﬀ',
+	"ffi": 'ﬃ', "ffl": 'ﬄ', "minus": '−', "multiply": '×',
+	"divide": '÷', "sterling": '£', "yen": '¥', "Euro": '€',
+	"cent": '¢', "nbspace": ' ', "periodcentered": '·',
+	"eacute": 'é', "egrave": 'è', "ecircumflex": 'ê',
+	"aacute": 'á', "agrave": 'à', "acircumflex": 'â',
+	"adieresis": 'ä', "odieresis": 'ö', "udieresis": 'ü',
+	"ccedilla": 'ç', "ntilde": 'ñ', "oacute": 'ó', "uacute": 'ú',
+	"iacute": 'í', "Eacute": 'É', "germandbls": 'ß',
+}
+
+// winAnsiEncoding is the Windows code page 1252 encoding used by WinAnsiEncoding
+var winAnsiEncoding = func() [256]rune {
+	var enc [256]rune
+	for i := 0; i < 256; i++ {
+		enc[i] = rune(i)
+	}
+	high := map[int]rune{
+		0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…',
+		0x86: '†', 0x87: '‡', 0x88: 'ˆ', 0x89: '‰', 0x8A: 'Š',
+		0x8B: '‹', 0x8C: 'Œ', 0x8E: 'Ž', 0x91: '‘', 0x92: '’',
+		0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—',
+		0x98: '˜', 0x99: '™', 0x9A: 'š', 0x9B: '›', 0x9C: 'œ',
+		0x9E: 'ž', 0x9F: 'Ÿ',
+	}
+	for code, r := range high {
+		enc[code] = r
+	}
+	for i := 0; i < 0x20; i++ {
+		if i != '\t' && i != '\n' && i != '\r' {
+			enc[i] = 0
+		}
+	}
+	return enc
+}()
+
+// standardEncoding is Adobe StandardEncoding, which differs from ASCII for quotes
+var standardEncoding = func() [256]rune {
+	enc := winAnsiEncoding
+	enc[0x27] = '’'
+	enc[0x60] = '‘'
+	for i := 0x80; i < 0x100; i++ {
+		enc[i] = 0
+	}
+	special := map[int]rune{
+		0xA1: '¡', 0xA2: '¢', 0xA3: '£', 0xA7: '§', 0xAA: '“',
+		0xAE: 'ﬁ', 0xAF: 'ﬂ', 0xB1: '–', 0xB2: '†', 0xB3: '‡',
+		0xB4: '·', 0xB6: '¶', 0xB7: '•', 0xBA: '”', 0xBC: '…',
+		0xD0: '—', 0xE1: 'Æ', 0xF1: 'æ', 0xFB: 'ß',
+	}
+	for code, r := range special {
+		enc[code] = r
+	}
+	return enc
+}()
+
+// macRomanEncoding is the Mac OS Roman encoding used by MacRomanEncoding
+var macRomanEncoding = func() [256]rune {
+	enc := winAnsiEncoding
+	upper := []rune(
+		"ÄÅÇÉÑÖÜáàâäãåçéèêëíìîïñóòôöõúùûü" +
+			"†°¢£§•¶ß®©™´¨≠ÆØ∞±≤≥¥µ∂∑∏π∫ªºΩæø" +
+			"¿¡¬√ƒ≈∆«»…\u00a0ÀÃÕŒœ–—“”‘’÷◊ÿŸ⁄€‹›ﬁﬂ" +
+			"‡·‚„‰ÂÊÁËÈÍÎÏÌÓÔÒÚÛÙıˆ˜¯˘˙˚¸˝˛ˇ")
+	for i, r := range upper {
+		enc[0x80+i] = r
+	}
+	return enc
+}()
@@ -0,0 +1,272 @@
+package document
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"ai-government-consultant/internal/summary"
+)
+
+// pdfObject is one indirect object of a test PDF: a dictionary or other value, or a stream
+type pdfObject struct {
+	num    int
+	body   string
+	stream []byte // written after body, which is then the stream dictionary without /Length
+}
+
+// buildPDF writes a PDF file from objects. The parser locates objects by scanning, so the file
+// needs no cross-reference table; trailer is written after the objects when it is not empty.
+func buildPDF(trailer string, objects ...pdfObject) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+	for _, obj := range objects {
+		fmt.Fprintf(&buf, "%d 0 obj\n", obj.num)
+		if obj.stream != nil {
+			body := strings.TrimSuffix(strings.TrimSpace(obj.body), ">>")
+			fmt.Fprintf(&buf, "%s /Length %d >>\nstream\n", body, len(obj.stream))
+			buf.Write(obj.stream)
+			buf.WriteString("\nendstream")
+		} else {
+			buf.WriteString(obj.body)
+		}
+		buf.WriteString("\nendobj\n")
+	}
+	if trailer != "" {
+		fmt.Fprintf(&buf, "trailer\n%s\n", trailer)
+	}
+	buf.WriteString("%%EOF\n")
+	return buf.Bytes()
+}
+
+// deflate compresses data as a FlateDecode stream
+func deflate(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("deflate: %v", err)
+	}
+	w.Close()
+	return buf.Bytes()
+}
+
+// textContent is a page content stream showing each line with the standard Helvetica font
+func textContent(lines ...string) []byte {
+	var content strings.Builder
+	content.WriteString("BT /F1 12 Tf 14 TL 72 720 Td\n")
+	for _, line := range lines {
+		fmt.Fprintf(&content, "(%s) Tj T*\n", line)
+	}
+	content.WriteString("ET")
+	return []byte(content.String())
+}
+
+const helvetica = "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>"
+
+// pagedPDF builds a PDF with one page per content stream; filter is the streams' /Filter entry
+func pagedPDF(filter string, contents ...[]byte) []byte {
+	objects := []pdfObject{{num: 1, body: "<< /Type /Catalog /Pages 2 0 R >>"}, {num: 3, body: helvetica}}
+	var kids []string
+	for i, content := range contents {
+		page, stream := 10+2*i, 11+2*i
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+		objects = append(objects,
+			pdfObject{num: page, body: fmt.Sprintf("<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", stream)},
+			pdfObject{num: stream, body: "<< " + filter + " >>", stream: content},
+		)
+	}
+	objects = append(objects, pdfObject{num: 2, body: fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(contents))})
+	return buildPDF("<< /Root 1 0 R /Size 30 >>", objects...)
+}
+
+func TestExtractTextFromPDF(t *testing.T) {
+	page1 := textContent("Section 1. Purpose", "This policy sets travel rules.")
+	page2 := textContent("Section 2. Requirements", "Travelers shall file vouchers within 5 days of return.")
+
+	tests := []struct {
+		name string
+		data func(t *testing.T) []byte
+	}{
+		{name: "plain streams", data: func(t *testing.T) []byte { return pagedPDF("", page1, page2) }},
+		{name: "flate streams", data: func(t *testing.T) []byte {
+			return pagedPDF("/Filter /FlateDecode", deflate(t, page1), deflate(t, page2))
+		}},
+		{name: "filter array", data: func(t *testing.T) []byte {
+			return pagedPDF("/Filter [/FlateDecode]", deflate(t, page1), deflate(t, page2))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := (&Service{}).extractTextFromPDF(tt.data(t))
+			if err != nil {
+				t.Fatalf("extractTextFromPDF: %v", err)
+			}
+			if len(result.Pages) != 2 {
+				t.Fatalf("got %d pages, want 2", len(result.Pages))
+			}
+			wantPages := []string{"This policy sets travel rules.", "Travelers shall file vouchers within 5 days of return."}
+			for i, span := range result.Pages {
+				if span.Number != i+1 {
+					t.Errorf("page %d numbered %d", i+1, span.Number)
+				}
+				if !strings.Contains(result.Text[span.Start:span.End], wantPages[i]) {
+					t.Errorf("page %d text %q lacks %q", i+1, result.Text[span.Start:span.End], wantPages[i])
+				}
+			}
+		})
+	}
+}
+
+func TestExtractedPDFTextSummarizesWithMockProvider(t *testing.T) {
+	data := pagedPDF("/Filter /FlateDecode",
+		deflate(t, textContent("This policy sets travel rules.")),
+		deflate(t, textContent("Travelers shall file vouchers within 5 days of return.")),
+	)
+	result, err := (&Service{}).extractTextFromPDF(data)
+	if err != nil {
+		t.Fatalf("extractTextFromPDF: %v", err)
+	}
+
+	generated, err := summary.NewSummarizer(summary.NewMockProvider(), nil).Summarize(context.Background(), "Travel", result.Text, nil)
+	if err != nil {
+		t.Fatalf("Summarize: %v", err)
+	}
+	if !strings.HasPrefix(generated.Executive, "This policy sets travel rules.") {
+		t.Errorf("Executive = %q", generated.Executive)
+	}
+	if len(generated.Obligations) != 1 || generated.Obligations[0].Deadline != "within 5 days of return" {
+		t.Errorf("Obligations = %+v, want the voucher deadline", generated.Obligations)
+	}
+}
+
+func TestInflatePDFDataCap(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		wantErr bool
+	}{
+		{name: "small", size: 1 << 10},
+		{name: "at the cap", size: MaxDocumentSize},
+		{name: "over the cap", size: MaxDocumentSize + 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Zeros compress about a thousandfold, as a decompression bomb would
+			compressed := deflate(t, make([]byte, tt.size))
+			out, err := inflatePDFData(compressed)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("inflated %d bytes, want an error past %d", len(out), MaxDocumentSize)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("inflatePDFData: %v", err)
+			}
+			if len(out) != tt.size {
+				t.Fatalf("inflated %d bytes, want %d", len(out), tt.size)
+			}
+		})
+	}
+
+	// A page whose content stream exceeds the cap is skipped rather than read into memory
+	bomb := deflate(t, make([]byte, MaxDocumentSize+1))
+	data := pagedPDF("/Filter /FlateDecode", deflate(t, textContent("Readable page.")), bomb)
+	result, err := (&Service{}).extractTextFromPDF(data)
+	if err != nil {
+		t.Fatalf("extractTextFromPDF: %v", err)
+	}
+	if !strings.Contains(result.Text, "Readable page.") || len(result.Text) > 1<<10 {
+		t.Errorf("extracted %d bytes, want only the readable page", len(result.Text))
+	}
+}
+
+// objectStreamPDF builds a PDF whose page objects live in an object stream. header lists the
+// object numbers and offsets as written in the stream; bodies follow it at First.
+func objectStreamPDF(t *testing.T, header string, bodies string) []byte {
+	stream := header + "\n" + bodies
+	first := len(header) + 1
+	return buildPDF("",
+		pdfObject{num: 20, body: fmt.Sprintf("<< /Type /ObjStm /N 4 /First %d /Filter /FlateDecode >>", first), stream: deflate(t, []byte(stream))},
+		pdfObject{num: 5, body: "<< >>", stream: textContent("Packed page text.")},
+		pdfObject{num: 6, body: helvetica},
+		pdfObject{num: 21, body: "<< /Type /XRef /Root 1 0 R /Size 22 >>", stream: []byte{}},
+	)
+}
+
+func TestLoadObjectStreams(t *testing.T) {
+	catalog := "<< /Type /Catalog /Pages 2 0 R >>"
+	pages := "<< /Type /Pages /Kids [3 0 R] /Count 1 >>"
+	page := "<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 6 0 R >> >> /Contents 5 0 R >>"
+	bodies := catalog + " " + pages + " " + page + " 42"
+	offCatalog, offPages, offPage, offLast := 0, len(catalog)+1, len(catalog)+len(pages)+2, len(bodies)-2
+
+	tests := []struct {
+		name    string
+		header  string
+		loaded  []int
+		skipped []int
+	}{
+		{
+			name:   "valid offsets",
+			header: fmt.Sprintf("1 %d 2 %d 3 %d 7 %d", offCatalog, offPages, offPage, offLast),
+			loaded: []int{1, 2, 3, 7},
+		},
+		{
+			name:    "negative offset",
+			header:  fmt.Sprintf("1 %d 2 %d 3 %d 7 -5", offCatalog, offPages, offPage),
+			loaded:  []int{1, 2, 3},
+			skipped: []int{7},
+		},
+		{
+			name:    "offset past the stream",
+			header:  fmt.Sprintf("1 %d 2 %d 3 %d 7 %d", offCatalog, offPages, offPage, len(bodies)+100),
+			loaded:  []int{1, 2, 3},
+			skipped: []int{7},
+		},
+		{
+			name:    "offset too large for an int",
+			header:  fmt.Sprintf("1 %d 2 %d 3 %d 7 1e300", offCatalog, offPages, offPage),
+			loaded:  []int{1, 2, 3},
+			skipped: []int{7},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := objectStreamPDF(t, tt.header, bodies)
+			doc, err := parsePDF(data)
+			if err != nil {
+				t.Fatalf("parsePDF: %v", err)
+			}
+			for _, num := range tt.loaded {
+				if doc.objects[num] == nil {
+					t.Errorf("object %d was not loaded from the object stream", num)
+				}
+			}
+			for _, num := range tt.skipped {
+				if obj, ok := doc.objects[num]; ok {
+					t.Errorf("object %d was loaded from an out-of-range offset as %v", num, obj)
+				}
+			}
+
+			// The trailer comes from the cross-reference stream
+			result, err := (&Service{}).extractTextFromPDF(data)
+			if err != nil {
+				t.Fatalf("extractTextFromPDF: %v", err)
+			}
+			if !strings.Contains(result.Text, "Packed page text.") {
+				t.Errorf("text %q lacks the packed page", result.Text)
+			}
+		})
+	}
+}
+
+func TestParsePDFRejectsNonPDF(t *testing.T) {
+	if _, err := parsePDF([]byte("plain text, not a PDF")); err == nil {
+		t.Fatal("parsePDF accepted a file without a PDF header")
+	}
+}
@@ -0,0 +1,198 @@
+package document
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"ai-government-consultant/internal/models"
+)
+
+// piiDetector finds one kind of personal data. When the pattern has a capture group, only the
+// group is the sensitive value and the rest of the match is context such as "Account No.".
+type piiDetector struct {
+	Type       string
+	Pattern    *regexp.Regexp
+	Confidence float64
+	Valid      func(value string) bool
+}
+
+// piiDetectors are listed from most to least specific; when matches overlap, the earlier detector wins
+var piiDetectors = []piiDetector{
+	{
+		Type:       "ssn",
+		Pattern:    regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+		Confidence: 0.95,
+		Valid:      validSSN,
+	},
+	{
+		Type:       "ssn",
+		Pattern:    regexp.MustCompile(`(?i)\b(?:ssn|social security (?:number|no\.?))[\s:#]*(\d{9})\b`),
+		Confidence: 0.95,
+		Valid:      validSSN,
+	},
+	{
+		Type:       "credit_card",
+		Pattern:    regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		Confidence: 0.90,
+		Valid:      validCardNumber,
+	},
+	{
+		Type:       "routing_number",
+		Pattern:    regexp.MustCompile(`(?i)\b(?:routing|aba|rtn)(?:\s+(?:number|no\.?|#))?[\s:#]*(\d{9})\b`),
+		Confidence: 0.90,
+		Valid:      validRoutingNumber,
+	},
+	{
+		Type:       "bank_account",
+		Pattern:    regexp.MustCompile(`(?i)\b(?:bank account|account|acct\.?)(?:\s+(?:number|no\.?|#))?[\s:#]*(\d[\d-]{4,18}\d)\b`),
+		Confidence: 0.85,
+	},
+	{
+		Type:       "date_of_birth",
+		Pattern:    regexp.MustCompile(`(?i)\b(?:dob|d\.o\.b\.|date of birth|born(?: on)?)[\s:]*(\d{1,2}[/-]\d{1,2}[/-]\d{2,4}|(?:jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.? \d{1,2},? \d{4})`),
+		Confidence: 0.90,
+	},
+	{
+		Type:       "email",
+		Pattern:    regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`),
+		Confidence: 0.95,
+	},
+	{
+		// A phone number must be written with separators or an area code in parentheses, so that
+		// bare policy, contract and case numbers are not taken for one
+		Type:       "phone",
+		Pattern:    regexp.MustCompile(`(?:\+?1[-. ])?(?:\(\d{3}\) ?\d{3}[-. ]?|\b\d{3}[-. ]\d{3}[-. ])\d{4}\b`),
+		Confidence: 0.85,
+	},
+	{
+		// Unseparated digits count only after phone wording such as "Tel:" or "call"
+		Type:       "phone",
+		Pattern:    regexp.MustCompile(`(?i)\b(?:tel|telephone|phone|cell|mobile|fax|call)\.?(?:\s+(?:number|no\.?|#))?[\s:#]*((?:\+?1)?\d{10})\b`),
+		Confidence: 0.70,
+	},
+	{
+		Type:       "street_address",
+		Pattern:    regexp.MustCompile(`\b\d{1,6}\s+(?:[NSEW]\.?\s+)?(?:[A-Z][a-zA-Z]+\s+){1,4}(?:Street|St|Avenue|Ave|Road|Rd|Boulevard|Blvd|Lane|Ln|Drive|Dr|Court|Ct|Way|Place|Pl|Terrace|Circle|Cir|Parkway|Pkwy)\b\.?(?:,?\s+(?:Apt|Apartment|Suite|Ste|Unit|#)\.?\s*[A-Za-z0-9-]+)?`),
+		Confidence: 0.80,
+	},
+}
+
+// detectPII finds personal data in content and returns it as sensitive entities, ordered by position
+func detectPII(content string) []models.Entity {
+	var found []models.Entity
+	for _, detector := range piiDetectors {
+		for _, match := range detector.Pattern.FindAllStringSubmatchIndex(content, -1) {
+			start, end := match[0], match[1]
+			if len(match) >= 4 && match[2] >= 0 {
+				start, end = match[2], match[3]
+			}
+			value := content[start:end]
+			if detector.Valid != nil && !detector.Valid(value) {
+				continue
+			}
+			if overlapsAny(found, start, end) {
+				continue
+			}
+			found = append(found, models.Entity{
+				Type:       detector.Type,
+				Value:      value,
+				Confidence: detector.Confidence,
+				StartPos:   start,
+				EndPos:     end,
+				Sensitive:  true,
+			})
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].StartPos < found[j].StartPos
+	})
+	return found
+}
+
+// redactText masks every sensitive entity in content with a type label such as "[SSN]", padded
+// with spaces to the span's byte length. Keeping the length means page, section and chunk offsets
+// recorded against the original content apply unchanged to the redacted text.
+func redactText(content string, entities []models.Entity) string {
+	redacted := []byte(content)
+	for _, entity := range entities {
+		if !entity.Sensitive || entity.StartPos < 0 || entity.EndPos > len(redacted) || entity.StartPos >= entity.EndPos {
+			continue
+		}
+		span := entity.EndPos - entity.StartPos
+		label := "[" + strings.ToUpper(entity.Type) + "]"
+		if len(label) > span {
+			label = strings.Repeat("*", span)
+		}
+		copy(redacted[entity.StartPos:], label+strings.Repeat(" ", span-len(label)))
+	}
+	return string(redacted)
+}
+
+// overlapsAny reports whether the byte range [start, end) overlaps any of the entities
+func overlapsAny(entities []models.Entity, start, end int) bool {
+	for _, entity := range entities {
+		if start < entity.EndPos && entity.StartPos < end {
+			return true
+		}
+	}
+	return false
+}
+
+// validSSN rejects numbers the SSA never issues: area 000, 666 or 900-999, group 00, serial 0000
+func validSSN(value string) bool {
+	digits := onlyDigits(value)
+	if len(digits) != 9 {
+		return false
+	}
+	area, group, serial := digits[:3], digits[3:5], digits[5:]
+	return area != "000" && area != "666" && area[0] != '9' && group != "00" && serial != "0000"
+}
+
+// validCardNumber checks the length and Luhn checksum of a payment card number
+func validCardNumber(value string) bool {
+	digits := onlyDigits(value)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// validRoutingNumber checks the ABA routing number checksum
+func validRoutingNumber(value string) bool {
+	digits := onlyDigits(value)
+	if len(digits) != 9 {
+		return false
+	}
+
+	weights := []int{3, 7, 1, 3, 7, 1, 3, 7, 1}
+	sum := 0
+	for i, weight := range weights {
+		sum += int(digits[i]-'0') * weight
+	}
+	return sum%10 == 0
+}
+
+func onlyDigits(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, value)
+}
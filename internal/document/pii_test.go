@@ -0,0 +1,218 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"ai-government-consultant/internal/models"
+	"ai-government-consultant/internal/summary"
+)
+
+// piiMatch is an entity detectPII is expected to report
+type piiMatch struct {
+	typ   string
+	value string
+}
+
+var piiTests = []struct {
+	name string
+	text string
+	want []piiMatch
+}{
+	// ssn
+	{name: "dashed ssn", text: "Employee SSN 123-45-6789 on file.", want: []piiMatch{{"ssn", "123-45-6789"}}},
+	{name: "ssn after keyword", text: "Social Security Number: 123456789.", want: []piiMatch{{"ssn", "123456789"}}},
+	{name: "ssn with area 000", text: "Form 000-12-3456 was filed."},
+	{name: "ssn with area 666", text: "Reference 666-12-3456."},
+	{name: "ssn with area 9xx", text: "Reference 912-34-5678."},
+	{name: "ssn with group 00", text: "Reference 123-00-4567."},
+	{name: "nine digits without keyword", text: "Contract 123456789 was awarded."},
+
+	// credit_card
+	{name: "spaced card", text: "Charged to 4111 1111 1111 1111 today.", want: []piiMatch{{"credit_card", "4111 1111 1111 1111"}}},
+	{name: "dashed card", text: "Card 5500-0000-0000-0004 expires soon.", want: []piiMatch{{"credit_card", "5500-0000-0000-0004"}}},
+	{name: "unseparated card", text: "Card 378282246310005 on file.", want: []piiMatch{{"credit_card", "378282246310005"}}},
+	{name: "card failing luhn", text: "Charged to 4111 1111 1111 1112 today."},
+	{name: "long tracking number", text: "Tracking 12345678901234567 shipped."},
+
+	// routing_number
+	{name: "routing number", text: "Routing number 021000021 for deposits.", want: []piiMatch{{"routing_number", "021000021"}}},
+	{name: "aba number", text: "ABA: 011000015", want: []piiMatch{{"routing_number", "011000015"}}},
+	{name: "routing failing checksum", text: "Routing number 021000022 for deposits."},
+	{name: "valid routing without keyword", text: "Docket 021000021 was closed."},
+
+	// bank_account
+	{name: "account number", text: "Account No. 12345678 is closed.", want: []piiMatch{{"bank_account", "12345678"}}},
+	{name: "dashed bank account", text: "Bank account 0042-771-93 was frozen.", want: []piiMatch{{"bank_account", "0042-771-93"}}},
+	{name: "account in prose", text: "An account of the meeting follows."},
+	{name: "short account number", text: "Account 1234 is a test ledger."},
+
+	// date_of_birth
+	{name: "dob numeric", text: "DOB: 04/12/1980", want: []piiMatch{{"date_of_birth", "04/12/1980"}}},
+	{name: "born on", text: "She was born on March 3, 1975 in Ohio.", want: []piiMatch{{"date_of_birth", "March 3, 1975"}}},
+	{name: "date of birth", text: "Date of birth 7-9-62.", want: []piiMatch{{"date_of_birth", "7-9-62"}}},
+	{name: "date without birth wording", text: "Effective 04/12/1980 the rule applies."},
+
+	// email
+	{name: "email", text: "Contact jane.doe@agency.gov for help.", want: []piiMatch{{"email", "jane.doe@agency.gov"}}},
+	{name: "email with plus", text: "Send to ops+alerts@example.co.uk", want: []piiMatch{{"email", "ops+alerts@example.co.uk"}}},
+	{name: "spelled out email", text: "Write to jane at agency dot gov."},
+	{name: "handle without domain", text: "Mention @agency in the post."},
+
+	// phone
+	{name: "parenthesized phone", text: "Call (202) 555-0143 today.", want: []piiMatch{{"phone", "(202) 555-0143"}}},
+	{name: "dashed phone", text: "Office: 202-555-0143.", want: []piiMatch{{"phone", "202-555-0143"}}},
+	{name: "dotted phone with country code", text: "Line +1 202.555.0143 open.", want: []piiMatch{{"phone", "+1 202.555.0143"}}},
+	{name: "unseparated phone after keyword", text: "Tel: 2025550143", want: []piiMatch{{"phone", "2025550143"}}},
+	{name: "policy number", text: "Policy 1234567890 renews."},
+	{name: "partly separated digits", text: "Case 555-1234567 is open."},
+
+	// street_address
+	{name: "street address", text: "Mail to 1600 Pennsylvania Avenue in Washington.", want: []piiMatch{{"street_address", "1600 Pennsylvania Avenue"}}},
+	{name: "address with suite", text: "Visit 450 Golden Gate Ave, Suite 200 downtown.", want: []piiMatch{{"street_address", "450 Golden Gate Ave, Suite 200"}}},
+	{name: "address with direction", text: "Ship to 12 N. Main St. by Friday.", want: []piiMatch{{"street_address", "12 N. Main St."}}},
+	{name: "count before words", text: "Review 15 pages before the meeting."},
+	{name: "capitalized words without street type", text: "Section 4 Program Goals apply."},
+
+	// several kinds, reported in position order
+	{
+		name: "mixed",
+		text: "Jane (jane@agency.gov, SSN 123-45-6789) paid with 4111111111111111 from 1600 Pennsylvania Avenue in Ohio.",
+		want: []piiMatch{
+			{"email", "jane@agency.gov"},
+			{"ssn", "123-45-6789"},
+			{"credit_card", "4111111111111111"},
+			{"street_address", "1600 Pennsylvania Avenue"},
+		},
+	},
+	{name: "non-ascii text", text: "Dossier für Zoë Müller – Tel: 2025550143 – ÄÖÜ", want: []piiMatch{{"phone", "2025550143"}}},
+	{name: "no personal data", text: "The agency shall publish its annual report by June 30."},
+}
+
+func TestDetectPII(t *testing.T) {
+	for _, tt := range piiTests {
+		t.Run(tt.name, func(t *testing.T) {
+			entities := detectPII(tt.text)
+			if len(entities) != len(tt.want) {
+				t.Fatalf("detectPII(%q) = %+v, want %v", tt.text, entities, tt.want)
+			}
+			for i, want := range tt.want {
+				got := entities[i]
+				if got.Type != want.typ || got.Value != want.value {
+					t.Errorf("entity %d = %s %q, want %s %q", i, got.Type, got.Value, want.typ, want.value)
+				}
+				if tt.text[got.StartPos:got.EndPos] != got.Value {
+					t.Errorf("entity %d spans %q, not its value %q", i, tt.text[got.StartPos:got.EndPos], got.Value)
+				}
+				if !got.Sensitive {
+					t.Errorf("entity %d is not marked sensitive", i)
+				}
+			}
+		})
+	}
+}
+
+func TestRedactTextKeepsLength(t *testing.T) {
+	for _, tt := range piiTests {
+		t.Run(tt.name, func(t *testing.T) {
+			entities := detectPII(tt.text)
+			redacted := redactText(tt.text, entities)
+			if len(redacted) != len(tt.text) {
+				t.Fatalf("redacted text is %d bytes, want %d", len(redacted), len(tt.text))
+			}
+			for _, entity := range entities {
+				if strings.Contains(redacted, entity.Value) {
+					t.Errorf("redacted text %q still contains %q", redacted, entity.Value)
+				}
+			}
+			// Text outside the entities is untouched
+			for i := range tt.text {
+				if !overlapsAny(entities, i, i+1) && redacted[i] != tt.text[i] {
+					t.Fatalf("byte %d outside the entities changed: %q", i, redacted)
+				}
+			}
+		})
+	}
+}
+
+func TestRedactText(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		entities []models.Entity
+		want     string
+	}{
+		{
+			name:     "label padded to the span",
+			text:     "SSN 123-45-6789 on file",
+			entities: []models.Entity{{Type: "ssn", StartPos: 4, EndPos: 15, Sensitive: true}},
+			want:     "SSN [SSN]       on file",
+		},
+		{
+			name:     "label longer than the span",
+			text:     "DOB 7-9-62.",
+			entities: []models.Entity{{Type: "date_of_birth", StartPos: 4, EndPos: 10, Sensitive: true}},
+			want:     "DOB ******.",
+		},
+		{
+			name:     "entity that is not sensitive",
+			text:     "Agency: GSA",
+			entities: []models.Entity{{Type: "organization", StartPos: 8, EndPos: 11}},
+			want:     "Agency: GSA",
+		},
+		{
+			name:     "entity out of range",
+			text:     "short",
+			entities: []models.Entity{{Type: "ssn", StartPos: 2, EndPos: 40, Sensitive: true}, {Type: "ssn", StartPos: 3, EndPos: 3, Sensitive: true}},
+			want:     "short",
+		},
+		{
+			name:     "multibyte text around the entity",
+			text:     "Zoë: jo@x.io – ok",
+			entities: []models.Entity{{Type: "email", StartPos: 6, EndPos: 13, Sensitive: true}},
+			want:     "Zoë: [EMAIL] – ok",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := redactText(tt.text, tt.entities)
+			if got != tt.want {
+				t.Errorf("redactText = %q, want %q", got, tt.want)
+			}
+			if len(got) != len(tt.text) {
+				t.Errorf("redacted text is %d bytes, want %d", len(got), len(tt.text))
+			}
+		})
+	}
+}
+
+func TestSummaryOfRedactedTextHasNoPII(t *testing.T) {
+	text := "Case file for the relocation grant. " +
+		"The applicant, reachable at (202) 555-0143 or jane.doe@agency.gov, lives at 1600 Pennsylvania Avenue in Ohio. " +
+		"The applicant must repay the advance by March 1, 2027 into account 12345678. " +
+		"Her SSN 123-45-6789 and DOB: 04/12/1980 were verified."
+	entities := detectPII(text)
+	if len(entities) != 6 {
+		t.Fatalf("detected %d entities, want 6: %+v", len(entities), entities)
+	}
+
+	generated, err := summary.NewSummarizer(summary.NewMockProvider(), nil).Summarize(context.Background(), "Relocation Grant", redactText(text, entities), nil)
+	if err != nil {
+		t.Fatalf("Summarize: %v", err)
+	}
+	if len(generated.Obligations) != 1 || generated.Obligations[0].Party != "The applicant" {
+		t.Fatalf("Obligations = %+v, want the applicant's repayment", generated.Obligations)
+	}
+
+	encoded, err := json.Marshal(generated)
+	if err != nil {
+		t.Fatalf("encoding summary: %v", err)
+	}
+	for _, entity := range entities {
+		if strings.Contains(string(encoded), entity.Value) {
+			t.Errorf("summary contains %s %q: %s", entity.Type, entity.Value, encoded)
+		}
+	}
+}
@@ -129,21 +129,87 @@ func (r *Repository) Delete(ctx context.Context, id primitive.ObjectID) error {
 	return nil
 }
 
+// AccessScope restricts a search to the documents a user may read. A document matches when its
+// classification level is within the user's clearance and the user holds all of its compartments,
+// uploaded it, or has an active sharing grant for it.
+type AccessScope struct {
+	UserID          primitive.ObjectID
+	Classifications []string
+	Compartments    []string
+}
+
+// NewAccessScope builds the access scope of a user
+func NewAccessScope(user *models.User) *AccessScope {
+	compartments := user.Compartments
+	if compartments == nil {
+		compartments = []string{}
+	}
+	return &AccessScope{
+		UserID:          user.ID,
+		Classifications: user.AccessibleClassifications(),
+		Compartments:    compartments,
+	}
+}
+
+// Match returns a query for the documents in scope. prefix is put before each field name, to
+// match documents joined into another collection's records, e.g. "document." after a $lookup.
+func (a *AccessScope) Match(prefix string) bson.M {
+	return bson.M{"$and": a.predicate(prefix, time.Now())}
+}
+
+// predicate returns the query conditions of the scope, evaluated at now
+func (a *AccessScope) predicate(prefix string, now time.Time) []bson.M {
+	return []bson.M{
+		{prefix + "classification.level": bson.M{"$in": a.Classifications}},
+		{"$or": []bson.M{
+			// No compartment outside the user's own; also matches documents without compartments
+			{prefix + "classification.compartments": bson.M{"$not": bson.M{"$elemMatch": bson.M{"$nin": a.Compartments}}}},
+			{prefix + "uploaded_by": a.UserID},
+			{prefix + "shared_with": bson.M{"$elemMatch": bson.M{
+				"user_id": a.UserID,
+				"$or": []bson.M{
+					{"expires_at": nil},
+					{"expires_at": bson.M{"$gt": now}},
+				},
+			}}},
+		}},
+	}
+}
+
 // SearchFilter represents search criteria for documents
 type SearchFilter struct {
 	Query          string                  `json:"query"`
 	Category       models.DocumentCategory `json:"category"`
 	Tags           []string                `json:"tags"`
+	Department     string                  `json:"department"`
+	Author         string                  `json:"author"`
 	UploadedBy     *primitive.ObjectID     `json:"uploaded_by"`
 	Classification string                  `json:"classification"`
 	Status         models.ProcessingStatus `json:"status"`
+	WorkspaceIDs   []primitive.ObjectID    `json:"workspace_ids"`
+	CustomFields   map[string]interface{}  `json:"custom_fields"` // custom field values, typed as stored; see metadata.Service.FilterValues
 	DateFrom       *time.Time              `json:"date_from"`
 	DateTo         *time.Time              `json:"date_to"`
+	LatestOnly     bool                    `json:"latest_only"` // skip superseded versions
+	Access         *AccessScope            `json:"-"`           // nil searches every document
+	SortBy         string                  `json:"sort_by"`
+	SortOrder      string                  `json:"sort_order"`
 	Limit          int                     `json:"limit"`
 	Skip           int                     `json:"skip"`
 }
 
-// Search searches for documents based on criteria
+// sortFields maps the sort keys accepted by the API to document fields
+var sortFields = map[string]string{
+	"uploadedAt":     "uploaded_at",
+	"uploaded_at":    "uploaded_at",
+	"name":           "name",
+	"size":           "size",
+	"category":       "metadata.category",
+	"classification": "classification.level",
+}
+
+// Search searches for documents based on criteria. The access scope is part of the query, so
+// the total and every page only ever count documents the user may read.
 func (r *Repository) Search(ctx context.Context, filter SearchFilter) ([]*models.Document, int64, error) {
 	// Build the query
 	query := bson.M{}
@@ -163,6 +229,16 @@ func (r *Repository) Search(ctx context.Context, filter SearchFilter) ([]*models
 		query["metadata.tags"] = bson.M{"$in": filter.Tags}
 	}
 
+	// Department filter
+	if filter.Department != "" {
+		query["metadata.department"] = filter.Department
+	}
+
+	// Author filter
+	if filter.Author != "" {
+		query["metadata.author"] = bson.M{"$regex": filter.Author, "$options": "i"}
+	}
+
 	// Uploaded by filter
 	if filter.UploadedBy != nil {
 		query["uploaded_by"] = *filter.UploadedBy
@@ -178,6 +254,16 @@ func (r *Repository) Search(ctx context.Context, filter SearchFilter) ([]*models
 		query["processing_status"] = filter.Status
 	}
 
+	// Workspace filter
+	if len(filter.WorkspaceIDs) > 0 {
+		query["workspace_ids"] = bson.M{"$in": filter.WorkspaceIDs}
+	}
+
+	// Custom field filters, served by the indexes of the metadata schemas
+	for name, value := range filter.CustomFields {
+		query["metadata.custom_fields."+name] = value
+	}
+
 	// Date range filter
 	if filter.DateFrom != nil || filter.DateTo != nil {
 		dateQuery := bson.M{}
@@ -190,6 +276,16 @@ func (r *Repository) Search(ctx context.Context, filter SearchFilter) ([]*models
 		query["uploaded_at"] = dateQuery
 	}
 
+	// Superseded versions are only reachable through the version history
+	if filter.LatestOnly {
+		query["superseded"] = bson.M{"$ne": true}
+	}
+
+	// Access control
+	if filter.Access != nil {
+		query["$and"] = filter.Access.predicate("", time.Now())
+	}
+
 	// Count total documents matching the query
 	total, err := r.collection.CountDocuments(ctx, query)
 	if err != nil {
@@ -206,8 +302,14 @@ func (r *Repository) Search(ctx context.Context, filter SearchFilter) ([]*models
 	findOptions.SetLimit(int64(filter.Limit))
 	findOptions.SetSkip(int64(filter.Skip))
 
-	// If text search is used, sort by text score, otherwise sort by upload date
-	if filter.Query != "" {
+	// An explicit sort wins; otherwise text searches sort by text score and the rest by upload date
+	if field, ok := sortFields[filter.SortBy]; ok {
+		direction := 1
+		if filter.SortOrder == "desc" {
+			direction = -1
+		}
+		findOptions.SetSort(bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}})
+	} else if filter.Query != "" {
 		findOptions.SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}})
 	} else {
 		findOptions.SetSort(bson.D{{Key: "uploaded_at", Value: -1}}) // Sort by upload date, newest first
@@ -221,7 +323,7 @@ func (r *Repository) Search(ctx context.Context, filter SearchFilter) ([]*models
 	defer cursor.Close(ctx)
 
 	// Decode results
-	var documents []*models.Document
+	documents := []*models.Document{}
 	for cursor.Next(ctx) {
 		var doc models.Document
 		if err := cursor.Decode(&doc); err != nil {
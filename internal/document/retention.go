@@ -0,0 +1,103 @@
+package document
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ai-government-consultant/internal/models"
+	"ai-government-consultant/internal/retention"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// assignRetention sets the retention of a new document from its category and workspaces
+func (s *Service) assignRetention(ctx context.Context, doc *models.Document) error {
+	if s.retention == nil {
+		return nil
+	}
+	assigned, err := s.retention.Assign(ctx, models.RecordTypeDocument, string(doc.Metadata.Category), doc.WorkspaceIDs, doc.UploadedAt)
+	if err != nil {
+		return fmt.Errorf("failed to assign retention: %w", err)
+	}
+	doc.Retention = assigned
+	return nil
+}
+
+// DeleteDocument deletes a document, its search chunks and its original file on behalf of userID.
+// A document under legal hold is kept, the refusal is audited and models.ErrRecordOnLegalHold
+// is returned.
+func (s *Service) DeleteDocument(documentID string, userID primitive.ObjectID) error {
+	objID, err := primitive.ObjectIDFromHex(documentID)
+	if err != nil {
+		return fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	err = s.deleteDocument(ctx, objID, false)
+	if err == models.ErrRecordOnLegalHold && s.retention != nil {
+		var held models.Document
+		if findErr := s.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&held); findErr == nil {
+			s.retention.LogBlockedDeletion(ctx, models.RecordTypeDocument, objID, userID, held.LegalHoldIDs)
+		}
+	}
+	return err
+}
+
+// DeleteRecord deletes a document whose retention has ended. It implements retention.RecordDeleter.
+func (s *Service) DeleteRecord(ctx context.Context, id primitive.ObjectID, keepFiles bool) error {
+	return s.deleteDocument(ctx, id, keepFiles)
+}
+
+// deleteDocument removes a document that is not under legal hold. The hold check and the delete
+// are one operation, so a hold placed concurrently either blocks the delete or lands after it.
+func (s *Service) deleteDocument(ctx context.Context, id primitive.ObjectID, keepFiles bool) error {
+	filter := retention.NotOnHold()
+	filter["_id"] = id
+
+	var doc models.Document
+	err := s.collection.FindOneAndDelete(ctx, filter).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		count, countErr := s.collection.CountDocuments(ctx, bson.M{"_id": id})
+		if countErr != nil {
+			return fmt.Errorf("failed to find document: %w", countErr)
+		}
+		if count > 0 {
+			return models.ErrRecordOnLegalHold
+		}
+		return fmt.Errorf("document not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+
+	if _, err := s.db.Collection("document_chunks").DeleteMany(ctx, bson.M{"document_id": id}); err != nil {
+		return fmt.Errorf("failed to delete document chunks: %w", err)
+	}
+	if _, err := s.db.Collection("annotations").DeleteMany(ctx, bson.M{"document_id": id}); err != nil {
+		return fmt.Errorf("failed to delete document annotations: %w", err)
+	}
+	if _, err := s.db.Collection("document_tables").DeleteMany(ctx, bson.M{"document_id": id}); err != nil {
+		return fmt.Errorf("failed to delete document tables: %w", err)
+	}
+	if !keepFiles && doc.BlobKey != "" {
+		if err := s.blobs.Delete(ctx, doc.BlobKey); err != nil {
+			return fmt.Errorf("failed to delete document file: %w", err)
+		}
+	}
+
+	// Deleting the latest version makes the one before it the latest again
+	if !doc.Superseded && doc.PreviousVersionID != nil {
+		if _, err := s.collection.UpdateOne(ctx,
+			bson.M{"_id": *doc.PreviousVersionID},
+			bson.M{"$unset": bson.M{"superseded": ""}},
+		); err != nil {
+			return fmt.Errorf("failed to restore previous version: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,406 @@
+package document
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// rtfSkippedDestinations are groups that carry no document text
+var rtfSkippedDestinations = map[string]bool{
+	"fonttbl": true, "colortbl": true, "pict": true, "object": true, "fldinst": true,
+	"listtable": true, "listoverridetable": true, "revtbl": true, "rsidtbl": true,
+	"generator": true, "themedata": true, "colorschememapping": true, "datastore": true,
+	"latentstyles": true, "xmlnstbl": true, "filetbl": true, "bkmkstart": true, "bkmkend": true,
+	"footnote": true, "annotation": true, "atnid": true, "atnauthor": true,
+}
+
+// rtfInfoFields are \info sub-destinations captured as document properties
+var rtfInfoFields = map[string]bool{
+	"title": true, "author": true, "subject": true, "company": true, "creatim": true,
+}
+
+// rtfGroupState is the formatting state saved and restored with each RTF group
+type rtfGroupState struct {
+	destination string
+	skip        bool
+	unicodeSkip int
+}
+
+// rtfParser turns an RTF token stream into text blocks
+type rtfParser struct {
+	data     []byte
+	pos      int
+	codepage *charmap.Charmap
+
+	state  rtfGroupState
+	stack  []rtfGroupState
+	skipN  int // characters still to skip after a \u escape
+	blocks []textBlock
+	front  []textBlock
+	back   []textBlock
+	tables tableStack
+
+	current    strings.Builder
+	level      int  // heading level of the current paragraph
+	inTable    bool // current paragraph is inside a table (\intbl)
+	styleNames map[int]string
+	styleID    int
+	styleText  strings.Builder
+	info       map[string]string
+	created    map[string]int
+}
+
+// extractTextFromRTF extracts text, the heading outline and \info properties from an RTF document
+func (s *Service) extractTextFromRTF(data []byte) (*extractionResult, error) {
+	if !strings.HasPrefix(strings.TrimSpace(string(data[:min(len(data), 16)])), "{\\rtf") {
+		// Not RTF (e.g. pre-extracted text used in testing)
+		text, err := s.extractTextFromTXT(string(data))
+		if err != nil {
+			return nil, err
+		}
+		return &extractionResult{Text: text}, nil
+	}
+
+	parser := &rtfParser{
+		data:       data,
+		codepage:   charmap.Windows1252,
+		state:      rtfGroupState{unicodeSkip: 1},
+		styleNames: make(map[int]string),
+		info:       make(map[string]string),
+		created:    make(map[string]int),
+	}
+	parser.parse()
+
+	result := newStructuredResult(parser.front, parser.blocks, parser.back)
+	if strings.TrimSpace(result.Text) == "" {
+		return nil, fmt.Errorf("RTF document contains no text")
+	}
+
+	if title := strings.TrimSpace(parser.info["title"]); title != "" {
+		result.Title = &title
+	}
+	if author := strings.TrimSpace(parser.info["author"]); author != "" {
+		result.Author = &author
+	}
+	if year := parser.created["yr"]; year > 0 {
+		created := time.Date(year, time.Month(max(parser.created["mo"], 1)), max(parser.created["dy"], 1),
+			parser.created["hr"], parser.created["min"], 0, 0, time.UTC)
+		result.CreatedDate = &created
+	}
+
+	return result, nil
+}
+
+func (p *rtfParser) parse() {
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		switch c {
+		case '{':
+			p.pos++
+			p.stack = append(p.stack, p.state)
+		case '}':
+			p.pos++
+			p.endGroup()
+		case '\\':
+			p.controlSymbol()
+		case '\r', '\n':
+			p.pos++
+		default:
+			p.pos++
+			p.char(rune(c))
+		}
+	}
+	p.endParagraph()
+	p.endTable()
+}
+
+func (p *rtfParser) endGroup() {
+	if len(p.stack) == 0 {
+		return
+	}
+	parent := p.stack[len(p.stack)-1]
+
+	if !p.state.skip && p.state.destination != parent.destination {
+		switch p.state.destination {
+		case "stylesheet-entry":
+			name := strings.TrimSuffix(strings.TrimSpace(p.styleText.String()), ";")
+			p.styleNames[p.styleID] = name
+			p.styleText.Reset()
+		case "header", "footer":
+			// A header or footer flushes its last paragraph into its own region
+			p.endParagraph()
+		}
+	}
+
+	p.state = parent
+	p.stack = p.stack[:len(p.stack)-1]
+}
+
+// controlSymbol reads a control word or control symbol starting at a backslash
+func (p *rtfParser) controlSymbol() {
+	p.pos++ // backslash
+	if p.pos >= len(p.data) {
+		return
+	}
+
+	c := p.data[p.pos]
+	if !isASCIILetter(c) {
+		p.pos++
+		switch c {
+		case '\'':
+			if p.pos+2 <= len(p.data) {
+				if b, err := strconv.ParseUint(string(p.data[p.pos:p.pos+2]), 16, 8); err == nil {
+					p.char(p.codepage.DecodeByte(byte(b)))
+				}
+				p.pos += 2
+			}
+		case '*':
+			// Unknown destinations marked with \* are ignored
+			p.state.skip = true
+		case '~':
+			p.char(' ')
+		case '-':
+			// optional hyphen
+		case '_':
+			p.char('-')
+		case '\\', '{', '}':
+			p.char(rune(c))
+		case '\n', '\r':
+			p.paragraph()
+		}
+		return
+	}
+
+	start := p.pos
+	for p.pos < len(p.data) && isASCIILetter(p.data[p.pos]) {
+		p.pos++
+	}
+	word := string(p.data[start:p.pos])
+
+	paramStart := p.pos
+	if p.pos < len(p.data) && p.data[p.pos] == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '9' {
+		p.pos++
+	}
+	param, hasParam := 0, p.pos > paramStart
+	if hasParam {
+		param, _ = strconv.Atoi(string(p.data[paramStart:p.pos]))
+	}
+	if p.pos < len(p.data) && p.data[p.pos] == ' ' {
+		p.pos++ // the delimiting space belongs to the control word
+	}
+
+	p.controlWord(word, param, hasParam)
+}
+
+func (p *rtfParser) controlWord(word string, param int, hasParam bool) {
+	switch {
+	case rtfSkippedDestinations[word]:
+		p.state.skip = true
+		return
+	case word == "stylesheet":
+		p.state.destination = "stylesheet"
+		return
+	case word == "info":
+		p.state.destination = "info"
+		return
+	case rtfInfoFields[word] && p.state.destination == "info":
+		p.state.destination = word
+		return
+	case strings.HasPrefix(word, "header") || strings.HasPrefix(word, "footer"):
+		if word == "header" || word == "headerl" || word == "headerr" || word == "headerf" ||
+			word == "footer" || word == "footerl" || word == "footerr" || word == "footerf" {
+			p.endParagraph()
+			p.state.destination = word[:6]
+			return
+		}
+	}
+
+	if p.state.destination == "stylesheet" && word == "s" {
+		p.state.destination = "stylesheet-entry"
+		p.styleID = param
+		return
+	}
+	if p.state.destination == "creatim" {
+		p.created[word] = param
+		return
+	}
+
+	switch word {
+	case "bin":
+		p.pos += max(param, 0) // raw binary data
+	case "ansicpg":
+		if cp := rtfCodepage(param); cp != nil {
+			p.codepage = cp
+		}
+	case "uc":
+		p.state.unicodeSkip = param
+	case "u":
+		if param < 0 {
+			param += 65536
+		}
+		p.char(rune(param))
+		p.skipN = p.state.unicodeSkip
+	case "par", "sect":
+		p.paragraph()
+	case "line", "tab":
+		p.char(' ')
+	case "emdash":
+		p.char('—')
+	case "endash":
+		p.char('–')
+	case "bullet":
+		p.char('•')
+	case "lquote":
+		p.char('‘')
+	case "rquote":
+		p.char('’')
+	case "ldblquote":
+		p.char('“')
+	case "rdblquote":
+		p.char('”')
+	case "pard":
+		p.level = 0
+		p.inTable = false
+	case "intbl":
+		p.inTable = true
+	case "outlinelevel":
+		if param >= 0 && param < maxHeadingLevel {
+			p.level = param + 1
+		}
+	case "s":
+		if hasParam && p.state.destination == "" {
+			if level := headingStyleLevel(p.styleNames[param]); level > 0 {
+				p.level = level
+			}
+		}
+	case "cell", "nestcell":
+		p.startTable()
+		p.flushParagraph()
+		p.tables.endCell()
+		p.tables.startCell()
+	case "row", "nestrow":
+		p.startTable()
+		p.tables.endRow()
+		p.tables.startRow()
+		p.tables.startCell()
+	}
+}
+
+// char writes a character to the current destination
+func (p *rtfParser) char(r rune) {
+	if p.skipN > 0 {
+		p.skipN--
+		return
+	}
+	if p.state.skip {
+		return
+	}
+	switch p.state.destination {
+	case "stylesheet-entry":
+		p.styleText.WriteRune(r)
+	case "title", "author", "subject", "company":
+		p.info[p.state.destination] += string(r)
+	case "", "header", "footer":
+		p.current.WriteRune(r)
+	}
+}
+
+// paragraph ends the current paragraph (\par)
+func (p *rtfParser) paragraph() {
+	if p.state.skip {
+		return
+	}
+	if !p.inTable {
+		p.endTable()
+	}
+	p.endParagraph()
+}
+
+func (p *rtfParser) startTable() {
+	if !p.tables.active() {
+		p.tables.startTable()
+		p.tables.startRow()
+		p.tables.startCell()
+	}
+}
+
+func (p *rtfParser) endTable() {
+	if !p.tables.active() {
+		return
+	}
+	p.flushParagraph()
+	p.blocks = append(p.blocks, p.tables.endTable()...)
+}
+
+// flushParagraph moves the current paragraph text into the open table cell
+func (p *rtfParser) flushParagraph() {
+	text := strings.TrimSpace(p.current.String())
+	p.current.Reset()
+	if text != "" {
+		p.tables.addText(text)
+	}
+}
+
+func (p *rtfParser) endParagraph() {
+	if p.tables.active() && p.state.destination == "" {
+		p.flushParagraph()
+		return
+	}
+
+	text := strings.TrimSpace(p.current.String())
+	p.current.Reset()
+	if text == "" {
+		return
+	}
+
+	switch p.state.destination {
+	case "header":
+		p.front = append(p.front, textBlock{text: text})
+	case "footer":
+		p.back = append(p.back, textBlock{text: text})
+	default:
+		p.blocks = append(p.blocks, textBlock{text: text, level: p.level})
+	}
+}
+
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// rtfCodepage maps an \ansicpg value to a single-byte character map
+func rtfCodepage(cp int) *charmap.Charmap {
+	switch cp {
+	case 437:
+		return charmap.CodePage437
+	case 850:
+		return charmap.CodePage850
+	case 1250:
+		return charmap.Windows1250
+	case 1251:
+		return charmap.Windows1251
+	case 1252:
+		return charmap.Windows1252
+	case 1253:
+		return charmap.Windows1253
+	case 1254:
+		return charmap.Windows1254
+	case 1255:
+		return charmap.Windows1255
+	case 1256:
+		return charmap.Windows1256
+	case 1257:
+		return charmap.Windows1257
+	case 1258:
+		return charmap.Windows1258
+	case 10000:
+		return charmap.Macintosh
+	}
+	return nil
+}
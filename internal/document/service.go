@@ -1,7 +1,9 @@
 package document
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -10,7 +12,13 @@ import (
 	"time"
 	"unicode/utf8"
 
+	"ai-government-consultant/internal/citation"
+	"ai-government-consultant/internal/metadata"
 	"ai-government-consultant/internal/models"
+	"ai-government-consultant/internal/queue"
+	"ai-government-consultant/internal/retention"
+	"ai-government-consultant/internal/storage"
+	"ai-government-consultant/internal/summary"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -20,17 +28,30 @@ import (
 
 // SupportedFormats defines the supported document formats
 var SupportedFormats = map[string]bool{
-	".pdf":  true,
-	".doc":  true,
-	".docx": true,
-	".txt":  true,
+	".pdf":      true,
+	".doc":      true,
+	".docx":     true,
+	".odt":      true,
+	".txt":      true,
+	".html":     true,
+	".htm":      true,
+	".md":       true,
+	".markdown": true,
+	".rtf":      true,
+	".eml":      true,
+	".csv":      true,
+	".xlsx":     true,
 }
 
+// MaxDocumentSize is the largest file accepted for upload (50MB)
+const MaxDocumentSize = 50 * 1024 * 1024
+
 // ProcessingResult represents the result of document processing
 type ProcessingResult struct {
-	DocumentID primitive.ObjectID `json:"document_id"`
-	Status     string             `json:"status"`
-	Message    string             `json:"message"`
+	DocumentID  primitive.ObjectID  `json:"document_id"`
+	Status      string              `json:"status"`
+	Message     string              `json:"message"`
+	DuplicateOf *primitive.ObjectID `json:"duplicate_of,omitempty"` // canonical document of a rejected duplicate
 }
 
 // ValidationResult represents the result of document validation
@@ -45,14 +66,38 @@ type ValidationResult struct {
 type Service struct {
 	db         *mongo.Database
 	collection *mongo.Collection
+	repository *Repository
+	blobs      storage.BlobStore
+	jobs       *queue.Queue
+	batches    *mongo.Collection
+	retention  *retention.Service
+	schemas    *metadata.Service
+	summarizer *summary.Summarizer
 }
 
-// NewService creates a new document processing service
-func NewService(db *mongo.Database) *Service {
-	return &Service{
+// NewService creates a new document processing service that keeps original files in blobs,
+// processes uploads through the jobs queue, assigns retention from the records schedules and
+// validates custom metadata fields against the metadata schemas. Processed documents are
+// summarized when summarizer is not nil.
+func NewService(db *mongo.Database, blobs storage.BlobStore, jobs *queue.Queue, records *retention.Service, schemas *metadata.Service, summarizer *summary.Summarizer) *Service {
+	s := &Service{
 		db:         db,
 		collection: db.Collection("documents"),
-	}
+		repository: NewRepository(db),
+		blobs:      blobs,
+		jobs:       jobs,
+		batches:    db.Collection("ingest_batches"),
+		retention:  records,
+		schemas:    schemas,
+		summarizer: summarizer,
+	}
+	jobs.Handle(ProcessDocumentJob, s.processDocumentJob)
+	jobs.Handle(IngestBatchJob, s.ingestBatchJob)
+	if summarizer != nil {
+		jobs.Handle(SummarizeDocumentJob, s.summarizeDocumentJob)
+	}
+	records.RegisterDeleter(models.RecordTypeDocument, s)
+	return s
 }
 
 // GetDatabase returns the database instance
@@ -78,10 +123,9 @@ func (s *Service) ValidateDocument(file *multipart.FileHeader) (*ValidationResul
 	}
 
 	// Check file size (max 50MB)
-	const maxSize = 50 * 1024 * 1024 // 50MB
-	if file.Size > maxSize {
+	if file.Size > MaxDocumentSize {
 		result.Valid = false
-		result.Errors = append(result.Errors, fmt.Sprintf("file size exceeds maximum allowed size of %d bytes", maxSize))
+		result.Errors = append(result.Errors, fmt.Sprintf("file size exceeds maximum allowed size of %d bytes", MaxDocumentSize))
 	}
 
 	// Check if file is empty
@@ -99,8 +143,10 @@ func (s *Service) ValidateDocument(file *multipart.FileHeader) (*ValidationResul
 	return result, nil
 }
 
-// UploadDocument handles document upload and initial processing
-func (s *Service) UploadDocument(file *multipart.FileHeader, metadata models.DocumentMetadata, uploadedBy primitive.ObjectID) (*ProcessingResult, error) {
+// UploadDocument handles document upload and initial processing. The duplicate policy decides
+// what happens if the document turns out to duplicate an existing one. The declared
+// classification, if given, is checked against the document's own markings during processing.
+func (s *Service) UploadDocument(file *multipart.FileHeader, metadata models.DocumentMetadata, uploadedBy primitive.ObjectID, duplicatePolicy models.DuplicatePolicy, declared *models.SecurityClassification) (*ProcessingResult, error) {
 	// Validate the document first
 	validation, err := s.ValidateDocument(file)
 	if err != nil {
@@ -114,26 +160,12 @@ func (s *Service) UploadDocument(file *multipart.FileHeader, metadata models.Doc
 		}, nil
 	}
 
-	// Read file content
-	src, err := file.Open()
-	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
-	}
-	defer src.Close()
-
-	content, err := io.ReadAll(src)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file content: %w", err)
-	}
-
-	// Convert content to string with UTF-8 validation and cleaning
-	contentStr := s.sanitizeUTF8Content(content)
-
 	// Create document model
+	docID := primitive.NewObjectID()
 	doc := &models.Document{
-		ID:               primitive.NewObjectID(),
+		ID:               docID,
 		Name:             file.Filename,
-		Content:          contentStr,
+		BlobKey:          blobKey(docID),
 		ContentType:      file.Header.Get("Content-Type"),
 		Size:             file.Size,
 		UploadedBy:       uploadedBy,
@@ -141,6 +173,11 @@ func (s *Service) UploadDocument(file *multipart.FileHeader, metadata models.Doc
 		Classification:   models.SecurityClassification{Level: "INTERNAL"}, // Default classification
 		Metadata:         metadata,
 		ProcessingStatus: models.ProcessingStatusPending,
+		DuplicatePolicy:  duplicatePolicy,
+	}
+	if declared != nil {
+		doc.Classification = *declared
+		doc.DeclaredClassification = declared
 	}
 
 	// Validate the document model
@@ -151,17 +188,50 @@ func (s *Service) UploadDocument(file *multipart.FileHeader, metadata models.Doc
 		}, nil
 	}
 
-	// Insert document into database
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	_, err = s.collection.InsertOne(ctx, doc)
+	// Custom fields must satisfy the metadata schemas of the document's department and category
+	if err := s.validateMetadata(ctx, &doc.Metadata); err != nil {
+		if invalidMetadata(err) {
+			return &ProcessingResult{
+				Status:  "failed",
+				Message: err.Error(),
+			}, nil
+		}
+		return nil, err
+	}
+
+	doc.ContentHash, err = hashFile(file)
 	if err != nil {
-		return nil, fmt.Errorf("failed to insert document: %w", err)
+		return nil, err
+	}
+
+	// An identical file can be rejected straight away; near duplicates are only
+	// known once the text has been extracted during processing
+	if duplicatePolicy == models.DuplicatePolicyReject {
+		canonical, err := s.findExactDuplicate(ctx, doc)
+		if err != nil {
+			return nil, err
+		}
+		if canonical != nil {
+			return &ProcessingResult{
+				DocumentID:  canonical.ID,
+				Status:      "duplicate",
+				Message:     fmt.Sprintf("document is identical to existing document %s", canonical.ID.Hex()),
+				DuplicateOf: &canonical.ID,
+			}, nil
+		}
 	}
 
-	// Start async processing
-	go s.processDocumentAsync(doc.ID)
+	if err := s.storeDocument(file, doc); err != nil {
+		return nil, err
+	}
+
+	// Queue for background processing
+	if err := s.queueProcessing(doc.ID); err != nil {
+		return nil, err
+	}
 
 	return &ProcessingResult{
 		DocumentID: doc.ID,
@@ -170,6 +240,35 @@ func (s *Service) UploadDocument(file *multipart.FileHeader, metadata models.Doc
 	}, nil
 }
 
+// storeDocument streams an uploaded file to blob storage and inserts its document record
+func (s *Service) storeDocument(file *multipart.FileHeader, doc *models.Document) error {
+	src, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer src.Close()
+
+	uploadCtx, uploadCancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer uploadCancel()
+
+	if err := s.blobs.Put(uploadCtx, doc.BlobKey, src, file.Size, doc.ContentType); err != nil {
+		return fmt.Errorf("failed to store file: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := s.assignRetention(ctx, doc); err != nil {
+		s.blobs.Delete(ctx, doc.BlobKey)
+		return err
+	}
+	if _, err := s.collection.InsertOne(ctx, doc); err != nil {
+		s.blobs.Delete(ctx, doc.BlobKey)
+		return fmt.Errorf("failed to insert document: %w", err)
+	}
+	return nil
+}
+
 // ProcessDocument processes a document by ID
 func (s *Service) ProcessDocument(documentID string) (*models.Document, error) {
 	objID, err := primitive.ObjectIDFromHex(documentID)
@@ -230,42 +329,24 @@ func (s *Service) GetProcessingStatus(documentID string) (*models.Document, erro
 	return &doc, nil
 }
 
-// processDocumentAsync processes a document asynchronously
-func (s *Service) processDocumentAsync(documentID primitive.ObjectID) {
-	// Update status to processing
-	s.updateProcessingStatus(documentID, models.ProcessingStatusProcessing, "")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	var doc models.Document
-	err := s.collection.FindOne(ctx, bson.M{"_id": documentID}).Decode(&doc)
-	if err != nil {
-		s.updateProcessingStatus(documentID, models.ProcessingStatusFailed, fmt.Sprintf("failed to find document: %s", err.Error()))
-		return
-	}
-
-	// Process the document
-	err = s.processDocument(&doc)
-	if err != nil {
-		s.updateProcessingStatus(documentID, models.ProcessingStatusFailed, err.Error())
-		return
-	}
-
-	// Update status to completed
-	s.updateProcessingStatus(documentID, models.ProcessingStatusCompleted, "")
-}
-
 // processDocument performs the actual document processing
 func (s *Service) processDocument(doc *models.Document) error {
 	// Extract text based on file type
-	extractedText, err := s.extractText(doc)
+	extracted, err := s.extractText(doc)
 	if err != nil {
 		return fmt.Errorf("text extraction failed: %w", err)
 	}
 
-	// Update document with extracted text
-	doc.Content = extractedText
+	// Update document with extracted text and page layout
+	doc.Content = extracted.Text
+	doc.Pages = extracted.Pages
+	doc.Sections = extracted.Sections
+	s.applyExtractedMetadata(&doc.Metadata, extracted)
+
+	// Flag exact and near duplicates of existing documents
+	if err := s.detectDuplicate(doc); err != nil {
+		return err
+	}
 
 	// Extract metadata
 	extractedMetadata, err := s.extractMetadata(doc)
@@ -277,12 +358,43 @@ func (s *Service) processDocument(doc *models.Document) error {
 	s.mergeMetadata(&doc.Metadata, extractedMetadata)
 
 	// Extract entities (basic implementation)
-	entities, err := s.extractEntities(extractedText)
+	entities, err := s.extractEntities(extracted.Text)
 	if err != nil {
 		return fmt.Errorf("entity extraction failed: %w", err)
 	}
 	doc.ExtractedEntities = entities
 
+	// Index what the document cites
+	var cited []string
+	for _, entity := range entities {
+		if entity.Type == "citation" {
+			cited = append(cited, entity.Value)
+		}
+	}
+	doc.Citations = newOrderedSet().add(cited...).values
+	doc.CitationKeys = citation.Keys(doc.Citations)
+
+	// Keep a redacted variant for embeddings and prompts
+	doc.RedactedContent, doc.PIICount = "", 0
+	for _, entity := range entities {
+		if entity.Sensitive {
+			doc.PIICount++
+		}
+	}
+	if doc.PIICount > 0 {
+		doc.RedactedContent = redactText(doc.Content, entities)
+	}
+
+	// Classify from banner lines and portion markings before attachments inherit the classification
+	applyClassification(doc, detectClassification(doc.Content))
+
+	// Attachments become child documents linked to this one
+	if len(extracted.Attachments) > 0 {
+		if skipped := s.ingestAttachments(doc, extracted.Attachments); len(skipped) > 0 {
+			doc.Metadata.CustomFields["skipped_attachments"] = skipped
+		}
+	}
+
 	// Set processing timestamp
 	now := time.Now()
 	doc.ProcessingTimestamp = &now
@@ -291,14 +403,45 @@ func (s *Service) processDocument(doc *models.Document) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	// Tables are stored on their own, as rows and columns
+	if err := s.storeTables(ctx, doc, extracted.Tables); err != nil {
+		return err
+	}
+
 	update := bson.M{
 		"$set": bson.M{
-			"content":              doc.Content,
-			"metadata":             doc.Metadata,
-			"extracted_entities":   doc.ExtractedEntities,
-			"processing_timestamp": doc.ProcessingTimestamp,
+			"content":                 doc.Content,
+			"pages":                   doc.Pages,
+			"sections":                doc.Sections,
+			"metadata":                doc.Metadata,
+			"extracted_entities":      doc.ExtractedEntities,
+			"redacted_content":        doc.RedactedContent,
+			"pii_count":               doc.PIICount,
+			"table_count":             doc.TableCount,
+			"classification":          doc.Classification,
+			"classification_markings": doc.ClassificationMarkings,
+			"citations":               doc.Citations,
+			"citation_keys":           doc.CitationKeys,
+			"processing_timestamp":    doc.ProcessingTimestamp,
+			"text_hash":               doc.TextHash,
+			"minhash":                 doc.MinHash,
+			"minhash_bands":           doc.MinHashBands,
 		},
 	}
+	unset := bson.M{}
+	if doc.DuplicateOf != nil {
+		update["$set"].(bson.M)["duplicate_of"] = doc.DuplicateOf
+	} else {
+		unset["duplicate_of"] = ""
+	}
+	if doc.ClassificationReview != nil {
+		update["$set"].(bson.M)["classification_review"] = doc.ClassificationReview
+	} else {
+		unset["classification_review"] = ""
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
 
 	_, err = s.collection.UpdateOne(ctx, bson.M{"_id": doc.ID}, update)
 	if err != nil {
@@ -308,6 +451,135 @@ func (s *Service) processDocument(doc *models.Document) error {
 	return nil
 }
 
+// blobKey returns the blob storage key for a document's original file
+func blobKey(documentID primitive.ObjectID) string {
+	return "documents/" + documentID.Hex()
+}
+
+// originalContent reads the original uploaded bytes of a document
+func (s *Service) originalContent(doc *models.Document) ([]byte, error) {
+	blob, err := s.openOriginal(doc)
+	if err != nil {
+		return nil, err
+	}
+	defer blob.Close()
+
+	data, err := io.ReadAll(blob)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read original file: %w", err)
+	}
+	return data, nil
+}
+
+// openOriginal opens the original file of a document. Documents uploaded before blob
+// storage was introduced kept their bytes inline in RawContent or Content.
+func (s *Service) openOriginal(doc *models.Document) (storage.Blob, error) {
+	if doc.BlobKey != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		blob, err := s.blobs.Open(ctx, doc.BlobKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open original file: %w", err)
+		}
+		return blob, nil
+	}
+	if len(doc.RawContent) > 0 {
+		return storage.NewBytesBlob(doc.RawContent), nil
+	}
+	if doc.Content != "" {
+		return storage.NewBytesBlob([]byte(doc.Content)), nil
+	}
+	return nil, fmt.Errorf("document content not available")
+}
+
+// ingestAttachments stores each supported attachment as a child document of parent and
+// queues it for processing. It returns the names of attachments that were skipped.
+func (s *Service) ingestAttachments(parent *models.Document, attachments []extractedAttachment) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var skipped []string
+	for _, attachment := range attachments {
+		ext := strings.ToLower(filepath.Ext(attachment.Name))
+		if !SupportedFormats[ext] || len(attachment.Data) == 0 || len(attachment.Data) > MaxDocumentSize {
+			skipped = append(skipped, attachment.Name)
+			continue
+		}
+
+		// Reprocessing the parent must not duplicate its children
+		existing, err := s.collection.CountDocuments(ctx, bson.M{"parent_id": parent.ID, "name": attachment.Name})
+		if err != nil || existing > 0 {
+			continue
+		}
+
+		childID, parentID := primitive.NewObjectID(), parent.ID
+		child := &models.Document{
+			ID:             childID,
+			Name:           attachment.Name,
+			BlobKey:        blobKey(childID),
+			ContentType:    attachment.ContentType,
+			Size:           int64(len(attachment.Data)),
+			UploadedBy:     parent.UploadedBy,
+			UploadedAt:     time.Now(),
+			Classification: parent.Classification,
+			Metadata: models.DocumentMetadata{
+				Department:   parent.Metadata.Department,
+				Category:     parent.Metadata.Category,
+				Tags:         []string{},
+				CustomFields: map[string]interface{}{"attachment_of": parent.Name},
+			},
+			ProcessingStatus: models.ProcessingStatusPending,
+			ParentID:         &parentID,
+			ContentHash:      fmt.Sprintf("%x", sha256.Sum256(attachment.Data)),
+			DuplicatePolicy:  parent.DuplicatePolicy,
+		}
+
+		if err := s.blobs.Put(ctx, child.BlobKey, bytes.NewReader(attachment.Data), child.Size, child.ContentType); err != nil {
+			skipped = append(skipped, attachment.Name)
+			continue
+		}
+		if err := s.assignRetention(ctx, child); err != nil {
+			s.blobs.Delete(ctx, child.BlobKey)
+			skipped = append(skipped, attachment.Name)
+			continue
+		}
+		if _, err := s.collection.InsertOne(ctx, child); err != nil {
+			s.blobs.Delete(ctx, child.BlobKey)
+			skipped = append(skipped, attachment.Name)
+			continue
+		}
+
+		s.queueProcessing(child.ID)
+	}
+
+	return skipped
+}
+
+// ListAttachments returns the child documents extracted from a parent document
+func (s *Service) ListAttachments(documentID string) ([]*models.Document, error) {
+	objID, err := primitive.ObjectIDFromHex(documentID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := s.collection.Find(ctx, bson.M{"parent_id": objID}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find attachments: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	documents := []*models.Document{}
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, fmt.Errorf("failed to decode attachments: %w", err)
+	}
+
+	return documents, nil
+}
+
 // updateProcessingStatus updates the processing status of a document
 func (s *Service) updateProcessingStatus(documentID primitive.ObjectID, status models.ProcessingStatus, errorMsg string) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -357,168 +629,66 @@ func (s *Service) sanitizeUTF8Content(content []byte) string {
 	return cleaned.String()
 }
 
-// ListDocuments returns a paginated list of documents
-func (s *Service) ListDocuments(limit, skip int, sortBy, sortOrder string) ([]*models.Document, int64, error) {
+// ListDocuments returns a paginated list of the latest versions of the documents the user may read
+func (s *Service) ListDocuments(user *models.User, limit, skip int, sortBy, sortOrder string) ([]*models.Document, int64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Debug: log the collection name
-	collectionName := s.collection.Name()
-	fmt.Printf("DEBUG: Querying collection: %s\n", collectionName)
-
-	// Get total count first
-	total, err := s.collection.CountDocuments(ctx, bson.M{})
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count documents: %w", err)
-	}
-	fmt.Printf("DEBUG: Total document count: %d\n", total)
-
-	// If no documents exist, return early
-	if total == 0 {
-		return []*models.Document{}, 0, nil
-	}
-
-	// Build find options with proper sorting
-	skip64 := int64(skip)
-	limit64 := int64(limit)
-	findOptions := options.Find().SetSkip(skip64).SetLimit(limit64)
-	
-	// Add sorting based on sortBy parameter
-	if sortBy != "" {
-		sortDirection := 1
-		if sortOrder == "desc" {
-			sortDirection = -1
-		}
-		
-		// Handle different sort field mappings
-		var sortField string
-		switch sortBy {
-		case "uploadedAt", "uploaded_at":
-			sortField = "uploaded_at"
-		case "name":
-			sortField = "name"
-		case "size":
-			sortField = "size"
-		case "category":
-			sortField = "metadata.category"
-		case "classification":
-			sortField = "classification.level"
-		default:
-			sortField = "uploaded_at" // Default fallback
-		}
-		
-		findOptions.SetSort(bson.D{{Key: sortField, Value: sortDirection}})
-	} else {
-		// Default sort by upload date descending
-		findOptions.SetSort(bson.D{{Key: "uploaded_at", Value: -1}})
-	}
-
-	// Find documents
-	cursor, err := s.collection.Find(ctx, bson.M{}, findOptions)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to find documents: %w", err)
-	}
-	defer cursor.Close(ctx)
-
-	var documents []*models.Document
-	for cursor.Next(ctx) {
-		var doc models.Document
-		if err := cursor.Decode(&doc); err != nil {
-			return nil, 0, fmt.Errorf("failed to decode document: %w", err)
-		}
-		documents = append(documents, &doc)
-	}
-
-	if err := cursor.Err(); err != nil {
-		return nil, 0, fmt.Errorf("cursor error: %w", err)
-	}
-
-	fmt.Printf("DEBUG: Found %d documents\n", len(documents))
-	return documents, total, nil
+	return s.repository.Search(ctx, SearchFilter{
+		LatestOnly: true,
+		Access:     NewAccessScope(user),
+		SortBy:     sortBy,
+		SortOrder:  sortOrder,
+		Limit:      limit,
+		Skip:       skip,
+	})
 }
 
-// SearchDocuments searches for documents based on various criteria
-func (s *Service) SearchDocuments(query string, category models.DocumentCategory, tags []string, department, author string, limit, skip int, sortBy, sortOrder string) ([]*models.Document, int64, error) {
+// SearchDocuments searches the documents the user may read based on various criteria. When
+// workspaceIDs is given only documents in at least one of those workspaces match. customFields
+// filters on indexed custom fields by their values as text; an unknown field or a value of the
+// wrong type returns metadata.ErrInvalidFilter.
+func (s *Service) SearchDocuments(user *models.User, query string, category models.DocumentCategory, tags []string, department, author string, workspaceIDs []primitive.ObjectID, customFields map[string]string, limit, skip int, sortBy, sortOrder string) ([]*models.Document, int64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Build search filter
-	filter := bson.M{}
-	
-	// Text search if query is provided
-	if query != "" {
-		filter["$text"] = bson.M{"$search": query}
-	}
-	
-	// Category filter
-	if category != "" {
-		filter["metadata.category"] = category
-	}
-	
-	// Tags filter
-	if len(tags) > 0 {
-		filter["metadata.tags"] = bson.M{"$in": tags}
-	}
-	
-	// Department filter
-	if department != "" {
-		filter["metadata.department"] = department
-	}
-	
-	// Author filter
-	if author != "" {
-		filter["metadata.author"] = bson.M{"$regex": author, "$options": "i"}
-	}
-
-	// Get total count
-	total, err := s.collection.CountDocuments(ctx, filter)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count documents: %w", err)
-	}
-
-	// Prepare find options
-	findOptions := options.Find().SetSkip(int64(skip)).SetLimit(int64(limit))
-	
-	// Add sorting if specified
-	if sortBy != "" {
-		sortDirection := 1
-		if sortOrder == "desc" {
-			sortDirection = -1
+	var fieldValues map[string]interface{}
+	if len(customFields) > 0 {
+		if s.schemas == nil {
+			return nil, 0, fmt.Errorf("%w: no metadata schemas are configured", metadata.ErrInvalidFilter)
 		}
-		findOptions.SetSort(bson.M{sortBy: sortDirection})
-	}
-
-	// Find documents
-	cursor, err := s.collection.Find(ctx, filter, findOptions)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to search documents: %w", err)
-	}
-	defer cursor.Close(ctx)
-
-	var documents []*models.Document
-	for cursor.Next(ctx) {
-		var doc models.Document
-		if err := cursor.Decode(&doc); err != nil {
-			return nil, 0, fmt.Errorf("failed to decode document: %w", err)
+		var err error
+		if fieldValues, err = s.schemas.FilterValues(ctx, customFields); err != nil {
+			return nil, 0, err
 		}
-		documents = append(documents, &doc)
-	}
-
-	if err := cursor.Err(); err != nil {
-		return nil, 0, fmt.Errorf("cursor error: %w", err)
 	}
 
-	return documents, total, nil
+	// Superseded versions are only reachable through the version history
+	return s.repository.Search(ctx, SearchFilter{
+		Query:        query,
+		Category:     category,
+		Tags:         tags,
+		Department:   department,
+		Author:       author,
+		WorkspaceIDs: workspaceIDs,
+		CustomFields: fieldValues,
+		LatestOnly:   true,
+		Access:       NewAccessScope(user),
+		SortBy:       sortBy,
+		SortOrder:    sortOrder,
+		Limit:        limit,
+		Skip:         skip,
+	})
 }
 
-// GetDocumentFile retrieves the raw file data for a document
-func (s *Service) GetDocumentFile(documentID string) ([]byte, error) {
+// GetDocumentFile opens the original uploaded file of a document for streaming.
+// The caller must close the returned blob.
+func (s *Service) GetDocumentFile(documentID string) (storage.Blob, error) {
 	objID, err := primitive.ObjectIDFromHex(documentID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid document ID: %w", err)
 	}
 
-	// First get the document to check if it exists and get file path
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -531,12 +701,5 @@ func (s *Service) GetDocumentFile(documentID string) ([]byte, error) {
 		return nil, fmt.Errorf("failed to find document: %w", err)
 	}
 
-	// For now, return the content as bytes
-	// TODO: Implement proper file storage system
-	if doc.Content == "" {
-		return nil, fmt.Errorf("document content not available")
-	}
-
-	// Convert content string to bytes
-	return []byte(doc.Content), nil
+	return s.openOriginal(&doc)
 }
@@ -0,0 +1,91 @@
+package document
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"ai-government-consultant/internal/models"
+	"ai-government-consultant/internal/storage"
+)
+
+// fakeS3 serves one object for HEAD and ranged GET requests, like an S3-compatible endpoint
+func fakeS3(t *testing.T, key string, data []byte) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bucket/"+key {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodHead:
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		case http.MethodGet:
+			start := 0
+			if spec := strings.TrimPrefix(r.Header.Get("Range"), "bytes="); spec != "" {
+				start, _ = strconv.Atoi(strings.TrimSuffix(spec, "-"))
+			}
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(data[start:])
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOpenOriginalReadsS3BlobAfterReturning(t *testing.T) {
+	content := []byte(strings.Repeat("original upload bytes ", 100))
+	server := fakeS3(t, "documents/original.txt", content)
+
+	store, err := storage.NewS3Bucket(&storage.S3Config{
+		Endpoint:  strings.TrimPrefix(server.URL, "http://"),
+		Bucket:    "bucket",
+		AccessKey: "access",
+		SecretKey: "secret",
+	})
+	if err != nil {
+		t.Fatalf("NewS3Bucket: %v", err)
+	}
+	s := &Service{blobs: store}
+	doc := &models.Document{BlobKey: "documents/original.txt"}
+
+	blob, err := s.openOriginal(doc)
+	if err != nil {
+		t.Fatalf("openOriginal: %v", err)
+	}
+	defer blob.Close()
+
+	// The reads happen after openOriginal returned and its lookup timeout was released
+	got, err := io.ReadAll(blob)
+	if err != nil {
+		t.Fatalf("reading the opened blob: %v", err)
+	}
+	if string(got) != string(content) {
+		t.Fatalf("read %d bytes, want %d", len(got), len(content))
+	}
+
+	// Seeking reissues the ranged GET
+	if _, err := blob.Seek(9, io.SeekStart); err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+	tail, err := io.ReadAll(blob)
+	if err != nil {
+		t.Fatalf("reading after seek: %v", err)
+	}
+	if string(tail) != string(content[9:]) {
+		t.Fatalf("read %q after seek, want %q", tail[:20], content[9:29])
+	}
+
+	data, err := s.originalContent(doc)
+	if err != nil {
+		t.Fatalf("originalContent: %v", err)
+	}
+	if string(data) != string(content) {
+		t.Fatalf("originalContent returned %d bytes, want %d", len(data), len(content))
+	}
+}
@@ -0,0 +1,88 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ai-government-consultant/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrGranteeNotCleared is returned when a document is shared with a user whose clearance is
+// below its classification level; a grant only covers compartments, never the level.
+var ErrGranteeNotCleared = errors.New("user is not cleared for the document's classification level")
+
+// ShareDocument grants a user need-to-know for a document outside its compartments, until
+// expiresAt or indefinitely when it is nil. Sharing again with the same user replaces the grant.
+func (s *Service) ShareDocument(documentID string, userID, grantedBy primitive.ObjectID, expiresAt *time.Time) (*models.Document, error) {
+	doc, err := s.GetProcessingStatus(documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var grantee models.User
+	err = s.db.Collection("users").FindOne(ctx, bson.M{"_id": userID, "is_active": true}).Decode(&grantee)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, models.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	if !grantee.CanAccessClassification(doc.Classification.Level) {
+		return nil, ErrGranteeNotCleared
+	}
+
+	grant := models.DocumentGrant{
+		UserID:    userID,
+		GrantedBy: grantedBy,
+		GrantedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+	grants := []models.DocumentGrant{}
+	for _, existing := range doc.SharedWith {
+		if existing.UserID != userID {
+			grants = append(grants, existing)
+		}
+	}
+	grants = append(grants, grant)
+
+	if _, err := s.collection.UpdateOne(ctx, bson.M{"_id": doc.ID}, bson.M{"$set": bson.M{"shared_with": grants}}); err != nil {
+		return nil, fmt.Errorf("failed to share document: %w", err)
+	}
+
+	doc.SharedWith = grants
+	return doc, nil
+}
+
+// UnshareDocument revokes a user's sharing grant on a document
+func (s *Service) UnshareDocument(documentID string, userID primitive.ObjectID) (*models.Document, error) {
+	doc, err := s.GetProcessingStatus(documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	update := bson.M{"$pull": bson.M{"shared_with": bson.M{"user_id": userID}}}
+	if _, err := s.collection.UpdateOne(ctx, bson.M{"_id": doc.ID}, update); err != nil {
+		return nil, fmt.Errorf("failed to unshare document: %w", err)
+	}
+
+	grants := []models.DocumentGrant{}
+	for _, existing := range doc.SharedWith {
+		if existing.UserID != userID {
+			grants = append(grants, existing)
+		}
+	}
+	doc.SharedWith = grants
+	return doc, nil
+}
@@ -0,0 +1,344 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// maxSpreadsheetRows bounds how many rows are extracted per sheet
+const maxSpreadsheetRows = 100000
+
+// extractTextFromCSV extracts rows from a delimited text file, one line per row, as a single table
+func (s *Service) extractTextFromCSV(data []byte) (*extractionResult, error) {
+	content := s.sanitizeUTF8Content(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.Comma = detectDelimiter(content)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var blocks []textBlock
+	var columns []string
+	table := &extractedTable{}
+	rows := 0
+	for rows < maxSpreadsheetRows {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CSV: %w", err)
+		}
+		if columns == nil {
+			columns = trimCells(record)
+		}
+		cells := trimCells(record)
+		if line := strings.Join(cells, " | "); strings.Trim(line, " |") != "" {
+			table.rows = append(table.rows, cells)
+			blocks = append(blocks, textBlock{text: line, table: table})
+			rows++
+		}
+	}
+
+	result := newStructuredResult(nil, blocks, nil)
+	if strings.TrimSpace(result.Text) == "" {
+		return nil, fmt.Errorf("CSV file contains no data")
+	}
+	result.CustomFields = map[string]interface{}{
+		"columns":   columns,
+		"row_count": max(rows-1, 0), // excluding the header row
+	}
+	return result, nil
+}
+
+// detectDelimiter picks the most frequent candidate delimiter on the first line
+func detectDelimiter(content string) rune {
+	firstLine := content
+	if i := strings.IndexByte(content, '\n'); i >= 0 {
+		firstLine = content[:i]
+	}
+
+	best, bestCount := ',', 0
+	for _, candidate := range []rune{',', ';', '\t', '|'} {
+		if count := strings.Count(firstLine, string(candidate)); count > bestCount {
+			best, bestCount = candidate, count
+		}
+	}
+	return best
+}
+
+func trimCells(record []string) []string {
+	cells := make([]string, len(record))
+	for i, cell := range record {
+		cells[i] = strings.Join(strings.Fields(cell), " ")
+	}
+	return cells
+}
+
+// xlsxSheet is a worksheet listed in the workbook
+type xlsxSheet struct {
+	name   string
+	target string
+}
+
+// extractTextFromXLSX extracts every worksheet as a section of rows and a table named after the
+// sheet, recording sheet names in metadata
+func (s *Service) extractTextFromXLSX(data []byte) (*extractionResult, error) {
+	pkg, err := openPackage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	workbook, err := readPackagePart(pkg, "xl/workbook.xml")
+	if err != nil {
+		return nil, err
+	}
+	if workbook == nil {
+		return nil, fmt.Errorf("spreadsheet package has no workbook")
+	}
+
+	sheets := xlsxSheets(workbook, readRelationships(pkg, "xl/_rels/workbook.xml.rels"))
+
+	var sharedStrings []string
+	if part, err := readPackagePart(pkg, "xl/sharedStrings.xml"); err == nil && part != nil {
+		sharedStrings = xlsxSharedStrings(part)
+	}
+
+	var blocks []textBlock
+	sheetNames := make([]string, 0, len(sheets))
+	for _, sheet := range sheets {
+		part, err := readPackagePart(pkg, sheet.target)
+		if err != nil || part == nil {
+			continue
+		}
+		rows, err := xlsxRows(part, sharedStrings)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse sheet %q: %w", sheet.name, err)
+		}
+
+		sheetNames = append(sheetNames, sheet.name)
+		blocks = append(blocks, textBlock{text: sheet.name, level: 1})
+		table := &extractedTable{caption: sheet.name}
+		for _, row := range rows {
+			if line := strings.Join(row, " | "); strings.Trim(line, " |") != "" {
+				table.rows = append(table.rows, row)
+				blocks = append(blocks, textBlock{text: line, table: table})
+			}
+		}
+	}
+
+	result := newStructuredResult(nil, blocks, nil)
+	if len(sheetNames) == 0 {
+		return nil, fmt.Errorf("spreadsheet contains no worksheets")
+	}
+	result.CustomFields = map[string]interface{}{"sheet_names": sheetNames}
+
+	if core, err := readPackagePart(pkg, "docProps/core.xml"); err == nil && core != nil {
+		fields := readXMLFields(core, "title", "creator", "created")
+		if title := fields["title"]; title != "" {
+			result.Title = &title
+		}
+		if author := fields["creator"]; author != "" {
+			result.Author = &author
+		}
+		result.CreatedDate = parsePackageDate(fields["created"])
+	}
+
+	return result, nil
+}
+
+// readRelationships maps relationship IDs to part names resolved against the relationship source
+func readRelationships(pkg *zip.Reader, relsPath string) map[string]string {
+	targets := make(map[string]string)
+	data, err := readPackagePart(pkg, relsPath)
+	if err != nil || data == nil {
+		return targets
+	}
+
+	base := path.Dir(path.Dir(relsPath)) // xl/_rels/workbook.xml.rels -> xl
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return targets
+		}
+		if el, ok := tok.(xml.StartElement); ok && el.Name.Local == "Relationship" {
+			target := xmlAttr(el, "Target")
+			if strings.HasPrefix(target, "/") {
+				target = strings.TrimPrefix(target, "/")
+			} else {
+				target = path.Join(base, target)
+			}
+			targets[xmlAttr(el, "Id")] = target
+		}
+	}
+}
+
+func xlsxSheets(workbook []byte, rels map[string]string) []xlsxSheet {
+	var sheets []xlsxSheet
+	dec := xml.NewDecoder(bytes.NewReader(workbook))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return sheets
+		}
+		el, ok := tok.(xml.StartElement)
+		if !ok || el.Name.Local != "sheet" {
+			continue
+		}
+
+		// The relationship ID is the namespaced r:id attribute
+		var relID string
+		for _, attr := range el.Attr {
+			if attr.Name.Local == "id" && attr.Name.Space != "" {
+				relID = attr.Value
+			}
+		}
+		target := rels[relID]
+		if target == "" {
+			target = fmt.Sprintf("xl/worksheets/sheet%d.xml", len(sheets)+1)
+		}
+		sheets = append(sheets, xlsxSheet{name: xmlAttr(el, "name"), target: target})
+	}
+}
+
+// xlsxSharedStrings reads the shared string table, concatenating rich text runs
+func xlsxSharedStrings(data []byte) []string {
+	var stringsTable []string
+	var current strings.Builder
+	inText, skip := false, 0
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return stringsTable
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				current.Reset()
+			case "rPh":
+				skip++ // phonetic guides
+			case "t":
+				inText = skip == 0
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				stringsTable = append(stringsTable, current.String())
+			case "rPh":
+				skip--
+			case "t":
+				inText = false
+			}
+		case xml.CharData:
+			if inText {
+				current.Write(t)
+			}
+		}
+	}
+}
+
+// xlsxRows reads the cell values of a worksheet, placing each value in its referenced column
+func xlsxRows(data []byte, sharedStrings []string) ([][]string, error) {
+	var rows [][]string
+	var row []string
+	var cellType, cellRef string
+	var value strings.Builder
+	inValue := false
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for len(rows) < maxSpreadsheetRows {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				row = nil
+			case "c":
+				cellType, cellRef = xmlAttr(t, "t"), xmlAttr(t, "r")
+				value.Reset()
+			case "v", "t":
+				inValue = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "v", "t":
+				inValue = false
+			case "c":
+				text := xlsxCellValue(cellType, strings.TrimSpace(value.String()), sharedStrings)
+				column := xlsxColumn(cellRef)
+				if column < 0 {
+					column = len(row)
+				}
+				if text != "" && column < 16384 {
+					for len(row) <= column {
+						row = append(row, "")
+					}
+					row[column] = strings.Join(strings.Fields(text), " ")
+				}
+			case "row":
+				if len(row) > 0 {
+					rows = append(rows, row)
+				}
+			}
+		case xml.CharData:
+			if inValue {
+				value.Write(t)
+			}
+		}
+	}
+
+	return rows, nil
+}
+
+func xlsxCellValue(cellType, raw string, sharedStrings []string) string {
+	switch cellType {
+	case "s":
+		index, err := strconv.Atoi(raw)
+		if err != nil || index < 0 || index >= len(sharedStrings) {
+			return ""
+		}
+		return sharedStrings[index]
+	case "b":
+		if raw == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	default:
+		return raw
+	}
+}
+
+// xlsxColumn converts the column letters of a cell reference such as "AB12" to a zero-based index
+func xlsxColumn(ref string) int {
+	column := 0
+	letters := 0
+	for _, c := range ref {
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		column = column*26 + int(c-'A'+1)
+		letters++
+	}
+	if letters == 0 {
+		return -1
+	}
+	return column - 1
+}
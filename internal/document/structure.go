@@ -0,0 +1,335 @@
+package document
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"ai-government-consultant/internal/models"
+)
+
+// maxHeadingLevel is the deepest heading level tracked in the section outline
+const maxHeadingLevel = 9
+
+var headingNumberRe = regexp.MustCompile(`^((?:\d+\.)*\d+)\.?\s+(.+)$`)
+
+// structuredText accumulates extracted paragraphs and records the heading outline
+type structuredText struct {
+	sb       strings.Builder
+	headings []outlineHeading
+	counters [maxHeadingLevel]int
+	bodyEnd  int
+}
+
+// textBlock is a paragraph recovered from a structured source format. Level is
+// the heading level, or zero for body text. The rows of a table are laid out one
+// block per row, each pointing at the table.
+type textBlock struct {
+	text         string
+	level        int
+	autoNumbered bool
+	table        *extractedTable
+}
+
+// extractedTable is a table recovered from a source document. Its rows are also
+// laid out in the extracted text, one line per row, between the byte offsets
+// start and end.
+type extractedTable struct {
+	caption string
+	rows    [][]string
+	page    int
+	start   int
+	end     int
+}
+
+// captionRe matches a table caption such as "Table 3: Obligations by Program"
+var captionRe = regexp.MustCompile(`(?i)^(?:table|exhibit)\s+[\w.-]+\b`)
+
+// newExtractedTable returns a table of rows, or nil when the rows are too few to
+// be more than layout: a table needs two rows and two columns
+func newExtractedTable(caption string, rows [][]string) *extractedTable {
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	if len(rows) < 2 || width < 2 {
+		return nil
+	}
+	return &extractedTable{caption: caption, rows: rows}
+}
+
+// newStructuredResult assembles front matter (page headers), the body and back
+// matter (footers, notes, comments) into an extraction result with a section
+// outline derived from the body headings. Repeated front and back matter
+// blocks are kept once.
+func newStructuredResult(front, body, back []textBlock) *extractionResult {
+	st := &structuredText{}
+
+	seen := make(map[string]bool)
+	for _, block := range front {
+		if !seen[block.text] {
+			seen[block.text] = true
+			st.paragraph(block.text)
+		}
+	}
+
+	var tables []extractedTable
+	var placed *extractedTable
+	previous := ""
+	for _, block := range body {
+		if placed != nil && block.table != placed {
+			tables = append(tables, *placed)
+			placed = nil
+		}
+		if block.level > 0 {
+			st.heading(block.level, block.text, block.autoNumbered)
+			previous = block.text
+			continue
+		}
+
+		start, ok := st.paragraph(block.text)
+		switch {
+		case !ok:
+		case block.table == nil:
+			previous = block.text
+		case placed == nil:
+			// A caption is the line just before the table
+			placed = block.table
+			placed.start = start
+			if placed.caption == "" && captionRe.MatchString(strings.TrimSpace(previous)) {
+				placed.caption = strings.Join(strings.Fields(previous), " ")
+			}
+			fallthrough
+		default:
+			placed.end = st.sb.Len()
+			previous = ""
+		}
+	}
+	if placed != nil {
+		tables = append(tables, *placed)
+	}
+	st.endBody()
+
+	seen = make(map[string]bool)
+	for _, block := range back {
+		if !seen[block.text] {
+			seen[block.text] = true
+			st.paragraph(block.text)
+		}
+	}
+
+	return &extractionResult{
+		Text:     st.String(),
+		Sections: st.sections(),
+		Tables:   tables,
+	}
+}
+
+// outlineHeading is a heading encountered while building structured text
+type outlineHeading struct {
+	level  int
+	number string
+	title  string
+	offset int
+}
+
+// paragraph appends a line of body text and returns where it starts, or false if it was blank
+func (st *structuredText) paragraph(text string) (int, bool) {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return 0, false
+	}
+	if st.sb.Len() > 0 {
+		st.sb.WriteByte('\n')
+	}
+	start := st.sb.Len()
+	st.sb.WriteString(text)
+	return start, true
+}
+
+// heading appends a heading line and records it in the outline. When the heading
+// text carries no explicit number but the source numbers it automatically, the
+// number is derived from the outline position.
+func (st *structuredText) heading(level int, text string, autoNumbered bool) {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return
+	}
+	if level < 1 {
+		level = 1
+	}
+	if level > maxHeadingLevel {
+		level = maxHeadingLevel
+	}
+
+	number, title := "", text
+	if match := headingNumberRe.FindStringSubmatch(text); match != nil {
+		number, title = match[1], match[2]
+		st.syncCounters(number)
+	} else if autoNumbered {
+		st.counters[level-1]++
+		for i := level; i < maxHeadingLevel; i++ {
+			st.counters[i] = 0
+		}
+		number = st.counterNumber(level)
+	}
+
+	if st.sb.Len() > 0 {
+		st.sb.WriteByte('\n')
+	}
+	st.headings = append(st.headings, outlineHeading{
+		level:  level,
+		number: number,
+		title:  title,
+		offset: st.sb.Len(),
+	})
+	st.sb.WriteString(text)
+}
+
+// syncCounters aligns automatic numbering with an explicit heading number
+func (st *structuredText) syncCounters(number string) {
+	parts := strings.Split(number, ".")
+	for i := 0; i < maxHeadingLevel; i++ {
+		st.counters[i] = 0
+		if i < len(parts) {
+			st.counters[i], _ = strconv.Atoi(parts[i])
+		}
+	}
+}
+
+func (st *structuredText) counterNumber(level int) string {
+	var parts []string
+	for i := 0; i < level; i++ {
+		if st.counters[i] == 0 && len(parts) == 0 {
+			continue // skip unused outer levels
+		}
+		parts = append(parts, strconv.Itoa(st.counters[i]))
+	}
+	return strings.Join(parts, ".")
+}
+
+// endBody marks the end of the main body; text appended afterwards (footers,
+// comments) is not attributed to any section
+func (st *structuredText) endBody() {
+	st.bodyEnd = st.sb.Len()
+}
+
+// String returns the accumulated text
+func (st *structuredText) String() string {
+	return st.sb.String()
+}
+
+// sections converts the recorded headings into section spans. A section runs
+// from its heading to the next heading at the same or a shallower level.
+func (st *structuredText) sections() []models.SectionSpan {
+	bodyEnd := st.bodyEnd
+	if bodyEnd == 0 {
+		bodyEnd = st.sb.Len()
+	}
+
+	spans := make([]models.SectionSpan, 0, len(st.headings))
+	for i, h := range st.headings {
+		end := bodyEnd
+		for _, next := range st.headings[i+1:] {
+			if next.level <= h.level {
+				end = next.offset
+				break
+			}
+		}
+		spans = append(spans, models.SectionSpan{
+			Number: h.number,
+			Title:  h.title,
+			Level:  h.level,
+			Start:  h.offset,
+			End:    end,
+		})
+	}
+	return spans
+}
+
+// tableFrame holds the in-progress row and cell of one (possibly nested) table
+type tableFrame struct {
+	row   []string
+	cell  strings.Builder
+	lines []string
+	rows  [][]string
+}
+
+// tableStack flattens tables into one line per row with cells separated by " | ",
+// keeping the cells of each outermost table. Nested tables are folded into the
+// enclosing cell.
+type tableStack []*tableFrame
+
+func (t *tableStack) active() bool {
+	return len(*t) > 0
+}
+
+func (t *tableStack) startTable() {
+	*t = append(*t, &tableFrame{})
+}
+
+// endTable closes the innermost table. When it was the outermost table, it returns
+// a block for each of its rows.
+func (t *tableStack) endTable() []textBlock {
+	if len(*t) == 0 {
+		return nil
+	}
+	frame := (*t)[len(*t)-1]
+	*t = (*t)[:len(*t)-1]
+
+	if len(*t) > 0 {
+		t.addText(strings.Join(frame.lines, "; "))
+		return nil
+	}
+	table := newExtractedTable("", frame.rows)
+	blocks := make([]textBlock, len(frame.lines))
+	for i, line := range frame.lines {
+		blocks[i] = textBlock{text: line, table: table}
+	}
+	return blocks
+}
+
+func (t *tableStack) startRow() {
+	if len(*t) > 0 {
+		(*t)[len(*t)-1].row = nil
+	}
+}
+
+func (t *tableStack) endRow() {
+	if len(*t) == 0 {
+		return
+	}
+	frame := (*t)[len(*t)-1]
+	if line := strings.Join(frame.row, " | "); strings.Trim(line, " |") != "" {
+		frame.lines = append(frame.lines, line)
+		frame.rows = append(frame.rows, frame.row)
+	}
+	frame.row = nil
+}
+
+func (t *tableStack) startCell() {
+	if len(*t) > 0 {
+		(*t)[len(*t)-1].cell.Reset()
+	}
+}
+
+func (t *tableStack) endCell() {
+	if len(*t) == 0 {
+		return
+	}
+	frame := (*t)[len(*t)-1]
+	frame.row = append(frame.row, strings.Join(strings.Fields(frame.cell.String()), " "))
+	frame.cell.Reset()
+}
+
+// addText appends paragraph text to the current cell
+func (t *tableStack) addText(text string) {
+	if len(*t) == 0 || strings.TrimSpace(text) == "" {
+		return
+	}
+	cell := &(*t)[len(*t)-1].cell
+	if cell.Len() > 0 {
+		cell.WriteByte(' ')
+	}
+	cell.WriteString(text)
+}
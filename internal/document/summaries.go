@@ -0,0 +1,86 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ai-government-consultant/internal/models"
+	"ai-government-consultant/internal/queue"
+	"ai-government-consultant/internal/summary"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SummarizeDocumentJob is the job type that writes the LLM summary of a processed document
+const SummarizeDocumentJob = "document.summarize"
+
+// queueSummary adds a summary job for a processed document when summarization is enabled
+func (s *Service) queueSummary(ctx context.Context, documentID primitive.ObjectID) error {
+	if s.summarizer == nil {
+		return nil
+	}
+	if _, err := s.jobs.Enqueue(ctx, SummarizeDocumentJob, documentID.Hex()); err != nil {
+		return fmt.Errorf("failed to queue document for summarization: %w", err)
+	}
+	return nil
+}
+
+// summarizeDocumentJob summarizes the document named by the job key. The summary is written
+// from the redacted text, so it never contains the personal data the document holds.
+func (s *Service) summarizeDocumentJob(ctx context.Context, job *queue.Job) error {
+	documentID, err := primitive.ObjectIDFromHex(job.Key)
+	if err != nil {
+		return fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	var doc struct {
+		Name            string `bson:"name"`
+		Content         string `bson:"content"`
+		RedactedContent string `bson:"redacted_content"`
+		Metadata        struct {
+			Title *string `bson:"title"`
+		} `bson:"metadata"`
+		Sections []models.SectionSpan `bson:"sections"`
+	}
+	projection := bson.M{"name": 1, "content": 1, "redacted_content": 1, "metadata.title": 1, "sections": 1}
+	err = s.collection.FindOne(ctx, bson.M{"_id": documentID}, options.FindOne().SetProjection(projection)).Decode(&doc)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			// The document was deleted while queued; nothing left to do
+			return nil
+		}
+		return fmt.Errorf("failed to find document: %w", err)
+	}
+
+	text := doc.Content
+	if doc.RedactedContent != "" {
+		text = doc.RedactedContent
+	}
+	title := doc.Name
+	if doc.Metadata.Title != nil && *doc.Metadata.Title != "" {
+		title = *doc.Metadata.Title
+	}
+
+	summaryCtx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	update := bson.M{"$unset": bson.M{"summary": ""}}
+	generated, err := s.summarizer.Summarize(summaryCtx, title, text, doc.Sections)
+	switch {
+	case errors.Is(err, summary.ErrNoContent):
+	case err != nil:
+		return err
+	default:
+		update = bson.M{"$set": bson.M{"summary": generated}}
+	}
+
+	if _, err := s.collection.UpdateOne(ctx, bson.M{"_id": documentID}, update); err != nil {
+		return fmt.Errorf("failed to store document summary: %w", err)
+	}
+	return nil
+}
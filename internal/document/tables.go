@@ -0,0 +1,177 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ai-government-consultant/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxStoredTableRows bounds the body rows stored of one table; all rows stay in the document text
+const maxStoredTableRows = 5000
+
+// ErrTableNotFound is returned when a document has no table at the requested index
+var ErrTableNotFound = errors.New("table not found")
+
+// storeTables replaces the stored tables of a document with the tables extracted from it
+func (s *Service) storeTables(ctx context.Context, doc *models.Document, tables []extractedTable) error {
+	collection := s.db.Collection("document_tables")
+	if _, err := collection.DeleteMany(ctx, bson.M{"document_id": doc.ID}); err != nil {
+		return fmt.Errorf("failed to replace document tables: %w", err)
+	}
+
+	now := time.Now()
+	records := make([]interface{}, 0, len(tables))
+	for _, table := range tables {
+		if record := newDocumentTable(doc, len(records), table, now); record != nil {
+			records = append(records, record)
+		}
+	}
+	doc.TableCount = len(records)
+	if len(records) == 0 {
+		return nil
+	}
+
+	if _, err := collection.InsertMany(ctx, records); err != nil {
+		return fmt.Errorf("failed to store document tables: %w", err)
+	}
+	return nil
+}
+
+// ListTables returns a document's tables in order, without their rows
+func (s *Service) ListTables(ctx context.Context, documentID primitive.ObjectID) ([]models.DocumentTable, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "index", Value: 1}}).
+		SetProjection(bson.M{"rows": 0, "redacted_rows": 0})
+	cursor, err := s.db.Collection("document_tables").Find(ctx, bson.M{"document_id": documentID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find document tables: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	tables := []models.DocumentTable{}
+	if err := cursor.All(ctx, &tables); err != nil {
+		return nil, fmt.Errorf("failed to decode document tables: %w", err)
+	}
+	return tables, nil
+}
+
+// GetTable returns the table of a document at index, with its rows
+func (s *Service) GetTable(ctx context.Context, documentID primitive.ObjectID, index int) (*models.DocumentTable, error) {
+	var table models.DocumentTable
+	err := s.db.Collection("document_tables").FindOne(ctx, bson.M{"document_id": documentID, "index": index}).Decode(&table)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrTableNotFound
+		}
+		return nil, fmt.Errorf("failed to find document table: %w", err)
+	}
+	return &table, nil
+}
+
+// newDocumentTable builds the stored form of an extracted table. The first row is taken as the
+// header, and columns empty in every row are dropped. It returns nil for a table left with no
+// columns.
+func newDocumentTable(doc *models.Document, index int, table extractedTable, now time.Time) *models.DocumentTable {
+	rows := squareRows(table.rows)
+	if len(rows) == 0 {
+		return nil
+	}
+
+	record := &models.DocumentTable{
+		ID:         primitive.NewObjectID(),
+		DocumentID: doc.ID,
+		Index:      index,
+		Caption:    table.caption,
+		Columns:    rows[0],
+		Rows:       rows[1:],
+		RowCount:   len(rows) - 1,
+		Start:      table.start,
+		End:        table.end,
+		CreatedAt:  now,
+	}
+	if table.page > 0 {
+		page := table.page
+		record.Page = &page
+	} else {
+		record.Page = doc.PageAt(table.start)
+	}
+	record.Section = doc.SectionAt(table.start)
+	if len(record.Rows) > maxStoredTableRows {
+		record.Rows = record.Rows[:maxStoredTableRows]
+		record.Truncated = true
+	}
+
+	// Keep a redacted variant when any cell holds personal data
+	redactedColumns, columnsChanged := redactCells(record.Columns)
+	redactedRows := make([][]string, len(record.Rows))
+	changed := columnsChanged
+	for i, row := range record.Rows {
+		var rowChanged bool
+		redactedRows[i], rowChanged = redactCells(row)
+		changed = changed || rowChanged
+	}
+	if changed {
+		record.RedactedColumns, record.RedactedRows = redactedColumns, redactedRows
+	}
+	return record
+}
+
+// squareRows pads rows to the same width and drops the columns that are empty in every row
+func squareRows(rows [][]string) [][]string {
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	used := make([]bool, width)
+	for _, row := range rows {
+		for i, cell := range row {
+			if strings.TrimSpace(cell) != "" {
+				used[i] = true
+			}
+		}
+	}
+
+	var columns []int
+	for i, ok := range used {
+		if ok {
+			columns = append(columns, i)
+		}
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+
+	squared := make([][]string, len(rows))
+	for i, row := range rows {
+		squared[i] = make([]string, len(columns))
+		for j, column := range columns {
+			if column < len(row) {
+				squared[i][j] = row[column]
+			}
+		}
+	}
+	return squared
+}
+
+// redactCells masks the personal data in each cell, reporting whether any was found
+func redactCells(cells []string) ([]string, bool) {
+	redacted := make([]string, len(cells))
+	changed := false
+	for i, cell := range cells {
+		redacted[i] = cell
+		if entities := detectPII(cell); len(entities) > 0 {
+			redacted[i] = strings.Join(strings.Fields(redactText(cell, entities)), " ")
+			changed = true
+		}
+	}
+	return redacted, changed
+}
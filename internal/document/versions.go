@@ -0,0 +1,392 @@
+package document
+
+import (
+	"context"
+	"fmt"
+	"mime/multipart"
+	"regexp"
+	"strings"
+	"time"
+
+	"ai-government-consultant/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxDiffCells bounds the paragraph comparison table built for a diff
+const maxDiffCells = 25_000_000
+
+// versionListProjection leaves out the large fields when listing versions
+var versionListProjection = bson.M{"content": 0, "redacted_content": 0, "raw_content": 0, "embeddings": 0, "shadow_embeddings": 0}
+
+var sentenceBoundary = regexp.MustCompile(`([.!?])\s+`)
+
+// VersionDiff is a paragraph-level comparison of two versions of a document
+type VersionDiff struct {
+	SeriesID    primitive.ObjectID `json:"series_id"`
+	FromVersion int                `json:"from_version"`
+	ToVersion   int                `json:"to_version"`
+	FromID      primitive.ObjectID `json:"from_id"`
+	ToID        primitive.ObjectID `json:"to_id"`
+	Changes     []ParagraphChange  `json:"changes"`
+	Added       int                `json:"added"`
+	Removed     int                `json:"removed"`
+	Unchanged   int                `json:"unchanged"`
+}
+
+// ParagraphChange is one paragraph of a diff and whether it was added, removed or kept
+type ParagraphChange struct {
+	Type      string `json:"type"` // "unchanged", "added" or "removed"
+	Text      string `json:"text"`
+	FromIndex *int   `json:"from_index,omitempty"` // paragraph index in the older version
+	ToIndex   *int   `json:"to_index,omitempty"`   // paragraph index in the newer version
+}
+
+// UploadDocumentVersion stores file as a new revision of the document's version chain. The
+// previous latest version keeps its extracted text and embeddings but is marked superseded.
+func (s *Service) UploadDocumentVersion(documentID string, file *multipart.FileHeader, uploadedBy primitive.ObjectID) (*ProcessingResult, error) {
+	validation, err := s.ValidateDocument(file)
+	if err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	if !validation.Valid {
+		return &ProcessingResult{
+			Status:  "failed",
+			Message: fmt.Sprintf("validation failed: %s", strings.Join(validation.Errors, ", ")),
+		}, nil
+	}
+
+	base, err := s.GetProcessingStatus(documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	latest, err := s.latestVersion(ctx, base)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := newVersion(latest, file.Filename, file.Header.Get("Content-Type"), file.Size, uploadedBy)
+	if err := doc.Validate(); err != nil {
+		return &ProcessingResult{
+			Status:  "failed",
+			Message: fmt.Sprintf("document validation failed: %s", err.Error()),
+		}, nil
+	}
+	if err := s.inheritMetadata(ctx, doc, latest); err != nil {
+		return nil, err
+	}
+	if err := s.validateMetadata(ctx, &doc.Metadata); err != nil {
+		if invalidMetadata(err) {
+			return &ProcessingResult{
+				Status:  "failed",
+				Message: err.Error(),
+			}, nil
+		}
+		return nil, err
+	}
+
+	doc.ContentHash, err = hashFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.appendVersion(ctx, latest, func() error {
+		return s.storeDocument(file, doc)
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := s.queueProcessing(doc.ID); err != nil {
+		return nil, err
+	}
+
+	return &ProcessingResult{
+		DocumentID: doc.ID,
+		Status:     "uploaded",
+		Message:    fmt.Sprintf("Version %d uploaded successfully and queued for processing", doc.Version),
+	}, nil
+}
+
+// newVersion builds the document record of the revision following latest
+func newVersion(latest *models.Document, name, contentType string, size int64, uploadedBy primitive.ObjectID) *models.Document {
+	seriesID := latest.VersionSeries()
+	previousID := latest.ID
+	docID := primitive.NewObjectID()
+	return &models.Document{
+		ID:                     docID,
+		Name:                   name,
+		BlobKey:                blobKey(docID),
+		ContentType:            contentType,
+		Size:                   size,
+		UploadedBy:             uploadedBy,
+		UploadedAt:             time.Now(),
+		Classification:         latest.Classification,
+		DeclaredClassification: latest.DeclaredClassification,
+		Metadata:               revisionMetadata(latest.Metadata),
+		ProcessingStatus:       models.ProcessingStatusPending,
+		Version:                latest.VersionNumber() + 1,
+		SeriesID:               &seriesID,
+		PreviousVersionID:      &previousID,
+		WorkspaceIDs:           latest.WorkspaceIDs, // a new version stays in the same workspaces
+		SharedWith:             latest.SharedWith,
+	}
+}
+
+// appendVersion marks latest superseded and stores its successor with store. The latest version
+// is claimed first so two concurrent uploads cannot both extend the chain from it, and the claim
+// is released if storing fails.
+func (s *Service) appendVersion(ctx context.Context, latest *models.Document, store func() error) error {
+	claim, err := s.collection.UpdateOne(ctx,
+		bson.M{"_id": latest.ID, "superseded": bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{
+			"superseded": true,
+			"series_id":  latest.VersionSeries(),
+			"version":    latest.VersionNumber(),
+		}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update previous version: %w", err)
+	}
+	if claim.MatchedCount == 0 {
+		return fmt.Errorf("another version of this document was uploaded concurrently; retry the upload")
+	}
+
+	if err := store(); err != nil {
+		s.collection.UpdateOne(ctx, bson.M{"_id": latest.ID}, bson.M{"$unset": bson.M{"superseded": ""}})
+		return err
+	}
+	return nil
+}
+
+// ListVersions returns every version in the document's chain, oldest first, without their content
+func (s *Service) ListVersions(documentID string) ([]*models.Document, error) {
+	doc, err := s.GetProcessingStatus(documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := s.collection.Find(ctx, s.seriesFilter(doc),
+		options.Find().SetSort(bson.D{{Key: "version", Value: 1}}).SetProjection(versionListProjection))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find versions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	versions := []*models.Document{}
+	if err := cursor.All(ctx, &versions); err != nil {
+		return nil, fmt.Errorf("failed to decode versions: %w", err)
+	}
+	return versions, nil
+}
+
+// GetVersion returns a specific version from the document's chain
+func (s *Service) GetVersion(documentID string, version int) (*models.Document, error) {
+	doc, err := s.GetProcessingStatus(documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return s.findVersion(ctx, doc, version)
+}
+
+// DiffVersions compares the extracted text of two versions of a document paragraph by paragraph.
+// Unless unredacted is set, the redacted text of versions containing personal data is compared.
+func (s *Service) DiffVersions(documentID string, fromVersion, toVersion int, unredacted bool) (*VersionDiff, error) {
+	doc, err := s.GetProcessingStatus(documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	from, err := s.findVersion(ctx, doc, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.findVersion(ctx, doc, toVersion)
+	if err != nil {
+		return nil, err
+	}
+	for _, version := range []*models.Document{from, to} {
+		if !version.IsProcessed() {
+			return nil, fmt.Errorf("version %d has not been processed yet", version.VersionNumber())
+		}
+	}
+
+	if !unredacted {
+		from.RedactPII()
+		to.RedactPII()
+	}
+
+	changes, err := diffParagraphs(splitParagraphs(from.Content), splitParagraphs(to.Content))
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &VersionDiff{
+		SeriesID:    doc.VersionSeries(),
+		FromVersion: from.VersionNumber(),
+		ToVersion:   to.VersionNumber(),
+		FromID:      from.ID,
+		ToID:        to.ID,
+		Changes:     changes,
+	}
+	for _, change := range changes {
+		switch change.Type {
+		case "added":
+			diff.Added++
+		case "removed":
+			diff.Removed++
+		default:
+			diff.Unchanged++
+		}
+	}
+	return diff, nil
+}
+
+// seriesFilter matches every version in a document's chain
+func (s *Service) seriesFilter(doc *models.Document) bson.M {
+	if doc.SeriesID == nil {
+		return bson.M{"_id": doc.ID}
+	}
+	return bson.M{"series_id": *doc.SeriesID}
+}
+
+// latestVersion returns the newest version in a document's chain
+func (s *Service) latestVersion(ctx context.Context, doc *models.Document) (*models.Document, error) {
+	if !doc.Superseded {
+		return doc, nil
+	}
+
+	filter := s.seriesFilter(doc)
+	filter["superseded"] = bson.M{"$ne": true}
+
+	var latest models.Document
+	if err := s.collection.FindOne(ctx, filter).Decode(&latest); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("latest version not found")
+		}
+		return nil, fmt.Errorf("failed to find latest version: %w", err)
+	}
+	return &latest, nil
+}
+
+// findVersion returns the numbered version from a document's chain
+func (s *Service) findVersion(ctx context.Context, doc *models.Document, version int) (*models.Document, error) {
+	if version < 1 {
+		return nil, fmt.Errorf("invalid version number: %d", version)
+	}
+	if doc.VersionNumber() == version {
+		return doc, nil
+	}
+	if doc.SeriesID == nil {
+		return nil, fmt.Errorf("version %d not found", version)
+	}
+
+	var found models.Document
+	err := s.collection.FindOne(ctx, bson.M{"series_id": *doc.SeriesID, "version": version}).Decode(&found)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("version %d not found", version)
+		}
+		return nil, fmt.Errorf("failed to find version: %w", err)
+	}
+	return &found, nil
+}
+
+// revisionMetadata carries the user-supplied metadata of a version over to its successor.
+// Fields recovered by extraction are left for the new file to fill in.
+func revisionMetadata(previous models.DocumentMetadata) models.DocumentMetadata {
+	return models.DocumentMetadata{
+		Title:        previous.Title,
+		Department:   previous.Department,
+		Category:     previous.Category,
+		Tags:         append([]string{}, previous.Tags...),
+		Language:     previous.Language,
+		CustomFields: map[string]interface{}{},
+	}
+}
+
+// splitParagraphs splits extracted text into paragraphs at blank lines. Text without
+// paragraph breaks (such as whitespace-collapsed plain text) falls back to lines, then sentences.
+func splitParagraphs(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	paragraphs := nonEmpty(strings.Split(text, "\n\n"))
+	if len(paragraphs) <= 1 {
+		paragraphs = nonEmpty(strings.Split(text, "\n"))
+	}
+	if len(paragraphs) <= 1 {
+		paragraphs = nonEmpty(strings.Split(sentenceBoundary.ReplaceAllString(text, "$1\n"), "\n"))
+	}
+	return paragraphs
+}
+
+func nonEmpty(parts []string) []string {
+	result := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.Join(strings.Fields(part), " "); part != "" {
+			result = append(result, part)
+		}
+	}
+	return result
+}
+
+// diffParagraphs computes a minimal paragraph diff from the longest common subsequence
+func diffParagraphs(from, to []string) ([]ParagraphChange, error) {
+	n, m := len(from), len(to)
+	if int64(n+1)*int64(m+1) > maxDiffCells {
+		return nil, fmt.Errorf("documents are too large to diff (%d and %d paragraphs)", n, m)
+	}
+
+	// lcs[i][j] is the LCS length of from[i:] and to[j:]
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	changes := make([]ParagraphChange, 0, max(n, m))
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && from[i] == to[j]:
+			changes = append(changes, ParagraphChange{Type: "unchanged", Text: to[j], FromIndex: intPtr(i), ToIndex: intPtr(j)})
+			i++
+			j++
+		case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+			changes = append(changes, ParagraphChange{Type: "removed", Text: from[i], FromIndex: intPtr(i)})
+			i++
+		default:
+			changes = append(changes, ParagraphChange{Type: "added", Text: to[j], ToIndex: intPtr(j)})
+			j++
+		}
+	}
+	return changes, nil
+}
+
+func intPtr(v int) *int {
+	return &v
+}
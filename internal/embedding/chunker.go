@@ -0,0 +1,217 @@
+package embedding
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"ai-government-consultant/internal/models"
+)
+
+// ChunkerConfig controls how document content is split into chunks
+type ChunkerConfig struct {
+	Size         int  // target chunk length in bytes
+	Overlap      int  // bytes of the previous chunk repeated at the start of the next
+	HeadingAware bool // start a new chunk at every heading in the document outline
+}
+
+// DefaultChunkerConfig returns the default chunking configuration
+func DefaultChunkerConfig() *ChunkerConfig {
+	return &ChunkerConfig{
+		Size:         1500,
+		Overlap:      200,
+		HeadingAware: true,
+	}
+}
+
+// Chunker splits document content into overlapping passages with their content offsets
+type Chunker struct {
+	config ChunkerConfig
+}
+
+// NewChunker creates a chunker, falling back to defaults for unset or inconsistent values
+func NewChunker(config *ChunkerConfig) *Chunker {
+	defaults := DefaultChunkerConfig()
+	if config == nil {
+		config = defaults
+	}
+
+	c := &Chunker{config: *config}
+	if c.config.Size <= 0 {
+		c.config.Size = defaults.Size
+	}
+	if c.config.Overlap < 0 {
+		c.config.Overlap = 0
+	}
+	if c.config.Overlap > c.config.Size/2 {
+		c.config.Overlap = c.config.Size / 2
+	}
+	return c
+}
+
+// Chunk splits a document's content into chunks. Chunks prefer to end at paragraph,
+// line or sentence breaks, and with HeadingAware set never span a heading.
+func (c *Chunker) Chunk(doc *models.Document) []models.DocumentChunk {
+	var chunks []models.DocumentChunk
+	now := time.Now()
+
+	for _, region := range c.regions(doc) {
+		start, end := region[0], region[1]
+		for {
+			start = skipSpace(doc.Content, start, end)
+			if start >= end {
+				break
+			}
+
+			chunkEnd := end
+			if end-start > c.config.Size {
+				chunkEnd = breakPoint(doc.Content, start, start+c.config.Size)
+			}
+
+			text := strings.TrimRightFunc(doc.Content[start:chunkEnd], unicode.IsSpace)
+			chunks = append(chunks, models.DocumentChunk{
+				DocumentID: doc.ID,
+				Index:      len(chunks),
+				Content:    text,
+				Start:      start,
+				End:        start + len(text),
+				Section:    doc.SectionAt(start),
+				PageNumber: doc.PageAt(start),
+				CreatedAt:  now,
+			})
+
+			if chunkEnd >= end {
+				break
+			}
+			start = c.overlapStart(doc.Content, start, chunkEnd)
+		}
+	}
+
+	return chunks
+}
+
+// regions returns the content ranges chunks may not cross. Headings too short to
+// stand on their own are merged into the section that follows them.
+func (c *Chunker) regions(doc *models.Document) [][2]int {
+	length := len(doc.Content)
+	if !c.config.HeadingAware || len(doc.Sections) == 0 {
+		return [][2]int{{0, length}}
+	}
+
+	boundaries := []int{0, length}
+	for _, section := range doc.Sections {
+		if section.Start > 0 && section.Start < length {
+			boundaries = append(boundaries, section.Start)
+		}
+	}
+	sort.Ints(boundaries)
+
+	minLength := c.config.Size / 5
+	var regions [][2]int
+	start := 0
+	for _, boundary := range boundaries[1:] {
+		if boundary <= start {
+			continue
+		}
+		if boundary < length && len(strings.TrimSpace(doc.Content[start:boundary])) < minLength {
+			continue
+		}
+		regions = append(regions, [2]int{start, boundary})
+		start = boundary
+	}
+	return regions
+}
+
+// overlapStart returns where the chunk after [start, end) begins, backing up by at most
+// the configured overlap to the start of a sentence, or failing that of a word
+func (c *Chunker) overlapStart(content string, start, end int) int {
+	next := end - c.config.Overlap
+	if next <= start {
+		return end
+	}
+	overlap := content[next:end]
+	for _, terminator := range []string{"\n", ". ", "? ", "! "} {
+		if i := strings.Index(overlap, terminator); i >= 0 && next+i+len(terminator) < end {
+			return next + i + len(terminator)
+		}
+	}
+	if i := strings.IndexFunc(overlap, unicode.IsSpace); i >= 0 {
+		return next + i
+	}
+	return end
+}
+
+// breakPoint finds the best place to end a chunk before limit, preferring paragraph
+// breaks, then line breaks, then sentence ends, then spaces
+func breakPoint(content string, start, limit int) int {
+	for limit > start && !utf8.RuneStart(content[limit]) {
+		limit--
+	}
+
+	// Never break in the first half of the window, so chunks stay close to the target size
+	window := content[start:limit]
+	floor := len(window) / 2
+
+	if i := strings.LastIndex(window, "\n\n"); i > floor {
+		return start + i + 2
+	}
+	if i := strings.LastIndexByte(window, '\n'); i > floor {
+		return start + i + 1
+	}
+	for _, terminator := range []string{". ", "? ", "! ", "; "} {
+		if i := strings.LastIndex(window, terminator); i > floor {
+			return start + i + len(terminator)
+		}
+	}
+	if i := strings.LastIndexByte(window, ' '); i > floor {
+		return start + i + 1
+	}
+	return limit
+}
+
+func skipSpace(content string, start, end int) int {
+	for start < end {
+		r, size := utf8.DecodeRuneInString(content[start:end])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		start += size
+	}
+	return start
+}
+
+// chunkEmbeddingText returns the text embedded for a chunk, prefixed with its section
+// heading so passages deep inside a section keep their topic
+func chunkEmbeddingText(chunk *models.DocumentChunk) string {
+	if chunk.Section == nil {
+		return chunk.Content
+	}
+	return *chunk.Section + "\n" + chunk.Content
+}
+
+// meanVector averages chunk vectors into a single document-level vector
+func meanVector(vectors [][]float64) []float64 {
+	var mean []float64
+	count := 0
+	for _, vector := range vectors {
+		if len(vector) == 0 {
+			continue
+		}
+		if mean == nil {
+			mean = make([]float64, len(vector))
+		}
+		if len(vector) != len(mean) {
+			continue
+		}
+		for i, value := range vector {
+			mean[i] += value
+		}
+		count++
+	}
+	for i := range mean {
+		mean[i] /= float64(count)
+	}
+	return mean
+}
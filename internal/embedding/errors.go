@@ -54,4 +54,13 @@ var (
 	ErrPipelineProcessing = errors.New("pipeline processing failed")
 	ErrWorkerPoolFailed   = errors.New("worker pool failed")
 	ErrBatchProcessing    = errors.New("batch processing failed")
+
+	// Migration errors
+	ErrMigrationNotFound    = errors.New("embedding migration not found")
+	ErrMigrationInProgress  = errors.New("another embedding migration is in progress")
+	ErrMigrationState       = errors.New("embedding migration is not in a state allowing this")
+	ErrMigrationSameModel   = errors.New("target model is already the active model")
+	ErrMigrationIncomplete  = errors.New("some records have no vector of the target model")
+	ErrMigrationUnsupported = errors.New("embedding migrations are not enabled")
+	ErrNoShadowModel        = errors.New("no embedding migration is running")
 )
@@ -0,0 +1,106 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// GeminiEmbeddingRequest represents the request structure for Gemini embedding API
+type GeminiEmbeddingRequest struct {
+	Content struct {
+		Parts []struct {
+			Text string `json:"text"`
+		} `json:"parts"`
+	} `json:"content"`
+}
+
+// GeminiEmbeddingResponse represents the response structure from Gemini embedding API
+type GeminiEmbeddingResponse struct {
+	Embedding struct {
+		Values []float64 `json:"values"`
+	} `json:"embedding"`
+}
+
+// GeminiProvider embeds text with Google's Gemini API
+type GeminiProvider struct {
+	apiKey     string
+	url        string
+	model      string
+	httpClient *http.Client
+}
+
+// NewGeminiProvider creates a Gemini provider for model, defaulting to text-embedding-004.
+// endpoint overrides the embedContent URL of the model when not empty.
+func NewGeminiProvider(apiKey, model, endpoint string) *GeminiProvider {
+	if model == "" {
+		model = "text-embedding-004"
+	}
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:embedContent", model)
+	}
+
+	return &GeminiProvider{
+		apiKey: apiKey,
+		url:    endpoint,
+		model:  model,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Name returns "gemini/" followed by the model
+func (p *GeminiProvider) Name() string {
+	return "gemini/" + p.model
+}
+
+// Embed sends text to Gemini's embedContent endpoint. The API key goes in a header, so it never
+// appears in URLs that end up in logs or error messages.
+func (p *GeminiProvider) Embed(ctx context.Context, text string) ([]float64, error) {
+	request := GeminiEmbeddingRequest{}
+	request.Content.Parts = []struct {
+		Text string `json:"text"`
+	}{
+		{Text: text},
+	}
+
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-goog-api-key", p.apiKey)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, ErrAPIRateLimited
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%w: status %d: %s", ErrAPIRequestFailed, resp.StatusCode, string(body))
+	}
+
+	var response GeminiEmbeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(response.Embedding.Values) == 0 {
+		return nil, ErrAPIResponseInvalid
+	}
+	return response.Embedding.Values, nil
+}
@@ -0,0 +1,250 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// FusionMethod selects how the lexical and vector rankings of a hybrid search are combined
+type FusionMethod string
+
+const (
+	// FusionRRF scores a result by reciprocal rank fusion, the sum of weight/(k+rank) over the
+	// rankings it appears in. Only positions count, so scores on different scales combine well.
+	FusionRRF FusionMethod = "rrf"
+
+	// FusionWeighted scores a result by the weighted mean of its min-max normalized scores
+	FusionWeighted FusionMethod = "weighted"
+)
+
+// FusionConfig configures how hybrid searches combine lexical and vector results
+type FusionConfig struct {
+	Method        FusionMethod
+	RRFK          float64 // rank offset of reciprocal rank fusion; larger values flatten the ranking
+	LexicalWeight float64
+	VectorWeight  float64
+	Candidates    int // results taken from each retriever before fusion
+}
+
+// DefaultFusionConfig returns reciprocal rank fusion with k=60 and equal weights
+func DefaultFusionConfig() *FusionConfig {
+	return &FusionConfig{
+		Method:        FusionRRF,
+		RRFK:          60,
+		LexicalWeight: 1,
+		VectorWeight:  1,
+		Candidates:    50,
+	}
+}
+
+// fusionConfig returns config with unset fields taken from DefaultFusionConfig
+func fusionConfig(config *FusionConfig) FusionConfig {
+	defaults := DefaultFusionConfig()
+	if config == nil {
+		return *defaults
+	}
+
+	c := *config
+	if c.Method != FusionWeighted {
+		c.Method = FusionRRF
+	}
+	if c.RRFK <= 0 {
+		c.RRFK = defaults.RRFK
+	}
+	if c.LexicalWeight <= 0 {
+		c.LexicalWeight = defaults.LexicalWeight
+	}
+	if c.VectorWeight <= 0 {
+		c.VectorWeight = defaults.VectorWeight
+	}
+	if c.Candidates <= 0 {
+		c.Candidates = defaults.Candidates
+	}
+	return c
+}
+
+// HybridOptions defines options for hybrid search. Unset fusion parameters take the service's
+// configured values; Threshold applies to vector similarity only.
+type HybridOptions struct {
+	SearchOptions
+	Fusion        FusionMethod `json:"fusion,omitempty"`
+	LexicalWeight float64      `json:"lexical_weight,omitempty"`
+	VectorWeight  float64      `json:"vector_weight,omitempty"`
+	RRFK          float64      `json:"rrf_k,omitempty"`
+	Candidates    int          `json:"candidates,omitempty"`
+}
+
+// HybridResult is a hybrid search result; Score is the fused score
+type HybridResult struct {
+	SearchResult
+	Explanation ScoreExplanation `json:"explanation"`
+}
+
+// ScoreExplanation shows how the fused score of a result was made up
+type ScoreExplanation struct {
+	Method   FusionMethod    `json:"method"`
+	MaxScore float64         `json:"max_score"`         // fused score of a result ranked first by both searches
+	Lexical  *RetrieverScore `json:"lexical,omitempty"` // nil when the lexical search did not return the result
+	Vector   *RetrieverScore `json:"vector,omitempty"`  // nil when the vector search did not return the result
+}
+
+// RetrieverScore is the standing of a result in the ranking of one retriever
+type RetrieverScore struct {
+	Rank         int                `json:"rank"`       // 1 for the retriever's best result
+	Score        float64            `json:"score"`      // BM25 score or cosine similarity
+	Normalized   float64            `json:"normalized"` // score scaled to [0, 1] among the retriever's results, for weighted fusion
+	Weight       float64            `json:"weight"`
+	Contribution float64            `json:"contribution"`    // share of the fused score
+	Terms        map[string]float64 `json:"terms,omitempty"` // BM25 score of each matched query term
+}
+
+// HybridSearch ranks documents and knowledge items by both BM25 keyword relevance and vector
+// similarity to query, and fuses the two rankings. Exact terms such as statute numbers and
+// acronyms are found by the keyword search even where their embeddings are not close. If one of
+// the searches fails, the results of the other are returned.
+func (s *Service) HybridSearch(ctx context.Context, query string, options *HybridOptions) ([]HybridResult, error) {
+	if options == nil {
+		options = &HybridOptions{SearchOptions: SearchOptions{Limit: 10, Threshold: 0.7}}
+	}
+	fusion := s.fusion
+	if options.Fusion != "" {
+		fusion.Method = options.Fusion
+	}
+	if options.LexicalWeight > 0 {
+		fusion.LexicalWeight = options.LexicalWeight
+	}
+	if options.VectorWeight > 0 {
+		fusion.VectorWeight = options.VectorWeight
+	}
+	if options.RRFK > 0 {
+		fusion.RRFK = options.RRFK
+	}
+	if options.Candidates > 0 {
+		fusion.Candidates = options.Candidates
+	}
+	if fusion.Method != FusionRRF && fusion.Method != FusionWeighted {
+		return nil, fmt.Errorf("unknown fusion method %q", fusion.Method)
+	}
+	limit := options.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	candidates := max(fusion.Candidates, limit)
+
+	vectorOptions := options.SearchOptions
+	vectorOptions.Limit = candidates
+	vectorResults, vectorErr := s.VectorSearch(ctx, query, &vectorOptions)
+	if vectorErr != nil {
+		s.logger.Error("Hybrid search continues without vector results", vectorErr, nil)
+	}
+
+	var lexicalHits []lexicalHit
+	var lexicalErr error
+	if terms := queryTerms(query); len(terms) > 0 {
+		lexicalHits, lexicalErr = s.lexicalSearch(ctx, terms, &options.SearchOptions, candidates)
+		if lexicalErr != nil {
+			s.logger.Error("Hybrid search continues without lexical results", lexicalErr, nil)
+		}
+	}
+	if vectorErr != nil && (lexicalErr != nil || len(lexicalHits) == 0) {
+		return nil, vectorErr
+	}
+
+	results := fuseRankings(fusion, lexicalHits, vectorResults)
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	s.logger.Debug("Hybrid search completed", map[string]interface{}{
+		"query_length":  len(query),
+		"fusion":        string(fusion.Method),
+		"lexical_count": len(lexicalHits),
+		"vector_count":  len(vectorResults),
+		"results_count": len(results),
+	})
+	return results, nil
+}
+
+// fuseRankings merges the lexical and vector rankings into one, highest fused score first
+func fuseRankings(fusion FusionConfig, lexicalHits []lexicalHit, vectorResults []SearchResult) []HybridResult {
+	maxScore := 1.0
+	if fusion.Method == FusionRRF {
+		maxScore = (fusion.LexicalWeight + fusion.VectorWeight) / (fusion.RRFK + 1)
+	}
+
+	var results []*HybridResult
+	byKey := make(map[string]*HybridResult)
+	result := func(r SearchResult) *HybridResult {
+		key := resultKey(r)
+		if existing, ok := byKey[key]; ok {
+			return existing
+		}
+		fused := &HybridResult{SearchResult: r, Explanation: ScoreExplanation{Method: fusion.Method, MaxScore: maxScore}}
+		byKey[key] = fused
+		results = append(results, fused)
+		return fused
+	}
+
+	lexicalScores := make([]float64, len(lexicalHits))
+	for i, hit := range lexicalHits {
+		lexicalScores[i] = hit.score
+	}
+	vectorScores := make([]float64, len(vectorResults))
+	for i, r := range vectorResults {
+		vectorScores[i] = r.Score
+	}
+
+	for i, r := range vectorResults {
+		fused := result(r)
+		fused.Explanation.Vector = retrieverScore(fusion, fusion.VectorWeight, i, vectorScores)
+	}
+	for i, hit := range lexicalHits {
+		fused := result(hit.result)
+		fused.Explanation.Lexical = retrieverScore(fusion, fusion.LexicalWeight, i, lexicalScores)
+		fused.Explanation.Lexical.Terms = hit.terms
+	}
+
+	fusedResults := make([]HybridResult, len(results))
+	for i, fused := range results {
+		fused.Score = 0
+		for _, score := range []*RetrieverScore{fused.Explanation.Lexical, fused.Explanation.Vector} {
+			if score != nil {
+				fused.Score += score.Contribution
+			}
+		}
+		fusedResults[i] = *fused
+	}
+	sort.SliceStable(fusedResults, func(i, j int) bool { return fusedResults[i].Score > fusedResults[j].Score })
+	return fusedResults
+}
+
+// retrieverScore returns the standing of the result at position i of a ranking with scores
+func retrieverScore(fusion FusionConfig, weight float64, i int, scores []float64) *RetrieverScore {
+	score := &RetrieverScore{Rank: i + 1, Score: scores[i], Weight: weight}
+	if fusion.Method == FusionRRF {
+		score.Contribution = weight / (fusion.RRFK + float64(score.Rank))
+		return score
+	}
+
+	// Rankings are sorted, so the first and last scores are the extremes
+	highest, lowest := scores[0], scores[len(scores)-1]
+	score.Normalized = 1
+	if highest > lowest {
+		score.Normalized = (scores[i] - lowest) / (highest - lowest)
+	}
+	score.Contribution = weight * score.Normalized / (fusion.LexicalWeight + fusion.VectorWeight)
+	return score
+}
+
+// resultKey identifies the passage, document or knowledge item of a search result
+func resultKey(r SearchResult) string {
+	switch {
+	case r.Chunk != nil:
+		return "chunk:" + r.Chunk.ID.Hex()
+	case r.Knowledge != nil:
+		return "knowledge:" + r.Knowledge.ID.Hex()
+	default:
+		return "document:" + r.ID
+	}
+}
@@ -0,0 +1,620 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ai-government-consultant/internal/models"
+	"ai-government-consultant/internal/vectorindex"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// syncOverlap is how far before the previous sync a sync looks for changed vectors, to allow
+	// for clock differences between servers and writes still in flight
+	syncOverlap = time.Minute
+
+	// preFilterLimit is the most records a search filter may select to be applied before the
+	// index search; searches matching more records filter the index results instead
+	preFilterLimit = 10000
+
+	// maxCandidates bounds how many index results a search considers while filtering
+	maxCandidates = 4000
+)
+
+// IndexConfig configures the in-process HNSW indexes that serve vector search. MongoDB stays the
+// source of truth: the indexes are built from the stored vectors, kept up to date as vectors are
+// written and resynchronized periodically, and every result is read back from MongoDB.
+type IndexConfig struct {
+	Path         string             // directory of the index snapshots; empty keeps the indexes in memory only
+	SyncInterval time.Duration      // how often the indexes catch up with MongoDB and are saved (default: 5m)
+	Graph        vectorindex.Config // HNSW parameters
+}
+
+// indexedCollection is a collection whose vectors are indexed
+type indexedCollection struct {
+	name       string // collection name, also the name of its snapshot
+	ownerField string // field holding the record a vector belongs to; empty when it is the vector's own record
+	stampField string // time field set whenever the vector is written
+}
+
+var indexedCollections = []indexedCollection{
+	{name: "documents", stampField: "processing_timestamp"},
+	{name: "document_chunks", ownerField: "document_id", stampField: "created_at"},
+	{name: "knowledge_items", stampField: "updated_at"},
+}
+
+// vectorIndex is the HNSW index of one collection
+type vectorIndex struct {
+	collection indexedCollection
+	index      atomic.Pointer[vectorindex.Index]
+	ready      atomic.Bool  // set once the index holds every stored vector
+	dirty      atomic.Bool  // set when the index changed since its last snapshot
+	model      atomic.Value // string naming the model of the vectors in the index
+
+	mu       sync.Mutex // serializes syncs
+	syncedAt time.Time  // the index holds every vector written before this time
+}
+
+// indexes holds the vector indexes of the service and their sync loop
+type indexes struct {
+	config       IndexConfig
+	byCollection map[string]*vectorIndex
+	cancel       context.CancelFunc
+	done         chan struct{}
+	rebuild      chan struct{} // signalled when the active model changes
+}
+
+func newIndexes(config *IndexConfig) *indexes {
+	ix := &indexes{
+		config:       *config,
+		byCollection: make(map[string]*vectorIndex),
+		rebuild:      make(chan struct{}, 1),
+	}
+	if ix.config.SyncInterval <= 0 {
+		ix.config.SyncInterval = 5 * time.Minute
+	}
+	for _, collection := range indexedCollections {
+		vi := &vectorIndex{collection: collection}
+		vi.index.Store(vectorindex.New(ix.config.Graph))
+		vi.model.Store("")
+		ix.byCollection[collection.name] = vi
+	}
+	return ix
+}
+
+// StartIndex loads the vector indexes from their snapshots, or builds them from MongoDB, and
+// keeps them in step with MongoDB until StopIndex. Searches scan MongoDB until an index is ready.
+func (s *Service) StartIndex() {
+	if s.indexes == nil || s.mongodb == nil || s.indexes.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.indexes.cancel = cancel
+	s.indexes.done = make(chan struct{})
+	go s.runIndex(ctx)
+}
+
+// StopIndex stops keeping the vector indexes up to date and saves their snapshots
+func (s *Service) StopIndex() {
+	if s.indexes == nil || s.indexes.cancel == nil {
+		return
+	}
+	s.indexes.cancel()
+	<-s.indexes.done
+}
+
+// runIndex loads every index, then syncs and saves them every sync interval until ctx ends
+func (s *Service) runIndex(ctx context.Context) {
+	defer close(s.indexes.done)
+
+	for _, collection := range indexedCollections {
+		vi := s.indexes.byCollection[collection.name]
+		if err := s.loadIndex(ctx, vi); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			s.logger.Error("Failed to build vector index", err, map[string]interface{}{
+				"collection": collection.name,
+			})
+		}
+	}
+
+	ticker := time.NewTicker(s.indexes.config.SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.saveIndexes()
+			return
+		case <-ticker.C:
+		case <-s.indexes.rebuild:
+		}
+
+		for _, collection := range indexedCollections {
+			vi := s.indexes.byCollection[collection.name]
+			if err := s.syncIndex(ctx, vi); err != nil {
+				if ctx.Err() != nil {
+					break
+				}
+				s.logger.Error("Failed to sync vector index", err, map[string]interface{}{
+					"collection": collection.name,
+				})
+				continue
+			}
+			vi.ready.Store(true)
+
+			// Rebuild the graph once a quarter of it is removed vectors
+			index := vi.index.Load()
+			if tombstones := index.Tombstones(); tombstones > 0 && tombstones*4 > index.Len()+tombstones {
+				index.Compact()
+				vi.dirty.Store(true)
+			}
+		}
+		s.saveIndexes()
+	}
+}
+
+// loadIndex reads the snapshot of vi, if it has one built from the service's model, and brings
+// it up to date with MongoDB. Without a usable snapshot the index is built from scratch.
+func (s *Service) loadIndex(ctx context.Context, vi *vectorIndex) error {
+	if path := s.snapshotPath(vi); path != "" {
+		index, info, err := vectorindex.Load(path, s.indexes.config.Graph)
+		switch {
+		case err == nil && info.Model == s.Model():
+			vi.mu.Lock()
+			vi.index.Store(index)
+			vi.model.Store(info.Model)
+			vi.syncedAt = info.SyncedAt
+			vi.mu.Unlock()
+		case err == nil:
+			s.logger.Info("Rebuilding vector index for a new embedding model", map[string]interface{}{
+				"collection":     vi.collection.name,
+				"snapshot_model": info.Model,
+				"model":          s.Model(),
+			})
+		case !errors.Is(err, os.ErrNotExist):
+			s.logger.Warn("Ignoring unreadable vector index snapshot", map[string]interface{}{
+				"collection": vi.collection.name,
+				"error":      err.Error(),
+			})
+		}
+	}
+
+	started := time.Now()
+	if err := s.syncIndex(ctx, vi); err != nil {
+		return err
+	}
+	vi.ready.Store(true)
+
+	s.logger.Info("Vector index ready", map[string]interface{}{
+		"collection": vi.collection.name,
+		"vectors":    vi.index.Load().Len(),
+		"duration":   time.Since(started).String(),
+	})
+	return nil
+}
+
+// syncIndex adds the vectors written since the last sync to vi and removes the vectors that are
+// no longer stored. After the active model changed the index is rebuilt from scratch.
+func (s *Service) syncIndex(ctx context.Context, vi *vectorIndex) error {
+	vi.mu.Lock()
+	defer vi.mu.Unlock()
+
+	model := s.Model()
+	if vi.model.Load() != model {
+		vi.index.Store(vectorindex.New(s.indexes.config.Graph))
+		vi.model.Store(model)
+		vi.syncedAt = time.Time{}
+	}
+
+	started := time.Now()
+	index := vi.index.Load()
+	collection := s.mongodb.Collection(vi.collection.name)
+	stored := vectorMatch(model)
+
+	// Add the vectors written since the last sync
+	changed := stored
+	if !vi.syncedAt.IsZero() {
+		changed = bson.M{"$and": []bson.M{stored, {vi.collection.stampField: bson.M{"$gte": vi.syncedAt.Add(-syncOverlap)}}}}
+	}
+	projection := bson.M{"embeddings": 1, "embedding_model": 1, "shadow_embeddings": 1, "shadow_embedding_model": 1}
+	if vi.collection.ownerField != "" {
+		projection[vi.collection.ownerField] = 1
+	}
+	cursor, err := collection.Find(ctx, changed, options.Find().SetProjection(projection).SetBatchSize(500))
+	if err != nil {
+		return fmt.Errorf("failed to read vectors: %w", err)
+	}
+	added := 0
+	for cursor.Next(ctx) {
+		id, owner, vector, ok := s.indexEntry(vi, cursor.Current, model)
+		if ok && index.Add(id, owner, vector) {
+			added++
+		}
+	}
+	err = cursor.Err()
+	cursor.Close(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read vectors: %w", err)
+	}
+
+	// Remove the vectors whose records were deleted or lost their vector. Only vectors indexed
+	// before the scan are considered, since vectors written during it may be missing from it.
+	known := index.IDs()
+	cursor, err = collection.Find(ctx, stored, options.Find().SetProjection(bson.M{"_id": 1}).SetBatchSize(5000))
+	if err != nil {
+		return fmt.Errorf("failed to read vector IDs: %w", err)
+	}
+	present := make(map[string]bool, index.Len())
+	for cursor.Next(ctx) {
+		if id, ok := cursor.Current.Lookup("_id").ObjectIDOK(); ok {
+			present[id.Hex()] = true
+		}
+	}
+	err = cursor.Err()
+	cursor.Close(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read vector IDs: %w", err)
+	}
+	removed := 0
+	for _, id := range known {
+		if !present[id] {
+			index.Remove(id)
+			removed++
+		}
+	}
+
+	vi.syncedAt = started
+	if added > 0 || removed > 0 {
+		vi.dirty.Store(true)
+		s.logger.Debug("Synced vector index", map[string]interface{}{
+			"collection": vi.collection.name,
+			"added":      added,
+			"removed":    removed,
+		})
+	}
+	return nil
+}
+
+// indexEntry reads the ID, owner and vector of model of a record of vi's collection
+func (s *Service) indexEntry(vi *vectorIndex, raw bson.Raw, model string) (string, string, []float64, bool) {
+	var record struct {
+		ID                   primitive.ObjectID `bson:"_id"`
+		Embeddings           []float64          `bson:"embeddings"`
+		EmbeddingModel       string             `bson:"embedding_model"`
+		ShadowEmbeddings     []float64          `bson:"shadow_embeddings"`
+		ShadowEmbeddingModel string             `bson:"shadow_embedding_model"`
+	}
+	if err := bson.Unmarshal(raw, &record); err != nil {
+		return "", "", nil, false
+	}
+	vector := activeVector(model, record.EmbeddingModel, record.Embeddings, record.ShadowEmbeddingModel, record.ShadowEmbeddings)
+	if len(vector) == 0 {
+		return "", "", nil, false
+	}
+
+	owner := record.ID
+	if vi.collection.ownerField != "" {
+		var ok bool
+		if owner, ok = raw.Lookup(vi.collection.ownerField).ObjectIDOK(); !ok {
+			return "", "", nil, false
+		}
+	}
+	return record.ID.Hex(), owner.Hex(), vector, true
+}
+
+// saveIndexes writes the snapshots of the ready indexes that changed
+func (s *Service) saveIndexes() {
+	for _, collection := range indexedCollections {
+		vi := s.indexes.byCollection[collection.name]
+		path := s.snapshotPath(vi)
+		if path == "" || !vi.ready.Load() || !vi.dirty.Swap(false) {
+			continue
+		}
+
+		vi.mu.Lock()
+		info := vectorindex.SnapshotInfo{Model: vi.model.Load().(string), SyncedAt: vi.syncedAt}
+		vi.mu.Unlock()
+		if err := vi.index.Load().Save(path, info); err != nil {
+			vi.dirty.Store(true)
+			s.logger.Error("Failed to save vector index snapshot", err, map[string]interface{}{
+				"collection": collection.name,
+			})
+		}
+	}
+}
+
+// snapshotPath returns the snapshot file of vi, or "" when snapshots are disabled
+func (s *Service) snapshotPath(vi *vectorIndex) string {
+	if s.indexes.config.Path == "" {
+		return ""
+	}
+	return filepath.Join(s.indexes.config.Path, vi.collection.name+".hnsw")
+}
+
+// readyIndex returns the index of collection if it can serve searches, which it cannot while it
+// holds the vectors of a model that is no longer active
+func (s *Service) readyIndex(collection string) *vectorIndex {
+	if s.indexes == nil {
+		return nil
+	}
+	if vi := s.indexes.byCollection[collection]; vi != nil && vi.ready.Load() && vi.model.Load() == s.Model() {
+		return vi
+	}
+	return nil
+}
+
+// rebuildIndexes has the sync loop rebuild the indexes for the active model right away
+func (s *Service) rebuildIndexes() {
+	if s.indexes == nil {
+		return
+	}
+	select {
+	case s.indexes.rebuild <- struct{}{}:
+	default:
+	}
+}
+
+// indexVector adds a vector that was just written to the index of collection
+func (s *Service) indexVector(collection string, id, owner primitive.ObjectID, vector []float64) {
+	if s.indexes == nil {
+		return
+	}
+	vi := s.indexes.byCollection[collection]
+	if vi.index.Load().Add(id.Hex(), owner.Hex(), vector) {
+		vi.dirty.Store(true)
+	}
+}
+
+// unindexOwner removes the vectors of owner from the index of collection
+func (s *Service) unindexOwner(collection string, owner primitive.ObjectID) {
+	if s.indexes == nil {
+		return
+	}
+	vi := s.indexes.byCollection[collection]
+	vi.index.Load().RemoveOwner(owner.Hex())
+	vi.dirty.Store(true)
+}
+
+// loadFunc reads the records of index hits from MongoDB and returns their results in the order of
+// the hits. Records that are gone or do not match the search filter are left out.
+type loadFunc func(ctx context.Context, hits []vectorindex.Hit, filter bson.M) ([]SearchResult, error)
+
+// indexSearch searches vi for the records most similar to query that match filter, a query on
+// ownerCollection, the collection of the records the vectors belong to. A selective filter is
+// applied first, restricting the index search to the records it matches; otherwise the index
+// results are filtered as they are read, fetching more of them until enough match.
+func (s *Service) indexSearch(ctx context.Context, vi *vectorIndex, query []float64, ownerCollection string, filter bson.M, searchOptions *SearchOptions, load loadFunc) ([]SearchResult, error) {
+	var owners map[string]bool
+	if len(searchOptions.Filters) > 0 || len(searchOptions.WorkspaceIDs) > 0 ||
+		(searchOptions.Access != nil && ownerCollection == "documents") {
+		var err error
+		owners, err = s.filterOwners(ctx, ownerCollection, filter)
+		if err != nil {
+			return nil, err
+		}
+		if owners != nil && len(owners) == 0 {
+			return nil, nil
+		}
+	}
+
+	index := vi.index.Load()
+	for k := max(2*searchOptions.Limit, 20); ; k *= 4 {
+		hits := index.Search(query, k, owners)
+		exhausted := len(hits) < k
+		for i, hit := range hits {
+			if hit.Score < searchOptions.Threshold {
+				hits, exhausted = hits[:i], true
+				break
+			}
+		}
+
+		results, err := load(ctx, hits, filter)
+		if err != nil {
+			return nil, err
+		}
+		if len(results) >= searchOptions.Limit || exhausted || k >= maxCandidates {
+			if len(results) > searchOptions.Limit {
+				results = results[:searchOptions.Limit]
+			}
+			return results, nil
+		}
+	}
+}
+
+// filterOwners returns the IDs of the records of collection matching filter, or nil when it
+// matches too many for the search to be restricted to them
+func (s *Service) filterOwners(ctx context.Context, collection string, filter bson.M) (map[string]bool, error) {
+	findOptions := options.Find().SetProjection(bson.M{"_id": 1}).SetLimit(preFilterLimit + 1)
+	cursor, err := s.mongodb.Collection(collection).Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply search filters: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	owners := make(map[string]bool)
+	for cursor.Next(ctx) {
+		if id, ok := cursor.Current.Lookup("_id").ObjectIDOK(); ok {
+			owners[id.Hex()] = true
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to apply search filters: %w", err)
+	}
+	if len(owners) > preFilterLimit {
+		return nil, nil
+	}
+	return owners, nil
+}
+
+// hitIDs returns the IDs, or with owners set the owner IDs, of hits
+func hitIDs(hits []vectorindex.Hit, owners bool) []primitive.ObjectID {
+	seen := make(map[string]bool, len(hits))
+	var ids []primitive.ObjectID
+	for _, hit := range hits {
+		hex := hit.ID
+		if owners {
+			hex = hit.Owner
+		}
+		if seen[hex] {
+			continue
+		}
+		seen[hex] = true
+		if id, err := primitive.ObjectIDFromHex(hex); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// withIDs returns filter restricted to the records with ids
+func withIDs(filter bson.M, ids []primitive.ObjectID) bson.M {
+	restricted := bson.M{}
+	for key, value := range filter {
+		restricted[key] = value
+	}
+	existing, ok := filter["_id"]
+	if !ok {
+		restricted["_id"] = bson.M{"$in": ids}
+		return restricted
+	}
+
+	// Keep the filter's own condition on the ID, e.g. one excluding the record searched from
+	conditions, _ := filter["$and"].([]bson.M)
+	conditions = append(append([]bson.M(nil), conditions...), bson.M{"_id": existing}, bson.M{"_id": bson.M{"$in": ids}})
+	restricted["$and"] = conditions
+	delete(restricted, "_id")
+	return restricted
+}
+
+// loadDocuments reads the documents of index hits
+func (s *Service) loadDocuments(searchOptions *SearchOptions) loadFunc {
+	return func(ctx context.Context, hits []vectorindex.Hit, filter bson.M) ([]SearchResult, error) {
+		if len(hits) == 0 {
+			return nil, nil
+		}
+		cursor, err := s.mongodb.Collection("documents").Find(ctx, withIDs(filter, hitIDs(hits, false)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read documents: %w", err)
+		}
+		var docs []models.Document
+		if err := cursor.All(ctx, &docs); err != nil {
+			return nil, fmt.Errorf("failed to decode documents: %w", err)
+		}
+
+		byID := make(map[string]*models.Document, len(docs))
+		for i := range docs {
+			byID[docs[i].ID.Hex()] = &docs[i]
+		}
+		var results []SearchResult
+		for _, hit := range hits {
+			if doc, ok := byID[hit.ID]; ok {
+				results = append(results, documentResult(doc, hit.Score, searchOptions))
+			}
+		}
+		return results, nil
+	}
+}
+
+// loadKnowledgeItems reads the knowledge items of index hits
+func (s *Service) loadKnowledgeItems(searchOptions *SearchOptions) loadFunc {
+	return func(ctx context.Context, hits []vectorindex.Hit, filter bson.M) ([]SearchResult, error) {
+		if len(hits) == 0 {
+			return nil, nil
+		}
+		cursor, err := s.mongodb.Collection("knowledge_items").Find(ctx, withIDs(filter, hitIDs(hits, false)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read knowledge items: %w", err)
+		}
+		var items []models.KnowledgeItem
+		if err := cursor.All(ctx, &items); err != nil {
+			return nil, fmt.Errorf("failed to decode knowledge items: %w", err)
+		}
+
+		byID := make(map[string]*models.KnowledgeItem, len(items))
+		for i := range items {
+			byID[items[i].ID.Hex()] = &items[i]
+		}
+		var results []SearchResult
+		for _, hit := range hits {
+			if item, ok := byID[hit.ID]; ok {
+				results = append(results, knowledgeResult(item, hit.Score))
+			}
+		}
+		return results, nil
+	}
+}
+
+// loadChunks reads the passages of index hits together with their documents; filter applies to
+// the documents
+func (s *Service) loadChunks(searchOptions *SearchOptions) loadFunc {
+	return func(ctx context.Context, hits []vectorindex.Hit, filter bson.M) ([]SearchResult, error) {
+		if len(hits) == 0 {
+			return nil, nil
+		}
+
+		cursor, err := s.mongodb.Collection("documents").Find(ctx, withIDs(filter, hitIDs(hits, true)),
+			options.Find().SetProjection(chunkDocumentProjection(searchOptions)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read documents: %w", err)
+		}
+		var docs []models.Document
+		if err := cursor.All(ctx, &docs); err != nil {
+			return nil, fmt.Errorf("failed to decode documents: %w", err)
+		}
+		documents := make(map[string]models.Document, len(docs))
+		for _, doc := range docs {
+			// Unredacted content is only needed to restore the passages of documents with personal data
+			if doc.PIICount == 0 {
+				doc.Content = ""
+			}
+			documents[doc.ID.Hex()] = doc
+		}
+
+		var chunkIDs []primitive.ObjectID
+		for _, hit := range hits {
+			if _, ok := documents[hit.Owner]; ok {
+				if id, err := primitive.ObjectIDFromHex(hit.ID); err == nil {
+					chunkIDs = append(chunkIDs, id)
+				}
+			}
+		}
+		if len(chunkIDs) == 0 {
+			return nil, nil
+		}
+		cursor, err = s.mongodb.Collection("document_chunks").Find(ctx, bson.M{"_id": bson.M{"$in": chunkIDs}},
+			options.Find().SetProjection(bson.M{"embeddings": 0, "shadow_embeddings": 0}))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read document chunks: %w", err)
+		}
+		var chunks []models.DocumentChunk
+		if err := cursor.All(ctx, &chunks); err != nil {
+			return nil, fmt.Errorf("failed to decode document chunks: %w", err)
+		}
+		byID := make(map[string]models.DocumentChunk, len(chunks))
+		for _, chunk := range chunks {
+			byID[chunk.ID.Hex()] = chunk
+		}
+
+		var results []SearchResult
+		for _, hit := range hits {
+			chunk, ok := byID[hit.ID]
+			if !ok {
+				continue
+			}
+			doc := documents[hit.Owner] // a copy for each passage, which the result keeps
+			results = append(results, chunkResult(chunk, &doc, hit.Score, searchOptions))
+		}
+		return results, nil
+	}
+}
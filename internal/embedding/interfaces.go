@@ -3,6 +3,9 @@ package embedding
 import (
 	"context"
 
+	"ai-government-consultant/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -12,9 +15,39 @@ type EmbeddingService interface {
 	GenerateKnowledgeEmbedding(ctx context.Context, knowledgeID primitive.ObjectID) error
 }
 
+// MigrationService defines the embedding operations of model migrations
+type MigrationService interface {
+	Model() string
+	ShadowModel() string
+	ResolveModel(model string) (string, error)
+	LoadModels(ctx context.Context) error
+	SetShadowModel(ctx context.Context, migrationID primitive.ObjectID, model string) error
+	ActivateShadowModel(ctx context.Context, migrationID primitive.ObjectID, model string) error
+	ClearShadowModel(ctx context.Context, migrationID primitive.ObjectID) error
+	EmbedShadow(ctx context.Context, collection string, id primitive.ObjectID) error
+}
+
 // EmbeddingRepository defines the interface for embedding repository operations
 type EmbeddingRepository interface {
 	GetDocumentsWithoutEmbeddings(ctx context.Context, limit int) ([]primitive.ObjectID, error)
 	GetKnowledgeItemsWithoutEmbeddings(ctx context.Context, limit int) ([]primitive.ObjectID, error)
 	GetEmbeddingStats(ctx context.Context) (*EmbeddingStats, error)
 }
+
+// MigrationRepository defines the repository operations of model migrations
+type MigrationRepository interface {
+	StampVectorModels(ctx context.Context, model string) error
+	CountVectors(ctx context.Context, collection string) (int64, error)
+	GetRecordsWithoutShadow(ctx context.Context, collection, model string, after *primitive.ObjectID, limit int) ([]primitive.ObjectID, error)
+	CountRecordsWithoutShadow(ctx context.Context, collection, model string) (int64, error)
+	PromoteShadowVectors(ctx context.Context, model string) error
+	ClearShadowVectors(ctx context.Context, model string) error
+	CreateMigration(ctx context.Context, migration *models.EmbeddingMigration) error
+	GetMigration(ctx context.Context, id primitive.ObjectID) (*models.EmbeddingMigration, error)
+	ListMigrations(ctx context.Context, limit int) ([]*models.EmbeddingMigration, error)
+	CountOpenMigrations(ctx context.Context) (int64, error)
+	TransitionMigration(ctx context.Context, id primitive.ObjectID, from []models.EmbeddingMigrationStatus, to models.EmbeddingMigrationStatus, fields bson.M) (bool, error)
+	SaveMigrationProgress(ctx context.Context, migration *models.EmbeddingMigration) error
+	SetMigrationActive(ctx context.Context, id primitive.ObjectID, active bool) error
+	ReleasePausedMigration(ctx context.Context, id primitive.ObjectID) (bool, error)
+}
@@ -0,0 +1,385 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"ai-government-consultant/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BM25 parameters: k1 limits how much repeating a term adds, b how much long texts are penalized
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+const (
+	// maxQueryTerms bounds the terms of a query that are scored
+	maxQueryTerms = 16
+
+	// corpusStatsTTL is how long collection statistics and term frequencies are reused
+	corpusStatsTTL = 10 * time.Minute
+)
+
+// lexicalHit is a result of the lexical search with the text it was scored on
+type lexicalHit struct {
+	result SearchResult
+	text   string
+	score  float64
+	terms  map[string]float64 // BM25 score of each matched query term
+}
+
+// corpusStats holds the statistics of a collection that BM25 needs
+type corpusStats struct {
+	count     int64
+	avgLength float64          // mean length in bytes of the scored text
+	df        map[string]int64 // number of records containing each term
+}
+
+// cachedStats are the statistics of a collection, kept until they expire
+type cachedStats struct {
+	mu      sync.Mutex
+	stats   corpusStats
+	expires time.Time
+}
+
+// lexicalStats caches the corpus statistics of the searched collections
+type lexicalStats struct {
+	mu          sync.Mutex
+	collections map[string]*cachedStats
+}
+
+// queryTerms returns the distinct terms of a query, leaving out stop words
+func queryTerms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, term := range lexicalTokens(query) {
+		if stopWords[term] || seen[term] {
+			continue
+		}
+		seen[term] = true
+		terms = append(terms, term)
+		if len(terms) == maxQueryTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// lexicalTokens splits text into lowercase terms. Periods, hyphens and slashes between letters or
+// digits stay part of a term, so that citations such as "200.318", "COVID-19" or "A/B" match as a
+// whole. A plural "s" is dropped from longer words.
+func lexicalTokens(text string) []string {
+	runes := []rune(strings.ToLower(text))
+	isWord := func(i int) bool {
+		return i >= 0 && i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]))
+	}
+
+	var tokens []string
+	start := -1
+	for i := 0; i <= len(runes); i++ {
+		joined := i < len(runes) && (runes[i] == '.' || runes[i] == '-' || runes[i] == '/') && isWord(i-1) && isWord(i+1)
+		if isWord(i) || (start >= 0 && joined) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, normalizeTerm(string(runes[start:i])))
+			start = -1
+		}
+	}
+	return tokens
+}
+
+// normalizeTerm folds simple English plurals, so "obligations" matches "obligation"
+func normalizeTerm(term string) string {
+	if len(term) > 4 && strings.HasSuffix(term, "s") && !strings.HasSuffix(term, "ss") {
+		for _, r := range term {
+			if !unicode.IsLetter(r) {
+				return term
+			}
+		}
+		return term[:len(term)-1]
+	}
+	return term
+}
+
+// stats returns the corpus statistics of collection, counting the records that contain each of
+// terms. Statistics are reused for corpusStatsTTL.
+func (s *Service) stats(ctx context.Context, collection, field string, terms []string) (*corpusStats, error) {
+	s.lexical.mu.Lock()
+	cached := s.lexical.collections[collection]
+	if cached == nil {
+		cached = &cachedStats{}
+		s.lexical.collections[collection] = cached
+	}
+	s.lexical.mu.Unlock()
+
+	cached.mu.Lock()
+	defer cached.mu.Unlock()
+
+	if time.Now().After(cached.expires) {
+		cursor, err := s.mongodb.Collection(collection).Aggregate(ctx, []bson.M{
+			{"$group": bson.M{
+				"_id":    nil,
+				"count":  bson.M{"$sum": 1},
+				"length": bson.M{"$avg": bson.M{"$strLenBytes": bson.M{"$ifNull": bson.A{"$" + field, ""}}}},
+			}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute %s statistics: %w", collection, err)
+		}
+		var totals []struct {
+			Count  int64   `bson:"count"`
+			Length float64 `bson:"length"`
+		}
+		if err := cursor.All(ctx, &totals); err != nil {
+			return nil, fmt.Errorf("failed to decode %s statistics: %w", collection, err)
+		}
+
+		cached.stats = corpusStats{df: make(map[string]int64)}
+		if len(totals) > 0 {
+			cached.stats.count, cached.stats.avgLength = totals[0].Count, totals[0].Length
+		}
+		cached.expires = time.Now().Add(corpusStatsTTL)
+	}
+
+	stats := &corpusStats{
+		count:     cached.stats.count,
+		avgLength: cached.stats.avgLength,
+		df:        make(map[string]int64, len(terms)),
+	}
+	for _, term := range terms {
+		count, ok := cached.stats.df[term]
+		if !ok {
+			// A phrase search matches the term as written, including the punctuation inside it
+			var err error
+			count, err = s.mongodb.Collection(collection).CountDocuments(ctx, bson.M{
+				"$text": bson.M{"$search": `"` + term + `"`},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to count %s containing %q: %w", collection, term, err)
+			}
+			cached.stats.df[term] = count
+		}
+		stats.df[term] = count
+	}
+	return stats, nil
+}
+
+// scoreBM25 scores the hits against terms with the statistics of their collection, and drops the
+// hits matching none of them
+func scoreBM25(hits []lexicalHit, terms []string, stats *corpusStats) []lexicalHit {
+	scored := hits[:0]
+	for _, hit := range hits {
+		frequencies := make(map[string]int)
+		for _, token := range lexicalTokens(hit.text) {
+			frequencies[token]++
+		}
+
+		length := float64(len(hit.text))
+		avgLength := stats.avgLength
+		if avgLength <= 0 {
+			avgLength = length
+		}
+		norm := 1.0
+		if avgLength > 0 {
+			norm = 1 - bm25B + bm25B*length/avgLength
+		}
+
+		hit.terms = make(map[string]float64)
+		hit.score = 0
+		for _, term := range terms {
+			tf := float64(frequencies[term])
+			if tf == 0 {
+				continue
+			}
+			// Records found by the text index count towards the frequency even if the count missed them
+			df := math.Max(float64(stats.df[term]), 1)
+			n := math.Max(float64(stats.count), df)
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			score := idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+			hit.terms[term] = score
+			hit.score += score
+		}
+		if hit.score > 0 {
+			scored = append(scored, hit)
+		}
+	}
+	return scored
+}
+
+// lexicalSearch finds the passages, legacy documents and knowledge items containing the terms of
+// query, ranked by BM25. The text index finds the candidates; options select the collections and
+// filter them as in VectorSearch.
+func (s *Service) lexicalSearch(ctx context.Context, terms []string, searchOptions *SearchOptions, limit int) ([]lexicalHit, error) {
+	search := strings.Join(terms, " ")
+	var hits []lexicalHit
+
+	if searchOptions.Collection == "" || searchOptions.Collection == "documents" {
+		chunks, err := s.lexicalChunks(ctx, search, searchOptions, limit)
+		if err != nil {
+			return nil, err
+		}
+		stats, err := s.stats(ctx, "document_chunks", "content", terms)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, scoreBM25(chunks, terms, stats)...)
+
+		// Documents embedded before chunking was introduced are matched as a whole
+		legacyOptions := *searchOptions
+		legacyOptions.Filters = map[string]interface{}{"chunk_count": bson.M{"$exists": false}}
+		for key, value := range searchOptions.Filters {
+			legacyOptions.Filters[key] = value
+		}
+		docs, err := s.lexicalDocuments(ctx, search, &legacyOptions, limit)
+		if err != nil {
+			return nil, err
+		}
+		if len(docs) > 0 {
+			stats, err := s.stats(ctx, "documents", "content", terms)
+			if err != nil {
+				return nil, err
+			}
+			hits = append(hits, scoreBM25(docs, terms, stats)...)
+		}
+	}
+
+	if searchOptions.Collection == "" || searchOptions.Collection == "knowledge_items" {
+		items, err := s.lexicalKnowledgeItems(ctx, search, searchOptions, limit)
+		if err != nil {
+			return nil, err
+		}
+		stats, err := s.stats(ctx, "knowledge_items", "content", terms)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, scoreBM25(items, terms, stats)...)
+	}
+
+	sortLexicalHits(hits)
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// lexicalChunks returns the passages the text index matches with search, joined with their
+// documents
+func (s *Service) lexicalChunks(ctx context.Context, search string, searchOptions *SearchOptions, limit int) ([]lexicalHit, error) {
+	// Passages of filtered out documents are dropped after the join, so more are read first
+	pipeline := []bson.M{
+		{"$match": bson.M{"$text": bson.M{"$search": search}}},
+		{"$sort": bson.M{"score": bson.M{"$meta": "textScore"}}},
+		{"$limit": limit * 4},
+	}
+	pipeline = append(pipeline, joinDocumentStages(searchOptions)...)
+	pipeline = append(pipeline, bson.M{"$limit": limit})
+	pipeline = append(pipeline, chunkOutputStages(searchOptions)...)
+
+	cursor, err := s.mongodb.Collection("document_chunks").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute chunk text search: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var hits []lexicalHit
+	for cursor.Next(ctx) {
+		var hit struct {
+			models.DocumentChunk `bson:",inline"`
+			Document             models.Document `bson:"document"`
+		}
+		if err := cursor.Decode(&hit); err != nil {
+			s.logger.Error("Failed to decode chunk text search result", err, nil)
+			continue
+		}
+		hits = append(hits, lexicalHit{
+			text:   hit.DocumentChunk.Content,
+			result: chunkResult(hit.DocumentChunk, &hit.Document, 0, searchOptions),
+		})
+	}
+	return hits, cursor.Err()
+}
+
+// lexicalDocuments returns the documents the text index matches with search
+func (s *Service) lexicalDocuments(ctx context.Context, search string, searchOptions *SearchOptions, limit int) ([]lexicalHit, error) {
+	filter := documentFilter(searchOptions, "")
+	filter["$text"] = bson.M{"$search": search}
+	findOptions := options.Find().
+		SetSort(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetLimit(int64(limit))
+
+	cursor, err := s.mongodb.Collection("documents").Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute document text search: %w", err)
+	}
+	var docs []models.Document
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode document text search results: %w", err)
+	}
+
+	hits := make([]lexicalHit, 0, len(docs))
+	for i := range docs {
+		// Score the text the caller may see, so that matched terms cannot confirm redacted values;
+		// a document matched on those alone scores nothing and is dropped
+		content := docs[i].Content
+		if !searchOptions.Unredacted && docs[i].RedactedContent != "" {
+			content = docs[i].RedactedContent
+		}
+		text := docs[i].Name + "\n" + content
+		hits = append(hits, lexicalHit{
+			result: documentResult(&docs[i], 0, searchOptions),
+			text:   text,
+		})
+	}
+	return hits, nil
+}
+
+// lexicalKnowledgeItems returns the knowledge items the text index matches with search
+func (s *Service) lexicalKnowledgeItems(ctx context.Context, search string, searchOptions *SearchOptions, limit int) ([]lexicalHit, error) {
+	filter := knowledgeFilter(searchOptions)
+	filter["$text"] = bson.M{"$search": search}
+	findOptions := options.Find().
+		SetSort(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"embeddings": 0, "shadow_embeddings": 0})
+
+	cursor, err := s.mongodb.Collection("knowledge_items").Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute knowledge text search: %w", err)
+	}
+	var items []models.KnowledgeItem
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, fmt.Errorf("failed to decode knowledge text search results: %w", err)
+	}
+
+	hits := make([]lexicalHit, 0, len(items))
+	for i := range items {
+		text := items[i].Title + "\n" + items[i].Content
+		if items[i].Summary != nil {
+			text += "\n" + *items[i].Summary
+		}
+		hits = append(hits, lexicalHit{
+			result: knowledgeResult(&items[i], 0),
+			text:   text,
+		})
+	}
+	return hits, nil
+}
+
+// sortLexicalHits orders hits by BM25 score, highest first
+func sortLexicalHits(hits []lexicalHit) {
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].score > hits[j].score })
+}
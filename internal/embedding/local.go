@@ -0,0 +1,124 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// defaultLocalDimension is the vector dimension of the local embedder when none is configured
+const defaultLocalDimension = 384
+
+// Feature weights of the local embedder. Whole words carry the meaning; word pairs add some
+// phrase sense, and character trigrams let inflections and misspellings of a word still match.
+const (
+	wordWeight    = 1.0
+	bigramWeight  = 0.7
+	trigramWeight = 0.25
+	stopWeight    = 0.1 // function words; other common words keep their full weight
+)
+
+// stopWords are function words that say little about what a text is about
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"been": true, "but": true, "by": true, "for": true, "from": true, "has": true, "have": true,
+	"in": true, "is": true, "it": true, "its": true, "of": true, "on": true, "or": true,
+	"shall": true, "that": true, "the": true, "this": true, "to": true, "was": true, "were": true,
+	"which": true, "will": true, "with": true,
+}
+
+// LocalProvider embeds text on the CPU without any network access. Each text is broken into
+// words, word pairs and character trigrams, weighted by sublinear term frequency times a fixed
+// weight per kind of feature, and hashed into a vector of a fixed dimension with a random sign
+// per feature. The result is normalized to unit length.
+//
+// The weighting is not TF-IDF: no corpus document frequencies are kept, so that the same text
+// always gives the same vector whatever else is stored. Only the stop words are down-weighted,
+// and words common across the corpus count as much as rare ones. It suits air-gapped deployments
+// and tests; its results rank by shared vocabulary rather than meaning.
+type LocalProvider struct {
+	dimension int
+}
+
+// NewLocalProvider creates a local embedder producing vectors of dimension, defaulting to 384
+func NewLocalProvider(dimension int) *LocalProvider {
+	if dimension <= 0 {
+		dimension = defaultLocalDimension
+	}
+	return &LocalProvider{dimension: dimension}
+}
+
+// Name returns "local/ngram-hash-" followed by the dimension; vectors of other dimensions are
+// not comparable
+func (p *LocalProvider) Name() string {
+	return fmt.Sprintf("local/ngram-hash-%d", p.dimension)
+}
+
+// Embed returns the hashed n-gram vector of text. Text without any words embeds to one fixed
+// vector rather than the zero vector, which has no cosine similarity.
+func (p *LocalProvider) Embed(ctx context.Context, text string) ([]float64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	weights := make(map[string]float64)
+	counts := make(map[string]int)
+	add := func(feature string, weight float64) {
+		counts[feature]++
+		weights[feature] = weight
+	}
+	for i, word := range words {
+		weight := wordWeight
+		if stopWords[word] {
+			weight = stopWeight
+		}
+		add("w:"+word, weight)
+
+		if i > 0 && !stopWords[word] && !stopWords[words[i-1]] {
+			add("b:"+words[i-1]+" "+word, bigramWeight)
+		}
+
+		if !stopWords[word] {
+			padded := []rune("#" + word + "#")
+			for j := 0; j+3 <= len(padded); j++ {
+				add("t:"+string(padded[j:j+3]), trigramWeight)
+			}
+		}
+	}
+
+	if len(counts) == 0 {
+		add("empty", wordWeight)
+	}
+
+	vector := make([]float64, p.dimension)
+	for feature, count := range counts {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+
+		value := (1 + math.Log(float64(count))) * weights[feature]
+		if sum>>63 == 1 {
+			value = -value
+		}
+		vector[sum%uint64(p.dimension)] += value
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for i := range vector {
+			vector[i] /= norm
+		}
+	}
+	return vector, nil
+}
@@ -0,0 +1,494 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-government-consultant/internal/models"
+	"ai-government-consultant/internal/queue"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MigrationJob runs the embedding migration whose ID is the job key
+const MigrationJob = "embedding.migrate"
+
+// maxMigrationErrors bounds the record errors kept on a migration; every failure still counts
+const maxMigrationErrors = 50
+
+// migratedCollections are the collections a migration walks through. The passages in
+// document_chunks are re-embedded with their documents.
+var migratedCollections = []string{"documents", "knowledge_items"}
+
+// StartMigration starts re-embedding every stored vector with target, e.g. "openai/bge-m3", into
+// shadow vectors. Searches keep using the active model until CutOverMigration.
+func (p *Pipeline) StartMigration(ctx context.Context, target string, startedBy primitive.ObjectID) (*models.EmbeddingMigration, error) {
+	if p.jobs == nil {
+		return nil, ErrMigrationUnsupported
+	}
+	if err := p.models.LoadModels(ctx); err != nil {
+		return nil, err
+	}
+	target, err := p.models.ResolveModel(strings.TrimSpace(target))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load embedding model: %w", err)
+	}
+	source := p.models.Model()
+	if target == source {
+		return nil, ErrMigrationSameModel
+	}
+	open, err := p.migrations.CountOpenMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if open > 0 {
+		return nil, ErrMigrationInProgress
+	}
+
+	// Vectors stored before their model was recorded get the source model, so that they are never
+	// taken for the target's, and shadow vectors left by a cancelled migration to the same model
+	// are dropped, since they may be older than their records
+	if err := p.migrations.StampVectorModels(ctx, source); err != nil {
+		return nil, err
+	}
+	if err := p.migrations.ClearShadowVectors(ctx, target); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	migration := &models.EmbeddingMigration{
+		ID:          primitive.NewObjectID(),
+		SourceModel: source,
+		TargetModel: target,
+		Status:      models.EmbeddingMigrationRunning,
+		StartedBy:   startedBy,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	for _, collection := range migratedCollections {
+		total, err := p.migrations.CountVectors(ctx, collection)
+		if err != nil {
+			return nil, err
+		}
+		migration.Collections = append(migration.Collections, models.EmbeddingMigrationProgress{
+			Collection: collection,
+			Total:      total,
+			Pass:       1,
+		})
+	}
+	if err := p.migrations.CreateMigration(ctx, migration); err != nil {
+		return nil, err
+	}
+
+	if err := p.models.SetShadowModel(ctx, migration.ID, target); err != nil {
+		p.migrations.TransitionMigration(ctx, migration.ID, []models.EmbeddingMigrationStatus{models.EmbeddingMigrationRunning},
+			models.EmbeddingMigrationCancelled, bson.M{"finished_at": time.Now()})
+		return nil, err
+	}
+	if _, err := p.jobs.Enqueue(ctx, MigrationJob, migration.ID.Hex()); err != nil {
+		return nil, fmt.Errorf("failed to queue embedding migration: %w", err)
+	}
+
+	p.logger.Info("Started embedding migration", map[string]interface{}{
+		"migration_id": migration.ID.Hex(),
+		"source_model": source,
+		"target_model": target,
+	})
+	return migration, nil
+}
+
+// GetMigration retrieves an embedding migration with its progress
+func (p *Pipeline) GetMigration(ctx context.Context, id primitive.ObjectID) (*models.EmbeddingMigration, error) {
+	if p.jobs == nil {
+		return nil, ErrMigrationUnsupported
+	}
+	return p.migrations.GetMigration(ctx, id)
+}
+
+// ListMigrations lists the latest embedding migrations, newest first
+func (p *Pipeline) ListMigrations(ctx context.Context, limit int) ([]*models.EmbeddingMigration, error) {
+	if p.jobs == nil {
+		return nil, ErrMigrationUnsupported
+	}
+	return p.migrations.ListMigrations(ctx, limit)
+}
+
+// PauseMigration stops a running migration after the batch in progress; it keeps its progress.
+// Shadow vectors are still written for records embedded while it is paused.
+func (p *Pipeline) PauseMigration(ctx context.Context, id primitive.ObjectID) (*models.EmbeddingMigration, error) {
+	return p.transitionMigration(ctx, id, []models.EmbeddingMigrationStatus{models.EmbeddingMigrationRunning},
+		models.EmbeddingMigrationPaused, nil)
+}
+
+// ResumeMigration continues a paused migration where it stopped
+func (p *Pipeline) ResumeMigration(ctx context.Context, id primitive.ObjectID) (*models.EmbeddingMigration, error) {
+	migration, err := p.transitionMigration(ctx, id, []models.EmbeddingMigrationStatus{models.EmbeddingMigrationPaused},
+		models.EmbeddingMigrationRunning, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// A worker that has not stopped yet goes on with the migration
+	if err := p.runMigration(ctx, migration, time.Now()); err != nil {
+		return nil, err
+	}
+	return migration, nil
+}
+
+// CancelMigration abandons a migration before its cutover. Its shadow vectors are removed in the
+// background and the active model stays as it is.
+func (p *Pipeline) CancelMigration(ctx context.Context, id primitive.ObjectID) (*models.EmbeddingMigration, error) {
+	migration, err := p.transitionMigration(ctx, id, []models.EmbeddingMigrationStatus{
+		models.EmbeddingMigrationRunning,
+		models.EmbeddingMigrationPaused,
+		models.EmbeddingMigrationReady,
+	}, models.EmbeddingMigrationCancelled, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.models.ClearShadowModel(ctx, id); err != nil {
+		return nil, err
+	}
+	if err := p.runMigration(ctx, migration, time.Now()); err != nil {
+		return nil, err
+	}
+
+	p.logger.Info("Cancelled embedding migration", map[string]interface{}{
+		"migration_id": id.Hex(),
+		"target_model": migration.TargetModel,
+	})
+	return migration, nil
+}
+
+// CutOverMigration makes the target model of a ready migration the active model of every server
+// at once; searches then compare only with its vectors. Records still without a vector of the
+// target model would drop out of searches, so the cutover is refused while there are any unless
+// force is set. Once every server has loaded the new model the shadow vectors replace the
+// previous model's.
+func (p *Pipeline) CutOverMigration(ctx context.Context, id primitive.ObjectID, force bool) (*models.EmbeddingMigration, error) {
+	if p.jobs == nil {
+		return nil, ErrMigrationUnsupported
+	}
+	migration, err := p.migrations.GetMigration(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if migration.Status != models.EmbeddingMigrationReady {
+		return nil, ErrMigrationState
+	}
+
+	// Records may have been written since the migration got ready
+	var remaining int64
+	for i := range migration.Collections {
+		progress := &migration.Collections[i]
+		if progress.Remaining, err = p.migrations.CountRecordsWithoutShadow(ctx, progress.Collection, migration.TargetModel); err != nil {
+			return nil, err
+		}
+		remaining += progress.Remaining
+	}
+	if err := p.migrations.SaveMigrationProgress(ctx, migration); err != nil {
+		return nil, err
+	}
+	if remaining > 0 && !force {
+		return nil, fmt.Errorf("%w: %d records", ErrMigrationIncomplete, remaining)
+	}
+
+	if err := p.models.ActivateShadowModel(ctx, id, migration.TargetModel); err != nil {
+		return nil, err
+	}
+	migration, err = p.transitionMigration(ctx, id, []models.EmbeddingMigrationStatus{models.EmbeddingMigrationReady},
+		models.EmbeddingMigrationCutOver, bson.M{"cut_over_at": time.Now()})
+	if err != nil {
+		return nil, err
+	}
+	if err := p.runMigration(ctx, migration, time.Now().Add(p.promotionDelay)); err != nil {
+		return nil, err
+	}
+
+	p.logger.Info("Cut over to a new embedding model", map[string]interface{}{
+		"migration_id":   id.Hex(),
+		"previous_model": migration.SourceModel,
+		"model":          migration.TargetModel,
+		"remaining":      remaining,
+	})
+	return migration, nil
+}
+
+// transitionMigration moves a migration in one of the statuses from to status to and returns it
+func (p *Pipeline) transitionMigration(ctx context.Context, id primitive.ObjectID, from []models.EmbeddingMigrationStatus, to models.EmbeddingMigrationStatus, fields bson.M) (*models.EmbeddingMigration, error) {
+	if p.jobs == nil {
+		return nil, ErrMigrationUnsupported
+	}
+	moved, err := p.migrations.TransitionMigration(ctx, id, from, to, fields)
+	if err != nil {
+		return nil, err
+	}
+	migration, err := p.migrations.GetMigration(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !moved {
+		return nil, fmt.Errorf("%w: it is %s", ErrMigrationState, migration.Status)
+	}
+	return migration, nil
+}
+
+// runMigration queues the job of a migration at runAt, unless a worker is on it
+func (p *Pipeline) runMigration(ctx context.Context, migration *models.EmbeddingMigration, runAt time.Time) error {
+	open, err := p.jobs.HasOpenJob(ctx, MigrationJob, migration.ID.Hex())
+	if err != nil {
+		return fmt.Errorf("failed to check for embedding migration job: %w", err)
+	}
+	if open && migration.Active {
+		return nil
+	}
+	if _, err := p.jobs.EnqueueAt(ctx, MigrationJob, migration.ID.Hex(), runAt); err != nil {
+		return fmt.Errorf("failed to queue embedding migration: %w", err)
+	}
+	return nil
+}
+
+// migrationJob carries a migration forward from its current status: a running migration
+// re-embeds batch after batch until it is ready or paused, a cut over one has its shadow vectors
+// promoted, and a cancelled one has them removed.
+func (p *Pipeline) migrationJob(ctx context.Context, job *queue.Job) error {
+	id, err := primitive.ObjectIDFromHex(job.Key)
+	if err != nil {
+		return fmt.Errorf("invalid migration ID: %w", err)
+	}
+	if err := p.migrations.SetMigrationActive(ctx, id, true); err != nil {
+		return err
+	}
+	released := false
+	defer func() {
+		if !released {
+			if err := p.migrations.SetMigrationActive(context.Background(), id, false); err != nil {
+				p.logger.Error("Failed to release embedding migration", err, map[string]interface{}{
+					"migration_id": id.Hex(),
+				})
+			}
+		}
+	}()
+
+	for {
+		migration, err := p.migrations.GetMigration(ctx, id)
+		if errors.Is(err, ErrMigrationNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch migration.Status {
+		case models.EmbeddingMigrationRunning:
+			if err := p.migrateBatch(ctx, migration); err != nil {
+				return err
+			}
+		case models.EmbeddingMigrationPaused:
+			// Stop, unless the migration was resumed meanwhile
+			if released, err = p.migrations.ReleasePausedMigration(ctx, id); err != nil {
+				return err
+			}
+			if released {
+				p.logger.Info("Paused embedding migration", map[string]interface{}{
+					"migration_id": id.Hex(),
+					"progress":     migration.Progress(),
+				})
+				return nil
+			}
+		case models.EmbeddingMigrationCutOver:
+			return p.promoteMigration(ctx, migration)
+		case models.EmbeddingMigrationCancelled:
+			return p.discardMigration(ctx, migration)
+		default:
+			return nil
+		}
+	}
+}
+
+// migrateBatch re-embeds the next batch of records of a running migration and stores its
+// progress. Each collection is walked through twice: the second pass catches records written
+// without a shadow vector by servers that had not loaded the migration yet. Once both passes are
+// done everywhere the migration is ready for the cutover.
+func (p *Pipeline) migrateBatch(ctx context.Context, migration *models.EmbeddingMigration) error {
+	if p.models.ShadowModel() != migration.TargetModel {
+		if err := p.models.LoadModels(ctx); err != nil {
+			return err
+		}
+		if shadow := p.models.ShadowModel(); shadow != migration.TargetModel {
+			return fmt.Errorf("shadow embedding model is %q, not the migration's %s", shadow, migration.TargetModel)
+		}
+	}
+
+	for i := range migration.Collections {
+		progress := &migration.Collections[i]
+		if progress.Done {
+			continue
+		}
+
+		ids, err := p.migrations.GetRecordsWithoutShadow(ctx, progress.Collection, migration.TargetModel, progress.LastID, p.batchSize)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			if progress.Pass < 2 {
+				progress.Pass, progress.LastID = 2, nil
+			} else {
+				progress.Done = true
+			}
+			return p.migrations.SaveMigrationProgress(ctx, migration)
+		}
+
+		result := p.processShadowBatch(ctx, progress.Collection, ids)
+		progress.Processed += int64(result.Successful)
+		progress.Failed += int64(result.Failed)
+		progress.LastID = &ids[len(ids)-1]
+		migration.Errors = append(migration.Errors, result.Errors...)
+		if len(migration.Errors) > maxMigrationErrors {
+			migration.Errors = migration.Errors[len(migration.Errors)-maxMigrationErrors:]
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return p.migrations.SaveMigrationProgress(ctx, migration)
+	}
+
+	var remaining int64
+	for i := range migration.Collections {
+		progress := &migration.Collections[i]
+		count, err := p.migrations.CountRecordsWithoutShadow(ctx, progress.Collection, migration.TargetModel)
+		if err != nil {
+			return err
+		}
+		progress.Remaining = count
+		remaining += count
+	}
+	if err := p.migrations.SaveMigrationProgress(ctx, migration); err != nil {
+		return err
+	}
+	if _, err := p.migrations.TransitionMigration(ctx, migration.ID, []models.EmbeddingMigrationStatus{models.EmbeddingMigrationRunning},
+		models.EmbeddingMigrationReady, bson.M{"ready_at": time.Now()}); err != nil {
+		return err
+	}
+
+	p.logger.Info("Embedding migration ready for cutover", map[string]interface{}{
+		"migration_id": migration.ID.Hex(),
+		"target_model": migration.TargetModel,
+		"remaining":    remaining,
+	})
+	return nil
+}
+
+// promoteMigration replaces the previous model's vectors with the shadow vectors of a cut over
+// migration, completing it
+func (p *Pipeline) promoteMigration(ctx context.Context, migration *models.EmbeddingMigration) error {
+	if err := p.migrations.PromoteShadowVectors(ctx, migration.TargetModel); err != nil {
+		return err
+	}
+	if _, err := p.migrations.TransitionMigration(ctx, migration.ID, []models.EmbeddingMigrationStatus{models.EmbeddingMigrationCutOver},
+		models.EmbeddingMigrationCompleted, bson.M{"finished_at": time.Now()}); err != nil {
+		return err
+	}
+
+	p.logger.Info("Completed embedding migration", map[string]interface{}{
+		"migration_id": migration.ID.Hex(),
+		"model":        migration.TargetModel,
+	})
+	return nil
+}
+
+// discardMigration removes the shadow vectors of a cancelled migration
+func (p *Pipeline) discardMigration(ctx context.Context, migration *models.EmbeddingMigration) error {
+	if migration.FinishedAt != nil {
+		return nil
+	}
+	if err := p.models.ClearShadowModel(ctx, migration.ID); err != nil {
+		return err
+	}
+	if err := p.migrations.ClearShadowVectors(ctx, migration.TargetModel); err != nil {
+		return err
+	}
+	_, err := p.migrations.TransitionMigration(ctx, migration.ID, []models.EmbeddingMigrationStatus{models.EmbeddingMigrationCancelled},
+		models.EmbeddingMigrationCancelled, bson.M{"finished_at": time.Now()})
+	return err
+}
+
+// processShadowBatch writes the shadow vectors of a batch of records of collection with worker pool
+func (p *Pipeline) processShadowBatch(ctx context.Context, collection string, ids []primitive.ObjectID) *ProcessResult {
+	result := &ProcessResult{TotalProcessed: len(ids)}
+
+	// Create worker pool
+	jobs := make(chan primitive.ObjectID, len(ids))
+	results := make(chan error, len(ids))
+
+	var wg sync.WaitGroup
+
+	// Start workers
+	for i := 0; i < p.maxWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				results <- p.embedShadowWithRetry(ctx, collection, id)
+			}
+		}()
+	}
+
+	// Send jobs
+	for _, id := range ids {
+		jobs <- id
+	}
+	close(jobs)
+
+	// Wait for workers to complete
+	wg.Wait()
+	close(results)
+
+	// Collect results
+	for err := range results {
+		if err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, err.Error())
+		} else {
+			result.Successful++
+		}
+	}
+
+	return result
+}
+
+// embedShadowWithRetry writes the shadow vector of a single record with retry logic
+func (p *Pipeline) embedShadowWithRetry(ctx context.Context, collection string, id primitive.ObjectID) error {
+	var lastErr error
+
+	for attempt := 0; attempt <= p.retryAttempts; attempt++ {
+		if attempt > 0 {
+			// Wait before retry
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(p.retryDelay):
+			}
+		}
+
+		err := p.models.EmbedShadow(ctx, collection, id)
+		if err == nil {
+			return nil // Success
+		}
+
+		lastErr = err
+		p.logger.Error("Failed to generate shadow embedding", err, map[string]interface{}{
+			"collection": collection,
+			"id":         id.Hex(),
+			"attempt":    attempt,
+		})
+	}
+
+	return fmt.Errorf("failed to migrate %s %s after %d attempts: %w",
+		collection, id.Hex(), p.retryAttempts+1, lastErr)
+}
@@ -0,0 +1,98 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIProvider embeds text with any server implementing OpenAI's /v1/embeddings API, such as
+// vLLM, Ollama, LocalAI or text-embeddings-inference
+type OpenAIProvider struct {
+	url        string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+type openAIEmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type openAIEmbeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+}
+
+// NewOpenAIProvider creates a provider for model on the server at baseURL, e.g.
+// http://localhost:8000/v1. apiKey is sent as a bearer token when not empty.
+func NewOpenAIProvider(baseURL, apiKey, model string) *OpenAIProvider {
+	url := strings.TrimSuffix(baseURL, "/")
+	if !strings.HasSuffix(url, "/embeddings") {
+		url += "/embeddings"
+	}
+
+	return &OpenAIProvider{
+		url:    url,
+		apiKey: apiKey,
+		model:  model,
+		httpClient: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}
+}
+
+// Name returns "openai/" followed by the model
+func (p *OpenAIProvider) Name() string {
+	return "openai/" + p.model
+}
+
+// Embed requests the embedding of text from the server
+func (p *OpenAIProvider) Embed(ctx context.Context, text string) ([]float64, error) {
+	requestBody, err := json.Marshal(openAIEmbeddingRequest{Model: p.model, Input: []string{text}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, ErrAPIRateLimited
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("%w: status %d: %s", ErrAPIRequestFailed, resp.StatusCode, string(body))
+	}
+
+	var response openAIEmbeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	for _, item := range response.Data {
+		if item.Index == 0 && len(item.Embedding) > 0 {
+			return item.Embedding, nil
+		}
+	}
+	return nil, ErrAPIResponseInvalid
+}
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"ai-government-consultant/internal/queue"
 	"ai-government-consultant/pkg/logger"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -17,6 +18,12 @@ type Pipeline struct {
 	repository EmbeddingRepository
 	logger     logger.Logger
 
+	// Model migrations; nil without a job queue
+	migrations     MigrationRepository
+	models         MigrationService
+	jobs           *queue.Queue
+	promotionDelay time.Duration
+
 	// Configuration
 	batchSize     int
 	maxWorkers    int
@@ -30,15 +37,24 @@ type PipelineConfig struct {
 	MaxWorkers    int
 	RetryAttempts int
 	RetryDelay    time.Duration
+
+	// Jobs runs embedding model migrations; they need a service and repository supporting them,
+	// as Service and Repository do
+	Jobs *queue.Queue
+
+	// PromotionDelay is how long after a cutover the shadow vectors replace the previous model's,
+	// giving every server time to load the new model (default: 2m)
+	PromotionDelay time.Duration
 }
 
 // DefaultPipelineConfig returns default pipeline configuration
 func DefaultPipelineConfig() *PipelineConfig {
 	return &PipelineConfig{
-		BatchSize:     50,
-		MaxWorkers:    5,
-		RetryAttempts: 3,
-		RetryDelay:    5 * time.Second,
+		BatchSize:      50,
+		MaxWorkers:     5,
+		RetryAttempts:  3,
+		RetryDelay:     5 * time.Second,
+		PromotionDelay: 2 * time.Minute,
 	}
 }
 
@@ -48,15 +64,29 @@ func NewPipeline(service EmbeddingService, repository EmbeddingRepository, logge
 		config = DefaultPipelineConfig()
 	}
 
-	return &Pipeline{
-		service:       service,
-		repository:    repository,
-		logger:        logger,
-		batchSize:     config.BatchSize,
-		maxWorkers:    config.MaxWorkers,
-		retryAttempts: config.RetryAttempts,
-		retryDelay:    config.RetryDelay,
+	p := &Pipeline{
+		service:        service,
+		repository:     repository,
+		logger:         logger,
+		promotionDelay: config.PromotionDelay,
+		batchSize:      config.BatchSize,
+		maxWorkers:     config.MaxWorkers,
+		retryAttempts:  config.RetryAttempts,
+		retryDelay:     config.RetryDelay,
+	}
+	if p.promotionDelay <= 0 {
+		p.promotionDelay = 2 * time.Minute
+	}
+
+	migrationService, serviceOK := service.(MigrationService)
+	migrationRepository, repositoryOK := repository.(MigrationRepository)
+	if config.Jobs != nil && serviceOK && repositoryOK {
+		p.models = migrationService
+		p.migrations = migrationRepository
+		p.jobs = config.Jobs
+		p.jobs.Handle(MigrationJob, p.migrationJob)
 	}
+	return p
 }
 
 // ProcessResult represents the result of processing embeddings
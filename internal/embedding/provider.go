@@ -0,0 +1,56 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// EmbeddingProvider turns text into a vector with an embedding model
+type EmbeddingProvider interface {
+	// Embed returns the embedding of text
+	Embed(ctx context.Context, text string) ([]float64, error)
+
+	// Name identifies the provider and model, e.g. "gemini/text-embedding-004". Vectors are only
+	// comparable with vectors of the same name.
+	Name() string
+}
+
+// ProviderConfig holds the settings of the embedding providers
+type ProviderConfig struct {
+	APIKey    string // Gemini API key, or bearer token of an OpenAI-compatible server
+	BaseURL   string // base URL of an OpenAI-compatible server, e.g. http://localhost:8000/v1
+	Dimension int    // vector dimension of the local embedder; defaults to 384
+}
+
+// NewProvider returns the provider selected by model, written "<provider>/<model>":
+// "gemini/text-embedding-004", "openai/<model>" for any OpenAI-compatible /v1/embeddings server,
+// or "local" for the offline embedder. A model without a provider is a Gemini model.
+func NewProvider(model string, config ProviderConfig) (EmbeddingProvider, error) {
+	provider, name := "gemini", strings.TrimSpace(model)
+	if prefix, rest, found := strings.Cut(name, "/"); found {
+		provider, name = prefix, rest
+	} else if name == "local" {
+		provider, name = "local", ""
+	}
+
+	switch provider {
+	case "gemini":
+		if config.APIKey == "" {
+			return nil, fmt.Errorf("embedding provider gemini requires an API key")
+		}
+		return NewGeminiProvider(config.APIKey, name, ""), nil
+	case "openai":
+		if config.BaseURL == "" {
+			return nil, fmt.Errorf("embedding provider openai requires a base URL")
+		}
+		if name == "" {
+			return nil, fmt.Errorf("embedding provider openai requires a model")
+		}
+		return NewOpenAIProvider(config.BaseURL, config.APIKey, name), nil
+	case "local":
+		return NewLocalProvider(config.Dimension), nil
+	default:
+		return nil, fmt.Errorf("unknown embedding provider %q", provider)
+	}
+}
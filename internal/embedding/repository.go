@@ -2,9 +2,12 @@ package embedding
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"ai-government-consultant/internal/models"
+
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -282,6 +285,13 @@ func (r *Repository) GetEmbeddingStats(ctx context.Context) (*EmbeddingStats, er
 	}
 	stats.TotalKnowledgeItems = totalKnowledge
 
+	// Count embedded document chunks
+	totalChunks, err := r.mongodb.Collection("document_chunks").CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count document chunks: %w", err)
+	}
+	stats.DocumentChunks = totalChunks
+
 	return stats, nil
 }
 
@@ -291,15 +301,17 @@ type EmbeddingStats struct {
 	TotalDocuments          int64 `json:"total_documents"`
 	KnowledgeWithEmbeddings int64 `json:"knowledge_with_embeddings"`
 	TotalKnowledgeItems     int64 `json:"total_knowledge_items"`
+	DocumentChunks          int64 `json:"document_chunks"`
 }
 
-// DeleteDocumentEmbeddings removes embeddings from a document
+// DeleteDocumentEmbeddings removes embeddings and embedded chunks from a document
 func (r *Repository) DeleteDocumentEmbeddings(ctx context.Context, documentID primitive.ObjectID) error {
 	collection := r.mongodb.Collection("documents")
 
 	update := bson.M{
 		"$unset": bson.M{
-			"embeddings": "",
+			"embeddings":  "",
+			"chunk_count": "",
 		},
 	}
 
@@ -312,6 +324,10 @@ func (r *Repository) DeleteDocumentEmbeddings(ctx context.Context, documentID pr
 		return fmt.Errorf("document not found: %s", documentID.Hex())
 	}
 
+	if _, err := r.mongodb.Collection("document_chunks").DeleteMany(ctx, bson.M{"document_id": documentID}); err != nil {
+		return fmt.Errorf("failed to delete document chunks: %w", err)
+	}
+
 	return nil
 }
 
@@ -339,3 +355,232 @@ func (r *Repository) DeleteKnowledgeEmbeddings(ctx context.Context, knowledgeID
 
 	return nil
 }
+
+// migrationCollection holds the embedding migrations
+const migrationCollection = "embedding_migrations"
+
+// vectorCollections are the collections holding vectors
+var vectorCollections = []string{"documents", "document_chunks", "knowledge_items"}
+
+// StampVectorModels records model and the dimension on the vectors stored before either was
+// recorded, so that they are never taken for another model's
+func (r *Repository) StampVectorModels(ctx context.Context, model string) error {
+	for _, name := range vectorCollections {
+		_, err := r.mongodb.Collection(name).UpdateMany(ctx,
+			bson.M{
+				"embeddings": bson.M{"$exists": true, "$ne": nil},
+				"$or": []bson.M{
+					{"embedding_model": nil},
+					{"embedding_dimension": bson.M{"$exists": false}},
+				},
+			},
+			mongo.Pipeline{{{Key: "$set", Value: bson.M{
+				"embedding_model":     bson.M{"$ifNull": bson.A{"$embedding_model", model}},
+				"embedding_dimension": bson.M{"$size": "$embeddings"},
+			}}}})
+		if err != nil {
+			return fmt.Errorf("failed to record the model of %s vectors: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// CountVectors counts the records of collection with a vector
+func (r *Repository) CountVectors(ctx context.Context, collection string) (int64, error) {
+	count, err := r.mongodb.Collection(collection).CountDocuments(ctx, bson.M{
+		"embeddings": bson.M{"$exists": true, "$ne": nil},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count %s vectors: %w", collection, err)
+	}
+	return count, nil
+}
+
+// withoutShadow matches the records of a collection with a vector but no shadow vector of model
+func withoutShadow(model string) bson.M {
+	return bson.M{
+		"embeddings":             bson.M{"$exists": true, "$ne": nil},
+		"shadow_embedding_model": bson.M{"$ne": model},
+	}
+}
+
+// GetRecordsWithoutShadow returns, in ID order, up to limit records of collection after the
+// record after that have a vector but no shadow vector of model
+func (r *Repository) GetRecordsWithoutShadow(ctx context.Context, collection, model string, after *primitive.ObjectID, limit int) ([]primitive.ObjectID, error) {
+	filter := withoutShadow(model)
+	if after != nil {
+		filter["_id"] = bson.M{"$gt": *after}
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"_id": 1})
+
+	cursor, err := r.mongodb.Collection(collection).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s to migrate: %w", collection, err)
+	}
+	defer cursor.Close(ctx)
+
+	var ids []primitive.ObjectID
+	for cursor.Next(ctx) {
+		if id, ok := cursor.Current.Lookup("_id").ObjectIDOK(); ok {
+			ids = append(ids, id)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to find %s to migrate: %w", collection, err)
+	}
+	return ids, nil
+}
+
+// CountRecordsWithoutShadow counts the records of collection with a vector but no shadow vector
+// of model
+func (r *Repository) CountRecordsWithoutShadow(ctx context.Context, collection, model string) (int64, error) {
+	count, err := r.mongodb.Collection(collection).CountDocuments(ctx, withoutShadow(model))
+	if err != nil {
+		return 0, fmt.Errorf("failed to count %s to migrate: %w", collection, err)
+	}
+	return count, nil
+}
+
+// PromoteShadowVectors replaces the vectors of the records with a shadow vector of model by that
+// shadow vector. Each record is updated on its own, and searches for model match its vector
+// before and after.
+func (r *Repository) PromoteShadowVectors(ctx context.Context, model string) error {
+	for _, name := range vectorCollections {
+		_, err := r.mongodb.Collection(name).UpdateMany(ctx,
+			bson.M{"shadow_embedding_model": model},
+			mongo.Pipeline{
+				{{Key: "$set", Value: bson.M{
+					"embeddings":          "$shadow_embeddings",
+					"embedding_model":     "$shadow_embedding_model",
+					"embedding_dimension": "$shadow_embedding_dimension",
+				}}},
+				{{Key: "$unset", Value: bson.A{"shadow_embeddings", "shadow_embedding_model", "shadow_embedding_dimension"}}},
+			})
+		if err != nil {
+			return fmt.Errorf("failed to promote %s shadow vectors: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// ClearShadowVectors removes the shadow vectors of model
+func (r *Repository) ClearShadowVectors(ctx context.Context, model string) error {
+	for _, name := range vectorCollections {
+		_, err := r.mongodb.Collection(name).UpdateMany(ctx,
+			bson.M{"shadow_embedding_model": model},
+			bson.M{"$unset": bson.M{"shadow_embeddings": "", "shadow_embedding_model": "", "shadow_embedding_dimension": ""}})
+		if err != nil {
+			return fmt.Errorf("failed to remove %s shadow vectors: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// CreateMigration stores a new embedding migration
+func (r *Repository) CreateMigration(ctx context.Context, migration *models.EmbeddingMigration) error {
+	if _, err := r.mongodb.Collection(migrationCollection).InsertOne(ctx, migration); err != nil {
+		return fmt.Errorf("failed to create embedding migration: %w", err)
+	}
+	return nil
+}
+
+// GetMigration retrieves an embedding migration
+func (r *Repository) GetMigration(ctx context.Context, id primitive.ObjectID) (*models.EmbeddingMigration, error) {
+	var migration models.EmbeddingMigration
+	err := r.mongodb.Collection(migrationCollection).FindOne(ctx, bson.M{"_id": id}).Decode(&migration)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrMigrationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find embedding migration: %w", err)
+	}
+	return &migration, nil
+}
+
+// ListMigrations lists the embedding migrations, newest first
+func (r *Repository) ListMigrations(ctx context.Context, limit int) ([]*models.EmbeddingMigration, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := r.mongodb.Collection(migrationCollection).Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list embedding migrations: %w", err)
+	}
+	migrations := []*models.EmbeddingMigration{}
+	if err := cursor.All(ctx, &migrations); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding migrations: %w", err)
+	}
+	return migrations, nil
+}
+
+// CountOpenMigrations counts the embedding migrations that are not completed or cancelled
+func (r *Repository) CountOpenMigrations(ctx context.Context) (int64, error) {
+	count, err := r.mongodb.Collection(migrationCollection).CountDocuments(ctx, bson.M{
+		"status": bson.M{"$in": bson.A{
+			models.EmbeddingMigrationRunning,
+			models.EmbeddingMigrationPaused,
+			models.EmbeddingMigrationReady,
+			models.EmbeddingMigrationCutOver,
+		}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count open embedding migrations: %w", err)
+	}
+	return count, nil
+}
+
+// TransitionMigration moves an embedding migration in one of the statuses from to status to,
+// setting fields too. It returns false if the migration was in none of them.
+func (r *Repository) TransitionMigration(ctx context.Context, id primitive.ObjectID, from []models.EmbeddingMigrationStatus, to models.EmbeddingMigrationStatus, fields bson.M) (bool, error) {
+	set := bson.M{"status": to, "updated_at": time.Now()}
+	for key, value := range fields {
+		set[key] = value
+	}
+	result, err := r.mongodb.Collection(migrationCollection).UpdateOne(ctx,
+		bson.M{"_id": id, "status": bson.M{"$in": from}},
+		bson.M{"$set": set})
+	if err != nil {
+		return false, fmt.Errorf("failed to update embedding migration: %w", err)
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// SaveMigrationProgress stores the progress and errors of an embedding migration, leaving its
+// status as it is
+func (r *Repository) SaveMigrationProgress(ctx context.Context, migration *models.EmbeddingMigration) error {
+	_, err := r.mongodb.Collection(migrationCollection).UpdateOne(ctx,
+		bson.M{"_id": migration.ID},
+		bson.M{"$set": bson.M{
+			"collections": migration.Collections,
+			"errors":      migration.Errors,
+			"updated_at":  time.Now(),
+		}})
+	if err != nil {
+		return fmt.Errorf("failed to save embedding migration progress: %w", err)
+	}
+	return nil
+}
+
+// SetMigrationActive records whether a worker is running an embedding migration
+func (r *Repository) SetMigrationActive(ctx context.Context, id primitive.ObjectID, active bool) error {
+	_, err := r.mongodb.Collection(migrationCollection).UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"active": active}})
+	if err != nil {
+		return fmt.Errorf("failed to update embedding migration: %w", err)
+	}
+	return nil
+}
+
+// ReleasePausedMigration records that the worker of a paused migration stopped. It returns false,
+// leaving the worker to go on, if the migration was resumed in the meantime.
+func (r *Repository) ReleasePausedMigration(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	result, err := r.mongodb.Collection(migrationCollection).UpdateOne(ctx,
+		bson.M{"_id": id, "status": models.EmbeddingMigrationPaused},
+		bson.M{"$set": bson.M{"active": false}})
+	if err != nil {
+		return false, fmt.Errorf("failed to update embedding migration: %w", err)
+	}
+	return result.MatchedCount > 0, nil
+}
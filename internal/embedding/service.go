@@ -1,14 +1,14 @@
 package embedding
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"ai-government-consultant/internal/document"
 	"ai-government-consultant/internal/models"
 	"ai-government-consultant/pkg/logger"
 
@@ -20,37 +20,32 @@ import (
 
 // Service handles embedding generation and vector search operations
 type Service struct {
-	geminiAPIKey string
-	geminiURL    string
-	httpClient   *http.Client
-	mongodb      *mongo.Database
-	redis        *redis.Client
-	logger       logger.Logger
+	provider  EmbeddingProvider // provider of the configured model
+	providers ProviderFactory
+	models    atomic.Pointer[modelSet]
+	watch     modelWatch
+	mongodb   *mongo.Database
+	redis     *redis.Client
+	logger    logger.Logger
+	chunker   *Chunker
+	indexes   *indexes // nil when searches scan MongoDB
+	lexical   lexicalStats
+	fusion    FusionConfig
 }
 
 // Config holds the configuration for the embedding service
 type Config struct {
+	Provider     EmbeddingProvider // produces the vectors; when nil, Gemini with GeminiAPIKey
+	Providers    ProviderFactory   // builds the providers of other models, for migrations; nil allows none
+	ModelRefresh time.Duration     // how often the active model is reloaded from MongoDB (default: 30s)
 	GeminiAPIKey string
 	GeminiURL    string
 	MongoDB      *mongo.Database
 	Redis        *redis.Client
 	Logger       logger.Logger
-}
-
-// GeminiEmbeddingRequest represents the request structure for Gemini embedding API
-type GeminiEmbeddingRequest struct {
-	Content struct {
-		Parts []struct {
-			Text string `json:"text"`
-		} `json:"parts"`
-	} `json:"content"`
-}
-
-// GeminiEmbeddingResponse represents the response structure from Gemini embedding API
-type GeminiEmbeddingResponse struct {
-	Embedding struct {
-		Values []float64 `json:"values"`
-	} `json:"embedding"`
+	Chunking     *ChunkerConfig // defaults to DefaultChunkerConfig
+	Index        *IndexConfig   // serves searches from in-process HNSW indexes; nil scans MongoDB
+	Fusion       *FusionConfig  // defaults to DefaultFusionConfig
 }
 
 // EmbeddingResult represents the result of an embedding operation
@@ -67,15 +62,19 @@ type SearchResult struct {
 	Score     float64                `json:"score"`
 	Document  *models.Document       `json:"document,omitempty"`
 	Knowledge *models.KnowledgeItem  `json:"knowledge,omitempty"`
+	Chunk     *models.DocumentChunk  `json:"chunk,omitempty"` // the matching passage for chunk-level document hits
 	Metadata  map[string]interface{} `json:"metadata"`
 }
 
 // SearchOptions defines options for vector search
 type SearchOptions struct {
-	Limit      int                    `json:"limit"`
-	Threshold  float64                `json:"threshold"`
-	Filters    map[string]interface{} `json:"filters"`
-	Collection string                 `json:"collection"` // "documents" or "knowledge_items"
+	Limit        int                    `json:"limit"`
+	Threshold    float64                `json:"threshold"`
+	Filters      map[string]interface{} `json:"filters"`
+	Collection   string                 `json:"collection"`              // "documents" or "knowledge_items"
+	Unredacted   bool                   `json:"-"`                       // return document text with personal data unmasked
+	Access       *document.AccessScope  `json:"-"`                       // only return documents readable in this scope; nil returns every document
+	WorkspaceIDs []primitive.ObjectID   `json:"workspace_ids,omitempty"` // only return items in at least one of these workspaces
 }
 
 // NewService creates a new embedding service
@@ -83,32 +82,55 @@ func NewService(config *Config) (*Service, error) {
 	if config == nil {
 		return nil, fmt.Errorf("config is required")
 	}
-	if config.GeminiAPIKey == "" {
-		return nil, fmt.Errorf("gemini API key is required")
+
+	provider := config.Provider
+	if provider == nil {
+		if config.GeminiAPIKey == "" {
+			return nil, ErrAPIKeyRequired
+		}
+		provider = NewGeminiProvider(config.GeminiAPIKey, "", config.GeminiURL)
 	}
 
-	geminiURL := config.GeminiURL
-	if geminiURL == "" {
-		geminiURL = "https://generativelanguage.googleapis.com/v1beta/models/text-embedding-004:embedContent"
+	s := &Service{
+		provider:  provider,
+		providers: config.Providers,
+		watch:     modelWatch{interval: config.ModelRefresh},
+		mongodb:   config.MongoDB,
+		redis:     config.Redis,
+		logger:    config.Logger,
+		chunker:   NewChunker(config.Chunking),
+		lexical:   lexicalStats{collections: make(map[string]*cachedStats)},
+		fusion:    fusionConfig(config.Fusion),
+	}
+	if s.watch.interval <= 0 {
+		s.watch.interval = 30 * time.Second
 	}
+	s.models.Store(&modelSet{active: provider})
+	if config.Index != nil {
+		s.indexes = newIndexes(config.Index)
+	}
+	return s, nil
+}
 
-	return &Service{
-		geminiAPIKey: config.GeminiAPIKey,
-		geminiURL:    geminiURL,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		mongodb: config.MongoDB,
-		redis:   config.Redis,
-		logger:  config.Logger,
-	}, nil
+// Model identifies the provider and model that produce the service's vectors, e.g.
+// "gemini/text-embedding-004". It is recorded with every stored vector. It is the configured
+// model until an embedding migration cuts over to another one.
+func (s *Service) Model() string {
+	return s.models.Load().active.Name()
 }
 
-// GenerateEmbedding generates embeddings for the given text using Gemini API
+// GenerateEmbedding generates embeddings for the given text with the active model
 func (s *Service) GenerateEmbedding(ctx context.Context, text string) ([]float64, error) {
+	return s.embedWith(ctx, s.models.Load().active, text)
+}
+
+// embedWith generates embeddings for text with provider
+func (s *Service) embedWith(ctx context.Context, provider EmbeddingProvider, text string) ([]float64, error) {
+	// Vectors are cached per model, so changing the model never serves stale vectors
+	cacheKey := fmt.Sprintf("embedding:%s:%x", provider.Name(), text)
+
 	// Check cache first
 	if s.redis != nil {
-		cacheKey := fmt.Sprintf("embedding:%x", text)
 		cached, err := s.redis.Get(ctx, cacheKey).Result()
 		if err == nil {
 			var embeddings []float64
@@ -121,62 +143,27 @@ func (s *Service) GenerateEmbedding(ctx context.Context, text string) ([]float64
 		}
 	}
 
-	// Prepare request
-	request := GeminiEmbeddingRequest{}
-	request.Content.Parts = []struct {
-		Text string `json:"text"`
-	}{
-		{Text: text},
-	}
-
-	requestBody, err := json.Marshal(request)
+	embeddings, err := provider.Embed(ctx, text)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	// Make API request
-	url := fmt.Sprintf("%s?key=%s", s.geminiURL, s.geminiAPIKey)
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(requestBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make API request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	// Parse response
-	var response GeminiEmbeddingResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, err
 	}
 
-	embeddings := response.Embedding.Values
-
 	// Cache the result
 	if s.redis != nil {
-		cacheKey := fmt.Sprintf("embedding:%x", text)
 		embeddingJSON, _ := json.Marshal(embeddings)
 		s.redis.Set(ctx, cacheKey, embeddingJSON, 24*time.Hour) // Cache for 24 hours
 	}
 
 	s.logger.Debug("Generated embedding", map[string]interface{}{
+		"model":               provider.Name(),
 		"text_length":         len(text),
 		"embedding_dimension": len(embeddings),
 	})
 	return embeddings, nil
 }
 
-// GenerateDocumentEmbedding generates and stores embeddings for a document
+// GenerateDocumentEmbedding splits a document into chunks, embeds each chunk into the
+// document_chunks collection and stores the mean chunk vector on the document itself
 func (s *Service) GenerateDocumentEmbedding(ctx context.Context, documentID primitive.ObjectID) error {
 	// Retrieve document
 	collection := s.mongodb.Collection("documents")
@@ -186,27 +173,81 @@ func (s *Service) GenerateDocumentEmbedding(ctx context.Context, documentID prim
 		return fmt.Errorf("failed to find document: %w", err)
 	}
 
-	// Generate embedding for document content
-	embeddings, err := s.GenerateEmbedding(ctx, document.Content)
-	if err != nil {
-		return fmt.Errorf("failed to generate embedding: %w", err)
+	// Personal data never reaches the embedding model or the stored passages. Redaction
+	// preserves byte offsets, so the chunks still line up with the original content.
+	if document.RedactedContent != "" {
+		document.Content = document.RedactedContent
 	}
 
-	// Update document with embeddings
-	update := bson.M{
-		"$set": bson.M{
-			"embeddings":           embeddings,
-			"processing_timestamp": time.Now(),
-		},
+	chunks := s.chunker.Chunk(&document)
+	if len(chunks) == 0 {
+		return fmt.Errorf("document has no content to embed")
 	}
 
+	// Generate an embedding for every chunk, and while a migration runs one with its model too
+	set := s.models.Load()
+	for i := range chunks {
+		chunks[i].ID = primitive.NewObjectID()
+		chunks[i].EmbeddingModel = set.active.Name()
+		chunks[i].Embeddings, err = s.embedWith(ctx, set.active, chunkEmbeddingText(&chunks[i]))
+		if err != nil {
+			return fmt.Errorf("failed to generate embedding for chunk %d: %w", i, err)
+		}
+		chunks[i].EmbeddingDimension = len(chunks[i].Embeddings)
+		if set.shadow != nil {
+			chunks[i].ShadowEmbeddingModel = set.shadow.Name()
+			chunks[i].ShadowEmbeddings, err = s.embedWith(ctx, set.shadow, chunkEmbeddingText(&chunks[i]))
+			if err != nil {
+				return fmt.Errorf("failed to generate shadow embedding for chunk %d: %w", i, err)
+			}
+			chunks[i].ShadowEmbeddingDimension = len(chunks[i].ShadowEmbeddings)
+		}
+	}
+
+	// Insert the new chunks before removing the old ones so searches never see a document without chunks
+	chunkCollection := s.mongodb.Collection("document_chunks")
+	newChunks := make([]interface{}, len(chunks))
+	chunkIDs := make([]primitive.ObjectID, len(chunks))
+	for i := range chunks {
+		newChunks[i] = chunks[i]
+		chunkIDs[i] = chunks[i].ID
+	}
+	if _, err := chunkCollection.InsertMany(ctx, newChunks); err != nil {
+		return fmt.Errorf("failed to store document chunks: %w", err)
+	}
+	if _, err := chunkCollection.DeleteMany(ctx, bson.M{
+		"document_id": documentID,
+		"_id":         bson.M{"$nin": chunkIDs},
+	}); err != nil {
+		return fmt.Errorf("failed to remove previous document chunks: %w", err)
+	}
+
+	// Update document with its document-level embedding
+	vectors := make([][]float64, len(chunks))
+	shadowVectors := make([][]float64, len(chunks))
+	for i := range chunks {
+		vectors[i], shadowVectors[i] = chunks[i].Embeddings, chunks[i].ShadowEmbeddings
+	}
+	embeddings := meanVector(vectors)
+	update := vectorUpdate(set, embeddings, meanVector(shadowVectors), bson.M{
+		"chunk_count":          len(chunks),
+		"processing_timestamp": time.Now(),
+	})
+
 	_, err = collection.UpdateOne(ctx, bson.M{"_id": documentID}, update)
 	if err != nil {
 		return fmt.Errorf("failed to update document with embeddings: %w", err)
 	}
 
+	s.unindexOwner("document_chunks", documentID)
+	for i := range chunks {
+		s.indexVector("document_chunks", chunks[i].ID, documentID, chunks[i].Embeddings)
+	}
+	s.indexVector("documents", documentID, documentID, embeddings)
+
 	s.logger.Info("Generated document embedding", map[string]interface{}{
 		"document_id":         documentID.Hex(),
+		"chunk_count":         len(chunks),
 		"embedding_dimension": len(embeddings),
 	})
 	return nil
@@ -228,24 +269,27 @@ func (s *Service) GenerateKnowledgeEmbedding(ctx context.Context, knowledgeID pr
 		text += "\n" + *knowledge.Summary
 	}
 
-	// Generate embedding
-	embeddings, err := s.GenerateEmbedding(ctx, text)
+	// Generate embedding, and while a migration runs one with its model too
+	set := s.models.Load()
+	embeddings, err := s.embedWith(ctx, set.active, text)
 	if err != nil {
 		return fmt.Errorf("failed to generate embedding: %w", err)
 	}
+	var shadow []float64
+	if set.shadow != nil {
+		if shadow, err = s.embedWith(ctx, set.shadow, text); err != nil {
+			return fmt.Errorf("failed to generate shadow embedding: %w", err)
+		}
+	}
 
 	// Update knowledge item with embeddings
-	update := bson.M{
-		"$set": bson.M{
-			"embeddings": embeddings,
-			"updated_at": time.Now(),
-		},
-	}
+	update := vectorUpdate(set, embeddings, shadow, bson.M{"updated_at": time.Now()})
 
 	_, err = collection.UpdateOne(ctx, bson.M{"_id": knowledgeID}, update)
 	if err != nil {
 		return fmt.Errorf("failed to update knowledge item with embeddings: %w", err)
 	}
+	s.indexVector("knowledge_items", knowledgeID, knowledgeID, embeddings)
 
 	s.logger.Info("Generated knowledge embedding", map[string]interface{}{
 		"knowledge_id":        knowledgeID.Hex(),
@@ -272,9 +316,22 @@ func (s *Service) VectorSearch(ctx context.Context, query string, options *Searc
 
 	var results []SearchResult
 
-	// Search documents if collection is not specified or is "documents"
+	// Search document chunks if collection is not specified or is "documents"
 	if options.Collection == "" || options.Collection == "documents" {
-		docResults, err := s.searchDocuments(ctx, queryEmbedding, options)
+		chunkResults, err := s.searchChunks(ctx, queryEmbedding, options)
+		if err != nil {
+			s.logger.Error("Failed to search document chunks", err, nil)
+		} else {
+			results = append(results, chunkResults...)
+		}
+
+		// Documents embedded before chunking was introduced only have a document-level vector
+		legacyOptions := *options
+		legacyOptions.Filters = map[string]interface{}{"chunk_count": bson.M{"$exists": false}}
+		for key, value := range options.Filters {
+			legacyOptions.Filters[key] = value
+		}
+		docResults, err := s.searchDocuments(ctx, queryEmbedding, &legacyOptions)
 		if err != nil {
 			s.logger.Error("Failed to search documents", err, nil)
 		} else {
@@ -315,99 +372,24 @@ func (s *Service) VectorSearch(ctx context.Context, query string, options *Searc
 
 // searchDocuments performs vector search on documents collection
 func (s *Service) searchDocuments(ctx context.Context, queryEmbedding []float64, options *SearchOptions) ([]SearchResult, error) {
+	if vi := s.readyIndex("documents"); vi != nil {
+		return s.indexSearch(ctx, vi, queryEmbedding, "documents", documentFilter(options, ""), options, s.loadDocuments(options))
+	}
+
 	collection := s.mongodb.Collection("documents")
 
+	model := s.Model()
+	match := withVector(documentFilter(options, ""), model)
+
 	// Build aggregation pipeline for vector search
 	pipeline := []bson.M{
-		{
-			"$match": bson.M{
-				"embeddings":        bson.M{"$exists": true, "$ne": nil},
-				"processing_status": "completed",
-			},
-		},
-	}
-
-	// Add filters if specified
-	if len(options.Filters) > 0 {
-		matchStage := pipeline[0]["$match"].(bson.M)
-		for key, value := range options.Filters {
-			matchStage[key] = value
-		}
+		{"$match": match},
+		{"$addFields": bson.M{"similarity": cosineSimilarity(vectorField(model), queryEmbedding)}},
+		{"$match": bson.M{"similarity": bson.M{"$gte": options.Threshold}}},
+		{"$sort": bson.M{"similarity": -1}},
+		{"$limit": options.Limit},
 	}
 
-	// Add vector similarity calculation
-	pipeline = append(pipeline, bson.M{
-		"$addFields": bson.M{
-			"similarity": bson.M{
-				"$let": bson.M{
-					"vars": bson.M{
-						"dotProduct": bson.M{
-							"$reduce": bson.M{
-								"input": bson.M{
-									"$zip": bson.M{
-										"inputs": []interface{}{"$embeddings", queryEmbedding},
-									},
-								},
-								"initialValue": 0,
-								"in": bson.M{
-									"$add": []interface{}{
-										"$$value",
-										bson.M{"$multiply": []interface{}{"$$this.0", "$$this.1"}},
-									},
-								},
-							},
-						},
-						"magnitude1": bson.M{
-							"$sqrt": bson.M{
-								"$reduce": bson.M{
-									"input":        "$embeddings",
-									"initialValue": 0,
-									"in": bson.M{
-										"$add": []interface{}{"$$value", bson.M{"$multiply": []interface{}{"$$this", "$$this"}}},
-									},
-								},
-							},
-						},
-						"magnitude2": bson.M{
-							"$sqrt": bson.M{
-								"$reduce": bson.M{
-									"input":        queryEmbedding,
-									"initialValue": 0,
-									"in": bson.M{
-										"$add": []interface{}{"$$value", bson.M{"$multiply": []interface{}{"$$this", "$$this"}}},
-									},
-								},
-							},
-						},
-					},
-					"in": bson.M{
-						"$divide": []interface{}{
-							"$$dotProduct",
-							bson.M{"$multiply": []interface{}{"$$magnitude1", "$$magnitude2"}},
-						},
-					},
-				},
-			},
-		},
-	})
-
-	// Filter by similarity threshold
-	pipeline = append(pipeline, bson.M{
-		"$match": bson.M{
-			"similarity": bson.M{"$gte": options.Threshold},
-		},
-	})
-
-	// Sort by similarity (descending)
-	pipeline = append(pipeline, bson.M{
-		"$sort": bson.M{"similarity": -1},
-	})
-
-	// Limit results
-	pipeline = append(pipeline, bson.M{
-		"$limit": options.Limit,
-	})
-
 	cursor, err := collection.Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute document search aggregation: %w", err)
@@ -426,18 +408,7 @@ func (s *Service) searchDocuments(ctx context.Context, queryEmbedding []float64,
 			continue
 		}
 
-		result := SearchResult{
-			ID:       doc.ID.Hex(),
-			Score:    doc.Similarity,
-			Document: &doc.Document,
-			Metadata: map[string]interface{}{
-				"type":     "document",
-				"category": doc.Metadata.Category,
-				"tags":     doc.Metadata.Tags,
-			},
-		}
-
-		results = append(results, result)
+		results = append(results, documentResult(&doc.Document, doc.Similarity, options))
 	}
 
 	return results, nil
@@ -445,99 +416,24 @@ func (s *Service) searchDocuments(ctx context.Context, queryEmbedding []float64,
 
 // searchKnowledgeItems performs vector search on knowledge_items collection
 func (s *Service) searchKnowledgeItems(ctx context.Context, queryEmbedding []float64, options *SearchOptions) ([]SearchResult, error) {
+	if vi := s.readyIndex("knowledge_items"); vi != nil {
+		return s.indexSearch(ctx, vi, queryEmbedding, "knowledge_items", knowledgeFilter(options), options, s.loadKnowledgeItems(options))
+	}
+
 	collection := s.mongodb.Collection("knowledge_items")
 
+	model := s.Model()
+	match := withVector(knowledgeFilter(options), model)
+
 	// Build aggregation pipeline for vector search
 	pipeline := []bson.M{
-		{
-			"$match": bson.M{
-				"embeddings": bson.M{"$exists": true, "$ne": nil},
-				"is_active":  true,
-			},
-		},
-	}
-
-	// Add filters if specified
-	if len(options.Filters) > 0 {
-		matchStage := pipeline[0]["$match"].(bson.M)
-		for key, value := range options.Filters {
-			matchStage[key] = value
-		}
+		{"$match": match},
+		{"$addFields": bson.M{"similarity": cosineSimilarity(vectorField(model), queryEmbedding)}},
+		{"$match": bson.M{"similarity": bson.M{"$gte": options.Threshold}}},
+		{"$sort": bson.M{"similarity": -1}},
+		{"$limit": options.Limit},
 	}
 
-	// Add vector similarity calculation (same as documents)
-	pipeline = append(pipeline, bson.M{
-		"$addFields": bson.M{
-			"similarity": bson.M{
-				"$let": bson.M{
-					"vars": bson.M{
-						"dotProduct": bson.M{
-							"$reduce": bson.M{
-								"input": bson.M{
-									"$zip": bson.M{
-										"inputs": []interface{}{"$embeddings", queryEmbedding},
-									},
-								},
-								"initialValue": 0,
-								"in": bson.M{
-									"$add": []interface{}{
-										"$$value",
-										bson.M{"$multiply": []interface{}{"$$this.0", "$$this.1"}},
-									},
-								},
-							},
-						},
-						"magnitude1": bson.M{
-							"$sqrt": bson.M{
-								"$reduce": bson.M{
-									"input":        "$embeddings",
-									"initialValue": 0,
-									"in": bson.M{
-										"$add": []interface{}{"$$value", bson.M{"$multiply": []interface{}{"$$this", "$$this"}}},
-									},
-								},
-							},
-						},
-						"magnitude2": bson.M{
-							"$sqrt": bson.M{
-								"$reduce": bson.M{
-									"input":        queryEmbedding,
-									"initialValue": 0,
-									"in": bson.M{
-										"$add": []interface{}{"$$value", bson.M{"$multiply": []interface{}{"$$this", "$$this"}}},
-									},
-								},
-							},
-						},
-					},
-					"in": bson.M{
-						"$divide": []interface{}{
-							"$$dotProduct",
-							bson.M{"$multiply": []interface{}{"$$magnitude1", "$$magnitude2"}},
-						},
-					},
-				},
-			},
-		},
-	})
-
-	// Filter by similarity threshold
-	pipeline = append(pipeline, bson.M{
-		"$match": bson.M{
-			"similarity": bson.M{"$gte": options.Threshold},
-		},
-	})
-
-	// Sort by similarity (descending)
-	pipeline = append(pipeline, bson.M{
-		"$sort": bson.M{"similarity": -1},
-	})
-
-	// Limit results
-	pipeline = append(pipeline, bson.M{
-		"$limit": options.Limit,
-	})
-
 	cursor, err := collection.Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute knowledge search aggregation: %w", err)
@@ -556,24 +452,271 @@ func (s *Service) searchKnowledgeItems(ctx context.Context, queryEmbedding []flo
 			continue
 		}
 
-		result := SearchResult{
-			ID:        knowledge.ID.Hex(),
-			Score:     knowledge.Similarity,
-			Knowledge: &knowledge.KnowledgeItem,
-			Metadata: map[string]interface{}{
-				"type":       "knowledge",
-				"category":   knowledge.Category,
-				"tags":       knowledge.Tags,
-				"confidence": knowledge.Confidence,
-			},
+		results = append(results, knowledgeResult(&knowledge.KnowledgeItem, knowledge.Similarity))
+	}
+
+	return results, nil
+}
+
+// searchChunks performs vector search on the document_chunks collection, joining each
+// matching chunk with its document. Filters apply to the document fields.
+func (s *Service) searchChunks(ctx context.Context, queryEmbedding []float64, options *SearchOptions) ([]SearchResult, error) {
+	if vi := s.readyIndex("document_chunks"); vi != nil {
+		return s.indexSearch(ctx, vi, queryEmbedding, "documents", documentFilter(options, ""), options, s.loadChunks(options))
+	}
+
+	collection := s.mongodb.Collection("document_chunks")
+
+	model := s.Model()
+	pipeline := []bson.M{
+		{"$match": vectorMatch(model)},
+		{"$addFields": bson.M{"similarity": cosineSimilarity(vectorField(model), queryEmbedding)}},
+		{"$match": bson.M{"similarity": bson.M{"$gte": options.Threshold}}},
+		{"$sort": bson.M{"similarity": -1}},
+	}
+	pipeline = append(pipeline, joinDocumentStages(options)...)
+	pipeline = append(pipeline, bson.M{"$limit": options.Limit})
+	pipeline = append(pipeline, chunkOutputStages(options)...)
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute chunk search aggregation: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []SearchResult
+	for cursor.Next(ctx) {
+		var hit struct {
+			models.DocumentChunk `bson:",inline"`
+			Document             models.Document `bson:"document"`
+			Similarity           float64         `bson:"similarity"`
 		}
 
-		results = append(results, result)
+		if err := cursor.Decode(&hit); err != nil {
+			s.logger.Error("Failed to decode chunk search result", err, nil)
+			continue
+		}
+
+		results = append(results, chunkResult(hit.DocumentChunk, &hit.Document, hit.Similarity, options))
 	}
 
 	return results, nil
 }
 
+// joinDocumentStages are the aggregation stages joining chunks with their documents and keeping
+// those of the documents a search may return
+func joinDocumentStages(options *SearchOptions) []bson.M {
+	return []bson.M{
+		{"$lookup": bson.M{
+			"from":         "documents",
+			"localField":   "document_id",
+			"foreignField": "_id",
+			"as":           "document",
+		}},
+		{"$unwind": "$document"},
+		{"$match": documentFilter(options, "document.")},
+	}
+}
+
+// chunkOutputStages are the aggregation stages shaping joined chunks into results
+func chunkOutputStages(options *SearchOptions) []bson.M {
+	var stages []bson.M
+
+	// Stored passages are redacted; unredacted results keep the content of documents with
+	// personal data so that the original passage can be cut out of it
+	if options.Unredacted {
+		stages = append(stages, bson.M{"$set": bson.M{
+			"document.content": bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{"$document.pii_count", 0}}, "$document.content", "$$REMOVE"}},
+		}})
+	}
+
+	// The chunk carries the passage; the full content and vectors are not needed
+	projection := bson.M{"embeddings": 0, "shadow_embeddings": 0}
+	for key, value := range chunkDocumentProjection(options) {
+		projection["document."+key] = value
+	}
+	return append(stages, bson.M{"$project": projection})
+}
+
+// documentFilter returns the query selecting the documents a search may return. prefix is put
+// before each field name, e.g. "document." for documents joined to their chunks.
+func documentFilter(options *SearchOptions, prefix string) bson.M {
+	filter := bson.M{
+		prefix + "processing_status": "completed",
+		prefix + "superseded":        bson.M{"$ne": true},      // only the latest version of a document
+		prefix + "duplicate_of":      bson.M{"$exists": false}, // duplicates point at their canonical document
+	}
+	for key, value := range options.Filters {
+		filter[prefix+key] = value
+	}
+	if len(options.WorkspaceIDs) > 0 {
+		filter[prefix+"workspace_ids"] = bson.M{"$in": options.WorkspaceIDs}
+	}
+	if options.Access != nil {
+		filter["$and"] = options.Access.Match(prefix)["$and"]
+	}
+	return filter
+}
+
+// knowledgeFilter returns the query selecting the knowledge items a search may return
+func knowledgeFilter(options *SearchOptions) bson.M {
+	filter := bson.M{"is_active": true}
+	for key, value := range options.Filters {
+		filter[key] = value
+	}
+	if len(options.WorkspaceIDs) > 0 {
+		filter["workspace_ids"] = bson.M{"$in": options.WorkspaceIDs}
+	}
+	return filter
+}
+
+// chunkDocumentProjection leaves out the document fields that chunk results do not need
+func chunkDocumentProjection(options *SearchOptions) bson.M {
+	projection := bson.M{
+		"redacted_content":  0,
+		"raw_content":       0,
+		"embeddings":        0,
+		"shadow_embeddings": 0,
+	}
+	if !options.Unredacted {
+		projection["content"] = 0
+	}
+	return projection
+}
+
+// documentResult builds the search result of a document
+func documentResult(doc *models.Document, score float64, options *SearchOptions) SearchResult {
+	if !options.Unredacted {
+		doc.RedactPII()
+	}
+	doc.RedactedContent = ""
+
+	return SearchResult{
+		ID:       doc.ID.Hex(),
+		Score:    score,
+		Document: doc,
+		Metadata: map[string]interface{}{
+			"type":     "document",
+			"category": doc.Metadata.Category,
+			"tags":     doc.Metadata.Tags,
+		},
+	}
+}
+
+// knowledgeResult builds the search result of a knowledge item
+func knowledgeResult(knowledge *models.KnowledgeItem, score float64) SearchResult {
+	return SearchResult{
+		ID:        knowledge.ID.Hex(),
+		Score:     score,
+		Knowledge: knowledge,
+		Metadata: map[string]interface{}{
+			"type":       "knowledge",
+			"category":   knowledge.Category,
+			"tags":       knowledge.Tags,
+			"confidence": knowledge.Confidence,
+		},
+	}
+}
+
+// chunkResult builds the search result of a passage. doc carries its content only when the
+// passage has to be cut out of the unredacted text.
+func chunkResult(chunk models.DocumentChunk, doc *models.Document, score float64, options *SearchOptions) SearchResult {
+	if content := doc.Content; content != "" {
+		start, end := unredactedSpan(chunk.Start, chunk.End, doc.ExtractedEntities)
+		if start >= 0 && end <= len(content) && start < end {
+			chunk.Content = strings.TrimSpace(content[start:end])
+		}
+		doc.Content = ""
+	}
+	if !options.Unredacted {
+		doc.RedactPII()
+	}
+
+	return SearchResult{
+		ID:       doc.ID.Hex(),
+		Score:    score,
+		Document: doc,
+		Chunk:    &chunk,
+		Metadata: map[string]interface{}{
+			"type":        "document",
+			"category":    doc.Metadata.Category,
+			"tags":        doc.Metadata.Tags,
+			"chunk_id":    chunk.ID.Hex(),
+			"chunk_index": chunk.Index,
+			"start":       chunk.Start,
+			"end":         chunk.End,
+		},
+	}
+}
+
+// unredactedSpan widens a chunk's byte range to cover any sensitive entity it cuts through.
+// Chunk boundaries are chosen on the redacted text, where a masked value ends in padding
+// that may have been trimmed away.
+func unredactedSpan(start, end int, entities []models.Entity) (int, int) {
+	for _, entity := range entities {
+		if entity.Sensitive && entity.StartPos < end && start < entity.EndPos {
+			start = min(start, entity.StartPos)
+			end = max(end, entity.EndPos)
+		}
+	}
+	return start, end
+}
+
+// cosineSimilarity builds an aggregation expression computing the cosine similarity
+// between the vector field evaluates to and the query embedding
+func cosineSimilarity(field interface{}, queryEmbedding []float64) bson.M {
+	return bson.M{
+		"$let": bson.M{
+			"vars": bson.M{
+				"dotProduct": bson.M{
+					"$reduce": bson.M{
+						"input": bson.M{
+							"$zip": bson.M{
+								"inputs": []interface{}{field, queryEmbedding},
+							},
+						},
+						"initialValue": 0,
+						"in": bson.M{
+							"$add": []interface{}{
+								"$$value",
+								bson.M{"$multiply": []interface{}{"$$this.0", "$$this.1"}},
+							},
+						},
+					},
+				},
+				"magnitude1": bson.M{
+					"$sqrt": bson.M{
+						"$reduce": bson.M{
+							"input":        field,
+							"initialValue": 0,
+							"in": bson.M{
+								"$add": []interface{}{"$$value", bson.M{"$multiply": []interface{}{"$$this", "$$this"}}},
+							},
+						},
+					},
+				},
+				"magnitude2": bson.M{
+					"$sqrt": bson.M{
+						"$reduce": bson.M{
+							"input":        queryEmbedding,
+							"initialValue": 0,
+							"in": bson.M{
+								"$add": []interface{}{"$$value", bson.M{"$multiply": []interface{}{"$$this", "$$this"}}},
+							},
+						},
+					},
+				},
+			},
+			"in": bson.M{
+				"$divide": []interface{}{
+					"$$dotProduct",
+					bson.M{"$multiply": []interface{}{"$$magnitude1", "$$magnitude2"}},
+				},
+			},
+		},
+	}
+}
+
 // BatchGenerateEmbeddings generates embeddings for multiple texts in batch
 func (s *Service) BatchGenerateEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
 	embeddings := make([][]float64, len(texts))
@@ -604,8 +747,9 @@ func (s *Service) GetSimilarDocuments(ctx context.Context, documentID primitive.
 		return nil, fmt.Errorf("failed to find document: %w", err)
 	}
 
-	if len(document.Embeddings) == 0 {
-		return nil, fmt.Errorf("document has no embeddings")
+	vector := activeVector(s.Model(), document.EmbeddingModel, document.Embeddings, document.ShadowEmbeddingModel, document.ShadowEmbeddings)
+	if len(vector) == 0 {
+		return nil, fmt.Errorf("document has no embeddings of the active model")
 	}
 
 	// Search for similar documents
@@ -618,7 +762,7 @@ func (s *Service) GetSimilarDocuments(ctx context.Context, documentID primitive.
 		},
 	}
 
-	return s.searchDocuments(ctx, document.Embeddings, options)
+	return s.searchDocuments(ctx, vector, options)
 }
 
 // GetSimilarKnowledge finds knowledge items similar to a given knowledge item
@@ -631,8 +775,9 @@ func (s *Service) GetSimilarKnowledge(ctx context.Context, knowledgeID primitive
 		return nil, fmt.Errorf("failed to find knowledge item: %w", err)
 	}
 
-	if len(knowledge.Embeddings) == 0 {
-		return nil, fmt.Errorf("knowledge item has no embeddings")
+	vector := activeVector(s.Model(), knowledge.EmbeddingModel, knowledge.Embeddings, knowledge.ShadowEmbeddingModel, knowledge.ShadowEmbeddings)
+	if len(vector) == 0 {
+		return nil, fmt.Errorf("knowledge item has no embeddings of the active model")
 	}
 
 	// Search for similar knowledge items
@@ -645,7 +790,7 @@ func (s *Service) GetSimilarKnowledge(ctx context.Context, knowledgeID primitive
 		},
 	}
 
-	return s.searchKnowledgeItems(ctx, knowledge.Embeddings, options)
+	return s.searchKnowledgeItems(ctx, vector, options)
 }
 
 // ClearCache clears the embedding cache
@@ -0,0 +1,436 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ai-government-consultant/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// settingsCollection holds the embedding model settings shared by every server
+	settingsCollection = "embedding_settings"
+
+	// modelSettingsID is the ID of the model settings in settingsCollection
+	modelSettingsID = "models"
+)
+
+// ProviderFactory returns the provider of a model named as in EMBEDDING_MODEL, e.g.
+// "openai/bge-m3"; NewProvider with the server's provider settings is one
+type ProviderFactory func(model string) (EmbeddingProvider, error)
+
+// ModelSettings record which embedding model is active on every server and, while a migration
+// runs, which model its shadow vectors are written with. The cutover of a migration is the single
+// write of these settings that makes the migration's model the active one.
+type ModelSettings struct {
+	ID          string              `json:"-" bson:"_id"`
+	Model       string              `json:"model" bson:"model"`
+	ShadowModel string              `json:"shadow_model,omitempty" bson:"shadow_model,omitempty"`
+	MigrationID *primitive.ObjectID `json:"migration_id,omitempty" bson:"migration_id,omitempty"`
+	UpdatedAt   time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+// modelSet holds the providers in use: the active model's, which produces every searched vector,
+// and while a migration runs the provider of its shadow vectors
+type modelSet struct {
+	active EmbeddingProvider
+	shadow EmbeddingProvider
+}
+
+// modelWatch reloads the model settings periodically
+type modelWatch struct {
+	interval time.Duration
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// ShadowModel returns the model shadow vectors are written with, or "" without a running migration
+func (s *Service) ShadowModel() string {
+	if shadow := s.models.Load().shadow; shadow != nil {
+		return shadow.Name()
+	}
+	return ""
+}
+
+// ModelSettings returns the stored model settings, or nil when no migration ever ran and the
+// configured model is active
+func (s *Service) ModelSettings(ctx context.Context) (*ModelSettings, error) {
+	var settings ModelSettings
+	err := s.mongodb.Collection(settingsCollection).FindOne(ctx, bson.M{"_id": modelSettingsID}).Decode(&settings)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedding model settings: %w", err)
+	}
+	return &settings, nil
+}
+
+// LoadModels switches the service to the models of the stored settings. The stored active model
+// takes precedence over the configured one, since a cutover made on any server must hold on all.
+func (s *Service) LoadModels(ctx context.Context) error {
+	settings, err := s.ModelSettings(ctx)
+	if err != nil || settings == nil {
+		return err
+	}
+	return s.applyModels(settings)
+}
+
+// applyModels makes the models of settings the ones in use
+func (s *Service) applyModels(settings *ModelSettings) error {
+	current := s.models.Load()
+	next := &modelSet{active: current.active, shadow: current.shadow}
+
+	if settings.Model != current.active.Name() {
+		provider, err := s.providerFor(settings.Model)
+		if err != nil {
+			return fmt.Errorf("failed to switch to embedding model %s: %w", settings.Model, err)
+		}
+		next.active = provider
+	}
+	switch {
+	case settings.ShadowModel == "":
+		next.shadow = nil
+	case current.shadow == nil || current.shadow.Name() != settings.ShadowModel:
+		provider, err := s.providerFor(settings.ShadowModel)
+		if err != nil {
+			return fmt.Errorf("failed to load shadow embedding model %s: %w", settings.ShadowModel, err)
+		}
+		next.shadow = provider
+	}
+	if next.active == current.active && next.shadow == current.shadow {
+		return nil
+	}
+	s.models.Store(next)
+
+	if next.active != current.active {
+		fields := map[string]interface{}{
+			"previous_model": current.active.Name(),
+			"model":          next.active.Name(),
+		}
+		if next.active.Name() != s.provider.Name() {
+			fields["configured_model"] = s.provider.Name()
+		}
+		s.logger.Info("Switched the active embedding model", fields)
+		s.rebuildIndexes()
+	}
+	if next.shadow != current.shadow {
+		s.logger.Info("Switched the shadow embedding model", map[string]interface{}{
+			"shadow_model": settings.ShadowModel,
+		})
+	}
+	return nil
+}
+
+// ResolveModel returns the name the provider of model records with its vectors, e.g.
+// "local/ngram-hash-384" for "local"
+func (s *Service) ResolveModel(model string) (string, error) {
+	if model == s.provider.Name() {
+		return model, nil
+	}
+	if s.providers == nil {
+		return "", ErrMigrationUnsupported
+	}
+	provider, err := s.providers(model)
+	if err != nil {
+		return "", err
+	}
+	return provider.Name(), nil
+}
+
+// providerFor returns the provider of model
+func (s *Service) providerFor(model string) (EmbeddingProvider, error) {
+	if model == s.provider.Name() {
+		return s.provider, nil
+	}
+	if s.providers == nil {
+		return nil, ErrMigrationUnsupported
+	}
+	provider, err := s.providers(model)
+	if err != nil {
+		return nil, err
+	}
+	if provider.Name() != model {
+		return nil, fmt.Errorf("embedding model %s is named %s by its provider; use that name", model, provider.Name())
+	}
+	return provider, nil
+}
+
+// StartModelWatch reloads the model settings every ModelRefresh until StopModelWatch, so that a
+// migration started or cut over on another server reaches this one
+func (s *Service) StartModelWatch() {
+	if s.mongodb == nil || s.watch.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.watch.cancel = cancel
+	s.watch.done = make(chan struct{})
+	go func() {
+		defer close(s.watch.done)
+		ticker := time.NewTicker(s.watch.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.LoadModels(ctx); err != nil && ctx.Err() == nil {
+					s.logger.Error("Failed to reload embedding model settings", err, nil)
+				}
+			}
+		}
+	}()
+}
+
+// StopModelWatch stops reloading the model settings
+func (s *Service) StopModelWatch() {
+	if s.watch.cancel == nil {
+		return
+	}
+	s.watch.cancel()
+	<-s.watch.done
+}
+
+// SetShadowModel starts writing shadow vectors with model for the migration migrationID. Every
+// vector written from then on gets a shadow vector too, so records embedded while the migration
+// runs are not left behind.
+func (s *Service) SetShadowModel(ctx context.Context, migrationID primitive.ObjectID, model string) error {
+	if _, err := s.providerFor(model); err != nil {
+		return fmt.Errorf("failed to load embedding model %s: %w", model, err)
+	}
+	now := time.Now()
+	_, err := s.mongodb.Collection(settingsCollection).UpdateOne(ctx,
+		bson.M{"_id": modelSettingsID},
+		bson.M{
+			"$set":         bson.M{"shadow_model": model, "migration_id": migrationID, "updated_at": now},
+			"$setOnInsert": bson.M{"model": s.Model()},
+		},
+		options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to store shadow embedding model: %w", err)
+	}
+	return s.LoadModels(ctx)
+}
+
+// ActivateShadowModel cuts over to the shadow model of the migration migrationID: the one write
+// of the settings makes it the active model of every server as they reload them. Searches then
+// compare only with the vectors of that model, wherever a record holds it.
+func (s *Service) ActivateShadowModel(ctx context.Context, migrationID primitive.ObjectID, model string) error {
+	collection := s.mongodb.Collection(settingsCollection)
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": modelSettingsID, "migration_id": migrationID, "shadow_model": model},
+		bson.M{
+			"$set":   bson.M{"model": model, "updated_at": time.Now()},
+			"$unset": bson.M{"shadow_model": ""},
+		})
+	if err != nil {
+		return fmt.Errorf("failed to activate embedding model: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		// A repeated cutover finds the model already active
+		settings, err := s.ModelSettings(ctx)
+		if err != nil {
+			return err
+		}
+		if settings == nil || settings.MigrationID == nil || *settings.MigrationID != migrationID || settings.Model != model {
+			return fmt.Errorf("embedding model settings do not belong to migration %s", migrationID.Hex())
+		}
+	}
+	return s.LoadModels(ctx)
+}
+
+// ClearShadowModel stops writing the shadow vectors of the migration migrationID
+func (s *Service) ClearShadowModel(ctx context.Context, migrationID primitive.ObjectID) error {
+	_, err := s.mongodb.Collection(settingsCollection).UpdateOne(ctx,
+		bson.M{"_id": modelSettingsID, "migration_id": migrationID},
+		bson.M{
+			"$set":   bson.M{"updated_at": time.Now()},
+			"$unset": bson.M{"shadow_model": ""},
+		})
+	if err != nil {
+		return fmt.Errorf("failed to clear shadow embedding model: %w", err)
+	}
+	return s.LoadModels(ctx)
+}
+
+// EmbedShadow embeds a record of collection with the shadow model and stores the vector beside
+// the active one. A document is embedded passage by passage like GenerateDocumentEmbedding, and
+// its passages in document_chunks get their shadow vectors too.
+func (s *Service) EmbedShadow(ctx context.Context, collection string, id primitive.ObjectID) error {
+	shadow := s.models.Load().shadow
+	if shadow == nil {
+		return ErrNoShadowModel
+	}
+	switch collection {
+	case "documents":
+		return s.embedDocumentShadow(ctx, shadow, id)
+	case "knowledge_items":
+		return s.embedKnowledgeShadow(ctx, shadow, id)
+	default:
+		return fmt.Errorf("collection %s has no vectors to migrate", collection)
+	}
+}
+
+// embedDocumentShadow writes the shadow vectors of a document and its passages
+func (s *Service) embedDocumentShadow(ctx context.Context, shadow EmbeddingProvider, documentID primitive.ObjectID) error {
+	var document models.Document
+	err := s.mongodb.Collection("documents").FindOne(ctx, bson.M{"_id": documentID},
+		options.FindOne().SetProjection(bson.M{"embeddings": 0, "shadow_embeddings": 0})).Decode(&document)
+	if err != nil {
+		return fmt.Errorf("failed to find document: %w", err)
+	}
+
+	chunkCollection := s.mongodb.Collection("document_chunks")
+	cursor, err := chunkCollection.Find(ctx, bson.M{"document_id": documentID},
+		options.Find().SetProjection(bson.M{"embeddings": 0, "shadow_embeddings": 0}))
+	if err != nil {
+		return fmt.Errorf("failed to find document chunks: %w", err)
+	}
+	var chunks []models.DocumentChunk
+	if err := cursor.All(ctx, &chunks); err != nil {
+		return fmt.Errorf("failed to decode document chunks: %w", err)
+	}
+
+	// Documents embedded before chunking have no stored passages; their vector is the mean of
+	// passages cut as GenerateDocumentEmbedding would, which are not stored
+	stored := len(chunks) > 0
+	if !stored {
+		if document.RedactedContent != "" {
+			document.Content = document.RedactedContent
+		}
+		if chunks = s.chunker.Chunk(&document); len(chunks) == 0 {
+			return fmt.Errorf("document has no content to embed")
+		}
+	}
+
+	vectors := make([][]float64, len(chunks))
+	var writes []mongo.WriteModel
+	for i := range chunks {
+		vectors[i], err = s.embedWith(ctx, shadow, chunkEmbeddingText(&chunks[i]))
+		if err != nil {
+			return fmt.Errorf("failed to generate shadow embedding for chunk %d: %w", i, err)
+		}
+		if stored {
+			writes = append(writes, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": chunks[i].ID}).
+				SetUpdate(bson.M{"$set": shadowFields(shadow.Name(), vectors[i])}))
+		}
+	}
+	if len(writes) > 0 {
+		if _, err := chunkCollection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+			return fmt.Errorf("failed to store shadow embeddings of document chunks: %w", err)
+		}
+	}
+
+	_, err = s.mongodb.Collection("documents").UpdateOne(ctx, bson.M{"_id": documentID},
+		bson.M{"$set": shadowFields(shadow.Name(), meanVector(vectors))})
+	if err != nil {
+		return fmt.Errorf("failed to store shadow embedding of document: %w", err)
+	}
+	return nil
+}
+
+// embedKnowledgeShadow writes the shadow vector of a knowledge item
+func (s *Service) embedKnowledgeShadow(ctx context.Context, shadow EmbeddingProvider, knowledgeID primitive.ObjectID) error {
+	collection := s.mongodb.Collection("knowledge_items")
+	var knowledge models.KnowledgeItem
+	err := collection.FindOne(ctx, bson.M{"_id": knowledgeID},
+		options.FindOne().SetProjection(bson.M{"embeddings": 0, "shadow_embeddings": 0})).Decode(&knowledge)
+	if err != nil {
+		return fmt.Errorf("failed to find knowledge item: %w", err)
+	}
+
+	// The same text as GenerateKnowledgeEmbedding
+	text := knowledge.Title + "\n" + knowledge.Content
+	if knowledge.Summary != nil {
+		text += "\n" + *knowledge.Summary
+	}
+	vector, err := s.embedWith(ctx, shadow, text)
+	if err != nil {
+		return fmt.Errorf("failed to generate shadow embedding: %w", err)
+	}
+
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": knowledgeID}, bson.M{"$set": shadowFields(shadow.Name(), vector)})
+	if err != nil {
+		return fmt.Errorf("failed to store shadow embedding of knowledge item: %w", err)
+	}
+	return nil
+}
+
+// shadowFields are the fields holding a shadow vector of model
+func shadowFields(model string, vector []float64) bson.M {
+	return bson.M{
+		"shadow_embeddings":          vector,
+		"shadow_embedding_model":     model,
+		"shadow_embedding_dimension": len(vector),
+	}
+}
+
+// vectorUpdate is the update storing a record's vector of the active model of set, with its
+// shadow vector while a migration runs, and setting fields. Without a migration any shadow vector
+// is removed, being older than the new vector.
+func vectorUpdate(set *modelSet, vector, shadow []float64, fields bson.M) bson.M {
+	values := bson.M{
+		"embeddings":          vector,
+		"embedding_model":     set.active.Name(),
+		"embedding_dimension": len(vector),
+	}
+	for key, value := range fields {
+		values[key] = value
+	}
+	if set.shadow != nil {
+		for key, value := range shadowFields(set.shadow.Name(), shadow) {
+			values[key] = value
+		}
+		return bson.M{"$set": values}
+	}
+	return bson.M{
+		"$set":   values,
+		"$unset": bson.M{"shadow_embeddings": "", "shadow_embedding_model": "", "shadow_embedding_dimension": ""},
+	}
+}
+
+// vectorMatch matches the records holding a vector of model, either as their vector or, between a
+// cutover and the promotion of the shadow vectors, as their shadow vector. Vectors stored before
+// their model was recorded count as the active model's until a migration records it.
+func vectorMatch(model string) bson.M {
+	return bson.M{"$or": []bson.M{
+		{"embeddings": bson.M{"$exists": true, "$ne": nil}, "embedding_model": bson.M{"$in": bson.A{model, nil}}},
+		{"shadow_embedding_model": model},
+	}}
+}
+
+// withVector restricts filter to the records holding a vector of model
+func withVector(filter bson.M, model string) bson.M {
+	conditions, _ := filter["$and"].([]bson.M)
+	filter["$and"] = append(append([]bson.M(nil), conditions...), vectorMatch(model))
+	return filter
+}
+
+// vectorField is an aggregation expression for a record's vector of model
+func vectorField(model string) bson.M {
+	return bson.M{"$cond": bson.A{
+		bson.M{"$eq": bson.A{"$shadow_embedding_model", model}},
+		"$shadow_embeddings",
+		"$embeddings",
+	}}
+}
+
+// activeVector returns whichever of a record's vectors is of model, or nil
+func activeVector(model, vectorModel string, vector []float64, shadowModel string, shadow []float64) []float64 {
+	switch {
+	case shadowModel == model && len(shadow) > 0:
+		return shadow
+	case vectorModel == model || vectorModel == "":
+		return vector
+	default:
+		return nil
+	}
+}
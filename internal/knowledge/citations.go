@@ -0,0 +1,120 @@
+package knowledge
+
+import (
+	"context"
+	"strings"
+
+	"ai-government-consultant/internal/citation"
+	"ai-government-consultant/internal/models"
+)
+
+// citationEdgePrefix starts the context of every depends_on relationship derived from a
+// citation, which tells them apart from relationships added by hand
+const citationEdgePrefix = "cites "
+
+// maxCitationLinks bounds how many items are linked in one pass
+const maxCitationLinks = 500
+
+// applyCitations records the laws and policies an item cites. A regulation item whose title
+// names a single citation, such as "2 CFR 200 - Uniform Guidance", records that citation as
+// its authority so items citing it can depend on it.
+func applyCitations(item *models.KnowledgeItem) {
+	if item.Authority == "" && item.Type == models.KnowledgeTypeRegulation {
+		if id, ok := citation.Normalize(item.Title); ok {
+			item.Authority = id
+		}
+	}
+
+	// An item does not cite the regulation it records
+	item.Citations = []string{}
+	for _, id := range citation.IDs(citation.Parse(item.Title + "\n" + item.Content)) {
+		if id != item.Authority {
+			item.Citations = append(item.Citations, id)
+		}
+	}
+	item.CitationKeys = citation.Keys(item.Citations)
+}
+
+// linkCitations adds a depends_on relationship from the item to every regulation item it cites
+// and, if the item records a regulation, from every item citing that regulation to it
+func (s *Service) linkCitations(ctx context.Context, item *models.KnowledgeItem) {
+	if len(item.CitationKeys) > 0 {
+		targets, err := s.repository.GetByAuthority(ctx, item.CitationKeys, maxCitationLinks)
+		if err != nil {
+			s.logger.Error("Failed to find cited knowledge items", err, map[string]interface{}{
+				"id": item.ID.Hex(),
+			})
+		}
+		for _, target := range targets {
+			s.addCitationEdge(ctx, item, target)
+		}
+	}
+
+	if item.Authority != "" {
+		citing, err := s.repository.GetCiting(ctx, item.Authority, maxCitationLinks)
+		if err != nil {
+			s.logger.Error("Failed to find citing knowledge items", err, map[string]interface{}{
+				"id": item.ID.Hex(),
+			})
+		}
+		for _, source := range citing {
+			s.addCitationEdge(ctx, source, item)
+		}
+	}
+}
+
+// addCitationEdge records that source depends on target because it cites target's authority
+func (s *Service) addCitationEdge(ctx context.Context, source, target *models.KnowledgeItem) {
+	if source.ID == target.ID {
+		return
+	}
+	for _, rel := range source.Relationships {
+		if rel.Type == models.RelationshipTypeDependsOn && rel.TargetID == target.ID {
+			return
+		}
+	}
+
+	var cited []string
+	for _, id := range source.Citations {
+		if citation.Within(id, target.Authority) {
+			cited = append(cited, id)
+		}
+	}
+	if len(cited) == 0 {
+		return
+	}
+
+	relContext := citationEdgePrefix + strings.Join(cited, ", ")
+	if err := s.repository.AddRelationship(ctx, source.ID, target.ID, models.RelationshipTypeDependsOn, 1.0, relContext); err != nil {
+		s.logger.Error("Failed to link citing knowledge item", err, map[string]interface{}{
+			"source_id": source.ID.Hex(),
+			"target_id": target.ID.Hex(),
+		})
+		return
+	}
+	source.Relationships = append(source.Relationships, models.KnowledgeRelationship{
+		Type:     models.RelationshipTypeDependsOn,
+		TargetID: target.ID,
+		Strength: 1.0,
+		Context:  relContext,
+	})
+}
+
+// unlinkCitations removes the item's citation-derived depends_on relationships, before they
+// are rebuilt for changed content
+func (s *Service) unlinkCitations(ctx context.Context, item *models.KnowledgeItem) {
+	kept := item.Relationships[:0]
+	for _, rel := range item.Relationships {
+		if rel.Type == models.RelationshipTypeDependsOn && strings.HasPrefix(rel.Context, citationEdgePrefix) {
+			if err := s.repository.RemoveRelationship(ctx, item.ID, rel.TargetID, rel.Type); err != nil {
+				s.logger.Error("Failed to unlink citing knowledge item", err, map[string]interface{}{
+					"source_id": item.ID.Hex(),
+					"target_id": rel.TargetID.Hex(),
+				})
+			}
+			continue
+		}
+		kept = append(kept, rel)
+	}
+	item.Relationships = kept
+}
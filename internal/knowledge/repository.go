@@ -97,6 +97,14 @@ func (r *Repository) CreateIndexes(ctx context.Context) error {
 			Keys:    bson.D{{Key: "relationships.type", Value: 1}},
 			Options: options.Index().SetName("relationships_type_index"),
 		},
+		{
+			Keys:    bson.D{{Key: "citation_keys", Value: 1}},
+			Options: options.Index().SetName("citation_keys_index"),
+		},
+		{
+			Keys:    bson.D{{Key: "authority", Value: 1}},
+			Options: options.Index().SetName("authority_index"),
+		},
 		{
 			Keys:    bson.D{{Key: "usage.access_count", Value: -1}},
 			Options: options.Index().SetName("usage_count_index"),
@@ -223,6 +231,7 @@ type SearchFilter struct {
 	MinEffectiveness   float64                   `json:"min_effectiveness"`
 	RelationshipType   models.RelationshipType   `json:"relationship_type"`
 	RelationshipTarget *primitive.ObjectID       `json:"relationship_target"`
+	WorkspaceIDs       []primitive.ObjectID      `json:"workspace_ids"` // items in any of these workspaces
 	DateFrom           *time.Time                `json:"date_from"`
 	DateTo             *time.Time                `json:"date_to"`
 	Limit              int                       `json:"limit"`
@@ -300,6 +309,11 @@ func (r *Repository) Search(ctx context.Context, filter SearchFilter) ([]*models
 		}
 	}
 
+	// Workspace filter
+	if len(filter.WorkspaceIDs) > 0 {
+		query["workspace_ids"] = bson.M{"$in": filter.WorkspaceIDs}
+	}
+
 	// Usage filters
 	if filter.MinUsageCount > 0 {
 		query["usage.access_count"] = bson.M{"$gte": filter.MinUsageCount}
@@ -514,6 +528,63 @@ func (r *Repository) GetRelatedItems(ctx context.Context, itemID primitive.Objec
 	return items, nil
 }
 
+// GetByAuthority retrieves knowledge items recording any of the given laws or regulations
+func (r *Repository) GetByAuthority(ctx context.Context, authorities []string, limit int) ([]*models.KnowledgeItem, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := bson.M{
+		"authority": bson.M{"$in": authorities},
+		"is_active": true,
+	}
+
+	findOptions := options.Find()
+	findOptions.SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get knowledge items by authority: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var items []*models.KnowledgeItem
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, fmt.Errorf("failed to decode knowledge items: %w", err)
+	}
+
+	return items, nil
+}
+
+// GetCiting retrieves knowledge items citing a law or regulation, or anything under it
+func (r *Repository) GetCiting(ctx context.Context, citation string, limit int) ([]*models.KnowledgeItem, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := bson.M{
+		"citation_keys": citation,
+		"is_active":     true,
+	}
+
+	findOptions := options.Find()
+	findOptions.SetLimit(int64(limit))
+	findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}}) // Sort by creation date, newest first
+
+	cursor, err := r.collection.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get citing knowledge items: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var items []*models.KnowledgeItem
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, fmt.Errorf("failed to decode knowledge items: %w", err)
+	}
+
+	return items, nil
+}
+
 // GetExpiredItems retrieves knowledge items that have expired
 func (r *Repository) GetExpiredItems(ctx context.Context, limit int) ([]*models.KnowledgeItem, error) {
 	if limit <= 0 {
@@ -68,12 +68,18 @@ func (s *Service) CreateKnowledgeItem(ctx context.Context, item *models.Knowledg
 		item.Keywords = s.extractKeywords(item.Content)
 	}
 
+	// Record the laws and policies the item cites
+	applyCitations(item)
+
 	// Create the item
 	err := s.repository.Create(ctx, item)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create knowledge item: %w", err)
 	}
 
+	// Link the item to the regulations it cites, and the items citing it
+	s.linkCitations(ctx, item)
+
 	s.logger.Info("Created knowledge item", map[string]interface{}{
 		"id":       item.ID.Hex(),
 		"type":     item.Type,
@@ -100,7 +106,7 @@ func (s *Service) GetKnowledgeItem(ctx context.Context, id primitive.ObjectID) (
 // UpdateKnowledgeItem updates an existing knowledge item
 func (s *Service) UpdateKnowledgeItem(ctx context.Context, id primitive.ObjectID, updates map[string]interface{}) (*models.KnowledgeItem, error) {
 	// Get the current item to validate updates
-	_, err := s.repository.GetByID(ctx, id)
+	current, err := s.repository.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -130,6 +136,34 @@ func (s *Service) UpdateKnowledgeItem(ctx context.Context, id primitive.ObjectID
 		}
 	}
 
+	// Re-extract citations if the text or type changes
+	_, contentChanged := updates["content"]
+	_, titleChanged := updates["title"]
+	_, typeChanged := updates["type"]
+	recite := contentChanged || titleChanged || typeChanged
+	if recite {
+		revised := *current
+		if content, ok := updates["content"].(string); ok {
+			revised.Content = content
+		}
+		if title, ok := updates["title"].(string); ok {
+			revised.Title = title
+		}
+		if knowledgeType, ok := updates["type"].(string); ok {
+			revised.Type = models.KnowledgeType(knowledgeType)
+		}
+		if titleChanged || typeChanged {
+			revised.Authority = ""
+		}
+		if authority, ok := updates["authority"].(string); ok {
+			revised.Authority = authority
+		}
+		applyCitations(&revised)
+		updates["citations"] = revised.Citations
+		updates["citation_keys"] = revised.CitationKeys
+		updates["authority"] = revised.Authority
+	}
+
 	// Update the item
 	err = s.repository.Update(ctx, id, updates)
 	if err != nil {
@@ -142,6 +176,11 @@ func (s *Service) UpdateKnowledgeItem(ctx context.Context, id primitive.ObjectID
 		return nil, err
 	}
 
+	if recite {
+		s.unlinkCitations(ctx, updatedItem)
+		s.linkCitations(ctx, updatedItem)
+	}
+
 	s.logger.Info("Updated knowledge item", map[string]interface{}{
 		"id":      id.Hex(),
 		"version": updatedItem.Version,
@@ -18,6 +18,8 @@ type RepositoryInterface interface {
 	GetByType(ctx context.Context, knowledgeType models.KnowledgeType, limit int) ([]*models.KnowledgeItem, error)
 	GetBySource(ctx context.Context, sourceType string, sourceID primitive.ObjectID, limit int) ([]*models.KnowledgeItem, error)
 	GetRelatedItems(ctx context.Context, itemID primitive.ObjectID, relationshipType models.RelationshipType, limit int) ([]*models.KnowledgeItem, error)
+	GetByAuthority(ctx context.Context, authorities []string, limit int) ([]*models.KnowledgeItem, error)
+	GetCiting(ctx context.Context, citation string, limit int) ([]*models.KnowledgeItem, error)
 	GetExpiredItems(ctx context.Context, limit int) ([]*models.KnowledgeItem, error)
 	UpdateUsage(ctx context.Context, id primitive.ObjectID, context string) error
 	AddRelationship(ctx context.Context, sourceID, targetID primitive.ObjectID, relType models.RelationshipType, strength float64, relationshipContext string) error
@@ -0,0 +1,309 @@
+// Package metadata implements department-defined metadata schemas: admin-managed definitions of
+// the custom fields documents of a department or category must carry, which are enforced when
+// documents are uploaded or updated and indexed so documents can be filtered by them.
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"ai-government-consultant/internal/models"
+	"ai-government-consultant/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxIndexedFields bounds the custom fields indexed across all schemas, since every one is an
+// index on the documents collection
+const maxIndexedFields = 20
+
+// customFieldsPath is the document field holding custom metadata fields
+const customFieldsPath = "metadata.custom_fields."
+
+var (
+	// ErrNotFound is returned when a metadata schema does not exist
+	ErrNotFound = errors.New("not found")
+
+	// ErrFieldConflict is returned when a schema declares a field another schema declares with a
+	// different type
+	ErrFieldConflict = errors.New("field is declared with another type")
+
+	// ErrTooManyIndexedFields is returned when a schema would take the number of indexed fields
+	// above the limit
+	ErrTooManyIndexedFields = errors.New("too many indexed fields")
+)
+
+// Service handles metadata schemas and validates document metadata against them
+type Service struct {
+	schemas   *mongo.Collection
+	documents *mongo.Collection
+	logger    logger.Logger
+}
+
+// NewService creates a new metadata schema service
+func NewService(db *mongo.Database, log logger.Logger) *Service {
+	return &Service{
+		schemas:   db.Collection("metadata_schemas"),
+		documents: db.Collection("documents"),
+		logger:    log,
+	}
+}
+
+// CreateSchema creates a metadata schema and indexes its indexed fields. Existing documents are
+// not checked; they are validated against the schema when their metadata is next updated.
+func (s *Service) CreateSchema(ctx context.Context, schema *models.MetadataSchema) error {
+	now := time.Now()
+	schema.ID = primitive.NewObjectID()
+	normalizeSchema(schema)
+	schema.CreatedAt = now
+	schema.UpdatedAt = now
+	if err := schema.Validate(); err != nil {
+		return err
+	}
+	if err := s.checkCompatible(ctx, schema); err != nil {
+		return err
+	}
+	if err := s.ensureIndexes(ctx, schema); err != nil {
+		return err
+	}
+
+	if _, err := s.schemas.InsertOne(ctx, schema); err != nil {
+		return fmt.Errorf("failed to create metadata schema: %w", err)
+	}
+	return nil
+}
+
+// GetSchema returns a metadata schema by ID
+func (s *Service) GetSchema(ctx context.Context, id primitive.ObjectID) (*models.MetadataSchema, error) {
+	var schema models.MetadataSchema
+	if err := s.schemas.FindOne(ctx, bson.M{"_id": id}).Decode(&schema); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to find metadata schema: %w", err)
+	}
+	return &schema, nil
+}
+
+// ListSchemas returns the metadata schemas by name, optionally only those bound to a department
+// or category
+func (s *Service) ListSchemas(ctx context.Context, department string, category models.DocumentCategory) ([]*models.MetadataSchema, error) {
+	schemas, err := s.allSchemas(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	department = strings.TrimSpace(department)
+	matched := []*models.MetadataSchema{}
+	for _, schema := range schemas {
+		if department != "" && !strings.EqualFold(schema.Department, department) {
+			continue
+		}
+		if category != "" && schema.Category != category {
+			continue
+		}
+		matched = append(matched, schema)
+	}
+	return matched, nil
+}
+
+// UpdateSchema replaces the definition of a metadata schema. Indexes of fields no schema indexes
+// any more are dropped.
+func (s *Service) UpdateSchema(ctx context.Context, id primitive.ObjectID, changes *models.MetadataSchema) (*models.MetadataSchema, error) {
+	schema, err := s.GetSchema(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	schema.Name = changes.Name
+	schema.Description = changes.Description
+	schema.Department = changes.Department
+	schema.Category = changes.Category
+	schema.Properties = changes.Properties
+	schema.Required = changes.Required
+	normalizeSchema(schema)
+	schema.UpdatedAt = time.Now()
+	if err := schema.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkCompatible(ctx, schema); err != nil {
+		return nil, err
+	}
+	if err := s.ensureIndexes(ctx, schema); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.schemas.ReplaceOne(ctx, bson.M{"_id": id}, schema); err != nil {
+		return nil, fmt.Errorf("failed to update metadata schema: %w", err)
+	}
+	s.dropUnusedIndexes(ctx)
+	return schema, nil
+}
+
+// DeleteSchema deletes a metadata schema. The custom fields of documents are kept.
+func (s *Service) DeleteSchema(ctx context.Context, id primitive.ObjectID) error {
+	result, err := s.schemas.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete metadata schema: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	s.dropUnusedIndexes(ctx)
+	return nil
+}
+
+// Applicable returns the schemas that apply to documents with the given metadata, by name
+func (s *Service) Applicable(ctx context.Context, metadata models.DocumentMetadata) ([]*models.MetadataSchema, error) {
+	schemas, err := s.allSchemas(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	applicable := []*models.MetadataSchema{}
+	for _, schema := range schemas {
+		if schema.AppliesTo(metadata) {
+			applicable = append(applicable, schema)
+		}
+	}
+	return applicable, nil
+}
+
+// allSchemas returns every metadata schema, by name
+func (s *Service) allSchemas(ctx context.Context) ([]*models.MetadataSchema, error) {
+	cursor, err := s.schemas.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find metadata schemas: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	schemas := []*models.MetadataSchema{}
+	if err := cursor.All(ctx, &schemas); err != nil {
+		return nil, fmt.Errorf("failed to decode metadata schemas: %w", err)
+	}
+	return schemas, nil
+}
+
+// checkCompatible makes sure a schema agrees with every other schema on the types of the fields
+// they share, so a field filter has one meaning, and keeps the indexed fields within the limit
+func (s *Service) checkCompatible(ctx context.Context, schema *models.MetadataSchema) error {
+	schemas, err := s.allSchemas(ctx)
+	if err != nil {
+		return err
+	}
+
+	indexed := make(map[string]bool)
+	for name, property := range schema.Properties {
+		if property.Indexed {
+			indexed[name] = true
+		}
+	}
+	for _, other := range schemas {
+		if other.ID == schema.ID {
+			continue
+		}
+		for name, property := range other.Properties {
+			if own, ok := schema.Properties[name]; ok && own.Type != property.Type {
+				return fmt.Errorf("%w: %s is a %s field in schema %q", ErrFieldConflict, name, property.Type, other.Name)
+			}
+			if property.Indexed {
+				indexed[name] = true
+			}
+		}
+	}
+	if len(indexed) > maxIndexedFields {
+		return fmt.Errorf("%w: at most %d fields can be indexed", ErrTooManyIndexedFields, maxIndexedFields)
+	}
+	return nil
+}
+
+// ensureIndexes creates the document indexes of a schema's indexed fields. Each is a partial
+// index, so documents without the field take no space in it.
+func (s *Service) ensureIndexes(ctx context.Context, schema *models.MetadataSchema) error {
+	for name, property := range schema.Properties {
+		if !property.Indexed {
+			continue
+		}
+		path := customFieldsPath + name
+		index := mongo.IndexModel{
+			Keys: bson.D{{Key: path, Value: 1}},
+			Options: options.Index().
+				SetName(indexName(name)).
+				SetPartialFilterExpression(bson.M{path: bson.M{"$exists": true}}),
+		}
+		if _, err := s.documents.Indexes().CreateOne(ctx, index); err != nil {
+			return fmt.Errorf("failed to create index %s: %w", indexName(name), err)
+		}
+	}
+	return nil
+}
+
+// dropUnusedIndexes drops the indexes of custom fields no schema indexes any more. A failure is
+// only logged; an extra index costs space but does not change any result.
+func (s *Service) dropUnusedIndexes(ctx context.Context) {
+	schemas, err := s.allSchemas(ctx)
+	if err != nil {
+		s.logger.Error("Failed to find unused custom field indexes", err, nil)
+		return
+	}
+	used := make(map[string]bool)
+	for _, schema := range schemas {
+		for name, property := range schema.Properties {
+			if property.Indexed {
+				used[indexName(name)] = true
+			}
+		}
+	}
+
+	cursor, err := s.documents.Indexes().List(ctx)
+	if err != nil {
+		s.logger.Error("Failed to list document indexes", err, nil)
+		return
+	}
+	var indexes []struct {
+		Name string `bson:"name"`
+	}
+	if err := cursor.All(ctx, &indexes); err != nil {
+		s.logger.Error("Failed to decode document indexes", err, nil)
+		return
+	}
+	for _, index := range indexes {
+		if !strings.HasPrefix(index.Name, "custom_field_") || used[index.Name] {
+			continue
+		}
+		if _, err := s.documents.Indexes().DropOne(ctx, index.Name); err != nil {
+			s.logger.Error("Failed to drop custom field index", err, map[string]interface{}{
+				"index": index.Name,
+			})
+		}
+	}
+}
+
+// indexName is the name of the index of a custom field
+func indexName(field string) string {
+	return "custom_field_" + field + "_index"
+}
+
+// normalizeSchema trims a schema's names and orders its required fields
+func normalizeSchema(schema *models.MetadataSchema) {
+	schema.Name = strings.TrimSpace(schema.Name)
+	schema.Department = strings.TrimSpace(schema.Department)
+
+	seen := make(map[string]bool, len(schema.Required))
+	required := []string{}
+	for _, name := range schema.Required {
+		name = strings.TrimSpace(name)
+		if name != "" && !seen[name] {
+			seen[name] = true
+			required = append(required, name)
+		}
+	}
+	sort.Strings(required)
+	schema.Required = required
+}
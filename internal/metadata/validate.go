@@ -0,0 +1,319 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ai-government-consultant/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrInvalidMetadata is wrapped by a ValidationError
+	ErrInvalidMetadata = errors.New("custom fields do not match the metadata schema")
+
+	// ErrInvalidFilter is returned when a custom field filter names a field that is not indexed
+	// or has a value of the wrong type
+	ErrInvalidFilter = errors.New("invalid custom field filter")
+)
+
+// FieldError is one custom field that breaks a metadata schema
+type FieldError struct {
+	Field   string `json:"field"`
+	Schema  string `json:"schema"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every custom field of a document that breaks one of its metadata schemas
+type ValidationError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		problems[i] = field.Field + " " + field.Message
+	}
+	return fmt.Sprintf("%s: %s", ErrInvalidMetadata, strings.Join(problems, "; "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidMetadata
+}
+
+// Validate checks the custom fields of metadata against every schema that applies to it, and
+// converts the fields the schemas declare to their types, e.g. "2025" to the integer 2025 and
+// "2025-10-01" to a date. Fields no schema declares are kept as they are. A *ValidationError
+// lists every problem found.
+func (s *Service) Validate(ctx context.Context, metadata *models.DocumentMetadata) error {
+	schemas, err := s.Applicable(ctx, *metadata)
+	if err != nil {
+		return err
+	}
+	if len(schemas) == 0 {
+		return nil
+	}
+
+	fields := make(map[string]interface{}, len(metadata.CustomFields))
+	for key, value := range metadata.CustomFields {
+		fields[key] = value
+	}
+
+	var problems []FieldError
+	reported := make(map[string]bool)
+	report := func(field, schema, message string) {
+		if !reported[field+"\x00"+message] {
+			reported[field+"\x00"+message] = true
+			problems = append(problems, FieldError{Field: field, Schema: schema, Message: message})
+		}
+	}
+	for _, schema := range schemas {
+		for _, name := range sortedFields(schema) {
+			property := schema.Properties[name]
+			value, present := fields[name]
+			if !present || isEmpty(value) {
+				if schema.IsRequired(name) {
+					report(name, schema.Name, "is required")
+				}
+				continue
+			}
+
+			converted, err := convert(property.Type, value)
+			if err == nil {
+				err = checkConstraints(property, converted)
+			}
+			if err != nil {
+				report(name, schema.Name, err.Error())
+				continue
+			}
+			fields[name] = converted
+		}
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Fields: problems}
+	}
+
+	metadata.CustomFields = fields
+	return nil
+}
+
+// Inherit copies the fields the schemas applying to metadata declare from previous, unless
+// metadata sets them itself. A new version of a document keeps its schema fields this way.
+func (s *Service) Inherit(ctx context.Context, metadata *models.DocumentMetadata, previous map[string]interface{}) error {
+	schemas, err := s.Applicable(ctx, *metadata)
+	if err != nil {
+		return err
+	}
+	for _, schema := range schemas {
+		for name := range schema.Properties {
+			value, ok := previous[name]
+			if !ok {
+				continue
+			}
+			if metadata.CustomFields == nil {
+				metadata.CustomFields = make(map[string]interface{})
+			}
+			if _, exists := metadata.CustomFields[name]; !exists {
+				metadata.CustomFields[name] = value
+			}
+		}
+	}
+	return nil
+}
+
+// FilterValues converts custom field filters given as text, e.g. from a query string, to the
+// values stored for those fields. Only indexed fields can be filtered on.
+func (s *Service) FilterValues(ctx context.Context, filters map[string]string) (map[string]interface{}, error) {
+	if len(filters) == 0 {
+		return nil, nil
+	}
+	schemas, err := s.allSchemas(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// checkCompatible keeps a field's type the same in every schema declaring it
+	types := make(map[string]models.MetadataFieldType)
+	for _, schema := range schemas {
+		for name, property := range schema.Properties {
+			if property.Indexed {
+				types[name] = property.Type
+			}
+		}
+	}
+
+	values := make(map[string]interface{}, len(filters))
+	for name, text := range filters {
+		fieldType, ok := types[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not an indexed field", ErrInvalidFilter, name)
+		}
+		value, err := convert(fieldType, text)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s %s", ErrInvalidFilter, name, err.Error())
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// sortedFields returns the names of a schema's fields in order, so problems are reported in a
+// stable order
+func sortedFields(schema *models.MetadataSchema) []string {
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isEmpty reports whether a field value counts as missing
+func isEmpty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	text, ok := value.(string)
+	return ok && strings.TrimSpace(text) == ""
+}
+
+// convert converts a field value to the stored form of its type. Values may be given as text,
+// as they are by form uploads and CSV manifests.
+func convert(fieldType models.MetadataFieldType, value interface{}) (interface{}, error) {
+	switch fieldType {
+	case models.MetadataFieldString:
+		if text, ok := value.(string); ok {
+			return text, nil
+		}
+		return nil, errors.New("must be a string")
+
+	case models.MetadataFieldInteger:
+		number, ok := toNumber(value)
+		if !ok || number != math.Trunc(number) || math.Abs(number) > 1<<53 {
+			return nil, errors.New("must be an integer")
+		}
+		return int64(number), nil
+
+	case models.MetadataFieldNumber:
+		number, ok := toNumber(value)
+		if !ok {
+			return nil, errors.New("must be a number")
+		}
+		return number, nil
+
+	case models.MetadataFieldBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			if parsed, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return parsed, nil
+			}
+		}
+		return nil, errors.New("must be true or false")
+
+	case models.MetadataFieldDate:
+		switch v := value.(type) {
+		case time.Time:
+			return v.UTC(), nil
+		case primitive.DateTime:
+			return v.Time().UTC(), nil
+		case string:
+			text := strings.TrimSpace(v)
+			if parsed, err := time.Parse("2006-01-02", text); err == nil {
+				return parsed, nil
+			}
+			if parsed, err := time.Parse(time.RFC3339, text); err == nil {
+				return parsed.UTC(), nil
+			}
+		}
+		return nil, errors.New("must be a date (YYYY-MM-DD or RFC 3339)")
+	}
+	return nil, fmt.Errorf("has unknown type %q", fieldType)
+}
+
+// toNumber reads a finite number from a decoded JSON, BSON or text value
+func toNumber(value interface{}) (float64, bool) {
+	var number float64
+	switch v := value.(type) {
+	case float64:
+		number = v
+	case float32:
+		number = float64(v)
+	case int:
+		number = float64(v)
+	case int32:
+		number = float64(v)
+	case int64:
+		number = float64(v)
+	case json.Number:
+		parsed, err := v.Float64()
+		if err != nil {
+			return 0, false
+		}
+		number = parsed
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, false
+		}
+		number = parsed
+	default:
+		return 0, false
+	}
+	return number, !math.IsNaN(number) && !math.IsInf(number, 0)
+}
+
+// checkConstraints checks a converted value against the constraints of its property
+func checkConstraints(property models.MetadataProperty, value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		length := utf8.RuneCountInString(v)
+		if property.MinLength != nil && length < *property.MinLength {
+			return fmt.Errorf("must be at least %d characters", *property.MinLength)
+		}
+		if property.MaxLength != nil && length > *property.MaxLength {
+			return fmt.Errorf("must be at most %d characters", *property.MaxLength)
+		}
+		if property.Pattern != "" {
+			pattern, err := regexp.Compile(property.Pattern)
+			if err != nil || !pattern.MatchString(v) {
+				return fmt.Errorf("must match the pattern %s", property.Pattern)
+			}
+		}
+		if len(property.Enum) > 0 {
+			for _, allowed := range property.Enum {
+				if v == allowed {
+					return nil
+				}
+			}
+			return fmt.Errorf("must be one of %s", strings.Join(property.Enum, ", "))
+		}
+	case int64:
+		return checkRange(property, float64(v))
+	case float64:
+		return checkRange(property, v)
+	}
+	return nil
+}
+
+// checkRange checks a number against the minimum and maximum of its property
+func checkRange(property models.MetadataProperty, number float64) error {
+	if property.Minimum != nil && number < *property.Minimum {
+		return fmt.Errorf("must be at least %s", strconv.FormatFloat(*property.Minimum, 'f', -1, 64))
+	}
+	if property.Maximum != nil && number > *property.Maximum {
+		return fmt.Errorf("must be at most %s", strconv.FormatFloat(*property.Maximum, 'f', -1, 64))
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AnnotationStatus represents the state of an annotation thread
+type AnnotationStatus string
+
+const (
+	AnnotationStatusOpen     AnnotationStatus = "open"
+	AnnotationStatusResolved AnnotationStatus = "resolved"
+)
+
+// IsValid reports whether s is a known annotation status
+func (s AnnotationStatus) IsValid() bool {
+	return s == AnnotationStatusOpen || s == AnnotationStatusResolved
+}
+
+// TextAnchor locates the annotated passage of a document version. Start and End are character
+// offsets into the document's extracted text, End exclusive.
+type TextAnchor struct {
+	Start int    `json:"start" bson:"start"`
+	End   int    `json:"end" bson:"end"`
+	Quote string `json:"quote" bson:"quote"` // the passage when it was annotated, with personal data redacted
+}
+
+// AnnotationReply is a reply in an annotation thread
+type AnnotationReply struct {
+	ID        primitive.ObjectID   `json:"id" bson:"_id"`
+	Body      string               `json:"body" bson:"body"`
+	Mentions  []primitive.ObjectID `json:"mentions" bson:"mentions"`
+	CreatedBy primitive.ObjectID   `json:"created_by" bson:"created_by"`
+	CreatedAt time.Time            `json:"created_at" bson:"created_at"`
+}
+
+// Annotation is a comment thread anchored to a passage of one version of a document. Anchors do
+// not move between versions: an annotation stays with the version it was made on, and the
+// document's other versions are found through SeriesID.
+type Annotation struct {
+	ID         primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	DocumentID primitive.ObjectID   `json:"document_id" bson:"document_id"`
+	SeriesID   primitive.ObjectID   `json:"series_id" bson:"series_id"`
+	Version    int                  `json:"version" bson:"version"`
+	Anchor     TextAnchor           `json:"anchor" bson:"anchor"`
+	Body       string               `json:"body" bson:"body"`
+	Mentions   []primitive.ObjectID `json:"mentions" bson:"mentions"`
+	Replies    []AnnotationReply    `json:"replies" bson:"replies"`
+	Status     AnnotationStatus     `json:"status" bson:"status"`
+	ResolvedBy *primitive.ObjectID  `json:"resolved_by,omitempty" bson:"resolved_by,omitempty"`
+	ResolvedAt *time.Time           `json:"resolved_at,omitempty" bson:"resolved_at,omitempty"`
+	CreatedBy  primitive.ObjectID   `json:"created_by" bson:"created_by"`
+	CreatedAt  time.Time            `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time            `json:"updated_at" bson:"updated_at"`
+}
+
+// Validate validates the annotation model
+func (a *Annotation) Validate() error {
+	if a.DocumentID.IsZero() {
+		return ErrAnnotationDocumentRequired
+	}
+	if strings.TrimSpace(a.Body) == "" {
+		return ErrAnnotationBodyRequired
+	}
+	if a.Anchor.Start < 0 || a.Anchor.End <= a.Anchor.Start {
+		return ErrAnnotationAnchorInvalid
+	}
+	if !a.Status.IsValid() {
+		return ErrAnnotationStatusInvalid
+	}
+	if a.CreatedBy.IsZero() {
+		return ErrAnnotationCreatedByRequired
+	}
+	return nil
+}
+
+// Participants returns the users taking part in the thread: its author, the authors of its
+// replies and the users mentioned in it
+func (a *Annotation) Participants() []primitive.ObjectID {
+	seen := make(map[primitive.ObjectID]bool)
+	var participants []primitive.ObjectID
+	add := func(ids ...primitive.ObjectID) {
+		for _, id := range ids {
+			if !id.IsZero() && !seen[id] {
+				seen[id] = true
+				participants = append(participants, id)
+			}
+		}
+	}
+
+	add(a.CreatedBy)
+	add(a.Mentions...)
+	for _, reply := range a.Replies {
+		add(reply.CreatedBy)
+		add(reply.Mentions...)
+	}
+	return participants
+}
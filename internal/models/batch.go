@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BatchStatus represents the state of a bulk archive upload
+type BatchStatus string
+
+const (
+	BatchStatusPending             BatchStatus = "pending"    // archive stored, waiting to be unpacked
+	BatchStatusExtracting          BatchStatus = "extracting" // entries are being turned into documents
+	BatchStatusProcessing          BatchStatus = "processing" // every entry has a document; some are still being processed
+	BatchStatusCompleted           BatchStatus = "completed"
+	BatchStatusCompletedWithErrors BatchStatus = "completed_with_errors"
+	BatchStatusFailed              BatchStatus = "failed" // the archive could not be read
+)
+
+// Batch item statuses recorded while unpacking; once an entry has a document, its
+// status follows the document's processing status
+const (
+	BatchItemSkipped   = "skipped"   // not a supported document, e.g. an image or a directory entry
+	BatchItemFailed    = "failed"    // the entry could not be stored
+	BatchItemDuplicate = "duplicate" // rejected as identical to an existing document
+)
+
+// BatchManifestEntry is the per-file metadata and classification given in an upload manifest
+type BatchManifestEntry struct {
+	Path           string                 `json:"path" bson:"path"`
+	Title          string                 `json:"title,omitempty" bson:"title,omitempty"`
+	Author         string                 `json:"author,omitempty" bson:"author,omitempty"`
+	Department     string                 `json:"department,omitempty" bson:"department,omitempty"`
+	Category       DocumentCategory       `json:"category,omitempty" bson:"category,omitempty"`
+	Tags           []string               `json:"tags,omitempty" bson:"tags,omitempty"`
+	Language       string                 `json:"language,omitempty" bson:"language,omitempty"`
+	Classification string                 `json:"classification,omitempty" bson:"classification,omitempty"`
+	Compartments   []string               `json:"compartments,omitempty" bson:"compartments,omitempty"`
+	Handling       []string               `json:"handling,omitempty" bson:"handling,omitempty"`
+	CustomFields   map[string]interface{} `json:"custom_fields,omitempty" bson:"custom_fields,omitempty"`
+}
+
+// BatchItem tracks one archive entry
+type BatchItem struct {
+	Path        string              `json:"path" bson:"path"`
+	DocumentID  *primitive.ObjectID `json:"document_id,omitempty" bson:"document_id,omitempty"`
+	Status      string              `json:"status" bson:"status"`
+	Error       string              `json:"error,omitempty" bson:"error,omitempty"`
+	DuplicateOf *primitive.ObjectID `json:"duplicate_of,omitempty" bson:"duplicate_of,omitempty"`
+}
+
+// BatchProgress counts a batch's entries by status
+type BatchProgress struct {
+	Total      int `json:"total"`
+	Pending    int `json:"pending"`
+	Processing int `json:"processing"`
+	Completed  int `json:"completed"`
+	Failed     int `json:"failed"`
+	Skipped    int `json:"skipped"`
+	Duplicate  int `json:"duplicate"`
+}
+
+// IngestBatch is a bulk upload of a ZIP or TAR archive, each supported entry becoming a document
+type IngestBatch struct {
+	ID              primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	ArchiveName     string               `json:"archive_name" bson:"archive_name"`
+	ArchiveKey      string               `json:"-" bson:"archive_key,omitempty"` // blob storage key of the archive until it is unpacked
+	ArchiveSize     int64                `json:"archive_size" bson:"archive_size"`
+	Format          string               `json:"format" bson:"format"` // "zip", "tar" or "tar.gz"
+	Status          BatchStatus          `json:"status" bson:"status"`
+	Error           string               `json:"error,omitempty" bson:"error,omitempty"`
+	Defaults        BatchManifestEntry   `json:"defaults" bson:"defaults"` // metadata applied to entries the manifest does not describe
+	Manifest        []BatchManifestEntry `json:"-" bson:"manifest,omitempty"`
+	DuplicatePolicy DuplicatePolicy      `json:"duplicate_policy,omitempty" bson:"duplicate_policy,omitempty"`
+	Items           []BatchItem          `json:"items,omitempty" bson:"items"`
+	Progress        *BatchProgress       `json:"progress,omitempty" bson:"-"`
+	CreatedBy       primitive.ObjectID   `json:"created_by" bson:"created_by"`
+	CreatedAt       time.Time            `json:"created_at" bson:"created_at"`
+	UpdatedAt       time.Time            `json:"updated_at" bson:"updated_at"`
+	CompletedAt     *time.Time           `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+}
@@ -0,0 +1,189 @@
+package models
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ConnectorType names the kind of storage a connector syncs documents from
+type ConnectorType string
+
+const (
+	ConnectorTypeDirectory ConnectorType = "directory" // a directory on the server, e.g. a mounted shared drive
+	ConnectorTypeWebDAV    ConnectorType = "webdav"    // a WebDAV collection
+	ConnectorTypeS3        ConnectorType = "s3"        // an S3-compatible bucket, e.g. AWS S3 or MinIO
+)
+
+// IsValid reports whether t is a known connector type
+func (t ConnectorType) IsValid() bool {
+	return t == ConnectorTypeDirectory || t == ConnectorTypeWebDAV || t == ConnectorTypeS3
+}
+
+// ConnectorDeletePolicy selects what happens to a document when its file is removed from the source
+type ConnectorDeletePolicy string
+
+const (
+	ConnectorDeleteKeep   ConnectorDeletePolicy = "keep"   // keep the document; only the sync state records the removal (default)
+	ConnectorDeleteRemove ConnectorDeletePolicy = "delete" // delete every version of the document, unless it is under legal hold
+)
+
+// IsValid reports whether p is a known delete policy
+func (p ConnectorDeletePolicy) IsValid() bool {
+	return p == "" || p == ConnectorDeleteKeep || p == ConnectorDeleteRemove
+}
+
+// ConnectorSettings locates a connector's source. Which fields apply depends on the connector type.
+// Secrets are stored but never returned by the API.
+type ConnectorSettings struct {
+	// Directory
+	Path string `json:"path,omitempty" bson:"path,omitempty"` // absolute path on the server
+
+	// WebDAV
+	URL      string `json:"url,omitempty" bson:"url,omitempty"` // URL of the collection to sync
+	Username string `json:"username,omitempty" bson:"username,omitempty"`
+	Password string `json:"-" bson:"password,omitempty"`
+
+	// S3-compatible bucket
+	Endpoint  string `json:"endpoint,omitempty" bson:"endpoint,omitempty"` // host[:port]
+	Bucket    string `json:"bucket,omitempty" bson:"bucket,omitempty"`
+	Prefix    string `json:"prefix,omitempty" bson:"prefix,omitempty"` // only keys under this prefix are synced
+	Region    string `json:"region,omitempty" bson:"region,omitempty"`
+	AccessKey string `json:"access_key,omitempty" bson:"access_key,omitempty"`
+	SecretKey string `json:"-" bson:"secret_key,omitempty"`
+	UseSSL    bool   `json:"use_ssl,omitempty" bson:"use_ssl,omitempty"`
+}
+
+// ConnectorSyncStatus is the outcome of a connector sync run
+type ConnectorSyncStatus string
+
+const (
+	ConnectorSyncRunning             ConnectorSyncStatus = "running"
+	ConnectorSyncCompleted           ConnectorSyncStatus = "completed"
+	ConnectorSyncCompletedWithErrors ConnectorSyncStatus = "completed_with_errors" // some files could not be synced
+	ConnectorSyncFailed              ConnectorSyncStatus = "failed"                // the source could not be listed
+)
+
+// Connector periodically syncs the files of a directory, WebDAV share or S3 bucket into
+// documents. New files become documents, changed files new versions of them, and removed files
+// are handled by the delete policy. Documents are uploaded in the name of the connector's creator.
+type Connector struct {
+	ID              primitive.ObjectID      `json:"id" bson:"_id,omitempty"`
+	Name            string                  `json:"name" bson:"name"`
+	Type            ConnectorType           `json:"type" bson:"type"`
+	Settings        ConnectorSettings       `json:"settings" bson:"settings"`
+	IntervalMinutes int                     `json:"interval_minutes" bson:"interval_minutes"`
+	Enabled         bool                    `json:"enabled" bson:"enabled"`
+	DeletePolicy    ConnectorDeletePolicy   `json:"delete_policy,omitempty" bson:"delete_policy,omitempty"`
+	Category        DocumentCategory        `json:"category,omitempty" bson:"category,omitempty"`
+	Tags            []string                `json:"tags,omitempty" bson:"tags,omitempty"`
+	Classification  *SecurityClassification `json:"classification,omitempty" bson:"classification,omitempty"`
+	DuplicatePolicy DuplicatePolicy         `json:"duplicate_policy,omitempty" bson:"duplicate_policy,omitempty"`
+	CreatedBy       primitive.ObjectID      `json:"created_by" bson:"created_by"`
+	CreatedAt       time.Time               `json:"created_at" bson:"created_at"`
+	UpdatedAt       time.Time               `json:"updated_at" bson:"updated_at"`
+
+	// Sync state
+	SyncingSince   *time.Time          `json:"syncing_since,omitempty" bson:"syncing_since,omitempty"`
+	NextSyncAt     *time.Time          `json:"next_sync_at,omitempty" bson:"next_sync_at,omitempty"`
+	LastSyncAt     *time.Time          `json:"last_sync_at,omitempty" bson:"last_sync_at,omitempty"`
+	LastSyncStatus ConnectorSyncStatus `json:"last_sync_status,omitempty" bson:"last_sync_status,omitempty"`
+	LastRunID      *primitive.ObjectID `json:"last_run_id,omitempty" bson:"last_run_id,omitempty"`
+	LastError      string              `json:"last_error,omitempty" bson:"last_error,omitempty"`
+}
+
+// Validate validates the connector model
+func (c *Connector) Validate() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return ErrConnectorNameRequired
+	}
+	if !c.Type.IsValid() {
+		return ErrConnectorTypeInvalid
+	}
+	if c.IntervalMinutes <= 0 {
+		return ErrConnectorIntervalInvalid
+	}
+	if !c.DeletePolicy.IsValid() {
+		return ErrConnectorDeletePolicyInvalid
+	}
+	if !c.DuplicatePolicy.IsValid() {
+		return ErrConnectorDuplicateInvalid
+	}
+	if c.CreatedBy.IsZero() {
+		return ErrConnectorCreatedByRequired
+	}
+
+	settings := c.Settings
+	switch c.Type {
+	case ConnectorTypeDirectory:
+		if !filepath.IsAbs(settings.Path) {
+			return ErrConnectorSourceInvalid
+		}
+	case ConnectorTypeWebDAV:
+		u, err := url.Parse(settings.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return ErrConnectorSourceInvalid
+		}
+	case ConnectorTypeS3:
+		if settings.Endpoint == "" || settings.Bucket == "" || settings.AccessKey == "" || settings.SecretKey == "" {
+			return ErrConnectorSourceInvalid
+		}
+	}
+	return nil
+}
+
+// Interval is the time between scheduled syncs
+func (c *Connector) Interval() time.Duration {
+	return time.Duration(c.IntervalMinutes) * time.Minute
+}
+
+// Connector file states
+const (
+	ConnectorFileActive  = "active"  // the file is present at the source
+	ConnectorFileDeleted = "deleted" // the file was removed from the source
+)
+
+// ConnectorFile is the sync state of one file of a connector's source: what the file looked like
+// when it was last synced, and the document holding its latest version
+type ConnectorFile struct {
+	ID          primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	ConnectorID primitive.ObjectID  `json:"connector_id" bson:"connector_id"`
+	Path        string              `json:"path" bson:"path"` // relative to the connector's root, "/"-separated
+	Size        int64               `json:"size" bson:"size"`
+	ModTime     time.Time           `json:"mod_time" bson:"mod_time"`
+	ETag        string              `json:"etag,omitempty" bson:"etag,omitempty"`
+	Checksum    string              `json:"checksum,omitempty" bson:"checksum,omitempty"` // SHA-256 of the content
+	DocumentID  *primitive.ObjectID `json:"document_id,omitempty" bson:"document_id,omitempty"`
+	Status      string              `json:"status" bson:"status"`
+	Error       string              `json:"error,omitempty" bson:"error,omitempty"` // why the last sync of the file failed
+	SyncedAt    time.Time           `json:"synced_at" bson:"synced_at"`
+	DeletedAt   *time.Time          `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+}
+
+// ConnectorSyncError is a file that could not be synced
+type ConnectorSyncError struct {
+	Path  string `json:"path" bson:"path"`
+	Error string `json:"error" bson:"error"`
+}
+
+// ConnectorSyncRun is the report of one sync of a connector
+type ConnectorSyncRun struct {
+	ID          primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	ConnectorID primitive.ObjectID   `json:"connector_id" bson:"connector_id"`
+	Trigger     string               `json:"trigger" bson:"trigger"` // "scheduled" or "manual"
+	Status      ConnectorSyncStatus  `json:"status" bson:"status"`
+	StartedAt   time.Time            `json:"started_at" bson:"started_at"`
+	FinishedAt  *time.Time           `json:"finished_at,omitempty" bson:"finished_at,omitempty"`
+	Listed      int                  `json:"listed" bson:"listed"` // files found at the source
+	Added       int                  `json:"added" bson:"added"`
+	Updated     int                  `json:"updated" bson:"updated"`
+	Deleted     int                  `json:"deleted" bson:"deleted"`
+	Unchanged   int                  `json:"unchanged" bson:"unchanged"`
+	Skipped     int                  `json:"skipped" bson:"skipped"` // unsupported formats and empty files
+	Failed      int                  `json:"failed" bson:"failed"`
+	Errors      []ConnectorSyncError `json:"errors,omitempty" bson:"errors,omitempty"` // the first failures of the run
+	Error       string               `json:"error,omitempty" bson:"error,omitempty"`   // why the run failed
+}
@@ -147,6 +147,36 @@ type ConsultationResponse struct {
 	NextSteps       []ActionItem        `json:"next_steps" bson:"next_steps"`
 	GeneratedAt     time.Time           `json:"generated_at" bson:"generated_at"`
 	ProcessingTime  time.Duration       `json:"processing_time" bson:"processing_time"`
+	SourceDecisions []SourceDecision    `json:"source_decisions,omitempty" bson:"source_decisions,omitempty"` // why each retrieved source was used or left out
+}
+
+// Reasons a re-ranking stage leaves a retrieved source out of a consultation
+const (
+	RerankDroppedBelowMinScore = "below_min_score" // scored under the stage's relevance floor
+	RerankDroppedBeyondTopK    = "beyond_top_k"    // ranked below the stage's top k
+	RerankDroppedBeyondLimit   = "beyond_limit"    // ranked below the consultation's source limit
+)
+
+// RerankStep records how one re-ranking stage judged a retrieved source
+type RerankStep struct {
+	Reranker string  `json:"reranker" bson:"reranker"`
+	Score    float64 `json:"score" bson:"score"` // relevance from 0 to 1; MMR scores may be negative
+	Rank     int     `json:"rank" bson:"rank"`
+	Reason   string  `json:"reason,omitempty" bson:"reason,omitempty"`   // the reranker's explanation of the score, if it gives one
+	Dropped  string  `json:"dropped,omitempty" bson:"dropped,omitempty"` // why the stage left the source out
+}
+
+// SourceDecision records whether a source retrieved for a consultation was used, and why
+type SourceDecision struct {
+	SourceType     string              `json:"source_type" bson:"source_type"` // "document" or "knowledge"
+	SourceID       primitive.ObjectID  `json:"source_id" bson:"source_id"`
+	ChunkID        *primitive.ObjectID `json:"chunk_id,omitempty" bson:"chunk_id,omitempty"`
+	Title          string              `json:"title" bson:"title"`
+	RetrievalScore float64             `json:"retrieval_score" bson:"retrieval_score"`
+	Score          float64             `json:"score" bson:"score"` // score of the last stage that judged the source
+	Selected       bool                `json:"selected" bson:"selected"`
+	Dropped        string              `json:"dropped,omitempty" bson:"dropped,omitempty"`
+	Steps          []RerankStep        `json:"steps,omitempty" bson:"steps,omitempty"`
 }
 
 // ConversationTurn represents a single turn in a multi-turn conversation
@@ -173,6 +203,9 @@ type ConsultationSession struct {
 	Metadata          map[string]interface{} `json:"metadata" bson:"metadata"`
 	ConversationTurns []ConversationTurn     `json:"conversation_turns,omitempty" bson:"conversation_turns,omitempty"`
 	IsMultiTurn       bool                   `json:"is_multi_turn" bson:"is_multi_turn"`
+	WorkspaceIDs      []primitive.ObjectID   `json:"workspace_ids,omitempty" bson:"workspace_ids,omitempty"`
+	Retention         *RecordRetention       `json:"retention,omitempty" bson:"retention,omitempty"`
+	LegalHoldIDs      []primitive.ObjectID   `json:"legal_hold_ids,omitempty" bson:"legal_hold_ids,omitempty"`
 }
 
 // Validate validates the consultation session model
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"fmt"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -27,6 +28,34 @@ const (
 	ProcessingStatusFailed     ProcessingStatus = "failed"
 )
 
+// DuplicatePolicy selects what happens when an upload duplicates an existing document
+type DuplicatePolicy string
+
+const (
+	DuplicatePolicyLink   DuplicatePolicy = "link"   // keep the upload, linked to the canonical document and left out of retrieval (default)
+	DuplicatePolicyMerge  DuplicatePolicy = "merge"  // as link, and merge the upload's metadata into the canonical document
+	DuplicatePolicyReject DuplicatePolicy = "reject" // discard the upload
+	DuplicatePolicyAllow  DuplicatePolicy = "allow"  // skip duplicate detection
+)
+
+// IsValid returns true if the policy is empty (the default) or a known policy
+func (p DuplicatePolicy) IsValid() bool {
+	switch p {
+	case "", DuplicatePolicyLink, DuplicatePolicyMerge, DuplicatePolicyReject, DuplicatePolicyAllow:
+		return true
+	}
+	return false
+}
+
+// DuplicateInfo records that a document duplicates an earlier, canonical document
+type DuplicateInfo struct {
+	CanonicalID primitive.ObjectID `json:"canonical_id" bson:"canonical_id"`
+	Kind        string             `json:"kind" bson:"kind"`             // "exact" or "near"
+	Similarity  float64            `json:"similarity" bson:"similarity"` // 1 for exact duplicates
+	Action      string             `json:"action" bson:"action"`         // "linked", "merged" or "rejected"
+	DetectedAt  time.Time          `json:"detected_at" bson:"detected_at"`
+}
+
 // SecurityClassification represents the security classification of a document
 type SecurityClassification struct {
 	Level                string     `json:"level" bson:"level"` // "PUBLIC", "INTERNAL", "CONFIDENTIAL", "SECRET", "TOP_SECRET"
@@ -35,6 +64,40 @@ type SecurityClassification struct {
 	DeclassificationDate *time.Time `json:"declassification_date,omitempty" bson:"declassification_date,omitempty"`
 }
 
+// classificationLevels lists the classification levels from lowest to highest
+var classificationLevels = []string{"PUBLIC", "INTERNAL", "CONFIDENTIAL", "SECRET", "TOP_SECRET"}
+
+// ClassificationRank orders classification levels from lowest (0) to highest; unknown levels rank -1
+func ClassificationRank(level string) int {
+	for i, known := range classificationLevels {
+		if known == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// ClassificationMarking is a banner line or portion marking found in a document's text
+type ClassificationMarking struct {
+	Text     string `json:"text" bson:"text"`   // the marking as written, e.g. "SECRET//NOFORN" or "(U//FOUO)"
+	Kind     string `json:"kind" bson:"kind"`   // "banner" or "portion"
+	Level    string `json:"level" bson:"level"` // the classification level the marking maps to
+	StartPos int    `json:"start_pos" bson:"start_pos"`
+	EndPos   int    `json:"end_pos" bson:"end_pos"`
+}
+
+// ClassificationReview flags a document whose classification needs a reviewer, because the
+// declared classification disagrees with its markings or the markings disagree with each other
+type ClassificationReview struct {
+	Status        string              `json:"status" bson:"status"` // "pending" or "resolved"
+	Reasons       []string            `json:"reasons" bson:"reasons"`
+	DeclaredLevel string              `json:"declared_level,omitempty" bson:"declared_level,omitempty"`
+	DetectedLevel string              `json:"detected_level" bson:"detected_level"`
+	FlaggedAt     time.Time           `json:"flagged_at" bson:"flagged_at"`
+	ResolvedBy    *primitive.ObjectID `json:"resolved_by,omitempty" bson:"resolved_by,omitempty"`
+	ResolvedAt    *time.Time          `json:"resolved_at,omitempty" bson:"resolved_at,omitempty"`
+}
+
 // DocumentMetadata contains metadata about a document
 type DocumentMetadata struct {
 	Title        *string                `json:"title,omitempty" bson:"title,omitempty"`
@@ -49,6 +112,13 @@ type DocumentMetadata struct {
 	CustomFields map[string]interface{} `json:"custom_fields" bson:"custom_fields"`
 }
 
+// DocumentActionReadPII is the documents permission action that allows seeing document text
+// and entities without personal data redacted
+const DocumentActionReadPII = "read_pii"
+
+// redactedEntityValue replaces the value of sensitive entities shown to users without DocumentActionReadPII
+const redactedEntityValue = "[REDACTED]"
+
 // Entity represents an extracted entity from a document
 type Entity struct {
 	Type       string  `json:"type" bson:"type"`
@@ -56,24 +126,133 @@ type Entity struct {
 	Confidence float64 `json:"confidence" bson:"confidence"`
 	StartPos   int     `json:"start_pos" bson:"start_pos"`
 	EndPos     int     `json:"end_pos" bson:"end_pos"`
+	Sensitive  bool    `json:"sensitive,omitempty" bson:"sensitive,omitempty"` // personal data such as an SSN or bank account, redacted by default
+}
+
+// PageSpan records where a page's text lives within the extracted document content
+type PageSpan struct {
+	Number int `json:"number" bson:"number"` // 1-based page number
+	Start  int `json:"start" bson:"start"`   // byte offset of the first character in Content
+	End    int `json:"end" bson:"end"`       // byte offset just past the last character in Content
+}
+
+// SectionSpan records a heading from the source outline and the content range it governs
+type SectionSpan struct {
+	Number string `json:"number,omitempty" bson:"number,omitempty"` // outline number such as "3.2", if any
+	Title  string `json:"title" bson:"title"`
+	Level  int    `json:"level" bson:"level"` // 1-based heading level
+	Start  int    `json:"start" bson:"start"` // byte offset of the heading in Content
+	End    int    `json:"end" bson:"end"`     // byte offset where the section ends
+}
+
+// Label returns a human-readable reference such as "Section 3.2 – Procurement Thresholds"
+func (s SectionSpan) Label() string {
+	if s.Number == "" {
+		return s.Title
+	}
+	return fmt.Sprintf("Section %s – %s", s.Number, s.Title)
+}
+
+// DocumentSummary is the LLM-generated summary of a document, produced from its redacted text
+// after processing
+type DocumentSummary struct {
+	Executive   string           `json:"executive" bson:"executive"`
+	Sections    []SectionSummary `json:"sections,omitempty" bson:"sections,omitempty"`
+	Obligations []Obligation     `json:"obligations,omitempty" bson:"obligations,omitempty"`
+	Provider    string           `json:"provider" bson:"provider"` // provider and model that wrote the summary, e.g. "gemini/gemini-1.5-flash"
+	GeneratedAt time.Time        `json:"generated_at" bson:"generated_at"`
+}
+
+// SectionSummary summarizes one section of a document
+type SectionSummary struct {
+	Section string `json:"section" bson:"section"` // the section's label
+	Summary string `json:"summary" bson:"summary"`
+}
+
+// Obligation is a requirement a document places on someone, with its deadline if it has one
+type Obligation struct {
+	Text     string     `json:"text" bson:"text"`
+	Party    string     `json:"party,omitempty" bson:"party,omitempty"`       // who must act
+	Deadline string     `json:"deadline,omitempty" bson:"deadline,omitempty"` // the deadline as written, e.g. "within 30 days of award"
+	DueDate  *time.Time `json:"due_date,omitempty" bson:"due_date,omitempty"` // set when the deadline is a calendar date
+	Section  string     `json:"section,omitempty" bson:"section,omitempty"`
 }
 
 // Document represents a document in the system
 type Document struct {
-	ID                  primitive.ObjectID     `json:"id" bson:"_id,omitempty"`
-	Name                string                 `json:"name" bson:"name"`
-	Content             string                 `json:"content" bson:"content"`
-	ContentType         string                 `json:"content_type" bson:"content_type"`
-	Size                int64                  `json:"size" bson:"size"`
-	UploadedBy          primitive.ObjectID     `json:"uploaded_by" bson:"uploaded_by"`
-	UploadedAt          time.Time              `json:"uploaded_at" bson:"uploaded_at"`
-	Classification      SecurityClassification `json:"classification" bson:"classification"`
-	Metadata            DocumentMetadata       `json:"metadata" bson:"metadata"`
-	ProcessingStatus    ProcessingStatus       `json:"processing_status" bson:"processing_status"`
-	Embeddings          []float64              `json:"embeddings,omitempty" bson:"embeddings,omitempty"`
-	ExtractedEntities   []Entity               `json:"extracted_entities" bson:"extracted_entities"`
-	ProcessingTimestamp *time.Time             `json:"processing_timestamp,omitempty" bson:"processing_timestamp,omitempty"`
-	ProcessingError     *string                `json:"processing_error,omitempty" bson:"processing_error,omitempty"`
+	ID                       primitive.ObjectID      `json:"id" bson:"_id,omitempty"`
+	Name                     string                  `json:"name" bson:"name"`
+	Content                  string                  `json:"content" bson:"content"`
+	RedactedContent          string                  `json:"-" bson:"redacted_content,omitempty"` // Content with sensitive entities masked, set when any were found
+	PIICount                 int                     `json:"pii_count,omitempty" bson:"pii_count,omitempty"`
+	RawContent               []byte                  `json:"-" bson:"raw_content,omitempty"` // original bytes of documents uploaded before blob storage
+	BlobKey                  string                  `json:"-" bson:"blob_key,omitempty"`    // blob storage key of the original uploaded file
+	ContentType              string                  `json:"content_type" bson:"content_type"`
+	Size                     int64                   `json:"size" bson:"size"`
+	UploadedBy               primitive.ObjectID      `json:"uploaded_by" bson:"uploaded_by"`
+	UploadedAt               time.Time               `json:"uploaded_at" bson:"uploaded_at"`
+	Classification           SecurityClassification  `json:"classification" bson:"classification"`
+	DeclaredClassification   *SecurityClassification `json:"declared_classification,omitempty" bson:"declared_classification,omitempty"` // classification given by the uploader, if any
+	ClassificationMarkings   []ClassificationMarking `json:"classification_markings,omitempty" bson:"classification_markings,omitempty"`
+	ClassificationReview     *ClassificationReview   `json:"classification_review,omitempty" bson:"classification_review,omitempty"`
+	Metadata                 DocumentMetadata        `json:"metadata" bson:"metadata"`
+	ProcessingStatus         ProcessingStatus        `json:"processing_status" bson:"processing_status"`
+	Embeddings               []float64               `json:"embeddings,omitempty" bson:"embeddings,omitempty"`
+	EmbeddingModel           string                  `json:"embedding_model,omitempty" bson:"embedding_model,omitempty"` // provider and model that produced Embeddings, e.g. "gemini/text-embedding-004"
+	EmbeddingDimension       int                     `json:"embedding_dimension,omitempty" bson:"embedding_dimension,omitempty"`
+	ShadowEmbeddings         []float64               `json:"-" bson:"shadow_embeddings,omitempty"` // vector of the model an embedding migration is moving to
+	ShadowEmbeddingModel     string                  `json:"shadow_embedding_model,omitempty" bson:"shadow_embedding_model,omitempty"`
+	ShadowEmbeddingDimension int                     `json:"shadow_embedding_dimension,omitempty" bson:"shadow_embedding_dimension,omitempty"`
+	ChunkCount               int                     `json:"chunk_count,omitempty" bson:"chunk_count,omitempty"` // number of passages in document_chunks
+	TableCount               int                     `json:"table_count,omitempty" bson:"table_count,omitempty"` // number of tables in document_tables
+	ExtractedEntities        []Entity                `json:"extracted_entities" bson:"extracted_entities"`
+	Citations                []string                `json:"citations,omitempty" bson:"citations,omitempty"` // canonical IDs of the laws and policies the document cites, e.g. "2 CFR 200.318"
+	CitationKeys             []string                `json:"-" bson:"citation_keys,omitempty"`               // citations plus every enclosing part and title, for lookups
+	Pages                    []PageSpan              `json:"pages,omitempty" bson:"pages,omitempty"`
+	Sections                 []SectionSpan           `json:"sections,omitempty" bson:"sections,omitempty"`
+	Summary                  *DocumentSummary        `json:"summary,omitempty" bson:"summary,omitempty"`
+	ProcessingTimestamp      *time.Time              `json:"processing_timestamp,omitempty" bson:"processing_timestamp,omitempty"`
+	ProcessingError          *string                 `json:"processing_error,omitempty" bson:"processing_error,omitempty"`
+	ParentID                 *primitive.ObjectID     `json:"parent_id,omitempty" bson:"parent_id,omitempty"` // set on attachments extracted from a container such as an email
+	BatchID                  *primitive.ObjectID     `json:"batch_id,omitempty" bson:"batch_id,omitempty"`   // set on documents created from a bulk archive upload
+	WorkspaceIDs             []primitive.ObjectID    `json:"workspace_ids,omitempty" bson:"workspace_ids,omitempty"`
+	SharedWith               []DocumentGrant         `json:"shared_with,omitempty" bson:"shared_with,omitempty"`
+	Retention                *RecordRetention        `json:"retention,omitempty" bson:"retention,omitempty"`
+	LegalHoldIDs             []primitive.ObjectID    `json:"legal_hold_ids,omitempty" bson:"legal_hold_ids,omitempty"`
+	Version                  int                     `json:"version,omitempty" bson:"version,omitempty"`     // 1-based revision number within the version chain
+	SeriesID                 *primitive.ObjectID     `json:"series_id,omitempty" bson:"series_id,omitempty"` // ID of the first version, shared by every revision
+	PreviousVersionID        *primitive.ObjectID     `json:"previous_version_id,omitempty" bson:"previous_version_id,omitempty"`
+	Superseded               bool                    `json:"superseded,omitempty" bson:"superseded,omitempty"`     // a newer version of the document exists
+	ContentHash              string                  `json:"content_hash,omitempty" bson:"content_hash,omitempty"` // SHA-256 of the original file
+	TextHash                 string                  `json:"text_hash,omitempty" bson:"text_hash,omitempty"`       // SHA-256 of the normalized extracted text
+	MinHash                  []uint32                `json:"-" bson:"minhash,omitempty"`                           // MinHash signature of the extracted text's word shingles
+	MinHashBands             []string                `json:"-" bson:"minhash_bands,omitempty"`                     // LSH band keys of the signature, for candidate lookup
+	DuplicatePolicy          DuplicatePolicy         `json:"duplicate_policy,omitempty" bson:"duplicate_policy,omitempty"`
+	DuplicateOf              *DuplicateInfo          `json:"duplicate_of,omitempty" bson:"duplicate_of,omitempty"`
+}
+
+// DocumentGrant shares a document with a user who does not hold all of its compartments.
+// A grant never lifts the classification level above the user's clearance.
+type DocumentGrant struct {
+	UserID    primitive.ObjectID `json:"user_id" bson:"user_id"`
+	GrantedBy primitive.ObjectID `json:"granted_by" bson:"granted_by"`
+	GrantedAt time.Time          `json:"granted_at" bson:"granted_at"`
+	ExpiresAt *time.Time         `json:"expires_at,omitempty" bson:"expires_at,omitempty"`
+}
+
+// Active reports whether the grant is in force at the given time
+func (g DocumentGrant) Active(now time.Time) bool {
+	return g.ExpiresAt == nil || g.ExpiresAt.After(now)
+}
+
+// SharedWithUser reports whether the document has an active grant for the user
+func (d *Document) SharedWithUser(userID primitive.ObjectID, now time.Time) bool {
+	for _, grant := range d.SharedWith {
+		if grant.UserID == userID && grant.Active(now) {
+			return true
+		}
+	}
+	return false
 }
 
 // Validate validates the document model
@@ -98,7 +277,115 @@ func (d *Document) IsProcessed() bool {
 	return d.ProcessingStatus == ProcessingStatusCompleted
 }
 
+// RedactPII replaces the document's content with its redacted variant and masks the values
+// of sensitive entities, for users who may not see personal data
+func (d *Document) RedactPII() {
+	if d.RedactedContent != "" {
+		d.Content = d.RedactedContent
+		d.RedactedContent = ""
+	}
+	for i := range d.ExtractedEntities {
+		if d.ExtractedEntities[i].Sensitive {
+			d.ExtractedEntities[i].Value = redactedEntityValue
+		}
+	}
+}
+
+// VersionSeries returns the ID shared by every version of the document
+func (d *Document) VersionSeries() primitive.ObjectID {
+	if d.SeriesID != nil {
+		return *d.SeriesID
+	}
+	return d.ID
+}
+
+// VersionNumber returns the document's revision number; documents without history are version 1
+func (d *Document) VersionNumber() int {
+	if d.Version == 0 {
+		return 1
+	}
+	return d.Version
+}
+
 // HasEmbeddings returns true if the document has embeddings
 func (d *Document) HasEmbeddings() bool {
 	return len(d.Embeddings) > 0
 }
+
+// PageAt returns the page number containing the given content offset, or nil if page layout is unknown
+func (d *Document) PageAt(offset int) *int {
+	for _, page := range d.Pages {
+		if offset >= page.Start && offset < page.End {
+			number := page.Number
+			return &number
+		}
+	}
+	return nil
+}
+
+// SectionAt returns the label of the innermost section containing the given content offset,
+// or nil if the document has no heading outline
+func (d *Document) SectionAt(offset int) *string {
+	var match *SectionSpan
+	for i := range d.Sections {
+		section := &d.Sections[i]
+		if offset >= section.Start && offset < section.End {
+			if match == nil || section.Level > match.Level {
+				match = section
+			}
+		}
+	}
+	if match == nil {
+		return nil
+	}
+	label := match.Label()
+	return &label
+}
+
+// DocumentChunk is a passage of a document's content embedded and searched on its own
+type DocumentChunk struct {
+	ID                       primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	DocumentID               primitive.ObjectID `json:"document_id" bson:"document_id"`
+	Index                    int                `json:"index" bson:"index"` // position of the chunk within the document
+	Content                  string             `json:"content" bson:"content"`
+	Start                    int                `json:"start" bson:"start"` // byte offset of the chunk in the document Content
+	End                      int                `json:"end" bson:"end"`     // byte offset just past the chunk in the document Content
+	Section                  *string            `json:"section,omitempty" bson:"section,omitempty"`
+	PageNumber               *int               `json:"page_number,omitempty" bson:"page_number,omitempty"`
+	Embeddings               []float64          `json:"embeddings,omitempty" bson:"embeddings,omitempty"`
+	EmbeddingModel           string             `json:"embedding_model,omitempty" bson:"embedding_model,omitempty"` // provider and model that produced Embeddings
+	EmbeddingDimension       int                `json:"embedding_dimension,omitempty" bson:"embedding_dimension,omitempty"`
+	ShadowEmbeddings         []float64          `json:"-" bson:"shadow_embeddings,omitempty"` // vector of the model an embedding migration is moving to
+	ShadowEmbeddingModel     string             `json:"shadow_embedding_model,omitempty" bson:"shadow_embedding_model,omitempty"`
+	ShadowEmbeddingDimension int                `json:"shadow_embedding_dimension,omitempty" bson:"shadow_embedding_dimension,omitempty"`
+	CreatedAt                time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// DocumentTable is a table extracted from a document, stored in document_tables. Its rows are also
+// laid out in the document's Content, one line per row, between the byte offsets Start and End.
+type DocumentTable struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	DocumentID      primitive.ObjectID `json:"document_id" bson:"document_id"`
+	Index           int                `json:"index" bson:"index"`                         // position of the table within the document
+	Caption         string             `json:"caption,omitempty" bson:"caption,omitempty"` // e.g. "Table 3: Obligations by Program", or the sheet name
+	Page            *int               `json:"page,omitempty" bson:"page,omitempty"`
+	Section         *string            `json:"section,omitempty" bson:"section,omitempty"`
+	Columns         []string           `json:"columns" bson:"columns"`     // the header row
+	Rows            [][]string         `json:"rows,omitempty" bson:"rows"` // body rows, each as long as Columns
+	RowCount        int                `json:"row_count" bson:"row_count"`
+	Truncated       bool               `json:"truncated,omitempty" bson:"truncated,omitempty"` // set when only the first rows are stored
+	RedactedColumns []string           `json:"-" bson:"redacted_columns,omitempty"`            // Columns with sensitive values masked, set when any were found
+	RedactedRows    [][]string         `json:"-" bson:"redacted_rows,omitempty"`
+	Start           int                `json:"start" bson:"start"`
+	End             int                `json:"end" bson:"end"`
+	CreatedAt       time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// RedactPII replaces the table's cells with their redacted variant, for users who may not see
+// personal data
+func (t *DocumentTable) RedactPII() {
+	if t.RedactedRows != nil {
+		t.Columns, t.Rows = t.RedactedColumns, t.RedactedRows
+		t.RedactedColumns, t.RedactedRows = nil, nil
+	}
+}
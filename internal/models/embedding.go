@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EmbeddingMigrationStatus is the lifecycle state of an embedding migration
+type EmbeddingMigrationStatus string
+
+const (
+	EmbeddingMigrationRunning   EmbeddingMigrationStatus = "running"   // re-embedding records into the shadow vectors
+	EmbeddingMigrationPaused    EmbeddingMigrationStatus = "paused"    // stopped until resumed; progress is kept
+	EmbeddingMigrationReady     EmbeddingMigrationStatus = "ready"     // every record was visited; waiting for the cutover
+	EmbeddingMigrationCutOver   EmbeddingMigrationStatus = "cut_over"  // the target model is active; shadow vectors are being promoted
+	EmbeddingMigrationCompleted EmbeddingMigrationStatus = "completed" // the target model's vectors are the only ones kept
+	EmbeddingMigrationCancelled EmbeddingMigrationStatus = "cancelled" // abandoned; the source model stays active
+)
+
+// IsOpen reports whether a migration in status s still holds the shadow vectors, so that no
+// other migration may start
+func (s EmbeddingMigrationStatus) IsOpen() bool {
+	return s == EmbeddingMigrationRunning || s == EmbeddingMigrationPaused ||
+		s == EmbeddingMigrationReady || s == EmbeddingMigrationCutOver
+}
+
+// EmbeddingMigrationProgress is how far a migration got through one collection. Documents are
+// migrated together with their passages in document_chunks.
+type EmbeddingMigrationProgress struct {
+	Collection string              `json:"collection" bson:"collection"`
+	Total      int64               `json:"total" bson:"total"`         // records with vectors when the migration started
+	Processed  int64               `json:"processed" bson:"processed"` // records re-embedded
+	Failed     int64               `json:"failed" bson:"failed"`       // records that could not be re-embedded in a pass
+	Remaining  int64               `json:"remaining" bson:"remaining"` // records still without a vector of the target model once the migration is ready
+	Pass       int                 `json:"pass" bson:"pass"`           // 1 for the first pass, 2 for the sweep catching records written during it
+	LastID     *primitive.ObjectID `json:"last_id,omitempty" bson:"last_id,omitempty"`
+	Done       bool                `json:"done" bson:"done"`
+}
+
+// EmbeddingMigration re-embeds the corpus with a new embedding model into shadow vectors kept
+// beside the active ones, so that searches keep using the active model until the cutover
+// switches every server to the new model at once.
+type EmbeddingMigration struct {
+	ID          primitive.ObjectID           `json:"id" bson:"_id,omitempty"`
+	SourceModel string                       `json:"source_model" bson:"source_model"`
+	TargetModel string                       `json:"target_model" bson:"target_model"`
+	Status      EmbeddingMigrationStatus     `json:"status" bson:"status"`
+	Collections []EmbeddingMigrationProgress `json:"collections" bson:"collections"`
+	Errors      []string                     `json:"errors,omitempty" bson:"errors,omitempty"` // the latest record errors
+	Active      bool                         `json:"active" bson:"active"`                     // a worker is running the migration
+	StartedBy   primitive.ObjectID           `json:"started_by" bson:"started_by"`
+	CreatedAt   time.Time                    `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time                    `json:"updated_at" bson:"updated_at"`
+	ReadyAt     *time.Time                   `json:"ready_at,omitempty" bson:"ready_at,omitempty"`
+	CutOverAt   *time.Time                   `json:"cut_over_at,omitempty" bson:"cut_over_at,omitempty"`
+	FinishedAt  *time.Time                   `json:"finished_at,omitempty" bson:"finished_at,omitempty"` // when it completed, or when the shadow vectors of a cancelled migration were removed
+}
+
+// Progress returns the share of records processed, from 0 to 1
+func (m *EmbeddingMigration) Progress() float64 {
+	var total, processed int64
+	for _, collection := range m.Collections {
+		total += collection.Total
+		processed += min(collection.Processed, collection.Total)
+	}
+	if total == 0 {
+		return 1
+	}
+	return float64(processed) / float64(total)
+}
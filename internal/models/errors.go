@@ -56,6 +56,58 @@ var (
 	ErrKnowledgeConfidenceInvalid = errors.New("knowledge confidence is invalid")
 )
 
+// Workspace validation errors
+var (
+	ErrWorkspaceNameRequired      = errors.New("workspace name is required")
+	ErrWorkspaceCreatedByRequired = errors.New("workspace created by is required")
+	ErrWorkspaceRoleInvalid       = errors.New("workspace role is invalid")
+	ErrWorkspaceOwnerRequired     = errors.New("workspace must have an owner")
+)
+
+// Retention and legal hold errors
+var (
+	ErrRetentionScheduleNameRequired = errors.New("retention schedule name is required")
+	ErrRecordTypeInvalid             = errors.New("record type is invalid")
+	ErrRetentionDaysInvalid          = errors.New("retention period must be at least one day")
+	ErrDispositionActionInvalid      = errors.New("disposition action is invalid")
+	ErrRetentionScopeInvalid         = errors.New("retention schedule cannot apply to both a category and a workspace")
+	ErrLegalHoldNameRequired         = errors.New("legal hold name is required")
+	ErrLegalHoldPlacedByRequired     = errors.New("legal hold placed by is required")
+	ErrRecordOnLegalHold             = errors.New("record is under legal hold")
+)
+
+// Annotation validation errors
+var (
+	ErrAnnotationDocumentRequired  = errors.New("annotation document is required")
+	ErrAnnotationBodyRequired      = errors.New("annotation body is required")
+	ErrAnnotationAnchorInvalid     = errors.New("annotation anchor is invalid")
+	ErrAnnotationStatusInvalid     = errors.New("annotation status is invalid")
+	ErrAnnotationCreatedByRequired = errors.New("annotation created by is required")
+)
+
+// Connector validation errors
+var (
+	ErrConnectorNameRequired        = errors.New("connector name is required")
+	ErrConnectorTypeInvalid         = errors.New("connector type is invalid")
+	ErrConnectorIntervalInvalid     = errors.New("connector sync interval must be at least one minute")
+	ErrConnectorDeletePolicyInvalid = errors.New("connector delete policy is invalid")
+	ErrConnectorDuplicateInvalid    = errors.New("connector duplicate policy is invalid")
+	ErrConnectorSourceInvalid       = errors.New("connector source settings are invalid")
+	ErrConnectorCreatedByRequired   = errors.New("connector created by is required")
+)
+
+// Metadata schema validation errors
+var (
+	ErrMetadataSchemaNameRequired      = errors.New("metadata schema name is required")
+	ErrMetadataSchemaScopeRequired     = errors.New("metadata schema must apply to a department or a category")
+	ErrMetadataSchemaFieldsRequired    = errors.New("metadata schema must define at least one field")
+	ErrMetadataSchemaCreatedByRequired = errors.New("metadata schema created by is required")
+	ErrMetadataFieldNameInvalid        = errors.New("metadata field name is invalid")
+	ErrMetadataFieldTypeInvalid        = errors.New("metadata field type is invalid")
+	ErrMetadataFieldConstraintInvalid  = errors.New("metadata field constraints are invalid")
+	ErrMetadataRequiredFieldUndefined  = errors.New("required metadata field is not defined")
+)
+
 // Embedding validation errors
 var (
 	ErrEmbeddingRequired      = errors.New("embedding is required")
@@ -71,27 +71,36 @@ type KnowledgeUsage struct {
 
 // KnowledgeItem represents a piece of knowledge in the system
 type KnowledgeItem struct {
-	ID             primitive.ObjectID      `json:"id" bson:"_id,omitempty"`
-	Content        string                  `json:"content" bson:"content"`
-	Type           KnowledgeType           `json:"type" bson:"type"`
-	Title          string                  `json:"title" bson:"title"`
-	Summary        *string                 `json:"summary,omitempty" bson:"summary,omitempty"`
-	Keywords       []string                `json:"keywords" bson:"keywords"`
-	Tags           []string                `json:"tags" bson:"tags"`
-	Category       string                  `json:"category" bson:"category"`
-	Source         KnowledgeSource         `json:"source" bson:"source"`
-	Relationships  []KnowledgeRelationship `json:"relationships" bson:"relationships"`
-	Confidence     float64                 `json:"confidence" bson:"confidence"` // 0.0 to 1.0
-	Validation     KnowledgeValidation     `json:"validation" bson:"validation"`
-	Usage          KnowledgeUsage          `json:"usage" bson:"usage"`
-	Embeddings     []float64               `json:"embeddings,omitempty" bson:"embeddings,omitempty"`
-	CreatedAt      time.Time               `json:"created_at" bson:"created_at"`
-	UpdatedAt      time.Time               `json:"updated_at" bson:"updated_at"`
-	CreatedBy      primitive.ObjectID      `json:"created_by" bson:"created_by"`
-	LastModifiedBy *primitive.ObjectID     `json:"last_modified_by,omitempty" bson:"last_modified_by,omitempty"`
-	Version        int                     `json:"version" bson:"version"`
-	IsActive       bool                    `json:"is_active" bson:"is_active"`
-	Metadata       map[string]interface{}  `json:"metadata" bson:"metadata"`
+	ID                       primitive.ObjectID      `json:"id" bson:"_id,omitempty"`
+	Content                  string                  `json:"content" bson:"content"`
+	Type                     KnowledgeType           `json:"type" bson:"type"`
+	Title                    string                  `json:"title" bson:"title"`
+	Summary                  *string                 `json:"summary,omitempty" bson:"summary,omitempty"`
+	Keywords                 []string                `json:"keywords" bson:"keywords"`
+	Tags                     []string                `json:"tags" bson:"tags"`
+	Category                 string                  `json:"category" bson:"category"`
+	Source                   KnowledgeSource         `json:"source" bson:"source"`
+	Relationships            []KnowledgeRelationship `json:"relationships" bson:"relationships"`
+	Citations                []string                `json:"citations,omitempty" bson:"citations,omitempty"` // canonical IDs of the laws and policies the item cites
+	CitationKeys             []string                `json:"-" bson:"citation_keys,omitempty"`               // citations plus every enclosing part and title, for lookups
+	Authority                string                  `json:"authority,omitempty" bson:"authority,omitempty"` // citation of the law or regulation the item itself records, e.g. "2 CFR 200"
+	Confidence               float64                 `json:"confidence" bson:"confidence"`                   // 0.0 to 1.0
+	Validation               KnowledgeValidation     `json:"validation" bson:"validation"`
+	Usage                    KnowledgeUsage          `json:"usage" bson:"usage"`
+	Embeddings               []float64               `json:"embeddings,omitempty" bson:"embeddings,omitempty"`
+	EmbeddingModel           string                  `json:"embedding_model,omitempty" bson:"embedding_model,omitempty"` // provider and model that produced Embeddings
+	EmbeddingDimension       int                     `json:"embedding_dimension,omitempty" bson:"embedding_dimension,omitempty"`
+	ShadowEmbeddings         []float64               `json:"-" bson:"shadow_embeddings,omitempty"` // vector of the model an embedding migration is moving to
+	ShadowEmbeddingModel     string                  `json:"shadow_embedding_model,omitempty" bson:"shadow_embedding_model,omitempty"`
+	ShadowEmbeddingDimension int                     `json:"shadow_embedding_dimension,omitempty" bson:"shadow_embedding_dimension,omitempty"`
+	CreatedAt                time.Time               `json:"created_at" bson:"created_at"`
+	UpdatedAt                time.Time               `json:"updated_at" bson:"updated_at"`
+	CreatedBy                primitive.ObjectID      `json:"created_by" bson:"created_by"`
+	LastModifiedBy           *primitive.ObjectID     `json:"last_modified_by,omitempty" bson:"last_modified_by,omitempty"`
+	Version                  int                     `json:"version" bson:"version"`
+	IsActive                 bool                    `json:"is_active" bson:"is_active"`
+	Metadata                 map[string]interface{}  `json:"metadata" bson:"metadata"`
+	WorkspaceIDs             []primitive.ObjectID    `json:"workspace_ids,omitempty" bson:"workspace_ids,omitempty"`
 }
 
 // Validate validates the knowledge item model
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MetadataFieldType is the type of a custom metadata field
+type MetadataFieldType string
+
+const (
+	MetadataFieldString  MetadataFieldType = "string"
+	MetadataFieldInteger MetadataFieldType = "integer"
+	MetadataFieldNumber  MetadataFieldType = "number"
+	MetadataFieldBoolean MetadataFieldType = "boolean"
+	MetadataFieldDate    MetadataFieldType = "date" // stored as a date; given as YYYY-MM-DD or RFC 3339
+)
+
+// IsValid reports whether t is a known field type
+func (t MetadataFieldType) IsValid() bool {
+	switch t {
+	case MetadataFieldString, MetadataFieldInteger, MetadataFieldNumber, MetadataFieldBoolean, MetadataFieldDate:
+		return true
+	}
+	return false
+}
+
+// metadataFieldName is the form of a custom field name; it must be usable as a MongoDB field path
+var metadataFieldName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,63}$`)
+
+// MetadataProperty constrains one custom metadata field, after the JSON Schema keywords of the
+// same names. Indexed fields can be filtered on in document searches.
+type MetadataProperty struct {
+	Type        MetadataFieldType `json:"type" bson:"type"`
+	Title       string            `json:"title,omitempty" bson:"title,omitempty"`
+	Description string            `json:"description,omitempty" bson:"description,omitempty"`
+	Enum        []string          `json:"enum,omitempty" bson:"enum,omitempty"`       // strings only
+	Pattern     string            `json:"pattern,omitempty" bson:"pattern,omitempty"` // strings only; unanchored, as in JSON Schema
+	MinLength   *int              `json:"min_length,omitempty" bson:"min_length,omitempty"`
+	MaxLength   *int              `json:"max_length,omitempty" bson:"max_length,omitempty"`
+	Minimum     *float64          `json:"minimum,omitempty" bson:"minimum,omitempty"` // integers and numbers only
+	Maximum     *float64          `json:"maximum,omitempty" bson:"maximum,omitempty"`
+	Indexed     bool              `json:"indexed,omitempty" bson:"indexed,omitempty"`
+}
+
+// MetadataSchema defines the custom metadata fields of the documents of a department, a
+// category, or a category within a department. Every schema that applies to a document is
+// enforced when it is uploaded or its metadata is updated.
+type MetadataSchema struct {
+	ID          primitive.ObjectID          `json:"id" bson:"_id,omitempty"`
+	Name        string                      `json:"name" bson:"name"`
+	Description string                      `json:"description,omitempty" bson:"description,omitempty"`
+	Department  string                      `json:"department,omitempty" bson:"department,omitempty"`
+	Category    DocumentCategory            `json:"category,omitempty" bson:"category,omitempty"`
+	Properties  map[string]MetadataProperty `json:"properties" bson:"properties"`
+	Required    []string                    `json:"required,omitempty" bson:"required,omitempty"`
+	CreatedBy   primitive.ObjectID          `json:"created_by" bson:"created_by"`
+	CreatedAt   time.Time                   `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time                   `json:"updated_at" bson:"updated_at"`
+}
+
+// Validate validates the metadata schema model
+func (s *MetadataSchema) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return ErrMetadataSchemaNameRequired
+	}
+	if s.Department == "" && s.Category == "" {
+		return ErrMetadataSchemaScopeRequired
+	}
+	if len(s.Properties) == 0 {
+		return ErrMetadataSchemaFieldsRequired
+	}
+	if s.CreatedBy.IsZero() {
+		return ErrMetadataSchemaCreatedByRequired
+	}
+
+	for name, property := range s.Properties {
+		if !metadataFieldName.MatchString(name) {
+			return fmt.Errorf("%w: %q", ErrMetadataFieldNameInvalid, name)
+		}
+		if err := property.validate(); err != nil {
+			return fmt.Errorf("%w: %s", err, name)
+		}
+	}
+	for _, name := range s.Required {
+		if _, ok := s.Properties[name]; !ok {
+			return fmt.Errorf("%w: %s", ErrMetadataRequiredFieldUndefined, name)
+		}
+	}
+	return nil
+}
+
+// AppliesTo reports whether the schema binds documents with the given metadata. Departments
+// are compared case-insensitively.
+func (s *MetadataSchema) AppliesTo(metadata DocumentMetadata) bool {
+	if s.Department != "" {
+		if metadata.Department == nil || !strings.EqualFold(strings.TrimSpace(*metadata.Department), s.Department) {
+			return false
+		}
+	}
+	return s.Category == "" || s.Category == metadata.Category
+}
+
+// IsRequired reports whether the schema requires the field
+func (s *MetadataSchema) IsRequired(name string) bool {
+	for _, required := range s.Required {
+		if required == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (p MetadataProperty) validate() error {
+	if !p.Type.IsValid() {
+		return ErrMetadataFieldTypeInvalid
+	}
+	isString := p.Type == MetadataFieldString
+	isNumeric := p.Type == MetadataFieldInteger || p.Type == MetadataFieldNumber
+	switch {
+	case !isString && (len(p.Enum) > 0 || p.Pattern != "" || p.MinLength != nil || p.MaxLength != nil):
+		return ErrMetadataFieldConstraintInvalid
+	case !isNumeric && (p.Minimum != nil || p.Maximum != nil):
+		return ErrMetadataFieldConstraintInvalid
+	case p.MinLength != nil && *p.MinLength < 0, p.MaxLength != nil && *p.MaxLength < 0:
+		return ErrMetadataFieldConstraintInvalid
+	case p.MinLength != nil && p.MaxLength != nil && *p.MinLength > *p.MaxLength:
+		return ErrMetadataFieldConstraintInvalid
+	case p.Minimum != nil && p.Maximum != nil && *p.Minimum > *p.Maximum:
+		return ErrMetadataFieldConstraintInvalid
+	}
+	if p.Pattern != "" {
+		if _, err := regexp.Compile(p.Pattern); err != nil {
+			return ErrMetadataFieldConstraintInvalid
+		}
+	}
+	return nil
+}
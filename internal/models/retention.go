@@ -0,0 +1,138 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RecordType names a kind of record covered by retention schedules and legal holds
+type RecordType string
+
+const (
+	RecordTypeDocument     RecordType = "document"
+	RecordTypeConsultation RecordType = "consultation"
+)
+
+// IsValid reports whether t is a known record type
+func (t RecordType) IsValid() bool {
+	return t == RecordTypeDocument || t == RecordTypeConsultation
+}
+
+// DispositionAction is what happens to a record once its retention period ends
+type DispositionAction string
+
+const (
+	DispositionDelete  DispositionAction = "delete"  // the record and its files are destroyed
+	DispositionArchive DispositionAction = "archive" // a copy moves to records_archive and the live record is removed
+)
+
+// IsValid reports whether a is a known disposition action
+func (a DispositionAction) IsValid() bool {
+	return a == DispositionDelete || a == DispositionArchive
+}
+
+// RetentionSchedule sets how long one type of record is kept. A schedule applies to records in
+// its workspace or of its category (a document category or consultation type); a schedule with
+// neither is the default for its record type. When several specific schedules apply, the longest
+// one wins, and the default is only used when none does.
+type RetentionSchedule struct {
+	ID            primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	Name          string              `json:"name" bson:"name"`
+	Description   string              `json:"description,omitempty" bson:"description,omitempty"`
+	Authority     string              `json:"authority,omitempty" bson:"authority,omitempty"` // disposition authority, e.g. "GRS 5.2, item 020"
+	RecordType    RecordType          `json:"record_type" bson:"record_type"`
+	Category      string              `json:"category,omitempty" bson:"category,omitempty"`
+	WorkspaceID   *primitive.ObjectID `json:"workspace_id,omitempty" bson:"workspace_id,omitempty"`
+	RetentionDays int                 `json:"retention_days" bson:"retention_days"`
+	Disposition   DispositionAction   `json:"disposition" bson:"disposition"`
+	CreatedBy     primitive.ObjectID  `json:"created_by" bson:"created_by"`
+	CreatedAt     time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+// Validate validates the retention schedule model
+func (s *RetentionSchedule) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return ErrRetentionScheduleNameRequired
+	}
+	if !s.RecordType.IsValid() {
+		return ErrRecordTypeInvalid
+	}
+	if s.RetentionDays <= 0 {
+		return ErrRetentionDaysInvalid
+	}
+	if !s.Disposition.IsValid() {
+		return ErrDispositionActionInvalid
+	}
+	if s.Category != "" && s.WorkspaceID != nil {
+		return ErrRetentionScopeInvalid
+	}
+	return nil
+}
+
+// IsDefault reports whether the schedule applies to every record of its type
+func (s *RetentionSchedule) IsDefault() bool {
+	return s.Category == "" && s.WorkspaceID == nil
+}
+
+// RecordRetention is the retention schedule assigned to a record and when it falls due
+type RecordRetention struct {
+	ScheduleID      primitive.ObjectID `json:"schedule_id" bson:"schedule_id"`
+	Disposition     DispositionAction  `json:"disposition" bson:"disposition"`
+	DispositionDate time.Time          `json:"disposition_date" bson:"disposition_date"`
+	AssignedAt      time.Time          `json:"assigned_at" bson:"assigned_at"`
+}
+
+// LegalHoldStatus is the state of a legal hold
+type LegalHoldStatus string
+
+const (
+	LegalHoldActive   LegalHoldStatus = "active"
+	LegalHoldReleased LegalHoldStatus = "released"
+)
+
+// LegalHoldCounts counts the records under a legal hold
+type LegalHoldCounts struct {
+	Documents     int64 `json:"documents"`
+	Consultations int64 `json:"consultations"`
+}
+
+// LegalHold preserves records for litigation, audit or investigation. Records under an active
+// hold list it in legal_hold_ids and cannot be deleted, by users or by disposition, until every
+// hold on them is released.
+type LegalHold struct {
+	ID         primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	Name       string              `json:"name" bson:"name"`
+	Matter     string              `json:"matter,omitempty" bson:"matter,omitempty"` // case or matter number
+	Reason     string              `json:"reason,omitempty" bson:"reason,omitempty"`
+	Status     LegalHoldStatus     `json:"status" bson:"status"`
+	Counts     *LegalHoldCounts    `json:"counts,omitempty" bson:"-"`
+	PlacedBy   primitive.ObjectID  `json:"placed_by" bson:"placed_by"`
+	PlacedAt   time.Time           `json:"placed_at" bson:"placed_at"`
+	ReleasedBy *primitive.ObjectID `json:"released_by,omitempty" bson:"released_by,omitempty"`
+	ReleasedAt *time.Time          `json:"released_at,omitempty" bson:"released_at,omitempty"`
+}
+
+// Validate validates the legal hold model
+func (h *LegalHold) Validate() error {
+	if strings.TrimSpace(h.Name) == "" {
+		return ErrLegalHoldNameRequired
+	}
+	if h.PlacedBy.IsZero() {
+		return ErrLegalHoldPlacedByRequired
+	}
+	return nil
+}
+
+// ArchivedRecord is a copy of a record taken when it was archived at the end of its retention
+type ArchivedRecord struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	RecordType RecordType         `json:"record_type" bson:"record_type"`
+	RecordID   primitive.ObjectID `json:"record_id" bson:"record_id"`
+	ScheduleID primitive.ObjectID `json:"schedule_id" bson:"schedule_id"`
+	Record     bson.Raw           `json:"-" bson:"record"`
+	ArchivedAt time.Time          `json:"archived_at" bson:"archived_at"`
+}
@@ -43,6 +43,7 @@ type User struct {
 	Role              UserRole           `json:"role" bson:"role"`
 	Permissions       []Permission       `json:"permissions" bson:"permissions"`
 	SecurityClearance SecurityClearance  `json:"security_clearance" bson:"security_clearance"`
+	Compartments      []string           `json:"compartments,omitempty" bson:"compartments,omitempty"`
 	PasswordHash      string             `json:"-" bson:"password_hash"` // Hidden from JSON
 	LastLogin         *time.Time         `json:"last_login,omitempty" bson:"last_login,omitempty"`
 	CreatedAt         time.Time          `json:"created_at" bson:"created_at"`
@@ -104,6 +105,45 @@ func (u *User) CanAccessClassification(classification string) bool {
 	}
 }
 
+// AccessibleClassifications returns the classification levels the user can access
+func (u *User) AccessibleClassifications() []string {
+	levels := []string{}
+	for _, level := range classificationLevels {
+		if u.CanAccessClassification(level) {
+			levels = append(levels, level)
+		}
+	}
+	return levels
+}
+
+// HasCompartments reports whether the user is read into every one of the given compartments
+func (u *User) HasCompartments(compartments []string) bool {
+	for _, required := range compartments {
+		held := false
+		for _, compartment := range u.Compartments {
+			if compartment == required {
+				held = true
+				break
+			}
+		}
+		if !held {
+			return false
+		}
+	}
+	return true
+}
+
+// CanAccessDocument checks the user's clearance against the document's level, and their
+// compartments against its compartments unless they uploaded it or it was shared with them
+func (u *User) CanAccessDocument(doc *Document) bool {
+	if !u.CanAccessClassification(doc.Classification.Level) {
+		return false
+	}
+	return u.HasCompartments(doc.Classification.Compartments) ||
+		doc.UploadedBy == u.ID ||
+		doc.SharedWithUser(u.ID, time.Now())
+}
+
 // IsAdmin returns true if the user has admin role
 func (u *User) IsAdmin() bool {
 	return u.Role == UserRoleAdmin
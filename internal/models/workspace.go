@@ -0,0 +1,99 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WorkspaceRole represents a member's role in a workspace. Each role includes the
+// rights of the roles below it.
+type WorkspaceRole string
+
+const (
+	WorkspaceRoleViewer WorkspaceRole = "viewer" // may read and search the workspace's items
+	WorkspaceRoleEditor WorkspaceRole = "editor" // may also add and remove items
+	WorkspaceRoleOwner  WorkspaceRole = "owner"  // may also manage members, rename and delete the workspace
+)
+
+// workspaceRoleRanks orders workspace roles from least to most privileged
+var workspaceRoleRanks = map[WorkspaceRole]int{
+	WorkspaceRoleViewer: 1,
+	WorkspaceRoleEditor: 2,
+	WorkspaceRoleOwner:  3,
+}
+
+// IsValid reports whether r is a known workspace role
+func (r WorkspaceRole) IsValid() bool {
+	_, ok := workspaceRoleRanks[r]
+	return ok
+}
+
+// Includes reports whether r grants at least the rights of required
+func (r WorkspaceRole) Includes(required WorkspaceRole) bool {
+	return r.IsValid() && workspaceRoleRanks[r] >= workspaceRoleRanks[required]
+}
+
+// WorkspaceMember is a user's membership in a workspace
+type WorkspaceMember struct {
+	UserID  primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Role    WorkspaceRole      `json:"role" bson:"role"`
+	AddedBy primitive.ObjectID `json:"added_by" bson:"added_by"`
+	AddedAt time.Time          `json:"added_at" bson:"added_at"`
+}
+
+// WorkspaceCounts counts the items in a workspace
+type WorkspaceCounts struct {
+	Documents      int64 `json:"documents"`
+	KnowledgeItems int64 `json:"knowledge_items"`
+	Consultations  int64 `json:"consultations"`
+}
+
+// Workspace is a named collection of documents, knowledge items and consultations with its
+// own members. Items record the workspaces they belong to in workspace_ids, so an item can be
+// in several workspaces.
+type Workspace struct {
+	ID          primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	Name        string               `json:"name" bson:"name"`
+	Description string               `json:"description,omitempty" bson:"description,omitempty"`
+	Members     []WorkspaceMember    `json:"members" bson:"members"`
+	MemberIDs   []primitive.ObjectID `json:"-" bson:"member_ids"` // user IDs of Members, for membership lookups
+	Counts      *WorkspaceCounts     `json:"counts,omitempty" bson:"-"`
+	CreatedBy   primitive.ObjectID   `json:"created_by" bson:"created_by"`
+	CreatedAt   time.Time            `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at" bson:"updated_at"`
+}
+
+// Validate validates the workspace model
+func (w *Workspace) Validate() error {
+	if strings.TrimSpace(w.Name) == "" {
+		return ErrWorkspaceNameRequired
+	}
+	if w.CreatedBy.IsZero() {
+		return ErrWorkspaceCreatedByRequired
+	}
+	owners := 0
+	for _, member := range w.Members {
+		if !member.Role.IsValid() {
+			return ErrWorkspaceRoleInvalid
+		}
+		if member.Role == WorkspaceRoleOwner {
+			owners++
+		}
+	}
+	if owners == 0 {
+		return ErrWorkspaceOwnerRequired
+	}
+	return nil
+}
+
+// RoleOf returns the user's role in the workspace, or "" if the user is not a member
+func (w *Workspace) RoleOf(userID primitive.ObjectID) WorkspaceRole {
+	for _, member := range w.Members {
+		if member.UserID == userID {
+			return member.Role
+		}
+	}
+	return ""
+}
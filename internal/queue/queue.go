@@ -0,0 +1,532 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"ai-government-consultant/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CollectionName is the MongoDB collection holding queued jobs
+const CollectionName = "jobs"
+
+// JobStatus is the lifecycle state of a job
+type JobStatus string
+
+const (
+	// JobStatusPending jobs wait for a worker, either new or backing off after a failed attempt
+	JobStatusPending JobStatus = "pending"
+	// JobStatusRunning jobs are leased by a worker
+	JobStatusRunning JobStatus = "running"
+	// JobStatusCompleted jobs finished successfully
+	JobStatusCompleted JobStatus = "completed"
+	// JobStatusDead jobs exhausted their attempts and sit in the dead-letter list until requeued
+	JobStatusDead JobStatus = "dead"
+)
+
+var (
+	// ErrJobNotFound is returned when no job has the given ID
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobNotRequeueable is returned when requeueing a job that is still pending, running or completed
+	ErrJobNotRequeueable = errors.New("only dead jobs can be requeued")
+)
+
+// Job is a unit of background work persisted in MongoDB
+type Job struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Type        string             `json:"type" bson:"type"`
+	Key         string             `json:"key" bson:"key"` // what the job acts on, e.g. a document ID
+	Status      JobStatus          `json:"status" bson:"status"`
+	Attempts    int                `json:"attempts" bson:"attempts"`
+	MaxAttempts int                `json:"max_attempts" bson:"max_attempts"`
+	RunAt       time.Time          `json:"run_at" bson:"run_at"`
+	LockedBy    string             `json:"locked_by,omitempty" bson:"locked_by,omitempty"`
+	LockedUntil *time.Time         `json:"locked_until,omitempty" bson:"locked_until,omitempty"`
+	LastError   string             `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+	CompletedAt *time.Time         `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+}
+
+// FinalAttempt reports whether a failure of the current attempt sends the job to the dead-letter list
+func (j *Job) FinalAttempt() bool {
+	return j.Attempts >= j.MaxAttempts
+}
+
+// Handler runs one attempt of a job. Returning an error schedules a retry with backoff.
+type Handler func(ctx context.Context, job *Job) error
+
+// Config configures the worker pool and retry policy
+type Config struct {
+	Workers      int           // concurrent jobs per process
+	MaxAttempts  int           // attempts before a job is dead-lettered
+	BackoffBase  time.Duration // delay after the first failed attempt, doubled for each further one
+	BackoffMax   time.Duration // upper bound on the retry delay
+	Lease        time.Duration // how long a claimed job stays locked without a heartbeat
+	PollInterval time.Duration // how often idle workers look for due jobs
+}
+
+// DefaultConfig returns the default queue configuration
+func DefaultConfig() *Config {
+	return &Config{
+		Workers:      4,
+		MaxAttempts:  5,
+		BackoffBase:  10 * time.Second,
+		BackoffMax:   30 * time.Minute,
+		Lease:        2 * time.Minute,
+		PollInterval: 2 * time.Second,
+	}
+}
+
+// Stats counts jobs by status
+type Stats struct {
+	Pending   int64 `json:"pending"`
+	Running   int64 `json:"running"`
+	Completed int64 `json:"completed"`
+	Dead      int64 `json:"dead"`
+}
+
+// Queue is a durable job queue backed by a MongoDB collection. Workers claim due jobs
+// with a lease that they renew while the job runs, so jobs held by a process that
+// stopped mid-run are picked up again once the lease expires.
+type Queue struct {
+	collection *mongo.Collection
+	config     Config
+	logger     logger.Logger
+	workerID   string
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+
+	wake    chan struct{}
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
+}
+
+// New creates a queue on the jobs collection of db. Zero config values take their defaults.
+func New(db *mongo.Database, config *Config, log logger.Logger) *Queue {
+	cfg := *DefaultConfig()
+	if config != nil {
+		if config.Workers > 0 {
+			cfg.Workers = config.Workers
+		}
+		if config.MaxAttempts > 0 {
+			cfg.MaxAttempts = config.MaxAttempts
+		}
+		if config.BackoffBase > 0 {
+			cfg.BackoffBase = config.BackoffBase
+		}
+		if config.BackoffMax > 0 {
+			cfg.BackoffMax = config.BackoffMax
+		}
+		if config.Lease > 0 {
+			cfg.Lease = config.Lease
+		}
+		if config.PollInterval > 0 {
+			cfg.PollInterval = config.PollInterval
+		}
+	}
+
+	hostname, _ := os.Hostname()
+	return &Queue{
+		collection: db.Collection(CollectionName),
+		config:     cfg,
+		logger:     log,
+		workerID:   fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), primitive.NewObjectID().Hex()),
+		handlers:   make(map[string]Handler),
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// Handle registers the handler for a job type. Handlers must be registered before Start.
+func (q *Queue) Handle(jobType string, handler Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[jobType] = handler
+}
+
+// Enqueue adds a job to run as soon as a worker is free
+func (q *Queue) Enqueue(ctx context.Context, jobType, key string) (*Job, error) {
+	return q.EnqueueAt(ctx, jobType, key, time.Now())
+}
+
+// EnqueueAt adds a job that becomes due at runAt, for work scheduled ahead of time
+func (q *Queue) EnqueueAt(ctx context.Context, jobType, key string, runAt time.Time) (*Job, error) {
+	now := time.Now()
+	job := &Job{
+		ID:          primitive.NewObjectID(),
+		Type:        jobType,
+		Key:         key,
+		Status:      JobStatusPending,
+		MaxAttempts: q.config.MaxAttempts,
+		RunAt:       runAt,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if _, err := q.collection.InsertOne(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	if !runAt.After(now) {
+		q.notify()
+	}
+	return job, nil
+}
+
+// HasOpenJob reports whether a pending or running job of the given type exists for key
+func (q *Queue) HasOpenJob(ctx context.Context, jobType, key string) (bool, error) {
+	count, err := q.collection.CountDocuments(ctx, bson.M{
+		"type":   jobType,
+		"key":    key,
+		"status": bson.M{"$in": []JobStatus{JobStatusPending, JobStatusRunning}},
+	}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("failed to check for open jobs: %w", err)
+	}
+	return count > 0, nil
+}
+
+// Start launches the worker pool. Workers stop when Stop is called.
+func (q *Queue) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	q.cancel = cancel
+
+	for i := 0; i < q.config.Workers; i++ {
+		q.workers.Add(1)
+		go q.work(ctx)
+	}
+
+	q.logger.Info("Job queue started", map[string]interface{}{
+		"workers":   q.config.Workers,
+		"worker_id": q.workerID,
+	})
+}
+
+// Stop signals the workers to stop and waits for running jobs to finish
+func (q *Queue) Stop() {
+	if q.cancel == nil {
+		return
+	}
+	q.cancel()
+	q.workers.Wait()
+}
+
+// List returns jobs, newest first, optionally filtered by status and type
+func (q *Queue) List(ctx context.Context, status JobStatus, jobType string, limit, skip int) ([]*Job, int64, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	if jobType != "" {
+		filter["type"] = jobType
+	}
+
+	total, err := q.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count jobs: %w", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "updated_at", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(skip))
+	cursor, err := q.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find jobs: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	jobs := []*Job{}
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode jobs: %w", err)
+	}
+	return jobs, total, nil
+}
+
+// Get returns a job by ID
+func (q *Queue) Get(ctx context.Context, id primitive.ObjectID) (*Job, error) {
+	var job Job
+	if err := q.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&job); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrJobNotFound
+		}
+		return nil, fmt.Errorf("failed to find job: %w", err)
+	}
+	return &job, nil
+}
+
+// Requeue moves a dead job back to pending with a fresh set of attempts
+func (q *Queue) Requeue(ctx context.Context, id primitive.ObjectID) (*Job, error) {
+	now := time.Now()
+	var job Job
+	err := q.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": JobStatusDead},
+		bson.M{"$set": bson.M{
+			"status":       JobStatusPending,
+			"attempts":     0,
+			"max_attempts": q.config.MaxAttempts,
+			"run_at":       now,
+			"updated_at":   now,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		if _, getErr := q.Get(ctx, id); getErr != nil {
+			return nil, getErr
+		}
+		return nil, ErrJobNotRequeueable
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to requeue job: %w", err)
+	}
+
+	q.notify()
+	return &job, nil
+}
+
+// Stats counts jobs by status
+func (q *Queue) Stats(ctx context.Context) (*Stats, error) {
+	cursor, err := q.collection.Aggregate(ctx, []bson.M{
+		{"$group": bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate job stats: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	stats := &Stats{}
+	for cursor.Next(ctx) {
+		var group struct {
+			Status JobStatus `bson:"_id"`
+			Count  int64     `bson:"count"`
+		}
+		if err := cursor.Decode(&group); err != nil {
+			return nil, fmt.Errorf("failed to decode job stats: %w", err)
+		}
+		switch group.Status {
+		case JobStatusPending:
+			stats.Pending = group.Count
+		case JobStatusRunning:
+			stats.Running = group.Count
+		case JobStatusCompleted:
+			stats.Completed = group.Count
+		case JobStatusDead:
+			stats.Dead = group.Count
+		}
+	}
+	return stats, cursor.Err()
+}
+
+// notify wakes an idle worker without blocking
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// work claims and runs jobs until ctx is cancelled, sleeping between polls when the queue is empty
+func (q *Queue) work(ctx context.Context) {
+	defer q.workers.Done()
+
+	ticker := time.NewTicker(q.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, err := q.claim(ctx)
+		if err != nil && ctx.Err() == nil {
+			q.logger.Error("Failed to claim job", err, nil)
+		}
+		if job != nil {
+			q.run(job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim leases the next due job: a pending job whose run time has come, or a running
+// job whose lease expired because its worker stopped
+func (q *Queue) claim(ctx context.Context) (*Job, error) {
+	q.mu.RLock()
+	types := make([]string, 0, len(q.handlers))
+	for jobType := range q.handlers {
+		types = append(types, jobType)
+	}
+	q.mu.RUnlock()
+	if len(types) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	lockedUntil := now.Add(q.config.Lease)
+	filter := bson.M{
+		"type": bson.M{"$in": types},
+		"$or": []bson.M{
+			{"status": JobStatusPending, "run_at": bson.M{"$lte": now}},
+			{"status": JobStatusRunning, "locked_until": bson.M{"$lt": now}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":       JobStatusRunning,
+			"locked_by":    q.workerID,
+			"locked_until": lockedUntil,
+			"updated_at":   now,
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "run_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var job Job
+	if err := q.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// run executes one attempt of a claimed job, renewing its lease until the handler returns
+func (q *Queue) run(job *Job) {
+	fields := map[string]interface{}{
+		"job_id":   job.ID.Hex(),
+		"type":     job.Type,
+		"key":      job.Key,
+		"attempts": job.Attempts,
+	}
+
+	// A job reclaimed after its worker stopped has already used up its final attempt
+	if job.Attempts > job.MaxAttempts {
+		q.finish(job, fmt.Errorf("worker stopped during the final attempt"))
+		return
+	}
+
+	q.mu.RLock()
+	handler := q.handlers[job.Type]
+	q.mu.RUnlock()
+
+	// Jobs are not tied to the queue's context so that Stop lets running jobs finish
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.heartbeat(ctx, job.ID)
+
+	err := q.safeRun(ctx, handler, job)
+	cancel()
+
+	if err != nil {
+		q.logger.Warn("Job attempt failed", mergeFields(fields, map[string]interface{}{"error": err.Error()}))
+	} else {
+		q.logger.Debug("Job completed", fields)
+	}
+	q.finish(job, err)
+}
+
+// safeRun calls the handler, turning a panic into an error so one bad job cannot kill its worker
+func (q *Queue) safeRun(ctx context.Context, handler Handler, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// heartbeat extends the job's lease until ctx is cancelled
+func (q *Queue) heartbeat(ctx context.Context, id primitive.ObjectID) {
+	ticker := time.NewTicker(q.config.Lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			updateCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			q.collection.UpdateOne(updateCtx,
+				bson.M{"_id": id, "locked_by": q.workerID},
+				bson.M{"$set": bson.M{"locked_until": time.Now().Add(q.config.Lease)}},
+			)
+			cancel()
+		}
+	}
+}
+
+// finish records the outcome of an attempt: completed, retried after a backoff, or dead-lettered
+func (q *Queue) finish(job *Job, runErr error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	set := bson.M{"updated_at": now}
+	switch {
+	case runErr == nil:
+		set["status"] = JobStatusCompleted
+		set["completed_at"] = now
+	case job.FinalAttempt():
+		set["status"] = JobStatusDead
+		set["last_error"] = runErr.Error()
+	default:
+		set["status"] = JobStatusPending
+		set["run_at"] = now.Add(q.backoff(job.Attempts))
+		set["last_error"] = runErr.Error()
+	}
+
+	// Only the worker holding the lease may record the outcome
+	_, err := q.collection.UpdateOne(ctx,
+		bson.M{"_id": job.ID, "locked_by": q.workerID},
+		bson.M{"$set": set, "$unset": bson.M{"locked_by": "", "locked_until": ""}},
+	)
+	if err != nil {
+		q.logger.Error("Failed to record job result", err, map[string]interface{}{"job_id": job.ID.Hex()})
+	}
+	if set["status"] == JobStatusDead {
+		q.logger.Error("Job moved to dead-letter list", runErr, map[string]interface{}{
+			"job_id": job.ID.Hex(),
+			"type":   job.Type,
+			"key":    job.Key,
+		})
+	}
+}
+
+// backoff returns the delay before retrying after the given number of failed attempts
+func (q *Queue) backoff(attempts int) time.Duration {
+	delay := q.config.BackoffBase
+	for i := 1; i < attempts && delay < q.config.BackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > q.config.BackoffMax {
+		delay = q.config.BackoffMax
+	}
+	return delay
+}
+
+func mergeFields(fields, extra map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(fields)+len(extra))
+	for k, v := range fields {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,203 @@
+package rerank
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// PairScorer scores the relevance of each text to a query, reading the query and the text
+// together as a cross-encoder does
+type PairScorer interface {
+	// ScorePairs returns a score from 0 to 1 for each of texts, in their order
+	ScorePairs(ctx context.Context, query string, texts []string) ([]float64, error)
+
+	// Name identifies the scorer and its model
+	Name() string
+}
+
+// CrossEncoder ranks candidates by the scores of a PairScorer
+type CrossEncoder struct {
+	scorer PairScorer
+}
+
+// NewCrossEncoder creates a cross-encoder reranker scoring with scorer
+func NewCrossEncoder(scorer PairScorer) *CrossEncoder {
+	return &CrossEncoder{scorer: scorer}
+}
+
+// Name returns "cross_encoder/" followed by the scorer's name
+func (c *CrossEncoder) Name() string {
+	return "cross_encoder/" + c.scorer.Name()
+}
+
+// Rerank scores each candidate against query
+func (c *CrossEncoder) Rerank(ctx context.Context, query string, candidates []Candidate) ([]Judgment, error) {
+	texts := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		texts[i] = truncate(candidate.Text, maxPassageChars)
+	}
+
+	scores, err := c.scorer.ScorePairs(ctx, query, texts)
+	if err != nil {
+		return nil, fmt.Errorf("cross-encoder failed: %w", err)
+	}
+	if len(scores) != len(candidates) {
+		return nil, fmt.Errorf("cross-encoder returned %d scores for %d candidates", len(scores), len(candidates))
+	}
+
+	judgments := make([]Judgment, len(scores))
+	for i, score := range scores {
+		judgments[i] = Judgment{Score: score}
+	}
+	return judgments, nil
+}
+
+// HTTPScorer scores with a cross-encoder served over the /rerank API of text-embeddings-inference,
+// e.g. running BAAI/bge-reranker-base
+type HTTPScorer struct {
+	url        string
+	apiKey     string
+	httpClient *http.Client
+}
+
+type rerankRequest struct {
+	Query    string   `json:"query"`
+	Texts    []string `json:"texts"`
+	Truncate bool     `json:"truncate"`
+}
+
+type rerankScore struct {
+	Index int     `json:"index"`
+	Score float64 `json:"score"`
+}
+
+// NewHTTPScorer creates a scorer for the server at url, e.g. http://localhost:8080/rerank.
+// apiKey is sent as a bearer token when not empty.
+func NewHTTPScorer(url, apiKey string) *HTTPScorer {
+	url = strings.TrimSuffix(url, "/")
+	if !strings.HasSuffix(url, "/rerank") {
+		url += "/rerank"
+	}
+
+	return &HTTPScorer{
+		url:    url,
+		apiKey: apiKey,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Name returns "http"
+func (s *HTTPScorer) Name() string {
+	return "http"
+}
+
+// ScorePairs requests the scores of texts from the server
+func (s *HTTPScorer) ScorePairs(ctx context.Context, query string, texts []string) ([]float64, error) {
+	requestBody, err := json.Marshal(rerankRequest{Query: query, Texts: texts, Truncate: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var results []rerankScore
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	scores := make([]float64, len(texts))
+	for _, result := range results {
+		if result.Index < 0 || result.Index >= len(texts) {
+			return nil, fmt.Errorf("response has a score for unknown text %d", result.Index)
+		}
+		scores[result.Index] = result.Score
+	}
+	return scores, nil
+}
+
+// OverlapScorer scores a text by the share of the query's words it contains, so that the
+// cross-encoder stage works without a model server. Words of three letters or more count,
+// except common function words.
+type OverlapScorer struct{}
+
+// NewOverlapScorer creates an offline word overlap scorer
+func NewOverlapScorer() *OverlapScorer {
+	return &OverlapScorer{}
+}
+
+// Name returns "overlap"
+func (s *OverlapScorer) Name() string {
+	return "overlap"
+}
+
+// ScorePairs returns the share of the distinct query words found in each text
+func (s *OverlapScorer) ScorePairs(ctx context.Context, query string, texts []string) ([]float64, error) {
+	queryWords := make(map[string]bool)
+	for _, word := range words(query) {
+		queryWords[word] = true
+	}
+
+	scores := make([]float64, len(texts))
+	if len(queryWords) == 0 {
+		return scores, nil
+	}
+	for i, text := range texts {
+		found := make(map[string]bool)
+		for _, word := range words(text) {
+			if queryWords[word] {
+				found[word] = true
+			}
+		}
+		scores[i] = float64(len(found)) / float64(len(queryWords))
+	}
+	return scores, nil
+}
+
+// functionWords are left out of the overlap; they say little about what a text is about
+var functionWords = map[string]bool{
+	"and": true, "are": true, "can": true, "does": true, "for": true, "from": true, "has": true,
+	"have": true, "how": true, "its": true, "our": true, "shall": true, "should": true, "that": true,
+	"the": true, "this": true, "was": true, "were": true, "what": true, "when": true, "which": true,
+	"who": true, "why": true, "will": true, "with": true,
+}
+
+// words splits text into its lowercase words of three letters or more, leaving out function words
+func words(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	kept := fields[:0]
+	for _, field := range fields {
+		if len([]rune(field)) >= 3 && !functionWords[field] {
+			kept = append(kept, field)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,107 @@
+package rerank
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxPassageChars bounds the text of each candidate shown to the grader
+const maxPassageChars = 1500
+
+// Generator generates text from a prompt with a language model; summary.Provider is one
+type Generator interface {
+	Generate(ctx context.Context, prompt string) (string, error)
+	Name() string
+}
+
+// LLMGrader asks a language model to grade how well each candidate answers the query, on a
+// scale of 0 to 10, and to say why. All candidates are graded in one request.
+type LLMGrader struct {
+	generator Generator
+}
+
+// NewLLMGrader creates a grader asking generator
+func NewLLMGrader(generator Generator) *LLMGrader {
+	return &LLMGrader{generator: generator}
+}
+
+// Name returns "llm/" followed by the generator's name
+func (g *LLMGrader) Name() string {
+	return "llm/" + g.generator.Name()
+}
+
+type gradeAnswer struct {
+	Grades []struct {
+		Passage int     `json:"passage"`
+		Score   float64 `json:"score"`
+		Reason  string  `json:"reason"`
+	} `json:"grades"`
+}
+
+// Rerank grades the candidates, scaling the grades to 0-1. A candidate the model leaves out is
+// scored 0.
+func (g *LLMGrader) Rerank(ctx context.Context, query string, candidates []Candidate) ([]Judgment, error) {
+	text, err := g.generator.Generate(ctx, gradePrompt(query, candidates))
+	if err != nil {
+		return nil, fmt.Errorf("relevance grader failed: %w", err)
+	}
+
+	// Models sometimes wrap JSON in a code fence or add a sentence around it
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("relevance grader returned no JSON object")
+	}
+	var answer gradeAnswer
+	if err := json.Unmarshal([]byte(text[start:end+1]), &answer); err != nil {
+		return nil, fmt.Errorf("failed to decode relevance grades: %w", err)
+	}
+	if len(answer.Grades) == 0 {
+		return nil, fmt.Errorf("relevance grader returned no grades")
+	}
+
+	judgments := make([]Judgment, len(candidates))
+	for i := range judgments {
+		judgments[i].Reason = "not graded"
+	}
+	for _, grade := range answer.Grades {
+		if grade.Passage < 1 || grade.Passage > len(candidates) {
+			continue
+		}
+		judgments[grade.Passage-1] = Judgment{
+			Score:  min(max(grade.Score, 0), 10) / 10,
+			Reason: grade.Reason,
+		}
+	}
+	return judgments, nil
+}
+
+// gradePrompt asks for a grade of each candidate
+func gradePrompt(query string, candidates []Candidate) string {
+	var prompt strings.Builder
+	prompt.WriteString("You are selecting sources for a government policy consultant. Grade how relevant each numbered ")
+	prompt.WriteString("passage below is to answering the question, from 0 (unrelated) to 10 (directly answers it). ")
+	prompt.WriteString("Judge only what the passage says, not what its source might say elsewhere.\n\n")
+	prompt.WriteString("Respond with a single JSON object of this form, with one grade per passage:\n")
+	prompt.WriteString(`{"grades": [{"passage": 1, "score": 7, "reason": "..."}]}` + "\n\n")
+	prompt.WriteString("Give each reason in one short sentence.\n")
+	prompt.WriteString("Text shown as a bracketed label such as [SSN] has been redacted; never guess what it was.\n\n")
+	fmt.Fprintf(&prompt, "Question: %s\n\n", query)
+	for i, candidate := range candidates {
+		fmt.Fprintf(&prompt, "Passage %d:\n%s\n\n", i+1, truncate(candidate.Text, maxPassageChars))
+	}
+	return prompt.String()
+}
+
+// truncate cuts text to at most n bytes without splitting a UTF-8 character
+func truncate(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	return text[:n]
+}
@@ -0,0 +1,103 @@
+package rerank
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Embedder returns the embedding of a text; embedding.Service is one
+type Embedder interface {
+	GenerateEmbedding(ctx context.Context, text string) ([]float64, error)
+}
+
+// MMR re-orders candidates by maximal marginal relevance, so that passages repeating what a
+// better ranked passage already says are pushed down. Candidates are picked one at a time by
+// lambda * relevance - (1 - lambda) * redundancy, where relevance is the candidate's score so far
+// scaled to 0-1, and redundancy its highest cosine similarity to a passage already picked.
+type MMR struct {
+	embedder Embedder
+	lambda   float64
+}
+
+// NewMMR creates an MMR reranker trading relevance against diversity by lambda, from 0 (only
+// diversity) to 1 (only relevance); it defaults to 0.7
+func NewMMR(embedder Embedder, lambda float64) *MMR {
+	if lambda <= 0 || lambda > 1 {
+		lambda = 0.7
+	}
+	return &MMR{embedder: embedder, lambda: lambda}
+}
+
+// Name returns "mmr"
+func (m *MMR) Name() string {
+	return "mmr"
+}
+
+// Rerank scores each candidate with its marginal relevance at the time it is picked. Scores fall
+// as candidates are picked, so ranking by them gives the order of picking.
+func (m *MMR) Rerank(ctx context.Context, query string, candidates []Candidate) ([]Judgment, error) {
+	vectors := make([][]float64, len(candidates))
+	for i, candidate := range candidates {
+		vector, err := m.embedder.GenerateEmbedding(ctx, truncate(candidate.Text, maxPassageChars))
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed candidate %d: %w", i, err)
+		}
+		vectors[i] = vector
+	}
+
+	highest := 0.0
+	for _, candidate := range candidates {
+		highest = max(highest, candidate.Score)
+	}
+	relevance := make([]float64, len(candidates))
+	for i, candidate := range candidates {
+		if highest > 0 {
+			relevance[i] = max(candidate.Score, 0) / highest
+		}
+	}
+
+	judgments := make([]Judgment, len(candidates))
+	redundancy := make([]float64, len(candidates)) // highest similarity to a picked candidate
+	picked := make([]bool, len(candidates))
+	for range candidates {
+		best, bestScore := -1, math.Inf(-1)
+		for i := range candidates {
+			if picked[i] {
+				continue
+			}
+			if score := m.lambda*relevance[i] - (1-m.lambda)*redundancy[i]; score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+
+		picked[best] = true
+		judgments[best] = Judgment{Score: bestScore}
+		if redundancy[best] > 0 {
+			judgments[best].Reason = fmt.Sprintf("similarity %.2f to a passage ranked above", redundancy[best])
+		}
+		for i := range candidates {
+			if !picked[i] {
+				redundancy[i] = max(redundancy[i], cosine(vectors[i], vectors[best]))
+			}
+		}
+	}
+	return judgments, nil
+}
+
+// cosine returns the cosine similarity of a and b, or 0 if their dimensions differ
+func cosine(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
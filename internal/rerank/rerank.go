@@ -0,0 +1,156 @@
+// Package rerank re-orders the sources retrieved for a consultation before they are put in the
+// prompt. A pipeline runs re-ranking stages in turn, such as an LLM relevance grader, a
+// cross-encoder and an MMR diversity reranker; each stage can keep only its top k sources and drop
+// those below a relevance floor, and every judgment is recorded.
+package rerank
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"ai-government-consultant/internal/models"
+	"ai-government-consultant/pkg/logger"
+)
+
+// Candidate is a retrieved source to be re-ranked
+type Candidate struct {
+	Text  string  // text the source is judged on
+	Score float64 // relevance so far: the retrieval score, then the score of the last stage
+}
+
+// Judgment is a reranker's verdict on one candidate
+type Judgment struct {
+	Score  float64
+	Reason string
+}
+
+// Reranker judges the relevance of candidates to a query
+type Reranker interface {
+	// Rerank returns a judgment for each candidate, in the order of candidates. Candidates are
+	// ranked by the scores of their judgments, highest first.
+	Rerank(ctx context.Context, query string, candidates []Candidate) ([]Judgment, error)
+
+	// Name identifies the reranker in the recorded decisions, e.g. "llm/gemini/gemini-1.5-flash"
+	Name() string
+}
+
+// Stage is a reranker with the sources it lets through
+type Stage struct {
+	Reranker Reranker
+	TopK     int     // keep at most this many sources; 0 keeps them all
+	MinScore float64 // drop sources scored below this
+}
+
+// Outcome is what a pipeline decided about one candidate
+type Outcome struct {
+	Index    int     // position of the candidate in the input
+	Score    float64 // score of the last stage that judged the candidate
+	Selected bool
+	Dropped  string // why the candidate was left out, one of the models.RerankDropped reasons
+	Steps    []models.RerankStep
+}
+
+// Pipeline runs re-ranking stages one after another on the candidates the previous stage kept
+type Pipeline struct {
+	stages []Stage
+	logger logger.Logger
+}
+
+// NewPipeline creates a pipeline of stages; without stages it keeps the retrieval order
+func NewPipeline(logger logger.Logger, stages ...Stage) *Pipeline {
+	return &Pipeline{stages: stages, logger: logger}
+}
+
+// Enabled returns true if the pipeline has any stages
+func (p *Pipeline) Enabled() bool {
+	return p != nil && len(p.stages) > 0
+}
+
+// Rerank runs the stages on candidates, given in retrieval order, and selects at most limit of
+// them. A stage that fails is skipped, leaving the order as it was. The outcomes list the
+// selected candidates in their final order, then the dropped ones in the order they were dropped.
+func (p *Pipeline) Rerank(ctx context.Context, query string, candidates []Candidate, limit int) []Outcome {
+	outcomes := make([]Outcome, len(candidates))
+	current := make([]int, len(candidates)) // indexes of the candidates still in, in rank order
+	for i, candidate := range candidates {
+		outcomes[i] = Outcome{Index: i, Score: candidate.Score}
+		current[i] = i
+	}
+	var dropped []int
+
+	if p != nil {
+		for _, stage := range p.stages {
+			if len(current) == 0 {
+				break
+			}
+
+			stageCandidates := make([]Candidate, len(current))
+			for i, index := range current {
+				stageCandidates[i] = Candidate{Text: candidates[index].Text, Score: outcomes[index].Score}
+			}
+			judgments, err := stage.Reranker.Rerank(ctx, query, stageCandidates)
+			if err == nil && len(judgments) != len(current) {
+				err = fmt.Errorf("returned %d judgments for %d candidates", len(judgments), len(current))
+			}
+			if err != nil {
+				p.logger.Error("Re-ranking stage failed, keeping the previous order", err, map[string]interface{}{
+					"reranker": stage.Reranker.Name(),
+				})
+				continue
+			}
+
+			order := make([]int, len(current))
+			for i := range order {
+				order[i] = i
+			}
+			sort.SliceStable(order, func(a, b int) bool { return judgments[order[a]].Score > judgments[order[b]].Score })
+
+			var kept []int
+			for rank, i := range order {
+				index := current[i]
+				step := models.RerankStep{
+					Reranker: stage.Reranker.Name(),
+					Score:    judgments[i].Score,
+					Rank:     rank + 1,
+					Reason:   judgments[i].Reason,
+				}
+				switch {
+				case judgments[i].Score < stage.MinScore:
+					step.Dropped = models.RerankDroppedBelowMinScore
+				case stage.TopK > 0 && len(kept) >= stage.TopK:
+					step.Dropped = models.RerankDroppedBeyondTopK
+				}
+
+				outcome := &outcomes[index]
+				outcome.Score = step.Score
+				outcome.Steps = append(outcome.Steps, step)
+				if step.Dropped != "" {
+					outcome.Dropped = step.Dropped
+					dropped = append(dropped, index)
+					continue
+				}
+				kept = append(kept, index)
+			}
+			current = kept
+		}
+	}
+
+	if limit > 0 && len(current) > limit {
+		for _, index := range current[limit:] {
+			outcomes[index].Dropped = models.RerankDroppedBeyondLimit
+			dropped = append(dropped, index)
+		}
+		current = current[:limit]
+	}
+
+	ordered := make([]Outcome, 0, len(outcomes))
+	for _, index := range current {
+		outcomes[index].Selected = true
+		ordered = append(ordered, outcomes[index])
+	}
+	for _, index := range dropped {
+		ordered = append(ordered, outcomes[index])
+	}
+	return ordered
+}
@@ -0,0 +1,193 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ai-government-consultant/internal/audit"
+	"ai-government-consultant/internal/models"
+	"ai-government-consultant/internal/queue"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Start schedules the disposition sweep unless one is already waiting. Sweeps run once per
+// sweep interval; the job key is the start of the interval, so processes that start together
+// schedule each sweep only once.
+func (s *Service) Start(ctx context.Context) error {
+	return s.scheduleSweep(ctx, time.Now().Truncate(s.config.SweepInterval))
+}
+
+// scheduleSweep queues the sweep of the interval starting at runAt
+func (s *Service) scheduleSweep(ctx context.Context, runAt time.Time) error {
+	key := runAt.UTC().Format(time.RFC3339)
+	open, err := s.jobs.HasOpenJob(ctx, DispositionJob, key)
+	if err != nil {
+		return err
+	}
+	if open {
+		return nil
+	}
+	if _, err := s.jobs.EnqueueAt(ctx, DispositionJob, key, runAt); err != nil {
+		return fmt.Errorf("failed to schedule disposition sweep: %w", err)
+	}
+	return nil
+}
+
+// dispositionJob disposes of every record whose retention has ended and that is not under
+// legal hold, then schedules the next sweep
+func (s *Service) dispositionJob(ctx context.Context, job *queue.Job) error {
+	now := time.Now()
+	for recordType := range recordSources {
+		disposed, err := s.sweep(ctx, recordType, now)
+		if err != nil {
+			return err
+		}
+		if disposed > 0 {
+			s.logger.Info("Disposed of expired records", map[string]interface{}{
+				"record_type": recordType,
+				"records":     disposed,
+			})
+		}
+	}
+	return s.scheduleSweep(ctx, now.Truncate(s.config.SweepInterval).Add(s.config.SweepInterval))
+}
+
+// sweep disposes of the expired records of one type in batches and returns how many it disposed of.
+// A record that cannot be disposed of is logged and left for the next sweep.
+func (s *Service) sweep(ctx context.Context, recordType models.RecordType, now time.Time) (int, error) {
+	source := recordSources[recordType]
+	collection := s.db.Collection(source.collection)
+	schedules, err := s.ListSchedules(ctx, recordType)
+	if err != nil {
+		return 0, err
+	}
+
+	disposed := 0
+	lastID := primitive.NilObjectID
+	for {
+		filter := bson.M{
+			"_id":                        bson.M{"$gt": lastID},
+			"retention.disposition_date": bson.M{"$lte": now},
+		}
+		for key, value := range NotOnHold() {
+			filter[key] = value
+		}
+		cursor, err := collection.Find(ctx, filter, options.Find().
+			SetSort(bson.D{{Key: "_id", Value: 1}}).
+			SetLimit(int64(s.config.BatchSize)))
+		if err != nil {
+			return disposed, fmt.Errorf("failed to find expired %s records: %w", recordType, err)
+		}
+		var records []bson.Raw
+		for cursor.Next(ctx) {
+			records = append(records, append(bson.Raw(nil), cursor.Current...))
+		}
+		cursor.Close(ctx)
+		if len(records) == 0 {
+			return disposed, nil
+		}
+
+		for _, record := range records {
+			id, category, workspaceIDs, from, current := recordFields(record, source)
+			lastID = id
+
+			// The record's category, workspaces or the schedules may have changed since the
+			// retention was assigned, so it is checked again before anything is destroyed
+			retention := assign(schedules, category, workspaceIDs, from)
+			if retention == nil || retention.DispositionDate.After(now) {
+				if !sameRetention(current, retention) {
+					update := bson.M{"$unset": bson.M{"retention": ""}}
+					if retention != nil {
+						update = bson.M{"$set": bson.M{"retention": retention}}
+					}
+					collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+				}
+				continue
+			}
+
+			if err := s.dispose(ctx, recordType, id, record, retention); err != nil {
+				s.logger.Error("Failed to dispose of record", err, map[string]interface{}{
+					"record_type": recordType,
+					"record_id":   id.Hex(),
+				})
+				continue
+			}
+			disposed++
+		}
+	}
+}
+
+// dispose archives or deletes one record according to its retention and audits the disposition
+func (s *Service) dispose(ctx context.Context, recordType models.RecordType, id primitive.ObjectID, record bson.Raw, retention *models.RecordRetention) error {
+	var archiveID *primitive.ObjectID
+	if retention.Disposition == models.DispositionArchive {
+		archived := models.ArchivedRecord{
+			ID:         primitive.NewObjectID(),
+			RecordType: recordType,
+			RecordID:   id,
+			ScheduleID: retention.ScheduleID,
+			Record:     record,
+			ArchivedAt: time.Now(),
+		}
+		if _, err := s.archive.InsertOne(ctx, archived); err != nil {
+			return fmt.Errorf("failed to archive record: %w", err)
+		}
+		archiveID = &archived.ID
+	}
+
+	if err := s.deleteRecord(ctx, recordType, id, archiveID != nil); err != nil {
+		// A hold placed since the record was read keeps it live, so the copy is not needed
+		if archiveID != nil {
+			s.archive.DeleteOne(ctx, bson.M{"_id": *archiveID})
+		}
+		return err
+	}
+
+	details := map[string]interface{}{
+		"record_type":      recordType,
+		"record_id":        id.Hex(),
+		"schedule_id":      retention.ScheduleID.Hex(),
+		"disposition":      retention.Disposition,
+		"disposition_date": retention.DispositionDate,
+	}
+	if archiveID != nil {
+		details["archive_id"] = archiveID.Hex()
+	}
+	err := s.auditLog.LogEvent(ctx, audit.AuditEntry{
+		EventType: audit.EventRecordDisposed,
+		Level:     audit.AuditLevelInfo,
+		Resource:  fmt.Sprintf("%s/%s", recordType, id.Hex()),
+		Action:    string(retention.Disposition),
+		Result:    "success",
+		Details:   details,
+	})
+	if err != nil {
+		s.logger.Error("Failed to audit record disposition", err, details)
+	}
+	return nil
+}
+
+// deleteRecord deletes a record through its registered deleter, or straight from its collection
+func (s *Service) deleteRecord(ctx context.Context, recordType models.RecordType, id primitive.ObjectID, keepFiles bool) error {
+	s.mu.RLock()
+	deleter := s.deleters[recordType]
+	s.mu.RUnlock()
+	if deleter != nil {
+		return deleter.DeleteRecord(ctx, id, keepFiles)
+	}
+
+	filter := NotOnHold()
+	filter["_id"] = id
+	result, err := s.db.Collection(recordSources[recordType].collection).DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s record: %w", recordType, err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("%s record %s is under legal hold or no longer exists", recordType, id.Hex())
+	}
+	return nil
+}
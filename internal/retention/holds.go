@@ -0,0 +1,279 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"ai-government-consultant/internal/audit"
+	"ai-government-consultant/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxRecordsPerRequest bounds how many records one request places under or lifts from a hold
+const maxRecordsPerRequest = 1000
+
+// NotOnHold returns the query condition matching records without any legal hold
+func NotOnHold() bson.M {
+	return bson.M{"legal_hold_ids.0": bson.M{"$exists": false}}
+}
+
+// CreateHold places a new legal hold. Records are added to it with AddRecords.
+func (s *Service) CreateHold(ctx context.Context, name, matter, reason string, placedBy primitive.ObjectID) (*models.LegalHold, error) {
+	hold := &models.LegalHold{
+		ID:       primitive.NewObjectID(),
+		Name:     strings.TrimSpace(name),
+		Matter:   strings.TrimSpace(matter),
+		Reason:   strings.TrimSpace(reason),
+		Status:   models.LegalHoldActive,
+		PlacedBy: placedBy,
+		PlacedAt: time.Now(),
+	}
+	if err := hold.Validate(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.holds.InsertOne(ctx, hold); err != nil {
+		return nil, fmt.Errorf("failed to create legal hold: %w", err)
+	}
+	s.logHoldEvent(ctx, audit.EventLegalHoldPlaced, placedBy, hold, map[string]interface{}{
+		"matter": hold.Matter,
+		"reason": hold.Reason,
+	})
+	return hold, nil
+}
+
+// GetHold returns a legal hold with a count of the records under it
+func (s *Service) GetHold(ctx context.Context, id primitive.ObjectID) (*models.LegalHold, error) {
+	hold, err := s.findHold(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := &models.LegalHoldCounts{}
+	for recordType, count := range map[models.RecordType]*int64{
+		models.RecordTypeDocument:     &counts.Documents,
+		models.RecordTypeConsultation: &counts.Consultations,
+	} {
+		n, err := s.db.Collection(recordSources[recordType].collection).CountDocuments(ctx, bson.M{"legal_hold_ids": id})
+		if err != nil {
+			return nil, fmt.Errorf("failed to count %s records on hold: %w", recordType, err)
+		}
+		*count = n
+	}
+	hold.Counts = counts
+	return hold, nil
+}
+
+// ListHolds returns legal holds, newest first, optionally filtered by status
+func (s *Service) ListHolds(ctx context.Context, status models.LegalHoldStatus, limit, skip int) ([]*models.LegalHold, int64, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	total, err := s.holds.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count legal holds: %w", err)
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "placed_at", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(skip))
+
+	cursor, err := s.holds.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find legal holds: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	holds := []*models.LegalHold{}
+	if err := cursor.All(ctx, &holds); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode legal holds: %w", err)
+	}
+	return holds, total, nil
+}
+
+// ReleaseHold releases a legal hold and lifts it from every record under it. Records are only
+// free to be deleted once no other hold remains on them.
+func (s *Service) ReleaseHold(ctx context.Context, id, releasedBy primitive.ObjectID) (*models.LegalHold, error) {
+	now := time.Now()
+	var hold models.LegalHold
+	err := s.holds.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": models.LegalHoldActive},
+		bson.M{"$set": bson.M{
+			"status":      models.LegalHoldReleased,
+			"released_by": releasedBy,
+			"released_at": now,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&hold)
+	if err == mongo.ErrNoDocuments {
+		if _, findErr := s.findHold(ctx, id); findErr != nil {
+			return nil, findErr
+		}
+		return nil, ErrHoldReleased
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to release legal hold: %w", err)
+	}
+
+	released := map[string]interface{}{}
+	for recordType, source := range recordSources {
+		result, err := s.db.Collection(source.collection).UpdateMany(ctx,
+			bson.M{"legal_hold_ids": id},
+			bson.M{"$pull": bson.M{"legal_hold_ids": id}},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to lift legal hold from %s records: %w", recordType, err)
+		}
+		released[string(recordType)+"_count"] = result.ModifiedCount
+	}
+
+	s.logHoldEvent(ctx, audit.EventLegalHoldReleased, releasedBy, &hold, released)
+	return &hold, nil
+}
+
+// AddRecords places records under a legal hold and returns how many were found
+func (s *Service) AddRecords(ctx context.Context, id primitive.ObjectID, recordType models.RecordType, recordIDs []primitive.ObjectID, userID primitive.ObjectID) (int64, error) {
+	hold, collection, err := s.holdRecords(ctx, id, recordType, recordIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := collection.UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$in": recordIDs}},
+		bson.M{"$addToSet": bson.M{"legal_hold_ids": id}},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to place %s records on hold: %w", recordType, err)
+	}
+
+	s.logHoldEvent(ctx, audit.EventLegalHoldChanged, userID, hold, map[string]interface{}{
+		"change":      "added",
+		"record_type": recordType,
+		"record_ids":  hexIDs(recordIDs),
+		"matched":     result.MatchedCount,
+	})
+	return result.MatchedCount, nil
+}
+
+// RemoveRecords lifts a legal hold from records and returns how many were under it
+func (s *Service) RemoveRecords(ctx context.Context, id primitive.ObjectID, recordType models.RecordType, recordIDs []primitive.ObjectID, userID primitive.ObjectID) (int64, error) {
+	hold, collection, err := s.holdRecords(ctx, id, recordType, recordIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := collection.UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$in": recordIDs}, "legal_hold_ids": id},
+		bson.M{"$pull": bson.M{"legal_hold_ids": id}},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to lift hold from %s records: %w", recordType, err)
+	}
+
+	s.logHoldEvent(ctx, audit.EventLegalHoldChanged, userID, hold, map[string]interface{}{
+		"change":      "removed",
+		"record_type": recordType,
+		"record_ids":  hexIDs(recordIDs),
+		"matched":     result.ModifiedCount,
+	})
+	return result.ModifiedCount, nil
+}
+
+// holdRecords checks a request to change the records under a hold and returns the hold and
+// the collection of the records
+func (s *Service) holdRecords(ctx context.Context, id primitive.ObjectID, recordType models.RecordType, recordIDs []primitive.ObjectID) (*models.LegalHold, *mongo.Collection, error) {
+	source, ok := recordSources[recordType]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: unknown record type %q", ErrInvalidRecords, recordType)
+	}
+	if len(recordIDs) == 0 {
+		return nil, nil, fmt.Errorf("%w: no record IDs given", ErrInvalidRecords)
+	}
+	if len(recordIDs) > maxRecordsPerRequest {
+		return nil, nil, fmt.Errorf("%w: at most %d records can be changed at once", ErrInvalidRecords, maxRecordsPerRequest)
+	}
+
+	hold, err := s.findHold(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if hold.Status != models.LegalHoldActive {
+		return nil, nil, ErrHoldReleased
+	}
+	return hold, s.db.Collection(source.collection), nil
+}
+
+// LogBlockedDeletion records in the audit log that deleting a record was refused because of
+// the legal holds on it
+func (s *Service) LogBlockedDeletion(ctx context.Context, recordType models.RecordType, recordID, userID primitive.ObjectID, holdIDs []primitive.ObjectID) {
+	user := userID.Hex()
+	err := s.auditLog.LogEvent(ctx, audit.AuditEntry{
+		EventType: audit.EventDeletionBlocked,
+		Level:     audit.AuditLevelWarning,
+		UserID:    &user,
+		Resource:  fmt.Sprintf("%s/%s", recordType, recordID.Hex()),
+		Action:    string(audit.EventDeletionBlocked),
+		Result:    "failure",
+		Details: map[string]interface{}{
+			"record_type":    recordType,
+			"record_id":      recordID.Hex(),
+			"legal_hold_ids": hexIDs(holdIDs),
+		},
+	})
+	if err != nil {
+		s.logger.Error("Failed to audit blocked deletion", err, map[string]interface{}{
+			"record_type": recordType,
+			"record_id":   recordID.Hex(),
+		})
+	}
+}
+
+// logHoldEvent records a change to a legal hold in the audit log
+func (s *Service) logHoldEvent(ctx context.Context, eventType audit.AuditEventType, userID primitive.ObjectID, hold *models.LegalHold, details map[string]interface{}) {
+	user := userID.Hex()
+	details["legal_hold_id"] = hold.ID.Hex()
+	details["name"] = hold.Name
+	err := s.auditLog.LogEvent(ctx, audit.AuditEntry{
+		EventType: eventType,
+		Level:     audit.AuditLevelInfo,
+		UserID:    &user,
+		Resource:  "legal_hold/" + hold.ID.Hex(),
+		Action:    string(eventType),
+		Result:    "success",
+		Details:   details,
+	})
+	if err != nil {
+		s.logger.Error("Failed to audit legal hold change", err, map[string]interface{}{
+			"legal_hold_id": hold.ID.Hex(),
+			"event":         eventType,
+		})
+	}
+}
+
+func (s *Service) findHold(ctx context.Context, id primitive.ObjectID) (*models.LegalHold, error) {
+	var hold models.LegalHold
+	if err := s.holds.FindOne(ctx, bson.M{"_id": id}).Decode(&hold); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to find legal hold: %w", err)
+	}
+	return &hold, nil
+}
+
+func hexIDs(ids []primitive.ObjectID) []string {
+	hex := make([]string, len(ids))
+	for i, id := range ids {
+		hex[i] = id.Hex()
+	}
+	return hex
+}
@@ -0,0 +1,358 @@
+// Package retention implements records retention: schedules that decide how long documents and
+// consultations are kept, a scheduled disposition job that deletes or archives records once their
+// retention ends, and legal holds that keep records from being deleted while they are in force.
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-government-consultant/internal/audit"
+	"ai-government-consultant/internal/models"
+	"ai-government-consultant/internal/queue"
+	"ai-government-consultant/pkg/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// DispositionJob is the job type of the periodic sweep that disposes of expired records
+	DispositionJob = "retention.disposition"
+
+	// ReassignJob is the job type that recomputes the retention of every record of one type
+	// after its schedules change; the job key is the record type
+	ReassignJob = "retention.reassign"
+)
+
+var (
+	// ErrNotFound is returned when a retention schedule or legal hold does not exist
+	ErrNotFound = errors.New("not found")
+
+	// ErrHoldReleased is returned when changing the records of a legal hold that was released
+	ErrHoldReleased = errors.New("legal hold has been released")
+
+	// ErrInvalidRecords is returned when a request to place or lift a hold on records cannot be carried out
+	ErrInvalidRecords = errors.New("invalid records")
+)
+
+// recordSource describes where records of one type are stored and which of their fields
+// schedules are matched against
+type recordSource struct {
+	collection    string
+	categoryField string
+	dateField     string // retention runs from this date
+}
+
+var recordSources = map[models.RecordType]recordSource{
+	models.RecordTypeDocument:     {collection: "documents", categoryField: "metadata.category", dateField: "uploaded_at"},
+	models.RecordTypeConsultation: {collection: "consultations", categoryField: "type", dateField: "created_at"},
+}
+
+// RecordDeleter removes a record along with anything stored alongside it, such as files and
+// search chunks. keepFiles is set when the record was archived, so its files stay reachable
+// from the archived copy. It must not delete a record that is under legal hold.
+type RecordDeleter interface {
+	DeleteRecord(ctx context.Context, id primitive.ObjectID, keepFiles bool) error
+}
+
+// Config configures the disposition sweep
+type Config struct {
+	SweepInterval time.Duration // how often expired records are disposed of
+	BatchSize     int           // records disposed of per sweep
+}
+
+// Service handles retention schedules, legal holds and disposition
+type Service struct {
+	db        *mongo.Database
+	schedules *mongo.Collection
+	holds     *mongo.Collection
+	archive   *mongo.Collection
+	jobs      *queue.Queue
+	auditLog  audit.Service
+	logger    logger.Logger
+	config    Config
+
+	mu       sync.RWMutex
+	deleters map[models.RecordType]RecordDeleter
+}
+
+// NewService creates a retention service that records legal holds and dispositions in
+// auditLog and runs its sweeps on the jobs queue
+func NewService(db *mongo.Database, jobs *queue.Queue, auditLog audit.Service, log logger.Logger, config *Config) *Service {
+	cfg := Config{SweepInterval: time.Hour, BatchSize: 100}
+	if config != nil {
+		if config.SweepInterval > 0 {
+			cfg.SweepInterval = config.SweepInterval
+		}
+		if config.BatchSize > 0 {
+			cfg.BatchSize = config.BatchSize
+		}
+	}
+
+	s := &Service{
+		db:        db,
+		schedules: db.Collection("retention_schedules"),
+		holds:     db.Collection("legal_holds"),
+		archive:   db.Collection("records_archive"),
+		jobs:      jobs,
+		auditLog:  auditLog,
+		logger:    log,
+		config:    cfg,
+		deleters:  make(map[models.RecordType]RecordDeleter),
+	}
+	jobs.Handle(DispositionJob, s.dispositionJob)
+	jobs.Handle(ReassignJob, s.reassignJob)
+	return s
+}
+
+// RegisterDeleter sets how records of a type are deleted on disposition. Record types without
+// a deleter are removed from their collection directly.
+func (s *Service) RegisterDeleter(recordType models.RecordType, deleter RecordDeleter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleters[recordType] = deleter
+}
+
+// CreateSchedule creates a retention schedule and queues its records for reassignment
+func (s *Service) CreateSchedule(ctx context.Context, schedule *models.RetentionSchedule) error {
+	now := time.Now()
+	schedule.ID = primitive.NewObjectID()
+	schedule.Name = strings.TrimSpace(schedule.Name)
+	schedule.Category = strings.TrimSpace(schedule.Category)
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+	if err := schedule.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := s.schedules.InsertOne(ctx, schedule); err != nil {
+		return fmt.Errorf("failed to create retention schedule: %w", err)
+	}
+	return s.queueReassign(ctx, schedule.RecordType)
+}
+
+// GetSchedule returns a retention schedule by ID
+func (s *Service) GetSchedule(ctx context.Context, id primitive.ObjectID) (*models.RetentionSchedule, error) {
+	var schedule models.RetentionSchedule
+	if err := s.schedules.FindOne(ctx, bson.M{"_id": id}).Decode(&schedule); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to find retention schedule: %w", err)
+	}
+	return &schedule, nil
+}
+
+// ListSchedules returns the retention schedules, optionally of one record type, by name
+func (s *Service) ListSchedules(ctx context.Context, recordType models.RecordType) ([]*models.RetentionSchedule, error) {
+	filter := bson.M{}
+	if recordType != "" {
+		filter["record_type"] = recordType
+	}
+
+	cursor, err := s.schedules.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find retention schedules: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	schedules := []*models.RetentionSchedule{}
+	if err := cursor.All(ctx, &schedules); err != nil {
+		return nil, fmt.Errorf("failed to decode retention schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// UpdateSchedule replaces the settings of a retention schedule and queues its records for
+// reassignment. The record type and creator cannot change.
+func (s *Service) UpdateSchedule(ctx context.Context, id primitive.ObjectID, changes *models.RetentionSchedule) (*models.RetentionSchedule, error) {
+	schedule, err := s.GetSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	schedule.Name = strings.TrimSpace(changes.Name)
+	schedule.Description = changes.Description
+	schedule.Authority = changes.Authority
+	schedule.Category = strings.TrimSpace(changes.Category)
+	schedule.WorkspaceID = changes.WorkspaceID
+	schedule.RetentionDays = changes.RetentionDays
+	schedule.Disposition = changes.Disposition
+	schedule.UpdatedAt = time.Now()
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.schedules.ReplaceOne(ctx, bson.M{"_id": id}, schedule); err != nil {
+		return nil, fmt.Errorf("failed to update retention schedule: %w", err)
+	}
+	if err := s.queueReassign(ctx, schedule.RecordType); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// DeleteSchedule deletes a retention schedule and queues its records for reassignment
+func (s *Service) DeleteSchedule(ctx context.Context, id primitive.ObjectID) error {
+	schedule, err := s.GetSchedule(ctx, id)
+	if err != nil {
+		return err
+	}
+	if _, err := s.schedules.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+		return fmt.Errorf("failed to delete retention schedule: %w", err)
+	}
+	return s.queueReassign(ctx, schedule.RecordType)
+}
+
+// Assign returns the retention of a new record from the schedules that apply to it, or nil
+// when none does and the record is kept indefinitely
+func (s *Service) Assign(ctx context.Context, recordType models.RecordType, category string, workspaceIDs []primitive.ObjectID, from time.Time) (*models.RecordRetention, error) {
+	schedules, err := s.ListSchedules(ctx, recordType)
+	if err != nil {
+		return nil, err
+	}
+	return assign(schedules, category, workspaceIDs, from), nil
+}
+
+// assign picks the schedule for a record: the longest of the schedules for its category or its
+// workspaces, falling back to the longest default schedule
+func assign(schedules []*models.RetentionSchedule, category string, workspaceIDs []primitive.ObjectID, from time.Time) *models.RecordRetention {
+	var specific, fallback *models.RetentionSchedule
+	for _, schedule := range schedules {
+		switch {
+		case schedule.IsDefault():
+			if fallback == nil || schedule.RetentionDays > fallback.RetentionDays {
+				fallback = schedule
+			}
+		case schedule.Category != "" && schedule.Category == category,
+			schedule.WorkspaceID != nil && containsID(workspaceIDs, *schedule.WorkspaceID):
+			if specific == nil || schedule.RetentionDays > specific.RetentionDays {
+				specific = schedule
+			}
+		}
+	}
+
+	chosen := specific
+	if chosen == nil {
+		chosen = fallback
+	}
+	if chosen == nil {
+		return nil
+	}
+	return &models.RecordRetention{
+		ScheduleID:      chosen.ID,
+		Disposition:     chosen.Disposition,
+		DispositionDate: from.AddDate(0, 0, chosen.RetentionDays),
+		AssignedAt:      time.Now(),
+	}
+}
+
+func containsID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+// queueReassign queues a reassignment of every record of a type unless one is already waiting
+func (s *Service) queueReassign(ctx context.Context, recordType models.RecordType) error {
+	open, err := s.jobs.HasOpenJob(ctx, ReassignJob, string(recordType))
+	if err != nil {
+		return err
+	}
+	if open {
+		return nil
+	}
+	if _, err := s.jobs.Enqueue(ctx, ReassignJob, string(recordType)); err != nil {
+		return fmt.Errorf("failed to queue retention reassignment: %w", err)
+	}
+	return nil
+}
+
+// reassignJob recomputes the retention of every record of the type named by the job key
+func (s *Service) reassignJob(ctx context.Context, job *queue.Job) error {
+	recordType := models.RecordType(job.Key)
+	source, ok := recordSources[recordType]
+	if !ok {
+		return fmt.Errorf("unknown record type %q", job.Key)
+	}
+	schedules, err := s.ListSchedules(ctx, recordType)
+	if err != nil {
+		return err
+	}
+
+	collection := s.db.Collection(source.collection)
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{
+		source.categoryField: 1,
+		source.dateField:     1,
+		"workspace_ids":      1,
+		"retention":          1,
+	}))
+	if err != nil {
+		return fmt.Errorf("failed to find %s records: %w", recordType, err)
+	}
+	defer cursor.Close(ctx)
+
+	reassigned := 0
+	for cursor.Next(ctx) {
+		id, category, workspaceIDs, from, current := recordFields(cursor.Current, source)
+		retention := assign(schedules, category, workspaceIDs, from)
+		if sameRetention(current, retention) {
+			continue
+		}
+
+		update := bson.M{"$unset": bson.M{"retention": ""}}
+		if retention != nil {
+			update = bson.M{"$set": bson.M{"retention": retention}}
+		}
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": id}, update); err != nil {
+			return fmt.Errorf("failed to update retention of %s %s: %w", recordType, id.Hex(), err)
+		}
+		reassigned++
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("cursor error: %w", err)
+	}
+
+	s.logger.Info("Reassigned record retention", map[string]interface{}{
+		"record_type": recordType,
+		"records":     reassigned,
+	})
+	return nil
+}
+
+// recordFields reads the fields schedules are matched against from a record
+func recordFields(raw bson.Raw, source recordSource) (id primitive.ObjectID, category string, workspaceIDs []primitive.ObjectID, from time.Time, current *models.RecordRetention) {
+	var record struct {
+		ID           primitive.ObjectID      `bson:"_id"`
+		WorkspaceIDs []primitive.ObjectID    `bson:"workspace_ids"`
+		Retention    *models.RecordRetention `bson:"retention"`
+	}
+	bson.Unmarshal(raw, &record)
+
+	if value, err := raw.LookupErr(strings.Split(source.categoryField, ".")...); err == nil {
+		category, _ = value.StringValueOK()
+	}
+	if value, err := raw.LookupErr(source.dateField); err == nil {
+		from, _ = value.TimeOK()
+	}
+	return record.ID, category, record.WorkspaceIDs, from, record.Retention
+}
+
+// sameRetention reports whether a record's retention would be unchanged by reassignment
+func sameRetention(current, next *models.RecordRetention) bool {
+	if current == nil || next == nil {
+		return current == nil && next == nil
+	}
+	return current.ScheduleID == next.ScheduleID &&
+		current.Disposition == next.Disposition &&
+		current.DispositionDate.Equal(next.DispositionDate)
+}
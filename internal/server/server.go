@@ -6,17 +6,30 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"ai-government-consultant/internal/annotation"
 	"ai-government-consultant/internal/api"
+	"ai-government-consultant/internal/audit"
 	"ai-government-consultant/internal/auth"
+	"ai-government-consultant/internal/citation"
 	"ai-government-consultant/internal/config"
+	"ai-government-consultant/internal/connector"
 	"ai-government-consultant/internal/consultation"
 	"ai-government-consultant/internal/database"
 	"ai-government-consultant/internal/document"
 	"ai-government-consultant/internal/embedding"
+	"ai-government-consultant/internal/metadata"
+	"ai-government-consultant/internal/queue"
+	"ai-government-consultant/internal/rerank"
+	"ai-government-consultant/internal/retention"
+	"ai-government-consultant/internal/storage"
+	"ai-government-consultant/internal/summary"
+	"ai-government-consultant/internal/vectorindex"
 	"ai-government-consultant/internal/websocket"
+	"ai-government-consultant/internal/workspace"
 	"ai-government-consultant/pkg/logger"
 
 	"github.com/gin-gonic/gin"
@@ -30,7 +43,17 @@ type Server struct {
 	logger              logger.Logger
 	authService         *auth.AuthService
 	documentService     *document.Service
+	jobQueue            *queue.Queue
+	citationIndex       *citation.Index
+	workspaceService    *workspace.Service
+	retentionService    *retention.Service
+	annotationService   *annotation.Service
+	connectorService    *connector.Service
+	metadataService     *metadata.Service
+	embeddingService    *embedding.Service
+	embeddingPipeline   *embedding.Pipeline
 	consultationService *consultation.Service
+	sessionManager      *consultation.SessionManager
 	knowledgeService    api.KnowledgeServiceInterface
 	auditService        api.AuditServiceInterface
 	wsHub               *websocket.Hub
@@ -109,6 +132,13 @@ func (s *Server) Start() error {
 		return err
 	}
 
+	// Let running jobs finish; anything still queued is picked up on the next start
+	s.jobQueue.Stop()
+
+	// Stop reloading the embedding models and save the vector indexes so the next start does not rebuild them
+	s.embeddingService.StopModelWatch()
+	s.embeddingService.StopIndex()
+
 	s.logger.Info("Server exited", nil)
 	return nil
 }
@@ -147,8 +177,54 @@ func (s *Server) initializeServices() error {
 		return fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
+	// Initialize blob storage for original uploaded files
+	blobStore, err := storage.New(ctx, &storage.Config{
+		Backend:     s.config.Storage.Backend,
+		Path:        s.config.Storage.Path,
+		S3Endpoint:  s.config.Storage.S3Endpoint,
+		S3Bucket:    s.config.Storage.S3Bucket,
+		S3Region:    s.config.Storage.S3Region,
+		S3AccessKey: s.config.Storage.S3AccessKey,
+		S3SecretKey: s.config.Storage.S3SecretKey,
+		S3UseSSL:    s.config.Storage.S3UseSSL,
+	}, db)
+	if err != nil {
+		return fmt.Errorf("failed to initialize blob storage: %w", err)
+	}
+
+	// Initialize the durable job queue used for document processing
+	s.jobQueue = queue.New(db, &queue.Config{
+		Workers:     s.config.Queue.Workers,
+		MaxAttempts: s.config.Queue.MaxAttempts,
+		BackoffBase: time.Duration(s.config.Queue.BackoffBase) * time.Second,
+		BackoffMax:  time.Duration(s.config.Queue.BackoffMax) * time.Second,
+		Lease:       time.Duration(s.config.Queue.Lease) * time.Second,
+	}, s.logger)
+
+	// Initialize records retention, which audits legal holds and dispositions to audit_logs
+	s.retentionService = retention.NewService(db, s.jobQueue, audit.NewService(audit.NewMongoRepository(db)), s.logger, &retention.Config{
+		SweepInterval: time.Duration(s.config.Retention.SweepInterval) * time.Second,
+		BatchSize:     s.config.Retention.BatchSize,
+	})
+
+	// Initialize the optional LLM summarization of processed documents
+	var summarizer *summary.Summarizer
+	if s.config.AI.SummaryProvider != "" {
+		provider, err := summary.NewProvider(s.config.AI.SummaryProvider, s.config.AI.LLMAPIKey, s.config.AI.SummaryModel)
+		if err != nil {
+			return fmt.Errorf("failed to initialize document summaries: %w", err)
+		}
+		summarizer = summary.NewSummarizer(provider, &summary.Config{MaxPromptChars: s.config.AI.SummaryMaxInput})
+	}
+
 	// Initialize services
-	s.documentService = document.NewService(db)
+	s.metadataService = metadata.NewService(db, s.logger)
+	s.documentService = document.NewService(db, blobStore, s.jobQueue, s.retentionService, s.metadataService, summarizer)
+	s.connectorService = connector.NewService(db, s.documentService, s.jobQueue, s.logger, &connector.Config{
+		DirectoryRoots: s.config.Connector.DirectoryRoots,
+	})
+	s.citationIndex = citation.NewIndex(db)
+	s.workspaceService = workspace.NewService(db)
 	s.knowledgeService = api.NewSimpleKnowledgeService(db)
 	s.auditService = api.NewSimpleAuditService(db)
 
@@ -164,25 +240,114 @@ func (s *Server) initializeServices() error {
 	}
 	s.authService = auth.NewAuthService(db.Collection("users"), redisClient, jwtConfig)
 
-	// Initialize embedding service
+	// Initialize embedding service with the provider selected by EMBEDDING_MODEL; migrations
+	// build the providers of other models the same way
+	embeddingProviders := func(model string) (embedding.EmbeddingProvider, error) {
+		apiKey := s.config.AI.EmbeddingAPIKey
+		if apiKey == "" && !strings.HasPrefix(model, "openai/") {
+			apiKey = s.config.AI.LLMAPIKey
+		}
+		return embedding.NewProvider(model, embedding.ProviderConfig{
+			APIKey:    apiKey,
+			BaseURL:   s.config.AI.EmbeddingAPIURL,
+			Dimension: s.config.AI.EmbeddingDimension,
+		})
+	}
+	embeddingProvider, err := embeddingProviders(s.config.AI.EmbeddingModel)
+	if err != nil {
+		return fmt.Errorf("failed to initialize embedding provider: %w", err)
+	}
+	embeddingModelRefresh := time.Duration(s.config.AI.EmbeddingModelRefresh) * time.Second
 	embeddingConfig := &embedding.Config{
-		GeminiAPIKey: s.config.AI.LLMAPIKey,
+		Provider:     embeddingProvider,
+		Providers:    embeddingProviders,
+		ModelRefresh: embeddingModelRefresh,
 		MongoDB:      db,
 		Redis:        redisClient,
 		Logger:       s.logger,
+		Chunking: &embedding.ChunkerConfig{
+			Size:         s.config.AI.ChunkSize,
+			Overlap:      s.config.AI.ChunkOverlap,
+			HeadingAware: s.config.AI.ChunkHeadingAware,
+		},
+		Fusion: &embedding.FusionConfig{
+			Method:        embedding.FusionMethod(s.config.Search.Fusion),
+			RRFK:          s.config.Search.RRFK,
+			LexicalWeight: s.config.Search.LexicalWeight,
+			VectorWeight:  s.config.Search.VectorWeight,
+			Candidates:    s.config.Search.Candidates,
+		},
+	}
+	if s.config.Index.Enabled {
+		embeddingConfig.Index = &embedding.IndexConfig{
+			Path:         s.config.Index.Path,
+			SyncInterval: time.Duration(s.config.Index.SyncInterval) * time.Second,
+			Graph: vectorindex.Config{
+				M:              s.config.Index.M,
+				EfConstruction: s.config.Index.EfConstruction,
+				EfSearch:       s.config.Index.EfSearch,
+			},
+		}
 	}
-	embeddingService, err := embedding.NewService(embeddingConfig)
+	s.embeddingService, err = embedding.NewService(embeddingConfig)
 	if err != nil {
 		return fmt.Errorf("failed to initialize embedding service: %w", err)
 	}
 
+	// Switch to the model of the last embedding migration cutover, if any
+	modelsCtx, modelsCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer modelsCancel()
+	if err := s.embeddingService.LoadModels(modelsCtx); err != nil {
+		return fmt.Errorf("failed to load embedding models: %w", err)
+	}
+
+	// Initialize the embedding pipeline, which runs model migrations on the job queue
+	embeddingPipelineConfig := embedding.DefaultPipelineConfig()
+	embeddingPipelineConfig.Jobs = s.jobQueue
+	embeddingPipelineConfig.PromotionDelay = 2 * embeddingModelRefresh
+	s.embeddingPipeline = embedding.NewPipeline(s.embeddingService, embedding.NewRepository(db), s.logger, embeddingPipelineConfig)
+
+	// Initialize the optional re-ranking of retrieved consultation sources
+	var rerankStages []rerank.Stage
+	for _, name := range s.config.Rerank.Stages {
+		switch name {
+		case "llm":
+			rerankStages = append(rerankStages, rerank.Stage{
+				Reranker: rerank.NewLLMGrader(summary.NewGeminiProvider(s.config.AI.LLMAPIKey, s.config.Rerank.LLMModel)),
+				TopK:     s.config.Rerank.LLMTopK,
+				MinScore: s.config.Rerank.LLMMinScore,
+			})
+		case "cross_encoder":
+			var scorer rerank.PairScorer = rerank.NewOverlapScorer()
+			if s.config.Rerank.CrossEncoderURL != "" {
+				scorer = rerank.NewHTTPScorer(s.config.Rerank.CrossEncoderURL, s.config.Rerank.CrossEncoderAPIKey)
+			}
+			rerankStages = append(rerankStages, rerank.Stage{
+				Reranker: rerank.NewCrossEncoder(scorer),
+				TopK:     s.config.Rerank.CrossEncoderTopK,
+				MinScore: s.config.Rerank.CrossEncoderMinScore,
+			})
+		case "mmr":
+			rerankStages = append(rerankStages, rerank.Stage{
+				Reranker: rerank.NewMMR(s.embeddingService, s.config.Rerank.MMRLambda),
+				TopK:     s.config.Rerank.MMRTopK,
+				MinScore: s.config.Rerank.MMRMinScore,
+			})
+		default:
+			return fmt.Errorf("unknown re-ranking stage %q", name)
+		}
+	}
+
 	// Initialize consultation service
 	consultationConfig := &consultation.Config{
 		GeminiAPIKey:     s.config.AI.LLMAPIKey,
 		MongoDB:          db,
 		Redis:            redisClient,
-		EmbeddingService: embeddingService,
+		EmbeddingService: s.embeddingService,
 		Logger:           s.logger,
+		Retention:        s.retentionService,
+		Reranker:         rerank.NewPipeline(s.logger, rerankStages...),
+		RerankCandidates: s.config.Rerank.Candidates,
 		RateLimit: consultation.RateLimitConfig{
 			RequestsPerMinute: 60,
 			BurstSize:         10,
@@ -192,6 +357,7 @@ func (s *Server) initializeServices() error {
 	if err != nil {
 		return fmt.Errorf("failed to initialize consultation service: %w", err)
 	}
+	s.sessionManager = consultation.NewSessionManager(db, s.consultationService)
 
 	// Initialize WebSocket hub and handler
 	s.wsHub = websocket.NewHub()
@@ -200,6 +366,31 @@ func (s *Server) initializeServices() error {
 	// Start WebSocket hub in a goroutine
 	go s.wsHub.Run()
 
+	// Initialize document annotations, which push changes to collaborators over the hub
+	s.annotationService = annotation.NewService(db, s.wsHub, s.logger)
+
+	// Start job workers and queue documents left unprocessed by a previous run
+	s.jobQueue.Start()
+	recoverCtx, recoverCancel := context.WithTimeout(context.Background(), time.Minute)
+	defer recoverCancel()
+	if recovered, err := s.documentService.RecoverStaleDocuments(recoverCtx); err != nil {
+		s.logger.Error("Failed to recover unprocessed documents", err, nil)
+	} else if recovered > 0 {
+		s.logger.Info("Queued unprocessed documents for processing", map[string]interface{}{
+			"documents": recovered,
+		})
+	}
+	if err := s.retentionService.Start(recoverCtx); err != nil {
+		s.logger.Error("Failed to schedule retention disposition", err, nil)
+	}
+	if err := s.connectorService.Start(recoverCtx); err != nil {
+		s.logger.Error("Failed to schedule connector syncs", err, nil)
+	}
+
+	// Load or build the vector indexes in the background; searches scan MongoDB until they are ready
+	s.embeddingService.StartIndex()
+	s.embeddingService.StartModelWatch()
+
 	s.logger.Info("All services initialized successfully", nil)
 	return nil
 }
@@ -242,8 +433,18 @@ func (s *Server) setupRoutes() {
 		AuthService:         s.authService,
 		DocumentService:     s.documentService,
 		ConsultationService: s.consultationService,
+		SessionManager:      s.sessionManager,
 		KnowledgeService:    s.knowledgeService,
 		AuditService:        s.auditService,
+		JobQueue:            s.jobQueue,
+		CitationIndex:       s.citationIndex,
+		WorkspaceService:    s.workspaceService,
+		RetentionService:    s.retentionService,
+		AnnotationService:   s.annotationService,
+		ConnectorService:    s.connectorService,
+		MetadataService:     s.metadataService,
+		EmbeddingService:    s.embeddingService,
+		EmbeddingPipeline:   s.embeddingPipeline,
 		SpeechService:       nil, // Speech service is optional
 		AllowedOrigins:      allowedOrigins,
 	}
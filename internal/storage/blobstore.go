@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrBlobNotFound is returned when no blob is stored under a key
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore stores the original bytes of uploaded files, separately from extracted text
+type BlobStore interface {
+	// Put stores size bytes read from data under key, replacing any existing blob
+	Put(ctx context.Context, key string, data io.Reader, size int64, contentType string) error
+	// Open returns a seekable reader over the blob stored under key
+	Open(ctx context.Context, key string) (Blob, error)
+	// Delete removes the blob stored under key; deleting a missing blob is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// Blob is a stored file opened for reading. Seeking is supported so that byte
+// ranges can be served without reading the whole file.
+type Blob interface {
+	io.ReadSeekCloser
+	Size() int64
+}
+
+// Config selects and configures a blob store backend
+type Config struct {
+	Backend string // "gridfs" (default), "filesystem" or "s3"
+
+	// Filesystem backend
+	Path string
+
+	// S3-compatible backend (AWS S3, MinIO, ...)
+	S3Endpoint  string
+	S3Bucket    string
+	S3Region    string
+	S3AccessKey string
+	S3SecretKey string
+	S3UseSSL    bool
+}
+
+// New creates the blob store selected by config. The MongoDB database is used by the GridFS backend.
+func New(ctx context.Context, config *Config, db *mongo.Database) (BlobStore, error) {
+	if config == nil {
+		config = &Config{}
+	}
+
+	switch config.Backend {
+	case "", "gridfs":
+		return NewGridFSStore(db)
+	case "filesystem":
+		return NewFilesystemStore(config.Path)
+	case "s3":
+		return NewS3Store(ctx, &S3Config{
+			Endpoint:  config.S3Endpoint,
+			Bucket:    config.S3Bucket,
+			Region:    config.S3Region,
+			AccessKey: config.S3AccessKey,
+			SecretKey: config.S3SecretKey,
+			UseSSL:    config.S3UseSSL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown blob store backend: %s", config.Backend)
+	}
+}
+
+// bytesBlob serves an in-memory byte slice as a Blob
+type bytesBlob struct {
+	*bytes.Reader
+}
+
+// NewBytesBlob wraps data held in memory, such as content stored before blob storage existed
+func NewBytesBlob(data []byte) Blob {
+	return bytesBlob{Reader: bytes.NewReader(data)}
+}
+
+func (b bytesBlob) Close() error {
+	return nil
+}
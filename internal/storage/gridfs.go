@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// gridfsBucketName is the GridFS bucket holding uploaded files (collections files.files and files.chunks)
+const gridfsBucketName = "files"
+
+// gridfsFile is the part of a GridFS files collection entry the store needs
+type gridfsFile struct {
+	ID     interface{} `bson:"_id"`
+	Length int64       `bson:"length"`
+}
+
+// GridFSStore stores blobs in MongoDB GridFS, using the key as the file name
+type GridFSStore struct {
+	bucket *gridfs.Bucket
+}
+
+// NewGridFSStore creates a GridFS blob store in the given database
+func NewGridFSStore(db *mongo.Database) (*GridFSStore, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database is required for GridFS blob storage")
+	}
+	bucket, err := gridfs.NewBucket(db, options.GridFSBucket().SetName(gridfsBucketName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GridFS bucket: %w", err)
+	}
+	return &GridFSStore{bucket: bucket}, nil
+}
+
+// Put uploads the blob as a new GridFS file and then removes earlier files with the same key
+func (s *GridFSStore) Put(ctx context.Context, key string, data io.Reader, size int64, contentType string) error {
+	opts := options.GridFSUpload().SetMetadata(bson.M{"content_type": contentType})
+	fileID, err := s.bucket.UploadFromStream(key, data, opts)
+	if err != nil {
+		return fmt.Errorf("failed to upload blob: %w", err)
+	}
+
+	cursor, err := s.bucket.FindContext(ctx, bson.M{"filename": key, "_id": bson.M{"$ne": fileID}})
+	if err != nil {
+		return fmt.Errorf("failed to find previous blob revisions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var file gridfsFile
+		if err := cursor.Decode(&file); err != nil {
+			continue
+		}
+		if err := s.bucket.DeleteContext(ctx, file.ID); err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+			return fmt.Errorf("failed to delete previous blob revision: %w", err)
+		}
+	}
+	return nil
+}
+
+// Open opens the latest GridFS file stored under key
+func (s *GridFSStore) Open(ctx context.Context, key string) (Blob, error) {
+	cursor, err := s.bucket.FindContext(ctx, bson.M{"filename": key},
+		options.GridFSFind().SetSort(bson.M{"uploadDate": -1}).SetLimit(1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find blob: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	if !cursor.Next(ctx) {
+		if err := cursor.Err(); err != nil {
+			return nil, fmt.Errorf("failed to find blob: %w", err)
+		}
+		return nil, ErrBlobNotFound
+	}
+
+	var file gridfsFile
+	if err := cursor.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to decode blob file: %w", err)
+	}
+
+	return &gridfsBlob{bucket: s.bucket, fileID: file.ID, size: file.Length}, nil
+}
+
+// Delete removes every GridFS file stored under key
+func (s *GridFSStore) Delete(ctx context.Context, key string) error {
+	cursor, err := s.bucket.FindContext(ctx, bson.M{"filename": key})
+	if err != nil {
+		return fmt.Errorf("failed to find blob: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var file gridfsFile
+		if err := cursor.Decode(&file); err != nil {
+			continue
+		}
+		if err := s.bucket.DeleteContext(ctx, file.ID); err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+			return fmt.Errorf("failed to delete blob: %w", err)
+		}
+	}
+	return nil
+}
+
+// gridfsBlob reads a GridFS file, reopening the download stream at the new offset after a seek
+type gridfsBlob struct {
+	bucket *gridfs.Bucket
+	fileID interface{}
+	size   int64
+	offset int64
+	stream *gridfs.DownloadStream
+}
+
+func (b *gridfsBlob) Read(p []byte) (int, error) {
+	if b.offset >= b.size {
+		return 0, io.EOF
+	}
+	if b.stream == nil {
+		stream, err := b.bucket.OpenDownloadStream(b.fileID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to open blob stream: %w", err)
+		}
+		if _, err := stream.Skip(b.offset); err != nil {
+			stream.Close()
+			return 0, fmt.Errorf("failed to seek blob stream: %w", err)
+		}
+		b.stream = stream
+	}
+
+	n, err := b.stream.Read(p)
+	b.offset += int64(n)
+	return n, err
+}
+
+func (b *gridfsBlob) Seek(offset int64, whence int) (int64, error) {
+	var target int64
+	switch whence {
+	case io.SeekStart:
+		target = offset
+	case io.SeekCurrent:
+		target = b.offset + offset
+	case io.SeekEnd:
+		target = b.size + offset
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+	if target < 0 {
+		return 0, fmt.Errorf("negative seek position")
+	}
+
+	if target != b.offset && b.stream != nil {
+		b.stream.Close()
+		b.stream = nil
+	}
+	b.offset = target
+	return target, nil
+}
+
+func (b *gridfsBlob) Size() int64 {
+	return b.size
+}
+
+func (b *gridfsBlob) Close() error {
+	if b.stream == nil {
+		return nil
+	}
+	err := b.stream.Close()
+	b.stream = nil
+	return err
+}
@@ -0,0 +1,119 @@
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	// sectionBlock matches one section of a summarization prompt
+	sectionBlock = regexp.MustCompile(`(?s)` + regexp.QuoteMeta(sectionOpen) + `(.*?)>>>\n(.*?)\n` + regexp.QuoteMeta(sectionClose))
+
+	// obligationPattern matches sentences that require something of someone
+	obligationPattern = regexp.MustCompile(`(?i)\b(shall|must|(?:is|are|will be) required to)\b`)
+
+	// deadlinePattern matches a deadline such as "within 30 days of award" or "by March 1, 2027"
+	deadlinePattern = regexp.MustCompile(`(?i)\b(?:no later than|not later than|on or before|by|before|within)\s+(?:\d+\s+(?:business\s+|calendar\s+)?(?:hours?|days?|weeks?|months?|years?)(?:\s+(?:of|after|from|following)\s+[^,.;]+)?|(?:January|February|March|April|May|June|July|August|September|October|November|December)\s+\d{1,2},\s+\d{4}|\d{4}-\d{2}-\d{2})`)
+
+	// calendarDate matches a calendar date within a deadline
+	calendarDate = regexp.MustCompile(`(?:January|February|March|April|May|June|July|August|September|October|November|December)\s+\d{1,2},\s+\d{4}|\d{4}-\d{2}-\d{2}`)
+)
+
+// MockProvider answers summarization prompts offline with extractive summaries: the lead
+// sentence of each section, and the sentences that state obligations with any deadline in them.
+// Its answers depend only on the prompt, so it suits tests and development without an LLM.
+type MockProvider struct{}
+
+// NewMockProvider creates a mock provider
+func NewMockProvider() *MockProvider {
+	return &MockProvider{}
+}
+
+// Name returns "mock"
+func (p *MockProvider) Name() string {
+	return "mock"
+}
+
+// Generate answers a summarization prompt in the JSON form the prompt asks for
+func (p *MockProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	var answer response
+	var leads []string
+	for _, block := range sectionBlock.FindAllStringSubmatch(prompt, -1) {
+		label, sentences := block[1], splitSentences(block[2])
+		if len(sentences) == 0 {
+			continue
+		}
+
+		answer.Sections = append(answer.Sections, sectionAnswer{Section: label, Summary: sentences[0]})
+		if len(leads) < 3 {
+			leads = append(leads, sentences[0])
+		}
+
+		for _, sentence := range sentences {
+			modal := obligationPattern.FindStringIndex(sentence)
+			if modal == nil {
+				continue
+			}
+			obligation := obligationAnswer{Text: sentence, Section: label}
+			if subject := strings.TrimSpace(sentence[:modal[0]]); subject != "" && len(strings.Fields(subject)) <= 6 {
+				obligation.Party = subject
+			}
+			if deadline := deadlinePattern.FindString(sentence); deadline != "" {
+				obligation.Deadline = deadline
+				obligation.DueDate = isoDate(calendarDate.FindString(deadline))
+			}
+			answer.Obligations = append(answer.Obligations, obligation)
+		}
+	}
+	answer.ExecutiveSummary = strings.Join(leads, " ")
+
+	encoded, err := json.Marshal(answer)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// splitSentences splits text into sentences, collapsing whitespace. A full stop followed by a
+// lowercase letter, digit or section sign, as in "5 U.S.C. § 552", does not end a sentence.
+func splitSentences(text string) []string {
+	words := strings.Fields(text)
+	var sentences []string
+	var current []string
+	for i, word := range words {
+		current = append(current, word)
+		if !strings.ContainsAny(word[len(word)-1:], ".!?") {
+			continue
+		}
+		if i+1 < len(words) {
+			next := []rune(words[i+1])[0]
+			if unicode.IsLower(next) || unicode.IsDigit(next) || next == '§' {
+				continue
+			}
+		}
+		sentences = append(sentences, truncate(strings.Join(current, " "), 500))
+		current = nil
+	}
+	if len(current) > 0 {
+		sentences = append(sentences, truncate(strings.Join(current, " "), 500))
+	}
+	return sentences
+}
+
+// isoDate converts a date such as "March 1, 2027" to "2027-03-01", returning "" if it is not one
+func isoDate(value string) string {
+	for _, layout := range []string{"January 2, 2006", "2006-01-02"} {
+		if date, err := time.Parse(layout, strings.Join(strings.Fields(value), " ")); err == nil {
+			return date.Format("2006-01-02")
+		}
+	}
+	return ""
+}
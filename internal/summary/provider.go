@@ -0,0 +1,32 @@
+// Package summary generates multi-level document summaries with an LLM: an executive summary,
+// a summary of each section, and the obligations and deadlines the document sets.
+package summary
+
+import (
+	"context"
+	"fmt"
+)
+
+// Provider generates text from a prompt with a language model
+type Provider interface {
+	// Generate returns the model's response to the prompt
+	Generate(ctx context.Context, prompt string) (string, error)
+
+	// Name identifies the provider and model, e.g. "gemini/gemini-1.5-flash"
+	Name() string
+}
+
+// NewProvider returns the provider named by name: "gemini", or "mock" for the offline provider
+func NewProvider(name, apiKey, model string) (Provider, error) {
+	switch name {
+	case "gemini":
+		if apiKey == "" {
+			return nil, fmt.Errorf("summary provider gemini requires an API key")
+		}
+		return NewGeminiProvider(apiKey, model), nil
+	case "mock":
+		return NewMockProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown summary provider %q", name)
+	}
+}
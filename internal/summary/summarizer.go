@@ -0,0 +1,299 @@
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ai-government-consultant/internal/models"
+)
+
+// ErrNoContent is returned when a document has no text to summarize
+var ErrNoContent = errors.New("document has no text to summarize")
+
+const (
+	// Sections are sent to the model between these markers, one block per section
+	sectionOpen  = "<<<SECTION: "
+	sectionClose = "<<<END SECTION>>>"
+
+	// maxObligations bounds the obligations kept from one document
+	maxObligations = 200
+)
+
+// Config configures a Summarizer
+type Config struct {
+	MaxPromptChars int // document text sent in one prompt; longer documents are summarized in batches of sections
+}
+
+// Summarizer writes document summaries with a Provider. A document that fits in one prompt is
+// summarized in a single call. A longer one has its sections summarized in batches, and the
+// executive summary is then written from the section summaries.
+type Summarizer struct {
+	provider       Provider
+	maxPromptChars int
+}
+
+// NewSummarizer creates a summarizer using provider
+func NewSummarizer(provider Provider, config *Config) *Summarizer {
+	maxPromptChars := 24000
+	if config != nil && config.MaxPromptChars > 0 {
+		maxPromptChars = config.MaxPromptChars
+	}
+	return &Summarizer{
+		provider:       provider,
+		maxPromptChars: maxPromptChars,
+	}
+}
+
+// Provider returns the name of the summarizer's provider
+func (s *Summarizer) Provider() string {
+	return s.provider.Name()
+}
+
+// part is a stretch of document text summarized as one section
+type part struct {
+	label string
+	text  string
+}
+
+// response is the JSON the model is asked to answer with
+type response struct {
+	ExecutiveSummary string             `json:"executive_summary"`
+	Sections         []sectionAnswer    `json:"sections"`
+	Obligations      []obligationAnswer `json:"obligations"`
+}
+
+type sectionAnswer struct {
+	Section string `json:"section"`
+	Summary string `json:"summary"`
+}
+
+type obligationAnswer struct {
+	Text     string `json:"text"`
+	Party    string `json:"party"`
+	Deadline string `json:"deadline"`
+	DueDate  string `json:"due_date"` // YYYY-MM-DD
+	Section  string `json:"section"`
+}
+
+// Summarize summarizes a document's text. sections are byte ranges of text, as found when the
+// document was processed; without them the document is summarized as a whole.
+func (s *Summarizer) Summarize(ctx context.Context, title, text string, sections []models.SectionSpan) (*models.DocumentSummary, error) {
+	parts := documentParts(text, sections)
+	if len(parts) == 0 {
+		return nil, ErrNoContent
+	}
+
+	summary := &models.DocumentSummary{
+		Sections:    []models.SectionSummary{},
+		Obligations: []models.Obligation{},
+		Provider:    s.provider.Name(),
+	}
+	batches := s.batch(parts)
+	for _, batch := range batches {
+		answer, err := s.ask(ctx, sectionPrompt(title, batch, len(batches) == 1))
+		if err != nil {
+			return nil, err
+		}
+		if len(batches) == 1 {
+			summary.Executive = strings.TrimSpace(answer.ExecutiveSummary)
+		}
+		mergeAnswer(summary, answer)
+	}
+
+	// A document sent in several batches gets its executive summary from the section summaries
+	if len(batches) > 1 {
+		digest := make([]part, 0, len(summary.Sections))
+		for _, section := range summary.Sections {
+			digest = append(digest, part{label: section.Section, text: section.Summary})
+		}
+		answer, err := s.ask(ctx, executivePrompt(title, digest))
+		if err != nil {
+			return nil, err
+		}
+		summary.Executive = strings.TrimSpace(answer.ExecutiveSummary)
+	}
+	if summary.Executive == "" {
+		return nil, fmt.Errorf("summary provider returned no executive summary")
+	}
+
+	summary.GeneratedAt = time.Now()
+	return summary, nil
+}
+
+// ask sends a prompt and decodes the model's JSON answer
+func (s *Summarizer) ask(ctx context.Context, prompt string) (*response, error) {
+	text, err := s.provider.Generate(ctx, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("summary provider failed: %w", err)
+	}
+
+	// Models sometimes wrap JSON in a code fence or add a sentence around it
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("summary provider returned no JSON object")
+	}
+	var answer response
+	if err := json.Unmarshal([]byte(text[start:end+1]), &answer); err != nil {
+		return nil, fmt.Errorf("failed to decode summary: %w", err)
+	}
+	return &answer, nil
+}
+
+// batch groups parts into prompts of at most maxPromptChars of document text. A part longer
+// than that is cut short.
+func (s *Summarizer) batch(parts []part) [][]part {
+	var batches [][]part
+	var current []part
+	size := 0
+	for _, p := range parts {
+		if len(p.text) > s.maxPromptChars {
+			p.text = truncate(p.text, s.maxPromptChars)
+		}
+		if size+len(p.text) > s.maxPromptChars && len(current) > 0 {
+			batches = append(batches, current)
+			current, size = nil, 0
+		}
+		current = append(current, p)
+		size += len(p.text)
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+	return batches
+}
+
+// mergeAnswer adds the section summaries and obligations of one answer to the summary
+func mergeAnswer(summary *models.DocumentSummary, answer *response) {
+	for _, section := range answer.Sections {
+		if text := strings.TrimSpace(section.Summary); text != "" {
+			summary.Sections = append(summary.Sections, models.SectionSummary{
+				Section: strings.TrimSpace(section.Section),
+				Summary: text,
+			})
+		}
+	}
+
+	for _, item := range answer.Obligations {
+		if len(summary.Obligations) >= maxObligations {
+			break
+		}
+		text := strings.TrimSpace(item.Text)
+		if text == "" {
+			continue
+		}
+		obligation := models.Obligation{
+			Text:     text,
+			Party:    strings.TrimSpace(item.Party),
+			Deadline: strings.TrimSpace(item.Deadline),
+			Section:  strings.TrimSpace(item.Section),
+		}
+		if due, err := time.Parse("2006-01-02", strings.TrimSpace(item.DueDate)); err == nil {
+			obligation.DueDate = &due
+		}
+		summary.Obligations = append(summary.Obligations, obligation)
+	}
+}
+
+// documentParts splits text into its sections. Text before the first heading is kept as a
+// preamble, and a section with subsections keeps only the text before its first subsection.
+func documentParts(text string, sections []models.SectionSpan) []part {
+	if len(sections) == 0 {
+		if strings.TrimSpace(text) == "" {
+			return nil
+		}
+		return []part{{text: strings.TrimSpace(text)}}
+	}
+
+	var parts []part
+	add := func(label string, start, end int) {
+		start, end = max(start, 0), min(end, len(text))
+		if start >= end {
+			return
+		}
+		if body := strings.TrimSpace(text[start:end]); body != "" {
+			parts = append(parts, part{label: label, text: body})
+		}
+	}
+
+	add("Preamble", 0, sections[0].Start)
+	for i, section := range sections {
+		end := section.End
+		if i+1 < len(sections) && sections[i+1].Start < end {
+			end = sections[i+1].Start
+		}
+		// The heading line is already in the label
+		start := end
+		if section.Start >= 0 && section.Start < end && end <= len(text) {
+			if newline := strings.IndexByte(text[section.Start:end], '\n'); newline >= 0 {
+				start = section.Start + newline + 1
+			}
+		}
+		add(section.Label(), start, end)
+	}
+	return parts
+}
+
+// sectionPrompt asks for the summaries and obligations of a batch of sections, and for the
+// executive summary too when the batch is the whole document
+func sectionPrompt(title string, parts []part, whole bool) string {
+	var prompt strings.Builder
+	prompt.WriteString("You are summarizing a government document for policy analysts. Use only the text provided, ")
+	prompt.WriteString("and do not speculate beyond it.\n\n")
+	prompt.WriteString("Respond with a single JSON object of this form:\n")
+	if whole {
+		prompt.WriteString(`{"executive_summary": "...", `)
+	} else {
+		prompt.WriteString(`{`)
+	}
+	prompt.WriteString(`"sections": [{"section": "...", "summary": "..."}], `)
+	prompt.WriteString(`"obligations": [{"text": "...", "party": "...", "deadline": "...", "due_date": "YYYY-MM-DD", "section": "..."}]}` + "\n\n")
+	if whole {
+		prompt.WriteString("- executive_summary: three to five sentences on the document's purpose, main provisions and who it affects.\n")
+	}
+	prompt.WriteString("- sections: one entry per section below, in order, with the section label as given and a summary of one to three sentences.\n")
+	prompt.WriteString("- obligations: each requirement the document places on an agency, official, contractor or the public. ")
+	prompt.WriteString("Give the party who must act and the deadline as written, if any. Set due_date only when the deadline is a calendar date. ")
+	prompt.WriteString("Use an empty list if there are none.\n")
+	prompt.WriteString("Text shown as a bracketed label such as [SSN] has been redacted; never guess what it was.\n\n")
+	writeParts(&prompt, title, parts)
+	return prompt.String()
+}
+
+// executivePrompt asks for the executive summary of a document from its section summaries
+func executivePrompt(title string, digest []part) string {
+	var prompt strings.Builder
+	prompt.WriteString("You are summarizing a government document for policy analysts. Below are summaries of each of its sections.\n\n")
+	prompt.WriteString(`Respond with a single JSON object of the form {"executive_summary": "..."}, where executive_summary is `)
+	prompt.WriteString("three to five sentences on the document's purpose, main provisions and who it affects.\n\n")
+	writeParts(&prompt, title, digest)
+	return prompt.String()
+}
+
+func writeParts(prompt *strings.Builder, title string, parts []part) {
+	if title != "" {
+		fmt.Fprintf(prompt, "Document title: %s\n\n", title)
+	}
+	for _, p := range parts {
+		label := p.label
+		if label == "" {
+			label = "Document"
+		}
+		fmt.Fprintf(prompt, "%s%s>>>\n%s\n%s\n\n", sectionOpen, label, p.text, sectionClose)
+	}
+}
+
+// truncate cuts text to at most n bytes without splitting a UTF-8 character
+func truncate(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	return text[:n]
+}
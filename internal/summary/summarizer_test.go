@@ -0,0 +1,179 @@
+package summary
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"ai-government-consultant/internal/models"
+)
+
+// countingProvider records the prompts sent to the provider it wraps
+type countingProvider struct {
+	Provider
+	prompts []string
+}
+
+func (p *countingProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	p.prompts = append(p.prompts, prompt)
+	return p.Provider.Generate(ctx, prompt)
+}
+
+// sectioned builds a document from headed sections and returns its text with the section spans
+func sectioned(preamble string, sections ...[2]string) (string, []models.SectionSpan) {
+	var text strings.Builder
+	text.WriteString(preamble)
+	var spans []models.SectionSpan
+	for i, section := range sections {
+		start := text.Len()
+		text.WriteString(section[0] + "\n" + section[1] + "\n")
+		spans = append(spans, models.SectionSpan{
+			Number: string(rune('1' + i)),
+			Title:  section[0],
+			Level:  1,
+			Start:  start,
+			End:    text.Len(),
+		})
+	}
+	return text.String(), spans
+}
+
+func TestSummarizeWithMockProvider(t *testing.T) {
+	text, sections := sectioned("Department of Example policy memorandum.\n",
+		[2]string{"Purpose", "This memorandum sets procurement thresholds. It applies to all bureaus."},
+		[2]string{"Requirements", "Each bureau shall report its awards by March 1, 2027. The contractor must submit invoices within 30 days of delivery. Reports are public."},
+	)
+
+	provider := &countingProvider{Provider: NewMockProvider()}
+	summarizer := NewSummarizer(provider, nil)
+	got, err := summarizer.Summarize(context.Background(), "Procurement Thresholds", text, sections)
+	if err != nil {
+		t.Fatalf("Summarize: %v", err)
+	}
+
+	if len(provider.prompts) != 1 {
+		t.Fatalf("sent %d prompts, want 1 for a document that fits in one", len(provider.prompts))
+	}
+	if got.Provider != "mock" {
+		t.Errorf("Provider = %q, want mock", got.Provider)
+	}
+	if !strings.HasPrefix(got.Executive, "Department of Example policy memorandum.") {
+		t.Errorf("Executive = %q, want it to open with the lead sentence", got.Executive)
+	}
+	if got.GeneratedAt.IsZero() {
+		t.Error("GeneratedAt is not set")
+	}
+
+	wantSections := []models.SectionSummary{
+		{Section: "Preamble", Summary: "Department of Example policy memorandum."},
+		{Section: "Section 1 – Purpose", Summary: "This memorandum sets procurement thresholds."},
+		{Section: "Section 2 – Requirements", Summary: "Each bureau shall report its awards by March 1, 2027."},
+	}
+	if len(got.Sections) != len(wantSections) {
+		t.Fatalf("got %d section summaries, want %d: %+v", len(got.Sections), len(wantSections), got.Sections)
+	}
+	for i, want := range wantSections {
+		if got.Sections[i] != want {
+			t.Errorf("Sections[%d] = %+v, want %+v", i, got.Sections[i], want)
+		}
+	}
+
+	tests := []struct {
+		party    string
+		deadline string
+		dueDate  string
+	}{
+		{party: "Each bureau", deadline: "by March 1, 2027", dueDate: "2027-03-01"},
+		{party: "The contractor", deadline: "within 30 days of delivery"},
+	}
+	if len(got.Obligations) != len(tests) {
+		t.Fatalf("got %d obligations, want %d: %+v", len(got.Obligations), len(tests), got.Obligations)
+	}
+	for i, tt := range tests {
+		obligation := got.Obligations[i]
+		if obligation.Party != tt.party || obligation.Deadline != tt.deadline {
+			t.Errorf("Obligations[%d] = %q by %q, want %q by %q", i, obligation.Party, obligation.Deadline, tt.party, tt.deadline)
+		}
+		if obligation.Section != "Section 2 – Requirements" {
+			t.Errorf("Obligations[%d].Section = %q", i, obligation.Section)
+		}
+		switch {
+		case tt.dueDate == "" && obligation.DueDate != nil:
+			t.Errorf("Obligations[%d].DueDate = %v, want none", i, obligation.DueDate)
+		case tt.dueDate != "" && (obligation.DueDate == nil || obligation.DueDate.Format("2006-01-02") != tt.dueDate):
+			t.Errorf("Obligations[%d].DueDate = %v, want %s", i, obligation.DueDate, tt.dueDate)
+		}
+	}
+}
+
+func TestSummarizeInBatches(t *testing.T) {
+	text, sections := sectioned("",
+		[2]string{"Scope", "The policy covers grants. It replaces the 2019 guidance."},
+		[2]string{"Roles", "Program offices shall review applications. Reviews are recorded."},
+		[2]string{"Reporting", "Grantees must file quarterly reports. Late reports are flagged."},
+	)
+
+	provider := &countingProvider{Provider: NewMockProvider()}
+	summarizer := NewSummarizer(provider, &Config{MaxPromptChars: 70})
+	got, err := summarizer.Summarize(context.Background(), "Grants Policy", text, sections)
+	if err != nil {
+		t.Fatalf("Summarize: %v", err)
+	}
+
+	// One prompt per section, then one for the executive summary
+	if len(provider.prompts) != 4 {
+		t.Fatalf("sent %d prompts, want 4", len(provider.prompts))
+	}
+	if !strings.Contains(provider.prompts[3], `{"executive_summary": "..."}`) {
+		t.Errorf("last prompt does not ask for the executive summary:\n%s", provider.prompts[3])
+	}
+	if len(got.Sections) != 3 || len(got.Obligations) != 2 {
+		t.Fatalf("got %d sections and %d obligations, want 3 and 2", len(got.Sections), len(got.Obligations))
+	}
+	want := "The policy covers grants. Program offices shall review applications. Grantees must file quarterly reports."
+	if got.Executive != want {
+		t.Errorf("Executive = %q, want %q", got.Executive, want)
+	}
+}
+
+func TestSummarizeWithoutContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		sections []models.SectionSpan
+	}{
+		{name: "empty", text: ""},
+		{name: "whitespace", text: " \n\t "},
+		{name: "empty sections", text: "Heading\n", sections: []models.SectionSpan{{Title: "Heading", Start: 0, End: 8}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSummarizer(NewMockProvider(), nil).Summarize(context.Background(), "", tt.text, tt.sections)
+			if !errors.Is(err, ErrNoContent) {
+				t.Fatalf("Summarize error = %v, want ErrNoContent", err)
+			}
+		})
+	}
+}
+
+func TestMockProviderIsDeterministic(t *testing.T) {
+	prompt := sectionPrompt("Title", []part{{label: "Scope", text: "Agencies shall comply before 2027-01-15. It is effective now."}}, true)
+	first, err := NewMockProvider().Generate(context.Background(), prompt)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	second, _ := NewMockProvider().Generate(context.Background(), prompt)
+	if first != second {
+		t.Fatalf("answers differ:\n%s\n%s", first, second)
+	}
+	if !strings.Contains(first, `"due_date":"2027-01-15"`) {
+		t.Errorf("answer %s lacks the ISO due date", first)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewMockProvider().Generate(ctx, prompt); !errors.Is(err, context.Canceled) {
+		t.Errorf("Generate with a cancelled context = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,554 @@
+package vectorindex
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// Config holds the parameters of the HNSW graph
+type Config struct {
+	M              int // neighbors kept per node and layer; layer 0 keeps twice as many (default: 16)
+	EfConstruction int // candidates considered when linking a new node (default: 200)
+	EfSearch       int // candidates considered per search; raised to the result count when lower (default: 64)
+	ExactLimit     int // filtered searches over at most this many vectors compare them all (default: 2000)
+}
+
+// DefaultConfig returns the default graph parameters
+func DefaultConfig() Config {
+	return Config{
+		M:              16,
+		EfConstruction: 200,
+		EfSearch:       64,
+		ExactLimit:     2000,
+	}
+}
+
+// withDefaults fills in the unset parameters of c
+func (c Config) withDefaults() Config {
+	defaults := DefaultConfig()
+	if c.M <= 1 {
+		c.M = defaults.M
+	}
+	if c.EfConstruction <= 0 {
+		c.EfConstruction = defaults.EfConstruction
+	}
+	if c.EfSearch <= 0 {
+		c.EfSearch = defaults.EfSearch
+	}
+	if c.ExactLimit <= 0 {
+		c.ExactLimit = defaults.ExactLimit
+	}
+	return c
+}
+
+// Hit is a vector found by a search
+type Hit struct {
+	ID    string
+	Owner string
+	Score float64 // cosine similarity with the query
+}
+
+// Index is an approximate nearest neighbor index over unit vectors, using a hierarchical
+// navigable small world (HNSW) graph. Every vector has an ID and an owner: the record it belongs
+// to, such as the document of a passage, or its own ID. Searches can be restricted to a set of
+// owners. Index is safe for concurrent use.
+//
+// Removed vectors stay in the graph as tombstones so that searches can still route through them;
+// Compact rebuilds the graph without them.
+type Index struct {
+	mu     sync.RWMutex
+	config Config
+	g      *graph
+}
+
+// New creates an empty index
+func New(config Config) *Index {
+	config = config.withDefaults()
+	return &Index{config: config, g: newGraph(config)}
+}
+
+// Len returns the number of vectors in the index, not counting removed ones
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.g.ids)
+}
+
+// Tombstones returns the number of removed vectors still in the graph
+func (ix *Index) Tombstones() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.g.nodes) - len(ix.g.ids)
+}
+
+// Dimension returns the dimension of the vectors in the index, or 0 while it is empty
+func (ix *Index) Dimension() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return ix.g.dimension
+}
+
+// IDs returns the IDs of the vectors in the index
+func (ix *Index) IDs() []string {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	ids := make([]string, 0, len(ix.g.ids))
+	for id := range ix.g.ids {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Add inserts the vector of id, replacing any vector it had. Vectors of another dimension than
+// the ones already in the index, and zero vectors, are ignored; Add reports whether the vector
+// was added.
+func (ix *Index) Add(id, owner string, vector []float64) bool {
+	v := normalize(vector)
+	if v == nil {
+		return false
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if ix.g.dimension != 0 && ix.g.dimension != len(v) {
+		return false
+	}
+	ix.g.seq++
+	ix.g.insert(id, owner, v, ix.g.seq)
+	return true
+}
+
+// Remove removes the vector of id
+func (ix *Index) Remove(id string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.g.remove(id)
+}
+
+// RemoveOwner removes every vector of owner
+func (ix *Index) RemoveOwner(owner string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for _, n := range append([]uint32(nil), ix.g.owners[owner]...) {
+		ix.g.remove(ix.g.nodes[n].id)
+	}
+}
+
+// Search returns up to k vectors most similar to query, most similar first. When owners is not
+// nil, only vectors of those owners are returned: if they have few vectors all of them are
+// compared with the query, otherwise the graph is searched for matching vectors only.
+func (ix *Index) Search(query []float64, k int, owners map[string]bool) []Hit {
+	q := normalize(query)
+	if q == nil || k <= 0 {
+		return nil
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	g := ix.g
+	if len(g.ids) == 0 || len(q) != g.dimension {
+		return nil
+	}
+
+	var found []candidate
+	if owners == nil {
+		found = g.search(q, k, max(ix.config.EfSearch, k), nil)
+	} else {
+		// Pre-filter: gather the vectors of the owners, and compare them all when there are few
+		var nodes []uint32
+		for owner := range owners {
+			nodes = append(nodes, g.owners[owner]...)
+			if len(nodes) > ix.config.ExactLimit {
+				break
+			}
+		}
+		if len(nodes) <= ix.config.ExactLimit {
+			found = g.exact(q, k, nodes)
+		} else {
+			accept := func(n uint32) bool { return owners[g.nodes[n].owner] }
+			found = g.search(q, k, max(ix.config.EfSearch, 2*k), accept)
+		}
+	}
+
+	hits := make([]Hit, len(found))
+	for i, c := range found {
+		n := g.nodes[c.node]
+		hits[i] = Hit{ID: n.id, Owner: n.owner, Score: float64(c.score)}
+	}
+	return hits
+}
+
+// Compact rebuilds the graph without the removed vectors. The index stays usable while the new
+// graph is built; vectors added or removed meanwhile are carried over before it is swapped in.
+func (ix *Index) Compact() {
+	ix.mu.RLock()
+	type entry struct {
+		id, owner string
+		vector    []float32
+		seq       uint64
+	}
+	entries := make([]entry, 0, len(ix.g.ids))
+	for _, n := range ix.g.ids {
+		node := ix.g.nodes[n]
+		entries = append(entries, entry{node.id, node.owner, node.vector, node.seq})
+	}
+	seq := ix.g.seq
+	ix.mu.RUnlock()
+
+	fresh := newGraph(ix.config)
+	for _, e := range entries {
+		fresh.insert(e.id, e.owner, e.vector, e.seq)
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if ix.g.seq != seq {
+		for id, n := range ix.g.ids {
+			node := ix.g.nodes[n]
+			if f, ok := fresh.ids[id]; !ok || fresh.nodes[f].seq != node.seq {
+				fresh.insert(node.id, node.owner, node.vector, node.seq)
+			}
+		}
+		for id := range fresh.ids {
+			if _, ok := ix.g.ids[id]; !ok {
+				fresh.remove(id)
+			}
+		}
+	}
+	fresh.seq = ix.g.seq
+	ix.g = fresh
+}
+
+// node is a vector in the graph with its neighbors on each of its layers
+type node struct {
+	id        string
+	owner     string
+	vector    []float32
+	neighbors [][]uint32 // neighbors[l] are the neighbors on layer l
+	seq       uint64     // insertion sequence number, to tell apart vectors re-added under the same ID
+	deleted   bool
+}
+
+// graph is the HNSW graph. It is not safe for concurrent use; Index guards it.
+type graph struct {
+	config    Config
+	levelMult float64
+	rng       *rand.Rand
+
+	nodes     []*node
+	ids       map[string]uint32   // live nodes by ID
+	owners    map[string][]uint32 // live nodes by owner
+	entry     int64               // entry point, -1 while the graph is empty
+	maxLevel  int
+	dimension int
+	seq       uint64
+}
+
+func newGraph(config Config) *graph {
+	return &graph{
+		config:    config,
+		levelMult: 1 / math.Log(float64(config.M)),
+		rng:       rand.New(rand.NewSource(1)),
+		ids:       make(map[string]uint32),
+		owners:    make(map[string][]uint32),
+		entry:     -1,
+	}
+}
+
+// maxNeighbors returns how many neighbors a node keeps on layer
+func (g *graph) maxNeighbors(layer int) int {
+	if layer == 0 {
+		return 2 * g.config.M
+	}
+	return g.config.M
+}
+
+// insert adds a unit vector to the graph, first removing any vector of the same ID
+func (g *graph) insert(id, owner string, vector []float32, seq uint64) {
+	g.remove(id)
+
+	level := int(math.Floor(-math.Log(1-g.rng.Float64()) * g.levelMult))
+	n := uint32(len(g.nodes))
+	g.nodes = append(g.nodes, &node{
+		id:        id,
+		owner:     owner,
+		vector:    vector,
+		neighbors: make([][]uint32, level+1),
+		seq:       seq,
+	})
+	g.ids[id] = n
+	g.owners[owner] = append(g.owners[owner], n)
+
+	if g.entry < 0 {
+		g.entry = int64(n)
+		g.maxLevel = level
+		g.dimension = len(vector)
+		return
+	}
+
+	// Descend greedily to the node's top layer, then link it on every layer below
+	entry := uint32(g.entry)
+	entryScore := dot(vector, g.nodes[entry].vector)
+	for l := g.maxLevel; l > level; l-- {
+		entry, entryScore = g.greedy(vector, entry, entryScore, l)
+	}
+	entries := []candidate{{entry, entryScore}}
+	for l := min(level, g.maxLevel); l >= 0; l-- {
+		found := g.searchLayer(vector, entries, g.config.EfConstruction, l, nil)
+		neighbors := g.selectNeighbors(found, g.config.M)
+		g.nodes[n].neighbors[l] = nodeIDs(neighbors)
+		for _, c := range neighbors {
+			g.link(c.node, n, l)
+		}
+		entries = found
+	}
+
+	if level > g.maxLevel {
+		g.entry = int64(n)
+		g.maxLevel = level
+	}
+}
+
+// link adds to as a neighbor of from on layer, pruning from's neighbors when it has too many
+func (g *graph) link(from, to uint32, layer int) {
+	node := g.nodes[from]
+	node.neighbors[layer] = append(node.neighbors[layer], to)
+	if len(node.neighbors[layer]) <= g.maxNeighbors(layer) {
+		return
+	}
+
+	candidates := make([]candidate, len(node.neighbors[layer]))
+	for i, nb := range node.neighbors[layer] {
+		candidates[i] = candidate{nb, dot(node.vector, g.nodes[nb].vector)}
+	}
+	sortCandidates(candidates)
+	node.neighbors[layer] = nodeIDs(g.selectNeighbors(candidates, g.maxNeighbors(layer)))
+}
+
+// selectNeighbors picks up to m of candidates, sorted most similar first, with the HNSW
+// heuristic: a candidate is skipped when it is closer to an already picked neighbor than to the
+// node, which keeps links spread out. Skipped candidates fill any places left.
+func (g *graph) selectNeighbors(candidates []candidate, m int) []candidate {
+	if len(candidates) <= m {
+		return candidates
+	}
+	picked := make([]candidate, 0, m)
+	var skipped []candidate
+	for _, c := range candidates {
+		if len(picked) == m {
+			break
+		}
+		keep := true
+		for _, p := range picked {
+			if dot(g.nodes[c.node].vector, g.nodes[p.node].vector) > c.score {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			picked = append(picked, c)
+		} else {
+			skipped = append(skipped, c)
+		}
+	}
+	for _, c := range skipped {
+		if len(picked) == m {
+			break
+		}
+		picked = append(picked, c)
+	}
+	return picked
+}
+
+// remove marks the vector of id as deleted. The node keeps its links so searches still pass
+// through it.
+func (g *graph) remove(id string) {
+	n, ok := g.ids[id]
+	if !ok {
+		return
+	}
+	node := g.nodes[n]
+	node.deleted = true
+	delete(g.ids, id)
+
+	owned := g.owners[node.owner]
+	for i, o := range owned {
+		if o == n {
+			owned = append(owned[:i], owned[i+1:]...)
+			break
+		}
+	}
+	if len(owned) == 0 {
+		delete(g.owners, node.owner)
+	} else {
+		g.owners[node.owner] = owned
+	}
+}
+
+// greedy moves from entry to its most similar neighbor on layer until none is more similar
+func (g *graph) greedy(q []float32, entry uint32, score float32, layer int) (uint32, float32) {
+	for changed := true; changed; {
+		changed = false
+		for _, nb := range g.nodes[entry].neighbors[layer] {
+			if s := dot(q, g.nodes[nb].vector); s > score {
+				entry, score, changed = nb, s, true
+			}
+		}
+	}
+	return entry, score
+}
+
+// search returns the k live nodes most similar to q that accept allows
+func (g *graph) search(q []float32, k, ef int, accept func(uint32) bool) []candidate {
+	entry := uint32(g.entry)
+	score := dot(q, g.nodes[entry].vector)
+	for l := g.maxLevel; l > 0; l-- {
+		entry, score = g.greedy(q, entry, score, l)
+	}
+
+	live := func(n uint32) bool {
+		return !g.nodes[n].deleted && (accept == nil || accept(n))
+	}
+	found := g.searchLayer(q, []candidate{{entry, score}}, ef, 0, live)
+	if len(found) > k {
+		found = found[:k]
+	}
+	return found
+}
+
+// exact compares q with every live node of nodes and returns the k most similar
+func (g *graph) exact(q []float32, k int, nodes []uint32) []candidate {
+	found := make([]candidate, 0, len(nodes))
+	for _, n := range nodes {
+		if !g.nodes[n].deleted {
+			found = append(found, candidate{n, dot(q, g.nodes[n].vector)})
+		}
+	}
+	sortCandidates(found)
+	if len(found) > k {
+		found = found[:k]
+	}
+	return found
+}
+
+// searchLayer explores layer from entries and returns up to ef nodes most similar to q, most
+// similar first. Only nodes that accept allows are returned, but all nodes are explored. The
+// search ends once ef results are found and no unexplored node is more similar than them.
+func (g *graph) searchLayer(q []float32, entries []candidate, ef, layer int, accept func(uint32) bool) []candidate {
+	visited := make(map[uint32]bool, ef*4)
+	pending := &maxHeap{}
+	results := &minHeap{}
+	for _, e := range entries {
+		visited[e.node] = true
+		heap.Push(pending, e)
+		if accept == nil || accept(e.node) {
+			heap.Push(results, e)
+		}
+	}
+	for results.Len() > ef {
+		heap.Pop(results)
+	}
+
+	for pending.Len() > 0 {
+		c := heap.Pop(pending).(candidate)
+		if results.Len() >= ef && c.score < (*results)[0].score {
+			break
+		}
+		for _, nb := range g.nodes[c.node].neighbors[layer] {
+			if visited[nb] {
+				continue
+			}
+			visited[nb] = true
+			s := dot(q, g.nodes[nb].vector)
+			if results.Len() < ef || s > (*results)[0].score {
+				heap.Push(pending, candidate{nb, s})
+				if accept == nil || accept(nb) {
+					heap.Push(results, candidate{nb, s})
+					if results.Len() > ef {
+						heap.Pop(results)
+					}
+				}
+			}
+		}
+	}
+
+	found := make([]candidate, results.Len())
+	copy(found, *results)
+	sortCandidates(found)
+	return found
+}
+
+// candidate is a node with its similarity to the vector being searched for
+type candidate struct {
+	node  uint32
+	score float32
+}
+
+func sortCandidates(candidates []candidate) {
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+}
+
+func nodeIDs(candidates []candidate) []uint32 {
+	ids := make([]uint32, len(candidates))
+	for i, c := range candidates {
+		ids[i] = c.node
+	}
+	return ids
+}
+
+// maxHeap pops the most similar candidate first
+type maxHeap []candidate
+
+func (h maxHeap) Len() int            { return len(h) }
+func (h maxHeap) Less(i, j int) bool  { return h[i].score > h[j].score }
+func (h maxHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *maxHeap) Push(x interface{}) { *h = append(*h, x.(candidate)) }
+func (h *maxHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// minHeap pops the least similar candidate first
+type minHeap []candidate
+
+func (h minHeap) Len() int            { return len(h) }
+func (h minHeap) Less(i, j int) bool  { return h[i].score < h[j].score }
+func (h minHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(x interface{}) { *h = append(*h, x.(candidate)) }
+func (h *minHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// normalize converts vector to a unit vector of float32, or returns nil for a zero vector.
+// Similarity between unit vectors is their dot product.
+func normalize(vector []float64) []float32 {
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+	if norm == 0 || math.IsNaN(norm) || math.IsInf(norm, 0) {
+		return nil
+	}
+	norm = math.Sqrt(norm)
+	unit := make([]float32, len(vector))
+	for i, v := range vector {
+		unit[i] = float32(v / norm)
+	}
+	return unit
+}
+
+func dot(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
@@ -0,0 +1,248 @@
+package vectorindex
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// randomVectors returns n vectors of dimension dim, with components drawn from rng
+func randomVectors(rng *rand.Rand, n, dim int) [][]float64 {
+	vectors := make([][]float64, n)
+	for i := range vectors {
+		vectors[i] = make([]float64, dim)
+		for j := range vectors[i] {
+			vectors[i][j] = rng.NormFloat64()
+		}
+	}
+	return vectors
+}
+
+func cosine(a, b []float64) float64 {
+	var ab, aa, bb float64
+	for i := range a {
+		ab += a[i] * b[i]
+		aa += a[i] * a[i]
+		bb += b[i] * b[i]
+	}
+	return ab / math.Sqrt(aa*bb)
+}
+
+// bruteForce returns the IDs of the k vectors most similar to query that keep allows
+func bruteForce(vectors map[string][]float64, query []float64, k int, keep func(id string) bool) []string {
+	var ids []string
+	for id := range vectors {
+		if keep == nil || keep(id) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return cosine(query, vectors[ids[i]]) > cosine(query, vectors[ids[j]])
+	})
+	if len(ids) > k {
+		ids = ids[:k]
+	}
+	return ids
+}
+
+// recall returns the share of want found among hits
+func recall(hits []Hit, want []string) float64 {
+	if len(want) == 0 {
+		return 1
+	}
+	found := make(map[string]bool, len(hits))
+	for _, hit := range hits {
+		found[hit.ID] = true
+	}
+	matched := 0
+	for _, id := range want {
+		if found[id] {
+			matched++
+		}
+	}
+	return float64(matched) / float64(len(want))
+}
+
+// vectorID names the i-th test vector; vectors are owned in groups of ten
+func vectorID(i int) (id, owner string) {
+	return fmt.Sprintf("v%d", i), fmt.Sprintf("doc%d", i/10)
+}
+
+func TestSearchRecall(t *testing.T) {
+	tests := []struct {
+		name      string
+		n, dim    int
+		config    Config
+		k         int
+		owners    int // when set, searches are restricted to this many owners
+		minRecall float64
+	}{
+		{name: "default config", n: 3000, dim: 32, config: DefaultConfig(), k: 10, minRecall: 0.95},
+		{name: "sparse graph", n: 3000, dim: 32, config: Config{M: 8, EfConstruction: 100, EfSearch: 40}, k: 10, minRecall: 0.80},
+		{name: "more results than ef", n: 2000, dim: 16, config: Config{EfSearch: 8}, k: 50, minRecall: 0.90},
+		{name: "few owners compared exactly", n: 3000, dim: 32, config: DefaultConfig(), k: 10, owners: 5, minRecall: 1},
+		{name: "many owners searched in the graph", n: 3000, dim: 32, config: Config{ExactLimit: 100}, k: 10, owners: 100, minRecall: 0.90},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(42))
+			index := New(tt.config)
+			vectors := make(map[string][]float64, tt.n)
+			for i, vector := range randomVectors(rng, tt.n, tt.dim) {
+				id, owner := vectorID(i)
+				vectors[id] = vector
+				if !index.Add(id, owner, vector) {
+					t.Fatalf("Add(%s) rejected the vector", id)
+				}
+			}
+			if index.Len() != tt.n {
+				t.Fatalf("Len = %d, want %d", index.Len(), tt.n)
+			}
+
+			var owners map[string]bool
+			var keep func(id string) bool
+			if tt.owners > 0 {
+				owners = make(map[string]bool, tt.owners)
+				for _, o := range rng.Perm(tt.n / 10)[:tt.owners] {
+					owners[fmt.Sprintf("doc%d", o)] = true
+				}
+				keep = func(id string) bool {
+					var i int
+					fmt.Sscanf(id, "v%d", &i)
+					_, owner := vectorID(i)
+					return owners[owner]
+				}
+			}
+
+			const queries = 50
+			total := 0.0
+			for _, query := range randomVectors(rng, queries, tt.dim) {
+				hits := index.Search(query, tt.k, owners)
+				if len(hits) != tt.k {
+					t.Fatalf("Search returned %d hits, want %d", len(hits), tt.k)
+				}
+				for i, hit := range hits {
+					if owners != nil && !owners[hit.Owner] {
+						t.Fatalf("hit %s of owner %s is outside the filter", hit.ID, hit.Owner)
+					}
+					if i > 0 && hit.Score > hits[i-1].Score {
+						t.Fatalf("hits are not ordered by score: %v", hits)
+					}
+					if want := cosine(query, vectors[hit.ID]); math.Abs(hit.Score-want) > 1e-4 {
+						t.Fatalf("hit %s scored %f, want cosine %f", hit.ID, hit.Score, want)
+					}
+				}
+				total += recall(hits, bruteForce(vectors, query, tt.k, keep))
+			}
+			if got := total / queries; got < tt.minRecall {
+				t.Errorf("recall@%d = %.3f, want at least %.2f", tt.k, got, tt.minRecall)
+			}
+		})
+	}
+}
+
+func TestAddRejectsUnusableVectors(t *testing.T) {
+	index := New(DefaultConfig())
+	if index.Add("zero", "doc", []float64{0, 0, 0}) {
+		t.Error("Add accepted a zero vector")
+	}
+	if !index.Add("a", "doc", []float64{1, 0, 0}) {
+		t.Fatal("Add rejected a unit vector")
+	}
+	if index.Add("b", "doc", []float64{1, 0}) {
+		t.Error("Add accepted a vector of another dimension")
+	}
+	if index.Len() != 1 || index.Dimension() != 3 {
+		t.Errorf("Len = %d and Dimension = %d, want 1 and 3", index.Len(), index.Dimension())
+	}
+	if hits := index.Search([]float64{1, 0}, 5, nil); hits != nil {
+		t.Errorf("Search with a query of another dimension = %v", hits)
+	}
+}
+
+func TestRemoveAndReAdd(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	index := New(DefaultConfig())
+	vectors := randomVectors(rng, 500, 16)
+	for i, vector := range vectors {
+		id, owner := vectorID(i)
+		index.Add(id, owner, vector)
+	}
+
+	// A removed vector is no longer found, even when queried with itself
+	index.Remove("v3")
+	if index.Len() != 499 || index.Tombstones() != 1 {
+		t.Fatalf("after Remove: Len = %d, Tombstones = %d, want 499 and 1", index.Len(), index.Tombstones())
+	}
+	for _, hit := range index.Search(vectors[3], 10, nil) {
+		if hit.ID == "v3" {
+			t.Fatal("removed vector v3 was returned")
+		}
+	}
+	for _, hit := range index.Search(vectors[3], 10, map[string]bool{"doc0": true}) {
+		if hit.ID == "v3" {
+			t.Fatal("removed vector v3 was returned by a filtered search")
+		}
+	}
+	index.Remove("v3")
+	if index.Tombstones() != 1 {
+		t.Fatalf("removing v3 twice left %d tombstones", index.Tombstones())
+	}
+
+	// Re-adding the ID with another vector finds it under the new vector only
+	replacement := randomVectors(rng, 1, 16)[0]
+	index.Add("v3", "doc0", replacement)
+	if index.Len() != 500 {
+		t.Fatalf("after re-adding: Len = %d, want 500", index.Len())
+	}
+	hits := index.Search(replacement, 1, nil)
+	if len(hits) != 1 || hits[0].ID != "v3" || hits[0].Score < 0.9999 {
+		t.Fatalf("Search for the re-added vector = %v, want v3 first", hits)
+	}
+	for _, hit := range index.Search(vectors[3], 10, nil) {
+		if hit.ID == "v3" && hit.Score > 0.9999 {
+			t.Fatal("v3 was found under its removed vector")
+		}
+	}
+
+	// Adding an existing ID replaces its vector
+	index.Add("v4", "doc0", replacement)
+	if index.Len() != 500 || index.Tombstones() != 2 {
+		t.Fatalf("after replacing v4: Len = %d, Tombstones = %d, want 500 and 2", index.Len(), index.Tombstones())
+	}
+
+	// Removing an owner removes its vectors, including re-added ones
+	index.RemoveOwner("doc0")
+	if index.Len() != 490 {
+		t.Fatalf("after RemoveOwner: Len = %d, want 490", index.Len())
+	}
+	if hits := index.Search(replacement, 5, map[string]bool{"doc0": true}); len(hits) != 0 {
+		t.Fatalf("Search of a removed owner = %v", hits)
+	}
+
+	// Compact drops the tombstones and keeps every live vector searchable
+	index.Compact()
+	if index.Len() != 490 || index.Tombstones() != 0 {
+		t.Fatalf("after Compact: Len = %d, Tombstones = %d, want 490 and 0", index.Len(), index.Tombstones())
+	}
+	for i := 10; i < len(vectors); i += 37 {
+		id, _ := vectorID(i)
+		if hits := index.Search(vectors[i], 1, nil); len(hits) != 1 || hits[0].ID != id {
+			t.Errorf("Search for %s after Compact = %v", id, hits)
+		}
+	}
+
+	// An index emptied by removals can be refilled
+	for _, id := range index.IDs() {
+		index.Remove(id)
+	}
+	if hits := index.Search(vectors[20], 5, nil); len(hits) != 0 {
+		t.Fatalf("Search of an emptied index = %v", hits)
+	}
+	index.Add("v20", "doc2", vectors[20])
+	if hits := index.Search(vectors[20], 5, nil); len(hits) != 1 || hits[0].ID != "v20" {
+		t.Fatalf("Search after refilling = %v, want v20", hits)
+	}
+}
@@ -0,0 +1,167 @@
+package vectorindex
+
+import (
+	"bufio"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// snapshotVersion is increased whenever the snapshot layout changes; older snapshots are ignored
+const snapshotVersion = 1
+
+// ErrSnapshotIncompatible is returned when a snapshot was written by another version of the index
+var ErrSnapshotIncompatible = errors.New("vector index snapshot is incompatible")
+
+// SnapshotInfo describes what a snapshot was built from, so that a stale snapshot can be
+// recognized when it is loaded
+type SnapshotInfo struct {
+	Model    string    // embedding model of the vectors
+	SyncedAt time.Time // the index held every vector stored before this time
+}
+
+// snapshot is the gob encoding of an index
+type snapshot struct {
+	Version   int
+	Info      SnapshotInfo
+	Config    Config
+	Entry     int64
+	MaxLevel  int
+	Dimension int
+	Seq       uint64
+	Nodes     []snapshotNode
+}
+
+type snapshotNode struct {
+	ID        string
+	Owner     string
+	Vector    []float32
+	Neighbors [][]uint32
+	Seq       uint64
+	Deleted   bool
+}
+
+// Save writes a snapshot of the index to path. The snapshot is written to a temporary file
+// first and renamed into place, so a crash never leaves a partial snapshot behind.
+func (ix *Index) Save(path string, info SnapshotInfo) error {
+	ix.mu.RLock()
+	g := ix.g
+	snap := snapshot{
+		Version:   snapshotVersion,
+		Info:      info,
+		Config:    ix.config,
+		Entry:     g.entry,
+		MaxLevel:  g.maxLevel,
+		Dimension: g.dimension,
+		Seq:       g.seq,
+		Nodes:     make([]snapshotNode, len(g.nodes)),
+	}
+	for i, n := range g.nodes {
+		neighbors := make([][]uint32, len(n.neighbors))
+		for l, nb := range n.neighbors {
+			neighbors[l] = append([]uint32(nil), nb...)
+		}
+		snap.Nodes[i] = snapshotNode{
+			ID:        n.id,
+			Owner:     n.owner,
+			Vector:    n.vector,
+			Neighbors: neighbors,
+			Seq:       n.seq,
+			Deleted:   n.deleted,
+		}
+	}
+	ix.mu.RUnlock()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	if err := gob.NewEncoder(w).Encode(&snap); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+	return nil
+}
+
+// Load reads the snapshot at path into an index using config's search parameters. The graph
+// keeps the M and EfConstruction it was built with.
+func Load(path string, config Config) (*Index, SnapshotInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, SnapshotInfo{}, err
+	}
+	defer file.Close()
+
+	var snap snapshot
+	if err := gob.NewDecoder(bufio.NewReader(file)).Decode(&snap); err != nil {
+		return nil, SnapshotInfo{}, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return nil, SnapshotInfo{}, ErrSnapshotIncompatible
+	}
+
+	config.M = snap.Config.M
+	config.EfConstruction = snap.Config.EfConstruction
+	config = config.withDefaults()
+
+	g := newGraph(config)
+	g.entry = snap.Entry
+	g.maxLevel = snap.MaxLevel
+	g.dimension = snap.Dimension
+	g.seq = snap.Seq
+	g.nodes = make([]*node, len(snap.Nodes))
+	for i, n := range snap.Nodes {
+		for _, layer := range n.Neighbors {
+			for _, nb := range layer {
+				if int(nb) >= len(snap.Nodes) {
+					return nil, SnapshotInfo{}, ErrSnapshotIncompatible
+				}
+			}
+		}
+		if len(n.Vector) != snap.Dimension || len(n.Neighbors) == 0 {
+			return nil, SnapshotInfo{}, ErrSnapshotIncompatible
+		}
+
+		g.nodes[i] = &node{
+			id:        n.ID,
+			owner:     n.Owner,
+			vector:    n.Vector,
+			neighbors: n.Neighbors,
+			seq:       n.Seq,
+			deleted:   n.Deleted,
+		}
+		if !n.Deleted {
+			g.ids[n.ID] = uint32(i)
+			g.owners[n.Owner] = append(g.owners[n.Owner], uint32(i))
+		}
+	}
+	if g.entry >= int64(len(g.nodes)) || (g.entry < 0 && len(g.nodes) > 0) ||
+		(g.entry >= 0 && len(g.nodes[g.entry].neighbors) != g.maxLevel+1) {
+		return nil, SnapshotInfo{}, ErrSnapshotIncompatible
+	}
+
+	return &Index{config: config, g: g}, snap.Info, nil
+}
@@ -0,0 +1,136 @@
+package vectorindex
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func readSnapshot(t *testing.T, path string) snapshot {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("opening snapshot: %v", err)
+	}
+	defer file.Close()
+	var snap snapshot
+	if err := gob.NewDecoder(file).Decode(&snap); err != nil {
+		t.Fatalf("decoding snapshot: %v", err)
+	}
+	return snap
+}
+
+func writeSnapshot(t *testing.T, path string, snap snapshot) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("creating snapshot: %v", err)
+	}
+	defer file.Close()
+	if err := gob.NewEncoder(file).Encode(&snap); err != nil {
+		t.Fatalf("encoding snapshot: %v", err)
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	index := New(Config{M: 8, EfConstruction: 100})
+	vectors := randomVectors(rng, 800, 24)
+	for i, vector := range vectors {
+		id, owner := vectorID(i)
+		index.Add(id, owner, vector)
+	}
+	index.Remove("v5")
+	index.RemoveOwner("doc7")
+
+	path := filepath.Join(t.TempDir(), "snapshots", "index.gob")
+	info := SnapshotInfo{Model: "local/ngram-hash-384", SyncedAt: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)}
+	if err := index.Save(path, info); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, loadedInfo, err := Load(path, Config{EfSearch: 64})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if loadedInfo.Model != info.Model || !loadedInfo.SyncedAt.Equal(info.SyncedAt) {
+		t.Errorf("SnapshotInfo = %+v, want %+v", loadedInfo, info)
+	}
+	if loaded.Len() != index.Len() || loaded.Tombstones() != index.Tombstones() || loaded.Dimension() != index.Dimension() {
+		t.Fatalf("loaded Len/Tombstones/Dimension = %d/%d/%d, want %d/%d/%d",
+			loaded.Len(), loaded.Tombstones(), loaded.Dimension(), index.Len(), index.Tombstones(), index.Dimension())
+	}
+	if loaded.config.M != 8 || loaded.config.EfConstruction != 100 {
+		t.Errorf("loaded graph parameters M = %d, EfConstruction = %d, want 8 and 100", loaded.config.M, loaded.config.EfConstruction)
+	}
+
+	// The restored graph answers exactly as the saved one
+	for _, query := range randomVectors(rng, 20, 24) {
+		for _, owners := range []map[string]bool{nil, {"doc1": true, "doc7": true}} {
+			want := index.Search(query, 10, owners)
+			got := loaded.Search(query, 10, owners)
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Fatalf("loaded Search = %v, want %v", got, want)
+			}
+		}
+	}
+
+	// The restored index keeps working: removals, additions and compaction
+	loaded.Remove("v100")
+	loaded.Add("v5", "doc0", vectors[5])
+	if hits := loaded.Search(vectors[5], 1, nil); len(hits) != 1 || hits[0].ID != "v5" {
+		t.Errorf("Search for v5 re-added after Load = %v", hits)
+	}
+	loaded.Compact()
+	if loaded.Tombstones() != 0 || loaded.Len() != index.Len() {
+		t.Errorf("after Compact: Len = %d, Tombstones = %d, want %d and 0", loaded.Len(), loaded.Tombstones(), index.Len())
+	}
+}
+
+func TestLoadRejectsBadSnapshots(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, _, err := Load(filepath.Join(dir, "missing.gob"), DefaultConfig()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Load of a missing file = %v, want os.ErrNotExist", err)
+	}
+
+	garbage := filepath.Join(dir, "garbage.gob")
+	os.WriteFile(garbage, []byte("not a snapshot"), 0o644)
+	if _, _, err := Load(garbage, DefaultConfig()); err == nil {
+		t.Error("Load accepted a file that is not a snapshot")
+	}
+
+	// Snapshots of another version, or whose graph does not fit together, are incompatible
+	index := New(DefaultConfig())
+	index.Add("a", "doc", []float64{1, 0})
+	index.Add("b", "doc", []float64{0, 1})
+	path := filepath.Join(dir, "index.gob")
+	if err := index.Save(path, SnapshotInfo{}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	tests := []struct {
+		name   string
+		modify func(snap *snapshot)
+	}{
+		{name: "older version", modify: func(snap *snapshot) { snap.Version = snapshotVersion - 1 }},
+		{name: "neighbor out of range", modify: func(snap *snapshot) { snap.Nodes[0].Neighbors[0] = []uint32{9} }},
+		{name: "vector of another dimension", modify: func(snap *snapshot) { snap.Nodes[1].Vector = []float32{1} }},
+		{name: "entry point out of range", modify: func(snap *snapshot) { snap.Entry = 5 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snap := readSnapshot(t, path)
+			tt.modify(&snap)
+			modified := filepath.Join(dir, "modified.gob")
+			writeSnapshot(t, modified, snap)
+			if _, _, err := Load(modified, DefaultConfig()); !errors.Is(err, ErrSnapshotIncompatible) {
+				t.Fatalf("Load = %v, want ErrSnapshotIncompatible", err)
+			}
+		})
+	}
+}
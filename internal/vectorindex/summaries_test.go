@@ -0,0 +1,62 @@
+package vectorindex_test
+
+import (
+	"context"
+	"testing"
+
+	"ai-government-consultant/internal/embedding"
+	"ai-government-consultant/internal/summary"
+	"ai-government-consultant/internal/vectorindex"
+)
+
+// TestSearchMockSummaries indexes the executive summaries the mock provider writes and finds each
+// document by its own wording
+func TestSearchMockSummaries(t *testing.T) {
+	documents := map[string]string{
+		"travel":      "This policy governs official travel. Travelers shall file expense vouchers within 5 days of return.",
+		"procurement": "This directive sets procurement thresholds. Contracting officers must publish solicitations by March 1, 2027.",
+		"records":     "This guidance covers records retention. Each office is required to archive email records annually.",
+		"telework":    "This memorandum describes telework eligibility. Supervisors shall approve telework agreements in writing.",
+	}
+
+	ctx := context.Background()
+	summarizer := summary.NewSummarizer(summary.NewMockProvider(), nil)
+	embedder := embedding.NewLocalProvider(256)
+	index := vectorindex.New(vectorindex.DefaultConfig())
+	for id, text := range documents {
+		generated, err := summarizer.Summarize(ctx, id, text, nil)
+		if err != nil {
+			t.Fatalf("Summarize(%s): %v", id, err)
+		}
+		if len(generated.Obligations) != 1 {
+			t.Fatalf("summary of %s has %d obligations, want 1", id, len(generated.Obligations))
+		}
+		vector, err := embedder.Embed(ctx, generated.Executive)
+		if err != nil {
+			t.Fatalf("Embed(%s): %v", id, err)
+		}
+		index.Add(id, id, vector)
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "when are travel expense vouchers due", want: "travel"},
+		{query: "procurement solicitations thresholds", want: "procurement"},
+		{query: "archive email records retention", want: "records"},
+		{query: "telework agreements approval", want: "telework"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			vector, err := embedder.Embed(ctx, tt.query)
+			if err != nil {
+				t.Fatalf("Embed: %v", err)
+			}
+			hits := index.Search(vector, 2, nil)
+			if len(hits) == 0 || hits[0].ID != tt.want {
+				t.Fatalf("Search(%q) = %v, want %s first", tt.query, hits, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,365 @@
+// Package workspace manages workspaces: named collections of documents, knowledge items and
+// consultations that carry their own members and roles. Items record the workspaces they
+// belong to in workspace_ids, which is what search and retrieval filter on when scoped to
+// one or more workspaces.
+package workspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ai-government-consultant/internal/document"
+	"ai-government-consultant/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ItemKind names a kind of item a workspace can hold
+type ItemKind string
+
+const (
+	ItemDocuments     ItemKind = "documents"
+	ItemKnowledge     ItemKind = "knowledge"
+	ItemConsultations ItemKind = "consultations"
+)
+
+// maxItemsPerRequest bounds how many items one request adds or removes
+const maxItemsPerRequest = 1000
+
+// itemCollections maps each item kind to the collection storing it
+var itemCollections = map[ItemKind]string{
+	ItemDocuments:     "documents",
+	ItemKnowledge:     "knowledge_items",
+	ItemConsultations: "consultations",
+}
+
+var (
+	// ErrNotFound is returned when a workspace does not exist
+	ErrNotFound = errors.New("workspace not found")
+
+	// ErrForbidden is returned when a user's role in a workspace does not allow an action
+	ErrForbidden = errors.New("insufficient workspace role")
+
+	// ErrConflict is returned when a workspace changed between being read and written
+	ErrConflict = errors.New("workspace was modified concurrently")
+
+	// ErrInvalidItems is returned when a request to add or remove items cannot be carried out
+	ErrInvalidItems = errors.New("invalid workspace items")
+)
+
+// Service handles workspaces and their membership
+type Service struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+// NewService creates a new workspace service
+func NewService(db *mongo.Database) *Service {
+	return &Service{
+		db:         db,
+		collection: db.Collection("workspaces"),
+	}
+}
+
+// Create creates a workspace owned by its creator
+func (s *Service) Create(ctx context.Context, name, description string, createdBy primitive.ObjectID) (*models.Workspace, error) {
+	now := time.Now()
+	workspace := &models.Workspace{
+		ID:          primitive.NewObjectID(),
+		Name:        strings.TrimSpace(name),
+		Description: strings.TrimSpace(description),
+		Members: []models.WorkspaceMember{{
+			UserID:  createdBy,
+			Role:    models.WorkspaceRoleOwner,
+			AddedBy: createdBy,
+			AddedAt: now,
+		}},
+		MemberIDs: []primitive.ObjectID{createdBy},
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := workspace.Validate(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.collection.InsertOne(ctx, workspace); err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+	return workspace, nil
+}
+
+// Get returns a workspace with a count of its items
+func (s *Service) Get(ctx context.Context, id primitive.ObjectID) (*models.Workspace, error) {
+	workspace, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := &models.WorkspaceCounts{}
+	for kind, count := range map[ItemKind]*int64{
+		ItemDocuments:     &counts.Documents,
+		ItemKnowledge:     &counts.KnowledgeItems,
+		ItemConsultations: &counts.Consultations,
+	} {
+		n, err := s.db.Collection(itemCollections[kind]).CountDocuments(ctx, bson.M{"workspace_ids": id})
+		if err != nil {
+			return nil, fmt.Errorf("failed to count workspace %s: %w", kind, err)
+		}
+		*count = n
+	}
+	workspace.Counts = counts
+	return workspace, nil
+}
+
+// List returns workspaces by name. When memberID is set only that user's workspaces are listed.
+func (s *Service) List(ctx context.Context, memberID *primitive.ObjectID, limit, skip int) ([]*models.Workspace, int64, error) {
+	filter := bson.M{}
+	if memberID != nil {
+		filter["member_ids"] = *memberID
+	}
+
+	total, err := s.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count workspaces: %w", err)
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "name", Value: 1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(skip))
+
+	cursor, err := s.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find workspaces: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var workspaces []*models.Workspace
+	if err := cursor.All(ctx, &workspaces); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode workspaces: %w", err)
+	}
+	return workspaces, total, nil
+}
+
+// Update renames a workspace or changes its description; nil values are left as they are
+func (s *Service) Update(ctx context.Context, id primitive.ObjectID, name, description *string) (*models.Workspace, error) {
+	set := bson.M{"updated_at": time.Now()}
+	if name != nil {
+		if strings.TrimSpace(*name) == "" {
+			return nil, models.ErrWorkspaceNameRequired
+		}
+		set["name"] = strings.TrimSpace(*name)
+	}
+	if description != nil {
+		set["description"] = strings.TrimSpace(*description)
+	}
+
+	var workspace models.Workspace
+	err := s.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&workspace)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to update workspace: %w", err)
+	}
+	return &workspace, nil
+}
+
+// Delete deletes a workspace and removes it from every item in it. The items themselves are kept.
+func (s *Service) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := s.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete workspace: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	for kind, name := range itemCollections {
+		_, err := s.db.Collection(name).UpdateMany(ctx,
+			bson.M{"workspace_ids": id},
+			bson.M{"$pull": bson.M{"workspace_ids": id}},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to remove workspace from %s: %w", kind, err)
+		}
+	}
+	return nil
+}
+
+// SetMember adds a user to a workspace or changes their role
+func (s *Service) SetMember(ctx context.Context, id, userID primitive.ObjectID, role models.WorkspaceRole, addedBy primitive.ObjectID) (*models.Workspace, error) {
+	if !role.IsValid() {
+		return nil, models.ErrWorkspaceRoleInvalid
+	}
+	users, err := s.db.Collection("users").CountDocuments(ctx, bson.M{"_id": userID, "is_active": true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	if users == 0 {
+		return nil, models.ErrUserNotFound
+	}
+	return s.updateMembers(ctx, id, func(members []models.WorkspaceMember) []models.WorkspaceMember {
+		for i := range members {
+			if members[i].UserID == userID {
+				members[i].Role = role
+				return members
+			}
+		}
+		return append(members, models.WorkspaceMember{
+			UserID:  userID,
+			Role:    role,
+			AddedBy: addedBy,
+			AddedAt: time.Now(),
+		})
+	})
+}
+
+// RemoveMember removes a user from a workspace. The last owner cannot be removed.
+func (s *Service) RemoveMember(ctx context.Context, id, userID primitive.ObjectID) (*models.Workspace, error) {
+	return s.updateMembers(ctx, id, func(members []models.WorkspaceMember) []models.WorkspaceMember {
+		kept := members[:0]
+		for _, member := range members {
+			if member.UserID != userID {
+				kept = append(kept, member)
+			}
+		}
+		return kept
+	})
+}
+
+// updateMembers applies change to a workspace's members and saves them, provided the
+// workspace still has an owner and was not changed in the meantime
+func (s *Service) updateMembers(ctx context.Context, id primitive.ObjectID, change func([]models.WorkspaceMember) []models.WorkspaceMember) (*models.Workspace, error) {
+	workspace, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	previousUpdate := workspace.UpdatedAt
+
+	workspace.Members = change(workspace.Members)
+	if err := workspace.Validate(); err != nil {
+		return nil, err
+	}
+	workspace.MemberIDs = make([]primitive.ObjectID, len(workspace.Members))
+	for i, member := range workspace.Members {
+		workspace.MemberIDs[i] = member.UserID
+	}
+	workspace.UpdatedAt = time.Now()
+
+	result, err := s.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "updated_at": previousUpdate},
+		bson.M{"$set": bson.M{
+			"members":    workspace.Members,
+			"member_ids": workspace.MemberIDs,
+			"updated_at": workspace.UpdatedAt,
+		}},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update workspace members: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrConflict
+	}
+	return workspace, nil
+}
+
+// AddItems adds items to a workspace and returns how many were found. Only items the user can
+// read are added: documents within their clearance and compartments or shared with them, and
+// consultations of their own unless they administer consultations.
+func (s *Service) AddItems(ctx context.Context, id primitive.ObjectID, kind ItemKind, itemIDs []primitive.ObjectID, user *models.User) (int64, error) {
+	filter, err := s.itemFilter(ctx, id, kind, itemIDs)
+	if err != nil {
+		return 0, err
+	}
+	switch kind {
+	case ItemDocuments:
+		filter = bson.M{"$and": []bson.M{filter, document.NewAccessScope(user).Match("")}}
+	case ItemConsultations:
+		if !user.HasPermission("consultations", "admin") {
+			filter["user_id"] = user.ID
+		}
+	}
+
+	result, err := s.db.Collection(itemCollections[kind]).UpdateMany(ctx, filter,
+		bson.M{"$addToSet": bson.M{"workspace_ids": id}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to add %s to workspace: %w", kind, err)
+	}
+	return result.MatchedCount, nil
+}
+
+// RemoveItems removes items from a workspace and returns how many were in it
+func (s *Service) RemoveItems(ctx context.Context, id primitive.ObjectID, kind ItemKind, itemIDs []primitive.ObjectID) (int64, error) {
+	filter, err := s.itemFilter(ctx, id, kind, itemIDs)
+	if err != nil {
+		return 0, err
+	}
+	filter["workspace_ids"] = id
+
+	result, err := s.db.Collection(itemCollections[kind]).UpdateMany(ctx, filter,
+		bson.M{"$pull": bson.M{"workspace_ids": id}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove %s from workspace: %w", kind, err)
+	}
+	return result.ModifiedCount, nil
+}
+
+// itemFilter checks an add or remove request and returns the filter selecting its items
+func (s *Service) itemFilter(ctx context.Context, id primitive.ObjectID, kind ItemKind, itemIDs []primitive.ObjectID) (bson.M, error) {
+	if _, ok := itemCollections[kind]; !ok {
+		return nil, fmt.Errorf("%w: unknown item kind %q", ErrInvalidItems, kind)
+	}
+	if len(itemIDs) == 0 {
+		return nil, fmt.Errorf("%w: no item IDs given", ErrInvalidItems)
+	}
+	if len(itemIDs) > maxItemsPerRequest {
+		return nil, fmt.Errorf("%w: at most %d items can be changed at once", ErrInvalidItems, maxItemsPerRequest)
+	}
+	if _, err := s.find(ctx, id); err != nil {
+		return nil, err
+	}
+	return bson.M{"_id": bson.M{"$in": itemIDs}}, nil
+}
+
+// Authorize checks that the user holds at least the required role in every listed workspace;
+// workspace administrators hold every role. It returns ErrNotFound for a workspace that does
+// not exist or that the user is not a member of.
+func (s *Service) Authorize(ctx context.Context, ids []primitive.ObjectID, user *models.User, required models.WorkspaceRole) error {
+	for _, id := range ids {
+		workspace, err := s.find(ctx, id)
+		if err != nil {
+			return err
+		}
+		if user.HasPermission("workspaces", "admin") {
+			continue
+		}
+		role := workspace.RoleOf(user.ID)
+		if role == "" {
+			return ErrNotFound
+		}
+		if !role.Includes(required) {
+			return ErrForbidden
+		}
+	}
+	return nil
+}
+
+func (s *Service) find(ctx context.Context, id primitive.ObjectID) (*models.Workspace, error) {
+	var workspace models.Workspace
+	if err := s.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&workspace); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to find workspace: %w", err)
+	}
+	return &workspace, nil
+}